@@ -0,0 +1,109 @@
+// Command collections moves users' Qdrant collections to a new naming scheme without downtime.
+// Each user's docs, conversations and summaries collections are recorded in the vector_collections
+// registry; a rename points a Qdrant alias with the new name at the collection and records it,
+// so no vectors are copied and searches keep working throughout.
+//
+// Servers cache collection names for a minute, so the previous names stay usable as aliases:
+// once every server has picked up the new names, run again with -drop-previous to remove them.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/database"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/storage"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	userID := flag.String("user", "", "rename only this user's collections (default: every user)")
+	scopes := flag.String("scopes", "", "comma-separated collection scopes to rename: docs, conversations, summaries (default: all)")
+	scheme := flag.String("scheme", "", `naming scheme to rename to, with {user} and {scope} placeholders (e.g. "rag_{scope}_{user}")`)
+	dropPrevious := flag.Bool("drop-previous", false, "remove the aliases kept under previous names by an earlier run")
+	flag.Parse()
+
+	if *scheme == "" && !*dropPrevious {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load environment variables
+	if err := godotenv.Load("../.env"); err != nil {
+		// This is expected when running in Docker
+	}
+
+	cfg := config.Load()
+
+	env := os.Getenv("ENVIRONMENT")
+	if env == "" {
+		env = "development"
+	}
+	logger.InitLogger(env)
+
+	db, err := database.NewPostgresDB(cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+
+	if err := database.EnsureSchema(db, cfg.AutoMigrate); err != nil {
+		logger.Fatal("Failed to prepare database schema", "error", err)
+	}
+
+	qdrantClient, err := storage.NewQdrantClient(cfg.QdrantURL)
+	if err != nil {
+		logger.Fatal("Failed to initialize Qdrant client", "url", cfg.QdrantURL, "error", err)
+	}
+	defer qdrantClient.Close()
+
+	collectionService := service.NewCollectionService(
+		repository.NewUserRepository(db),
+		repository.NewVectorRepository(qdrantClient, repository.NewCollectionRepository(db)),
+		repository.NewLockRepository(db),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var scopeList []string
+	for _, scope := range strings.Split(*scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopeList = append(scopeList, scope)
+		}
+	}
+
+	results, err := collectionService.Migrate(ctx, service.CollectionMigrationOptions{
+		UserID:       *userID,
+		Scopes:       scopeList,
+		Scheme:       *scheme,
+		DropPrevious: *dropPrevious,
+	})
+	renamed := 0
+	for _, result := range results {
+		if result.Renamed {
+			renamed++
+		}
+		logger.Info("Migrated vector collection",
+			"user_id", result.UserID,
+			"scope", result.Scope,
+			"name", result.Name,
+			"previous", result.Previous,
+			"renamed", result.Renamed,
+			"dropped_previous", result.Dropped,
+		)
+	}
+	if err != nil {
+		logger.Fatal("Collection migration failed", "error", err)
+	}
+
+	logger.Info("Collection migration completed", "collections", len(results), "renamed", renamed)
+}
@@ -0,0 +1,71 @@
+// Command migrate applies pending database migrations, for deployments that run with
+// AUTO_MIGRATE=false so replicas don't migrate on startup. Run it before rolling out a new
+// build: migrations only add to the schema, so replicas of the previous build keep working
+// against the migrated database while the new ones start.
+//
+// Replicas migrating at the same time take turns through a Postgres advisory lock. With -check
+// it only runs the preflight checks and reports pending migrations, exiting with status 1 when
+// there are any.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/database"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	check := flag.Bool("check", false, "run the preflight checks and report pending migrations without applying them")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load("../.env"); err != nil {
+		// This is expected when running in Docker
+	}
+
+	cfg := config.Load()
+
+	env := os.Getenv("ENVIRONMENT")
+	if env == "" {
+		env = "development"
+	}
+	logger.InitLogger(env)
+
+	db, err := database.NewPostgresDB(cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+
+	status, err := database.Preflight(context.Background(), db)
+	if err != nil {
+		logger.Fatal("Preflight check failed", "error", err)
+	}
+	logger.Info("Database schema",
+		"server_version", status.ServerVersion,
+		"applied", status.Applied,
+		"latest", status.Latest,
+		"pending", status.Pending(),
+	)
+	if status.Newer() {
+		logger.Warn("Database schema is newer than this build; nothing to apply")
+	}
+
+	if *check {
+		if status.Pending() > 0 {
+			db.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := database.RunMigrations(db); err != nil {
+		logger.Fatal("Failed to run migrations", "error", err)
+	}
+	logger.Info("Database migrations completed")
+}
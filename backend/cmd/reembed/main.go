@@ -0,0 +1,122 @@
+// Command reembed moves stored documents to a new embedding model without downtime. It embeds
+// every document's chunks with the configured model (EMBEDDING_PROVIDER, EMBEDDING_MODEL,
+// EMBEDDING_DIMENSIONS) into a fresh Qdrant collection per user, while the current collection
+// keeps serving searches, then atomically points the user's docs alias at the new collection.
+//
+// Queries must be embedded with the same model as the collection they search, so switch the
+// server to the new model at the same time as the aliases: run with -activate=false ahead of
+// time to do the slow embedding, then deploy the new model and run again. With EMBEDDING_CACHE
+// on, the second run reuses the cached embeddings and only picks up documents added since.
+//
+// When a provider updates a model's weights under the same name, stored vectors drift away from
+// the queries embedded against them (the server's embedding drift monitor notifies affected
+// users). Run with -refresh to re-embed those users with their current model anyway.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/database"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/storage"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	userID := flag.String("user", "", "re-embed only this user's documents (default: every user)")
+	activate := flag.Bool("activate", true, "switch each user's docs alias to the new collection once it's filled")
+	dropOld := flag.Bool("drop-old", false, "delete the previously active collection after switching")
+	refresh := flag.Bool("refresh", false, "re-embed users already on their model too, ignoring cached embeddings (after an upstream model update)")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load("../.env"); err != nil {
+		// This is expected when running in Docker
+	}
+
+	cfg := config.Load()
+
+	env := os.Getenv("ENVIRONMENT")
+	if env == "" {
+		env = "development"
+	}
+	logger.InitLogger(env)
+
+	db, err := database.NewPostgresDB(cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+
+	if err := database.EnsureSchema(db, cfg.AutoMigrate); err != nil {
+		logger.Fatal("Failed to prepare database schema", "error", err)
+	}
+
+	qdrantClient, err := storage.NewQdrantClient(cfg.QdrantURL)
+	if err != nil {
+		logger.Fatal("Failed to initialize Qdrant client", "url", cfg.QdrantURL, "error", err)
+	}
+	defer qdrantClient.Close()
+
+	embeddings, err := service.NewEmbeddingProviders(cfg, repository.NewSettingsRepository(db), repository.NewEmbeddingCacheRepository(db))
+	if err != nil {
+		logger.Fatal("Failed to initialize embedding provider", "error", err)
+	}
+
+	sparseEncoder, err := service.NewSparseEncoder(cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize sparse encoder", "error", err)
+	}
+
+	reembedService := service.NewReembedService(
+		repository.NewDocumentRepository(db),
+		repository.NewChunkRepository(db),
+		repository.NewVectorRepository(qdrantClient, repository.NewCollectionRepository(db)),
+		repository.NewLockRepository(db),
+		repository.NewSettingsRepository(db),
+		embeddings,
+		sparseEncoder,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Users who chose their own model in settings are moved to that model instead
+	logger.Info("Re-embedding documents",
+		"model", embeddings.Default().Model(),
+		"dimensions", embeddings.Default().Dimensions(),
+		"activate", *activate,
+		"refresh", *refresh,
+	)
+
+	results, err := reembedService.Run(ctx, service.ReembedOptions{
+		UserID:   *userID,
+		Activate: *activate,
+		DropOld:  *dropOld,
+		Refresh:  *refresh,
+	})
+	for _, result := range results {
+		logger.Info("Re-embedded user documents",
+			"user_id", result.UserID,
+			"model", result.Model,
+			"collection", result.Collection,
+			"previous", result.Previous,
+			"documents", result.Documents,
+			"chunks", result.Chunks,
+			"activated", result.Activated,
+			"skipped", result.Skipped,
+		)
+	}
+	if err != nil {
+		logger.Fatal("Re-embedding failed", "error", err)
+	}
+
+	logger.Info("Re-embedding completed", "users", len(results))
+}
@@ -2,29 +2,56 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Schedule timezones must resolve in minimal containers
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 	fiberlogger "github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/chaos"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/database"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/discord"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/handler"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/mailer"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/matrix"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/notification"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/parser"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/queue"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/storage"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/watcher"
 )
 
+// Run modes
+const (
+	// modeAll serves the API and runs the background workers in one process
+	modeAll = "all"
+	// modeAPI serves only the HTTP API; jobs are left for worker instances
+	modeAPI = "api"
+	// modeWorker runs only the background workers, without the HTTP server
+	modeWorker = "worker"
+)
+
 func main() {
+	mode := flag.String("mode", modeAll, "run mode: all, api, or worker")
+	flag.Parse()
+
 	// Load environment variables
 	if err := godotenv.Load("../.env"); err != nil {
 		// This is expected when running in Docker
@@ -40,11 +67,34 @@ func main() {
 	}
 	logger.InitLogger(env)
 
+	if *mode != modeAll && *mode != modeAPI && *mode != modeWorker {
+		logger.Fatal("Unknown run mode (valid options: all, api, worker)", "mode", *mode)
+	}
+
 	logger.Info("Starting RAG Personal Assistant",
 		"environment", env,
+		"mode", *mode,
 		"port", cfg.Port,
 	)
 
+	// Fault injection wires the Qdrant, OpenAI and S3 clients created below, so it is enabled first
+	if cfg.ChaosEnabled {
+		if env == "production" {
+			logger.Fatal("Fault injection can't be enabled in production; unset CHAOS_ENABLED")
+		}
+		chaos.Enable()
+		faults, err := chaos.ParseFaults(cfg.ChaosFaults)
+		if err != nil {
+			logger.Fatal("Invalid CHAOS_FAULTS", "error", err)
+		}
+		for _, fault := range faults {
+			if err := chaos.Set(fault); err != nil {
+				logger.Fatal("Failed to set fault", "target", fault.Target, "error", err)
+			}
+		}
+		logger.Warn("Fault injection enabled", "faults", len(faults))
+	}
+
 	// Initialize database
 	db, err := database.NewPostgresDB(cfg.DatabaseURL)
 	if err != nil {
@@ -53,11 +103,11 @@ func main() {
 	defer db.Close()
 	logger.Info("Database connected successfully")
 
-	// Run migrations
-	if err := database.RunMigrations(db); err != nil {
-		logger.Fatal("Failed to run migrations", "error", err)
+	// Run migrations, or with AUTO_MIGRATE=false check that cmd/migrate has
+	if err := database.EnsureSchema(db, cfg.AutoMigrate); err != nil {
+		logger.Fatal("Failed to prepare database schema", "error", err)
 	}
-	logger.Info("Database migrations completed")
+	logger.Info("Database schema is up to date", "auto_migrate", cfg.AutoMigrate)
 
 	// Initialize storage driver (local, localstack, or s3)
 	storageDriver, err := storage.NewStorageDriver(cfg)
@@ -82,38 +132,278 @@ func main() {
 	}
 	logger.Info("Qdrant client initialized", "url", cfg.QdrantURL)
 
+	// Initialize job queue (postgres, nats, or rabbitmq)
+	jobQueue, err := queue.NewQueue(cfg, db)
+	if err != nil {
+		logger.Fatal("Failed to initialize job queue",
+			"driver", cfg.JobQueueDriver,
+			"error", err,
+		)
+	}
+	defer jobQueue.Close()
+	logger.Info("Job queue initialized", "driver", cfg.JobQueueDriver)
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	documentRepo := repository.NewDocumentRepository(db)
-	vectorRepo := repository.NewVectorRepository(qdrantClient)
+	vectorRepo := repository.NewVectorRepository(qdrantClient, repository.NewCollectionRepository(db))
+	chunkRepo := repository.NewChunkRepository(db)
+	scheduledQueryRepo := repository.NewScheduledQueryRepository(db)
+	savedQueryRepo := repository.NewSavedQueryRepository(db)
+	applicationRepo := repository.NewApplicationRepository(db)
+	flashcardRepo := repository.NewFlashcardRepository(db)
+	conversationRepo := repository.NewConversationRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	lockRepo := repository.NewLockRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	digestRepo := repository.NewDigestRepository(db)
+	glossaryRepo := repository.NewGlossaryRepository(db)
+	snapshotRepo := repository.NewSnapshotRepository(db)
+	crawlRepo := repository.NewCrawlRepository(db)
+	blocklistRepo := repository.NewBlocklistRepository(db)
+	promptVersionRepo := repository.NewPromptVersionRepository(db)
+	faqRepo := repository.NewFAQRepository(db)
+	privacyRepo := repository.NewPrivacyRepository(db)
+	embeddingCacheRepo := repository.NewEmbeddingCacheRepository(db)
+	embeddingBatchRepo := repository.NewEmbeddingBatchRepository(db)
+	secretFindingRepo := repository.NewSecretFindingRepository(db)
+	syncRepo := repository.NewSyncRepository(db)
+	matrixRepo := repository.NewMatrixRepository(db)
+	discordRepo := repository.NewDiscordRepository(db)
+	mailLogRepo := repository.NewMailLogRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+
+	// Initialize mailer (disabled when no MAIL_DRIVER is configured)
+	var appMailer *mailer.Mailer
+	mailDriver, err := mailer.NewDriver(context.Background(), cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize mail driver", "driver", cfg.MailDriver, "error", err)
+	}
+	if mailDriver != nil {
+		appMailer = mailer.New(mailDriver, cfg.MailFrom, mailLogRepo)
+		logger.Info("Mailer initialized", "driver", cfg.MailDriver)
+	}
 
 	// Initialize services
-	embeddingService := service.NewEmbeddingService(cfg.OpenAIKey)
-	documentService := service.NewDocumentService(documentRepo, vectorRepo, storageDriver, embeddingService)
-	ragService := service.NewRAGService(vectorRepo, embeddingService, cfg.OpenAIKey, documentRepo)
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret)
+	embeddings, err := service.NewEmbeddingProviders(cfg, settingsRepo, embeddingCacheRepo)
+	if err != nil {
+		logger.Fatal("Failed to initialize embedding provider", "error", err)
+	}
+	sparseEncoder, err := service.NewSparseEncoder(cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize sparse encoder", "error", err)
+	}
 
-	// Initialize Knowledge Base Watcher
-	kbWatcher, err := watcher.NewWatcher(cfg.KnowledgeBasePath, cfg.DefaultUserID, documentService)
+	reembedService := service.NewReembedService(documentRepo, chunkRepo, vectorRepo, lockRepo, settingsRepo, embeddings, sparseEncoder)
+	toolRegistry := service.NewToolRegistry(
+		service.NewCalculatorTool(),
+		service.NewCurrentDateTool(),
+		service.NewUnitConversionTool(),
+	)
+	if cfg.WebSearchAPIKey != "" {
+		toolRegistry.Register(service.NewWebSearchTool(cfg.WebSearchAPIKey))
+	}
+	webhookService := service.NewWebhookService(webhookRepo, jobQueue)
+	// Notifications are routed to each user's chosen channels by the bus
+	notificationBus := notification.NewBus(notificationRepo)
+	notificationBus.Register(notification.ChannelWebhook, webhookService)
+	if appMailer != nil {
+		notificationBus.Register(notification.ChannelEmail, notification.NewEmailNotifier(appMailer, cfg.AppURL,
+			func(ctx context.Context, userID string) (string, error) {
+				user, err := userRepo.GetByID(ctx, userID)
+				if err != nil {
+					return "", err
+				}
+				return user.Email, nil
+			}))
+	}
+	if cfg.TelegramBotToken != "" {
+		notificationBus.Register(notification.ChannelTelegram, notification.NewTelegramNotifier(cfg.TelegramBotToken, notificationRepo.Target))
+	}
+	if cfg.NtfyURL != "" {
+		notificationBus.Register(notification.ChannelPush, notification.NewPushNotifier(cfg.NtfyURL, cfg.NtfyToken, cfg.AppURL, notificationRepo.Target))
+	}
+	logger.Info("Notification channels initialized", "channels", notificationBus.Channels())
+	notifier := notification.NewMultiNotifier(notification.NewLogNotifier(), notificationBus)
+	secretScanner, err := service.NewSecretScanner(cfg.SecretScan, secretFindingRepo, notifier)
 	if err != nil {
-		logger.Fatal("Failed to initialize knowledge base watcher", "error", err)
+		logger.Fatal("Invalid secret scanning configuration", "error", err)
+	}
+	chunkDeduplicator, err := service.NewChunkDeduplicator(cfg.ChunkDedup, chunkRepo)
+	if err != nil {
+		logger.Fatal("Invalid chunk dedup configuration", "error", err)
+	}
+	embeddingBatchService := service.NewEmbeddingBatchService(embeddingBatchRepo, documentRepo, chunkRepo, vectorRepo, embeddings, sparseEncoder)
+	embeddingDriftMonitor := service.NewEmbeddingDriftMonitor(documentRepo, chunkRepo, vectorRepo, embeddings, notifier)
+	openAI, err := service.NewOpenAIEndpoint(cfg)
+	if err != nil {
+		logger.Fatal("Invalid OpenAI configuration", "error", err)
 	}
-	
-	// Start watcher in background
-	watcherCtx, watcherCancel := context.WithCancel(context.Background())
-	defer watcherCancel()
-	if err := kbWatcher.Start(watcherCtx); err != nil {
-		logger.Fatal("Failed to start knowledge base watcher", "error", err)
+	var ocr parser.OCR
+	if cfg.OCRProvider == "openai" {
+		ocr = service.NewOpenAIOCR(openAI, cfg.OCRModel, cfg.ImageCaptions)
+	}
+	if err := service.ValidateChunking(cfg.ChunkingStrategy); err != nil {
+		logger.Fatal("Invalid chunking strategy", "error", err)
+	}
+	documentService := service.NewDocumentService(documentRepo, vectorRepo, chunkRepo, storageDriver, embeddings, sparseEncoder, lockRepo, embeddingBatchService, secretScanner, chunkDeduplicator, ocr, int64(cfg.IngestMemoryLimitMB)<<20, cfg.ChunkingStrategy)
+	notificationService := service.NewNotificationService(notificationRepo, notificationBus)
+	auditService := service.NewAuditService(auditRepo, documentRepo, notifier)
+	pipeline, err := service.ParsePipelineConfig(cfg.RAGPipeline)
+	if err != nil {
+		logger.Fatal("Invalid RAG pipeline configuration", "error", err)
+	}
+	ragService := service.NewRAGService(vectorRepo, chunkRepo, embeddings, openAI, documentRepo, conversationRepo, settingsRepo, toolRegistry, auditService, glossaryRepo, faqRepo, blocklistRepo, promptVersionRepo, sparseEncoder, pipeline)
+	conversationService := service.NewConversationService(conversationRepo, vectorRepo, embeddings)
+	var ttsProvider service.TTSProvider
+	if cfg.TTSProvider == "openai" {
+		ttsProvider = service.NewOpenAITTSProvider(openAI, cfg.TTSModel, cfg.TTSVoice)
+	}
+	speechService := service.NewSpeechService(documentRepo, storageDriver, ttsProvider)
+	authService := service.NewAuthService(userRepo, cfg.JWTSecret, appMailer, cfg.AppURL)
+	mailLogService := service.NewMailLogService(mailLogRepo)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	widgetService := service.NewWidgetService(apiKeyService, ragService)
+	settingsService := service.NewSettingsService(settingsRepo, embeddings, jobQueue)
+	glossaryService := service.NewGlossaryService(glossaryRepo)
+	blocklistService := service.NewBlocklistService(blocklistRepo)
+	promptVersionService := service.NewPromptVersionService(promptVersionRepo)
+	faqService := service.NewFAQService(faqRepo, embeddings)
+	syncService := service.NewSyncService(syncRepo, documentRepo, conversationRepo)
+	usageService := service.NewUsageService(usageRepo)
+	privacyService := service.NewPrivacyService(privacyRepo, vectorRepo, cfg, pipeline)
+	portabilityService := service.NewPortabilityService(documentService, documentRepo, chunkRepo, conversationRepo, userRepo, settingsRepo)
+	scheduledQueryService := service.NewScheduledQueryService(scheduledQueryRepo, lockRepo, ragService, notifier)
+	savedQueryService := service.NewSavedQueryService(savedQueryRepo, ragService)
+	workspaceService := service.NewWorkspaceService(settingsService, glossaryService, savedQueryService, scheduledQueryService, blocklistService)
+	onboardingService := service.NewOnboardingService(documentService, ragService)
+	applicationService := service.NewApplicationService(applicationRepo, documentRepo, chunkRepo, ragService)
+	reportService := service.NewReportService(chunkRepo, ragService)
+	flashcardService := service.NewFlashcardService(flashcardRepo, documentRepo, chunkRepo, ragService)
+	schedulerService := service.NewSchedulerService(scheduleRepo, lockRepo)
+	digestService := service.NewDigestService(digestRepo, documentRepo, chunkRepo, ragService, notifier)
+	snapshotService := service.NewSnapshotService(snapshotRepo, documentRepo, vectorRepo)
+	crawlService := service.NewCrawlService(crawlRepo, documentRepo, documentService, jobQueue)
+	var matrixClient *matrix.Client
+	if cfg.MatrixHomeserverURL != "" {
+		matrixClient = matrix.NewClient(cfg.MatrixHomeserverURL, cfg.MatrixAccessToken, cfg.MatrixUserID)
+	}
+	matrixService := service.NewMatrixService(matrixClient, matrixRepo, lockRepo, documentService, ragService)
+	var discordClient *discord.Client
+	if cfg.DiscordBotToken != "" {
+		discordClient = discord.NewClient(cfg.DiscordBotToken, cfg.DiscordApplicationID)
+	}
+	discordService := service.NewDiscordService(discordClient, cfg.DiscordPublicKey, cfg.DiscordIngestChannelID, discordRepo, lockRepo, documentService, ragService)
+
+	// Initialize Knowledge Base Watcher (manual sync only enqueues jobs, so API-only instances use it too)
+	folderUsers, err := watcher.ParseFolderUsers(cfg.KnowledgeBaseUsers)
+	if err != nil {
+		logger.Fatal("Invalid knowledge base folder mapping", "error", err)
+	}
+	kbWatcher, err := watcher.NewWatcher(cfg.KnowledgeBasePath, cfg.DefaultUserID, folderUsers, jobQueue, lockRepo)
+	if err != nil {
+		logger.Fatal("Failed to initialize knowledge base watcher", "error", err)
 	}
 	defer kbWatcher.Close()
 
-	// Perform initial sync
-	go func() {
-		time.Sleep(2 * time.Second) // Wait for server to be ready
-		if err := kbWatcher.Sync(context.Background()); err != nil {
-			logger.Error("Initial sync failed", "error", err)
+	// Background workers: ingestion consumers, knowledge base watcher, scheduled queries and the chat bots
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	defer workerCancel()
+	workerDone := make(chan struct{})
+	if *mode == modeAPI {
+		close(workerDone)
+	} else {
+		jobRouter := queue.NewRouter()
+		jobRouter.Handle(service.JobIngestLocalFile, documentService.HandleIngestLocalFile)
+		jobRouter.Handle(service.JobWebhookDeliver, webhookService.HandleDeliver)
+		jobRouter.Handle(service.JobReembedUser, reembedService.HandleReembedUser)
+		jobRouter.Handle(service.JobCrawlSite, crawlService.HandleCrawlSite)
+		consumerDone := make(chan struct{})
+		go func() {
+			defer close(consumerDone)
+			if err := jobQueue.Consume(workerCtx, cfg.JobConcurrency, jobRouter.Dispatch); err != nil {
+				logger.Error("Job worker stopped", "error", err)
+			}
+		}()
+
+		if err := kbWatcher.Start(workerCtx); err != nil {
+			logger.Fatal("Failed to start knowledge base watcher", "error", err)
 		}
-	}()
+
+		// Perform initial sync
+		go func() {
+			time.Sleep(2 * time.Second) // Wait for server to be ready
+			if err := kbWatcher.Sync(workerCtx, cfg.EmbeddingBatchSync); err != nil {
+				logger.Error("Initial sync failed", "error", err)
+			}
+		}()
+
+		// Persisted cron schedules; stored edits to a schedule take precedence over these defaults
+		if err := schedulerService.Register(workerCtx, "scheduled_queries", "* * * * *", "UTC", scheduledQueryService.RunDue); err != nil {
+			logger.Fatal("Failed to register schedule", "error", err)
+		}
+		if err := schedulerService.Register(workerCtx, "document_summaries", "*/5 * * * *", "UTC", ragService.IndexDocumentSummaries); err != nil {
+			logger.Fatal("Failed to register schedule", "error", err)
+		}
+		if err := schedulerService.Register(workerCtx, "daily_digest", "15 0 * * *", "UTC", digestService.RunDaily); err != nil {
+			logger.Fatal("Failed to register schedule", "error", err)
+		}
+		if err := schedulerService.Register(workerCtx, "faq_extraction", "*/15 * * * *", "UTC", faqService.Refresh); err != nil {
+			logger.Fatal("Failed to register schedule", "error", err)
+		}
+		if err := schedulerService.Register(workerCtx, "embedding_batches", "*/10 * * * *", "UTC", embeddingBatchService.Poll); err != nil {
+			logger.Fatal("Failed to register schedule", "error", err)
+		}
+		if err := schedulerService.Register(workerCtx, "sync_maintenance", "45 3 * * *", "UTC", syncService.Maintain); err != nil {
+			logger.Fatal("Failed to register schedule", "error", err)
+		}
+		if err := schedulerService.Register(workerCtx, "embedding_drift", "0 4 * * 0", "UTC", embeddingDriftMonitor.Check); err != nil {
+			logger.Fatal("Failed to register schedule", "error", err)
+		}
+		if err := schedulerService.Register(workerCtx, "knowledge_base_snapshots", "30 2 * * *", "UTC", snapshotService.RunDaily); err != nil {
+			logger.Fatal("Failed to register schedule", "error", err)
+		}
+		schedulerDone := schedulerService.Start(workerCtx)
+		go func() {
+			defer close(workerDone)
+			<-consumerDone
+			<-schedulerDone
+		}()
+
+		go matrixService.Run(workerCtx)
+		go discordService.Run(workerCtx)
+	}
+
+	if *mode == modeWorker {
+		logger.Info("Worker started", "job_queue", cfg.JobQueueDriver, "concurrency", cfg.JobConcurrency)
+
+		// Workers have no API, so profiles of ingestion are served to admins on a port of their own
+		var debugApp *fiber.App
+		if cfg.DebugPort != "" {
+			debugApp = fiber.New(fiber.Config{AppName: "RAG Personal Assistant worker"})
+			debugApp.Use(middleware.AuthRequired(cfg.JWTSecret, apiKeyService), middleware.RequireScope(service.ScopeAdmin))
+			debugRoutes(debugApp, "")
+			go func() {
+				if err := debugApp.Listen(":" + cfg.DebugPort); err != nil {
+					logger.Error("Debug server failed", "error", err)
+				}
+			}()
+		}
+		waitForShutdown()
+
+		logger.Info("Shutting down worker...")
+		if debugApp != nil {
+			debugApp.Shutdown()
+		}
+		workerCancel()
+		<-workerDone
+		logger.Info("Worker exited gracefully")
+		return
+	}
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
@@ -126,6 +416,7 @@ func main() {
 
 	// Global middleware
 	app.Use(recover.New())
+	app.Use(middleware.Metrics())
 	app.Use(fiberlogger.New(fiberlogger.Config{
 		Format: "[${time}] ${status} - ${method} ${path} (${latency})\n",
 	}))
@@ -135,21 +426,85 @@ func main() {
 		AllowHeaders:     "Origin,Content-Type,Accept,Authorization",
 		AllowCredentials: true,
 	}))
+	app.Use(middleware.ClientCountry(cfg.GeoCountryHeader))
 
 	// Initialize handlers
-	authHandler := handler.NewAuthHandler(authService)
-	documentHandler := handler.NewDocumentHandler(documentService)
-	queryHandler := handler.NewQueryHandler(ragService)
-
-	// Health check
+	var loginWarmer *service.RAGService
+	if cfg.LoginWarmup {
+		loginWarmer = ragService
+	}
+	authHandler := handler.NewAuthHandler(authService, auditService, loginWarmer)
+	documentHandler := handler.NewDocumentHandler(documentService, auditService)
+	queryHandler := handler.NewQueryHandler(ragService, speechService)
+	scheduledQueryHandler := handler.NewScheduledQueryHandler(scheduledQueryService)
+	savedQueryHandler := handler.NewSavedQueryHandler(savedQueryService)
+	applicationHandler := handler.NewApplicationHandler(applicationService)
+	reportHandler := handler.NewReportHandler(reportService)
+	flashcardHandler := handler.NewFlashcardHandler(flashcardService)
+	conversationHandler := handler.NewConversationHandler(conversationService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	mailLogHandler := handler.NewMailLogHandler(mailLogService)
+	promptVersionHandler := handler.NewPromptVersionHandler(promptVersionService)
+	settingsHandler := handler.NewSettingsHandler(settingsService)
+	glossaryHandler := handler.NewGlossaryHandler(glossaryService)
+	workspaceHandler := handler.NewWorkspaceHandler(workspaceService)
+	onboardingHandler := handler.NewOnboardingHandler(onboardingService)
+	blocklistHandler := handler.NewBlocklistHandler(blocklistService)
+	faqHandler := handler.NewFAQHandler(faqService)
+	syncHandler := handler.NewSyncHandler(syncService)
+	usageHandler := handler.NewUsageHandler(usageService)
+	privacyHandler := handler.NewPrivacyHandler(privacyService)
+	portabilityHandler := handler.NewPortabilityHandler(portabilityService, auditService)
+	scheduleHandler := handler.NewScheduleHandler(schedulerService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	digestHandler := handler.NewDigestHandler(digestService)
+	snapshotHandler := handler.NewSnapshotHandler(snapshotService)
+	crawlHandler := handler.NewCrawlHandler(crawlService)
+	widgetHandler := handler.NewWidgetHandler(widgetService)
+	shortcutsHandler := handler.NewShortcutsHandler(documentService, ragService)
+	matrixHandler := handler.NewMatrixHandler(matrixService)
+	discordHandler := handler.NewDiscordHandler(discordService)
+
+	// Health check (degraded while an outbound provider's circuit breaker is open)
 	app.Get("/health", func(c *fiber.Ctx) error {
+		status := "healthy"
+		providers := httpretry.Statuses()
+		for _, provider := range providers {
+			if provider.State != httpretry.StateClosed {
+				status = "degraded"
+			}
+		}
+
 		return c.JSON(fiber.Map{
-			"status":  "healthy",
-			"service": "rag-personal-assistant",
-			"time":    time.Now().Unix(),
+			"status":    status,
+			"service":   "rag-personal-assistant",
+			"time":      time.Now().Unix(),
+			"providers": providers,
 		})
 	})
 
+	// Embeddable widget (public; authenticated by a widget:ask key in the query string and
+	// rate limited per key, since visitors spend the key owner's tokens)
+	embed := app.Group("/embed")
+	embed.Get("/ask", widgetHandler.Page)
+	embed.Post("/ask", limiter.New(limiter.Config{
+		Max:        cfg.WidgetRateLimit,
+		Expiration: time.Minute,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.Query("key")
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "too many questions, try again in a minute",
+			})
+		},
+	}), widgetHandler.Ask)
+
+	// Discord interactions (public; authenticated by Discord's request signature)
+	app.Post("/discord/interactions", discordHandler.Interactions)
+
 	// API routes
 	api := app.Group("/api")
 
@@ -158,17 +513,88 @@ func main() {
 	auth.Post("/register", authHandler.Register)
 	auth.Post("/login", authHandler.Login)
 	auth.Post("/refresh", authHandler.RefreshToken)
-
-	// Protected routes
-	protected := api.Group("", middleware.AuthRequired(cfg.JWTSecret))
+	auth.Post("/verify-email", authHandler.VerifyEmail)
+	auth.Post("/password-reset", authHandler.ResetPassword)
+	// Reset emails are rate limited per client so the endpoint can't be used to spam an inbox
+	auth.Post("/password-reset/request", limiter.New(limiter.Config{
+		Max:        5,
+		Expiration: 15 * time.Minute,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "too many reset requests, try again later",
+			})
+		},
+	}), authHandler.RequestPasswordReset)
+
+	// Protected routes (JWT or API key; each group enforces its scopes)
+	protected := api.Group("", middleware.AuthRequired(cfg.JWTSecret, apiKeyService))
+
+	// Resend the email verification link to the signed-in user
+	protected.Post("/auth/verification", authHandler.ResendVerification)
+
+	// API key routes (keys can only be issued with scopes the caller holds)
+	apiKeys := protected.Group("/api-keys")
+	apiKeys.Post("", apiKeyHandler.Create)
+	apiKeys.Get("", apiKeyHandler.List)
+	apiKeys.Delete("/:id", apiKeyHandler.Delete)
+
+	// User settings routes
+	settings := protected.Group("/settings", middleware.RequireScope(service.ScopeQueryExecute))
+	settings.Get("", settingsHandler.Get)
+	settings.Put("", settingsHandler.Update)
+
+	// Glossary routes (terms are expanded in queries before embedding)
+	glossary := protected.Group("/glossary", middleware.RequireScope(service.ScopeQueryExecute))
+	glossary.Post("", glossaryHandler.Create)
+	glossary.Get("", glossaryHandler.List)
+	glossary.Get("/suggestions", glossaryHandler.Suggest)
+	glossary.Put("/:id", glossaryHandler.Update)
+	glossary.Delete("/:id", glossaryHandler.Delete)
+
+	// Workspace routes (first-run setup from a persona template)
+	workspaces := protected.Group("/workspaces", middleware.RequireScope(service.ScopeQueryExecute))
+	workspaces.Get("/templates", workspaceHandler.Templates)
+	workspaces.Post("/from-template", workspaceHandler.FromTemplate)
+
+	// Onboarding routes (index a sample and answer a first question about it in one call)
+	onboarding := protected.Group("/onboarding", middleware.RequireScope(service.ScopeDocumentsWrite), middleware.RequireScope(service.ScopeQueryExecute))
+	onboarding.Get("/demos", onboardingHandler.Demos)
+	onboarding.Post("", onboardingHandler.Start)
+
+	// Retrieval blocklist routes (chunks matching a rule are never put in prompts)
+	blocklist := protected.Group("/blocklist", middleware.RequireScope(service.ScopeQueryExecute))
+	blocklist.Post("", blocklistHandler.Create)
+	blocklist.Get("", blocklistHandler.List)
+	blocklist.Delete("/:id", blocklistHandler.Delete)
+
+	// FAQ routes (repeat questions clustered from query history, with their best answers)
+	faq := protected.Group("/faq", middleware.RequireScope(service.ScopeQueryExecute))
+	faq.Get("", faqHandler.List)
+	faq.Delete("/:id", faqHandler.Delete)
+
+	// Usage routes
+	protected.Get("/usage", middleware.RequireScope(service.ScopeQueryExecute), usageHandler.Get)
+
+	// Account routes
+	account := protected.Group("/account", middleware.RequireScope(service.ScopeDocumentsRead))
+	account.Get("/privacy-report", privacyHandler.Report)
+	// Account export and import in the format documented by GET /account/export/schema
+	account.Get("/export", middleware.RequireScope(service.ScopeQueryExecute), portabilityHandler.Export)
+	account.Get("/export/schema", portabilityHandler.Schema)
+	account.Post("/import", middleware.RequireScope(service.ScopeDocumentsWrite), middleware.RequireScope(service.ScopeQueryExecute), portabilityHandler.Import)
+
+	// Sync routes (change feed of documents, conversations and messages for offline clients)
+	protected.Get("/sync/changes", middleware.RequireScope(service.ScopeDocumentsRead), middleware.RequireScope(service.ScopeQueryExecute), syncHandler.Changes)
 
 	// Document routes
-	documents := protected.Group("/documents")
+	documents := protected.Group("/documents", middleware.RequireScopeByMethod(service.ScopeDocumentsRead, service.ScopeDocumentsWrite))
 	documents.Post("/upload", documentHandler.Upload)
+	documents.Post("/dry-run", documentHandler.DryRun)
 	documents.Post("/sync", func(c *fiber.Ctx) error {
-		// Manual sync trigger
+		// Manual sync trigger; ?batch=true embeds new files through the OpenAI Batch API
+		batch := c.QueryBool("batch", false)
 		go func() {
-			if err := kbWatcher.Sync(context.Background()); err != nil {
+			if err := kbWatcher.Sync(context.Background(), batch); err != nil {
 				logger.Error("Manual sync failed", "error", err)
 			}
 		}()
@@ -177,13 +603,175 @@ func main() {
 		})
 	})
 	documents.Get("", documentHandler.List)
+	documents.Get("/pinned", documentHandler.ListPinned)
+	documents.Get("/favorites", documentHandler.ListFavorites)
+	documents.Get("/warranties", documentHandler.ListWarranties)
+	documents.Get("/secrets", documentHandler.ListSecrets)
 	documents.Get("/:id", documentHandler.Get)
+	documents.Get("/:id/download", documentHandler.Download)
 	documents.Delete("/:id", documentHandler.Delete)
+	documents.Post("/:id/pin", documentHandler.Pin)
+	documents.Delete("/:id/pin", documentHandler.Unpin)
+	documents.Post("/:id/favorite", documentHandler.Favorite)
+	documents.Delete("/:id/favorite", documentHandler.Unfavorite)
+	documents.Post("/:id/canary", documentHandler.MarkCanary)
+	documents.Delete("/:id/canary", documentHandler.UnmarkCanary)
+
+	// Retrieval-only search (returns raw chunks, no LLM call)
+	protected.Post("/search", middleware.RequireScope(service.ScopeDocumentsRead), queryHandler.Search)
 
 	// Query routes
-	query := protected.Group("/query")
+	query := protected.Group("/query", middleware.RequireScope(service.ScopeQueryExecute))
 	query.Post("", queryHandler.Query)
+	query.Get("/quick", queryHandler.Quick)
 	query.Get("/stream", queryHandler.StreamQuery)
+	query.Get("/history", queryHandler.History)
+	query.Get("/history/pinned", queryHandler.ListPinnedHistory)
+	query.Get("/history/favorites", queryHandler.ListFavoriteHistory)
+	query.Get("/history/:id", queryHandler.GetHistory)
+	query.Delete("/history/:id", queryHandler.DeleteHistory)
+	query.Get("/:id/audio", queryHandler.Audio)
+	query.Post("/history/:id/pin", queryHandler.PinHistory)
+	query.Delete("/history/:id/pin", queryHandler.UnpinHistory)
+	query.Post("/history/:id/favorite", queryHandler.FavoriteHistory)
+	query.Delete("/history/:id/favorite", queryHandler.UnfavoriteHistory)
+	query.Post("/history/:id/feedback", queryHandler.Feedback)
+	query.Delete("/history/:id/feedback", queryHandler.ClearFeedback)
+	query.Post("/history/:id/read", queryHandler.MarkHistoryRead)
+
+	// Conversation routes
+	conversations := protected.Group("/conversations", middleware.RequireScope(service.ScopeQueryExecute))
+	conversations.Post("", conversationHandler.Create)
+	conversations.Get("", conversationHandler.List)
+	conversations.Get("/:id", conversationHandler.Get)
+	conversations.Get("/:id/export", conversationHandler.Export)
+	conversations.Put("/:id/settings", conversationHandler.UpdateSettings)
+	conversations.Get("/:id/branches", conversationHandler.Branches)
+	conversations.Put("/:id/branch", conversationHandler.SwitchBranch)
+	conversations.Post("/:id/messages/:messageId/edit", queryHandler.EditMessage)
+	conversations.Delete("/:id", conversationHandler.Delete)
+
+	// Scheduled query routes
+	scheduledQueries := protected.Group("/scheduled-queries", middleware.RequireScope(service.ScopeQueryExecute))
+	scheduledQueries.Post("", scheduledQueryHandler.Create)
+	scheduledQueries.Get("", scheduledQueryHandler.List)
+	scheduledQueries.Get("/:id", scheduledQueryHandler.Get)
+	scheduledQueries.Put("/:id", scheduledQueryHandler.Update)
+	scheduledQueries.Delete("/:id", scheduledQueryHandler.Delete)
+
+	// Saved query routes (templates with {placeholders} filled in on execute)
+	savedQueries := protected.Group("/saved-queries", middleware.RequireScope(service.ScopeQueryExecute))
+	savedQueries.Post("", savedQueryHandler.Create)
+	savedQueries.Get("", savedQueryHandler.List)
+	savedQueries.Get("/:id", savedQueryHandler.Get)
+	savedQueries.Put("/:id", savedQueryHandler.Update)
+	savedQueries.Delete("/:id", savedQueryHandler.Delete)
+	savedQueries.Post("/:id/execute", savedQueryHandler.Execute)
+
+	// Job application tracking routes
+	applications := protected.Group("/applications", middleware.RequireScope(service.ScopeQueryExecute))
+	applications.Post("", applicationHandler.Create)
+	applications.Get("", applicationHandler.List)
+	applications.Get("/:id", applicationHandler.Get)
+	applications.Put("/:id", applicationHandler.Update)
+	applications.Delete("/:id", applicationHandler.Delete)
+	applications.Post("/:id/cover-letter", applicationHandler.CoverLetter)
+
+	// Webhook routes (deliveries are signed with the secret returned at creation)
+	webhooks := protected.Group("/webhooks", middleware.RequireScope(service.ScopeQueryExecute))
+	webhooks.Post("", webhookHandler.Create)
+	webhooks.Get("", webhookHandler.List)
+	webhooks.Delete("/:id", webhookHandler.Delete)
+	webhooks.Get("/:id/deliveries", webhookHandler.ListDeliveries)
+	webhooks.Post("/deliveries/:id/redeliver", webhookHandler.Redeliver)
+
+	// Notification routes (per-channel targets and the channels each event is delivered through)
+	notifications := protected.Group("/notifications", middleware.RequireScope(service.ScopeQueryExecute))
+	notifications.Get("", notificationHandler.Get)
+	notifications.Put("/targets/:channel", notificationHandler.SetTarget)
+	notifications.Delete("/targets/:channel", notificationHandler.DeleteTarget)
+	notifications.Put("/routes/:event", notificationHandler.SetRoute)
+	notifications.Delete("/routes/:event", notificationHandler.DeleteRoute)
+	notifications.Post("/test/:channel", notificationHandler.Test)
+
+	// Flashcard routes (study mode with SM-2 review scheduling)
+	flashcards := protected.Group("/flashcards", middleware.RequireScope(service.ScopeQueryExecute))
+	flashcards.Post("", flashcardHandler.Create)
+	flashcards.Post("/generate", flashcardHandler.Generate)
+	flashcards.Get("", flashcardHandler.List)
+	flashcards.Get("/due", flashcardHandler.Due)
+	flashcards.Get("/stats", flashcardHandler.Stats)
+	flashcards.Post("/export", flashcardHandler.Export)
+	flashcards.Post("/:id/review", flashcardHandler.Review)
+	flashcards.Delete("/:id", flashcardHandler.Delete)
+
+	// Apple Shortcuts routes (form-encoded bodies and flat, stable responses for automations)
+	shortcuts := protected.Group("/shortcuts")
+	shortcuts.Post("/capture", middleware.RequireScope(service.ScopeDocumentsWrite), shortcutsHandler.Capture)
+	shortcuts.Post("/ask", middleware.RequireScope(service.ScopeQueryExecute), shortcutsHandler.Ask)
+
+	// Matrix bot routes (the linked account can both query and add documents)
+	matrixRoutes := protected.Group("/matrix", middleware.RequireScope(service.ScopeQueryExecute), middleware.RequireScope(service.ScopeDocumentsWrite))
+	matrixRoutes.Get("", matrixHandler.Get)
+	matrixRoutes.Put("/link", matrixHandler.Link)
+	matrixRoutes.Delete("/link", matrixHandler.Unlink)
+
+	// Discord bot routes (the linked account can both query and add documents)
+	discordRoutes := protected.Group("/discord", middleware.RequireScope(service.ScopeQueryExecute), middleware.RequireScope(service.ScopeDocumentsWrite))
+	discordRoutes.Get("", discordHandler.Get)
+	discordRoutes.Post("/link", discordHandler.CreateLinkCode)
+	discordRoutes.Delete("/link", discordHandler.Unlink)
+
+	// Report routes (expense reports are built from receipts extracted by the expense profile)
+	reports := protected.Group("/reports", middleware.RequireScope(service.ScopeQueryExecute))
+	reports.Post("/expenses", reportHandler.Expenses)
+
+	// Travel routes (bookings are extracted by the travel document profile)
+	travel := protected.Group("/travel", middleware.RequireScope(service.ScopeDocumentsRead))
+	travel.Get("/upcoming", documentHandler.UpcomingTravel)
+
+	// Daily digest routes (digests are generated by the daily_digest schedule)
+	digests := protected.Group("/digests", middleware.RequireScope(service.ScopeDocumentsRead))
+	digests.Get("", digestHandler.List)
+	digests.Get("/:id", digestHandler.Get)
+
+	// Knowledge base snapshot routes (the knowledge_base_snapshots schedule takes one daily; diff
+	// compares two of them, or one with the current state)
+	snapshots := protected.Group("/snapshots", middleware.RequireScope(service.ScopeDocumentsRead))
+	snapshots.Get("", snapshotHandler.List)
+	snapshots.Get("/diff", snapshotHandler.Diff)
+	snapshots.Get("/:id", snapshotHandler.Get)
+	snapshots.Post("", middleware.RequireScope(service.ScopeDocumentsWrite), snapshotHandler.Create)
+	snapshots.Delete("/:id", middleware.RequireScope(service.ScopeDocumentsWrite), snapshotHandler.Delete)
+
+	// Website crawl routes (crawls run as queue jobs; each page becomes a document)
+	crawls := protected.Group("/crawls", middleware.RequireScope(service.ScopeDocumentsRead))
+	crawls.Get("", crawlHandler.List)
+	crawls.Get("/:id", crawlHandler.Get)
+	crawls.Post("", middleware.RequireScope(service.ScopeDocumentsWrite), crawlHandler.Create)
+	crawls.Delete("/:id", middleware.RequireScope(service.ScopeDocumentsWrite), crawlHandler.Cancel)
+
+	// Admin routes
+	admin := protected.Group("/admin", middleware.RequireScope(service.ScopeAdmin))
+	admin.Get("/audit/flagged", auditHandler.ListFlagged)
+	admin.Get("/schedules", scheduleHandler.List)
+	admin.Put("/schedules/:id", scheduleHandler.Update)
+	admin.Get("/schedules/:id/runs", scheduleHandler.ListRuns)
+	admin.Get("/mail-log", mailLogHandler.List)
+	admin.Get("/prompt-versions", promptVersionHandler.List)
+	admin.Post("/prompt-versions", promptVersionHandler.Create)
+	admin.Put("/prompt-versions/:id/traffic", promptVersionHandler.SetTraffic)
+	admin.Delete("/prompt-versions/:id", promptVersionHandler.Delete)
+	admin.Get("/analytics/prompt-versions", promptVersionHandler.Compare)
+	if cfg.ChaosEnabled {
+		// Fault injection routes (only served with CHAOS_ENABLED; faults apply to this process)
+		chaosHandler := handler.NewChaosHandler()
+		admin.Get("/chaos", chaosHandler.List)
+		admin.Put("/chaos/:target", chaosHandler.Set)
+		admin.Delete("/chaos/:target", chaosHandler.Clear)
+		admin.Delete("/chaos", chaosHandler.ClearAll)
+	}
+	debugRoutes(admin, "/api/admin")
 
 	// Start server
 	port := cfg.Port
@@ -228,9 +816,7 @@ func main() {
 	}
 
 	// Wait for interrupt signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	waitForShutdown()
 
 	logger.Info("Shutting down server...")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -240,5 +826,23 @@ func main() {
 		logger.Fatal("Server forced to shutdown", "error", err)
 	}
 
+	// Let in-flight jobs finish before closing the queue and database
+	workerCancel()
+	<-workerDone
+
 	logger.Info("Server exited gracefully")
 }
+
+// waitForShutdown blocks until SIGINT or SIGTERM is received
+func waitForShutdown() {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+}
+
+// debugRoutes serves Prometheus metrics and pprof profiles (under /debug/pprof) on a router
+// mounted at prefix, which must already require admin access
+func debugRoutes(router fiber.Router, prefix string) {
+	router.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+	router.Use(pprof.New(pprof.Config{Prefix: prefix}))
+}
@@ -0,0 +1,19 @@
+// Package apperror defines the kinds of error repositories and services return, so handlers can
+// map them to HTTP status codes with errors.Is instead of matching on their text. Errors of a
+// kind wrap it, e.g. fmt.Errorf("document %w", apperror.ErrNotFound) reads "document not found".
+package apperror
+
+import "errors"
+
+var (
+	// ErrNotFound is a record that doesn't exist or isn't the caller's
+	ErrNotFound = errors.New("not found")
+	// ErrQuotaExceeded is a request over a size or usage limit, such as an upload too large
+	// to ingest
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrUnsupportedFileType is a file whose type can't be ingested
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	// ErrProviderUnavailable is an external provider (LLM, embeddings, OCR, storage) that is down,
+	// rate limiting after retries, or behind an open circuit breaker
+	ErrProviderUnavailable = errors.New("provider unavailable")
+)
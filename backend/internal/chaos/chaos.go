@@ -0,0 +1,207 @@
+// Package chaos injects failures into calls to external dependencies (Qdrant, OpenAI and S3) on
+// demand, to exercise the retries, circuit breakers and job retries that handle them. It does
+// nothing unless enabled at startup with CHAOS_ENABLED, and faults only apply to the process they
+// are set in.
+package chaos
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+)
+
+// Targets faults can be injected into
+const (
+	TargetQdrant = "qdrant"
+	TargetOpenAI = "openai"
+	TargetS3     = "s3"
+)
+
+// Fault kinds
+const (
+	// KindTimeout hangs for the fault's delay, or until the call's deadline, then fails
+	KindTimeout = "timeout"
+	// KindRateLimit fails with a rate limit: HTTP 429 with Retry-After, or gRPC RESOURCE_EXHAUSTED
+	KindRateLimit = "rate_limit"
+	// KindError fails with an outage: HTTP 503, or gRPC UNAVAILABLE
+	KindError = "error"
+)
+
+// defaultTimeout is how long a timeout fault hangs when it sets no delay
+const defaultTimeout = 30 * time.Second
+
+// Fault is a failure injected into a share of a target's calls
+type Fault struct {
+	Target string `json:"target"`
+	Kind   string `json:"kind"`
+	// Rate is the share of calls that fail, from 0 to 1; 0 fails every call
+	Rate float64 `json:"rate"`
+	// DelayMS is how long a timeout fault hangs; 0 uses 30 seconds
+	DelayMS int `json:"delay_ms,omitempty"`
+	// Remaining clears the fault after this many more failures; 0 keeps it until cleared
+	Remaining int        `json:"remaining,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Injected counts the failures injected since the fault was set
+	Injected int `json:"injected"`
+}
+
+// Delay returns how long a timeout fault hangs
+func (f *Fault) Delay() time.Duration {
+	if f.DelayMS > 0 {
+		return time.Duration(f.DelayMS) * time.Millisecond
+	}
+	return defaultTimeout
+}
+
+// Validate checks a fault's target, kind and limits
+func (f *Fault) Validate() error {
+	switch f.Target {
+	case TargetQdrant, TargetOpenAI, TargetS3:
+	default:
+		return fmt.Errorf("target must be one of %s, %s or %s", TargetQdrant, TargetOpenAI, TargetS3)
+	}
+	switch f.Kind {
+	case KindTimeout, KindRateLimit, KindError:
+	default:
+		return fmt.Errorf("kind must be one of %s, %s or %s", KindTimeout, KindRateLimit, KindError)
+	}
+	if f.Rate < 0 || f.Rate > 1 {
+		return fmt.Errorf("rate must be between 0 and 1")
+	}
+	if f.DelayMS < 0 || f.Remaining < 0 {
+		return fmt.Errorf("delay_ms and remaining can't be negative")
+	}
+	return nil
+}
+
+var (
+	enabled bool
+	mu      sync.Mutex
+	faults  = map[string]*Fault{}
+)
+
+// Enable turns fault injection on. Clients created before it is called aren't wired for it, so
+// it is called at startup before they are.
+func Enable() {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = true
+}
+
+// Enabled reports whether fault injection is on
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// Set injects a fault into its target, replacing the target's previous fault
+func Set(f Fault) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return fmt.Errorf("fault injection is disabled")
+	}
+	f.Injected = 0
+	faults[f.Target] = &f
+	logger.Warn("Fault injection set", "target", f.Target, "kind", f.Kind, "rate", f.Rate)
+	return nil
+}
+
+// Clear removes a target's fault; an empty target clears them all
+func Clear(target string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if target == "" {
+		faults = map[string]*Fault{}
+		return
+	}
+	delete(faults, target)
+}
+
+// List returns the faults in effect, sorted by target
+func List() []Fault {
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	list := make([]Fault, 0, len(faults))
+	for target, f := range faults {
+		if f.ExpiresAt != nil && now.After(*f.ExpiresAt) {
+			delete(faults, target)
+			continue
+		}
+		list = append(list, *f)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Target < list[j].Target
+	})
+	return list
+}
+
+// pick returns the fault to inject into a call to target, if any, counting it
+func pick(target string) (Fault, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	f, ok := faults[target]
+	if !ok {
+		return Fault{}, false
+	}
+	if f.ExpiresAt != nil && time.Now().After(*f.ExpiresAt) {
+		delete(faults, target)
+		return Fault{}, false
+	}
+	if f.Rate > 0 && rand.Float64() >= f.Rate {
+		return Fault{}, false
+	}
+
+	f.Injected++
+	if f.Remaining > 0 {
+		f.Remaining--
+		if f.Remaining == 0 {
+			delete(faults, target)
+		}
+	}
+	logger.Info("Injected fault", "target", target, "kind", f.Kind)
+	return *f, true
+}
+
+// ParseFaults reads faults from a comma-separated list of target:kind[:rate], such as
+// "openai:rate_limit:0.5,qdrant:timeout"
+func ParseFaults(spec string) ([]Fault, error) {
+	var list []Fault
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid fault %q: expected target:kind[:rate]", entry)
+		}
+		f := Fault{Target: strings.TrimSpace(parts[0]), Kind: strings.TrimSpace(parts[1])}
+		if len(parts) == 3 {
+			rate, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid fault %q: rate must be a number", entry)
+			}
+			f.Rate = rate
+		}
+		if err := f.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid fault %q: %w", entry, err)
+		}
+		list = append(list, f)
+	}
+	return list, nil
+}
@@ -0,0 +1,113 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// timeoutError is the error of a timeout fault; like a network timeout, it reports Timeout
+type timeoutError struct {
+	target string
+}
+
+func (e *timeoutError) Error() string   { return fmt.Sprintf("%s: simulated timeout", e.target) }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+// hang waits for a timeout fault's delay, returning early with the context's error if it ends
+func hang(ctx context.Context, target string, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return &timeoutError{target: target}
+	}
+}
+
+// transport fails a share of requests with the target's fault before they are sent
+type transport struct {
+	target string
+	base   http.RoundTripper
+}
+
+// Transport wraps base (nil for http.DefaultTransport) so requests fail with the target's fault.
+// When fault injection is disabled, base is returned as is.
+func Transport(target string, base http.RoundTripper) http.RoundTripper {
+	if !Enabled() {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{target: target, base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f, ok := pick(t.target)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	switch f.Kind {
+	case KindTimeout:
+		return nil, hang(req.Context(), t.target, f.Delay())
+	case KindRateLimit:
+		resp := simulatedResponse(req, http.StatusTooManyRequests, "simulated rate limit")
+		resp.Header.Set("Retry-After", "1")
+		return resp, nil
+	default:
+		return simulatedResponse(req, http.StatusServiceUnavailable, "simulated outage"), nil
+	}
+}
+
+// simulatedResponse builds a failed response with a JSON error body like the providers' own
+func simulatedResponse(req *http.Request, statusCode int, message string) *http.Response {
+	body := fmt.Sprintf(`{"error":{"message":%q,"type":"chaos"}}`, message)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// UnaryClientInterceptor fails a share of gRPC calls with the target's fault before they are sent
+func UnaryClientInterceptor(target string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		f, ok := pick(target)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		switch f.Kind {
+		case KindTimeout:
+			err := hang(ctx, target, f.Delay())
+			if ctx.Err() != nil {
+				return status.FromContextError(err).Err()
+			}
+			return status.Error(codes.DeadlineExceeded, err.Error())
+		case KindRateLimit:
+			return status.Errorf(codes.ResourceExhausted, "%s: simulated rate limit", target)
+		default:
+			return status.Errorf(codes.Unavailable, "%s: simulated outage", target)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Package crawler fetches the pages of a website breadth-first from a seed URL, within limits on
+// link depth and page count, and extracts their readable text
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/netguard"
+)
+
+// Crawl limits
+const (
+	// MaxPageSize caps the bytes read of a page
+	MaxPageSize = 2 * 1024 * 1024
+	// UserAgent identifies the crawler to sites and their robots.txt
+	UserAgent = "personal-rag-agent-crawler/1.0"
+)
+
+// Options bound a crawl
+type Options struct {
+	// MaxDepth is how many links away from the seed pages are followed; 0 fetches the seed only
+	MaxDepth int
+	// MaxPages caps the pages fetched, including ones that fail
+	MaxPages int
+	// SameDomain only follows links to the seed's host (with or without "www.")
+	SameDomain bool
+	// Delay is the pause between requests
+	Delay time.Duration
+}
+
+// Page is a fetched page's readable text
+type Page struct {
+	URL   string
+	Title string
+	// Text is the page's content as Markdown-style text: headings, paragraphs and list items
+	Text  string
+	Depth int
+
+	finalURL *url.URL
+}
+
+// Visitor receives each page as it is fetched, or the error fetching it. Returning an error
+// stops the crawl.
+type Visitor func(page *Page, err error) error
+
+// Crawler fetches pages over HTTP
+type Crawler struct {
+	client *http.Client
+}
+
+// New creates a crawler; a nil client uses one with a 30 second timeout that only connects to
+// public addresses, as crawled URLs come from users and the pages they link to
+func New(client *http.Client) *Crawler {
+	if client == nil {
+		client = netguard.NewClient(30 * time.Second)
+	}
+	return &Crawler{client: client}
+}
+
+// ParseSeed validates a seed URL: absolute, http or https, on a public host
+func ParseSeed(raw string) (*url.URL, error) {
+	seed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if err := netguard.CheckURL(seed); err != nil {
+		return nil, err
+	}
+	seed.Fragment = ""
+	return seed, nil
+}
+
+// queued is a URL waiting to be fetched
+type queued struct {
+	url   *url.URL
+	depth int
+}
+
+// Crawl fetches pages breadth-first from the seed, calling visit with each one, until the
+// limits are reached, no links are left or ctx is cancelled. Pages robots.txt disallows and
+// responses that aren't HTML are skipped without counting towards MaxPages.
+func (c *Crawler) Crawl(ctx context.Context, seed *url.URL, opts Options, visit Visitor) error {
+	robots := make(map[string]*robotsRules)
+	seen := map[string]bool{normalize(seed): true}
+	queue := []queued{{url: seed}}
+	fetched := 0
+
+	for len(queue) > 0 && fetched < opts.MaxPages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		next := queue[0]
+		queue = queue[1:]
+
+		rules, ok := robots[next.url.Host]
+		if !ok {
+			rules = c.fetchRobots(ctx, next.url)
+			robots[next.url.Host] = rules
+		}
+		if !rules.allowed(next.url) {
+			continue
+		}
+
+		if fetched > 0 && opts.Delay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(opts.Delay):
+			}
+		}
+
+		page, links, err := c.fetch(ctx, next.url)
+		if err == errNotHTML {
+			continue
+		}
+		fetched++
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := visit(&Page{URL: next.url.String(), Depth: next.depth}, err); err != nil {
+				return err
+			}
+			continue
+		}
+		// A redirect off the site leaves it: the page is neither kept nor followed
+		if opts.SameDomain && !sameSite(seed, page.finalURL) {
+			continue
+		}
+		page.Depth = next.depth
+		if err := visit(page, nil); err != nil {
+			return err
+		}
+
+		if next.depth >= opts.MaxDepth {
+			continue
+		}
+		for _, link := range links {
+			if opts.SameDomain && !sameSite(seed, link) {
+				continue
+			}
+			key := normalize(link)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			queue = append(queue, queued{url: link, depth: next.depth + 1})
+		}
+	}
+
+	return nil
+}
+
+// errNotHTML marks a response that isn't an HTML page, such as an image or PDF link
+var errNotHTML = fmt.Errorf("not an HTML page")
+
+// fetch downloads a page and extracts its text and the links it contains
+func (c *Crawler) fetch(ctx context.Context, pageURL *url.URL) (*Page, []*url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL.String(), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, nil, errNotHTML
+	}
+
+	// Redirects may have moved the page; its links are relative to where it ended up
+	base := resp.Request.URL
+	title, text, links, err := Extract(base, io.LimitReader(resp.Body, MaxPageSize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Page{URL: base.String(), Title: title, Text: text, finalURL: base}, links, nil
+}
+
+// sameSite reports whether a link is on the seed's host, ignoring a leading "www."
+func sameSite(seed, link *url.URL) bool {
+	return strings.TrimPrefix(strings.ToLower(seed.Hostname()), "www.") ==
+		strings.TrimPrefix(strings.ToLower(link.Hostname()), "www.")
+}
+
+// normalize returns the form of a URL used to visit it once: without fragment or trailing
+// slash, with a lowercase host
+func normalize(u *url.URL) string {
+	normalized := *u
+	normalized.Fragment = ""
+	normalized.Host = strings.ToLower(normalized.Host)
+	normalized.Path = strings.TrimSuffix(normalized.Path, "/")
+	return normalized.String()
+}
@@ -0,0 +1,253 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped are elements whose content isn't part of a page's text: scripts, styles and the
+// navigation, headers and footers repeated on every page of a site
+var skipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Svg: true,
+	atom.Head: true, atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Iframe: true,
+}
+
+// blocks are elements that start a new line of text
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Br: true, atom.Tr: true, atom.Table: true, atom.Ul: true, atom.Ol: true, atom.Dl: true,
+	atom.Dt: true, atom.Dd: true, atom.Blockquote: true, atom.Figure: true, atom.Figcaption: true,
+	atom.Hr: true,
+}
+
+// headings map heading elements to their Markdown prefix
+var headings = map[atom.Atom]string{
+	atom.H1: "# ", atom.H2: "## ", atom.H3: "### ", atom.H4: "#### ", atom.H5: "##### ", atom.H6: "###### ",
+}
+
+// Extract reads an HTML page, returning its title, its readable text as Markdown-style text and
+// the http(s) links it contains, resolved against base. A page with a <main> or <article>
+// element only contributes that element's text.
+func Extract(base *url.URL, r io.Reader) (title, text string, links []*url.URL, err error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	if baseHref := findBase(doc); baseHref != "" {
+		if resolved, err := base.Parse(baseHref); err == nil {
+			base = resolved
+		}
+	}
+
+	var walkLinks func(*html.Node)
+	walkLinks = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Title:
+				if title == "" && n.FirstChild != nil {
+					title = strings.Join(strings.Fields(n.FirstChild.Data), " ")
+				}
+			case atom.A:
+				if link := resolveLink(base, attr(n, "href")); link != nil && !strings.Contains(attr(n, "rel"), "nofollow") {
+					links = append(links, link)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walkLinks(child)
+		}
+	}
+	walkLinks(doc)
+
+	root := findContent(doc)
+	if root == nil {
+		root = doc
+	}
+	var out textWriter
+	out.write(root)
+
+	return title, out.String(), links, nil
+}
+
+// findBase returns the href of the page's <base> element, if any
+func findBase(n *html.Node) string {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Base {
+		return attr(n, "href")
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if href := findBase(child); href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+// findContent returns the page's <main> element, or else its first <article>
+func findContent(doc *html.Node) *html.Node {
+	var main, article *html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if n.DataAtom == atom.Main && main == nil {
+				main = n
+			}
+			if n.DataAtom == atom.Article && article == nil {
+				article = n
+			}
+		}
+		for child := n.FirstChild; child != nil && main == nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	if main != nil {
+		return main
+	}
+	return article
+}
+
+// resolveLink resolves an href against the page, keeping http(s) links without their fragment
+func resolveLink(base *url.URL, href string) *url.URL {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return nil
+	}
+	link, err := base.Parse(href)
+	if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+		return nil
+	}
+	link.Fragment = ""
+	return link
+}
+
+// attr returns the value of a node's attribute
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textWriter builds a page's text, collapsing whitespace except in preformatted blocks
+type textWriter struct {
+	lines []string
+	line  strings.Builder
+}
+
+// write appends the text of a node and its descendants
+func (w *textWriter) write(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+		if skipped[n.DataAtom] {
+			return
+		}
+		if n.DataAtom == atom.Pre {
+			w.breakLine()
+			w.lines = append(w.lines, "```\n"+strings.Trim(textContent(n), "\n")+"\n```")
+			return
+		}
+		if prefix, ok := headings[n.DataAtom]; ok {
+			w.breakLine()
+			w.line.WriteString(prefix)
+		}
+		if n.DataAtom == atom.Li {
+			w.breakLine()
+			w.line.WriteString("- ")
+		}
+		if blocks[n.DataAtom] {
+			w.breakLine()
+		}
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		w.write(child)
+	}
+
+	if n.Type == html.ElementNode {
+		if _, ok := headings[n.DataAtom]; ok || blocks[n.DataAtom] || n.DataAtom == atom.Li {
+			w.breakLine()
+		}
+		if n.DataAtom == atom.Td || n.DataAtom == atom.Th {
+			w.line.WriteString(" | ")
+		}
+	}
+}
+
+// text appends running text with its whitespace collapsed
+func (w *textWriter) text(data string) {
+	fields := strings.Fields(data)
+	if len(fields) == 0 {
+		if data != "" && w.line.Len() > 0 {
+			w.line.WriteString(" ")
+		}
+		return
+	}
+	current := w.line.String()
+	if current != "" && !strings.HasSuffix(current, " ") && startsWithSpace(data) {
+		w.line.WriteString(" ")
+	}
+	w.line.WriteString(strings.Join(fields, " "))
+	if endsWithSpace(data) {
+		w.line.WriteString(" ")
+	}
+}
+
+// breakLine ends the current line; empty lines and bare list or heading markers are dropped
+func (w *textWriter) breakLine() {
+	line := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(w.line.String()), "|"))
+	w.line.Reset()
+	if line == "" || line == "-" || strings.Trim(line, "#") == "" {
+		return
+	}
+	w.lines = append(w.lines, line)
+}
+
+// String returns the text written, one paragraph per line group
+func (w *textWriter) String() string {
+	w.breakLine()
+	var b strings.Builder
+	for i, line := range w.lines {
+		if i > 0 {
+			// Consecutive list items stay together; other blocks are separated by a blank line
+			if strings.HasPrefix(line, "- ") && strings.HasPrefix(w.lines[i-1], "- ") {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// textContent returns a node's text as is
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.TrimLeft(s, " \t\r\n") != s
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && strings.TrimRight(s, " \t\r\n") != s
+}
@@ -0,0 +1,140 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// robotsRules are the Disallow and Allow path prefixes robots.txt sets for every crawler ("*")
+type robotsRules struct {
+	disallow []string
+	allow    []string
+}
+
+// fetchRobots reads a host's robots.txt; when it is missing or unreadable everything is allowed
+func (c *Crawler) fetchRobots(ctx context.Context, page *url.URL) *robotsRules {
+	robotsURL := url.URL{Scheme: page.Scheme, Host: page.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL.String(), nil)
+	if err != nil {
+		return &robotsRules{}
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &robotsRules{}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &robotsRules{}
+	}
+
+	return parseRobots(io.LimitReader(resp.Body, 512*1024))
+}
+
+// parseRobots reads the rules of the "*" user-agent groups
+func parseRobots(r io.Reader) *robotsRules {
+	rules := &robotsRules{}
+	applies := false
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive User-agent lines share the group that follows them
+			if !inAgents {
+				applies = false
+			}
+			inAgents = true
+			if value == "*" {
+				applies = true
+			}
+		case "disallow":
+			inAgents = false
+			if applies && value != "" {
+				rules.disallow = append(rules.disallow, value)
+			}
+		case "allow":
+			inAgents = false
+			if applies && value != "" {
+				rules.allow = append(rules.allow, value)
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	return rules
+}
+
+// allowed reports whether a URL may be fetched: the longest matching prefix wins, and Allow wins
+// a tie
+func (r *robotsRules) allowed(u *url.URL) bool {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	longestDisallow := -1
+	for _, prefix := range r.disallow {
+		if robotsMatch(path, prefix) && len(prefix) > longestDisallow {
+			longestDisallow = len(prefix)
+		}
+	}
+	if longestDisallow < 0 {
+		return true
+	}
+	for _, prefix := range r.allow {
+		if robotsMatch(path, prefix) && len(prefix) >= longestDisallow {
+			return true
+		}
+	}
+	return false
+}
+
+// robotsMatch matches a path against a robots.txt pattern, which may use "*" wildcards and end
+// with "$"
+func robotsMatch(path, pattern string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	if len(parts) == 1 {
+		return !anchored || path == parts[0]
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+)
+
+// Migration locking
+const (
+	// migrationLockID is the Postgres advisory lock held while migrating, so replicas starting
+	// together apply each migration once
+	migrationLockID int64 = 7262021433
+	// migrationLockTimeout is how long a replica waits for another to finish migrating
+	migrationLockTimeout = 5 * time.Minute
+)
+
+// minServerVersion is the oldest PostgreSQL the schema supports (12, for generated columns), as
+// server_version_num
+const minServerVersion = 120000
+
+// SchemaStatus compares a database's schema with this build's migrations
+type SchemaStatus struct {
+	// ServerVersion is the PostgreSQL server_version_num, e.g. 160002 for 16.2
+	ServerVersion int
+	// Applied is the number of migrations the database has recorded; 0 for databases migrated
+	// before they were counted
+	Applied int
+	// Latest is the number of migrations this build knows
+	Latest int
+}
+
+// Pending returns the number of migrations this build would apply
+func (s *SchemaStatus) Pending() int {
+	return max(s.Latest-s.Applied, 0)
+}
+
+// Newer reports whether a newer build has migrated the database. Migrations only add to the
+// schema, so older builds keep working against it while a rollout finishes.
+func (s *SchemaStatus) Newer() bool {
+	return s.Applied > s.Latest
+}
+
+// querier runs statements on a database or a single connection
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Status reads the server version and the number of migrations the database has applied
+func Status(ctx context.Context, db querier) (*SchemaStatus, error) {
+	status := &SchemaStatus{Latest: len(migrationList())}
+
+	if err := db.QueryRowContext(ctx, `SELECT current_setting('server_version_num')::int`).Scan(&status.ServerVersion); err != nil {
+		return nil, fmt.Errorf("failed to read server version: %w", err)
+	}
+
+	var tracked bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&tracked); err != nil {
+		return nil, fmt.Errorf("failed to check schema version: %w", err)
+	}
+	if tracked {
+		err := db.QueryRowContext(ctx, `SELECT version FROM schema_migrations`).Scan(&status.Applied)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to read schema version: %w", err)
+		}
+	}
+
+	return status, nil
+}
+
+// Preflight checks that the database can be migrated before anything is changed: a supported
+// PostgreSQL version, the uuid-ossp extension available, and permission to create tables
+func Preflight(ctx context.Context, db querier) (*SchemaStatus, error) {
+	status, err := Status(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if status.ServerVersion < minServerVersion {
+		return nil, fmt.Errorf("PostgreSQL %d or later is required, the server is %d.%d",
+			minServerVersion/10000, status.ServerVersion/10000, status.ServerVersion%10000)
+	}
+	if status.Pending() == 0 {
+		return status, nil
+	}
+
+	var hasExtension bool
+	err = db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'uuid-ossp')`).
+		Scan(&hasExtension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check extensions: %w", err)
+	}
+	if !hasExtension {
+		return nil, fmt.Errorf("the uuid-ossp extension isn't available on the server")
+	}
+
+	var schema string
+	var canCreate bool
+	err = db.QueryRowContext(ctx, `SELECT current_schema(), has_schema_privilege(current_schema(), 'CREATE')`).
+		Scan(&schema, &canCreate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check privileges: %w", err)
+	}
+	if !canCreate {
+		return nil, fmt.Errorf("the database user can't create tables in schema %q", schema)
+	}
+
+	return status, nil
+}
+
+// RunMigrations applies the migrations the database hasn't, after the preflight checks. It
+// holds an advisory lock while migrating, so replicas starting together take turns and the
+// ones that wait find nothing left to do. The version is recorded after each migration, so a
+// failed run resumes where it stopped.
+func RunMigrations(db *sql.DB) error {
+	ctx := context.Background()
+
+	// Session advisory locks belong to a connection, so everything runs on one
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	lockCtx, cancel := context.WithTimeout(ctx, migrationLockTimeout)
+	defer cancel()
+	if _, err := conn.ExecContext(lockCtx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			logger.Error("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	status, err := Preflight(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("preflight check failed: %w", err)
+	}
+	if status.Newer() {
+		logger.Warn("Database schema is newer than this build; skipping migrations",
+			"applied", status.Applied,
+			"latest", status.Latest,
+		)
+		return nil
+	}
+	if status.Pending() == 0 {
+		return nil
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+			version INTEGER NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	migrations := migrationList()
+	for i := status.Applied; i < len(migrations); i++ {
+		if _, err := conn.ExecContext(ctx, migrations[i]); err != nil {
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+		_, err := conn.ExecContext(ctx, `
+			INSERT INTO schema_migrations (id, version) VALUES (TRUE, $1)
+			ON CONFLICT (id) DO UPDATE SET version = EXCLUDED.version, updated_at = NOW()
+		`, i+1)
+		if err != nil {
+			return fmt.Errorf("failed to record schema version: %w", err)
+		}
+	}
+
+	logger.Info("Applied database migrations", "from", status.Applied, "to", len(migrations))
+	return nil
+}
+
+// EnsureSchema prepares the database at startup: it migrates when migrate is set, and otherwise
+// only checks that the migrate command has applied every migration this build needs
+func EnsureSchema(db *sql.DB, migrate bool) error {
+	if migrate {
+		return RunMigrations(db)
+	}
+
+	status, err := Status(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	if status.Pending() > 0 {
+		return fmt.Errorf("%d database migrations are pending; run the migrate command first", status.Pending())
+	}
+	return nil
+}
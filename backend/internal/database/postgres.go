@@ -28,9 +28,11 @@ func NewPostgresDB(databaseURL string) (*sql.DB, error) {
 	return db, nil
 }
 
-// RunMigrations runs all database migrations
-func RunMigrations(db *sql.DB) error {
-	migrations := []string{
+// migrationList returns the schema migrations in the order they are applied. A database records
+// how many it has applied, so new ones are only ever appended; each stays idempotent for
+// databases migrated before the count was recorded.
+func migrationList() []string {
+	return []string{
 		`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`,
 
 		// Users table
@@ -71,13 +73,602 @@ func RunMigrations(db *sql.DB) error {
 		)`,
 
 		`CREATE INDEX IF NOT EXISTS idx_query_history_user_id ON query_history(user_id)`,
-	}
+		`CREATE INDEX IF NOT EXISTS idx_query_history_created_at ON query_history(user_id, created_at DESC)`,
 
-	for _, migration := range migrations {
-		if _, err := db.Exec(migration); err != nil {
-			return fmt.Errorf("migration failed: %w", err)
-		}
-	}
+		// Conversations table
+		`CREATE TABLE IF NOT EXISTS conversations (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			title VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_conversations_user_id ON conversations(user_id, updated_at DESC)`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS conversation_id UUID REFERENCES conversations(id) ON DELETE CASCADE`,
+		`CREATE INDEX IF NOT EXISTS idx_query_history_conversation_id ON query_history(conversation_id, created_at)`,
+
+		// Pin and favorite flags
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS favorite BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS favorite BOOLEAN NOT NULL DEFAULT FALSE`,
+
+		// Per-conversation generation and retrieval settings
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS model VARCHAR(100) NOT NULL DEFAULT ''`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS temperature REAL`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS filters JSONB`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS language VARCHAR(50) NOT NULL DEFAULT ''`,
+
+		// Scheduled queries table (standing questions)
+		`CREATE TABLE IF NOT EXISTS scheduled_queries (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			question TEXT NOT NULL,
+			filters JSONB,
+			frequency VARCHAR(20) NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			next_run_at TIMESTAMP NOT NULL,
+			last_run_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_scheduled_queries_user_id ON scheduled_queries(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_scheduled_queries_next_run ON scheduled_queries(next_run_at) WHERE enabled`,
+
+		// Admin flag granting the admin:* scope
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE`,
+
+		// API keys table (scoped access for integrations)
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			key_prefix VARCHAR(20) NOT NULL,
+			key_hash VARCHAR(64) UNIQUE NOT NULL,
+			scopes TEXT[] NOT NULL,
+			last_used_at TIMESTAMP,
+			expires_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id)`,
 
-	return nil
+		// Audit events table (account activity and anomaly flags)
+		`CREATE TABLE IF NOT EXISTS audit_events (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			action VARCHAR(50) NOT NULL,
+			resource_id VARCHAR(255) NOT NULL DEFAULT '',
+			ip_address VARCHAR(64) NOT NULL DEFAULT '',
+			country VARCHAR(8) NOT NULL DEFAULT '',
+			metadata JSONB,
+			flagged BOOLEAN NOT NULL DEFAULT FALSE,
+			flag_reason VARCHAR(50) NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_audit_events_user_action ON audit_events(user_id, action, created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_events_flagged ON audit_events(created_at DESC) WHERE flagged`,
+
+		// Canary documents alert when retrieved or downloaded
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS canary BOOLEAN NOT NULL DEFAULT FALSE`,
+
+		// User settings table (persona and answer preferences)
+		`CREATE TABLE IF NOT EXISTS user_settings (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			system_prompt TEXT NOT NULL DEFAULT '',
+			language VARCHAR(50) NOT NULL DEFAULT '',
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		// Per-query token usage and estimated cost
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS embedding_tokens INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS prompt_tokens INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS completion_tokens INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS cost_usd NUMERIC(12, 6) NOT NULL DEFAULT 0`,
+
+		// Background jobs (used by the postgres job queue driver)
+		`CREATE TABLE IF NOT EXISTS jobs (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			type VARCHAR(100) NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			locked_until TIMESTAMP,
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(run_at) WHERE status IN ('pending', 'running')`,
+
+		// Chunk text for keyword search when the embedding provider is unavailable.
+		// The 'simple' configuration avoids language-specific stemming for mixed-language documents.
+		`CREATE TABLE IF NOT EXISTS document_chunks (
+			id VARCHAR(255) PRIMARY KEY,
+			document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			content TEXT NOT NULL,
+			metadata JSONB NOT NULL DEFAULT '{}',
+			search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_document_chunks_search ON document_chunks USING GIN(search_vector)`,
+		`CREATE INDEX IF NOT EXISTS idx_document_chunks_user ON document_chunks(user_id)`,
+
+		// Cron schedules for background jobs and their run history
+		`CREATE TABLE IF NOT EXISTS schedules (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			name VARCHAR(100) UNIQUE NOT NULL,
+			cron_expr VARCHAR(100) NOT NULL,
+			timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+			misfire_policy VARCHAR(20) NOT NULL DEFAULT 'run_once',
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			next_run_at TIMESTAMP NOT NULL,
+			last_run_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE TABLE IF NOT EXISTS schedule_runs (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			schedule_id UUID NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+			scheduled_for TIMESTAMP NOT NULL,
+			started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			finished_at TIMESTAMP,
+			status VARCHAR(20) NOT NULL,
+			error TEXT NOT NULL DEFAULT ''
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_schedule_runs_schedule ON schedule_runs(schedule_id, started_at DESC)`,
+
+		// Outgoing webhooks and their delivery log
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			url TEXT NOT NULL,
+			secret VARCHAR(128) NOT NULL,
+			events TEXT[] NOT NULL DEFAULT '{}',
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks(user_id)`,
+
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+			event VARCHAR(100) NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_status INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at DESC)`,
+
+		// Document-level summaries, indexed separately for two-stage retrieval
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS summary TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS summarized_at TIMESTAMP`,
+
+		// Daily digests of newly added documents; one per user and period
+		`CREATE TABLE IF NOT EXISTS digests (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			period_start TIMESTAMP NOT NULL,
+			period_end TIMESTAMP NOT NULL,
+			content TEXT NOT NULL,
+			document_ids TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP DEFAULT NOW(),
+			UNIQUE(user_id, period_start)
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_digests_user ON digests(user_id, period_start DESC)`,
+
+		// Glossary terms expanded in queries before embedding; terms are unique per user, ignoring case
+		`CREATE TABLE IF NOT EXISTS glossary_terms (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			term VARCHAR(100) NOT NULL,
+			expansion TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_glossary_terms_user_term ON glossary_terms(user_id, LOWER(term))`,
+
+		// Saved, parameterized query templates
+		`CREATE TABLE IF NOT EXISTS saved_queries (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			template TEXT NOT NULL,
+			filters JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_saved_queries_user_id ON saved_queries(user_id)`,
+
+		// Job applications linking an ingested posting to the resume sent with it
+		`CREATE TABLE IF NOT EXISTS applications (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			posting_document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+			resume_document_id UUID REFERENCES documents(id) ON DELETE SET NULL,
+			company VARCHAR(255) NOT NULL DEFAULT '',
+			role VARCHAR(255) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'saved',
+			notes TEXT NOT NULL DEFAULT '',
+			cover_letter TEXT NOT NULL DEFAULT '',
+			applied_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_applications_user_id ON applications(user_id)`,
+
+		// Flashcards with their SM-2 review schedule, optionally generated from a document
+		`CREATE TABLE IF NOT EXISTS flashcards (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			document_id UUID REFERENCES documents(id) ON DELETE CASCADE,
+			question TEXT NOT NULL,
+			answer TEXT NOT NULL,
+			source VARCHAR(255) NOT NULL DEFAULT '',
+			ease_factor DOUBLE PRECISION NOT NULL DEFAULT 2.5,
+			interval_days INTEGER NOT NULL DEFAULT 0,
+			repetitions INTEGER NOT NULL DEFAULT 0,
+			lapses INTEGER NOT NULL DEFAULT 0,
+			due_at TIMESTAMP NOT NULL,
+			last_reviewed_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_flashcards_user_due ON flashcards(user_id, due_at)`,
+
+		// One row per flashcard review, for streaks and retention
+		`CREATE TABLE IF NOT EXISTS flashcard_reviews (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			flashcard_id UUID NOT NULL REFERENCES flashcards(id) ON DELETE CASCADE,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			grade SMALLINT NOT NULL,
+			interval_days INTEGER NOT NULL,
+			reviewed_at TIMESTAMP NOT NULL
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_flashcard_reviews_user_reviewed ON flashcard_reviews(user_id, reviewed_at)`,
+
+		// Websites allowed to embed a widget key
+		`ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allowed_origins TEXT[] NOT NULL DEFAULT '{}'`,
+
+		// Embeddings by SHA-256 of their text, so unchanged chunks aren't re-embedded on re-sync
+		`CREATE TABLE IF NOT EXISTS embedding_cache (
+			content_hash CHAR(64) NOT NULL,
+			model VARCHAR(255) NOT NULL,
+			dimensions INTEGER NOT NULL,
+			input_type VARCHAR(20) NOT NULL,
+			embedding BYTEA NOT NULL,
+			created_at TIMESTAMP DEFAULT NOW(),
+			PRIMARY KEY (content_hash, model, dimensions, input_type)
+		)`,
+
+		// The Matrix bot's private room with each linked user
+		`CREATE TABLE IF NOT EXISTS matrix_rooms (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			matrix_user_id VARCHAR(255) NOT NULL,
+			room_id VARCHAR(255) UNIQUE NOT NULL,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		// Discord accounts linked to users; link codes are stored as SHA-256 hashes
+		`CREATE TABLE IF NOT EXISTS discord_links (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			discord_user_id VARCHAR(32) UNIQUE,
+			link_code_hash CHAR(64),
+			link_code_expires_at TIMESTAMP,
+			linked_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		// Embedding tokens spent per document; rows outlive deleted documents so past spend still adds up
+		`CREATE TABLE IF NOT EXISTS embedding_usage (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			document_id UUID,
+			filename VARCHAR(255) NOT NULL DEFAULT '',
+			source VARCHAR(50) NOT NULL,
+			folder TEXT NOT NULL DEFAULT '',
+			model VARCHAR(100) NOT NULL DEFAULT '',
+			tokens INTEGER NOT NULL DEFAULT 0,
+			cost_usd NUMERIC(12, 6) NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_embedding_usage_user_created ON embedding_usage(user_id, created_at DESC)`,
+
+		// Email verification
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP`,
+
+		// Single-use email verification and password reset tokens, stored as SHA-256 hashes
+		`CREATE TABLE IF NOT EXISTS email_tokens (
+			token_hash CHAR(64) PRIMARY KEY,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			purpose VARCHAR(50) NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_email_tokens_user ON email_tokens(user_id, purpose)`,
+
+		// Outbound email send log
+		`CREATE TABLE IF NOT EXISTS mail_log (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+			recipient VARCHAR(255) NOT NULL,
+			template VARCHAR(100) NOT NULL,
+			subject TEXT NOT NULL DEFAULT '',
+			driver VARCHAR(20) NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_mail_log_created_at ON mail_log(created_at DESC)`,
+
+		// Notification delivery targets (Telegram chat, push topic) per user and channel
+		`CREATE TABLE IF NOT EXISTS notification_targets (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			channel VARCHAR(20) NOT NULL,
+			target VARCHAR(255) NOT NULL,
+			updated_at TIMESTAMP DEFAULT NOW(),
+			PRIMARY KEY (user_id, channel)
+		)`,
+
+		// Channels each event is delivered through, per user; event '*' covers events without a route
+		`CREATE TABLE IF NOT EXISTS notification_routes (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			event VARCHAR(100) NOT NULL,
+			channels TEXT[] NOT NULL DEFAULT '{}',
+			updated_at TIMESTAMP DEFAULT NOW(),
+			PRIMARY KEY (user_id, event)
+		)`,
+
+		// Answer quality at generation time, used to pick the best answer for the FAQ
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS confidence REAL`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS verified BOOLEAN`,
+
+		// Personal FAQ: clusters of similar past questions, each with its best answer
+		`CREATE TABLE IF NOT EXISTS faq_entries (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			question TEXT NOT NULL,
+			embedding REAL[] NOT NULL,
+			ask_count INTEGER NOT NULL DEFAULT 1,
+			best_history_id UUID REFERENCES query_history(id) ON DELETE SET NULL,
+			last_asked_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_faq_entries_user_id ON faq_entries(user_id, ask_count DESC)`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS faq_entry_id UUID REFERENCES faq_entries(id) ON DELETE SET NULL`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS faq_processed_at TIMESTAMP`,
+		`CREATE INDEX IF NOT EXISTS idx_query_history_faq_entry_id ON query_history(faq_entry_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_query_history_faq_unprocessed ON query_history(created_at) WHERE faq_processed_at IS NULL`,
+
+		// Per-user embedding model: the one chosen, and the one the user's collection holds
+		// (they differ until a re-embedding run moves the user's documents); empty is the default
+		`ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS embedding_model TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS active_embedding_model TEXT NOT NULL DEFAULT ''`,
+
+		// Phrases and regexes whose matching chunks are never put in prompts, with match counts
+		`CREATE TABLE IF NOT EXISTS retrieval_blocklist (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			pattern TEXT NOT NULL,
+			regex BOOLEAN NOT NULL DEFAULT FALSE,
+			match_count INTEGER NOT NULL DEFAULT 0,
+			last_matched_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_retrieval_blocklist_user_id ON retrieval_blocklist(user_id)`,
+
+		// Documents embedded through the OpenAI Batch API, polled until their vectors are written
+		`CREATE TABLE IF NOT EXISTS embedding_batches (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+			batch_id VARCHAR(100) NOT NULL,
+			input_file_id VARCHAR(100) NOT NULL,
+			model VARCHAR(100) NOT NULL,
+			source VARCHAR(50) NOT NULL,
+			folder TEXT NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT NOW(),
+			completed_at TIMESTAMP
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_embedding_batches_pending ON embedding_batches(created_at) WHERE status = 'pending'`,
+
+		// Credentials found in documents during ingestion; only a short preview of each is kept
+		`CREATE TABLE IF NOT EXISTS secret_findings (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+			rule VARCHAR(50) NOT NULL,
+			preview VARCHAR(50) NOT NULL DEFAULT '',
+			line INTEGER NOT NULL DEFAULT 0,
+			redacted BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_secret_findings_user_id ON secret_findings(user_id, created_at DESC)`,
+
+		// Change feed for offline clients: every write to documents, conversations and query
+		// history is recorded with a sequence number. There is no foreign key to users, since
+		// changes are recorded while a user's rows are deleted; the sync_maintenance schedule
+		// removes them afterwards.
+		`CREATE TABLE IF NOT EXISTS sync_changes (
+			seq BIGSERIAL PRIMARY KEY,
+			user_id UUID NOT NULL,
+			entity VARCHAR(20) NOT NULL,
+			entity_id UUID NOT NULL,
+			operation VARCHAR(10) NOT NULL,
+			changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_sync_changes_user_seq ON sync_changes(user_id, seq)`,
+		`CREATE INDEX IF NOT EXISTS idx_sync_changes_entity ON sync_changes(entity_id, seq)`,
+
+		// Highest sequence number of a user's pruned deletions; clients behind it must resync
+		`CREATE TABLE IF NOT EXISTS sync_horizons (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			pruned_seq BIGINT NOT NULL DEFAULT 0
+		)`,
+
+		// Data stored before the change feed existed is recorded once, as if just created
+		`INSERT INTO sync_changes (user_id, entity, entity_id, operation)
+		SELECT user_id, entity, id, 'upsert' FROM (
+			SELECT user_id, 'document' AS entity, id, upload_date AS changed_at FROM documents
+			UNION ALL SELECT user_id, 'conversation', id, updated_at FROM conversations
+			UNION ALL SELECT user_id, 'message', id, created_at FROM query_history
+		) existing
+		WHERE NOT EXISTS (SELECT 1 FROM sync_changes)
+		ORDER BY changed_at`,
+
+		// Triggers record the changes, so cascading deletes and every write path are covered;
+		// updates that change nothing are skipped
+		`CREATE OR REPLACE FUNCTION record_sync_change() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				INSERT INTO sync_changes (user_id, entity, entity_id, operation)
+				VALUES (OLD.user_id, TG_ARGV[0], OLD.id, 'delete');
+				RETURN OLD;
+			END IF;
+			IF TG_OP = 'UPDATE' AND OLD IS NOT DISTINCT FROM NEW THEN
+				RETURN NEW;
+			END IF;
+			INSERT INTO sync_changes (user_id, entity, entity_id, operation)
+			VALUES (NEW.user_id, TG_ARGV[0], NEW.id, 'upsert');
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql`,
+
+		`CREATE OR REPLACE TRIGGER sync_documents AFTER INSERT OR UPDATE OR DELETE ON documents
+		FOR EACH ROW EXECUTE FUNCTION record_sync_change('document')`,
+		`CREATE OR REPLACE TRIGGER sync_conversations AFTER INSERT OR UPDATE OR DELETE ON conversations
+		FOR EACH ROW EXECUTE FUNCTION record_sync_change('conversation')`,
+		`CREATE OR REPLACE TRIGGER sync_query_history AFTER INSERT OR UPDATE OR DELETE ON query_history
+		FOR EACH ROW EXECUTE FUNCTION record_sync_change('message')`,
+
+		// Prompt versions: a system prompt and retrieval config that a share of queries is routed to
+		// before full rollout
+		`CREATE TABLE IF NOT EXISTS prompt_versions (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			name VARCHAR(100) NOT NULL UNIQUE,
+			system_prompt TEXT NOT NULL,
+			pipeline JSONB NOT NULL DEFAULT '{}',
+			top_k INTEGER NOT NULL DEFAULT 0,
+			traffic_percent INTEGER NOT NULL DEFAULT 0 CHECK (traffic_percent BETWEEN 0 AND 100),
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		)`,
+		// The version that answered a query and how long it took, to compare versions; latency is
+		// only recorded for queries eligible for routing. No foreign key, so queries of a deleted
+		// version don't count towards the default prompt.
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS prompt_version_id UUID`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS latency_ms INTEGER`,
+		`CREATE INDEX IF NOT EXISTS idx_query_history_latency ON query_history(created_at) WHERE latency_ms IS NOT NULL`,
+		// User feedback on an answer: 1 helpful, -1 not helpful
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS feedback SMALLINT CHECK (feedback IN (-1, 1))`,
+
+		// Vector collection registry: the Qdrant name serving each of a user's collections, so the
+		// naming scheme can change without losing existing collections. previous_name is the name
+		// before the last rename, which stays usable as an alias until it is dropped.
+		`CREATE TABLE IF NOT EXISTS vector_collections (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			scope VARCHAR(32) NOT NULL,
+			name VARCHAR(255) NOT NULL UNIQUE,
+			previous_name VARCHAR(255),
+			updated_at TIMESTAMP DEFAULT NOW(),
+			PRIMARY KEY (user_id, scope)
+		)`,
+
+		// Knowledge base snapshots: the documents and vector count of a user's knowledge base at a
+		// point in time, compared to audit how it changed
+		`CREATE TABLE IF NOT EXISTS kb_snapshots (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			label VARCHAR(255) NOT NULL DEFAULT '',
+			automatic BOOLEAN NOT NULL DEFAULT FALSE,
+			document_count INTEGER NOT NULL,
+			vector_count BIGINT NOT NULL,
+			documents JSONB NOT NULL DEFAULT '[]',
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_kb_snapshots_user_created ON kb_snapshots(user_id, created_at DESC)`,
+
+		// Per-document chunking parameters, reused when a document is indexed again; empty for
+		// documents indexed before they were recorded
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS chunking_strategy VARCHAR(20) NOT NULL DEFAULT ''`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS chunk_size INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS chunk_overlap INTEGER NOT NULL DEFAULT 0`,
+
+		// Conversation branches: each message follows its parent, and an edited question starts a
+		// new branch from the parent of the message it replaces. Existing conversations become a
+		// single branch in message order, once, when the column is added.
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'query_history' AND column_name = 'parent_id'
+			) THEN
+				ALTER TABLE query_history ADD COLUMN parent_id UUID REFERENCES query_history(id) ON DELETE SET NULL;
+				UPDATE query_history q SET parent_id = p.previous_id
+				FROM (
+					SELECT id, LAG(id) OVER (PARTITION BY conversation_id ORDER BY created_at) AS previous_id
+					FROM query_history WHERE conversation_id IS NOT NULL
+				) p
+				WHERE q.id = p.id AND p.previous_id IS NOT NULL;
+			END IF;
+		END $$`,
+		`CREATE INDEX IF NOT EXISTS idx_query_history_parent_id ON query_history(parent_id)`,
+		// The last message of the branch a conversation shows and continues; NULL follows the
+		// latest message
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS active_message_id UUID REFERENCES query_history(id) ON DELETE SET NULL`,
+		// Streamed answers are recorded before they're generated and stay truncated unless they
+		// complete; read_at is when the client confirmed it received the answer
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS truncated BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE query_history ADD COLUMN IF NOT EXISTS read_at TIMESTAMP`,
+
+		`CREATE TABLE IF NOT EXISTS crawl_jobs (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			seed_url TEXT NOT NULL,
+			max_depth INTEGER NOT NULL,
+			max_pages INTEGER NOT NULL,
+			same_domain BOOLEAN NOT NULL DEFAULT TRUE,
+			status VARCHAR(20) NOT NULL DEFAULT 'queued',
+			pages_crawled INTEGER NOT NULL DEFAULT 0,
+			pages_indexed INTEGER NOT NULL DEFAULT 0,
+			pages_unchanged INTEGER NOT NULL DEFAULT 0,
+			pages_failed INTEGER NOT NULL DEFAULT 0,
+			current_url TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT NOW(),
+			started_at TIMESTAMP,
+			finished_at TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_crawl_jobs_user_created ON crawl_jobs(user_id, created_at DESC)`,
+	}
 }
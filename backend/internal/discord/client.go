@@ -0,0 +1,341 @@
+// Package discord is a minimal Discord client for running a bot: HTTP interactions (slash
+// commands), messages, threads and attachment downloads over the REST API.
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+const apiBaseURL = "https://discord.com/api/v10"
+
+// MaxMessageLength is the longest message content Discord accepts
+const MaxMessageLength = 2000
+
+// noMentions stops messages from pinging anyone; answers quote document text, which may
+// contain @mentions
+var noMentions = map[string]interface{}{"parse": []string{}}
+
+// Client calls the Discord API as the bot
+type Client struct {
+	token         string
+	applicationID string
+	httpClient    *httpretry.Client
+}
+
+// NewClient creates a client for the bot of the given application
+func NewClient(token, applicationID string) *Client {
+	return &Client{
+		token:         token,
+		applicationID: applicationID,
+		// Rate limits are answered with 429 and Retry-After, which the retry client honors
+		httpClient: httpretry.New("discord", 30*time.Second),
+	}
+}
+
+// User is a Discord user
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Bot      bool   `json:"bot,omitempty"`
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	URL      string `json:"url"`
+}
+
+// Message is a channel message
+type Message struct {
+	ID          string       `json:"id"`
+	ChannelID   string       `json:"channel_id"`
+	Author      User         `json:"author"`
+	Content     string       `json:"content"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Channel is a channel or thread
+type Channel struct {
+	ID   string `json:"id"`
+	Type int    `json:"type"`
+}
+
+// Command is an application (slash) command
+type Command struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []CommandOption `json:"options,omitempty"`
+}
+
+// CommandOptionString is the type of a string command option
+const CommandOptionString = 3
+
+// CommandOption is a slash command argument
+type CommandOption struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+	MaxLength   int    `json:"max_length,omitempty"`
+}
+
+// RegisterCommands replaces the application's global slash commands
+func (c *Client) RegisterCommands(ctx context.Context, commands []Command) error {
+	path := "/applications/" + c.applicationID + "/commands"
+	if err := c.do(ctx, http.MethodPut, path, commands, nil); err != nil {
+		return fmt.Errorf("failed to register commands: %w", err)
+	}
+	return nil
+}
+
+// EditOriginalResponse sets the content of an interaction's response and returns the message
+func (c *Client) EditOriginalResponse(ctx context.Context, interactionToken, content string) (*Message, error) {
+	path := "/webhooks/" + c.applicationID + "/" + interactionToken + "/messages/@original"
+	body := map[string]interface{}{
+		"content":          content,
+		"allowed_mentions": noMentions,
+	}
+
+	var message Message
+	if err := c.do(ctx, http.MethodPatch, path, body, &message); err != nil {
+		return nil, fmt.Errorf("failed to edit interaction response: %w", err)
+	}
+	return &message, nil
+}
+
+// StartThread starts a public thread from a message
+func (c *Client) StartThread(ctx context.Context, channelID, messageID, name string) (*Channel, error) {
+	path := "/channels/" + channelID + "/messages/" + messageID + "/threads"
+	var thread Channel
+	if err := c.do(ctx, http.MethodPost, path, map[string]interface{}{"name": name}, &thread); err != nil {
+		return nil, fmt.Errorf("failed to start thread: %w", err)
+	}
+	return &thread, nil
+}
+
+// CreateMessage posts a message; a non-empty replyTo makes it a reply to that message
+func (c *Client) CreateMessage(ctx context.Context, channelID, content, replyTo string) (*Message, error) {
+	body := map[string]interface{}{
+		"content":          content,
+		"allowed_mentions": noMentions,
+	}
+	if replyTo != "" {
+		body["message_reference"] = map[string]string{"message_id": replyTo}
+	}
+
+	var message Message
+	if err := c.do(ctx, http.MethodPost, "/channels/"+channelID+"/messages", body, &message); err != nil {
+		return nil, fmt.Errorf("failed to create message: %w", err)
+	}
+	return &message, nil
+}
+
+// EditMessage replaces a message's content
+func (c *Client) EditMessage(ctx context.Context, channelID, messageID, content string) error {
+	path := "/channels/" + channelID + "/messages/" + messageID
+	if err := c.do(ctx, http.MethodPatch, path, map[string]string{"content": content}, nil); err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+	return nil
+}
+
+// ListMessages returns up to 100 of a channel's messages. With after set it returns the
+// messages after that one, oldest first; otherwise the latest, newest first.
+func (c *Client) ListMessages(ctx context.Context, channelID, after string, limit int) ([]Message, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	if after != "" {
+		query.Set("after", after)
+	}
+
+	var messages []Message
+	if err := c.do(ctx, http.MethodGet, "/channels/"+channelID+"/messages?"+query.Encode(), nil, &messages); err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	if after != "" {
+		// Discord returns newest first either way
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+	return messages, nil
+}
+
+// Download fetches an attachment, reading at most maxSize bytes
+func (c *Client) Download(ctx context.Context, attachmentURL string, maxSize int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, attachmentURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download attachment: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download attachment: %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("attachment larger than %d bytes", maxSize)
+	}
+	return content, nil
+}
+
+// do sends a JSON request with the bot token and decodes the JSON response into out, when out
+// is non-nil
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, apiBaseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bot "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return fmt.Errorf("discord API error (status %d): %s", resp.StatusCode, string(errBody))
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Interaction types
+const (
+	InteractionPing               = 1
+	InteractionApplicationCommand = 2
+)
+
+// Interaction response types
+const (
+	ResponsePong                   = 1
+	ResponseChannelMessage         = 4
+	ResponseDeferredChannelMessage = 5
+)
+
+// MessageFlagEphemeral shows an interaction response only to the user who invoked it
+const MessageFlagEphemeral = 1 << 6
+
+// maxInteractionAge is how old (or far in the future) a signed interaction timestamp may be
+const maxInteractionAge = 5 * time.Minute
+
+// Interaction is an incoming interaction, such as a slash command invocation
+type Interaction struct {
+	ID        string `json:"id"`
+	Type      int    `json:"type"`
+	Token     string `json:"token"`
+	ChannelID string `json:"channel_id"`
+	GuildID   string `json:"guild_id,omitempty"`
+	// Member is set in servers; User is set in DMs
+	Member *struct {
+		User User `json:"user"`
+	} `json:"member,omitempty"`
+	User *User `json:"user,omitempty"`
+	Data struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string          `json:"name"`
+			Value json.RawMessage `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+}
+
+// Invoker returns the user who triggered the interaction
+func (i *Interaction) Invoker() User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	if i.User != nil {
+		return *i.User
+	}
+	return User{}
+}
+
+// StringOption returns a string option of the invoked command, or "" if it wasn't given
+func (i *Interaction) StringOption(name string) string {
+	for _, option := range i.Data.Options {
+		if option.Name != name {
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(option.Value, &value); err == nil {
+			return value
+		}
+	}
+	return ""
+}
+
+// InteractionResponse is the immediate reply to an interaction
+type InteractionResponse struct {
+	Type int                      `json:"type"`
+	Data *InteractionResponseData `json:"data,omitempty"`
+}
+
+// InteractionResponseData is the message sent with an interaction response
+type InteractionResponseData struct {
+	Content string `json:"content,omitempty"`
+	Flags   int    `json:"flags,omitempty"`
+}
+
+// VerifyInteraction checks an interaction request's Ed25519 signature against the
+// application's public key (hex) and rejects stale timestamps, as Discord requires
+func VerifyInteraction(publicKey, signature, timestamp string, body []byte, now time.Time) bool {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(sent, 0)); age > maxInteractionAge || age < -maxInteractionAge {
+		return false
+	}
+
+	return ed25519.Verify(ed25519.PublicKey(key), append([]byte(timestamp), body...), sig)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHandler handles API key requests
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// Create handles issuing an API key; the key is only returned in this response
+func (h *APIKeyHandler) Create(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.CreateAPIKeyInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	key, plaintext, err := h.apiKeyService.Create(c.Context(), userID, middleware.GetScopes(c), req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"api_key": key,
+		"key":     plaintext,
+	})
+}
+
+// List handles listing API keys
+func (h *APIKeyHandler) List(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	keys, err := h.apiKeyService.List(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list API keys",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"api_keys": keys,
+	})
+}
+
+// Delete handles revoking an API key
+func (h *APIKeyHandler) Delete(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.apiKeyService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "API key revoked successfully",
+	})
+}
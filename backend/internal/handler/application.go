@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ApplicationHandler handles job application requests
+type ApplicationHandler struct {
+	applicationService *service.ApplicationService
+}
+
+// NewApplicationHandler creates a new application handler
+func NewApplicationHandler(applicationService *service.ApplicationService) *ApplicationHandler {
+	return &ApplicationHandler{applicationService: applicationService}
+}
+
+// Create handles starting to track an application for an ingested job posting
+func (h *ApplicationHandler) Create(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.ApplicationInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	application, err := h.applicationService.Create(c.Context(), userID, req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"application": application,
+	})
+}
+
+// List handles listing applications, optionally filtered by ?status=
+func (h *ApplicationHandler) List(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	applications, err := h.applicationService.List(c.Context(), userID, c.Query("status"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"applications": applications,
+	})
+}
+
+// Get handles getting a single application
+func (h *ApplicationHandler) Get(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	application, err := h.applicationService.Get(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"application": application,
+	})
+}
+
+// Update handles updating an application's details or status
+func (h *ApplicationHandler) Update(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.ApplicationInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	application, err := h.applicationService.Update(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"application": application,
+	})
+}
+
+// Delete handles deleting an application
+func (h *ApplicationHandler) Delete(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.applicationService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "application deleted successfully",
+	})
+}
+
+// CoverLetter handles drafting a cover letter for an application from the user's documents
+func (h *ApplicationHandler) CoverLetter(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	application, err := h.applicationService.GenerateCoverLetter(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"application": application,
+	})
+}
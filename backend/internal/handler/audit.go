@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditHandler handles the admin view of audit events
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListFlagged handles listing flagged events across all users
+func (h *AuditHandler) ListFlagged(c *fiber.Ctx) error {
+	page, err := h.auditService.ListFlagged(c.Context(), c.QueryInt("limit", 50), c.QueryInt("offset", 0))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list flagged events",
+		})
+	}
+
+	return c.JSON(page)
+}
+
+// recordAudit records an audit event for the request; failures are logged, not returned
+func recordAudit(c *fiber.Ctx, auditService *service.AuditService, userID, action, resourceID string) {
+	event := newAuditEvent(c, userID, action, resourceID)
+	if err := auditService.Record(c.Context(), event); err != nil {
+		logger.Error("Failed to record audit event", "user_id", userID, "action", action, "error", err)
+	}
+}
+
+// newAuditEvent builds an audit event carrying the request's client details
+func newAuditEvent(c *fiber.Ctx, userID, action, resourceID string) *model.AuditEvent {
+	event := &model.AuditEvent{
+		UserID:     userID,
+		Action:     action,
+		ResourceID: resourceID,
+		IPAddress:  c.IP(),
+		Country:    middleware.GetCountry(c),
+	}
+	if keyID, ok := c.Locals("apiKeyID").(string); ok {
+		event.Metadata = map[string]interface{}{"api_key_id": keyID}
+	}
+	return event
+}
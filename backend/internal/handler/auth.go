@@ -1,18 +1,31 @@
 package handler
 
 import (
+	"errors"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
 	"github.com/gofiber/fiber/v2"
 )
 
 // AuthHandler handles authentication requests
 type AuthHandler struct {
-	authService *service.AuthService
+	authService  *service.AuthService
+	auditService *service.AuditService
+	// warmer warms up the query path of users who log in; nil disables warm-up
+	warmer *service.RAGService
 }
 
-// NewAuthHandler creates a new auth handler
-func NewAuthHandler(authService *service.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+// NewAuthHandler creates a new auth handler. warmer may be nil to skip warming up the query path
+// on login.
+func NewAuthHandler(authService *service.AuthService, auditService *service.AuditService, warmer *service.RAGService) *AuthHandler {
+	return &AuthHandler{
+		authService:  authService,
+		auditService: auditService,
+		warmer:       warmer,
+	}
 }
 
 // RegisterRequest represents a registration request
@@ -25,6 +38,8 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// Scopes optionally narrows the issued token (e.g. ["query:execute"])
+	Scopes []string `json:"scopes"`
 }
 
 // Register handles user registration
@@ -51,13 +66,11 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 
 	user, err := h.authService.Register(c.Context(), req.Email, req.Password)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return errorResponse(c, err, fiber.StatusBadRequest)
 	}
 
 	// Generate token
-	token, err := h.authService.GenerateToken(user.ID, user.Email)
+	token, err := h.authService.GenerateToken(user.ID, user.Email, service.GrantedScopes(user))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to generate token",
@@ -67,8 +80,9 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message": "user registered successfully",
 		"user": fiber.Map{
-			"id":    user.ID,
-			"email": user.Email,
+			"id":             user.ID,
+			"email":          user.Email,
+			"email_verified": user.EmailVerifiedAt != nil,
 		},
 		"token": token,
 	})
@@ -83,11 +97,14 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
-	token, err := h.authService.Login(c.Context(), req.Email, req.Password)
+	token, user, err := h.authService.Login(c.Context(), req.Email, req.Password, req.Scopes)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return errorResponse(c, err, fiber.StatusUnauthorized)
+	}
+
+	recordAudit(c, h.auditService, user.ID, service.AuditActionLogin, "")
+	if h.warmer != nil {
+		h.warmer.WarmUp(user.ID)
 	}
 
 	return c.JSON(fiber.Map{
@@ -103,3 +120,111 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 		"error": "refresh token not implemented yet",
 	})
 }
+
+// EmailTokenRequest carries a token from an emailed link
+type EmailTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// PasswordResetRequest represents a request for a password reset email
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest represents setting a new password with a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// VerifyEmail handles confirming an email address with the token from the verification email
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req EmailTokenRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "token is required",
+		})
+	}
+
+	if err := h.authService.VerifyEmail(c.Context(), req.Token); err != nil {
+		if errors.Is(err, repository.ErrEmailTokenInvalid) {
+			return errorResponse(c, err, fiber.StatusBadRequest)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to verify email",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "email verified",
+	})
+}
+
+// ResendVerification handles resending the verification email to the signed-in user
+func (h *AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.authService.SendVerificationEmail(c.Context(), userID); err != nil {
+		if errors.Is(err, service.ErrEmailDisabled) {
+			return errorResponse(c, err, fiber.StatusServiceUnavailable)
+		}
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "verification email sent",
+	})
+}
+
+// RequestPasswordReset handles emailing a password reset link. The response is the same whether
+// or not the address has an account.
+func (h *AuthHandler) RequestPasswordReset(c *fiber.Ctx) error {
+	var req PasswordResetRequest
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "email is required",
+		})
+	}
+
+	if err := h.authService.RequestPasswordReset(c.Context(), req.Email); err != nil {
+		if errors.Is(err, service.ErrEmailDisabled) {
+			return errorResponse(c, err, fiber.StatusServiceUnavailable)
+		}
+		logger.Error("Failed to send password reset email", "error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "if an account exists for that email, a reset link has been sent",
+	})
+}
+
+// ResetPassword handles setting a new password with the token from the reset email
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "token is required",
+		})
+	}
+
+	userID, err := h.authService.ResetPassword(c.Context(), req.Token, req.Password)
+	if err != nil {
+		if errors.Is(err, repository.ErrEmailTokenInvalid) || len(req.Password) < 8 {
+			return errorResponse(c, err, fiber.StatusBadRequest)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to reset password",
+		})
+	}
+
+	recordAudit(c, h.auditService, userID, service.AuditActionPasswordReset, "")
+
+	return c.JSON(fiber.Map{
+		"message": "password updated",
+	})
+}
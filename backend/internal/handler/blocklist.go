@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// BlocklistHandler handles retrieval blocklist requests
+type BlocklistHandler struct {
+	blocklistService *service.BlocklistService
+}
+
+// NewBlocklistHandler creates a new blocklist handler
+func NewBlocklistHandler(blocklistService *service.BlocklistService) *BlocklistHandler {
+	return &BlocklistHandler{blocklistService: blocklistService}
+}
+
+// Create handles adding a phrase or regex to the blocklist
+func (h *BlocklistHandler) Create(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.BlocklistRuleInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	rule, err := h.blocklistService.Create(c.Context(), userID, req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"rule": rule,
+	})
+}
+
+// List handles listing blocklist rules with their match counts
+func (h *BlocklistHandler) List(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	rules, err := h.blocklistService.List(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list blocklist rules",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"rules": rules,
+	})
+}
+
+// Delete handles removing a blocklist rule
+func (h *BlocklistHandler) Delete(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.blocklistService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "blocklist rule deleted successfully",
+	})
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/chaos"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ChaosHandler handles admin requests that inject faults into external dependencies. Faults
+// apply to the process serving the request; workers take theirs from CHAOS_FAULTS.
+type ChaosHandler struct{}
+
+// NewChaosHandler creates a new chaos handler
+func NewChaosHandler() *ChaosHandler {
+	return &ChaosHandler{}
+}
+
+// SetFaultRequest represents a fault to inject into a target
+type SetFaultRequest struct {
+	Kind      string  `json:"kind"`
+	Rate      float64 `json:"rate"`
+	DelayMS   int     `json:"delay_ms"`
+	Remaining int     `json:"remaining"`
+	// DurationSeconds clears the fault after this long; 0 keeps it until cleared
+	DurationSeconds int `json:"duration_seconds"`
+}
+
+// List handles listing the faults in effect
+func (h *ChaosHandler) List(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"faults": chaos.List(),
+	})
+}
+
+// Set handles injecting a fault into a target (qdrant, openai or s3), replacing its previous one
+func (h *ChaosHandler) Set(c *fiber.Ctx) error {
+	var req SetFaultRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if req.DurationSeconds < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "duration_seconds can't be negative",
+		})
+	}
+
+	fault := chaos.Fault{
+		Target:    c.Params("target"),
+		Kind:      req.Kind,
+		Rate:      req.Rate,
+		DelayMS:   req.DelayMS,
+		Remaining: req.Remaining,
+	}
+	if req.DurationSeconds > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.DurationSeconds) * time.Second)
+		fault.ExpiresAt = &expiresAt
+	}
+	if err := chaos.Set(fault); err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"fault": fault,
+	})
+}
+
+// Clear handles removing a target's fault
+func (h *ChaosHandler) Clear(c *fiber.Ctx) error {
+	chaos.Clear(c.Params("target"))
+
+	return c.JSON(fiber.Map{
+		"message": "fault cleared successfully",
+	})
+}
+
+// ClearAll handles removing every fault
+func (h *ChaosHandler) ClearAll(c *fiber.Ctx) error {
+	chaos.Clear("")
+
+	return c.JSON(fiber.Map{
+		"message": "faults cleared successfully",
+	})
+}
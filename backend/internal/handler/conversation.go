@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConversationHandler handles conversation requests
+type ConversationHandler struct {
+	conversationService *service.ConversationService
+}
+
+// NewConversationHandler creates a new conversation handler
+func NewConversationHandler(conversationService *service.ConversationService) *ConversationHandler {
+	return &ConversationHandler{conversationService: conversationService}
+}
+
+// Create handles starting a conversation with locked settings
+func (h *ConversationHandler) Create(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.ConversationSettings
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	conversation, err := h.conversationService.Create(c.Context(), userID, req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"conversation": conversation,
+	})
+}
+
+// UpdateSettings handles updating a conversation's model, temperature and retrieval scope
+func (h *ConversationHandler) UpdateSettings(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.ConversationSettings
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	conversation, err := h.conversationService.UpdateSettings(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"conversation": conversation,
+	})
+}
+
+// List handles listing conversations; with ?q= it performs semantic search instead
+func (h *ConversationHandler) List(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if q := c.Query("q"); q != "" {
+		conversations, err := h.conversationService.Search(c.Context(), userID, q, c.QueryInt("limit", 10))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to search conversations",
+			})
+		}
+		return c.JSON(fiber.Map{
+			"conversations": conversations,
+		})
+	}
+
+	conversations, err := h.conversationService.List(c.Context(), userID, c.QueryInt("limit", 20), c.QueryInt("offset", 0))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list conversations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"conversations": conversations,
+	})
+}
+
+// Get handles getting a conversation with its messages
+func (h *ConversationHandler) Get(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	conversation, err := h.conversationService.Get(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"conversation": conversation,
+	})
+}
+
+// Branches handles listing a conversation's branches
+func (h *ConversationHandler) Branches(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	branches, err := h.conversationService.Branches(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"branches": branches,
+	})
+}
+
+// SwitchBranch handles showing the branch through a message of a conversation
+func (h *ConversationHandler) SwitchBranch(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req struct {
+		MessageID string `json:"message_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	conversation, err := h.conversationService.SwitchBranch(c.Context(), userID, c.Params("id"), req.MessageID)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"conversation": conversation,
+	})
+}
+
+// Export handles downloading a conversation as Markdown, JSON or an Anki deck (?format=md|json|anki).
+// ?messages= takes comma-separated message IDs to export only those exchanges.
+func (h *ConversationHandler) Export(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	format := c.Query("format", service.ExportFormatMarkdown)
+	if format != service.ExportFormatMarkdown && format != service.ExportFormatJSON && format != service.ExportFormatAnki {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be md, json or anki",
+		})
+	}
+
+	var messageIDs []string
+	if messages := c.Query("messages"); messages != "" {
+		messageIDs = strings.Split(messages, ",")
+	}
+
+	export, err := h.conversationService.Export(c.Context(), userID, c.Params("id"), format, messageIDs)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	c.Attachment(export.Filename)
+	c.Set(fiber.HeaderContentType, export.ContentType)
+	return c.Send(export.Content)
+}
+
+// Delete handles deleting a conversation
+func (h *ConversationHandler) Delete(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.conversationService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "conversation deleted successfully",
+	})
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CrawlHandler handles website crawl requests
+type CrawlHandler struct {
+	crawlService *service.CrawlService
+}
+
+// NewCrawlHandler creates a new crawl handler
+func NewCrawlHandler(crawlService *service.CrawlService) *CrawlHandler {
+	return &CrawlHandler{crawlService: crawlService}
+}
+
+// Create handles starting a crawl; it runs in the background and is polled for progress
+func (h *CrawlHandler) Create(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.CreateCrawlInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	crawl, err := h.crawlService.Start(c.Context(), userID, req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"crawl": crawl,
+	})
+}
+
+// List handles listing crawls, newest first
+func (h *CrawlHandler) List(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	crawls, err := h.crawlService.List(c.Context(), userID, c.QueryInt("limit", 20), c.QueryInt("offset", 0))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list crawls",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"crawls": crawls,
+	})
+}
+
+// Get handles getting a crawl with its progress
+func (h *CrawlHandler) Get(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	crawl, err := h.crawlService.Get(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"crawl": crawl,
+	})
+}
+
+// Cancel handles stopping a queued or running crawl
+func (h *CrawlHandler) Cancel(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	err := h.crawlService.Cancel(c.Context(), userID, c.Params("id"))
+	if errors.Is(err, repository.ErrCrawlFinished) {
+		return errorResponse(c, err, fiber.StatusConflict)
+	}
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "crawl cancelled successfully",
+	})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/discord"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DiscordHandler handles Discord interactions and linking users to the Discord bot
+type DiscordHandler struct {
+	discordService *service.DiscordService
+}
+
+// NewDiscordHandler creates a new Discord handler
+func NewDiscordHandler(discordService *service.DiscordService) *DiscordHandler {
+	return &DiscordHandler{discordService: discordService}
+}
+
+// Interactions handles slash command interactions sent by Discord. The request is
+// authenticated by its signature, not a user token.
+func (h *DiscordHandler) Interactions(c *fiber.Ctx) error {
+	if !h.discordService.Enabled() {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	body := c.Body()
+	if !h.discordService.VerifyInteraction(c.Get("X-Signature-Ed25519"), c.Get("X-Signature-Timestamp"), body) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "invalid request signature",
+		})
+	}
+
+	var interaction discord.Interaction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	return c.JSON(h.discordService.HandleInteraction(c.Context(), &interaction))
+}
+
+// Get handles getting the bot status and the user's linked account
+func (h *DiscordHandler) Get(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	status, err := h.discordService.Status(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get discord status",
+		})
+	}
+
+	return c.JSON(status)
+}
+
+// CreateLinkCode handles issuing a code the user enters with /link in Discord
+func (h *DiscordHandler) CreateLinkCode(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if !h.discordService.Enabled() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "discord bot is not configured",
+		})
+	}
+
+	code, err := h.discordService.CreateLinkCode(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create link code",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(code)
+}
+
+// Unlink handles unlinking the user's Discord account
+func (h *DiscordHandler) Unlink(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.discordService.Unlink(c.Context(), userID); err != nil {
+		if errors.Is(err, repository.ErrDiscordLinkNotFound) {
+			return errorResponse(c, err, fiber.StatusNotFound)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to unlink discord account",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "discord account unlinked",
+	})
+}
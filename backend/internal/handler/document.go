@@ -1,7 +1,13 @@
 package handler
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
 	"github.com/gofiber/fiber/v2"
 )
@@ -9,11 +15,15 @@ import (
 // DocumentHandler handles document requests
 type DocumentHandler struct {
 	documentService *service.DocumentService
+	auditService    *service.AuditService
 }
 
 // NewDocumentHandler creates a new document handler
-func NewDocumentHandler(documentService *service.DocumentService) *DocumentHandler {
-	return &DocumentHandler{documentService: documentService}
+func NewDocumentHandler(documentService *service.DocumentService, auditService *service.AuditService) *DocumentHandler {
+	return &DocumentHandler{
+		documentService: documentService,
+		auditService:    auditService,
+	}
 }
 
 // Upload handles document upload
@@ -33,12 +43,16 @@ func (h *DocumentHandler) Upload(c *fiber.Ctx) error {
 		})
 	}
 
-	// Process document
-	doc, err := h.documentService.UploadDocument(c.Context(), userID, file)
+	// Optional chunking overrides, checked before the file is stored
+	chunking, err := chunkingOptions(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	// Process document (optional ingestion profile, e.g. "meeting")
+	doc, err := h.documentService.UploadDocument(c.Context(), userID, file, c.FormValue("profile"), chunking)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -47,6 +61,57 @@ func (h *DocumentHandler) Upload(c *fiber.Ctx) error {
 	})
 }
 
+// DryRun handles previewing an upload's chunks, token counts and embedding cost without
+// embedding or storing it. It takes the same form fields as Upload.
+func (h *DocumentHandler) DryRun(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "no file uploaded",
+		})
+	}
+
+	chunking, err := chunkingOptions(c)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	result, err := h.documentService.DryRunDocument(c.Context(), userID, file, c.FormValue("profile"), chunking)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(result)
+}
+
+// chunkingOptions reads an upload's optional chunking overrides: the strategy ("chunking":
+// "fixed" or "semantic"), "chunk_size" and "overlap", both in tokens
+func chunkingOptions(c *fiber.Ctx) (service.ChunkingOptions, error) {
+	options := service.ChunkingOptions{Strategy: c.FormValue("chunking")}
+	if value := c.FormValue("chunk_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return options, fmt.Errorf("chunk_size must be a number of tokens")
+		}
+		options.ChunkSize = size
+	}
+	if value := c.FormValue("overlap"); value != "" {
+		overlap, err := strconv.Atoi(value)
+		if err != nil {
+			return options, fmt.Errorf("overlap must be a number of tokens")
+		}
+		options.Overlap = &overlap
+	}
+	return options, options.Validate()
+}
+
 // List handles listing user documents
 func (h *DocumentHandler) List(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -86,9 +151,7 @@ func (h *DocumentHandler) Get(c *fiber.Ctx) error {
 
 	doc, err := h.documentService.GetDocument(c.Context(), userID, documentID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return errorResponse(c, err, fiber.StatusNotFound)
 	}
 
 	return c.JSON(fiber.Map{
@@ -96,6 +159,33 @@ func (h *DocumentHandler) Get(c *fiber.Ctx) error {
 	})
 }
 
+// Download handles downloading a document's original file
+func (h *DocumentHandler) Download(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	documentID := c.Params("id")
+	doc, file, err := h.documentService.OpenDocument(c.Context(), userID, documentID)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	recordAudit(c, h.auditService, userID, service.AuditActionDocumentDownload, documentID)
+	if doc.Canary {
+		event := newAuditEvent(c, userID, service.AuditActionCanaryAccess, documentID)
+		if err := h.auditService.RecordCanaryAccess(c.Context(), event, doc.Filename, "download"); err != nil {
+			logger.Error("Failed to record canary access", "user_id", userID, "document_id", documentID, "error", err)
+		}
+	}
+
+	c.Attachment(doc.Filename)
+	return c.SendStream(file)
+}
+
 // Delete handles deleting a document
 func (h *DocumentHandler) Delete(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -113,12 +203,165 @@ func (h *DocumentHandler) Delete(c *fiber.Ctx) error {
 	}
 
 	if err := h.documentService.DeleteDocument(c.Context(), userID, documentID); err != nil {
+		return errorResponse(c, err, fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "document deleted successfully",
+	})
+}
+
+// ListPinned handles listing pinned documents
+func (h *DocumentHandler) ListPinned(c *fiber.Ctx) error {
+	return h.listFlagged(c, h.documentService.ListPinnedDocuments)
+}
+
+// ListFavorites handles listing favorite documents
+func (h *DocumentHandler) ListFavorites(c *fiber.Ctx) error {
+	return h.listFlagged(c, h.documentService.ListFavoriteDocuments)
+}
+
+// ListWarranties handles listing warranties extracted from manuals and warranty documents.
+// ?status=active or ?status=expired filters by today's date.
+func (h *DocumentHandler) ListWarranties(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	status := c.Query("status")
+	if status != "" && status != service.WarrantyActive && status != service.WarrantyExpired {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "status must be active or expired",
+		})
+	}
+
+	warranties, err := h.documentService.ListWarranties(c.Context(), userID, status)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
+			"error": "failed to list warranties",
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "document deleted successfully",
+		"warranties": warranties,
+	})
+}
+
+// ListSecrets handles listing the credentials found in the user's documents during ingestion
+func (h *DocumentHandler) ListSecrets(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	findings, err := h.documentService.ListSecretFindings(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list secret findings",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"findings": findings,
+	})
+}
+
+// Pin handles pinning a document
+func (h *DocumentHandler) Pin(c *fiber.Ctx) error {
+	return h.setFlag(c, h.documentService.SetPinned, true)
+}
+
+// Unpin handles unpinning a document
+func (h *DocumentHandler) Unpin(c *fiber.Ctx) error {
+	return h.setFlag(c, h.documentService.SetPinned, false)
+}
+
+// Favorite handles marking a document as favorite
+func (h *DocumentHandler) Favorite(c *fiber.Ctx) error {
+	return h.setFlag(c, h.documentService.SetFavorite, true)
+}
+
+// Unfavorite handles removing a document from favorites
+func (h *DocumentHandler) Unfavorite(c *fiber.Ctx) error {
+	return h.setFlag(c, h.documentService.SetFavorite, false)
+}
+
+// MarkCanary handles turning a document into a canary
+func (h *DocumentHandler) MarkCanary(c *fiber.Ctx) error {
+	return h.setFlag(c, h.documentService.SetCanary, true)
+}
+
+// UnmarkCanary handles turning a canary back into a regular document
+func (h *DocumentHandler) UnmarkCanary(c *fiber.Ctx) error {
+	return h.setFlag(c, h.documentService.SetCanary, false)
+}
+
+func (h *DocumentHandler) listFlagged(c *fiber.Ctx, list func(ctx context.Context, userID string) ([]*model.Document, error)) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	documents, err := list(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list documents",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"documents": documents,
+	})
+}
+
+func (h *DocumentHandler) setFlag(c *fiber.Ctx, set func(ctx context.Context, userID, documentID string, value bool) error, value bool) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	documentID := c.Params("id")
+	if documentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "document ID is required",
+		})
+	}
+
+	if err := set(c.Context(), userID, documentID, value); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "document updated successfully",
+	})
+}
+
+// UpcomingTravel handles listing bookings from travel documents for trips that haven't ended yet
+func (h *DocumentHandler) UpcomingTravel(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	bookings, err := h.documentService.UpcomingTravel(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list upcoming travel",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"bookings": bookings,
 	})
 }
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/gofiber/fiber/v2"
+)
+
+// errorStatus maps an error's kind to its HTTP status, or fallback for errors of no known kind
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, apperror.ErrNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, apperror.ErrQuotaExceeded):
+		return fiber.StatusRequestEntityTooLarge
+	case errors.Is(err, apperror.ErrUnsupportedFileType):
+		return fiber.StatusUnsupportedMediaType
+	case errors.Is(err, apperror.ErrProviderUnavailable):
+		return fiber.StatusServiceUnavailable
+	}
+	return fallback
+}
+
+// errorResponse responds with err's message and the status of its kind, or fallback
+func errorResponse(c *fiber.Ctx, err error, fallback int) error {
+	return c.Status(errorStatus(err, fallback)).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
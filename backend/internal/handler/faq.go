@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// FAQHandler handles personal FAQ requests
+type FAQHandler struct {
+	faqService *service.FAQService
+}
+
+// NewFAQHandler creates a new FAQ handler
+func NewFAQHandler(faqService *service.FAQService) *FAQHandler {
+	return &FAQHandler{faqService: faqService}
+}
+
+// List handles listing the user's frequently asked questions with their best answers
+func (h *FAQHandler) List(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	page, err := h.faqService.List(c.Context(), userID, c.QueryInt("limit", 20), c.QueryInt("offset", 0))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list FAQ",
+		})
+	}
+
+	return c.JSON(page)
+}
+
+// Delete handles removing an FAQ entry
+func (h *FAQHandler) Delete(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.faqService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "FAQ entry deleted successfully",
+	})
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// FlashcardHandler handles flashcard and study review requests
+type FlashcardHandler struct {
+	flashcardService *service.FlashcardService
+}
+
+// NewFlashcardHandler creates a new flashcard handler
+func NewFlashcardHandler(flashcardService *service.FlashcardService) *FlashcardHandler {
+	return &FlashcardHandler{flashcardService: flashcardService}
+}
+
+// GenerateFlashcardsRequest represents a request to generate flashcards from a document
+type GenerateFlashcardsRequest struct {
+	DocumentID string `json:"document_id"`
+	Count      int    `json:"count"`
+}
+
+// ReviewFlashcardRequest represents a graded review of a flashcard
+type ReviewFlashcardRequest struct {
+	// Grade is the SM-2 recall grade: 0 (forgot) to 5 (perfect); 3 or higher counts as remembered
+	Grade *int `json:"grade"`
+}
+
+// Create handles adding a flashcard written by the user
+func (h *FlashcardHandler) Create(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.FlashcardInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	flashcard, err := h.flashcardService.Create(c.Context(), userID, req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"flashcard": flashcard,
+	})
+}
+
+// Generate handles generating flashcards from a document
+func (h *FlashcardHandler) Generate(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req GenerateFlashcardsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	flashcards, err := h.flashcardService.Generate(c.Context(), userID, req.DocumentID, req.Count)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"flashcards": flashcards,
+	})
+}
+
+// List handles listing flashcards, optionally filtered by ?document_id=
+func (h *FlashcardHandler) List(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	flashcards, err := h.flashcardService.List(c.Context(), userID, c.Query("document_id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list flashcards",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"flashcards": flashcards,
+	})
+}
+
+// Due handles listing the flashcards due for review (?limit=, at most 100)
+func (h *FlashcardHandler) Due(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	flashcards, err := h.flashcardService.Due(c.Context(), userID, c.QueryInt("limit", 0))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list due flashcards",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"flashcards": flashcards,
+	})
+}
+
+// Review handles grading a flashcard review and returns the card with its next due date
+func (h *FlashcardHandler) Review(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req ReviewFlashcardRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if req.Grade == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "grade is required",
+		})
+	}
+
+	flashcard, err := h.flashcardService.Review(c.Context(), userID, c.Params("id"), *req.Grade)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"flashcard": flashcard,
+	})
+}
+
+// Stats handles reporting card counts, review streaks and retention (?tz= sets the day boundary)
+func (h *FlashcardHandler) Stats(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	stats, err := h.flashcardService.Stats(c.Context(), userID, c.Query("tz"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"stats": stats,
+	})
+}
+
+// Export handles downloading flashcards as an Anki deck in Anki's CSV import format
+func (h *FlashcardHandler) Export(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.FlashcardExportRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	content, err := h.flashcardService.ExportAnki(c.Context(), userID, req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	c.Attachment("flashcards-anki.csv")
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	return c.Send(content)
+}
+
+// Delete handles deleting a flashcard
+func (h *FlashcardHandler) Delete(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.flashcardService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "flashcard deleted successfully",
+	})
+}
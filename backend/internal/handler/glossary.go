@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GlossaryHandler handles glossary requests
+type GlossaryHandler struct {
+	glossaryService *service.GlossaryService
+}
+
+// NewGlossaryHandler creates a new glossary handler
+func NewGlossaryHandler(glossaryService *service.GlossaryService) *GlossaryHandler {
+	return &GlossaryHandler{glossaryService: glossaryService}
+}
+
+// Create handles adding a glossary term
+func (h *GlossaryHandler) Create(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.GlossaryTermInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	term, err := h.glossaryService.Create(c.Context(), userID, req)
+	if errors.Is(err, repository.ErrDuplicateGlossaryTerm) {
+		return errorResponse(c, err, fiber.StatusConflict)
+	}
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"term": term,
+	})
+}
+
+// List handles listing glossary terms
+func (h *GlossaryHandler) List(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	terms, err := h.glossaryService.List(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list glossary terms",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"terms": terms,
+	})
+}
+
+// Suggest handles listing frequent acronyms in the user's documents that have no glossary entry
+func (h *GlossaryHandler) Suggest(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	suggestions, err := h.glossaryService.Suggest(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to suggest glossary terms",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"suggestions": suggestions,
+	})
+}
+
+// Update handles updating a glossary term
+func (h *GlossaryHandler) Update(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.GlossaryTermInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	term, err := h.glossaryService.Update(c.Context(), userID, c.Params("id"), req)
+	if errors.Is(err, repository.ErrDuplicateGlossaryTerm) {
+		return errorResponse(c, err, fiber.StatusConflict)
+	}
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"term": term,
+	})
+}
+
+// Delete handles deleting a glossary term
+func (h *GlossaryHandler) Delete(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.glossaryService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "glossary term deleted successfully",
+	})
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MailLogHandler handles the admin view of sent email
+type MailLogHandler struct {
+	mailLogService *service.MailLogService
+}
+
+// NewMailLogHandler creates a new mail log handler
+func NewMailLogHandler(mailLogService *service.MailLogService) *MailLogHandler {
+	return &MailLogHandler{mailLogService: mailLogService}
+}
+
+// List handles listing recent emails across all users (?status=failed shows only failures)
+func (h *MailLogHandler) List(c *fiber.Ctx) error {
+	entries, err := h.mailLogService.List(c.Context(), c.Query("status"), c.QueryInt("limit", 100))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list mail log",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"emails": entries,
+	})
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MatrixHandler handles linking users to the Matrix bot
+type MatrixHandler struct {
+	matrixService *service.MatrixService
+}
+
+// NewMatrixHandler creates a new Matrix handler
+func NewMatrixHandler(matrixService *service.MatrixService) *MatrixHandler {
+	return &MatrixHandler{matrixService: matrixService}
+}
+
+// MatrixLinkRequest is the body of PUT /api/matrix/link
+type MatrixLinkRequest struct {
+	MatrixUserID string `json:"matrix_user_id"`
+}
+
+// Get handles getting the bot status and the user's linked room
+func (h *MatrixHandler) Get(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	status, err := h.matrixService.Status(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get matrix status",
+		})
+	}
+
+	return c.JSON(status)
+}
+
+// Link handles linking a Matrix account; the bot invites it to a new private room
+func (h *MatrixHandler) Link(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if !h.matrixService.Enabled() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "matrix bot is not configured",
+		})
+	}
+
+	var req MatrixLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	room, err := h.matrixService.Link(c.Context(), userID, req.MatrixUserID)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"room": room,
+	})
+}
+
+// Unlink handles unlinking the user's Matrix account
+func (h *MatrixHandler) Unlink(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if !h.matrixService.Enabled() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "matrix bot is not configured",
+		})
+	}
+
+	if err := h.matrixService.Unlink(c.Context(), userID); err != nil {
+		if errors.Is(err, repository.ErrMatrixRoomNotFound) {
+			return errorResponse(c, err, fiber.StatusNotFound)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to unlink matrix account",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "matrix account unlinked",
+	})
+}
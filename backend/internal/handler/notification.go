@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationHandler handles notification preference requests
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// SetTargetRequest represents setting a channel target
+type SetTargetRequest struct {
+	Target string `json:"target"`
+}
+
+// SetRouteRequest represents setting the channels of an event
+type SetRouteRequest struct {
+	Channels []string `json:"channels"`
+}
+
+// Get handles returning the user's notification targets and routes
+func (h *NotificationHandler) Get(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	preferences, err := h.notificationService.Preferences(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get notification preferences",
+		})
+	}
+
+	return c.JSON(preferences)
+}
+
+// SetTarget handles setting the Telegram chat or push topic the user receives notifications on
+func (h *NotificationHandler) SetTarget(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req SetTargetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err := h.notificationService.SetTarget(c.Context(), userID, c.Params("channel"), req.Target); err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "notification target saved",
+	})
+}
+
+// DeleteTarget handles removing a channel target
+func (h *NotificationHandler) DeleteTarget(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.notificationService.DeleteTarget(c.Context(), userID, c.Params("channel")); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete notification target",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "notification target deleted",
+	})
+}
+
+// SetRoute handles choosing the channels an event is delivered through ("*" for other events)
+func (h *NotificationHandler) SetRoute(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req SetRouteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err := h.notificationService.SetRoute(c.Context(), userID, c.Params("event"), req.Channels); err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "notification route saved",
+	})
+}
+
+// DeleteRoute handles removing an event's route
+func (h *NotificationHandler) DeleteRoute(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.notificationService.DeleteRoute(c.Context(), userID, c.Params("event")); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete notification route",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "notification route deleted",
+	})
+}
+
+// Test handles sending a test notification through one channel
+func (h *NotificationHandler) Test(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.notificationService.Test(c.Context(), userID, c.Params("channel")); err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "test notification sent",
+	})
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// OnboardingHandler handles first-run onboarding requests
+type OnboardingHandler struct {
+	onboardingService *service.OnboardingService
+}
+
+// NewOnboardingHandler creates a new onboarding handler
+func NewOnboardingHandler(onboardingService *service.OnboardingService) *OnboardingHandler {
+	return &OnboardingHandler{onboardingService: onboardingService}
+}
+
+// OnboardingRequest represents a request to run onboarding, sent as JSON or, with a sample
+// file, as a multipart form
+type OnboardingRequest struct {
+	Demo     string `json:"demo" form:"demo"`
+	Question string `json:"question" form:"question"`
+}
+
+// Demos handles listing the bundled onboarding demos
+func (h *OnboardingHandler) Demos(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"demos": h.onboardingService.Demos(),
+	})
+}
+
+// Start handles indexing a sample (an uploaded "file" or a bundled demo) and answering a first
+// question about it
+func (h *OnboardingHandler) Start(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req OnboardingRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	// The file is optional; without one the demo is indexed
+	file, _ := c.FormFile("file")
+
+	result, err := h.onboardingService.Start(c.Context(), userID, service.OnboardingRequest{
+		File:     file,
+		Demo:     req.Demo,
+		Question: req.Question,
+	})
+	if errors.Is(err, service.ErrUnknownOnboardingDemo) {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusInternalServerError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PortabilityHandler handles account export and import requests
+type PortabilityHandler struct {
+	portabilityService *service.PortabilityService
+	auditService       *service.AuditService
+}
+
+// NewPortabilityHandler creates a new portability handler
+func NewPortabilityHandler(portabilityService *service.PortabilityService, auditService *service.AuditService) *PortabilityHandler {
+	return &PortabilityHandler{portabilityService: portabilityService, auditService: auditService}
+}
+
+// Export handles downloading the user's account data; files=true includes each document's
+// original file
+func (h *PortabilityHandler) Export(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	export, err := h.portabilityService.Export(c.Context(), userID, c.QueryBool("files"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusInternalServerError)
+	}
+	recordAudit(c, h.auditService, userID, service.AuditActionAccountExport, "")
+
+	c.Attachment("account-export-" + time.Now().UTC().Format("2006-01-02") + ".json")
+	return c.JSON(export)
+}
+
+// Schema handles returning the JSON Schema documenting the export format
+func (h *PortabilityHandler) Schema(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, "application/schema+json")
+	return c.Send(service.AccountExportSchema)
+}
+
+// Import handles adding an account export's data to the user's account
+func (h *PortabilityHandler) Import(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var export service.AccountExport
+	if err := c.BodyParser(&export); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if err := service.ValidateAccountExport(&export); err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	result, err := h.portabilityService.Import(c.Context(), userID, &export)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(result)
+}
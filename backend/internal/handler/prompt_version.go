@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PromptVersionHandler handles the admin view of prompt versions and their canary rollout
+type PromptVersionHandler struct {
+	promptVersionService *service.PromptVersionService
+}
+
+// NewPromptVersionHandler creates a new prompt version handler
+func NewPromptVersionHandler(promptVersionService *service.PromptVersionService) *PromptVersionHandler {
+	return &PromptVersionHandler{promptVersionService: promptVersionService}
+}
+
+// List handles listing prompt versions
+func (h *PromptVersionHandler) List(c *fiber.Ctx) error {
+	versions, err := h.promptVersionService.List(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list prompt versions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"versions": versions,
+	})
+}
+
+// Create handles adding a prompt version
+func (h *PromptVersionHandler) Create(c *fiber.Ctx) error {
+	var req service.PromptVersionInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	version, err := h.promptVersionService.Create(c.Context(), req)
+	if errors.Is(err, repository.ErrDuplicatePromptVersion) {
+		return errorResponse(c, err, fiber.StatusConflict)
+	}
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"version": version,
+	})
+}
+
+// SetTraffic handles changing the share of queries routed to a prompt version
+func (h *PromptVersionHandler) SetTraffic(c *fiber.Ctx) error {
+	var req struct {
+		Percent int `json:"percent"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	version, err := h.promptVersionService.SetTraffic(c.Context(), c.Params("id"), req.Percent)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"version": version,
+	})
+}
+
+// Delete handles deleting a prompt version
+func (h *PromptVersionHandler) Delete(c *fiber.Ctx) error {
+	if err := h.promptVersionService.Delete(c.Context(), c.Params("id")); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "prompt version deleted successfully",
+	})
+}
+
+// Compare handles comparing feedback, latency and cost of the default prompt and each version
+// over the last days (?days=, default 7)
+func (h *PromptVersionHandler) Compare(c *fiber.Ctx) error {
+	comparison, err := h.promptVersionService.Compare(c.Context(), c.QueryInt("days", 7))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to compare prompt versions",
+		})
+	}
+
+	return c.JSON(comparison)
+}
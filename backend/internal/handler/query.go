@@ -1,24 +1,55 @@
 package handler
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
 	"github.com/gofiber/fiber/v2"
 )
 
 // QueryHandler handles query requests
 type QueryHandler struct {
-	ragService *service.RAGService
+	ragService    *service.RAGService
+	speechService *service.SpeechService
 }
 
 // NewQueryHandler creates a new query handler
-func NewQueryHandler(ragService *service.RAGService) *QueryHandler {
-	return &QueryHandler{ragService: ragService}
+func NewQueryHandler(ragService *service.RAGService, speechService *service.SpeechService) *QueryHandler {
+	return &QueryHandler{
+		ragService:    ragService,
+		speechService: speechService,
+	}
 }
 
 // QueryRequest represents a query request
 type QueryRequest struct {
-	Question string `json:"question" validate:"required"`
+	Question       string            `json:"question" validate:"required"`
+	Filters        map[string]string `json:"filters"`
+	ConversationID string            `json:"conversation_id"`
+	Agent          bool              `json:"agent"`
+	MaxIterations  int               `json:"max_iterations"`
+	Model          string            `json:"model"`
+	Temperature    *float64          `json:"temperature"`
+	Language       string            `json:"language"`
+	Diversity      float64           `json:"diversity"`
+	Mode           string            `json:"mode"`
+	ClauseType     string            `json:"clause_type"`
+	// Exclude and ExcludeDocumentIDs omit content; "-key:value" in the question works too
+	Exclude            map[string][]string `json:"exclude"`
+	ExcludeDocumentIDs []string            `json:"exclude_document_ids"`
+	Warranty           string              `json:"warranty"`
+	// Pipeline overrides the configured stage implementations (e.g. {"rerank": "llm"})
+	Pipeline map[string]string `json:"pipeline"`
+	// Explain returns why each source was retrieved
+	Explain bool `json:"explain"`
 }
 
 // Query handles RAG queries
@@ -43,18 +74,172 @@ func (h *QueryHandler) Query(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.Diversity < 0 || req.Diversity > 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "diversity must be between 0 and 1",
+		})
+	}
+
+	if req.Mode != "" && req.Mode != service.QueryModeChunks && req.Mode != service.QueryModeDocuments {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "mode must be chunks or documents",
+		})
+	}
+
+	if req.Warranty != "" && req.Warranty != service.WarrantyActive && req.Warranty != service.WarrantyExpired {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "warranty must be active or expired",
+		})
+	}
+
 	// Perform RAG query
-	response, err := h.ragService.Query(c.Context(), userID, req.Question)
+	response, err := h.ragService.Query(c.Context(), userID, req.serviceRequest())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
+		return errorResponse(c, err, fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response)
+}
+
+// EditMessage handles editing a question of a conversation and answering it on a new branch.
+// The body takes the same options as a query; an empty question regenerates the answer.
+func (h *QueryHandler) EditMessage(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
 		})
 	}
 
+	var req QueryRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	response, err := h.ragService.EditMessage(c.Context(), userID, c.Params("id"), c.Params("messageId"), req.serviceRequest())
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusInternalServerError)
+	}
+
 	return c.JSON(response)
 }
 
-// StreamQuery handles streaming RAG queries
+// serviceRequest converts the request into a RAG query request
+func (req QueryRequest) serviceRequest() service.QueryRequest {
+	return service.QueryRequest{
+		Question:           req.Question,
+		Filters:            req.Filters,
+		ConversationID:     req.ConversationID,
+		Agent:              req.Agent,
+		MaxIterations:      req.MaxIterations,
+		Model:              req.Model,
+		Temperature:        req.Temperature,
+		Language:           req.Language,
+		Diversity:          req.Diversity,
+		Mode:               req.Mode,
+		ClauseType:         req.ClauseType,
+		Exclude:            req.Exclude,
+		ExcludeDocumentIDs: req.ExcludeDocumentIDs,
+		Warranty:           req.Warranty,
+		Pipeline:           req.Pipeline,
+		Explain:            req.Explain,
+	}
+}
+
+// Quick handles launcher queries (Raycast, Alfred): GET ?q= returns a one or two sentence
+// answer and the top source titles as JSON, or as plain text with format=text
+func (h *QueryHandler) Quick(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	question := strings.TrimSpace(c.Query("q"))
+	if question == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "q is required",
+		})
+	}
+
+	format := c.Query("format", "json")
+	if format != "json" && format != "text" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be json or text",
+		})
+	}
+
+	answer, err := h.ragService.QuickQuery(c.Context(), userID, question)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{
+			"error": fmt.Sprintf("no answer within %s", service.QuickQueryTimeout),
+		})
+	}
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusInternalServerError)
+	}
+
+	if format == "text" {
+		text := answer.Answer
+		if len(answer.Sources) > 0 {
+			text += "\n\nSources: " + strings.Join(answer.Sources, ", ")
+		}
+		return c.SendString(text)
+	}
+	return c.JSON(answer)
+}
+
+// Search handles retrieval-only searches, returning the top-k chunks without calling the LLM
+func (h *QueryHandler) Search(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.SearchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "query is required",
+		})
+	}
+
+	if req.TopK < 0 || req.TopK > service.MaxSearchTopK {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("top_k must be between 1 and %d", service.MaxSearchTopK),
+		})
+	}
+
+	if req.Diversity < 0 || req.Diversity > 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "diversity must be between 0 and 1",
+		})
+	}
+
+	response, err := h.ragService.Search(c.Context(), userID, req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response)
+}
+
+// StreamQuery handles streaming RAG queries: GET ?q= answers as server-sent events, "start"
+// with the query history ID and sources, "delta" with each new piece of the answer, then "done"
+// or "error". When the client disconnects, generation stops and the answer so far is kept in
+// history, marked truncated, for GET /query/history/:id.
 func (h *QueryHandler) StreamQuery(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
@@ -63,8 +248,312 @@ func (h *QueryHandler) StreamQuery(c *fiber.Ctx) error {
 		})
 	}
 
-	// TODO: Implement streaming query with SSE
-	return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
-		"error": "streaming query not implemented yet",
+	question := strings.TrimSpace(c.Query("q"))
+	if question == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "q is required",
+		})
+	}
+
+	// Fiber reuses the request's buffers, and the stream outlives the handler
+	userID, question = strings.Clone(userID), strings.Clone(question)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The request context ends with the handler, so the stream gets its own, cancelled when
+		// a write to the client fails
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		send := func(event string, data interface{}) {
+			if ctx.Err() != nil {
+				return
+			}
+			payload, err := json.Marshal(data)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+			if err := w.Flush(); err != nil {
+				cancel()
+			}
+		}
+
+		sent := 0
+		result, err := h.ragService.StreamAnswer(ctx, userID, question, func(historyID string, sources []map[string]interface{}) {
+			send("start", fiber.Map{"id": historyID, "sources": sources})
+		}, func(answer string) {
+			send("delta", fiber.Map{"content": answer[sent:]})
+			sent = len(answer)
+		})
+		if err != nil {
+			send("error", fiber.Map{"error": err.Error()})
+			return
+		}
+		send("done", result)
+	})
+
+	return nil
+}
+
+// GetHistory handles fetching a single query history entry, such as a streamed answer the
+// client disconnected from
+func (h *QueryHandler) GetHistory(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	entry, err := h.ragService.GetHistory(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(entry)
+}
+
+// MarkHistoryRead handles the client confirming it received an answer
+func (h *QueryHandler) MarkHistoryRead(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.ragService.MarkHistoryRead(c.Context(), userID, c.Params("id")); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "query history entry updated successfully",
 	})
 }
+
+// History handles listing the user's query history.
+// Supports limit/offset pagination, from/to date filters (YYYY-MM-DD or RFC3339), q keyword search
+// and unread=true for answers the client hasn't confirmed receiving.
+func (h *QueryHandler) History(c *fiber.Ctx) error {
+	return h.listHistory(c, repository.QueryHistoryFilter{})
+}
+
+// ListPinnedHistory handles listing pinned query history entries
+func (h *QueryHandler) ListPinnedHistory(c *fiber.Ctx) error {
+	return h.listHistory(c, repository.QueryHistoryFilter{Pinned: true})
+}
+
+// ListFavoriteHistory handles listing favorite query history entries
+func (h *QueryHandler) ListFavoriteHistory(c *fiber.Ctx) error {
+	return h.listHistory(c, repository.QueryHistoryFilter{Favorite: true})
+}
+
+func (h *QueryHandler) listHistory(c *fiber.Ctx, filter repository.QueryHistoryFilter) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	filter.Search = c.Query("q")
+	filter.Unread = c.QueryBool("unread")
+	filter.Limit = c.QueryInt("limit", 20)
+	filter.Offset = c.QueryInt("offset", 0)
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+	filter.From = from
+	filter.To = to
+
+	page, err := h.ragService.ListHistory(c.Context(), userID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list query history",
+		})
+	}
+
+	return c.JSON(page)
+}
+
+// DeleteHistory handles deleting a single query history entry
+func (h *QueryHandler) DeleteHistory(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	historyID := c.Params("id")
+	if historyID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "history ID is required",
+		})
+	}
+
+	if err := h.ragService.DeleteHistory(c.Context(), userID, historyID); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "query history entry deleted successfully",
+	})
+}
+
+// PinHistory handles pinning a query history entry
+func (h *QueryHandler) PinHistory(c *fiber.Ctx) error {
+	return h.setHistoryFlag(c, h.ragService.SetHistoryPinned, true)
+}
+
+// UnpinHistory handles unpinning a query history entry
+func (h *QueryHandler) UnpinHistory(c *fiber.Ctx) error {
+	return h.setHistoryFlag(c, h.ragService.SetHistoryPinned, false)
+}
+
+// FavoriteHistory handles marking a query history entry as favorite
+func (h *QueryHandler) FavoriteHistory(c *fiber.Ctx) error {
+	return h.setHistoryFlag(c, h.ragService.SetHistoryFavorite, true)
+}
+
+// UnfavoriteHistory handles removing a query history entry from favorites
+func (h *QueryHandler) UnfavoriteHistory(c *fiber.Ctx) error {
+	return h.setHistoryFlag(c, h.ragService.SetHistoryFavorite, false)
+}
+
+func (h *QueryHandler) setHistoryFlag(c *fiber.Ctx, set func(ctx context.Context, userID, historyID string, value bool) error, value bool) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	historyID := c.Params("id")
+	if historyID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "history ID is required",
+		})
+	}
+
+	if err := set(c.Context(), userID, historyID, value); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "query history entry updated successfully",
+	})
+}
+
+// HistoryFeedbackRequest rates an answer
+type HistoryFeedbackRequest struct {
+	Helpful *bool `json:"helpful"`
+}
+
+// Feedback handles rating whether an answer was helpful, which prompt version comparisons report
+func (h *QueryHandler) Feedback(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req HistoryFeedbackRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if req.Helpful == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "helpful is required",
+		})
+	}
+
+	feedback := -1
+	if *req.Helpful {
+		feedback = 1
+	}
+	return h.setHistoryFeedback(c, userID, feedback)
+}
+
+// ClearFeedback handles removing the rating of an answer
+func (h *QueryHandler) ClearFeedback(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	return h.setHistoryFeedback(c, userID, 0)
+}
+
+func (h *QueryHandler) setHistoryFeedback(c *fiber.Ctx, userID string, feedback int) error {
+	if err := h.ragService.SetHistoryFeedback(c.Context(), userID, c.Params("id"), feedback); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "query history entry updated successfully",
+	})
+}
+
+// Audio handles reading a stored answer aloud
+func (h *QueryHandler) Audio(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	audio, contentType, err := h.speechService.AnswerAudio(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=86400")
+	return c.Send(audio)
+}
+
+// parseDateRange parses the optional from/to query parameters; a bare "to" date includes the whole day
+func parseDateRange(c *fiber.Ctx) (from, to *time.Time, err error) {
+	if value := c.Query("from"); value != "" {
+		t, err := parseDateParam(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid from date")
+		}
+		from = &t
+	}
+
+	if value := c.Query("to"); value != "" {
+		t, err := parseDateParam(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid to date")
+		}
+		if len(value) == len("2006-01-02") {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = &t
+	}
+
+	return from, to, nil
+}
+
+// parseDateParam parses a date query parameter in YYYY-MM-DD or RFC3339 format
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReportHandler handles report requests
+type ReportHandler struct {
+	reportService *service.ReportService
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(reportService *service.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// Expenses handles generating an expense report from ingested receipts as a CSV or PDF download
+func (h *ReportHandler) Expenses(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.ExpenseReportRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+	if req.Format != "" && req.Format != service.ReportFormatCSV && req.Format != service.ReportFormatPDF {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be csv or pdf",
+		})
+	}
+
+	report, err := h.reportService.ExpenseReport(c.Context(), userID, req, c.BaseURL())
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	c.Attachment(report.Filename)
+	c.Set(fiber.HeaderContentType, report.ContentType)
+	return c.Send(report.Content)
+}
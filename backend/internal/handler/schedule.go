@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ScheduleHandler handles the admin view of background job schedules
+type ScheduleHandler struct {
+	schedulerService *service.SchedulerService
+}
+
+// NewScheduleHandler creates a new schedule handler
+func NewScheduleHandler(schedulerService *service.SchedulerService) *ScheduleHandler {
+	return &ScheduleHandler{schedulerService: schedulerService}
+}
+
+// List handles listing all schedules
+func (h *ScheduleHandler) List(c *fiber.Ctx) error {
+	schedules, err := h.schedulerService.List(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list schedules",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"schedules": schedules,
+	})
+}
+
+// Update handles changing a schedule's cron expression, timezone, misfire policy or enabled state
+func (h *ScheduleHandler) Update(c *fiber.Ctx) error {
+	var req service.ScheduleInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	schedule, err := h.schedulerService.Update(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"schedule": schedule,
+	})
+}
+
+// ListRuns handles listing a schedule's run history
+func (h *ScheduleHandler) ListRuns(c *fiber.Ctx) error {
+	runs, err := h.schedulerService.ListRuns(c.Context(), c.Params("id"), c.QueryInt("limit", 20))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"runs": runs,
+	})
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ScheduledQueryHandler handles scheduled query requests
+type ScheduledQueryHandler struct {
+	scheduledService *service.ScheduledQueryService
+}
+
+// NewScheduledQueryHandler creates a new scheduled query handler
+func NewScheduledQueryHandler(scheduledService *service.ScheduledQueryService) *ScheduledQueryHandler {
+	return &ScheduledQueryHandler{scheduledService: scheduledService}
+}
+
+// Create handles creating a scheduled query
+func (h *ScheduledQueryHandler) Create(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.ScheduledQueryInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	sq, err := h.scheduledService.Create(c.Context(), userID, req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"scheduled_query": sq,
+	})
+}
+
+// List handles listing scheduled queries
+func (h *ScheduledQueryHandler) List(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	scheduled, err := h.scheduledService.List(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list scheduled queries",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"scheduled_queries": scheduled,
+	})
+}
+
+// Get handles getting a single scheduled query
+func (h *ScheduledQueryHandler) Get(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	sq, err := h.scheduledService.Get(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"scheduled_query": sq,
+	})
+}
+
+// Update handles updating a scheduled query
+func (h *ScheduledQueryHandler) Update(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req service.ScheduledQueryInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	sq, err := h.scheduledService.Update(c.Context(), userID, c.Params("id"), req)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"scheduled_query": sq,
+	})
+}
+
+// Delete handles deleting a scheduled query
+func (h *ScheduledQueryHandler) Delete(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if err := h.scheduledService.Delete(c.Context(), userID, c.Params("id")); err != nil {
+		return errorResponse(c, err, fiber.StatusNotFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "scheduled query deleted successfully",
+	})
+}
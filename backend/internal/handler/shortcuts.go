@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ShortcutsHandler serves simplified endpoints for Apple Shortcuts and similar automations.
+// Requests are form-encoded (or JSON) with an API key in the Authorization header, and
+// responses are flat objects whose fields won't change: a "text" field holds a ready-to-show
+// summary, and failures return {"error": "..."}.
+type ShortcutsHandler struct {
+	documentService *service.DocumentService
+	ragService      *service.RAGService
+}
+
+// NewShortcutsHandler creates a new Shortcuts handler
+func NewShortcutsHandler(documentService *service.DocumentService, ragService *service.RAGService) *ShortcutsHandler {
+	return &ShortcutsHandler{
+		documentService: documentService,
+		ragService:      ragService,
+	}
+}
+
+// ShortcutCaptureRequest is the body of POST /api/shortcuts/capture
+type ShortcutCaptureRequest struct {
+	Text  string `json:"text" form:"text"`
+	Title string `json:"title" form:"title"`
+}
+
+// ShortcutCaptureResponse is the response of POST /api/shortcuts/capture
+type ShortcutCaptureResponse struct {
+	DocumentID string `json:"document_id"`
+	Filename   string `json:"filename"`
+	Text       string `json:"text"`
+}
+
+// Capture saves text as a note document: text is required, title is optional
+func (h *ShortcutsHandler) Capture(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req ShortcutCaptureRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	doc, err := h.documentService.CaptureNote(c.Context(), userID, req.Title, req.Text)
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(ShortcutCaptureResponse{
+		DocumentID: doc.ID,
+		Filename:   doc.Filename,
+		Text:       "Saved " + doc.Filename,
+	})
+}
+
+// ShortcutAskRequest is the body of POST /api/shortcuts/ask
+type ShortcutAskRequest struct {
+	Question string `json:"question" form:"question"`
+}
+
+// ShortcutAskResponse is the response of POST /api/shortcuts/ask
+type ShortcutAskResponse struct {
+	Answer  string   `json:"answer"`
+	Sources []string `json:"sources"`
+	Text    string   `json:"text"`
+}
+
+// Ask answers a question with a short plain-text answer suited to being shown or spoken
+func (h *ShortcutsHandler) Ask(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req ShortcutAskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "question is required",
+		})
+	}
+
+	answer, err := h.ragService.QuickQuery(c.Context(), userID, question)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{
+			"error": fmt.Sprintf("no answer within %s", service.QuickQueryTimeout),
+		})
+	}
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusInternalServerError)
+	}
+
+	text := answer.Answer
+	if len(answer.Sources) > 0 {
+		text += "\n\nSources: " + strings.Join(answer.Sources, ", ")
+	}
+	return c.JSON(ShortcutAskResponse{
+		Answer:  answer.Answer,
+		Sources: answer.Sources,
+		Text:    text,
+	})
+}
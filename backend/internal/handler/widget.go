@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// WidgetHandler serves the embeddable "ask my docs" widget. It authenticates with a widget key
+// in the query string instead of the Authorization header, since it runs in a visitor's browser.
+type WidgetHandler struct {
+	widgetService *service.WidgetService
+}
+
+// NewWidgetHandler creates a new widget handler
+func NewWidgetHandler(widgetService *service.WidgetService) *WidgetHandler {
+	return &WidgetHandler{widgetService: widgetService}
+}
+
+// widgetPage is the self-contained widget: a question box that posts back to its own URL
+var widgetPage = template.Must(template.New("widget").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{margin:0;padding:12px;font:14px/1.5 system-ui,sans-serif;color:#1f2328;background:transparent}
+form{display:flex;gap:6px}
+input{flex:1;min-width:0;padding:8px;border:1px solid #d0d7de;border-radius:6px;font:inherit}
+button{padding:8px 14px;border:0;border-radius:6px;background:#1f6feb;color:#fff;font:inherit;cursor:pointer}
+button:disabled{opacity:.6;cursor:default}
+#answer{margin-top:10px;white-space:pre-wrap}
+#sources{margin-top:6px;color:#656d76;font-size:12px}
+</style>
+</head>
+<body>
+<form id="ask">
+<input id="question" name="question" maxlength="{{.MaxLength}}" placeholder="{{.Title}}" aria-label="{{.Title}}" required>
+<button id="submit" type="submit">Ask</button>
+</form>
+<div id="answer" aria-live="polite"></div>
+<div id="sources"></div>
+<script>
+document.getElementById("ask").addEventListener("submit", async function (e) {
+  e.preventDefault();
+  var button = document.getElementById("submit");
+  var answer = document.getElementById("answer");
+  var sources = document.getElementById("sources");
+  button.disabled = true;
+  answer.textContent = "Thinking…";
+  sources.textContent = "";
+  try {
+    var res = await fetch(location.pathname + location.search, {
+      method: "POST",
+      headers: {"Content-Type": "application/json"},
+      body: JSON.stringify({question: document.getElementById("question").value})
+    });
+    var data = await res.json();
+    if (!res.ok) {
+      answer.textContent = data.error || "Something went wrong.";
+      return;
+    }
+    answer.textContent = data.answer;
+    if (data.sources && data.sources.length) {
+      sources.textContent = "Sources: " + data.sources.join(", ");
+    }
+  } catch (err) {
+    answer.textContent = "Something went wrong.";
+  } finally {
+    button.disabled = false;
+  }
+});
+</script>
+</body>
+</html>
+`))
+
+// Page serves the widget HTML. The page may only be framed by the key's allowed origins, and a
+// request whose Referer is some other site is refused.
+func (h *WidgetHandler) Page(c *fiber.Ctx) error {
+	key, err := h.widgetService.Authorize(c.Context(), c.Query("key"), c.Get(fiber.HeaderReferer))
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).SendString(err.Error())
+	}
+
+	title := strings.TrimSpace(c.Query("title"))
+	if title == "" {
+		title = "Ask a question about my documents"
+	}
+
+	var buf bytes.Buffer
+	if err := widgetPage.Execute(&buf, fiber.Map{
+		"Title":     title,
+		"MaxLength": service.MaxWidgetQuestionLength,
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("failed to render widget")
+	}
+
+	c.Set(fiber.HeaderContentSecurityPolicy, fmt.Sprintf(
+		"default-src 'none'; style-src 'unsafe-inline'; script-src 'unsafe-inline'; connect-src 'self'; frame-ancestors %s",
+		strings.Join(key.AllowedOrigins, " ")))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(buf.Bytes())
+}
+
+// AskRequest represents a question asked through the widget, as JSON or a form post
+type AskRequest struct {
+	Question string `json:"question" form:"question"`
+}
+
+// Ask answers a question from the widget. Only the widget page itself may post here, so the
+// request's Origin must be this server.
+func (h *WidgetHandler) Ask(c *fiber.Ctx) error {
+	origin, err := url.Parse(c.Get(fiber.HeaderOrigin))
+	if err != nil || origin.Host == "" || !strings.EqualFold(origin.Host, c.Hostname()) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "origin not allowed",
+		})
+	}
+
+	key, err := h.widgetService.Authorize(c.Context(), c.Query("key"), "")
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusForbidden)
+	}
+
+	var req AskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "question is required",
+		})
+	}
+	if len(question) > service.MaxWidgetQuestionLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("question must be at most %d characters", service.MaxWidgetQuestionLength),
+		})
+	}
+
+	answer, err := h.widgetService.Ask(c.Context(), key, question)
+	if err != nil {
+		// Visitors see a generic message; the owner's logs keep the cause
+		logger.Error("Widget question failed", "key_id", key.ID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to answer question",
+		})
+	}
+
+	return c.JSON(answer)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/middleware"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// WorkspaceHandler handles workspace template requests
+type WorkspaceHandler struct {
+	workspaceService *service.WorkspaceService
+}
+
+// NewWorkspaceHandler creates a new workspace handler
+func NewWorkspaceHandler(workspaceService *service.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{workspaceService: workspaceService}
+}
+
+// FromTemplateRequest represents a request to set up a workspace from a template
+type FromTemplateRequest struct {
+	Template string `json:"template"`
+}
+
+// Templates handles listing the available workspace templates and what templates don't set up
+func (h *WorkspaceHandler) Templates(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"templates":    h.workspaceService.Templates(),
+		"not_included": service.WorkspaceTemplateOmissions,
+	})
+}
+
+// FromTemplate handles setting up the user's workspace from a template
+func (h *WorkspaceHandler) FromTemplate(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req FromTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	setup, err := h.workspaceService.FromTemplate(c.Context(), userID, req.Template)
+	if errors.Is(err, service.ErrUnknownWorkspaceTemplate) {
+		return errorResponse(c, err, fiber.StatusBadRequest)
+	}
+	if err != nil {
+		return errorResponse(c, err, fiber.StatusInternalServerError)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(setup)
+}
@@ -0,0 +1,194 @@
+package httpretry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+)
+
+// Breaker states
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// Circuit breaker parameters
+const (
+	// breakerThreshold is the number of consecutive failed calls that opens the breaker
+	breakerThreshold = 5
+	// breakerCooldown is how long an open breaker rejects calls before letting one through
+	breakerCooldown = 30 * time.Second
+)
+
+// breaker stops calls to a provider that keeps failing, so requests fail fast instead of
+// waiting through retries. After the cooldown a single trial call decides whether it closes.
+type breaker struct {
+	mu       sync.Mutex
+	name     string
+	state    string
+	failures int
+	openedAt time.Time
+	// trial is set while the half-open trial call is in flight
+	trial     bool
+	lastError string
+}
+
+// BreakerStatus reports a provider's breaker for health checks
+type BreakerStatus struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	// ConsecutiveFailures counts failed calls since the last success
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*breaker{}
+)
+
+// breakerFor returns the provider's breaker; clients of the same provider share it
+func breakerFor(name string) *breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	b, ok := breakers[name]
+	if !ok {
+		b = &breaker{name: name, state: StateClosed}
+		breakers[name] = b
+	}
+	return b
+}
+
+// Breaker is a provider's circuit breaker for calls that aren't plain HTTP requests, such as
+// those of an SDK that retries on its own
+type Breaker struct {
+	provider string
+	breaker  *breaker
+}
+
+// NewBreaker returns the named provider's breaker, shared with the provider's Clients
+func NewBreaker(provider string) *Breaker {
+	return &Breaker{provider: provider, breaker: breakerFor(provider)}
+}
+
+// Call runs fn unless the breaker is open. Errors providerFault reports true for count as failed
+// calls and are returned as apperror.ErrProviderUnavailable; other errors mean the provider
+// answered, so they count as successes like 4xx responses do for a Client.
+func (b *Breaker) Call(ctx context.Context, fn func() error, providerFault func(error) bool) error {
+	if !b.breaker.allow(time.Now()) {
+		return fmt.Errorf("%s: %w", b.provider, ErrCircuitOpen)
+	}
+
+	err := fn()
+	switch {
+	case err != nil && ctx.Err() != nil:
+		b.breaker.release()
+	case err != nil && providerFault(err):
+		b.breaker.failure(time.Now(), err.Error())
+		return fmt.Errorf("%s %w: %w", b.provider, apperror.ErrProviderUnavailable, err)
+	default:
+		b.breaker.success()
+	}
+	return err
+}
+
+// Statuses reports every provider's breaker, sorted by provider
+func Statuses() []BreakerStatus {
+	breakersMu.Lock()
+	list := make([]*breaker, 0, len(breakers))
+	for _, b := range breakers {
+		list = append(list, b)
+	}
+	breakersMu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(list))
+	for _, b := range list {
+		statuses = append(statuses, b.status(time.Now()))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Provider < statuses[j].Provider
+	})
+	return statuses
+}
+
+// allow reports whether a call may proceed, moving an open breaker to half-open once the
+// cooldown has passed
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if now.Before(b.openedAt.Add(breakerCooldown)) {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.trial = true
+		return true
+	case StateHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success closes the breaker
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = StateClosed
+	b.failures = 0
+	b.trial = false
+}
+
+// failure counts a failed call, opening the breaker at the threshold or on a failed trial
+func (b *breaker) failure(now time.Time, err string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastError = err
+	b.trial = false
+	if b.state == StateHalfOpen || b.failures >= breakerThreshold {
+		b.state = StateOpen
+		b.openedAt = now
+	}
+}
+
+// release ends a call that says nothing about the provider (the caller gave up), letting
+// another half-open trial through
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+func (b *breaker) status(now time.Time) BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		Provider:            b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.state == StateOpen {
+		retryAt := b.openedAt.Add(breakerCooldown)
+		status.RetryAt = &retryAt
+	}
+	return status
+}
@@ -0,0 +1,190 @@
+// Package httpretry wraps outbound API calls (embedding and LLM providers) with retries and a
+// per-provider circuit breaker.
+package httpretry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/chaos"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+)
+
+// Retry parameters
+const (
+	maxAttempts = 4
+	baseDelay   = 500 * time.Millisecond
+	// maxDelay caps both backoff and Retry-After waits
+	maxDelay = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without calling the provider while its breaker is open
+var ErrCircuitOpen = fmt.Errorf("circuit breaker open: %w", apperror.ErrProviderUnavailable)
+
+// Client sends requests to one provider, retrying rate limits (429), server errors (5xx) and
+// network errors with jittered exponential backoff, honoring Retry-After
+type Client struct {
+	provider   string
+	httpClient *http.Client
+	breaker    *breaker
+}
+
+// New creates a client for the named provider with the given per-attempt timeout. Clients
+// created with the same provider name share a circuit breaker, and faults injected into the
+// provider apply to each attempt.
+func New(provider string, timeout time.Duration) *Client {
+	return &Client{
+		provider:   provider,
+		httpClient: &http.Client{Timeout: timeout, Transport: chaos.Transport(provider, nil)},
+		breaker:    breakerFor(provider),
+	}
+}
+
+// WithProxy sends the client's requests through the HTTP(S) proxy at proxy instead of the one
+// named by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. A nil proxy keeps the
+// environment's.
+func (c *Client) WithProxy(proxy *url.URL) *Client {
+	if proxy == nil {
+		return c
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	c.httpClient.Transport = chaos.Transport(c.provider, transport)
+	return c
+}
+
+// Do sends the request, retrying as needed. Requests with a body must be replayable: those
+// built by http.NewRequest from a bytes.Reader, bytes.Buffer or strings.Reader are. Responses
+// that aren't retried (or the last attempt's response) are returned for the caller to handle,
+// whatever their status.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if req.Body != nil && req.GetBody == nil {
+		return nil, fmt.Errorf("%s: request body can't be replayed for retries", c.provider)
+	}
+	if !c.breaker.allow(time.Now()) {
+		return nil, fmt.Errorf("%s: %w", c.provider, ErrCircuitOpen)
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				c.breaker.release()
+				return nil, fmt.Errorf("failed to reset request body: %w", err)
+			}
+			attemptReq.Body = body
+		}
+
+		resp, err := c.httpClient.Do(attemptReq)
+		retryable, reason := shouldRetry(ctx, resp, err)
+		if !retryable {
+			c.record(ctx, resp, err)
+			return resp, err
+		}
+		if attempt == maxAttempts-1 {
+			c.breaker.failure(time.Now(), reason)
+			if err != nil {
+				return nil, fmt.Errorf("%s %w: %w", c.provider, apperror.ErrProviderUnavailable, err)
+			}
+			return resp, nil
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				delay = min(retryAfter, maxDelay)
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		logger.Warn("Retrying provider request", "provider", c.provider, "attempt", attempt+1, "reason", reason, "delay", delay)
+
+		select {
+		case <-ctx.Done():
+			c.breaker.release()
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// record updates the breaker with a call that won't be retried. Client errors (4xx) mean the
+// provider is up, so they count as successes; calls the caller gave up on count as neither.
+func (c *Client) record(ctx context.Context, resp *http.Response, err error) {
+	switch {
+	case err != nil && ctx.Err() != nil:
+		c.breaker.release()
+	case err != nil:
+		c.breaker.failure(time.Now(), err.Error())
+	case resp.StatusCode >= 500:
+		c.breaker.failure(time.Now(), resp.Status)
+	default:
+		c.breaker.success()
+	}
+}
+
+// shouldRetry reports whether an attempt's outcome is worth retrying and why
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, string) {
+	if err != nil {
+		// Deadlines and cancellations of the caller's context are final
+		if ctx.Err() != nil {
+			return false, ""
+		}
+		return true, err.Error()
+	}
+	if retryableStatus(resp.StatusCode) {
+		return true, resp.Status
+	}
+	return false, ""
+}
+
+// retryableStatus reports whether a response status is a rate limit or a transient server error
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// ResponseError reads the body of a failed response into an error naming the provider. Rate
+// limits and server errors, which a Client has retried already, are
+// apperror.ErrProviderUnavailable.
+func ResponseError(provider string, resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	err := fmt.Errorf("%s error (status %d): %s", provider, resp.StatusCode, string(body))
+	if retryableStatus(resp.StatusCode) {
+		return fmt.Errorf("%w: %w", apperror.ErrProviderUnavailable, err)
+	}
+	return err
+}
+
+// backoff returns a full-jitter exponential delay for the given attempt
+func backoff(attempt int) time.Duration {
+	ceiling := min(baseDelay<<uint(attempt), maxDelay)
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,114 @@
+// Package mailer sends transactional email (verification, password resets, digests and alerts)
+// through one of several provider drivers, rendering messages from embedded templates and
+// recording every send in a log.
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// Message is a rendered email with plain text and HTML bodies
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Driver delivers messages through an email provider
+type Driver interface {
+	// Name identifies the driver in the send log
+	Name() string
+
+	// Send delivers the message from the given address
+	Send(ctx context.Context, from string, msg *Message) error
+}
+
+// Log records each email sent or attempted
+type Log interface {
+	Record(ctx context.Context, entry *model.MailLogEntry) error
+}
+
+// Send statuses recorded in the log
+const (
+	StatusSent   = "sent"
+	StatusFailed = "failed"
+)
+
+// Mailer renders templates and sends them through a driver
+type Mailer struct {
+	driver Driver
+	from   string
+	log    Log
+}
+
+// New creates a mailer sending from the given address. log may be nil.
+func New(driver Driver, from string, log Log) *Mailer {
+	return &Mailer{
+		driver: driver,
+		from:   from,
+		log:    log,
+	}
+}
+
+// NewDriver creates the driver selected by MAIL_DRIVER, or nil when email is disabled
+func NewDriver(ctx context.Context, cfg *config.Config) (Driver, error) {
+	switch cfg.MailDriver {
+	case "":
+		return nil, nil
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for the smtp mail driver")
+		}
+		return NewSMTPDriver(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword), nil
+	case "ses":
+		return NewSESDriver(ctx, cfg.SESRegion, cfg.AWSConfig)
+	case "mailgun":
+		if cfg.MailgunDomain == "" || cfg.MailgunAPIKey == "" {
+			return nil, fmt.Errorf("MAILGUN_DOMAIN and MAILGUN_API_KEY are required for the mailgun mail driver")
+		}
+		return NewMailgunDriver(cfg.MailgunAPIBase, cfg.MailgunDomain, cfg.MailgunAPIKey), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver: %s (valid options: smtp, ses, mailgun)", cfg.MailDriver)
+	}
+}
+
+// Send renders the named template with data and emails it to the address. userID, which may be
+// empty, is recorded in the send log along with the outcome.
+func (m *Mailer) Send(ctx context.Context, userID, to, templateName string, data interface{}) error {
+	msg, err := render(templateName, data)
+	if err != nil {
+		return err
+	}
+	msg.To = to
+
+	sendErr := m.driver.Send(ctx, m.from, msg)
+
+	if m.log != nil {
+		entry := &model.MailLogEntry{
+			UserID:    userID,
+			Recipient: to,
+			Template:  templateName,
+			Subject:   msg.Subject,
+			Driver:    m.driver.Name(),
+			Status:    StatusSent,
+		}
+		if sendErr != nil {
+			entry.Status = StatusFailed
+			entry.Error = sendErr.Error()
+		}
+		if err := m.log.Record(ctx, entry); err != nil {
+			logger.Warn("Failed to record sent email", "template", templateName, "error", err)
+		}
+	}
+
+	if sendErr != nil {
+		return fmt.Errorf("failed to send %s email via %s: %w", templateName, m.driver.Name(), sendErr)
+	}
+	return nil
+}
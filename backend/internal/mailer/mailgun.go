@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// MailgunDriver sends email with the Mailgun messages API
+type MailgunDriver struct {
+	messagesURL string
+	apiKey      string
+	httpClient  *httpretry.Client
+}
+
+// NewMailgunDriver creates a Mailgun driver for a sending domain. apiBase selects the region,
+// e.g. https://api.mailgun.net/v3 or https://api.eu.mailgun.net/v3.
+func NewMailgunDriver(apiBase, domain, apiKey string) *MailgunDriver {
+	return &MailgunDriver{
+		messagesURL: strings.TrimRight(apiBase, "/") + "/" + url.PathEscape(domain) + "/messages",
+		apiKey:      apiKey,
+		httpClient:  httpretry.New("mailgun", 30*time.Second),
+	}
+}
+
+// Name returns the driver name
+func (d *MailgunDriver) Name() string {
+	return "mailgun"
+}
+
+// Send delivers the message
+func (d *MailgunDriver) Send(ctx context.Context, from string, msg *Message) error {
+	form := url.Values{}
+	form.Set("from", from)
+	form.Set("to", msg.To)
+	form.Set("subject", msg.Subject)
+	if msg.Text != "" {
+		form.Set("text", msg.Text)
+	}
+	if msg.HTML != "" {
+		form.Set("html", msg.HTML)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.messagesURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("api", d.apiKey)
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return fmt.Errorf("mailgun error (status %d): %s", resp.StatusCode, string(errBody))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// SESDriver sends email with the Amazon SES v2 API, signing requests with the AWS credentials
+// from the environment (or the configured static keys)
+type SESDriver struct {
+	endpoint    string
+	region      string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *httpretry.Client
+}
+
+// NewSESDriver creates an SES driver for the region. An AWS endpoint override (LocalStack) is
+// used with the static keys, as for S3.
+func NewSESDriver(ctx context.Context, region string, awsCfg config.AWSConfig) (*SESDriver, error) {
+	opts := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(region)}
+	endpoint := fmt.Sprintf("https://email.%s.amazonaws.com", region)
+	if awsCfg.Endpoint != "" {
+		endpoint = strings.TrimRight(awsCfg.Endpoint, "/")
+		opts = append(opts, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			awsCfg.AccessKeyID,
+			awsCfg.SecretAccessKey,
+			"",
+		)))
+	}
+
+	loaded, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return &SESDriver{
+		endpoint:    endpoint,
+		region:      region,
+		credentials: loaded.Credentials,
+		signer:      v4.NewSigner(),
+		httpClient:  httpretry.New("ses", 30*time.Second),
+	}, nil
+}
+
+// Name returns the driver name
+func (d *SESDriver) Name() string {
+	return "ses"
+}
+
+// sesContent is a subject or body part of an SES message
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+// sesSendEmailRequest represents an SES v2 SendEmail request with simple content
+type sesSendEmailRequest struct {
+	FromEmailAddress string `json:"FromEmailAddress"`
+	Destination      struct {
+		ToAddresses []string `json:"ToAddresses"`
+	} `json:"Destination"`
+	Content struct {
+		Simple struct {
+			Subject sesContent `json:"Subject"`
+			Body    struct {
+				Text *sesContent `json:"Text,omitempty"`
+				HTML *sesContent `json:"Html,omitempty"`
+			} `json:"Body"`
+		} `json:"Simple"`
+	} `json:"Content"`
+}
+
+// Send delivers the message with SendEmail
+func (d *SESDriver) Send(ctx context.Context, from string, msg *Message) error {
+	var body sesSendEmailRequest
+	body.FromEmailAddress = from
+	body.Destination.ToAddresses = []string{msg.To}
+	body.Content.Simple.Subject = sesContent{Data: msg.Subject, Charset: "UTF-8"}
+	if msg.Text != "" {
+		body.Content.Simple.Body.Text = &sesContent{Data: msg.Text, Charset: "UTF-8"}
+	}
+	if msg.HTML != "" {
+		body.Content.Simple.Body.HTML = &sesContent{Data: msg.HTML, Charset: "UTF-8"}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint+"/v2/email/outbound-emails", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := d.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(payload)
+	if err := d.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "ses", d.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return fmt.Errorf("ses error (status %d): %s", resp.StatusCode, string(errBody))
+	}
+	return nil
+}
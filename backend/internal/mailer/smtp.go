@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+)
+
+// SMTPDriver sends email through an SMTP server
+type SMTPDriver struct {
+	host string
+	addr string
+	auth smtp.Auth
+}
+
+// NewSMTPDriver creates an SMTP driver. Authentication is skipped when username is empty.
+func NewSMTPDriver(host, port, username, password string) *SMTPDriver {
+	d := &SMTPDriver{
+		host: host,
+		addr: net.JoinHostPort(host, port),
+	}
+	if username != "" {
+		d.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return d
+}
+
+// Name returns the driver name
+func (d *SMTPDriver) Name() string {
+	return "smtp"
+}
+
+// Send delivers the message as multipart/alternative with text and HTML parts
+func (d *SMTPDriver) Send(ctx context.Context, from string, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	parts := multipart.NewWriter(&body)
+
+	fmt.Fprintf(&body, "From: %s\r\n", from)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&body, "Content-Type: multipart/alternative; boundary=%s\r\n", parts.Boundary())
+	body.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		if part.content == "" {
+			continue
+		}
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to build message: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return fmt.Errorf("failed to build message: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return fmt.Errorf("failed to build message: %w", err)
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+
+	return smtp.SendMail(d.addr, d.auth, from, []string{msg.To}, []byte(body.String()))
+}
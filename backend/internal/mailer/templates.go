@@ -0,0 +1,100 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Each template file defines "subject" and "text", and a "body" that templates/layout.tmpl wraps
+// into the HTML part. Notification templates are named after their event (e.g. digest.created).
+//
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Template names
+const (
+	TemplateVerifyEmail   = "verify_email"
+	TemplatePasswordReset = "password_reset"
+	// TemplateNotification renders notifications whose event has no template of its own
+	TemplateNotification = "notification"
+)
+
+// LinkData is the data of the verify_email and password_reset templates
+type LinkData struct {
+	Link      string
+	ExpiresIn string
+}
+
+// NotificationData is the data of notification templates
+type NotificationData struct {
+	Title  string
+	Body   string
+	AppURL string
+}
+
+// messageTemplate renders the parts of one kind of email
+type messageTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// templates are parsed at startup so a broken template fails fast
+var templates = loadTemplates()
+
+func loadTemplates() map[string]*messageTemplate {
+	files, err := fs.Glob(templateFS, "templates/*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+
+	loaded := make(map[string]*messageTemplate, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".tmpl")
+		if name == "layout" {
+			continue
+		}
+		loaded[name] = &messageTemplate{
+			text: texttemplate.Must(texttemplate.ParseFS(templateFS, file)),
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.tmpl", file)),
+		}
+	}
+	return loaded
+}
+
+// HasTemplate reports whether a template with the name exists
+func HasTemplate(name string) bool {
+	_, ok := templates[name]
+	return ok
+}
+
+// render renders the named template's subject, text and HTML parts
+func render(name string, data interface{}) (*Message, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template: %s", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "html", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+
+	return &Message{
+		// Keep the subject to one line so data can't inject headers
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()),
+		HTML:    html.String(),
+	}, nil
+}
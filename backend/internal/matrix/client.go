@@ -0,0 +1,227 @@
+// Package matrix is a minimal Matrix client-server API client for running a bot account:
+// long-poll sync, direct rooms, text messages and media downloads.
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SyncTimeout is how long the homeserver holds a sync request open waiting for events
+const SyncTimeout = 30 * time.Second
+
+// Client calls a homeserver as the bot user identified by the access token
+type Client struct {
+	homeserverURL string
+	accessToken   string
+	userID        string
+	httpClient    *http.Client
+	txnCounter    atomic.Int64
+}
+
+// NewClient creates a client for the bot account
+func NewClient(homeserverURL, accessToken, userID string) *Client {
+	return &Client{
+		homeserverURL: strings.TrimRight(homeserverURL, "/"),
+		accessToken:   accessToken,
+		userID:        userID,
+		// Longer than SyncTimeout so long polls return before the client gives up
+		httpClient: &http.Client{Timeout: SyncTimeout + 30*time.Second},
+	}
+}
+
+// UserID returns the bot's Matrix user ID
+func (c *Client) UserID() string {
+	return c.userID
+}
+
+// Event is a room timeline event
+type Event struct {
+	Type    string          `json:"type"`
+	EventID string          `json:"event_id"`
+	Sender  string          `json:"sender"`
+	Content json.RawMessage `json:"content"`
+}
+
+// MessageContent is the content of an m.room.message event
+type MessageContent struct {
+	MsgType string `json:"msgtype"`
+	Body    string `json:"body"`
+	// Filename is set when Body is a caption rather than the file's name
+	Filename string `json:"filename,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Info     struct {
+		MimeType string `json:"mimetype,omitempty"`
+		Size     int64  `json:"size,omitempty"`
+	} `json:"info"`
+}
+
+// SyncResponse holds the parts of a sync response the bot uses
+type SyncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []Event `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+// Sync returns events since the given batch token, waiting up to timeout for new ones.
+// An empty since returns the current state, whose NextBatch marks "now".
+func (c *Client) Sync(ctx context.Context, since string, timeout time.Duration) (*SyncResponse, error) {
+	query := url.Values{}
+	query.Set("timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+	if since != "" {
+		query.Set("since", since)
+	}
+	// Only room messages are needed; skip presence and account data
+	query.Set("filter", `{"presence":{"types":[]},"account_data":{"types":[]},"room":{"timeline":{"types":["m.room.message"]},"state":{"types":[]},"ephemeral":{"types":[]}}}`)
+
+	var resp SyncResponse
+	if err := c.do(ctx, http.MethodGet, "/_matrix/client/v3/sync?"+query.Encode(), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to sync: %w", err)
+	}
+	return &resp, nil
+}
+
+// CreateDirectRoom creates a private, invite-only room and invites the user to it
+func (c *Client) CreateDirectRoom(ctx context.Context, invitee, name string) (string, error) {
+	body := map[string]interface{}{
+		"preset":    "trusted_private_chat",
+		"is_direct": true,
+		"invite":    []string{invitee},
+		"name":      name,
+	}
+
+	var resp struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/_matrix/client/v3/createRoom", body, &resp); err != nil {
+		return "", fmt.Errorf("failed to create room: %w", err)
+	}
+	return resp.RoomID, nil
+}
+
+// LeaveRoom leaves a room
+func (c *Client) LeaveRoom(ctx context.Context, roomID string) error {
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(roomID) + "/leave"
+	if err := c.do(ctx, http.MethodPost, path, map[string]interface{}{}, nil); err != nil {
+		return fmt.Errorf("failed to leave room: %w", err)
+	}
+	return nil
+}
+
+// SendNotice posts a text message as a notice, which bots use so other bots don't reply to it
+func (c *Client) SendNotice(ctx context.Context, roomID, text string) error {
+	txnID := fmt.Sprintf("%d-%d", time.Now().UnixNano(), c.txnCounter.Add(1))
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(roomID) + "/send/m.room.message/" + url.PathEscape(txnID)
+	body := map[string]string{
+		"msgtype": "m.notice",
+		"body":    text,
+	}
+	if err := c.do(ctx, http.MethodPut, path, body, nil); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// Download fetches the media at an mxc:// URI, reading at most maxSize bytes
+func (c *Client) Download(ctx context.Context, mxcURI string, maxSize int64) ([]byte, error) {
+	serverName, mediaID, ok := strings.Cut(strings.TrimPrefix(mxcURI, "mxc://"), "/")
+	if !strings.HasPrefix(mxcURI, "mxc://") || !ok || serverName == "" || mediaID == "" {
+		return nil, fmt.Errorf("invalid media URI: %s", mxcURI)
+	}
+	path := "/_matrix/client/v1/media/download/" + url.PathEscape(serverName) + "/" + url.PathEscape(mediaID)
+
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download media: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download media: %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read media: %w", err)
+	}
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("media larger than %d bytes", maxSize)
+	}
+	return content, nil
+}
+
+// Error is an error response from the homeserver
+type Error struct {
+	Status  int
+	ErrCode string `json:"errcode"`
+	Message string `json:"error"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("matrix API error %d %s: %s", e.Status, e.ErrCode, e.Message)
+}
+
+// do sends a JSON request and decodes the JSON response into out, when out is non-nil
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := c.newRequest(ctx, method, path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &Error{Status: resp.StatusCode}
+		json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(apiErr)
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.homeserverURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	return req, nil
+}
@@ -8,8 +8,8 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthRequired is a middleware that requires a valid JWT token
-func AuthRequired(jwtSecret string) fiber.Handler {
+// AuthRequired is a middleware that requires a valid JWT token or API key
+func AuthRequired(jwtSecret string, apiKeyService *service.APIKeyService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get authorization header
 		authHeader := c.Get("Authorization")
@@ -29,6 +29,27 @@ func AuthRequired(jwtSecret string) fiber.Handler {
 
 		tokenString := parts[1]
 
+		// API keys are opaque and looked up by hash
+		if strings.HasPrefix(tokenString, service.APIKeyPrefix) {
+			key, err := apiKeyService.Authenticate(c.Context(), tokenString)
+			if err != nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			// Widget keys are public (embedded in web pages) and only work on the widget
+			if service.IsWidgetKey(key) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "widget keys can only be used with the embed widget",
+				})
+			}
+
+			c.Locals("userID", key.UserID)
+			c.Locals("scopes", key.Scopes)
+			c.Locals("apiKeyID", key.ID)
+			return c.Next()
+		}
+
 		// Parse token
 		token, err := jwt.ParseWithClaims(tokenString, &service.Claims{}, func(token *jwt.Token) (interface{}, error) {
 			return []byte(jwtSecret), nil
@@ -42,9 +63,16 @@ func AuthRequired(jwtSecret string) fiber.Handler {
 
 		// Extract claims
 		if claims, ok := token.Claims.(*service.Claims); ok {
+			// Tokens issued before scopes existed carry the regular user scopes
+			scopes := claims.Scopes
+			if len(scopes) == 0 {
+				scopes = service.UserScopes
+			}
+
 			// Store user ID in context
 			c.Locals("userID", claims.UserID)
 			c.Locals("email", claims.Email)
+			c.Locals("scopes", scopes)
 			return c.Next()
 		}
 
@@ -54,6 +82,35 @@ func AuthRequired(jwtSecret string) fiber.Handler {
 	}
 }
 
+// RequireScope is a middleware that requires the authenticated credential to hold a scope
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !service.HasScope(GetScopes(c), scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "missing required scope: " + scope,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// RequireScopeByMethod requires readScope for safe methods (GET, HEAD) and writeScope otherwise
+func RequireScopeByMethod(readScope, writeScope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		scope := writeScope
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			scope = readScope
+		}
+
+		if !service.HasScope(GetScopes(c), scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "missing required scope: " + scope,
+			})
+		}
+		return c.Next()
+	}
+}
+
 // GetUserID extracts the user ID from the request context
 func GetUserID(c *fiber.Ctx) string {
 	userID, ok := c.Locals("userID").(string)
@@ -62,3 +119,12 @@ func GetUserID(c *fiber.Ctx) string {
 	}
 	return userID
 }
+
+// GetScopes extracts the scopes of the authenticated credential from the request context
+func GetScopes(c *fiber.Ctx) []string {
+	scopes, ok := c.Locals("scopes").([]string)
+	if !ok {
+		return nil
+	}
+	return scopes
+}
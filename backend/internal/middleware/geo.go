@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientCountry is a middleware that stores the client's country code, as resolved by an
+// upstream proxy or CDN (e.g. CF-IPCountry), in the request context. The header is only
+// trustworthy when the proxy overwrites it, so an empty header name stores nothing.
+func ClientCountry(header string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if header != "" {
+			country := strings.ToUpper(strings.TrimSpace(c.Get(header)))
+			// "XX" and "T1" are placeholders for unknown locations and Tor
+			if len(country) == 2 && country != "XX" && country != "T1" {
+				c.Locals("country", country)
+			}
+		}
+		return c.Next()
+	}
+}
+
+// GetCountry extracts the client's country code from the request context
+func GetCountry(c *fiber.Ctx) string {
+	country, ok := c.Locals("country").(string)
+	if !ok {
+		return ""
+	}
+	return country
+}
@@ -9,8 +9,86 @@ type User struct {
 	ID           string    `json:"id" db:"id"`
 	Email        string    `json:"email" db:"email"`
 	PasswordHash string    `json:"-" db:"password_hash"`
+	IsAdmin      bool      `json:"is_admin" db:"is_admin"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	// EmailVerifiedAt is set once the user opens the link in their verification email
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+}
+
+// MailLogEntry records an email sent, or attempted, by the mailer
+type MailLogEntry struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id,omitempty" db:"user_id"`
+	Recipient string    `json:"recipient" db:"recipient"`
+	Template  string    `json:"template" db:"template"`
+	Subject   string    `json:"subject" db:"subject"`
+	Driver    string    `json:"driver" db:"driver"`
+	Status    string    `json:"status" db:"status"` // sent or failed
+	Error     string    `json:"error,omitempty" db:"error"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// NotificationTarget is where a user receives one notification channel, such as a Telegram chat
+// ID or a push topic
+type NotificationTarget struct {
+	Channel   string    `json:"channel" db:"channel"`
+	Target    string    `json:"target" db:"target"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationRoute sets the channels a user receives an event through. Event "*" applies to
+// events without a route of their own; no channels mutes the event.
+type NotificationRoute struct {
+	Event     string    `json:"event" db:"event"`
+	Channels  []string  `json:"channels" db:"channels"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UserSettings holds a user's persistent assistant preferences
+type UserSettings struct {
+	UserID string `json:"user_id" db:"user_id"`
+	// SystemPrompt is a persona prepended to every query (e.g. "answer tersely, cite page numbers")
+	SystemPrompt string `json:"system_prompt" db:"system_prompt"`
+	// Language is the default answer language when neither the query nor the conversation sets one
+	Language string `json:"language" db:"language"`
+	// EmbeddingModel overrides the server's embedding model (e.g. a multilingual model); empty
+	// uses the default
+	EmbeddingModel string `json:"embedding_model" db:"embedding_model"`
+	// ActiveEmbeddingModel is the model the user's documents are embedded with, which serves
+	// queries until re-embedding with EmbeddingModel completes
+	ActiveEmbeddingModel string    `json:"active_embedding_model" db:"active_embedding_model"`
+	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// APIKey represents a scoped key used by integrations instead of a JWT
+type APIKey struct {
+	ID     string `json:"id" db:"id"`
+	UserID string `json:"user_id" db:"user_id"`
+	Name   string `json:"name" db:"name"`
+	// Prefix is the first characters of the key, shown to help users identify it
+	Prefix  string   `json:"prefix" db:"key_prefix"`
+	KeyHash string   `json:"-" db:"key_hash"`
+	Scopes  []string `json:"scopes" db:"scopes"`
+	// AllowedOrigins are the websites that may embed a widget key (scheme://host[:port])
+	AllowedOrigins []string   `json:"allowed_origins,omitempty" db:"allowed_origins"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// AuditEvent records an account action and whether it was flagged as anomalous
+type AuditEvent struct {
+	ID         string                 `json:"id" db:"id"`
+	UserID     string                 `json:"user_id" db:"user_id"`
+	Action     string                 `json:"action" db:"action"`
+	ResourceID string                 `json:"resource_id,omitempty" db:"resource_id"`
+	IPAddress  string                 `json:"ip_address,omitempty" db:"ip_address"`
+	Country    string                 `json:"country,omitempty" db:"country"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
+	Flagged    bool                   `json:"flagged" db:"flagged"`
+	FlagReason string                 `json:"flag_reason,omitempty" db:"flag_reason"`
+	CreatedAt  time.Time              `json:"created_at" db:"created_at"`
 }
 
 // Document represents an uploaded document
@@ -23,17 +101,164 @@ type Document struct {
 	FileHash    string    `json:"file_hash" db:"file_hash"`
 	StoragePath string    `json:"storage_path" db:"storage_path"`
 	TotalChunks int       `json:"total_chunks" db:"total_chunks"`
+	Pinned      bool      `json:"pinned" db:"pinned"`
+	Favorite    bool      `json:"favorite" db:"favorite"`
+	Canary      bool      `json:"canary" db:"canary"` // Retrieval or download triggers an alert
+	Summary     string    `json:"summary,omitempty" db:"summary"`
 	UploadDate  time.Time `json:"upload_date" db:"upload_date"`
+	// ChunkingStrategy, ChunkSize and ChunkOverlap (both in tokens) are the parameters the
+	// document was chunked with, reused when it is indexed again. Documents indexed before they
+	// were recorded have them empty.
+	ChunkingStrategy string `json:"chunking_strategy,omitempty" db:"chunking_strategy"`
+	ChunkSize        int    `json:"chunk_size,omitempty" db:"chunk_size"`
+	ChunkOverlap     int    `json:"chunk_overlap" db:"chunk_overlap"`
 }
 
 // QueryHistory represents a query made by a user
 type QueryHistory struct {
-	ID        string                 `json:"id" db:"id"`
-	UserID    string                 `json:"user_id" db:"user_id"`
-	Question  string                 `json:"question" db:"question"`
-	Answer    string                 `json:"answer" db:"answer"`
-	Sources   map[string]interface{} `json:"sources" db:"sources"`
-	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+	ID             string                 `json:"id" db:"id"`
+	UserID         string                 `json:"user_id" db:"user_id"`
+	ConversationID string                 `json:"conversation_id,omitempty" db:"conversation_id"`
+	Question       string                 `json:"question" db:"question"`
+	Answer         string                 `json:"answer" db:"answer"`
+	Sources        map[string]interface{} `json:"sources" db:"sources"`
+	Pinned         bool                   `json:"pinned" db:"pinned"`
+	Favorite       bool                   `json:"favorite" db:"favorite"`
+	Usage          TokenUsage             `json:"usage"`
+	// Feedback is the user's rating: 1 helpful, -1 not helpful, 0 unrated
+	Feedback  int       `json:"feedback" db:"feedback"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// ParentID is the conversation message this one follows; empty for a conversation's first
+	// message. Edited questions share the parent of the message they replace.
+	ParentID string `json:"parent_id,omitempty" db:"parent_id"`
+	// Truncated marks a streamed answer that stopped before it completed, such as when the client
+	// disconnected; Answer holds what was generated
+	Truncated bool `json:"truncated" db:"truncated"`
+	// ReadAt is when the client confirmed it received the answer
+	ReadAt *time.Time `json:"read_at,omitempty" db:"read_at"`
+}
+
+// AnswerQuality records how well an answer was supported when it was generated
+type AnswerQuality struct {
+	// Confidence is the answer's confidence score (0-1), when computed
+	Confidence *float64
+	// Verified is the verify stage's verdict, when the stage ran
+	Verified *bool
+}
+
+// QueryRun records how an answer was produced, to compare prompt versions
+type QueryRun struct {
+	// PromptVersionID is the prompt version the query was routed to; empty for the default prompt
+	PromptVersionID string
+	// Latency is the time taken to answer; zero when the query wasn't eligible for routing
+	Latency time.Duration
+}
+
+// PromptVersion is a system prompt and retrieval configuration that a share of queries is
+// routed to, so it can be compared with the default before full rollout
+type PromptVersion struct {
+	ID           string `json:"id" db:"id"`
+	Name         string `json:"name" db:"name"`
+	SystemPrompt string `json:"system_prompt" db:"system_prompt"`
+	// Pipeline overrides the configured stage implementations (e.g. {"rerank": "llm"})
+	Pipeline map[string]string `json:"pipeline" db:"pipeline"`
+	// TopK is the number of chunks given to the model; 0 keeps the default
+	TopK int `json:"top_k" db:"top_k"`
+	// TrafficPercent is the share of queries answered with this version
+	TrafficPercent int       `json:"traffic_percent" db:"traffic_percent"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PromptVersionStats summarizes the queries answered with a prompt version, or with the default
+// prompt when VersionID is empty
+type PromptVersionStats struct {
+	VersionID    string  `json:"version_id,omitempty"`
+	Name         string  `json:"name"`
+	Queries      int     `json:"queries"`
+	AvgLatencyMS float64 `json:"avg_latency_ms"`
+	P95LatencyMS float64 `json:"p95_latency_ms"`
+	AvgCostUSD   float64 `json:"avg_cost_usd"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	// Helpful and NotHelpful count the answers users rated
+	Helpful    int `json:"helpful"`
+	NotHelpful int `json:"not_helpful"`
+	// Endorsed counts answers users pinned or favorited
+	Endorsed      int      `json:"endorsed"`
+	AvgConfidence *float64 `json:"avg_confidence,omitempty"`
+	// VerifiedRate is the share of verified answers the verify stage found supported
+	VerifiedRate *float64 `json:"verified_rate,omitempty"`
+}
+
+// FAQEntry is a question the user asks repeatedly, with the best answer given to it
+type FAQEntry struct {
+	ID        string      `json:"id" db:"id"`
+	Question  string      `json:"question" db:"question"`
+	Answer    string      `json:"answer" db:"answer"`
+	Sources   interface{} `json:"sources" db:"sources"`
+	AskCount  int         `json:"ask_count" db:"ask_count"`
+	HistoryID string      `json:"history_id" db:"best_history_id"`
+	// Endorsed is set when the answer was pinned or favorited by the user or passed verification;
+	// only endorsed answers are reused for repeat questions
+	Endorsed    bool      `json:"endorsed"`
+	Confidence  *float64  `json:"confidence,omitempty" db:"confidence"`
+	AnsweredAt  time.Time `json:"answered_at"`
+	LastAskedAt time.Time `json:"last_asked_at" db:"last_asked_at"`
+}
+
+// TokenUsage records the API tokens consumed by a query and their estimated cost
+type TokenUsage struct {
+	EmbeddingTokens  int     `json:"embedding_tokens" db:"embedding_tokens"`
+	PromptTokens     int     `json:"prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens" db:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd" db:"cost_usd"`
+}
+
+// UsagePeriod aggregates query usage over a day or month
+type UsagePeriod struct {
+	Period  time.Time `json:"period"`
+	Queries int       `json:"queries"`
+	TokenUsage
+}
+
+// EmbeddingUsage records the embedding tokens spent ingesting or re-indexing a document
+type EmbeddingUsage struct {
+	UserID     string `json:"user_id" db:"user_id"`
+	DocumentID string `json:"document_id,omitempty" db:"document_id"`
+	Filename   string `json:"filename" db:"filename"`
+	// Source is how the document arrived or why it was embedded, e.g. upload, knowledge_base, reembed
+	Source    string    `json:"source" db:"source"`
+	Folder    string    `json:"folder,omitempty" db:"folder"` // Knowledge base folder, if any
+	Model     string    `json:"model,omitempty" db:"model"`
+	Tokens    int       `json:"tokens" db:"tokens"`
+	CostUSD   float64   `json:"cost_usd" db:"cost_usd"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// EmbeddingUsageGroup sums embedding usage sharing a source or folder
+type EmbeddingUsageGroup struct {
+	Key       string  `json:"key"`
+	Documents int     `json:"documents"`
+	Tokens    int     `json:"tokens"`
+	CostUSD   float64 `json:"cost_usd"`
+}
+
+// Conversation groups a sequence of queries into a chat thread
+type Conversation struct {
+	ID     string `json:"id" db:"id"`
+	UserID string `json:"user_id" db:"user_id"`
+	Title  string `json:"title" db:"title"`
+	// Model, Temperature, Language and Filters lock generation and retrieval settings for every message
+	Model       string            `json:"model,omitempty" db:"model"`
+	Temperature *float64          `json:"temperature,omitempty" db:"temperature"`
+	Language    string            `json:"language,omitempty" db:"language"`
+	Filters     map[string]string `json:"filters,omitempty" db:"filters"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
+	// Score is the semantic search similarity, set only on search results
+	Score float32 `json:"score,omitempty" db:"-"`
+	// ActiveMessageID is the last message of the branch shown and continued by new questions
+	ActiveMessageID string `json:"active_message_id,omitempty" db:"active_message_id"`
 }
 
 // DocumentChunk represents a chunk of text from a document
@@ -44,11 +269,363 @@ type DocumentChunk struct {
 	Page       int
 	ChunkIndex int
 	Metadata   map[string]interface{}
+	// SimHash fingerprints the content for near-duplicate detection; 0 when not computed
+	SimHash uint64
 }
 
 // VectorPoint represents a point in the vector database
 type VectorPoint struct {
-	ID      string
-	Vector  []float32
+	ID     string
+	Vector []float32
+	// Sparse is the chunk's sparse (term weight) vector for hybrid retrieval, when generated
+	Sparse  *SparseVector
 	Payload map[string]interface{}
+	Score   float32
+}
+
+// SparseVector holds the non-zero weights of a sparse vector over a vocabulary
+type SparseVector struct {
+	Indices []uint32
+	Values  []float32
+}
+
+// ScheduledQuery represents a standing question that runs on a recurring schedule
+type ScheduledQuery struct {
+	ID        string            `json:"id" db:"id"`
+	UserID    string            `json:"user_id" db:"user_id"`
+	Question  string            `json:"question" db:"question"`
+	Filters   map[string]string `json:"filters,omitempty" db:"filters"`
+	Frequency string            `json:"frequency" db:"frequency"`
+	Enabled   bool              `json:"enabled" db:"enabled"`
+	NextRunAt time.Time         `json:"next_run_at" db:"next_run_at"`
+	LastRunAt *time.Time        `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// SavedQuery is a reusable query template whose {placeholders} are filled in when it is executed
+type SavedQuery struct {
+	ID       string            `json:"id" db:"id"`
+	UserID   string            `json:"user_id" db:"user_id"`
+	Name     string            `json:"name" db:"name"`
+	Template string            `json:"template" db:"template"`
+	Filters  map[string]string `json:"filters,omitempty" db:"filters"`
+	// Parameters lists the template's placeholder names in order of first use
+	Parameters []string  `json:"parameters"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Application tracks a job application for an ingested posting and the resume sent with it
+type Application struct {
+	ID                string  `json:"id" db:"id"`
+	UserID            string  `json:"user_id" db:"user_id"`
+	PostingDocumentID string  `json:"posting_document_id" db:"posting_document_id"`
+	ResumeDocumentID  *string `json:"resume_document_id,omitempty" db:"resume_document_id"`
+	Company           string  `json:"company" db:"company"`
+	Role              string  `json:"role" db:"role"`
+	// Status is one of saved, applied, interviewing, offer, rejected or withdrawn
+	Status      string     `json:"status" db:"status"`
+	Notes       string     `json:"notes,omitempty" db:"notes"`
+	CoverLetter string     `json:"cover_letter,omitempty" db:"cover_letter"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty" db:"applied_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Flashcard is a question and answer card reviewed on an SM-2 schedule
+type Flashcard struct {
+	ID         string  `json:"id" db:"id"`
+	UserID     string  `json:"user_id" db:"user_id"`
+	DocumentID *string `json:"document_id,omitempty" db:"document_id"`
+	Question   string  `json:"question" db:"question"`
+	Answer     string  `json:"answer" db:"answer"`
+	// Source is the filename of the document the card was generated from
+	Source string `json:"source,omitempty" db:"source"`
+	// EaseFactor, IntervalDays and Repetitions are the SM-2 scheduling state
+	EaseFactor     float64    `json:"ease_factor" db:"ease_factor"`
+	IntervalDays   int        `json:"interval_days" db:"interval_days"`
+	Repetitions    int        `json:"repetitions" db:"repetitions"`
+	Lapses         int        `json:"lapses" db:"lapses"`
+	DueAt          time.Time  `json:"due_at" db:"due_at"`
+	LastReviewedAt *time.Time `json:"last_reviewed_at,omitempty" db:"last_reviewed_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// FlashcardStats summarizes a user's flashcards and review history
+type FlashcardStats struct {
+	Total int `json:"total"`
+	Due   int `json:"due"`
+	// New cards have never been reviewed; mature cards have an interval of 21 days or more
+	New           int `json:"new"`
+	Learning      int `json:"learning"`
+	Mature        int `json:"mature"`
+	ReviewsToday  int `json:"reviews_today"`
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+	// Retention is the share of reviews in the last 30 days graded 3 or higher
+	Retention float64 `json:"retention"`
+}
+
+// Schedule is a persisted cron entry for a background job run by the scheduler
+type Schedule struct {
+	ID       string `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	CronExpr string `json:"cron_expr" db:"cron_expr"`
+	Timezone string `json:"timezone" db:"timezone"`
+	// MisfirePolicy decides what happens to a run missed by more than the grace period: "run_once" or "skip"
+	MisfirePolicy string     `json:"misfire_policy" db:"misfire_policy"`
+	Enabled       bool       `json:"enabled" db:"enabled"`
+	NextRunAt     time.Time  `json:"next_run_at" db:"next_run_at"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ScheduleRun records one execution (or skipped misfire) of a schedule
+type ScheduleRun struct {
+	ID           string     `json:"id" db:"id"`
+	ScheduleID   string     `json:"schedule_id" db:"schedule_id"`
+	ScheduledFor time.Time  `json:"scheduled_for" db:"scheduled_for"`
+	StartedAt    time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+	Status       string     `json:"status" db:"status"` // running, succeeded, failed, or missed
+	Error        string     `json:"error,omitempty" db:"error"`
+}
+
+// Webhook is a user-registered endpoint that receives signed notification events
+type Webhook struct {
+	ID     string `json:"id" db:"id"`
+	UserID string `json:"user_id" db:"user_id"`
+	URL    string `json:"url" db:"url"`
+	// Secret signs deliveries; it is returned only when the webhook is created
+	Secret string `json:"-" db:"secret"`
+	// Events limits deliveries to these event names; empty subscribes to all events
+	Events    []string  `json:"events" db:"events"`
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// WebhookDelivery logs one event sent to a webhook and the outcome of its latest attempt
+type WebhookDelivery struct {
+	ID             string                 `json:"id" db:"id"`
+	WebhookID      string                 `json:"webhook_id" db:"webhook_id"`
+	Event          string                 `json:"event" db:"event"`
+	Payload        map[string]interface{} `json:"payload" db:"payload"`
+	Status         string                 `json:"status" db:"status"` // pending, succeeded, or failed
+	Attempts       int                    `json:"attempts" db:"attempts"`
+	ResponseStatus int                    `json:"response_status,omitempty" db:"response_status"`
+	LastError      string                 `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// Digest is a generated summary of the documents a user added during a period
+type Digest struct {
+	ID            string    `json:"id" db:"id"`
+	UserID        string    `json:"user_id" db:"user_id"`
+	PeriodStart   time.Time `json:"period_start" db:"period_start"`
+	PeriodEnd     time.Time `json:"period_end" db:"period_end"`
+	Content       string    `json:"content" db:"content"` // Markdown
+	DocumentIDs   []string  `json:"document_ids" db:"document_ids"`
+	DocumentCount int       `json:"document_count" db:"document_count"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// Warranty is a product warranty extracted from an ingested manual or warranty document
+type Warranty struct {
+	DocumentID   string    `json:"document_id"`
+	Filename     string    `json:"filename"`
+	DocType      string    `json:"doc_type"`
+	Product      string    `json:"product,omitempty"`
+	ModelNumbers []string  `json:"model_numbers,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Active       bool      `json:"active"`
+}
+
+// TravelBooking is a booking, itinerary or passport extracted from an ingested travel document
+type TravelBooking struct {
+	DocumentID    string    `json:"document_id"`
+	Filename      string    `json:"filename"`
+	DocType       string    `json:"doc_type"`
+	BookingRefs   []string  `json:"booking_refs,omitempty"`
+	FlightNumbers []string  `json:"flight_numbers,omitempty"`
+	Hotel         string    `json:"hotel,omitempty"`
+	Places        []string  `json:"places,omitempty"`
+	StartsAt      time.Time `json:"starts_at"`
+	EndsAt        time.Time `json:"ends_at"`
+}
+
+// Receipt is a receipt or invoice extracted from an ingested expense document
+type Receipt struct {
+	DocumentID string    `json:"document_id"`
+	Filename   string    `json:"filename"`
+	DocType    string    `json:"doc_type"`
+	Merchant   string    `json:"merchant,omitempty"`
+	Date       time.Time `json:"date"`
+	Total      float64   `json:"total"`
+	Currency   string    `json:"currency,omitempty"`
+	Items      []string  `json:"items,omitempty"`
+}
+
+// GlossaryTerm is a user-defined term (acronym, codename, nickname) and the expansion
+// added to queries that mention it
+type GlossaryTerm struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Term      string    `json:"term" db:"term"`
+	Expansion string    `json:"expansion" db:"expansion"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// GlossarySuggestion is a frequent acronym found in a user's documents that has no glossary entry
+type GlossarySuggestion struct {
+	Term        string `json:"term"`
+	Occurrences int    `json:"occurrences"`
+	Documents   int    `json:"documents"`
+}
+
+// MatrixRoom is the private Matrix room the bot shares with a user's linked Matrix account
+type MatrixRoom struct {
+	UserID       string    `json:"user_id" db:"user_id"`
+	MatrixUserID string    `json:"matrix_user_id" db:"matrix_user_id"`
+	RoomID       string    `json:"room_id" db:"room_id"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// DiscordLink connects a user to their Discord account. A pending link has a one-time code
+// (entered with the bot's /link command) and no Discord user yet.
+type DiscordLink struct {
+	UserID        string     `json:"user_id" db:"user_id"`
+	DiscordUserID string     `json:"discord_user_id,omitempty" db:"discord_user_id"`
+	LinkedAt      *time.Time `json:"linked_at,omitempty" db:"linked_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// DataCounts counts the personal data stored for a user in PostgreSQL
+type DataCounts struct {
+	Documents      int   `json:"documents"`
+	DocumentBytes  int64 `json:"document_bytes"`
+	Chunks         int   `json:"chunks"`
+	QueryHistory   int   `json:"query_history"`
+	Conversations  int   `json:"conversations"`
+	FAQEntries     int   `json:"faq_entries"`
+	EmbeddingUsage int   `json:"embedding_usage"`
+}
+
+// BlocklistRule is a phrase, or regular expression, whose matching chunks are dropped from
+// retrieval results so they never reach a prompt
+type BlocklistRule struct {
+	ID            string     `json:"id" db:"id"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	Pattern       string     `json:"pattern" db:"pattern"`
+	Regex         bool       `json:"regex" db:"regex"`
+	MatchCount    int        `json:"match_count" db:"match_count"`
+	LastMatchedAt *time.Time `json:"last_matched_at,omitempty" db:"last_matched_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// EmbeddingBatch is a document whose chunks were submitted to the OpenAI Batch API for
+// embedding; its vectors are written once OpenAI completes the batch
+type EmbeddingBatch struct {
+	ID          string `json:"id" db:"id"`
+	UserID      string `json:"user_id" db:"user_id"`
+	DocumentID  string `json:"document_id" db:"document_id"`
+	BatchID     string `json:"batch_id" db:"batch_id"` // OpenAI's batch ID
+	InputFileID string `json:"input_file_id" db:"input_file_id"`
+	Model       string `json:"model" db:"model"`
+	// Source and Folder are recorded with the embedding usage once the batch completes
+	Source      string     `json:"source" db:"source"`
+	Folder      string     `json:"folder,omitempty" db:"folder"`
+	Status      string     `json:"status" db:"status"` // pending, completed, or discarded
+	LastError   string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// SecretFinding is a credential, such as an API key, private key or password, found in a
+// document's text during ingestion
+type SecretFinding struct {
+	ID         string `json:"id" db:"id"`
+	UserID     string `json:"user_id" db:"user_id"`
+	DocumentID string `json:"document_id" db:"document_id"`
+	Filename   string `json:"filename" db:"filename"`
+	Rule       string `json:"rule" db:"rule"` // e.g. aws_access_key, private_key, password
+	// Preview shows the start of the secret, enough to recognize it without storing it
+	Preview   string    `json:"preview" db:"preview"`
+	Line      int       `json:"line" db:"line"`
+	Redacted  bool      `json:"redacted" db:"redacted"` // Removed from the text before embedding
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// SyncChange is an entry of a user's change feed, the latest change of one document,
+// conversation or message
+type SyncChange struct {
+	Seq       int64     `json:"seq" db:"seq"`
+	Entity    string    `json:"entity" db:"entity"` // document, conversation, or message
+	EntityID  string    `json:"entity_id" db:"entity_id"`
+	Operation string    `json:"operation" db:"operation"` // upsert or delete
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+	// Data is the entity's current state, set for upserts
+	Data interface{} `json:"data,omitempty" db:"-"`
+}
+
+// VectorCollection records the Qdrant name serving one of a user's vector collections
+type VectorCollection struct {
+	UserID string `json:"user_id" db:"user_id"`
+	Scope  string `json:"scope" db:"scope"` // docs, conversations, or summaries
+	// Name is what searches and writes address: a collection, or an alias of one
+	Name string `json:"name" db:"name"`
+	// PreviousName is the name before the last rename, kept working until it is dropped
+	PreviousName string    `json:"previous_name,omitempty" db:"previous_name"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Snapshot records the state of a user's knowledge base at a point in time: every document and
+// the number of vectors stored, so two points in time can be compared
+type Snapshot struct {
+	ID     string `json:"id" db:"id"`
+	UserID string `json:"user_id" db:"user_id"`
+	Label  string `json:"label,omitempty" db:"label"`
+	// Automatic is set for snapshots taken by the daily schedule rather than the user
+	Automatic     bool  `json:"automatic" db:"automatic"`
+	DocumentCount int   `json:"document_count" db:"document_count"`
+	VectorCount   int64 `json:"vector_count" db:"vector_count"`
+	// Documents is only loaded for a single snapshot, not when listing them
+	Documents []SnapshotDocument `json:"documents,omitempty" db:"documents"`
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+}
+
+// SnapshotDocument is a document as it was when a snapshot was taken
+type SnapshotDocument struct {
+	DocumentID  string    `json:"document_id"`
+	Filename    string    `json:"filename"`
+	FileHash    string    `json:"file_hash"`
+	FileSize    int64     `json:"file_size"`
+	TotalChunks int       `json:"total_chunks"`
+	UploadDate  time.Time `json:"upload_date"`
+}
+
+// CrawlJob is a bounded crawl of a website whose pages are indexed as documents
+type CrawlJob struct {
+	ID         string `json:"id" db:"id"`
+	UserID     string `json:"user_id" db:"user_id"`
+	SeedURL    string `json:"seed_url" db:"seed_url"`
+	MaxDepth   int    `json:"max_depth" db:"max_depth"`
+	MaxPages   int    `json:"max_pages" db:"max_pages"`
+	SameDomain bool   `json:"same_domain" db:"same_domain"`
+	Status     string `json:"status" db:"status"` // queued, running, completed, failed, or cancelled
+	// PagesCrawled counts the pages fetched; each is also counted as indexed, unchanged since an
+	// earlier crawl, or failed
+	PagesCrawled   int        `json:"pages_crawled" db:"pages_crawled"`
+	PagesIndexed   int        `json:"pages_indexed" db:"pages_indexed"`
+	PagesUnchanged int        `json:"pages_unchanged" db:"pages_unchanged"`
+	PagesFailed    int        `json:"pages_failed" db:"pages_failed"`
+	CurrentURL     string     `json:"current_url,omitempty" db:"current_url"`
+	Error          string     `json:"error,omitempty" db:"error"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	StartedAt      *time.Time `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty" db:"finished_at"`
 }
@@ -0,0 +1,122 @@
+// Package netguard keeps outbound requests to user-supplied URLs, such as crawled sites and
+// webhooks, on the public internet, so they can't reach the server's own network or cloud
+// metadata endpoints
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxRedirects caps the redirects followed for a request
+const maxRedirects = 10
+
+// ErrBlockedHost is returned for URLs whose host isn't on the public internet
+var ErrBlockedHost = errors.New("host is not a public address")
+
+// blockedPrefixes are special-purpose ranges the address methods don't cover
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, which can reach IPv4 private ranges
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2002::/16"),      // 6to4, which embeds IPv4 addresses
+	netip.MustParsePrefix("fec0::/10"),      // deprecated site-local
+}
+
+// publicAddr reports whether an address is on the public internet, rather than loopback, a
+// private network, link-local (such as cloud metadata at 169.254.169.254) or reserved
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkHost rejects hosts that name a non-public address or an internal name: IP literals outside
+// the public internet, localhost, and single-label names such as the services of a Docker network.
+// Hosts that resolve to non-public addresses are rejected when dialed.
+func checkHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w: %s", ErrBlockedHost, host)
+		}
+		return nil
+	}
+	if host == "" || !strings.Contains(host, ".") || host == "localhost" ||
+		strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") {
+		return fmt.Errorf("%w: %s", ErrBlockedHost, host)
+	}
+	return nil
+}
+
+// dialControl rejects connections to non-public addresses. It runs after DNS resolution, for
+// every address tried, so names that resolve, or are rebound, to internal addresses are caught.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !publicAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedHost, host)
+	}
+	return nil
+}
+
+// checkRedirect follows up to maxRedirects redirects, to http(s) URLs on public hosts only
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if err := CheckURL(req.URL); err != nil {
+		return fmt.Errorf("redirect to %s: %w", req.URL, err)
+	}
+	return nil
+}
+
+// CheckURL accepts absolute http(s) URLs whose host passes checkHost
+func CheckURL(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	return checkHost(u.Hostname())
+}
+
+// NewClient returns an HTTP client that only connects to public addresses, and follows redirects
+// only to URLs CheckURL accepts. It doesn't use a proxy, which would be dialed in the target's
+// place.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   dialControl,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     transport,
+		CheckRedirect: checkRedirect,
+	}
+}
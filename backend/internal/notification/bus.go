@@ -0,0 +1,98 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+)
+
+// Delivery channels
+const (
+	ChannelEmail    = "email"
+	ChannelPush     = "push"
+	ChannelTelegram = "telegram"
+	ChannelWebhook  = "webhook"
+)
+
+// Router decides which channels deliver an event to a user. ok is false when the user has no
+// preference for the event, in which case every channel is used.
+type Router interface {
+	Channels(ctx context.Context, userID, event string) (channels []string, ok bool, err error)
+}
+
+// TargetLookup resolves a user's target on a channel (a Telegram chat ID, a push topic); ""
+// means the user hasn't set one up and the channel is skipped
+type TargetLookup func(ctx context.Context, userID, channel string) (string, error)
+
+// Bus is a Notifier that routes each notification to the channels the user chose for its event,
+// so the code emitting events doesn't need to know how they are delivered
+type Bus struct {
+	channels map[string]Notifier
+	router   Router
+}
+
+// NewBus creates a bus routing with router; channels are added with Register
+func NewBus(router Router) *Bus {
+	return &Bus{
+		channels: make(map[string]Notifier),
+		router:   router,
+	}
+}
+
+// Register adds a delivery channel
+func (b *Bus) Register(channel string, notifier Notifier) {
+	b.channels[channel] = notifier
+}
+
+// Channels returns the names of the registered channels, sorted
+func (b *Bus) Channels() []string {
+	names := make([]string, 0, len(b.channels))
+	for name := range b.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasChannel reports whether the channel is registered
+func (b *Bus) HasChannel(channel string) bool {
+	_, ok := b.channels[channel]
+	return ok
+}
+
+// Notify delivers the notification through the user's channels for the event, even if some fail
+func (b *Bus) Notify(ctx context.Context, notification Notification) error {
+	channels, ok, err := b.router.Channels(ctx, notification.UserID, notification.Event)
+	if err != nil {
+		return fmt.Errorf("failed to route %s notification: %w", notification.Event, err)
+	}
+	if !ok {
+		channels = b.Channels()
+	}
+
+	var errs []error
+	for _, channel := range channels {
+		notifier, registered := b.channels[channel]
+		if !registered {
+			logger.DebugCtx(ctx, "Skipping unavailable notification channel", "channel", channel, "event", notification.Event)
+			continue
+		}
+		if err := notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Send delivers the notification through one channel, bypassing the user's routes (used to test
+// a channel)
+func (b *Bus) Send(ctx context.Context, channel string, notification Notification) error {
+	notifier, ok := b.channels[channel]
+	if !ok {
+		return fmt.Errorf("notification channel %s is not available", channel)
+	}
+	return notifier.Notify(ctx, notification)
+}
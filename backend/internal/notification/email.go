@@ -0,0 +1,47 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/mailer"
+)
+
+// AddressLookup resolves the email address of a user
+type AddressLookup func(ctx context.Context, userID string) (string, error)
+
+// EmailNotifier is a Notifier that emails notifications through the mailer. Events with a
+// template of their own (e.g. digest.created) use it; the rest use the generic template.
+type EmailNotifier struct {
+	mailer *mailer.Mailer
+	appURL string
+	lookup AddressLookup
+}
+
+// NewEmailNotifier creates an email notifier
+func NewEmailNotifier(m *mailer.Mailer, appURL string, lookup AddressLookup) *EmailNotifier {
+	return &EmailNotifier{
+		mailer: m,
+		appURL: appURL,
+		lookup: lookup,
+	}
+}
+
+// Notify emails the notification to the user
+func (n *EmailNotifier) Notify(ctx context.Context, notification Notification) error {
+	to, err := n.lookup(ctx, notification.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve email address: %w", err)
+	}
+
+	templateName := mailer.TemplateNotification
+	if mailer.HasTemplate(notification.Event) {
+		templateName = notification.Event
+	}
+
+	return n.mailer.Send(ctx, notification.UserID, to, templateName, mailer.NotificationData{
+		Title:  notification.Title,
+		Body:   notification.Body,
+		AppURL: n.appURL,
+	})
+}
@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"context"
+	"errors"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+)
+
+// Notification represents a message delivered to a user
+type Notification struct {
+	UserID string
+	Event  string
+	Title  string
+	Body   string
+	Data   map[string]interface{}
+}
+
+// Notifier delivers notifications to users
+type Notifier interface {
+	// Notify delivers a notification to the user
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier is a Notifier that writes notifications to the application log
+type LogNotifier struct{}
+
+// NewLogNotifier creates a new log notifier
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify logs the notification
+func (n *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	logger.InfoCtx(ctx, "Notification",
+		"user_id", notification.UserID,
+		"event", notification.Event,
+		"title", notification.Title,
+	)
+	return nil
+}
+
+// MultiNotifier delivers each notification through several notifiers
+type MultiNotifier struct {
+	notifiers []Notifier
+}
+
+// NewMultiNotifier creates a notifier that fans out to the given notifiers
+func NewMultiNotifier(notifiers ...Notifier) *MultiNotifier {
+	return &MultiNotifier{notifiers: notifiers}
+}
+
+// Notify delivers the notification through every notifier, even if some fail
+func (m *MultiNotifier) Notify(ctx context.Context, notification Notification) error {
+	var errs []error
+	for _, notifier := range m.notifiers {
+		if err := notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
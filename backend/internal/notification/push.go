@@ -0,0 +1,88 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// PushNotifier is a Notifier that sends phone and desktop push notifications through an ntfy
+// server (ntfy.sh or self-hosted) to the topic the user subscribed to in the ntfy app
+type PushNotifier struct {
+	serverURL  string
+	token      string
+	appURL     string
+	lookup     TargetLookup
+	httpClient *httpretry.Client
+}
+
+// NewPushNotifier creates a push notifier for the ntfy server. token, an ntfy access token, may be
+// empty for servers that allow anonymous publishing; notifications open appURL when tapped.
+func NewPushNotifier(serverURL, token, appURL string, lookup TargetLookup) *PushNotifier {
+	return &PushNotifier{
+		serverURL:  strings.TrimRight(serverURL, "/"),
+		token:      token,
+		appURL:     appURL,
+		lookup:     lookup,
+		httpClient: httpretry.New("ntfy", 30*time.Second),
+	}
+}
+
+// ntfyPublishRequest represents an ntfy JSON publish request
+type ntfyPublishRequest struct {
+	Topic   string   `json:"topic"`
+	Title   string   `json:"title"`
+	Message string   `json:"message"`
+	Tags    []string `json:"tags,omitempty"`
+	Click   string   `json:"click,omitempty"`
+}
+
+// Notify pushes the notification to the user's topic; users without a topic are skipped
+func (n *PushNotifier) Notify(ctx context.Context, notification Notification) error {
+	topic, err := n.lookup(ctx, notification.UserID, ChannelPush)
+	if err != nil {
+		return fmt.Errorf("failed to resolve push topic: %w", err)
+	}
+	if topic == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(ntfyPublishRequest{
+		Topic:   topic,
+		Title:   notification.Title,
+		Message: notification.Body,
+		Tags:    []string{notification.Event},
+		Click:   n.appURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.serverURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return fmt.Errorf("ntfy error (status %d): %s", resp.StatusCode, string(errBody))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// telegramMaxMessage is the longest text a Telegram message may hold
+const telegramMaxMessage = 4096
+
+// TelegramNotifier is a Notifier that sends notifications as Telegram bot messages to the chat
+// the user registered
+type TelegramNotifier struct {
+	apiURL     string
+	lookup     TargetLookup
+	httpClient *httpretry.Client
+}
+
+// NewTelegramNotifier creates a Telegram notifier for the bot token
+func NewTelegramNotifier(botToken string, lookup TargetLookup) *TelegramNotifier {
+	return &TelegramNotifier{
+		apiURL:     "https://api.telegram.org/bot" + botToken,
+		lookup:     lookup,
+		httpClient: httpretry.New("telegram", 30*time.Second),
+	}
+}
+
+// telegramSendMessageRequest represents a Telegram sendMessage request
+type telegramSendMessageRequest struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// Notify messages the notification to the user's chat; users without a chat are skipped
+func (n *TelegramNotifier) Notify(ctx context.Context, notification Notification) error {
+	chatID, err := n.lookup(ctx, notification.UserID, ChannelTelegram)
+	if err != nil {
+		return fmt.Errorf("failed to resolve Telegram chat: %w", err)
+	}
+	if chatID == "" {
+		return nil
+	}
+
+	text := []rune(notification.Title + "\n\n" + notification.Body)
+	if len(text) > telegramMaxMessage {
+		text = append(text[:telegramMaxMessage-1], '…')
+	}
+
+	body, err := json.Marshal(telegramSendMessageRequest{ChatID: chatID, Text: string(text)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.apiURL+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return fmt.Errorf("telegram error (status %d): %s", resp.StatusCode, string(errBody))
+	}
+	return nil
+}
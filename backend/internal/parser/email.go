@@ -0,0 +1,366 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// maxMIMEDepth bounds how deeply nested multipart bodies are read
+const maxMIMEDepth = 8
+
+// headerGetter reads a message's or a MIME part's headers
+type headerGetter interface {
+	Get(key string) string
+}
+
+// EML emits the text of an email message as a page of its own, numbered 0, with its sender,
+// recipients, date and subject in its metadata
+func EML(ctx context.Context, r io.Reader, emit func(Page) error) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	page, err := emailPage(raw)
+	if err != nil {
+		return err
+	}
+	return emit(page)
+}
+
+// MBOX streams each message of an mbox mail archive to emit as a page of its own, as EML does,
+// recording the message's 1-based position in the archive as "message" in its metadata.
+// Messages that can't be parsed are skipped.
+func MBOX(ctx context.Context, r io.Reader, emit func(Page) error) error {
+	reader := bufio.NewReader(r)
+	var message bytes.Buffer
+	number := 0
+
+	flush := func() error {
+		if message.Len() == 0 {
+			return nil
+		}
+		number++
+		page, err := emailPage(message.Bytes())
+		message.Reset()
+		if err != nil {
+			return nil
+		}
+		page.Metadata["message"] = number
+		return emit(page)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			switch {
+			case mboxSeparator.Match(line):
+				// The separator line starts the next message
+				if err := flush(); err != nil {
+					return err
+				}
+			case mboxEscapedFrom.Match(line):
+				// mboxrd escapes body lines starting with "From " with a ">"
+				message.Write(line[1:])
+			default:
+				message.Write(line)
+			}
+		}
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read mailbox: %w", err)
+		}
+	}
+}
+
+var (
+	// mboxSeparator matches the line starting a message, e.g. "From alex@example.com Mon Jun  3
+	// 10:02:00 2024"
+	mboxSeparator = regexp.MustCompile(`^From \S+ .*\d{1,2}:\d{2}`)
+	// mboxEscapedFrom matches a body line escaped by mboxrd
+	mboxEscapedFrom = regexp.MustCompile(`^>+From `)
+)
+
+// emailPage parses a message into a page of its headers and body text without quoted replies
+func emailPage(raw []byte) (Page, error) {
+	// Messages saved from a mailbox may keep its separator line
+	if mboxSeparator.Match(raw) {
+		if end := bytes.IndexByte(raw, '\n'); end >= 0 {
+			raw = raw[end+1:]
+		}
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to parse email: %w", err)
+	}
+
+	decoder := &mime.WordDecoder{CharsetReader: charsetReader}
+	decode := func(value string) string {
+		decoded, err := decoder.DecodeHeader(value)
+		if err != nil {
+			return strings.TrimSpace(value)
+		}
+		return strings.TrimSpace(decoded)
+	}
+
+	metadata := map[string]interface{}{}
+	var header strings.Builder
+	addresses := func(key, field string) {
+		value := msg.Header.Get(field)
+		if value == "" {
+			return
+		}
+		display := decode(value)
+		parser := mail.AddressParser{WordDecoder: decoder}
+		if list, err := parser.ParseList(value); err == nil && len(list) > 0 {
+			emails := make([]string, len(list))
+			for i, address := range list {
+				emails[i] = strings.ToLower(address.Address)
+			}
+			metadata[key] = strings.Join(emails, ", ")
+			if key == "from" && list[0].Name != "" {
+				metadata["from_name"] = list[0].Name
+			}
+		} else {
+			metadata[key] = display
+		}
+		fmt.Fprintf(&header, "%s: %s\n", field, display)
+	}
+
+	addresses("from", "From")
+	addresses("to", "To")
+	addresses("cc", "Cc")
+	if date, err := msg.Header.Date(); err == nil {
+		metadata["date"] = date.Format("2006-01-02")
+		fmt.Fprintf(&header, "Date: %s\n", date.Format("Mon, 2 Jan 2006 15:04 -0700"))
+	}
+	if subject := decode(msg.Header.Get("Subject")); subject != "" {
+		metadata["subject"] = subject
+		fmt.Fprintf(&header, "Subject: %s\n", subject)
+	}
+	if messageID := strings.Trim(msg.Header.Get("Message-Id"), " <>"); messageID != "" {
+		metadata["message_id"] = messageID
+	}
+
+	body, err := mimeText(msg.Header, msg.Body, 0)
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to read email body: %w", err)
+	}
+	text := header.String()
+	if body = StripQuotedReplies(body); body != "" {
+		text += "\n" + body
+	}
+	return Page{Text: text, Metadata: metadata}, nil
+}
+
+// mimeText returns the text of a message or MIME part: the plain text of a multipart
+// alternative when it has one, else its HTML as text, and the text parts of other multiparts
+// joined. Attachments are skipped.
+func mimeText(header headerGetter, body io.Reader, depth int) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if strings.HasPrefix(header.Get("Content-Disposition"), "attachment") {
+		return "", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMIMEDepth || params["boundary"] == "" {
+			return "", nil
+		}
+		reader := multipart.NewReader(body, params["boundary"])
+		var texts []string
+		var plain, htmlText string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := mimeText(part.Header, part, depth+1)
+			if err != nil {
+				return "", err
+			}
+			if text == "" {
+				continue
+			}
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			switch {
+			case partType == "text/html" && htmlText == "":
+				htmlText = text
+			case partType != "text/html" && plain == "":
+				plain = text
+			}
+			texts = append(texts, text)
+		}
+		if mediaType == "multipart/alternative" {
+			if plain != "" {
+				return plain, nil
+			}
+			return htmlText, nil
+		}
+		return strings.Join(texts, "\n\n"), nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", nil
+	}
+	// A malformed encoding keeps the text decoded before it
+	content, err := io.ReadAll(transferDecoder(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil && len(content) == 0 {
+		return "", err
+	}
+	text := decodeCharset(params["charset"], content)
+	if mediaType == "text/html" {
+		text = htmlToText(text)
+	}
+	return strings.ReplaceAll(text, "\r\n", "\n"), nil
+}
+
+// transferDecoder decodes a body's content transfer encoding. Quoted-printable MIME parts are
+// already decoded by multipart, and then have no encoding header left.
+func transferDecoder(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// charsetReader converts text in a named charset to UTF-8 for header decoding
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// decodeCharset converts body text to UTF-8, replacing invalid bytes when the charset is unknown
+func decodeCharset(charset string, content []byte) string {
+	if charset != "" && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "us-ascii") {
+		if encoding, err := htmlindex.Get(charset); err == nil {
+			if decoded, err := encoding.NewDecoder().Bytes(content); err == nil {
+				return string(decoded)
+			}
+		}
+	}
+	if utf8.Valid(content) {
+		return string(content)
+	}
+	return strings.ToValidUTF8(string(content), "�")
+}
+
+var (
+	// htmlHidden matches elements whose content isn't shown
+	htmlHidden = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	// htmlQuote matches a quoted message
+	htmlQuote = regexp.MustCompile(`(?is)<blockquote\b.*?</blockquote>`)
+	// htmlBreak matches tags that end a line
+	htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|tr|li|h[1-6]|blockquote)>`)
+	// htmlTag matches any other tag or comment
+	htmlTag = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>`)
+	// blankLines matches runs of blank lines
+	blankLines = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+)
+
+// htmlToText renders an HTML body as plain text, one line per paragraph, block or line break
+func htmlToText(body string) string {
+	body = htmlHidden.ReplaceAllString(body, "")
+	// HTML mail quotes replies in blockquotes
+	body = htmlQuote.ReplaceAllString(body, "\n")
+	body = htmlBreak.ReplaceAllString(body, "\n")
+	body = htmlTag.ReplaceAllString(body, "")
+	body = html.UnescapeString(body)
+	body = strings.ReplaceAll(body, "\u00a0", " ")
+	return strings.TrimSpace(blankLines.ReplaceAllString(body, "\n\n"))
+}
+
+var (
+	// replyAttribution matches the end of the line introducing a quoted reply, e.g. "On Mon,
+	// 3 Jun 2024 at 10:02, Alex <alex@example.com> wrote:", which clients may wrap in two
+	replyAttribution = regexp.MustCompile(`(?i)\bwrote:\s*$`)
+	// originalMessage matches Outlook's separator before a quoted message
+	originalMessage = regexp.MustCompile(`(?i)^-{2,}\s*original message\s*-{2,}$`)
+	// quotedHeader matches the first line of the header block Outlook puts above a quoted message
+	quotedHeader = regexp.MustCompile(`(?i)^\*?from:\*?\s`)
+	// quotedHeaderField matches the header lines that follow it
+	quotedHeaderField = regexp.MustCompile(`(?i)^\*?(sent|date|to|subject):\*?\s`)
+	// underscores matches the rule Outlook puts above that header block
+	underscores = regexp.MustCompile(`^_{10,}$`)
+)
+
+// StripQuotedReplies removes the messages quoted in a reply: lines quoted with ">", and the
+// rest of the body from an attribution line ("On ... wrote:") or an Outlook "Original Message"
+// separator or header block. Forwarded messages are kept.
+func StripQuotedReplies(body string) string {
+	lines := strings.Split(body, "\n")
+	var kept []string
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+		if isQuoteStart(lines, i) {
+			break
+		}
+		kept = append(kept, strings.TrimRight(lines[i], " \t\r"))
+	}
+
+	// Drop the rule and blank lines left above a cut
+	for len(kept) > 0 {
+		last := strings.TrimSpace(kept[len(kept)-1])
+		if last != "" && !underscores.MatchString(last) {
+			break
+		}
+		kept = kept[:len(kept)-1]
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// isQuoteStart reports whether a quoted message starts at the line
+func isQuoteStart(lines []string, i int) bool {
+	line := strings.TrimSpace(lines[i])
+	switch {
+	case originalMessage.MatchString(line):
+		return true
+	case strings.HasPrefix(line, "On ") || strings.HasPrefix(line, "on "):
+		if replyAttribution.MatchString(line) {
+			return true
+		}
+		return i+1 < len(lines) && replyAttribution.MatchString(strings.TrimSpace(lines[i+1]))
+	case quotedHeader.MatchString(line):
+		// A header block has at least two more fields in the lines that follow
+		fields := 0
+		for j := i + 1; j < len(lines) && j <= i+5; j++ {
+			if quotedHeaderField.MatchString(strings.TrimSpace(lines[j])) {
+				fields++
+			}
+		}
+		return fields >= 2
+	}
+	return false
+}
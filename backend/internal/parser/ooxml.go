@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Office Open XML files (.pptx, .xlsx) are zip archives of XML parts, linked to each other by
+// relationship parts
+
+// relationships maps a part's relationship IDs to the parts they target
+type relationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// decodePart decodes an XML part of the archive into v
+func decodePart(parts map[string]*zip.File, name string, v interface{}) error {
+	f, ok := parts[name]
+	if !ok {
+		return fmt.Errorf("file is missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// partRelationships returns a part's relationships; a part without any has none
+func partRelationships(parts map[string]*zip.File, name string) (*relationships, error) {
+	relsName := path.Join(path.Dir(name), "_rels", path.Base(name)+".rels")
+	rels := &relationships{}
+	if _, ok := parts[relsName]; !ok {
+		return rels, nil
+	}
+	if err := decodePart(parts, relsName, rels); err != nil {
+		return nil, err
+	}
+	return rels, nil
+}
+
+// target returns the archive path of the part a relationship of the given type points to
+func (r *relationships) target(source, id, relType string) (string, bool) {
+	for _, rel := range r.Relationships {
+		if rel.ID == id && rel.Type == relType {
+			return resolveTarget(source, rel.Target), true
+		}
+	}
+	return "", false
+}
+
+// firstOfType returns the archive path of the first part related by the given type
+func (r *relationships) firstOfType(source, relType string) (string, bool) {
+	for _, rel := range r.Relationships {
+		if rel.Type == relType {
+			return resolveTarget(source, rel.Target), true
+		}
+	}
+	return "", false
+}
+
+// resolveTarget returns the archive path of a relationship target, which is relative to the
+// source part's directory unless it starts with a slash
+func resolveTarget(source, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(path.Dir(source), target)
+}
@@ -0,0 +1,136 @@
+// Package parser extracts the text of uploaded files, keeping track of the page each part of
+// the text is on so chunks can be cited by page
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+)
+
+// Page is the text of one page of a document
+type Page struct {
+	// Number is the page's 1-based number, 0 for formats without pages
+	Number int
+	Text   string
+	// Metadata is added to the page's chunks. A page with metadata, such as a slide, is chunked
+	// on its own rather than as part of the document's running text.
+	Metadata map[string]interface{}
+}
+
+// PageSeparator is put between pages when they are joined into one text
+const PageSeparator = "\n\n"
+
+// OCR reads the text in an image, such as a scanned page or a photo of handwritten notes
+type OCR interface {
+	Text(ctx context.Context, image []byte, contentType string) (string, error)
+}
+
+// ImageDescriber describes an image upload: what it shows as well as the text in it, so photos and
+// screenshots can be found by their content
+type ImageDescriber interface {
+	Describe(ctx context.Context, image []byte, contentType string) (string, error)
+}
+
+// imageTypes maps the image extensions that can be read with OCR to their content types
+var imageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+}
+
+// Extract streams the text of a file of the given size to emit page by page, reading it by
+// its extension. PDFs and presentations are read page by page, spreadsheets in groups of rows
+// and mail archives message by message; plain text formats, email messages and images are read
+// whole and become a single page numbered 0.
+// Images, and PDF pages without extractable text, are read with ocr; when it's nil images can't
+// be extracted and such PDF pages are left out. Images are described as well when ocr is an
+// ImageDescriber.
+func Extract(ctx context.Context, filename string, r io.ReaderAt, size int64, ocr OCR, emit func(Page) error) error {
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".txt", ".md", ".json":
+		content, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		return emit(Page{Text: string(content)})
+	case ".pdf":
+		return PDF(ctx, r, size, ocr, emit)
+	case ".pptx":
+		return PPTX(ctx, r, size, emit)
+	case ".csv":
+		return CSV(ctx, io.NewSectionReader(r, 0, size), emit)
+	case ".xlsx":
+		return XLSX(ctx, r, size, emit)
+	case ".eml":
+		return EML(ctx, io.NewSectionReader(r, 0, size), emit)
+	case ".mbox":
+		return MBOX(ctx, io.NewSectionReader(r, 0, size), emit)
+	case ".png", ".jpg", ".jpeg":
+		if ocr == nil {
+			return fmt.Errorf("%w: image files need OCR, which is not configured", apperror.ErrUnsupportedFileType)
+		}
+		content, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		read := ocr.Text
+		if describer, ok := ocr.(ImageDescriber); ok {
+			read = describer.Describe
+		}
+		text, err := read(ctx, content, imageTypes[ext])
+		if err != nil {
+			return fmt.Errorf("failed to read image text: %w", err)
+		}
+		return emit(Page{Text: text})
+	default:
+		return fmt.Errorf("%w: %s", apperror.ErrUnsupportedFileType, ext)
+	}
+}
+
+// Supported reports whether Extract can read a file of this type
+func Supported(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf", ".txt", ".md", ".json", ".csv", ".png", ".jpg", ".jpeg", ".pptx", ".xlsx", ".eml", ".mbox":
+		return true
+	}
+	return false
+}
+
+// ReadsWhole reports whether a file type is read into memory whole rather than page by page
+func ReadsWhole(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf", ".pptx", ".csv", ".xlsx", ".mbox":
+		return false
+	}
+	return true
+}
+
+// Join returns the text of the pages as one, separated by PageSeparator
+func Join(pages []Page) string {
+	texts := make([]string, len(pages))
+	for i, page := range pages {
+		texts[i] = page.Text
+	}
+	return strings.Join(texts, PageSeparator)
+}
+
+// PageAt returns the number of the page at a byte offset of the pages' joined text. An offset in
+// a separator belongs to the page before it.
+func PageAt(pages []Page, offset int) int {
+	end := 0
+	for _, page := range pages {
+		end += len(page.Text) + len(PageSeparator)
+		if offset < end {
+			return page.Number
+		}
+	}
+	if len(pages) == 0 {
+		return 0
+	}
+	return pages[len(pages)-1].Number
+}
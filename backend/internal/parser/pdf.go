@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDF streams the text of each page of a PDF of the given size to emit, reading pages from r as
+// they are needed. Pages without text, such as scanned pages, are read from their images with
+// ocr; when it's nil, or a page has no readable image, the page is left out, so page numbers may
+// skip.
+func PDF(ctx context.Context, r io.ReaderAt, size int64, ocr OCR, emit func(Page) error) error {
+	reader, err := pdf.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	var scans *scanReader
+	if ocr != nil {
+		scans = &scanReader{r: io.NewSectionReader(r, 0, size), ocr: ocr}
+	}
+
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= reader.NumPage(); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p := reader.Page(i)
+		if p.V.IsNull() {
+			continue
+		}
+		// Pages usually share fonts, so each font's character map is parsed once
+		for _, name := range p.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := p.Font(name)
+				fonts[name] = &font
+			}
+		}
+
+		text, err := p.GetPlainText(fonts)
+		if err != nil {
+			return fmt.Errorf("failed to extract text of page %d: %w", i, err)
+		}
+		if strings.TrimSpace(text) == "" {
+			if scans == nil {
+				continue
+			}
+			if text, err = scans.page(ctx, i); err != nil {
+				return err
+			}
+			if text == "" {
+				continue
+			}
+		}
+		if err := emit(Page{Number: i, Text: text}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
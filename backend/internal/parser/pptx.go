@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Slide sections, recorded in the "section" metadata of a slide's pages
+const (
+	SectionSlide = "slide"
+	SectionNotes = "notes"
+)
+
+// Relationship types linking a presentation's parts
+const (
+	relTypeSlide      = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide"
+	relTypeNotesSlide = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide"
+)
+
+// drawingML is the namespace of the text elements in slides and notes
+const drawingML = "http://schemas.openxmlformats.org/drawingml/2006/main"
+
+// presentation lists a presentation's slides in order
+type presentation struct {
+	Slides []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sldIdLst>sldId"`
+}
+
+// PPTX streams the text of each slide of a PowerPoint presentation of the given size to emit,
+// followed by the slide's speaker notes as a page of their own. Both are numbered 0 and carry
+// the slide's 1-based number and section in their metadata, so they are chunked apart and
+// cited by slide.
+func PPTX(ctx context.Context, r io.ReaderAt, size int64, emit func(Page) error) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to open presentation: %w", err)
+	}
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+
+	var pres presentation
+	if err := decodePart(parts, "ppt/presentation.xml", &pres); err != nil {
+		return err
+	}
+	presRels, err := partRelationships(parts, "ppt/presentation.xml")
+	if err != nil {
+		return err
+	}
+
+	for i, slide := range pres.Slides {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		number := i + 1
+		slidePath, ok := presRels.target("ppt/presentation.xml", slide.RelID, relTypeSlide)
+		if !ok {
+			continue
+		}
+
+		text, err := partText(parts, slidePath)
+		if err != nil {
+			return fmt.Errorf("failed to extract text of slide %d: %w", number, err)
+		}
+		if strings.TrimSpace(text) != "" {
+			if err := emit(slidePage(number, SectionSlide, text)); err != nil {
+				return err
+			}
+		}
+
+		slideRels, err := partRelationships(parts, slidePath)
+		if err != nil {
+			return err
+		}
+		notesPath, ok := slideRels.firstOfType(slidePath, relTypeNotesSlide)
+		if !ok {
+			continue
+		}
+		notes, err := partText(parts, notesPath)
+		if err != nil {
+			return fmt.Errorf("failed to extract notes of slide %d: %w", number, err)
+		}
+		if strings.TrimSpace(notes) != "" {
+			if err := emit(slidePage(number, SectionNotes, notes)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// slidePage returns a page of a slide's text or notes
+func slidePage(number int, section, text string) Page {
+	return Page{
+		Text:     text,
+		Metadata: map[string]interface{}{"slide": number, "section": section},
+	}
+}
+
+// partText returns the text of a slide or notes part, a line per paragraph. Fields, such as
+// slide numbers and dates, are left out.
+func partText(parts map[string]*zip.File, name string) (string, error) {
+	f, ok := parts[name]
+	if !ok {
+		return "", nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	var b strings.Builder
+	decoder := xml.NewDecoder(rc)
+	inText, inField := false, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != drawingML {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "fld":
+				inField++
+			case "br":
+				b.WriteString("\n")
+			}
+		case xml.EndElement:
+			if t.Name.Space != drawingML {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "fld":
+				inField--
+			case "p":
+				b.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText && inField == 0 {
+				b.Write(t)
+			}
+		}
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func init() {
+	// pdfcpu otherwise writes a config directory under the user's home, and exits the process if
+	// it can't
+	model.ConfigPath = "disable"
+}
+
+// ocrImageTypes maps the image formats pdfcpu extracts that can be read with OCR to their
+// content types
+var ocrImageTypes = map[string]string{
+	"png": "image/png",
+	"jpg": "image/jpeg",
+}
+
+// scanReader reads the text of PDF pages without extractable text from the images on them. The
+// PDF is loaded with pdfcpu on the first scanned page and reused for the rest.
+type scanReader struct {
+	r   io.ReadSeeker
+	ocr OCR
+	pdf *model.Context
+	// failed is set once the PDF couldn't be loaded, so it isn't tried for every page
+	failed bool
+}
+
+// page returns the text of a scanned page, empty when it has no readable image. A PDF whose
+// images can't be extracted is logged and its scanned pages left out, so the rest of the
+// document is still ingested.
+func (s *scanReader) page(ctx context.Context, number int) (string, error) {
+	if s.failed {
+		return "", nil
+	}
+	if s.pdf == nil {
+		conf := model.NewDefaultConfiguration()
+		conf.Cmd = model.EXTRACTIMAGES
+		pdf, err := api.ReadValidateAndOptimize(s.r, conf)
+		if err != nil {
+			logger.Warn("Failed to load PDF for OCR of scanned pages", "error", err)
+			s.failed = true
+			return "", nil
+		}
+		s.pdf = pdf
+	}
+
+	images, err := pdfcpu.ExtractPageImages(s.pdf, number, false)
+	if err != nil {
+		logger.Warn("Failed to extract images of scanned PDF page", "page", number, "error", err)
+		return "", nil
+	}
+
+	// A page may be stored as several images, e.g. in strips; read them in document order
+	objNrs := make([]int, 0, len(images))
+	for objNr := range images {
+		objNrs = append(objNrs, objNr)
+	}
+	sort.Ints(objNrs)
+
+	var texts []string
+	for _, objNr := range objNrs {
+		image := images[objNr]
+		contentType, ok := ocrImageTypes[image.FileType]
+		if !ok || image.Thumb || image.IsImgMask {
+			continue
+		}
+		data, err := io.ReadAll(image)
+		if err != nil {
+			return "", fmt.Errorf("failed to read image of page %d: %w", number, err)
+		}
+		text, err := s.ocr.Text(ctx, data, contentType)
+		if err != nil {
+			return "", fmt.Errorf("failed to read text of page %d: %w", number, err)
+		}
+		if strings.TrimSpace(text) != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	return strings.Join(texts, "\n"), nil
+}
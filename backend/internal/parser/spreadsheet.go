@@ -0,0 +1,443 @@
+package parser
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rowGroupSize is the most text of rows put in one page, in characters. Even rows full of
+// numbers, which take more tokens per character than prose, stay below the 128-token chunk size,
+// so a group of rows stays one chunk with every row's headers.
+const rowGroupSize = 300
+
+// relTypeWorksheet links a workbook to its sheets
+const relTypeWorksheet = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet"
+
+// rowGrouper renders a sheet's rows as "Header: value" lines and emits them in groups of up to
+// rowGroupSize, recording the sheet and rows of each group in its metadata
+type rowGrouper struct {
+	sheet   string
+	headers []string
+	emit    func(Page) error
+
+	text     strings.Builder
+	firstRow int
+	lastRow  int
+}
+
+// add renders a row; the first row added is the sheet's header row. number is the row's 1-based
+// number in the sheet.
+func (g *rowGrouper) add(number int, values []string) error {
+	if g.headers == nil {
+		g.headers = values
+		return nil
+	}
+
+	var row strings.Builder
+	for i, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		header := ""
+		if i < len(g.headers) {
+			header = strings.TrimSpace(g.headers[i])
+		}
+		if header == "" {
+			header = "Column " + columnName(i)
+		}
+		fmt.Fprintf(&row, "%s: %s\n", header, value)
+	}
+	if row.Len() == 0 {
+		return nil
+	}
+
+	if g.text.Len() > 0 && g.text.Len()+row.Len() > rowGroupSize {
+		if err := g.flush(); err != nil {
+			return err
+		}
+	}
+	if g.text.Len() == 0 {
+		g.firstRow = number
+	} else {
+		g.text.WriteString("\n")
+	}
+	g.text.WriteString(row.String())
+	g.lastRow = number
+	return nil
+}
+
+// flush emits the rows grouped so far
+func (g *rowGrouper) flush() error {
+	if g.text.Len() == 0 {
+		return nil
+	}
+	metadata := map[string]interface{}{"row_start": g.firstRow, "row_end": g.lastRow}
+	if g.sheet != "" {
+		metadata["sheet"] = g.sheet
+	}
+	text := strings.TrimSpace(g.text.String())
+	g.text.Reset()
+	return g.emit(Page{Text: text, Metadata: metadata})
+}
+
+// columnName returns the spreadsheet letters of a 0-based column index (0 is A, 26 is AA)
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// CSV streams the rows of a CSV file to emit in groups, each row rendered as "Header: value"
+// lines with the headers from the first row
+func CSV(ctx context.Context, r io.Reader, emit func(Page) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows := &rowGrouper{emit: emit}
+	for number := 1; ; number++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		if err := rows.add(number, record); err != nil {
+			return err
+		}
+	}
+	return rows.flush()
+}
+
+// workbook lists a workbook's sheets in order
+type workbook struct {
+	Sheets []struct {
+		Name  string `xml:"name,attr"`
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// XLSX streams the rows of each sheet of an Excel workbook of the given size to emit in groups,
+// like CSV, with each sheet's first non-empty row as its headers. Cells formatted as dates are
+// rendered as dates rather than Excel's day numbers.
+func XLSX(ctx context.Context, r io.ReaderAt, size int64, emit func(Page) error) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to open workbook: %w", err)
+	}
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+
+	var book workbook
+	if err := decodePart(parts, "xl/workbook.xml", &book); err != nil {
+		return err
+	}
+	bookRels, err := partRelationships(parts, "xl/workbook.xml")
+	if err != nil {
+		return err
+	}
+	sharedStrings, err := readSharedStrings(parts)
+	if err != nil {
+		return err
+	}
+	dateStyles, err := readDateStyles(parts)
+	if err != nil {
+		return err
+	}
+
+	for _, sheet := range book.Sheets {
+		sheetPath, ok := bookRels.target("xl/workbook.xml", sheet.RelID, relTypeWorksheet)
+		if !ok {
+			continue
+		}
+		rows := &rowGrouper{sheet: sheet.Name, emit: emit}
+		cells := &cellReader{sharedStrings: sharedStrings, dateStyles: dateStyles}
+		if err := cells.readSheet(ctx, parts, sheetPath, rows.add); err != nil {
+			return fmt.Errorf("failed to read sheet %s: %w", sheet.Name, err)
+		}
+		if err := rows.flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readSharedStrings returns the workbook's shared strings, which cells refer to by index
+func readSharedStrings(parts map[string]*zip.File) ([]string, error) {
+	f, ok := parts["xl/sharedStrings.xml"]
+	if !ok {
+		return nil, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shared strings: %w", err)
+	}
+	defer rc.Close()
+
+	// A string is the text of its runs; phonetic hints (rPh) are left out
+	var stringsList []string
+	var current strings.Builder
+	inText, inPhonetic := false, false
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse shared strings: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				current.Reset()
+			case "t":
+				inText = true
+			case "rPh":
+				inPhonetic = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				stringsList = append(stringsList, current.String())
+			case "t":
+				inText = false
+			case "rPh":
+				inPhonetic = false
+			}
+		case xml.CharData:
+			if inText && !inPhonetic {
+				current.Write(t)
+			}
+		}
+	}
+	return stringsList, nil
+}
+
+// styleSheet holds the number formats of a workbook's cell styles
+type styleSheet struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+// readDateStyles returns which cell styles, by index, format numbers as dates: Excel's built-in
+// date formats and custom formats with day, month or year codes
+func readDateStyles(parts map[string]*zip.File) (map[int]bool, error) {
+	dateStyles := map[int]bool{}
+	if _, ok := parts["xl/styles.xml"]; !ok {
+		return dateStyles, nil
+	}
+	var styles styleSheet
+	if err := decodePart(parts, "xl/styles.xml", &styles); err != nil {
+		return nil, err
+	}
+
+	dateFormats := map[int]bool{}
+	for id := 14; id <= 22; id++ {
+		dateFormats[id] = true
+	}
+	for _, id := range []int{45, 46, 47} {
+		dateFormats[id] = true
+	}
+	for _, format := range styles.NumFmts {
+		dateFormats[format.ID] = isDateFormat(format.Code)
+	}
+	for i, xf := range styles.CellXfs {
+		if dateFormats[xf.NumFmtID] {
+			dateStyles[i] = true
+		}
+	}
+	return dateStyles, nil
+}
+
+// isDateFormat reports whether a number format code renders a date, ignoring quoted text and
+// bracketed colors and conditions
+func isDateFormat(code string) bool {
+	quoted, bracketed := false, false
+	for _, c := range strings.ToLower(code) {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			bracketed = true
+		case c == ']':
+			bracketed = false
+		case bracketed:
+		case c == 'd' || c == 'y' || c == 'm':
+			return true
+		}
+	}
+	return false
+}
+
+// cellReader reads the rows of a sheet, resolving shared strings and dates
+type cellReader struct {
+	sharedStrings []string
+	dateStyles    map[int]bool
+}
+
+// excelEpoch is day 0 of Excel's date numbering, which counts the nonexistent 29 February 1900
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// readSheet passes each row of a sheet to add with its 1-based number. Empty cells in a row are
+// left empty, so values keep their columns.
+func (r *cellReader) readSheet(ctx context.Context, parts map[string]*zip.File, name string, add func(int, []string) error) error {
+	f, ok := parts[name]
+	if !ok {
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var (
+		rowNumber, rowCount int
+		values              []string
+		column              int
+		cellType            string
+		cellStyle           int
+		value               strings.Builder
+		inValue             bool
+	)
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				rowCount++
+				rowNumber = rowCount
+				if n, err := strconv.Atoi(attr(t, "r")); err == nil {
+					rowNumber, rowCount = n, n
+				}
+				values = values[:0]
+				column = -1
+			case "c":
+				column++
+				if ref := attr(t, "r"); ref != "" {
+					if index, ok := columnIndex(ref); ok {
+						column = index
+					}
+				}
+				cellType = attr(t, "t")
+				cellStyle, _ = strconv.Atoi(attr(t, "s"))
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				for len(values) <= column {
+					values = append(values, "")
+				}
+				values[column] = r.cellValue(cellType, cellStyle, value.String())
+			case "row":
+				if err := add(rowNumber, append([]string(nil), values...)); err != nil {
+					return err
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+}
+
+// cellValue renders a cell's raw value by its type and style
+func (r *cellReader) cellValue(cellType string, style int, raw string) string {
+	switch cellType {
+	case "s":
+		index, err := strconv.Atoi(raw)
+		if err != nil || index < 0 || index >= len(r.sharedStrings) {
+			return ""
+		}
+		return r.sharedStrings[index]
+	case "b":
+		if raw == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "", "n":
+		if r.dateStyles[style] {
+			if days, err := strconv.ParseFloat(raw, 64); err == nil {
+				date := excelEpoch.Add(time.Duration(days * 24 * float64(time.Hour)))
+				if date.Hour() == 0 && date.Minute() == 0 {
+					return date.Format("2006-01-02")
+				}
+				return date.Format("2006-01-02 15:04")
+			}
+		}
+		return raw
+	default:
+		// Inline and formula strings, and errors, are stored as text
+		return raw
+	}
+}
+
+// attr returns the value of an element's attribute by local name
+func attr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// columnIndex returns the 0-based column of a cell reference such as "C12"
+func columnIndex(ref string) (int, bool) {
+	index := 0
+	letters := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		index = index*26 + int(c-'A'+1)
+		letters++
+	}
+	if letters == 0 {
+		return 0, false
+	}
+	return index - 1, true
+}
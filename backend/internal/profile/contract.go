@@ -0,0 +1,276 @@
+package profile
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&ContractProfile{})
+}
+
+// ContractProfile segments contracts and agreements by clause and extracts parties,
+// dates and obligations into metadata so questions can be filtered by clause type
+type ContractProfile struct{}
+
+// ClauseTypes lists the clause_type values assigned by the contract profile
+var ClauseTypes = []string{
+	"preamble", "definitions", "term", "payment", "confidentiality", "termination", "liability",
+	"indemnification", "intellectual_property", "warranty", "governing_law", "dispute_resolution",
+	"force_majeure", "non_compete", "notices", "assignment", "general",
+}
+
+// clauseClassifiers map clause headings (or, failing that, opening text) to a clause type.
+// Order matters: the first match wins, so "termination" is tested before "term".
+var clauseClassifiers = []struct {
+	clauseType string
+	pattern    *regexp.Regexp
+}{
+	{"definitions", regexp.MustCompile(`(?i)\b(definitions?|interpretation)\b`)},
+	{"termination", regexp.MustCompile(`(?i)\bterminat`)},
+	{"term", regexp.MustCompile(`(?i)\b(term|duration|commencement)\b`)},
+	{"payment", regexp.MustCompile(`(?i)\b(payments?|fees?|price|pricing|invoic\w*|compensation|remuneration)\b`)},
+	{"confidentiality", regexp.MustCompile(`(?i)\b(confidential\w*|non-disclosure)\b`)},
+	{"liability", regexp.MustCompile(`(?i)\b(liabilit\w*|limitation of)\b`)},
+	{"indemnification", regexp.MustCompile(`(?i)\bindemn`)},
+	{"intellectual_property", regexp.MustCompile(`(?i)\b(intellectual property|ownership|licen[cs]e)\b`)},
+	{"warranty", regexp.MustCompile(`(?i)\b(warrant\w*|representations?)\b`)},
+	{"governing_law", regexp.MustCompile(`(?i)\b(governing law|jurisdiction|applicable law)\b`)},
+	{"dispute_resolution", regexp.MustCompile(`(?i)\b(disputes?|arbitration|mediation)\b`)},
+	{"force_majeure", regexp.MustCompile(`(?i)\bforce majeure\b`)},
+	{"non_compete", regexp.MustCompile(`(?i)\bnon-?(compet\w*|solicit\w*)\b`)},
+	{"notices", regexp.MustCompile(`(?i)\bnotices?\b`)},
+	{"assignment", regexp.MustCompile(`(?i)\b(assignment|subcontract\w*)\b`)},
+}
+
+var (
+	// clauseHeading matches top-level clause headings such as "5. Termination", "Clause 5 - Termination",
+	// "ARTICLE V: TERMINATION" or "Section 5 Termination". Subclauses like "5.2" stay inside their clause.
+	clauseHeading = regexp.MustCompile(`^\s*(?:(?i:section|clause|article)\s+([0-9]+|[IVXLC]+)|([0-9]+)\.)\s*[.:\-–)]?\s*([A-Z][^\n]{0,80})?$`)
+
+	// capsHeading matches unnumbered all-caps headings such as "CONFIDENTIALITY"
+	capsHeading = regexp.MustCompile(`^\s*([A-Z][A-Z &,/\-]{3,60})\s*$`)
+
+	// partiesPattern matches "between X (...) and Y (...)" in the preamble
+	partiesPattern = regexp.MustCompile(`(?is)\bbetween\s+(.{3,150}?)(?:\s*\([^)]*\))?,?\s+and\s+(.{3,150}?)(?:\s*\([^)]*\))?\s*(?:[,.;(]|$)`)
+
+	datePattern = regexp.MustCompile(`(?i)\b(?:\d{1,2}(?:st|nd|rd|th)?\s+(?:of\s+)?(?:january|february|march|april|may|june|july|august|september|october|november|december)\s*,?\s+\d{4}|(?:january|february|march|april|may|june|july|august|september|october|november|december)\s+\d{1,2}(?:st|nd|rd|th)?,?\s+\d{4}|\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{4})\b`)
+
+	// obligationPattern marks sentences that impose a duty on a party
+	obligationPattern = regexp.MustCompile(`(?i)\b(shall|must|agrees? to|is required to|undertakes? to)\b`)
+
+	sentenceEnd = regexp.MustCompile(`[.;]\s+`)
+)
+
+// Contract extraction limits
+const (
+	contractDetectMinClauses = 4
+	maxClauseObligations     = 5
+	maxObligationLength      = 200
+)
+
+// Name returns the profile name
+func (p *ContractProfile) Name() string {
+	return "contract"
+}
+
+// Detect reports whether the document looks like a contract or agreement
+func (p *ContractProfile) Detect(filename, text string) bool {
+	base := strings.ToLower(filepath.Base(filename))
+	for _, hint := range []string{"contract", "agreement", "nda", "lease", "terms-of-service", "msa", "sow"} {
+		if strings.Contains(base, hint) {
+			return true
+		}
+	}
+
+	lower := strings.ToLower(text)
+	if !strings.Contains(lower, "agreement") && !strings.Contains(lower, "contract") {
+		return false
+	}
+	if strings.Contains(lower, "in witness whereof") || strings.Contains(lower, "whereas") {
+		return true
+	}
+
+	clauses := 0
+	for _, line := range strings.Split(text, "\n") {
+		if _, _, ok := parseClauseHeading(line); ok {
+			clauses++
+			if clauses >= contractDetectMinClauses {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Segment splits the contract into its preamble and top-level clauses. Each segment records
+// its clause number, title and type, the dates and obligations it contains, and the
+// contract's parties and effective date.
+func (p *ContractProfile) Segment(text string) []Segment {
+	var segments []Segment
+	number, title := "", ""
+	var buf strings.Builder
+
+	flush := func() {
+		content := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if content == "" {
+			return
+		}
+		segments = append(segments, Segment{Content: content, Metadata: clauseMetadata(p.Name(), number, title, content)})
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		// A heading before any text is the document title and belongs to the preamble
+		if n, t, ok := parseClauseHeading(line); ok && (len(segments) > 0 || strings.TrimSpace(buf.String()) != "") {
+			flush()
+			number, title = n, t
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	flush()
+
+	parties := extractParties(text)
+	effectiveDate := extractEffectiveDate(text)
+	for _, segment := range segments {
+		if len(parties) > 0 {
+			segment.Metadata["parties"] = parties
+		}
+		if effectiveDate != "" {
+			segment.Metadata["effective_date"] = effectiveDate
+		}
+	}
+
+	return segments
+}
+
+// parseClauseHeading returns the clause number and title when the line is a clause heading
+func parseClauseHeading(line string) (number, title string, ok bool) {
+	if m := clauseHeading.FindStringSubmatch(line); m != nil {
+		title = strings.TrimSpace(m[3])
+		// A numbered line reading like a sentence is a list item, not a heading
+		if len(strings.Fields(title)) > 8 || obligationPattern.MatchString(title) {
+			return "", "", false
+		}
+		return m[1] + m[2], strings.TrimRight(title, ".:"), true
+	}
+	if m := capsHeading.FindStringSubmatch(line); m != nil && len(strings.Fields(m[1])) <= 6 {
+		return "", strings.TrimSpace(m[1]), true
+	}
+	return "", "", false
+}
+
+// clauseMetadata builds the metadata of one clause segment
+func clauseMetadata(profileName, number, title, content string) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"profile":     profileName,
+		"clause_type": classifyClause(number, title, content),
+	}
+	if number != "" {
+		metadata["clause_number"] = number
+	}
+	if title != "" {
+		metadata["clause_title"] = title
+	}
+	if dates := uniqueMatches(datePattern.FindAllString(content, -1)); len(dates) > 0 {
+		metadata["dates"] = dates
+	}
+
+	// Leave the heading line out so it doesn't run into the first sentence
+	body := content
+	if number != "" || title != "" {
+		if _, rest, found := strings.Cut(content, "\n"); found {
+			body = rest
+		}
+	}
+	if obligations := extractObligations(body); len(obligations) > 0 {
+		metadata["obligations"] = obligations
+	}
+	return metadata
+}
+
+// classifyClause assigns a clause type from the heading, falling back to the clause's opening text
+func classifyClause(number, title, content string) string {
+	if number == "" && title == "" {
+		return "preamble"
+	}
+	for _, source := range []string{title, truncateRunes(content, 300)} {
+		for _, classifier := range clauseClassifiers {
+			if classifier.pattern.MatchString(source) {
+				return classifier.clauseType
+			}
+		}
+	}
+	return "general"
+}
+
+// extractParties returns the two parties named in the preamble's "between X and Y"
+func extractParties(text string) []string {
+	m := partiesPattern.FindStringSubmatch(truncateRunes(text, 3000))
+	if m == nil {
+		return nil
+	}
+
+	var parties []string
+	for _, party := range m[1:] {
+		party = strings.Join(strings.Fields(party), " ")
+		party = strings.Trim(party, ` ,;"'`)
+		if party != "" {
+			parties = append(parties, party)
+		}
+	}
+	return parties
+}
+
+// extractEffectiveDate returns the date in the first sentence mentioning "effective",
+// or else the first date in the preamble
+func extractEffectiveDate(text string) string {
+	for _, sentence := range sentenceEnd.Split(text, -1) {
+		if strings.Contains(strings.ToLower(sentence), "effective") {
+			if date := datePattern.FindString(sentence); date != "" {
+				return date
+			}
+		}
+	}
+	return datePattern.FindString(truncateRunes(text, 2000))
+}
+
+// extractObligations returns the clause's sentences that impose a duty, shortened for metadata
+func extractObligations(content string) []string {
+	var obligations []string
+	for _, sentence := range sentenceEnd.Split(content, -1) {
+		sentence = strings.Join(strings.Fields(sentence), " ")
+		if !obligationPattern.MatchString(sentence) {
+			continue
+		}
+		obligations = append(obligations, truncateRunes(sentence, maxObligationLength))
+		if len(obligations) == maxClauseObligations {
+			break
+		}
+	}
+	return obligations
+}
+
+// uniqueMatches removes duplicates while keeping order
+func uniqueMatches(matches []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		if !seen[match] {
+			seen[match] = true
+			unique = append(unique, match)
+		}
+	}
+	return unique
+}
+
+// truncateRunes shortens s to at most max runes
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,223 @@
+package profile
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+func init() {
+	Register(&ExpenseProfile{})
+}
+
+// Expense document types assigned to the doc_type metadata field
+const (
+	DocTypeReceipt = "receipt"
+	DocTypeInvoice = "invoice"
+)
+
+// Expense metadata fields. ExpenseDateAtField holds the purchase date as a Unix timestamp
+// so reports can select receipts by period.
+const (
+	ExpenseTotalField    = "expense_total"
+	ExpenseCurrencyField = "expense_currency"
+	ExpenseDateField     = "expense_date"
+	ExpenseDateAtField   = "expense_date_at"
+)
+
+// ExpenseProfile annotates receipts and invoices with the merchant, date, total, currency and
+// line items. It is registered before the meeting profile, whose "Label: value" speaker lines
+// would otherwise claim most receipts.
+type ExpenseProfile struct{}
+
+var (
+	// expenseDocumentPattern marks a receipt or invoice near the top of the document
+	expenseDocumentPattern = regexp.MustCompile(`(?i)\b(receipt|tax\s+invoice|invoice\s*(?:no\.?|number|#|date)|order\s+summary|thank\s+you\s+for\s+(?:your\s+)?(?:purchase|order|shopping))\b`)
+
+	// totalLine captures the amount on a total line, such as "TOTAL RM 45.90" or "Amount due: $12.00".
+	// Subtotals don't match since the label must start the line.
+	totalLine = regexp.MustCompile(`(?i)^\s*(grand\s+total|total(?:\s+(?:due|paid|amount|payable|incl\.?(?:uding)?\s+\w+))?|amount\s+(?:due|paid|payable)|balance\s+due)\s*:?\s*(` + currencyToken + `)?\s*(-?\d[\d,]*(?:\.\d{1,2})?)\s*(` + currencyToken + `)?\s*$`)
+
+	// itemLine captures a line item description followed by its price
+	itemLine = regexp.MustCompile(`^\s*(?:\d+\s*[xX@]?\s+)?([\p{L}][^\n]{1,80}?)\s+(?:` + currencyToken + `)?\s*\d[\d,]*\.\d{2}\s*$`)
+
+	// nonItemLabel marks amount lines that are not purchases
+	nonItemLabel = regexp.MustCompile(`(?i)\b(sub-?\s?total|total|tax|vat|gst|sst|service\s+charge|tip|gratuity|discount|rounding|change|cash|card|visa|mastercard|amex|paid|balance|tendered|amount)\b`)
+
+	// currencyCode finds an ISO currency code anywhere in the document
+	currencyCode = regexp.MustCompile(`\b(USD|EUR|GBP|JPY|MYR|SGD|AUD|CAD|CHF|CNY|HKD|INR|IDR|THB|NZD)\b`)
+
+	// receiptDateLabel marks the line carrying the purchase date
+	receiptDateLabel = regexp.MustCompile(`(?i)\b(date|issued|invoice\s+date|purchased?|transaction)\b`)
+)
+
+// currencyToken matches a currency symbol or code next to an amount
+const currencyToken = `[A-Z]{3}|RM|S\$|A\$|C\$|[$€£¥₹]`
+
+// currencySymbols maps currency symbols to ISO codes
+var currencySymbols = map[string]string{
+	"$": "USD", "€": "EUR", "£": "GBP", "¥": "JPY", "₹": "INR", "RM": "MYR", "S$": "SGD", "A$": "AUD", "C$": "CAD",
+}
+
+// Expense extraction limits
+const (
+	maxExpenseItems      = 30
+	maxExpenseItemLength = 80
+	maxMerchantLength    = 100
+)
+
+// Name returns the profile name
+func (p *ExpenseProfile) Name() string {
+	return "expense"
+}
+
+// Detect reports whether the document looks like a receipt or invoice: a receipt filename or
+// heading, and a total line
+func (p *ExpenseProfile) Detect(filename, text string) bool {
+	base := strings.ToLower(filepath.Base(filename))
+	hinted := false
+	for _, hint := range []string{"receipt", "invoice"} {
+		if strings.Contains(base, hint) {
+			hinted = true
+		}
+	}
+	if !hinted && !expenseDocumentPattern.MatchString(truncateRunes(text, 2000)) {
+		return false
+	}
+	_, _, ok := extractTotal(text)
+	return ok
+}
+
+// Segment keeps the document whole and annotates it with the merchant, date, total and items.
+// Every chunk carries the fields so the receipt can be reported on as a unit.
+func (p *ExpenseProfile) Segment(text string) []Segment {
+	metadata := map[string]interface{}{"profile": p.Name()}
+
+	metadata["doc_type"] = DocTypeReceipt
+	if strings.Contains(strings.ToLower(truncateRunes(text, 2000)), "invoice") {
+		metadata["doc_type"] = DocTypeInvoice
+	}
+
+	if merchant := extractMerchant(text); merchant != "" {
+		metadata["merchant"] = merchant
+	}
+
+	total, currency, ok := extractTotal(text)
+	if ok {
+		metadata[ExpenseTotalField] = total
+	}
+	if currency == "" {
+		currency = currencyCode.FindString(text)
+	}
+	if currency != "" {
+		metadata[ExpenseCurrencyField] = currency
+	}
+
+	if date, ok := extractExpenseDate(text); ok {
+		metadata[ExpenseDateField] = date.Format("2006-01-02")
+		metadata[ExpenseDateAtField] = date.Unix()
+	}
+
+	if items := extractExpenseItems(text); len(items) > 0 {
+		metadata["items"] = items
+	}
+
+	return []Segment{{Content: text, Metadata: metadata}}
+}
+
+// extractMerchant returns the first line that isn't a receipt heading, which is usually the store name
+func extractMerchant(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || expenseDocumentPattern.MatchString(line) && len(strings.Fields(line)) <= 3 {
+			continue
+		}
+		if len(strings.Fields(line)) > 8 || !strings.ContainsFunc(line, unicode.IsLetter) {
+			return ""
+		}
+		return truncateRunes(line, maxMerchantLength)
+	}
+	return ""
+}
+
+// extractExpenseDate returns the first date on a labelled date line, or else the first date in the
+// document, since receipts often print the date alone. Dates are matched with travelDatePattern,
+// which accepts the abbreviated months receipts use.
+func extractExpenseDate(text string) (time.Time, bool) {
+	for _, sentence := range fieldSentences(text) {
+		loc := receiptDateLabel.FindStringIndex(sentence)
+		if loc == nil {
+			continue
+		}
+		if date, ok := parseDate(travelDatePattern.FindString(sentence[loc[1]:])); ok {
+			return date, true
+		}
+	}
+	return parseDate(travelDatePattern.FindString(text))
+}
+
+// extractTotal returns the amount and currency on the last total line. "Grand total" wins over
+// other totals, since receipts list totals before and after discounts or tips.
+func extractTotal(text string) (float64, string, bool) {
+	var match []string
+	for _, line := range strings.Split(text, "\n") {
+		m := totalLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if match == nil || !strings.HasPrefix(strings.ToLower(match[1]), "grand") {
+			match = m
+		}
+	}
+	if match == nil {
+		return 0, "", false
+	}
+
+	amount, ok := parseAmount(match[3])
+	if !ok {
+		return 0, "", false
+	}
+	currency := match[2]
+	if currency == "" {
+		currency = match[4]
+	}
+	if code, ok := currencySymbols[currency]; ok {
+		currency = code
+	} else if currency != strings.ToUpper(currency) {
+		// The case-insensitive match can take a word such as "due" for a currency code
+		currency = ""
+	}
+	return amount, currency, true
+}
+
+// parseAmount parses an amount with comma thousands separators, or a comma decimal
+// separator as in "12,50"
+func parseAmount(s string) (float64, bool) {
+	if i := strings.LastIndex(s, ","); i >= 0 && !strings.Contains(s, ".") && len(s)-i == 3 {
+		s = s[:i] + "." + s[i+1:]
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+// extractExpenseItems returns the descriptions of priced lines that aren't totals, taxes or payments
+func extractExpenseItems(text string) []string {
+	var items []string
+	for _, line := range strings.Split(text, "\n") {
+		m := itemLine.FindStringSubmatch(line)
+		if m == nil || nonItemLabel.MatchString(m[1]) {
+			continue
+		}
+		items = append(items, truncateRunes(strings.TrimSpace(m[1]), maxExpenseItemLength))
+		if len(items) == maxExpenseItems {
+			break
+		}
+	}
+	return items
+}
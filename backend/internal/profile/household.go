@@ -0,0 +1,283 @@
+package profile
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(&HouseholdProfile{})
+}
+
+// Household document types assigned to the doc_type metadata field
+const (
+	DocTypeRecipe   = "recipe"
+	DocTypeManual   = "manual"
+	DocTypeWarranty = "warranty"
+)
+
+// Warranty metadata fields. WarrantyExpiresAtField holds the expiry as a Unix timestamp
+// so searches can filter on it with a range condition.
+const (
+	WarrantyExpiresField   = "warranty_expires"
+	WarrantyExpiresAtField = "warranty_expires_at"
+)
+
+// HouseholdProfile annotates recipes, appliance manuals and warranties with structured fields:
+// ingredients for recipes; product, model and serial numbers and warranty expiry for the rest
+type HouseholdProfile struct{}
+
+var (
+	// ingredientsHeading starts a recipe's ingredient list
+	ingredientsHeading = regexp.MustCompile(`(?i)^\s*ingredients\s*:?\s*$`)
+
+	// recipeSectionHeading ends the ingredient list
+	recipeSectionHeading = regexp.MustCompile(`(?i)^\s*(instructions|method|directions|steps|preparation|how to make( it)?|notes?)\b[^\n]{0,30}:?\s*$`)
+
+	// listMarker is a bullet or number at the start of a list item
+	listMarker = regexp.MustCompile(`^\s*(?:[-*•·]|\d+[.)])\s*`)
+
+	servingsPattern = regexp.MustCompile(`(?i)\b(?:serves|servings|yields?)\s*:?\s*(\d+)`)
+
+	manualPattern = regexp.MustCompile(`(?i)\b(user|owner'?s|instruction|operating|service)\s+(manual|guide|instructions)\b`)
+
+	modelNumberPattern  = regexp.MustCompile(`(?i)\bmodel(?:\s*(?:no\.?|number|#))?\s*[:#]?\s*([A-Z0-9][A-Z0-9\-/.]{2,30})`)
+	serialNumberPattern = regexp.MustCompile(`(?i)\bserial(?:\s*(?:no\.?|number|#))?\s*[:#]?\s*([A-Z0-9][A-Z0-9\-]{3,30})`)
+
+	// Warranty terms, matched within a line or sentence
+	warrantyExpiryPattern   = regexp.MustCompile(`(?i)\b(expir(?:es|y|ation)|valid\s+(?:until|through|till)|covered\s+until|ends?\s+on)\b`)
+	purchaseDatePattern     = regexp.MustCompile(`(?i)\b(purchase[ds]?|date\s+of\s+sale|invoice\s+date|bought)\b`)
+	warrantyPeriodPattern   = regexp.MustCompile(`(?i)\b(\d{1,2}|one|two|three|four|five|ten)[\s-]*(years?|months?)\b`)
+	warrantyDocumentPattern = regexp.MustCompile(`(?i)\b(warranty\s+(card|certificate|registration|terms)|limited\s+warranty|guarantee\s+card)\b`)
+
+	ordinalSuffix = regexp.MustCompile(`(?i)(\d)(st|nd|rd|th)\b`)
+	septAbbrev    = regexp.MustCompile(`(?i)\bsept\b`)
+)
+
+// numberWords spells out the warranty periods written as words
+var numberWords = map[string]int{"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "ten": 10}
+
+// dateLayouts are tried in order when parsing dates matched by datePattern (or the travel
+// profile's travelDatePattern, which adds abbreviated months). Slashed dates are read day first.
+var dateLayouts = []string{"2 January 2006", "2 Jan 2006", "January 2 2006", "Jan 2 2006", "2006-01-02", "2/1/2006"}
+
+// Household extraction limits
+const (
+	maxIngredients      = 50
+	maxIngredientLength = 100
+	maxProductLength    = 100
+)
+
+// Name returns the profile name
+func (p *HouseholdProfile) Name() string {
+	return "household"
+}
+
+// Detect reports whether the document looks like a recipe, appliance manual or warranty
+func (p *HouseholdProfile) Detect(filename, text string) bool {
+	base := strings.ToLower(filepath.Base(filename))
+	for _, hint := range []string{"recipe", "manual", "warranty", "guarantee"} {
+		if strings.Contains(base, hint) {
+			return true
+		}
+	}
+	return p.docType(filename, text) != ""
+}
+
+// Segment keeps the document whole and annotates it with its type and structured fields.
+// Every chunk carries the fields, so filters such as an unexpired warranty match the whole document.
+func (p *HouseholdProfile) Segment(text string) []Segment {
+	metadata := map[string]interface{}{"profile": p.Name()}
+
+	docType := p.docType("", text)
+	if docType == "" {
+		docType = DocTypeManual
+	}
+	metadata["doc_type"] = docType
+
+	if docType == DocTypeRecipe {
+		if ingredients := extractIngredients(text); len(ingredients) > 0 {
+			metadata["ingredients"] = ingredients
+		}
+		if m := servingsPattern.FindStringSubmatch(text); m != nil {
+			if servings, err := strconv.Atoi(m[1]); err == nil {
+				metadata["servings"] = servings
+			}
+		}
+		return []Segment{{Content: text, Metadata: metadata}}
+	}
+
+	if product := extractProduct(text); product != "" {
+		metadata["product"] = product
+	}
+	if models := extractIdentifiers(modelNumberPattern, text); len(models) > 0 {
+		metadata["model_numbers"] = models
+	}
+	if serials := extractIdentifiers(serialNumberPattern, text); len(serials) > 0 {
+		metadata["serial_numbers"] = serials
+	}
+	if purchased, ok := extractDateAfter(text, purchaseDatePattern); ok {
+		metadata["purchase_date"] = purchased.Format("2006-01-02")
+	}
+	if expires, ok := extractWarrantyExpiry(text); ok {
+		metadata[WarrantyExpiresField] = expires.Format("2006-01-02")
+		metadata[WarrantyExpiresAtField] = expires.Unix()
+	}
+
+	return []Segment{{Content: text, Metadata: metadata}}
+}
+
+// docType classifies the document from its filename and text, or returns "" when it is none of the household types
+func (p *HouseholdProfile) docType(filename, text string) string {
+	base := strings.ToLower(filepath.Base(filename))
+	switch {
+	case strings.Contains(base, "recipe") || hasIngredientList(text):
+		return DocTypeRecipe
+	case strings.Contains(base, "warranty") || strings.Contains(base, "guarantee") || warrantyDocumentPattern.MatchString(truncateRunes(text, 2000)):
+		return DocTypeWarranty
+	case strings.Contains(base, "manual") || manualPattern.MatchString(truncateRunes(text, 2000)):
+		return DocTypeManual
+	}
+	return ""
+}
+
+// hasIngredientList reports whether an ingredients heading is followed later by a method heading
+func hasIngredientList(text string) bool {
+	inIngredients := false
+	for _, line := range strings.Split(text, "\n") {
+		if ingredientsHeading.MatchString(line) {
+			inIngredients = true
+		} else if inIngredients && recipeSectionHeading.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractIngredients returns the items listed under the ingredients heading, skipping
+// subgroup labels such as "For the sauce:"
+func extractIngredients(text string) []string {
+	var ingredients []string
+	inIngredients := false
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case ingredientsHeading.MatchString(line):
+			inIngredients = true
+			continue
+		case !inIngredients:
+			continue
+		case recipeSectionHeading.MatchString(line):
+			return ingredients
+		}
+
+		item := strings.TrimSpace(listMarker.ReplaceAllString(line, ""))
+		if item == "" || strings.HasSuffix(item, ":") {
+			continue
+		}
+		ingredients = append(ingredients, truncateRunes(item, maxIngredientLength))
+		if len(ingredients) == maxIngredients {
+			break
+		}
+	}
+	return ingredients
+}
+
+// extractProduct returns the document's first line when it reads like a title
+func extractProduct(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if len(strings.Fields(line)) > 12 {
+			return ""
+		}
+		return truncateRunes(line, maxProductLength)
+	}
+	return ""
+}
+
+// extractIdentifiers returns the distinct codes captured by pattern; codes must contain a digit
+// so words following "model" in running text are not mistaken for model numbers
+func extractIdentifiers(pattern *regexp.Regexp, text string) []string {
+	var identifiers []string
+	for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+		identifier := strings.TrimRight(m[1], ".-/")
+		if strings.ContainsAny(identifier, "0123456789") {
+			identifiers = append(identifiers, strings.ToUpper(identifier))
+		}
+	}
+	return uniqueMatches(identifiers)
+}
+
+// extractWarrantyExpiry returns the stated expiry date, or else the purchase date plus the warranty period
+func extractWarrantyExpiry(text string) (time.Time, bool) {
+	if expires, ok := extractDateAfter(text, warrantyExpiryPattern); ok {
+		return expires, true
+	}
+
+	purchased, ok := extractDateAfter(text, purchaseDatePattern)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, sentence := range fieldSentences(text) {
+		if !strings.Contains(strings.ToLower(sentence), "warrant") {
+			continue
+		}
+		if m := warrantyPeriodPattern.FindStringSubmatch(sentence); m != nil {
+			n, err := strconv.Atoi(m[1])
+			if err != nil {
+				n = numberWords[strings.ToLower(m[1])]
+			}
+			if strings.HasPrefix(strings.ToLower(m[2]), "year") {
+				return purchased.AddDate(n, 0, 0), true
+			}
+			return purchased.AddDate(0, n, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// extractDateAfter returns the first date following a match of keyword in the same line or sentence
+func extractDateAfter(text string, keyword *regexp.Regexp) (time.Time, bool) {
+	for _, sentence := range fieldSentences(text) {
+		loc := keyword.FindStringIndex(sentence)
+		if loc == nil {
+			continue
+		}
+		if date, ok := parseDate(datePattern.FindString(sentence[loc[1]:])); ok {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fieldSentences splits text into lines and then sentences, so a field on a
+// card or form line doesn't run into the next one
+func fieldSentences(text string) []string {
+	var sentences []string
+	for _, line := range strings.Split(text, "\n") {
+		sentences = append(sentences, sentenceEnd.Split(line, -1)...)
+	}
+	return sentences
+}
+
+// parseDate parses a date in one of the forms listed in dateLayouts
+func parseDate(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	s = ordinalSuffix.ReplaceAllString(s, "$1")
+	s = septAbbrev.ReplaceAllString(strings.NewReplacer(",", " ", ".", " ").Replace(s), "Sep")
+	s = strings.Join(strings.Fields(strings.ReplaceAll(" "+s+" ", " of ", " ")), " ")
+
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
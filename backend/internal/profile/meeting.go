@@ -0,0 +1,116 @@
+package profile
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&MeetingProfile{})
+}
+
+// MeetingProfile segments transcribed meeting notes by speaker turn and agenda topic
+type MeetingProfile struct{}
+
+var (
+	// speakerLine matches "Alex: ..." optionally prefixed by a timestamp like "[00:12:03]"
+	speakerLine = regexp.MustCompile(`^\s*(?:\[?\d{1,2}:\d{2}(?::\d{2})?(?:\.\d+)?\]?\s*)?([A-Z][\p{L}.' -]{0,40}?)\s*:\s+(.+)$`)
+
+	// topicLine matches markdown headings and "Agenda:"/"Topic:" markers
+	topicLine = regexp.MustCompile(`^\s*(?:#{1,6}\s+(.+)|(?i:agenda(?:\s+item)?(?:\s*\d+)?|topic)\s*[:\-]\s*(.+))$`)
+)
+
+// minSpeakerTurns is the number of speaker lines required to auto-detect a transcript
+const minSpeakerTurns = 3
+
+// Name returns the profile name
+func (p *MeetingProfile) Name() string {
+	return "meeting"
+}
+
+// Detect reports whether the text looks like a speaker-attributed transcript
+func (p *MeetingProfile) Detect(filename, text string) bool {
+	base := strings.ToLower(filepath.Base(filename))
+	for _, hint := range []string{"meeting", "standup", "transcript", "minutes"} {
+		if strings.Contains(base, hint) {
+			return true
+		}
+	}
+
+	turns := 0
+	for _, line := range strings.Split(text, "\n") {
+		if speakerLine.MatchString(line) && !topicLine.MatchString(line) {
+			turns++
+			if turns >= minSpeakerTurns {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Segment splits the transcript into consecutive speaker turns grouped under the current topic
+func (p *MeetingProfile) Segment(text string) []Segment {
+	var segments []Segment
+	var speakers []string
+	seen := make(map[string]bool)
+
+	topic := ""
+	speaker := ""
+	var buf strings.Builder
+
+	flush := func() {
+		content := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if content == "" {
+			return
+		}
+		metadata := map[string]interface{}{
+			"profile": p.Name(),
+		}
+		if speaker != "" {
+			metadata["speaker"] = speaker
+			content = speaker + ": " + content
+		}
+		if topic != "" {
+			metadata["topic"] = topic
+		}
+		segments = append(segments, Segment{Content: content, Metadata: metadata})
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if m := topicLine.FindStringSubmatch(line); m != nil {
+			flush()
+			topic = strings.TrimSpace(m[1] + m[2])
+			speaker = ""
+			continue
+		}
+
+		if m := speakerLine.FindStringSubmatch(line); m != nil {
+			name := strings.TrimSpace(m[1])
+			if name != speaker {
+				flush()
+				speaker = name
+				if !seen[name] {
+					seen[name] = true
+					speakers = append(speakers, name)
+				}
+			}
+			buf.WriteString(m[2])
+			buf.WriteString("\n")
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	flush()
+
+	// Every segment also records the full attendee list so meetings can be filtered by participant
+	for _, segment := range segments {
+		segment.Metadata["speakers"] = speakers
+	}
+
+	return segments
+}
@@ -0,0 +1,62 @@
+package profile
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Segment is a section of a document produced by a profile, carrying its own metadata
+type Segment struct {
+	Content  string
+	Metadata map[string]interface{}
+}
+
+// Profile customizes how a document type is segmented and annotated before chunking
+type Profile interface {
+	// Name returns the unique profile name used in uploads and chunk metadata
+	Name() string
+
+	// Detect reports whether the document looks like it belongs to this profile
+	Detect(filename, text string) bool
+
+	// Segment splits the document text into annotated segments
+	Segment(text string) []Segment
+}
+
+// registry holds all known profiles in registration order (detection order)
+var registry []Profile
+
+// Register adds a profile to the registry
+func Register(p Profile) {
+	registry = append(registry, p)
+}
+
+// Get returns the profile with the given name
+func Get(name string) (Profile, error) {
+	for _, p := range registry {
+		if p.Name() == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown ingestion profile: %s", name)
+}
+
+// Detect returns the first profile that recognizes the document, or nil
+func Detect(filename, text string) Profile {
+	for _, p := range registry {
+		if p.Detect(filename, text) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Names returns the names of all registered profiles
+func Names() []string {
+	names := make([]string, len(registry))
+	for i, p := range registry {
+		names[i] = p.Name()
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,206 @@
+package profile
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(&TravelProfile{})
+}
+
+// Travel document types assigned to the doc_type metadata field
+const (
+	DocTypeFlight    = "flight"
+	DocTypeHotel     = "hotel"
+	DocTypeCarRental = "car_rental"
+	DocTypeTrain     = "train"
+	DocTypePassport  = "passport"
+	DocTypeItinerary = "itinerary"
+)
+
+// Travel metadata fields. The *_at fields hold Unix timestamps so searches can filter on them.
+const (
+	TravelStartField   = "travel_start"
+	TravelStartAtField = "travel_start_at"
+	TravelEndField     = "travel_end"
+	TravelEndAtField   = "travel_end_at"
+)
+
+// TravelProfile annotates bookings, itineraries and passports with booking references,
+// flight numbers, places and travel dates
+type TravelProfile struct{}
+
+var (
+	// bookingRefPattern captures booking references, PNRs and confirmation codes after their label
+	bookingRefPattern = regexp.MustCompile(`(?i)\b(?:pnr|booking\s+(?:ref(?:erence)?|code|number|no\.?|id)|confirmation\s*(?:number|code|no\.?|#|id)?|reservation\s+(?:number|code|no\.?|id)|record\s+locator|e-?ticket\s+(?:number|no\.?))\s*(?:is\s*)?[:#]?\s*([A-Z0-9][A-Z0-9-]{4,19})\b`)
+
+	flightNumberPattern = regexp.MustCompile(`\b([A-Z]{2}|[A-Z][0-9]|[0-9][A-Z])\s?([0-9]{2,4})\b`)
+
+	// routePattern matches airport code pairs such as "KUL → LIS" or "KUL-LIS"
+	routePattern = regexp.MustCompile(`\b([A-Z]{3})\s*(?:→|->|–|-|to)\s*([A-Z]{3})\b`)
+
+	// placeLabel matches labelled place lines such as "Destination: Lisbon" or "Hotel: Memmo Alfama"
+	placeLabel = regexp.MustCompile(`(?i)^\s*(destination|city|location|address|hotel(?:\s+name)?|property|arrival\s+city|pick-?up\s+location)\s*:\s*(.{2,100}?)\s*$`)
+
+	passportNumberPattern = regexp.MustCompile(`(?i)\bpassport\s*(?:no\.?|number|#)\s*[:#]?\s*([A-Z0-9]{6,9})\b`)
+	passportExpiryPattern = regexp.MustCompile(`(?i)\b(date\s+of\s+expiry|expiry\s+date|expiration\s+date|expires|valid\s+until)\b`)
+
+	// issuedPattern marks dates that are about the booking itself rather than the trip
+	issuedPattern = regexp.MustCompile(`(?i)\b(booked|booking\s+date|date\s+of\s+(?:issue|booking)|issued|payment|paid|invoice|birth)\b`)
+
+	// travelDatePattern also accepts abbreviated month names, which bookings use far more than contracts
+	travelDatePattern = regexp.MustCompile(`(?i)\b(?:\d{1,2}(?:st|nd|rd|th)?\s+(?:of\s+)?(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?,?\s+\d{4}|(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?\s+\d{1,2}(?:st|nd|rd|th)?,?\s+\d{4}|\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{4})\b`)
+)
+
+// travelTypeHints classify a travel document by its text; the first match wins
+var travelTypeHints = []struct {
+	docType string
+	pattern *regexp.Regexp
+}{
+	{DocTypePassport, regexp.MustCompile(`(?i)\bpassport\s*(no\.?|number|#)|\bnationality\b`)},
+	{DocTypeFlight, regexp.MustCompile(`(?i)\b(flight|boarding\s+pass|airline|pnr|e-?ticket|departure\s+gate)\b`)},
+	{DocTypeHotel, regexp.MustCompile(`(?i)\b(hotel|check-?\s?in|check-?\s?out|room\s+type|nights?)\b`)},
+	{DocTypeCarRental, regexp.MustCompile(`(?i)\b(car\s+rental|rental\s+car|pick-?up\s+location|drop-?off)\b`)},
+	{DocTypeTrain, regexp.MustCompile(`(?i)\b(train|rail|coach|carriage|platform)\b`)},
+}
+
+// Travel extraction limits
+const (
+	maxTravelValues = 10
+)
+
+// Name returns the profile name
+func (p *TravelProfile) Name() string {
+	return "travel"
+}
+
+// Detect reports whether the document looks like a booking confirmation, itinerary or passport
+func (p *TravelProfile) Detect(filename, text string) bool {
+	base := strings.ToLower(filepath.Base(filename))
+	for _, hint := range []string{"itinerary", "booking", "boarding", "eticket", "e-ticket", "reservation", "passport"} {
+		if strings.Contains(base, hint) {
+			return true
+		}
+	}
+
+	head := truncateRunes(text, 3000)
+	if passportNumberPattern.MatchString(head) {
+		return true
+	}
+	if m := bookingRefPattern.FindStringSubmatch(head); m != nil && isReference(m[1]) {
+		_, isTravel := travelDocType(head)
+		return isTravel
+	}
+	return false
+}
+
+// Segment keeps the document whole and annotates it with its type, references, places and dates.
+// Every chunk carries the fields so a booking can be found and filtered as a unit.
+func (p *TravelProfile) Segment(text string) []Segment {
+	metadata := map[string]interface{}{"profile": p.Name()}
+
+	docType, _ := travelDocType(text)
+	metadata["doc_type"] = docType
+
+	if docType == DocTypePassport {
+		if m := passportNumberPattern.FindStringSubmatch(text); m != nil {
+			metadata["passport_number"] = strings.ToUpper(m[1])
+		}
+		if expires, ok := extractDateAfter(text, passportExpiryPattern); ok {
+			metadata["passport_expires"] = expires.Format("2006-01-02")
+		}
+		return []Segment{{Content: text, Metadata: metadata}}
+	}
+
+	var refs []string
+	for _, m := range bookingRefPattern.FindAllStringSubmatch(text, -1) {
+		if isReference(m[1]) {
+			refs = append(refs, strings.ToUpper(m[1]))
+		}
+	}
+	if refs = limit(uniqueMatches(refs), maxTravelValues); len(refs) > 0 {
+		metadata["booking_refs"] = refs
+	}
+
+	var flights, places []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.Contains(strings.ToLower(line), "flight") {
+			for _, m := range flightNumberPattern.FindAllStringSubmatch(line, -1) {
+				flights = append(flights, m[1]+m[2])
+			}
+		}
+		for _, m := range routePattern.FindAllStringSubmatch(line, -1) {
+			places = append(places, m[1], m[2])
+		}
+		if m := placeLabel.FindStringSubmatch(line); m != nil {
+			value := strings.TrimSpace(m[2])
+			if strings.HasPrefix(strings.ToLower(m[1]), "hotel") || strings.EqualFold(m[1], "property") {
+				metadata["hotel"] = value
+			}
+			places = append(places, value)
+		}
+	}
+	if flights = limit(uniqueMatches(flights), maxTravelValues); len(flights) > 0 {
+		metadata["flight_numbers"] = flights
+	}
+	if places = limit(uniqueMatches(places), maxTravelValues); len(places) > 0 {
+		metadata["places"] = places
+	}
+
+	if dates := extractTravelDates(text); len(dates) > 0 {
+		start, end := dates[0], dates[len(dates)-1]
+		metadata[TravelStartField] = start.Format("2006-01-02")
+		metadata[TravelStartAtField] = start.Unix()
+		metadata[TravelEndField] = end.Format("2006-01-02")
+		metadata[TravelEndAtField] = end.Unix()
+	}
+
+	return []Segment{{Content: text, Metadata: metadata}}
+}
+
+// travelDocType classifies a travel document, reporting whether any travel hint matched
+func travelDocType(text string) (string, bool) {
+	for _, hint := range travelTypeHints {
+		if hint.pattern.MatchString(text) {
+			return hint.docType, true
+		}
+	}
+	return DocTypeItinerary, false
+}
+
+// isReference rejects words captured after a reference label, such as "Confirmation: pending";
+// references are upper case or contain a digit
+func isReference(s string) bool {
+	return strings.ContainsAny(s, "0123456789") || strings.ToUpper(s) == s
+}
+
+// extractTravelDates returns the distinct trip dates in order, leaving out booking, payment and birth dates
+func extractTravelDates(text string) []time.Time {
+	seen := make(map[time.Time]bool)
+	var dates []time.Time
+	for _, sentence := range fieldSentences(text) {
+		if issuedPattern.MatchString(sentence) {
+			continue
+		}
+		for _, match := range travelDatePattern.FindAllString(sentence, -1) {
+			if date, ok := parseDate(match); ok && !seen[date] {
+				seen[date] = true
+				dates = append(dates, date)
+			}
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+// limit keeps at most n values
+func limit(values []string, n int) []string {
+	if len(values) > n {
+		return values[:n]
+	}
+	return values
+}
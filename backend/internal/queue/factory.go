@@ -0,0 +1,37 @@
+package queue
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+)
+
+// DriverType represents the type of job queue backend
+type DriverType string
+
+const (
+	// DriverPostgres polls a jobs table; needs no extra infrastructure
+	DriverPostgres DriverType = "postgres"
+	// DriverNATS uses a NATS JetStream work-queue stream
+	DriverNATS DriverType = "nats"
+	// DriverRabbitMQ uses a durable RabbitMQ queue
+	DriverRabbitMQ DriverType = "rabbitmq"
+)
+
+// NewQueue creates a job queue based on the configuration
+func NewQueue(cfg *config.Config, db *sql.DB) (Queue, error) {
+	switch DriverType(cfg.JobQueueDriver) {
+	case DriverPostgres:
+		return NewPostgresQueue(db), nil
+
+	case DriverNATS:
+		return NewNATSQueue(cfg.NATSURL)
+
+	case DriverRabbitMQ:
+		return NewRabbitMQQueue(cfg.RabbitMQURL)
+
+	default:
+		return nil, fmt.Errorf("unknown job queue driver: %s (valid options: postgres, nats, rabbitmq)", cfg.JobQueueDriver)
+	}
+}
@@ -0,0 +1,173 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+)
+
+// JetStream names shared by every replica
+const (
+	natsStream   = "RAG_JOBS"
+	natsSubject  = "jobs"
+	natsConsumer = "rag-workers"
+)
+
+// NATSQueue is a Queue backed by a NATS JetStream work-queue stream.
+// Each job is delivered to exactly one worker across all replicas.
+type NATSQueue struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	stream jetstream.Stream
+}
+
+// NewNATSQueue connects to NATS and ensures the jobs stream exists
+func NewNATSQueue(url string) (*NATSQueue, error) {
+	conn, err := nats.Connect(url, nats.Name("rag-personal-assistant"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      natsStream,
+		Subjects:  []string{natsSubject + ".>"},
+		Retention: jetstream.WorkQueuePolicy,
+		Storage:   jetstream.FileStorage,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create jobs stream: %w", err)
+	}
+
+	return &NATSQueue{
+		conn:   conn,
+		js:     js,
+		stream: stream,
+	}, nil
+}
+
+// Enqueue publishes a job; the job ID doubles as the JetStream dedup ID
+func (q *NATSQueue) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	job, err := newJob(uuid.New().String(), jobType, payload)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	if _, err := q.js.Publish(ctx, natsSubject+"."+jobType, data, jetstream.WithMsgID(job.ID)); err != nil {
+		return fmt.Errorf("failed to publish job: %w", err)
+	}
+
+	return nil
+}
+
+// Consume pulls jobs through a durable consumer shared by all workers
+func (q *NATSQueue) Consume(ctx context.Context, concurrency int, handler Handler) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	consumer, err := q.stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:    natsConsumer,
+		AckPolicy:  jetstream.AckExplicitPolicy,
+		AckWait:    lockTimeout,
+		MaxDeliver: MaxAttempts,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create jobs consumer: %w", err)
+	}
+
+	sem := make(chan struct{}, concurrency)
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem }()
+			q.handle(ctx, msg, handler)
+		}()
+	}, jetstream.PullMaxMessages(concurrency))
+	if err != nil {
+		return fmt.Errorf("failed to consume jobs: %w", err)
+	}
+
+	<-ctx.Done()
+	consumeCtx.Drain()
+	<-consumeCtx.Closed()
+
+	// Wait for in-flight handlers
+	for i := 0; i < concurrency; i++ {
+		sem <- struct{}{}
+	}
+	return nil
+}
+
+// handle runs a single delivery and acks, naks with backoff or terminates it
+func (q *NATSQueue) handle(ctx context.Context, msg jetstream.Msg, handler Handler) {
+	var job Job
+	if err := json.Unmarshal(msg.Data(), &job); err != nil {
+		logger.Error("Discarding malformed job", "subject", msg.Subject(), "error", err)
+		msg.Term()
+		return
+	}
+
+	job.Attempt = 1
+	if meta, err := msg.Metadata(); err == nil {
+		job.Attempt = int(meta.NumDelivered)
+	}
+
+	// Keep the message leased while the handler runs
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(lockTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				msg.InProgress()
+			}
+		}
+	}()
+
+	err := handler(ctx, &job)
+	switch {
+	case err == nil:
+		if ackErr := msg.Ack(); ackErr != nil {
+			logger.Error("Failed to ack job", "job_id", job.ID, "error", ackErr)
+		}
+
+	case IsPermanent(err) || job.Attempt >= MaxAttempts:
+		logger.Error("Job failed", "job_id", job.ID, "type", job.Type, "attempt", job.Attempt, "error", err)
+		msg.Term()
+
+	default:
+		logger.Warn("Job failed, retrying", "job_id", job.ID, "type", job.Type, "attempt", job.Attempt, "error", err)
+		msg.NakWithDelay(retryDelay(job.Attempt))
+	}
+}
+
+// Close drains the NATS connection
+func (q *NATSQueue) Close() error {
+	return q.conn.Drain()
+}
@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+)
+
+// Postgres queue timing
+const (
+	// pollInterval is how long an idle worker waits before polling again
+	pollInterval = time.Second
+	// lockTimeout returns a running job to the queue if its worker disappears
+	lockTimeout = 10 * time.Minute
+)
+
+// PostgresQueue is a Queue backed by the jobs table, claimed with FOR UPDATE SKIP LOCKED
+type PostgresQueue struct {
+	db *sql.DB
+}
+
+// NewPostgresQueue creates a new Postgres-backed queue
+func NewPostgresQueue(db *sql.DB) *PostgresQueue {
+	return &PostgresQueue{db: db}
+}
+
+// Enqueue inserts a pending job
+func (q *PostgresQueue) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	job, err := newJob("", jobType, payload)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO jobs (type, payload) VALUES ($1, $2)`
+	if _, err := q.db.ExecContext(ctx, query, job.Type, []byte(job.Payload)); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return nil
+}
+
+// Consume polls for due jobs with concurrency workers until ctx is cancelled
+func (q *PostgresQueue) Consume(ctx context.Context, concurrency int, handler Handler) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := q.claim(ctx)
+				if err != nil && ctx.Err() == nil {
+					logger.Error("Failed to claim job", "error", err)
+				}
+				if job == nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(pollInterval):
+						continue
+					}
+				}
+
+				q.finish(ctx, job, handler(ctx, job))
+			}
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// claim locks the next due job, including running jobs whose worker timed out
+func (q *PostgresQueue) claim(ctx context.Context) (*Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_until = $1, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = 'pending' AND run_at <= NOW())
+				OR (status = 'running' AND locked_until < NOW())
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, type, payload, attempts
+	`
+
+	var job Job
+	var payload []byte
+	err := q.db.QueryRowContext(ctx, query, time.Now().Add(lockTimeout)).
+		Scan(&job.ID, &job.Type, &payload, &job.Attempt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	job.Payload = payload
+	return &job, nil
+}
+
+// finish records a job's outcome, rescheduling it with backoff on retryable failures
+func (q *PostgresQueue) finish(ctx context.Context, job *Job, handlerErr error) {
+	var err error
+	switch {
+	case handlerErr == nil:
+		_, err = q.db.ExecContext(ctx,
+			`UPDATE jobs SET status = 'done', last_error = '', updated_at = NOW() WHERE id = $1`, job.ID)
+
+	case IsPermanent(handlerErr) || job.Attempt >= MaxAttempts:
+		logger.Error("Job failed", "job_id", job.ID, "type", job.Type, "attempt", job.Attempt, "error", handlerErr)
+		_, err = q.db.ExecContext(ctx,
+			`UPDATE jobs SET status = 'failed', last_error = $2, updated_at = NOW() WHERE id = $1`, job.ID, handlerErr.Error())
+
+	default:
+		logger.Warn("Job failed, retrying", "job_id", job.ID, "type", job.Type, "attempt", job.Attempt, "error", handlerErr)
+		_, err = q.db.ExecContext(ctx,
+			`UPDATE jobs SET status = 'pending', last_error = $2, run_at = $3, updated_at = NOW() WHERE id = $1`,
+			job.ID, handlerErr.Error(), time.Now().Add(retryDelay(job.Attempt)))
+	}
+
+	if err != nil {
+		// The lock timeout will hand the job to another worker
+		logger.Error("Failed to record job result", "job_id", job.ID, "error", err)
+	}
+}
+
+// Close is a no-op; the database connection is owned by the caller
+func (q *PostgresQueue) Close() error {
+	return nil
+}
@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Job is a unit of background work
+type Job struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+	// Attempt is 1 on the first delivery and increases with each retry
+	Attempt int `json:"-"`
+}
+
+// Handler processes a job; returning an error retries the job until MaxAttempts
+type Handler func(ctx context.Context, job *Job) error
+
+// Queue delivers jobs from producers (the API) to consumers (workers)
+type Queue interface {
+	// Enqueue submits a job; payload is encoded as JSON
+	Enqueue(ctx context.Context, jobType string, payload interface{}) error
+
+	// Consume processes jobs with up to concurrency handlers in flight and
+	// blocks until ctx is cancelled
+	Consume(ctx context.Context, concurrency int, handler Handler) error
+
+	// Close releases the queue's connections
+	Close() error
+}
+
+// MaxAttempts is the number of deliveries before a failing job is given up on
+const MaxAttempts = 5
+
+// retryDelay returns the backoff before the given attempt is retried
+func retryDelay(attempt int) time.Duration {
+	delay := time.Duration(1<<uint(attempt)) * time.Second
+	if delay > 5*time.Minute {
+		delay = 5 * time.Minute
+	}
+	return delay
+}
+
+// permanentError marks a job failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job fails immediately instead of being retried
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+// Router dispatches jobs to the handler registered for their type
+type Router struct {
+	handlers map[string]Handler
+}
+
+// NewRouter creates an empty router
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]Handler)}
+}
+
+// Handle registers the handler for a job type
+func (r *Router) Handle(jobType string, handler Handler) {
+	r.handlers[jobType] = handler
+}
+
+// Dispatch runs the handler for the job's type
+func (r *Router) Dispatch(ctx context.Context, job *Job) error {
+	handler, ok := r.handlers[job.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job type %q", job.Type))
+	}
+	return handler(ctx, job)
+}
+
+// newJob builds a job envelope for the payload
+func newJob(id, jobType string, payload interface{}) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+	return &Job{ID: id, Type: jobType, Payload: data}, nil
+}
@@ -0,0 +1,191 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+)
+
+// RabbitMQ queue names shared by every replica
+const (
+	rabbitQueue      = "rag_jobs"
+	rabbitRetryQueue = "rag_jobs.retry"
+	// rabbitAttemptHeader carries the delivery attempt across retries
+	rabbitAttemptHeader = "x-attempt"
+)
+
+// RabbitMQQueue is a Queue backed by a durable RabbitMQ queue.
+// Failed jobs wait in a retry queue with a per-message TTL and are
+// dead-lettered back onto the main queue when it expires.
+type RabbitMQQueue struct {
+	conn *amqp.Connection
+
+	mu      sync.Mutex
+	channel *amqp.Channel
+}
+
+// NewRabbitMQQueue connects to RabbitMQ and declares the job queues
+func NewRabbitMQQueue(url string) (*RabbitMQQueue, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+
+	if err := declareRabbitQueues(ch); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &RabbitMQQueue{
+		conn:    conn,
+		channel: ch,
+	}, nil
+}
+
+func declareRabbitQueues(ch *amqp.Channel) error {
+	if _, err := ch.QueueDeclare(rabbitQueue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare jobs queue: %w", err)
+	}
+
+	_, err := ch.QueueDeclare(rabbitRetryQueue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": rabbitQueue,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare retry queue: %w", err)
+	}
+
+	return nil
+}
+
+// Enqueue publishes a persistent job message
+func (q *RabbitMQQueue) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	job, err := newJob(uuid.New().String(), jobType, payload)
+	if err != nil {
+		return err
+	}
+
+	return q.publish(ctx, rabbitQueue, job, 1, 0)
+}
+
+// publish sends a job to the given queue; a non-zero delay sets the message TTL
+func (q *RabbitMQQueue) publish(ctx context.Context, queueName string, job *Job, attempt int, delay time.Duration) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	msg := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    job.ID,
+		Type:         job.Type,
+		Headers:      amqp.Table{rabbitAttemptHeader: int32(attempt)},
+		Body:         data,
+	}
+	if delay > 0 {
+		msg.Expiration = strconv.FormatInt(delay.Milliseconds(), 10)
+	}
+
+	// amqp channels are not safe for concurrent publishing
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.channel.PublishWithContext(ctx, "", queueName, false, false, msg); err != nil {
+		return fmt.Errorf("failed to publish job: %w", err)
+	}
+
+	return nil
+}
+
+// Consume receives jobs on a dedicated channel with prefetch set to concurrency
+func (q *RabbitMQQueue) Consume(ctx context.Context, concurrency int, handler Handler) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ch, err := q.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+	defer ch.Close()
+
+	if err := ch.Qos(concurrency, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+
+	deliveries, err := ch.ConsumeWithContext(ctx, rabbitQueue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume jobs: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for delivery := range deliveries {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(d amqp.Delivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			q.handle(ctx, d, handler)
+		}(delivery)
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// handle runs a single delivery; retries are republished to the retry queue before acking
+func (q *RabbitMQQueue) handle(ctx context.Context, d amqp.Delivery, handler Handler) {
+	var job Job
+	if err := json.Unmarshal(d.Body, &job); err != nil {
+		logger.Error("Discarding malformed job", "message_id", d.MessageId, "error", err)
+		d.Reject(false)
+		return
+	}
+
+	job.Attempt = 1
+	if attempt, ok := d.Headers[rabbitAttemptHeader].(int32); ok {
+		job.Attempt = int(attempt)
+	}
+
+	err := handler(ctx, &job)
+	switch {
+	case err == nil:
+
+	case IsPermanent(err) || job.Attempt >= MaxAttempts:
+		logger.Error("Job failed", "job_id", job.ID, "type", job.Type, "attempt", job.Attempt, "error", err)
+
+	default:
+		logger.Warn("Job failed, retrying", "job_id", job.ID, "type", job.Type, "attempt", job.Attempt, "error", err)
+		// Use a fresh context so shutdown doesn't lose the retry
+		if pubErr := q.publish(context.Background(), rabbitRetryQueue, &job, job.Attempt+1, retryDelay(job.Attempt)); pubErr != nil {
+			logger.Error("Failed to schedule job retry", "job_id", job.ID, "error", pubErr)
+			d.Nack(false, true)
+			return
+		}
+	}
+
+	if ackErr := d.Ack(false); ackErr != nil {
+		logger.Error("Failed to ack job", "job_id", job.ID, "error", ackErr)
+	}
+}
+
+// Close closes the RabbitMQ connection
+func (q *RabbitMQQueue) Close() error {
+	return q.conn.Close()
+}
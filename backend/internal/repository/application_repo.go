@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// ApplicationRepository handles job application data operations
+type ApplicationRepository struct {
+	db *sql.DB
+}
+
+// NewApplicationRepository creates a new application repository
+func NewApplicationRepository(db *sql.DB) *ApplicationRepository {
+	return &ApplicationRepository{db: db}
+}
+
+const applicationColumns = `id, user_id, posting_document_id, resume_document_id, company, role, status, notes, cover_letter,
+	applied_at, created_at, updated_at`
+
+// Create creates a new application
+func (r *ApplicationRepository) Create(ctx context.Context, a *model.Application) error {
+	query := `
+		INSERT INTO applications (user_id, posting_document_id, resume_document_id, company, role, status, notes, applied_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, a.UserID, a.PostingDocumentID, a.ResumeDocumentID, a.Company, a.Role, a.Status, a.Notes, a.AppliedAt).
+		Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create application: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an application owned by the user
+func (r *ApplicationRepository) GetByID(ctx context.Context, userID, id string) (*model.Application, error) {
+	query := `SELECT ` + applicationColumns + ` FROM applications WHERE id = $1 AND user_id = $2`
+
+	a, err := scanApplication(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("application %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application: %w", err)
+	}
+
+	return a, nil
+}
+
+// ListByUserID lists a user's applications, most recently updated first, optionally only those with a status
+func (r *ApplicationRepository) ListByUserID(ctx context.Context, userID, status string) ([]*model.Application, error) {
+	query := `SELECT ` + applicationColumns + ` FROM applications
+		WHERE user_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY updated_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applications: %w", err)
+	}
+	defer rows.Close()
+
+	applications := []*model.Application{}
+	for rows.Next() {
+		a, err := scanApplication(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan application: %w", err)
+		}
+		applications = append(applications, a)
+	}
+
+	return applications, rows.Err()
+}
+
+// Update updates an application's editable fields
+func (r *ApplicationRepository) Update(ctx context.Context, a *model.Application) error {
+	query := `
+		UPDATE applications
+		SET resume_document_id = $1, company = $2, role = $3, status = $4, notes = $5, cover_letter = $6,
+			applied_at = $7, updated_at = NOW()
+		WHERE id = $8 AND user_id = $9
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, a.ResumeDocumentID, a.Company, a.Role, a.Status, a.Notes, a.CoverLetter,
+		a.AppliedAt, a.ID, a.UserID).Scan(&a.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("application %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update application: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes an application owned by the user
+func (r *ApplicationRepository) Delete(ctx context.Context, userID, id string) error {
+	query := `DELETE FROM applications WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete application: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("application %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+func scanApplication(row rowScanner) (*model.Application, error) {
+	var a model.Application
+	var resumeDocumentID sql.NullString
+	var appliedAt sql.NullTime
+
+	if err := row.Scan(&a.ID, &a.UserID, &a.PostingDocumentID, &resumeDocumentID, &a.Company, &a.Role, &a.Status,
+		&a.Notes, &a.CoverLetter, &appliedAt, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if resumeDocumentID.Valid {
+		a.ResumeDocumentID = &resumeDocumentID.String
+	}
+	if appliedAt.Valid {
+		a.AppliedAt = &appliedAt.Time
+	}
+
+	return &a, nil
+}
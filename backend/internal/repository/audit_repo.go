@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// AuditRepository handles audit event data operations
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// auditEventColumns is the column list matching scanAuditEvent
+const auditEventColumns = `id, user_id, action, resource_id, ip_address, country, metadata, flagged, flag_reason, created_at`
+
+// scanAuditEvent scans a row selected with auditEventColumns
+func scanAuditEvent(row rowScanner) (*model.AuditEvent, error) {
+	var event model.AuditEvent
+	var metadataJSON []byte
+
+	err := row.Scan(&event.ID, &event.UserID, &event.Action, &event.ResourceID, &event.IPAddress,
+		&event.Country, &metadataJSON, &event.Flagged, &event.FlagReason, &event.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &event.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+
+	return &event, nil
+}
+
+// Create records an audit event
+func (r *AuditRepository) Create(ctx context.Context, event *model.AuditEvent) error {
+	var metadataJSON []byte
+	if len(event.Metadata) > 0 {
+		var err error
+		metadataJSON, err = json.Marshal(event.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO audit_events (user_id, action, resource_id, ip_address, country, metadata, flagged, flag_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, event.UserID, event.Action, event.ResourceID, event.IPAddress,
+		event.Country, metadataJSON, event.Flagged, event.FlagReason).
+		Scan(&event.ID, &event.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+
+	return nil
+}
+
+// CountActions counts a user's events of an action since the given time
+func (r *AuditRepository) CountActions(ctx context.Context, userID, action string, since time.Time) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM audit_events WHERE user_id = $1 AND action = $2 AND created_at >= $3`
+
+	if err := r.db.QueryRowContext(ctx, query, userID, action, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountDistinctResources counts the distinct resources a user acted on since the given time
+func (r *AuditRepository) CountDistinctResources(ctx context.Context, userID, action string, since time.Time) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(DISTINCT resource_id) FROM audit_events
+		WHERE user_id = $1 AND action = $2 AND created_at >= $3 AND resource_id <> ''
+	`
+
+	if err := r.db.QueryRowContext(ctx, query, userID, action, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count audit resources: %w", err)
+	}
+
+	return count, nil
+}
+
+// HasResourceAction reports whether a user acted on a resource since the given time
+func (r *AuditRepository) HasResourceAction(ctx context.Context, userID, action, resourceID string, since time.Time) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM audit_events
+			WHERE user_id = $1 AND action = $2 AND resource_id = $3 AND created_at >= $4
+		)
+	`
+
+	if err := r.db.QueryRowContext(ctx, query, userID, action, resourceID, since).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check audit events: %w", err)
+	}
+
+	return exists, nil
+}
+
+// CountryHistory reports whether a user has earlier actions with a known country and
+// whether any of them came from the given country
+func (r *AuditRepository) CountryHistory(ctx context.Context, userID, action, country string) (hasHistory, seen bool, err error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE country <> '') > 0,
+			COUNT(*) FILTER (WHERE country = $3) > 0
+		FROM audit_events
+		WHERE user_id = $1 AND action = $2
+	`
+
+	if err := r.db.QueryRowContext(ctx, query, userID, action, country).Scan(&hasHistory, &seen); err != nil {
+		return false, false, fmt.Errorf("failed to check country history: %w", err)
+	}
+
+	return hasHistory, seen, nil
+}
+
+// HasFlag reports whether a user already has an event flagged with the reason since the given time
+func (r *AuditRepository) HasFlag(ctx context.Context, userID, reason string, since time.Time) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM audit_events
+			WHERE user_id = $1 AND flagged AND flag_reason = $2 AND created_at >= $3
+		)
+	`
+
+	if err := r.db.QueryRowContext(ctx, query, userID, reason, since).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check audit flags: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ListFlagged lists flagged events across all users, newest first, with the total count
+func (r *AuditRepository) ListFlagged(ctx context.Context, limit, offset int) ([]*model.AuditEvent, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_events WHERE flagged`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count flagged events: %w", err)
+	}
+
+	query := `
+		SELECT ` + auditEventColumns + `
+		FROM audit_events
+		WHERE flagged
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list flagged events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*model.AuditEvent{}
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, total, rows.Err()
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// BlocklistRepository handles retrieval blocklist data operations
+type BlocklistRepository struct {
+	db *sql.DB
+}
+
+// NewBlocklistRepository creates a new blocklist repository
+func NewBlocklistRepository(db *sql.DB) *BlocklistRepository {
+	return &BlocklistRepository{db: db}
+}
+
+// Create creates a new blocklist rule
+func (r *BlocklistRepository) Create(ctx context.Context, rule *model.BlocklistRule) error {
+	query := `
+		INSERT INTO retrieval_blocklist (user_id, pattern, regex)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, rule.UserID, rule.Pattern, rule.Regex).
+		Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create blocklist rule: %w", err)
+	}
+
+	return nil
+}
+
+// ListByUserID lists a user's blocklist rules, oldest first
+func (r *BlocklistRepository) ListByUserID(ctx context.Context, userID string) ([]*model.BlocklistRule, error) {
+	query := `
+		SELECT id, user_id, pattern, regex, match_count, last_matched_at, created_at
+		FROM retrieval_blocklist
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocklist rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*model.BlocklistRule{}
+	for rows.Next() {
+		var rule model.BlocklistRule
+		var lastMatchedAt sql.NullTime
+		if err := rows.Scan(&rule.ID, &rule.UserID, &rule.Pattern, &rule.Regex, &rule.MatchCount, &lastMatchedAt, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blocklist rule: %w", err)
+		}
+		if lastMatchedAt.Valid {
+			rule.LastMatchedAt = &lastMatchedAt.Time
+		}
+		rules = append(rules, &rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// Delete deletes a blocklist rule
+func (r *BlocklistRepository) Delete(ctx context.Context, userID, id string) error {
+	query := `DELETE FROM retrieval_blocklist WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete blocklist rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("blocklist rule %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+// RecordMatches adds to the match counts of rules that blocked chunks
+func (r *BlocklistRepository) RecordMatches(ctx context.Context, matches map[string]int) error {
+	query := `
+		UPDATE retrieval_blocklist
+		SET match_count = match_count + $2, last_matched_at = NOW()
+		WHERE id = $1
+	`
+
+	for id, count := range matches {
+		if _, err := r.db.ExecContext(ctx, query, id, count); err != nil {
+			return fmt.Errorf("failed to record blocklist match: %w", err)
+		}
+	}
+
+	return nil
+}
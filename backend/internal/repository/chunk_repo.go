@@ -0,0 +1,456 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/lib/pq"
+)
+
+// ChunkRepository stores chunk text in Postgres for full-text search,
+// used as a keyword fallback when embeddings are unavailable
+type ChunkRepository struct {
+	db *sql.DB
+}
+
+// NewChunkRepository creates a new chunk repository
+func NewChunkRepository(db *sql.DB) *ChunkRepository {
+	return &ChunkRepository{db: db}
+}
+
+// InsertChunks stores the text and metadata of a document's vector points
+func (r *ChunkRepository) InsertChunks(ctx context.Context, userID string, points []*model.VectorPoint) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO document_chunks (id, document_id, user_id, content, metadata)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO NOTHING
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare chunk insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, point := range points {
+		content, _ := point.Payload["content"].(string)
+		documentID, _ := point.Payload["document_id"].(string)
+
+		metadata := make(map[string]interface{}, len(point.Payload))
+		for key, value := range point.Payload {
+			if key != "content" {
+				metadata[key] = value
+			}
+		}
+		metadataJSON, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal chunk metadata: %w", err)
+		}
+
+		if _, err := stmt.ExecContext(ctx, point.ID, documentID, userID, content, metadataJSON); err != nil {
+			return fmt.Errorf("failed to insert chunk: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chunks: %w", err)
+	}
+
+	return nil
+}
+
+// SearchText ranks a user's chunks against the query with Postgres full-text search.
+// Results have the same payload shape as vector search; Score is the text rank.
+func (r *ChunkRepository) SearchText(ctx context.Context, userID, query string, limit int, filter *SearchFilter) ([]*model.VectorPoint, error) {
+	match := []byte("{}")
+	if filter != nil && len(filter.Match) > 0 {
+		var err error
+		if match, err = json.Marshal(filter.Match); err != nil {
+			return nil, fmt.Errorf("failed to marshal filter: %w", err)
+		}
+	}
+
+	var documentIDs interface{}
+	if filter != nil && len(filter.DocumentIDs) > 0 {
+		documentIDs = pq.Array(filter.DocumentIDs)
+	}
+
+	// Each excluded key/value becomes a JSON object the metadata must not contain
+	excluded := []string{}
+	excludedDocumentIDs := []string{}
+	if filter != nil {
+		for key, values := range filter.Exclude {
+			for _, value := range values {
+				object, err := json.Marshal(map[string]string{key: value})
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal exclusion: %w", err)
+				}
+				excluded = append(excluded, string(object))
+			}
+		}
+		excludedDocumentIDs = append(excludedDocumentIDs, filter.ExcludeDocumentIDs...)
+	}
+
+	// Each range bound becomes a JSON path predicate the metadata must satisfy
+	ranges := []string{}
+	if filter != nil {
+		for key, bounds := range filter.Ranges {
+			if bounds.Gte != nil {
+				ranges = append(ranges, fmt.Sprintf("$.%s >= %s", strconv.Quote(key), strconv.FormatFloat(*bounds.Gte, 'f', -1, 64)))
+			}
+			if bounds.Lt != nil {
+				ranges = append(ranges, fmt.Sprintf("$.%s < %s", strconv.Quote(key), strconv.FormatFloat(*bounds.Lt, 'f', -1, 64)))
+			}
+		}
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, content, metadata, ts_rank_cd(search_vector, q) AS rank
+		FROM document_chunks, websearch_to_tsquery('simple', $2) q
+		WHERE user_id = $1 AND search_vector @@ q AND metadata @> $3
+			AND ($5::uuid[] IS NULL OR document_id = ANY($5))
+			AND NOT metadata @> ANY($6::jsonb[])
+			AND NOT document_id = ANY($7::uuid[])
+			AND metadata @@ ALL($8::jsonpath[])
+		ORDER BY rank DESC
+		LIMIT $4
+	`, userID, query, match, limit, documentIDs, pq.Array(excluded), pq.Array(excludedDocumentIDs), pq.Array(ranges))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*model.VectorPoint
+	for rows.Next() {
+		var point model.VectorPoint
+		var content string
+		var metadata []byte
+		if err := rows.Scan(&point.ID, &content, &metadata, &point.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+
+		if err := json.Unmarshal(metadata, &point.Payload); err != nil || point.Payload == nil {
+			point.Payload = make(map[string]interface{})
+		}
+		point.Payload["content"] = content
+
+		results = append(results, &point)
+	}
+
+	return results, rows.Err()
+}
+
+// GetDocumentText returns a document's chunk text in reading order, truncated to about maxChars
+func (r *ChunkRepository) GetDocumentText(ctx context.Context, documentID string, maxChars int) (string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT content
+		FROM document_chunks
+		WHERE document_id = $1
+		ORDER BY COALESCE((metadata->>'chunk_index')::int, 0)
+	`, documentID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get document text: %w", err)
+	}
+	defer rows.Close()
+
+	var text strings.Builder
+	for rows.Next() && text.Len() < maxChars {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			return "", fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		text.WriteString(content)
+		text.WriteString("\n")
+	}
+
+	return text.String(), rows.Err()
+}
+
+// ListSimHashes returns the content fingerprints stored with a user's chunks, as the hex strings
+// they were stored as
+func (r *ChunkRepository) ListSimHashes(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT metadata->>'simhash'
+		FROM document_chunks
+		WHERE user_id = $1 AND metadata ? 'simhash'
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chunk fingerprints: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk fingerprint: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+// ListByDocumentID returns a document's chunks in reading order, with the same payload shape
+// they were inserted with
+func (r *ChunkRepository) ListByDocumentID(ctx context.Context, documentID string) ([]*model.VectorPoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, content, metadata
+		FROM document_chunks
+		WHERE document_id = $1
+		ORDER BY COALESCE((metadata->>'chunk_index')::int, 0)
+	`, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var points []*model.VectorPoint
+	for rows.Next() {
+		var point model.VectorPoint
+		var content string
+		var metadata []byte
+		if err := rows.Scan(&point.ID, &content, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+
+		point.Payload, err = decodeChunkMetadata(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode metadata of chunk %s: %w", point.ID, err)
+		}
+		point.Payload["content"] = content
+
+		points = append(points, &point)
+	}
+
+	return points, rows.Err()
+}
+
+// SampleChunks returns up to limit of the user's chunks picked at random, with only their content
+// in the payload
+func (r *ChunkRepository) SampleChunks(ctx context.Context, userID string, limit int) ([]*model.VectorPoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, content
+		FROM document_chunks
+		WHERE user_id = $1
+		ORDER BY random()
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var points []*model.VectorPoint
+	for rows.Next() {
+		var point model.VectorPoint
+		var content string
+		if err := rows.Scan(&point.ID, &content); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		point.Payload = map[string]interface{}{"content": content}
+		points = append(points, &point)
+	}
+
+	return points, rows.Err()
+}
+
+// decodeChunkMetadata decodes stored metadata back into payload types: whole numbers as int64
+// and lists of strings as []string, as they were before being stored as JSON
+func decodeChunkMetadata(metadata []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(metadata))
+	decoder.UseNumber()
+
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	payload := make(map[string]interface{}, len(raw)+1)
+	for key, value := range raw {
+		switch v := value.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				payload[key] = n
+			} else if f, err := v.Float64(); err == nil {
+				payload[key] = f
+			}
+		case []interface{}:
+			values := make([]string, 0, len(v))
+			for _, item := range v {
+				if str, ok := item.(string); ok {
+					values = append(values, str)
+				}
+			}
+			payload[key] = values
+		default:
+			payload[key] = v
+		}
+	}
+
+	return payload, nil
+}
+
+// ListWarranties returns one warranty per document whose chunks carry a warranty expiry, soonest expiry first
+func (r *ChunkRepository) ListWarranties(ctx context.Context, userID string) ([]*model.Warranty, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT document_id, metadata
+		FROM (
+			SELECT DISTINCT ON (document_id) document_id, metadata
+			FROM document_chunks
+			WHERE user_id = $1 AND metadata ? 'warranty_expires_at'
+			ORDER BY document_id, COALESCE((metadata->>'chunk_index')::int, 0)
+		) w
+		ORDER BY (metadata->>'warranty_expires_at')::bigint
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list warranties: %w", err)
+	}
+	defer rows.Close()
+
+	var warranties []*model.Warranty
+	for rows.Next() {
+		var warranty model.Warranty
+		var metadataJSON []byte
+		if err := rows.Scan(&warranty.DocumentID, &metadataJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan warranty: %w", err)
+		}
+
+		var metadata struct {
+			Filename     string   `json:"filename"`
+			DocType      string   `json:"doc_type"`
+			Product      string   `json:"product"`
+			ModelNumbers []string `json:"model_numbers"`
+			ExpiresAt    int64    `json:"warranty_expires_at"`
+		}
+		if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode warranty metadata: %w", err)
+		}
+		warranty.Filename = metadata.Filename
+		warranty.DocType = metadata.DocType
+		warranty.Product = metadata.Product
+		warranty.ModelNumbers = metadata.ModelNumbers
+		warranty.ExpiresAt = time.Unix(metadata.ExpiresAt, 0).UTC()
+
+		warranties = append(warranties, &warranty)
+	}
+
+	return warranties, rows.Err()
+}
+
+// ListUpcomingTravel returns one booking per travel document whose trip ends at or after the given time,
+// soonest trip first
+func (r *ChunkRepository) ListUpcomingTravel(ctx context.Context, userID string, after time.Time) ([]*model.TravelBooking, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT document_id, metadata
+		FROM (
+			SELECT DISTINCT ON (document_id) document_id, metadata
+			FROM document_chunks
+			WHERE user_id = $1 AND metadata->>'profile' = 'travel' AND (metadata->>'travel_end_at')::bigint >= $2
+			ORDER BY document_id, COALESCE((metadata->>'chunk_index')::int, 0)
+		) t
+		ORDER BY (metadata->>'travel_start_at')::bigint
+	`, userID, after.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list travel bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var bookings []*model.TravelBooking
+	for rows.Next() {
+		var booking model.TravelBooking
+		var metadataJSON []byte
+		if err := rows.Scan(&booking.DocumentID, &metadataJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan travel booking: %w", err)
+		}
+
+		var metadata struct {
+			Filename      string   `json:"filename"`
+			DocType       string   `json:"doc_type"`
+			BookingRefs   []string `json:"booking_refs"`
+			FlightNumbers []string `json:"flight_numbers"`
+			Hotel         string   `json:"hotel"`
+			Places        []string `json:"places"`
+			StartsAt      int64    `json:"travel_start_at"`
+			EndsAt        int64    `json:"travel_end_at"`
+		}
+		if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode travel metadata: %w", err)
+		}
+		booking.Filename = metadata.Filename
+		booking.DocType = metadata.DocType
+		booking.BookingRefs = metadata.BookingRefs
+		booking.FlightNumbers = metadata.FlightNumbers
+		booking.Hotel = metadata.Hotel
+		booking.Places = metadata.Places
+		booking.StartsAt = time.Unix(metadata.StartsAt, 0).UTC()
+		booking.EndsAt = time.Unix(metadata.EndsAt, 0).UTC()
+
+		bookings = append(bookings, &booking)
+	}
+
+	return bookings, rows.Err()
+}
+
+// ListReceipts lists the receipts and invoices with a total dated within [from, to), oldest first
+func (r *ChunkRepository) ListReceipts(ctx context.Context, userID string, from, to time.Time) ([]*model.Receipt, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT document_id, metadata
+		FROM (
+			SELECT DISTINCT ON (document_id) document_id, metadata
+			FROM document_chunks
+			WHERE user_id = $1 AND metadata->>'profile' = 'expense' AND metadata->>'expense_total' IS NOT NULL
+				AND (metadata->>'expense_date_at')::bigint >= $2 AND (metadata->>'expense_date_at')::bigint < $3
+			ORDER BY document_id, COALESCE((metadata->>'chunk_index')::int, 0)
+		) t
+		ORDER BY (metadata->>'expense_date_at')::bigint
+	`, userID, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list receipts: %w", err)
+	}
+	defer rows.Close()
+
+	var receipts []*model.Receipt
+	for rows.Next() {
+		var receipt model.Receipt
+		var metadataJSON []byte
+		if err := rows.Scan(&receipt.DocumentID, &metadataJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan receipt: %w", err)
+		}
+
+		var metadata struct {
+			Filename string   `json:"filename"`
+			DocType  string   `json:"doc_type"`
+			Merchant string   `json:"merchant"`
+			DateAt   int64    `json:"expense_date_at"`
+			Total    float64  `json:"expense_total"`
+			Currency string   `json:"expense_currency"`
+			Items    []string `json:"items"`
+		}
+		if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode receipt metadata: %w", err)
+		}
+		receipt.Filename = metadata.Filename
+		receipt.DocType = metadata.DocType
+		receipt.Merchant = metadata.Merchant
+		receipt.Date = time.Unix(metadata.DateAt, 0).UTC()
+		receipt.Total = metadata.Total
+		receipt.Currency = metadata.Currency
+		receipt.Items = metadata.Items
+
+		receipts = append(receipts, &receipt)
+	}
+
+	return receipts, rows.Err()
+}
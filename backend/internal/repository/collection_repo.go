@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// CollectionRepository handles the vector collection registry
+type CollectionRepository struct {
+	db *sql.DB
+}
+
+// NewCollectionRepository creates a new collection registry repository
+func NewCollectionRepository(db *sql.DB) *CollectionRepository {
+	return &CollectionRepository{db: db}
+}
+
+// Register records the name of a user's collection unless one is recorded, and returns the
+// recorded name
+func (r *CollectionRepository) Register(ctx context.Context, userID, scope, name string) (string, error) {
+	query := `
+		INSERT INTO vector_collections (user_id, scope, name)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, scope) DO NOTHING
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, scope, name); err != nil {
+		return "", fmt.Errorf("failed to register collection: %w", err)
+	}
+
+	collection, err := r.Get(ctx, userID, scope)
+	if err != nil {
+		return "", err
+	}
+	return collection.Name, nil
+}
+
+// Get returns the registry entry of a user's collection
+func (r *CollectionRepository) Get(ctx context.Context, userID, scope string) (*model.VectorCollection, error) {
+	query := `
+		SELECT user_id, scope, name, previous_name, updated_at
+		FROM vector_collections
+		WHERE user_id = $1 AND scope = $2
+	`
+
+	collection, err := scanCollection(r.db.QueryRowContext(ctx, query, userID, scope))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("collection %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection: %w", err)
+	}
+	return collection, nil
+}
+
+// ListByUserID lists a user's registered collections
+func (r *CollectionRepository) ListByUserID(ctx context.Context, userID string) ([]*model.VectorCollection, error) {
+	query := `
+		SELECT user_id, scope, name, previous_name, updated_at
+		FROM vector_collections
+		WHERE user_id = $1
+		ORDER BY scope
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	defer rows.Close()
+
+	collections := []*model.VectorCollection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		collections = append(collections, collection)
+	}
+
+	return collections, rows.Err()
+}
+
+// Rename records a new name for a user's collection, keeping the current one as its previous name
+func (r *CollectionRepository) Rename(ctx context.Context, userID, scope, name string) error {
+	query := `
+		UPDATE vector_collections
+		SET previous_name = name, name = $3, updated_at = NOW()
+		WHERE user_id = $1 AND scope = $2
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, scope, name); err != nil {
+		return fmt.Errorf("failed to rename collection: %w", err)
+	}
+	return nil
+}
+
+// ClearPreviousName forgets a collection's previous name once it has been dropped
+func (r *CollectionRepository) ClearPreviousName(ctx context.Context, userID, scope string) error {
+	query := `UPDATE vector_collections SET previous_name = NULL WHERE user_id = $1 AND scope = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, scope); err != nil {
+		return fmt.Errorf("failed to clear previous collection name: %w", err)
+	}
+	return nil
+}
+
+// scanCollection scans a vector_collections row
+func scanCollection(row rowScanner) (*model.VectorCollection, error) {
+	var collection model.VectorCollection
+	var previousName sql.NullString
+	if err := row.Scan(&collection.UserID, &collection.Scope, &collection.Name, &previousName, &collection.UpdatedAt); err != nil {
+		return nil, err
+	}
+	collection.PreviousName = previousName.String
+	return &collection, nil
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/lib/pq"
+)
+
+// ConversationRepository handles conversation data operations
+type ConversationRepository struct {
+	db *sql.DB
+}
+
+// NewConversationRepository creates a new conversation repository
+func NewConversationRepository(db *sql.DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// conversationColumns is the column list matching scanConversation
+const conversationColumns = `id, user_id, title, model, temperature, language, filters, created_at, updated_at,
+	COALESCE(active_message_id::text, '')`
+
+// scanConversation scans a row selected with conversationColumns
+func scanConversation(row rowScanner) (*model.Conversation, error) {
+	var conv model.Conversation
+	var temperature sql.NullFloat64
+	var filtersJSON []byte
+
+	err := row.Scan(&conv.ID, &conv.UserID, &conv.Title, &conv.Model, &temperature, &conv.Language, &filtersJSON, &conv.CreatedAt, &conv.UpdatedAt,
+		&conv.ActiveMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if temperature.Valid {
+		conv.Temperature = &temperature.Float64
+	}
+	if len(filtersJSON) > 0 {
+		if err := json.Unmarshal(filtersJSON, &conv.Filters); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal filters: %w", err)
+		}
+	}
+
+	return &conv, nil
+}
+
+// Create creates a new conversation
+func (r *ConversationRepository) Create(ctx context.Context, conv *model.Conversation) error {
+	filtersJSON, err := marshalFilters(conv.Filters)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO conversations (user_id, title, model, temperature, language, filters)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query, conv.UserID, conv.Title, conv.Model, conv.Temperature, conv.Language, filtersJSON).
+		Scan(&conv.ID, &conv.CreatedAt, &conv.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create conversation: %w", err)
+	}
+
+	return nil
+}
+
+// Import creates a conversation from an account export, keeping its timestamps
+func (r *ConversationRepository) Import(ctx context.Context, conv *model.Conversation) error {
+	filtersJSON, err := marshalFilters(conv.Filters)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO conversations (user_id, title, model, temperature, language, filters, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	err = r.db.QueryRowContext(ctx, query, conv.UserID, conv.Title, conv.Model, conv.Temperature, conv.Language, filtersJSON,
+		conv.CreatedAt, conv.UpdatedAt).Scan(&conv.ID)
+	if err != nil {
+		return fmt.Errorf("failed to import conversation: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreActiveMessage shows the branch ending at the message without marking the conversation
+// as active now
+func (r *ConversationRepository) RestoreActiveMessage(ctx context.Context, id, messageID string) error {
+	query := `UPDATE conversations SET active_message_id = $1 WHERE id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, messageID, id); err != nil {
+		return fmt.Errorf("failed to update conversation: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a conversation owned by the user
+func (r *ConversationRepository) GetByID(ctx context.Context, userID, id string) (*model.Conversation, error) {
+	query := `SELECT ` + conversationColumns + ` FROM conversations WHERE id = $1 AND user_id = $2`
+
+	conv, err := scanConversation(r.db.QueryRowContext(ctx, query, id, userID))
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("conversation %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	return conv, nil
+}
+
+// ListByUserID lists a user's conversations, most recently active first
+func (r *ConversationRepository) ListByUserID(ctx context.Context, userID string, limit, offset int) ([]*model.Conversation, error) {
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = $1
+		ORDER BY updated_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	return r.list(ctx, query, userID, limit, offset)
+}
+
+// ListByIDs retrieves the user's conversations with the given IDs (in no particular order)
+func (r *ConversationRepository) ListByIDs(ctx context.Context, userID string, ids []string) ([]*model.Conversation, error) {
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = $1 AND id::text = ANY($2)
+	`
+
+	return r.list(ctx, query, userID, pq.Array(ids))
+}
+
+// UpdateTitle sets a conversation's title
+func (r *ConversationRepository) UpdateTitle(ctx context.Context, id, title string) error {
+	query := `UPDATE conversations SET title = $1 WHERE id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, title, id); err != nil {
+		return fmt.Errorf("failed to update conversation title: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSettings saves a conversation's title and locked model, temperature, language and filters
+func (r *ConversationRepository) UpdateSettings(ctx context.Context, conv *model.Conversation) error {
+	filtersJSON, err := marshalFilters(conv.Filters)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE conversations
+		SET title = $1, model = $2, temperature = $3, language = $4, filters = $5, updated_at = NOW()
+		WHERE id = $6 AND user_id = $7
+		RETURNING updated_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query, conv.Title, conv.Model, conv.Temperature, conv.Language, filtersJSON, conv.ID, conv.UserID).
+		Scan(&conv.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("conversation %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update conversation settings: %w", err)
+	}
+
+	return nil
+}
+
+// SetActiveMessage shows the branch ending at the message and marks the conversation as active now
+func (r *ConversationRepository) SetActiveMessage(ctx context.Context, id, messageID string) error {
+	query := `UPDATE conversations SET active_message_id = $1, updated_at = NOW() WHERE id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, messageID, id); err != nil {
+		return fmt.Errorf("failed to update conversation: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a conversation (and its messages) owned by the user
+func (r *ConversationRepository) Delete(ctx context.Context, userID, id string) error {
+	query := `DELETE FROM conversations WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("conversation %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ListMessages lists the query history entries of all branches of a conversation in
+// chronological order
+func (r *ConversationRepository) ListMessages(ctx context.Context, conversationID string) ([]*model.QueryHistory, error) {
+	query := `
+		SELECT ` + queryHistoryColumns + `
+		FROM query_history
+		WHERE conversation_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []*model.QueryHistory{}
+	for rows.Next() {
+		entry, err := scanQueryHistory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation message: %w", err)
+		}
+		messages = append(messages, entry)
+	}
+
+	return messages, rows.Err()
+}
+
+func (r *ConversationRepository) list(ctx context.Context, query string, args ...interface{}) ([]*model.Conversation, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	defer rows.Close()
+
+	conversations := []*model.Conversation{}
+	for rows.Next() {
+		conv, err := scanConversation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		conversations = append(conversations, conv)
+	}
+
+	return conversations, rows.Err()
+}
+
+// marshalFilters encodes metadata filters as JSON, storing NULL when there are none
+func marshalFilters(filters map[string]string) ([]byte, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal filters: %w", err)
+	}
+	return data, nil
+}
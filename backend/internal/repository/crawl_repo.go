@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// CrawlRepository handles website crawl job data operations
+type CrawlRepository struct {
+	db *sql.DB
+}
+
+// NewCrawlRepository creates a new crawl repository
+func NewCrawlRepository(db *sql.DB) *CrawlRepository {
+	return &CrawlRepository{db: db}
+}
+
+// ErrCrawlFinished is returned when cancelling a crawl that has already finished
+var ErrCrawlFinished = errors.New("crawl has already finished")
+
+const crawlColumns = `id, user_id, seed_url, max_depth, max_pages, same_domain, status, pages_crawled,
+	pages_indexed, pages_unchanged, pages_failed, current_url, error, created_at, started_at, finished_at`
+
+// scanCrawl scans a row selected with crawlColumns
+func scanCrawl(row rowScanner) (*model.CrawlJob, error) {
+	var job model.CrawlJob
+	var startedAt, finishedAt sql.NullTime
+	err := row.Scan(
+		&job.ID, &job.UserID, &job.SeedURL, &job.MaxDepth, &job.MaxPages, &job.SameDomain, &job.Status,
+		&job.PagesCrawled, &job.PagesIndexed, &job.PagesUnchanged, &job.PagesFailed, &job.CurrentURL,
+		&job.Error, &job.CreatedAt, &startedAt, &finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return &job, nil
+}
+
+// Create records a queued crawl
+func (r *CrawlRepository) Create(ctx context.Context, job *model.CrawlJob) error {
+	query := `
+		INSERT INTO crawl_jobs (user_id, seed_url, max_depth, max_pages, same_domain)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, job.UserID, job.SeedURL, job.MaxDepth, job.MaxPages, job.SameDomain).
+		Scan(&job.ID, &job.Status, &job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create crawl: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a crawl; an empty userID skips the ownership check, for the job handler
+func (r *CrawlRepository) GetByID(ctx context.Context, userID, id string) (*model.CrawlJob, error) {
+	query := `SELECT ` + crawlColumns + ` FROM crawl_jobs WHERE id = $1 AND ($2 = '' OR user_id::text = $2)`
+
+	job, err := scanCrawl(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("crawl %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get crawl: %w", err)
+	}
+
+	return job, nil
+}
+
+// ListByUserID lists a user's crawls, newest first
+func (r *CrawlRepository) ListByUserID(ctx context.Context, userID string, limit, offset int) ([]*model.CrawlJob, error) {
+	query := `
+		SELECT ` + crawlColumns + `
+		FROM crawl_jobs
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list crawls: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*model.CrawlJob{}
+	for rows.Next() {
+		job, err := scanCrawl(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan crawl: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Start marks a queued crawl as running. It reports false when the crawl isn't queued, because
+// it was cancelled or another attempt already ran it.
+func (r *CrawlRepository) Start(ctx context.Context, id string) (bool, error) {
+	query := `UPDATE crawl_jobs SET status = 'running', started_at = NOW() WHERE id = $1 AND status = 'queued'`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to start crawl: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// UpdateProgress records a running crawl's counters and the page it is on. It reports false when
+// the crawl is no longer running because it was cancelled.
+func (r *CrawlRepository) UpdateProgress(ctx context.Context, job *model.CrawlJob) (bool, error) {
+	query := `
+		UPDATE crawl_jobs
+		SET pages_crawled = $2, pages_indexed = $3, pages_unchanged = $4, pages_failed = $5, current_url = $6
+		WHERE id = $1 AND status = 'running'
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		job.ID, job.PagesCrawled, job.PagesIndexed, job.PagesUnchanged, job.PagesFailed, job.CurrentURL,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update crawl progress: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Finish marks a running crawl as completed or failed; a cancelled crawl keeps its status
+func (r *CrawlRepository) Finish(ctx context.Context, id, status, errMsg string) error {
+	query := `
+		UPDATE crawl_jobs
+		SET status = $2, error = $3, current_url = '', finished_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, status, errMsg); err != nil {
+		return fmt.Errorf("failed to finish crawl: %w", err)
+	}
+
+	return nil
+}
+
+// Cancel stops a queued or running crawl owned by the user. A running crawl stops after the page
+// it is on.
+func (r *CrawlRepository) Cancel(ctx context.Context, userID, id string) error {
+	query := `
+		UPDATE crawl_jobs
+		SET status = 'cancelled', current_url = '', finished_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status IN ('queued', 'running')
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel crawl: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		if _, err := r.GetByID(ctx, userID, id); err != nil {
+			return err
+		}
+		return ErrCrawlFinished
+	}
+
+	return nil
+}
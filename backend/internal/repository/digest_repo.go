@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/lib/pq"
+)
+
+// DigestRepository handles digest data operations
+type DigestRepository struct {
+	db *sql.DB
+}
+
+// NewDigestRepository creates a new digest repository
+func NewDigestRepository(db *sql.DB) *DigestRepository {
+	return &DigestRepository{db: db}
+}
+
+const digestColumns = `id, user_id, period_start, period_end, content, document_ids, created_at`
+
+// scanDigest scans a row selected with digestColumns
+func scanDigest(row rowScanner) (*model.Digest, error) {
+	var d model.Digest
+	err := row.Scan(&d.ID, &d.UserID, &d.PeriodStart, &d.PeriodEnd, &d.Content, pq.Array(&d.DocumentIDs), &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.DocumentCount = len(d.DocumentIDs)
+	return &d, nil
+}
+
+// Create stores a digest. It reports false without error when the user already
+// has a digest for the period, so reruns don't duplicate it.
+func (r *DigestRepository) Create(ctx context.Context, d *model.Digest) (bool, error) {
+	query := `
+		INSERT INTO digests (user_id, period_start, period_end, content, document_ids)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, period_start) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		d.UserID, d.PeriodStart, d.PeriodEnd, d.Content, pq.Array(d.DocumentIDs)).
+		Scan(&d.ID, &d.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create digest: %w", err)
+	}
+
+	d.DocumentCount = len(d.DocumentIDs)
+	return true, nil
+}
+
+// Exists reports whether the user already has a digest for the period starting at periodStart
+func (r *DigestRepository) Exists(ctx context.Context, userID string, periodStart time.Time) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM digests WHERE user_id = $1 AND period_start = $2)`
+
+	if err := r.db.QueryRowContext(ctx, query, userID, periodStart).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check digest: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetByID retrieves a digest owned by the user
+func (r *DigestRepository) GetByID(ctx context.Context, userID, id string) (*model.Digest, error) {
+	query := `SELECT ` + digestColumns + ` FROM digests WHERE id = $1 AND user_id = $2`
+
+	d, err := scanDigest(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("digest %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest: %w", err)
+	}
+
+	return d, nil
+}
+
+// ListByUserID lists a user's digests, newest first
+func (r *DigestRepository) ListByUserID(ctx context.Context, userID string, limit, offset int) ([]*model.Digest, error) {
+	query := `
+		SELECT ` + digestColumns + `
+		FROM digests
+		WHERE user_id = $1
+		ORDER BY period_start DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digests: %w", err)
+	}
+	defer rows.Close()
+
+	digests := []*model.Digest{}
+	for rows.Next() {
+		d, err := scanDigest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan digest: %w", err)
+		}
+		digests = append(digests, d)
+	}
+
+	return digests, rows.Err()
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// DiscordRepository handles Discord account link data operations
+type DiscordRepository struct {
+	db *sql.DB
+}
+
+// NewDiscordRepository creates a new Discord repository
+func NewDiscordRepository(db *sql.DB) *DiscordRepository {
+	return &DiscordRepository{db: db}
+}
+
+// Discord link errors
+var (
+	// ErrDiscordLinkNotFound is returned when the user or Discord account isn't linked
+	ErrDiscordLinkNotFound = fmt.Errorf("discord link %w", apperror.ErrNotFound)
+	// ErrDiscordLinkCodeInvalid is returned for unknown or expired link codes
+	ErrDiscordLinkCodeInvalid = errors.New("invalid or expired link code")
+)
+
+const discordLinkColumns = `user_id, discord_user_id, linked_at, created_at`
+
+// scanDiscordLink scans a row selected with discordLinkColumns
+func scanDiscordLink(row rowScanner) (*model.DiscordLink, error) {
+	var l model.DiscordLink
+	var discordUserID sql.NullString
+	var linkedAt sql.NullTime
+
+	if err := row.Scan(&l.UserID, &discordUserID, &linkedAt, &l.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	l.DiscordUserID = discordUserID.String
+	if linkedAt.Valid {
+		l.LinkedAt = &linkedAt.Time
+	}
+
+	return &l, nil
+}
+
+// SaveLinkCode stores a user's pending link code, replacing any earlier code. An existing link
+// stays in place until the code is used.
+func (r *DiscordRepository) SaveLinkCode(ctx context.Context, userID, codeHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO discord_links (user_id, link_code_hash, link_code_expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET link_code_hash = EXCLUDED.link_code_hash, link_code_expires_at = EXCLUDED.link_code_expires_at
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, codeHash, expiresAt.UTC()); err != nil {
+		return fmt.Errorf("failed to save link code: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteLink links the Discord account to the user holding the unexpired code and consumes
+// the code. A Discord account links to one user at a time, so any other link to it is removed.
+func (r *DiscordRepository) CompleteLink(ctx context.Context, codeHash, discordUserID string, now time.Time) (*model.DiscordLink, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE discord_links SET discord_user_id = NULL, linked_at = NULL
+		WHERE discord_user_id = $1 AND (link_code_hash IS NULL OR link_code_hash <> $2)
+	`, discordUserID, codeHash); err != nil {
+		return nil, fmt.Errorf("failed to unlink previous user: %w", err)
+	}
+
+	link, err := scanDiscordLink(tx.QueryRowContext(ctx, `
+		UPDATE discord_links
+		SET discord_user_id = $2, linked_at = $3, link_code_hash = NULL, link_code_expires_at = NULL
+		WHERE link_code_hash = $1 AND link_code_expires_at > $3
+		RETURNING `+discordLinkColumns, codeHash, discordUserID, now.UTC()))
+	if err == sql.ErrNoRows {
+		return nil, ErrDiscordLinkCodeInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete link: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit link: %w", err)
+	}
+
+	return link, nil
+}
+
+// GetByUserID retrieves a user's link, pending or complete
+func (r *DiscordRepository) GetByUserID(ctx context.Context, userID string) (*model.DiscordLink, error) {
+	return r.get(ctx, `SELECT `+discordLinkColumns+` FROM discord_links WHERE user_id = $1`, userID)
+}
+
+// GetByDiscordUserID retrieves the link of a Discord account
+func (r *DiscordRepository) GetByDiscordUserID(ctx context.Context, discordUserID string) (*model.DiscordLink, error) {
+	return r.get(ctx, `SELECT `+discordLinkColumns+` FROM discord_links WHERE discord_user_id = $1`, discordUserID)
+}
+
+// Delete removes a user's link and any pending code
+func (r *DiscordRepository) Delete(ctx context.Context, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM discord_links WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete discord link: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrDiscordLinkNotFound
+	}
+
+	return nil
+}
+
+func (r *DiscordRepository) get(ctx context.Context, query string, arg string) (*model.DiscordLink, error) {
+	link, err := scanDiscordLink(r.db.QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return nil, ErrDiscordLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discord link: %w", err)
+	}
+
+	return link, nil
+}
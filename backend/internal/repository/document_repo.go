@@ -4,9 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/lib/pq"
 )
 
 // DocumentRepository handles document data operations
@@ -19,19 +24,28 @@ func NewDocumentRepository(db *sql.DB) *DocumentRepository {
 	return &DocumentRepository{db: db}
 }
 
+// ErrDuplicateDocument is returned by Create when the user already has a document with the same content hash
+var ErrDuplicateDocument = errors.New("document already exists")
+
 // Create creates a new document record
 func (r *DocumentRepository) Create(ctx context.Context, doc *model.Document) error {
 	query := `
-		INSERT INTO documents (user_id, filename, file_type, file_size, file_hash, storage_path, total_chunks)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO documents (user_id, filename, file_type, file_size, file_hash, storage_path, total_chunks,
+			chunking_strategy, chunk_size, chunk_overlap)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, upload_date
 	`
 
 	err := r.db.QueryRowContext(ctx, query,
 		doc.UserID, doc.Filename, doc.FileType, doc.FileSize,
-		doc.FileHash, doc.StoragePath, doc.TotalChunks).
+		doc.FileHash, doc.StoragePath, doc.TotalChunks,
+		doc.ChunkingStrategy, doc.ChunkSize, doc.ChunkOverlap).
 		Scan(&doc.ID, &doc.UploadDate)
 
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateDocument
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create document: %w", err)
 	}
@@ -39,39 +53,292 @@ func (r *DocumentRepository) Create(ctx context.Context, doc *model.Document) er
 	return nil
 }
 
+// documentColumns is the column list matching scanDocument
+const documentColumns = `id, user_id, filename, file_type, file_size, file_hash, storage_path, total_chunks,
+	chunking_strategy, chunk_size, chunk_overlap, pinned, favorite, canary, summary, upload_date`
+
+// scanDocument scans a row selected with documentColumns
+func scanDocument(row rowScanner) (*model.Document, error) {
+	var doc model.Document
+	err := row.Scan(
+		&doc.ID, &doc.UserID, &doc.Filename, &doc.FileType, &doc.FileSize,
+		&doc.FileHash, &doc.StoragePath, &doc.TotalChunks,
+		&doc.ChunkingStrategy, &doc.ChunkSize, &doc.ChunkOverlap, &doc.Pinned, &doc.Favorite, &doc.Canary, &doc.Summary, &doc.UploadDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
 // GetByID retrieves a document by ID
 func (r *DocumentRepository) GetByID(ctx context.Context, id string) (*model.Document, error) {
-	var doc model.Document
+	query := `SELECT ` + documentColumns + ` FROM documents WHERE id = $1`
+
+	doc, err := scanDocument(r.db.QueryRowContext(ctx, query, id))
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("document %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// GetByHash retrieves the user's document with the given content hash
+func (r *DocumentRepository) GetByHash(ctx context.Context, userID, fileHash string) (*model.Document, error) {
+	query := `SELECT ` + documentColumns + ` FROM documents WHERE user_id = $1 AND file_hash = $2`
+
+	doc, err := scanDocument(r.db.QueryRowContext(ctx, query, userID, fileHash))
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("document %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// GetLatestByFilename retrieves the user's most recently uploaded document with the given
+// filename, such as an earlier version of a file being indexed again
+func (r *DocumentRepository) GetLatestByFilename(ctx context.Context, userID, filename string) (*model.Document, error) {
 	query := `
-		SELECT id, user_id, filename, file_type, file_size, file_hash, storage_path, total_chunks, upload_date
-		FROM documents WHERE id = $1
+		SELECT ` + documentColumns + `
+		FROM documents
+		WHERE user_id = $1 AND filename = $2
+		ORDER BY upload_date DESC
+		LIMIT 1
 	`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&doc.ID, &doc.UserID, &doc.Filename, &doc.FileType, &doc.FileSize,
-		&doc.FileHash, &doc.StoragePath, &doc.TotalChunks, &doc.UploadDate,
-	)
+	doc, err := scanDocument(r.db.QueryRowContext(ctx, query, userID, filename))
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("document not found")
+		return nil, fmt.Errorf("document %w", apperror.ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get document: %w", err)
 	}
 
-	return &doc, nil
+	return doc, nil
 }
 
 // ListByUserID lists all documents for a user
 func (r *DocumentRepository) ListByUserID(ctx context.Context, userID string) ([]*model.Document, error) {
 	query := `
-		SELECT id, user_id, filename, file_type, file_size, file_hash, storage_path, total_chunks, upload_date
+		SELECT ` + documentColumns + `
 		FROM documents
 		WHERE user_id = $1
 		ORDER BY upload_date DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	return r.listDocuments(ctx, query, userID)
+}
+
+// ListByIDs retrieves the user's documents with the given IDs (in no particular order)
+func (r *DocumentRepository) ListByIDs(ctx context.Context, userID string, ids []string) ([]*model.Document, error) {
+	query := `
+		SELECT ` + documentColumns + `
+		FROM documents
+		WHERE user_id = $1 AND id::text = ANY($2)
+	`
+
+	return r.listDocuments(ctx, query, userID, pq.Array(ids))
+}
+
+// ListOwnerIDs lists the IDs of users who have at least one document
+func (r *DocumentRepository) ListOwnerIDs(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT user_id FROM documents ORDER BY user_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document owners: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// CountByUserID counts a user's documents
+func (r *DocumentRepository) CountByUserID(ctx context.Context, userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM documents WHERE user_id = $1`
+
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListPinned lists a user's pinned documents
+func (r *DocumentRepository) ListPinned(ctx context.Context, userID string) ([]*model.Document, error) {
+	query := `
+		SELECT ` + documentColumns + `
+		FROM documents
+		WHERE user_id = $1 AND pinned
+		ORDER BY upload_date DESC
+	`
+
+	return r.listDocuments(ctx, query, userID)
+}
+
+// ListFavorites lists a user's favorite documents
+func (r *DocumentRepository) ListFavorites(ctx context.Context, userID string) ([]*model.Document, error) {
+	query := `
+		SELECT ` + documentColumns + `
+		FROM documents
+		WHERE user_id = $1 AND favorite
+		ORDER BY upload_date DESC
+	`
+
+	return r.listDocuments(ctx, query, userID)
+}
+
+// ListUploadedBetween lists all users' documents uploaded in [from, to), grouped by user
+func (r *DocumentRepository) ListUploadedBetween(ctx context.Context, from, to time.Time) ([]*model.Document, error) {
+	query := `
+		SELECT ` + documentColumns + `
+		FROM documents
+		WHERE upload_date >= $1 AND upload_date < $2
+		ORDER BY user_id, upload_date
+	`
+
+	return r.listDocuments(ctx, query, from, to)
+}
+
+// ListUnsummarized lists documents, oldest first, that have not been summarized yet
+func (r *DocumentRepository) ListUnsummarized(ctx context.Context, limit int) ([]*model.Document, error) {
+	query := `
+		SELECT ` + documentColumns + `
+		FROM documents
+		WHERE summarized_at IS NULL
+		ORDER BY upload_date
+		LIMIT $1
+	`
+
+	return r.listDocuments(ctx, query, limit)
+}
+
+// SetSummary stores a document's summary and marks it summarized
+func (r *DocumentRepository) SetSummary(ctx context.Context, id, summary string) error {
+	query := `UPDATE documents SET summary = $2, summarized_at = NOW() WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, summary); err != nil {
+		return fmt.Errorf("failed to save document summary: %w", err)
+	}
+
+	return nil
+}
+
+// ListPinnedIDs returns the IDs of a user's pinned documents
+func (r *DocumentRepository) ListPinnedIDs(ctx context.Context, userID string) (map[string]bool, error) {
+	return r.listIDs(ctx, `SELECT id FROM documents WHERE user_id = $1 AND pinned`, userID)
+}
+
+// ListCanaryIDs returns the IDs of a user's canary documents
+func (r *DocumentRepository) ListCanaryIDs(ctx context.Context, userID string) (map[string]bool, error) {
+	return r.listIDs(ctx, `SELECT id FROM documents WHERE user_id = $1 AND canary`, userID)
+}
+
+// listIDs runs a query selecting document IDs and returns them as a set
+func (r *DocumentRepository) listIDs(ctx context.Context, query string, args ...interface{}) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document IDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan document ID: %w", err)
+		}
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
+
+// SetPinned sets the pinned flag on a user's document
+func (r *DocumentRepository) SetPinned(ctx context.Context, userID, id string, pinned bool) error {
+	return r.setFlag(ctx, `UPDATE documents SET pinned = $1 WHERE id = $2 AND user_id = $3`, pinned, id, userID, "document")
+}
+
+// SetFavorite sets the favorite flag on a user's document
+func (r *DocumentRepository) SetFavorite(ctx context.Context, userID, id string, favorite bool) error {
+	return r.setFlag(ctx, `UPDATE documents SET favorite = $1 WHERE id = $2 AND user_id = $3`, favorite, id, userID, "document")
+}
+
+// SetCanary sets the canary flag on a user's document
+func (r *DocumentRepository) SetCanary(ctx context.Context, userID, id string, canary bool) error {
+	return r.setFlag(ctx, `UPDATE documents SET canary = $1 WHERE id = $2 AND user_id = $3`, canary, id, userID, "document")
+}
+
+// SetQueryHistoryPinned sets the pinned flag on a user's query history entry
+func (r *DocumentRepository) SetQueryHistoryPinned(ctx context.Context, userID, id string, pinned bool) error {
+	return r.setFlag(ctx, `UPDATE query_history SET pinned = $1 WHERE id = $2 AND user_id = $3`, pinned, id, userID, "query history entry")
+}
+
+// SetQueryHistoryFavorite sets the favorite flag on a user's query history entry
+func (r *DocumentRepository) SetQueryHistoryFavorite(ctx context.Context, userID, id string, favorite bool) error {
+	return r.setFlag(ctx, `UPDATE query_history SET favorite = $1 WHERE id = $2 AND user_id = $3`, favorite, id, userID, "query history entry")
+}
+
+// SetQueryHistoryFeedback records the user's rating of an answer: 1 helpful, -1 not helpful, 0
+// to clear it
+func (r *DocumentRepository) SetQueryHistoryFeedback(ctx context.Context, userID, id string, feedback int) error {
+	query := `UPDATE query_history SET feedback = NULLIF($1, 0) WHERE id = $2 AND user_id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, feedback, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update feedback: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("query history entry %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+// setFlag runs a single-row flag update, reporting the subject not found when no row matched
+func (r *DocumentRepository) setFlag(ctx context.Context, query string, value bool, id, userID, subject string) error {
+	result, err := r.db.ExecContext(ctx, query, value, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update flag: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s %w", subject, apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *DocumentRepository) listDocuments(ctx context.Context, query string, args ...interface{}) ([]*model.Document, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list documents: %w", err)
 	}
@@ -79,15 +346,11 @@ func (r *DocumentRepository) ListByUserID(ctx context.Context, userID string) ([
 
 	var documents []*model.Document
 	for rows.Next() {
-		var doc model.Document
-		err := rows.Scan(
-			&doc.ID, &doc.UserID, &doc.Filename, &doc.FileType, &doc.FileSize,
-			&doc.FileHash, &doc.StoragePath, &doc.TotalChunks, &doc.UploadDate,
-		)
+		doc, err := scanDocument(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan document: %w", err)
 		}
-		documents = append(documents, &doc)
+		documents = append(documents, doc)
 	}
 
 	return documents, nil
@@ -108,24 +371,361 @@ func (r *DocumentRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("document not found")
+		return fmt.Errorf("document %w", apperror.ErrNotFound)
 	}
 
 	return nil
 }
 
-// SaveQueryHistory saves a query to history
-func (r *DocumentRepository) SaveQueryHistory(ctx context.Context, userID, question, answer string, sources map[string]interface{}) error {
+// SaveQueryHistory saves a query to history, optionally attached to a conversation after the
+// parent message, and returns its ID
+func (r *DocumentRepository) SaveQueryHistory(ctx context.Context, userID, conversationID, parentID, question, answer string, sources map[string]interface{}, usage model.TokenUsage, quality model.AnswerQuality, run model.QueryRun) (string, error) {
+	sourcesJSON, err := json.Marshal(sources)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sources: %w", err)
+	}
+
+	query := `
+		INSERT INTO query_history (user_id, conversation_id, question, answer, sources, embedding_tokens, prompt_tokens, completion_tokens, cost_usd, confidence, verified,
+			prompt_version_id, latency_ms, parent_id)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, '')::uuid, NULLIF($13, 0), NULLIF($14, '')::uuid)
+		RETURNING id
+	`
+
+	var id string
+	err = r.db.QueryRowContext(ctx, query, userID, conversationID, question, answer, sourcesJSON,
+		usage.EmbeddingTokens, usage.PromptTokens, usage.CompletionTokens, usage.CostUSD, quality.Confidence, quality.Verified,
+		run.PromptVersionID, run.Latency.Milliseconds(), parentID).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to save query history: %w", err)
+	}
+
+	return id, nil
+}
+
+// StartQueryHistory records a streamed answer before it is generated, with its sources and no
+// answer, marked truncated until FinishQueryHistory completes it, and returns its ID
+func (r *DocumentRepository) StartQueryHistory(ctx context.Context, userID, question string, sources map[string]interface{}) (string, error) {
 	sourcesJSON, err := json.Marshal(sources)
 	if err != nil {
-		return fmt.Errorf("failed to marshal sources: %w", err)
+		return "", fmt.Errorf("failed to marshal sources: %w", err)
+	}
+
+	query := `
+		INSERT INTO query_history (user_id, question, answer, sources, truncated)
+		VALUES ($1, $2, '', $3, TRUE)
+		RETURNING id
+	`
+
+	var id string
+	if err := r.db.QueryRowContext(ctx, query, userID, question, sourcesJSON).Scan(&id); err != nil {
+		return "", fmt.Errorf("failed to save query history: %w", err)
+	}
+
+	return id, nil
+}
+
+// FinishQueryHistory saves the answer generated for a streamed query history entry, with whether
+// it stopped before completing
+func (r *DocumentRepository) FinishQueryHistory(ctx context.Context, id, answer string, usage model.TokenUsage, truncated bool) error {
+	query := `
+		UPDATE query_history
+		SET answer = $1, embedding_tokens = $2, prompt_tokens = $3, completion_tokens = $4, cost_usd = $5, truncated = $6
+		WHERE id = $7
+	`
+
+	_, err := r.db.ExecContext(ctx, query, answer, usage.EmbeddingTokens, usage.PromptTokens, usage.CompletionTokens,
+		usage.CostUSD, truncated, id)
+	if err != nil {
+		return fmt.Errorf("failed to update query history: %w", err)
+	}
+
+	return nil
+}
+
+// MarkQueryHistoryRead records that the client received a user's answer; an answer already
+// marked keeps its first read time
+func (r *DocumentRepository) MarkQueryHistoryRead(ctx context.Context, userID, id string) error {
+	query := `UPDATE query_history SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark query history read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("query history entry %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ImportQueryHistory stores a query history entry from an account export, keeping its flags
+// and timestamps, after the parent message of the conversation, and returns its ID
+func (r *DocumentRepository) ImportQueryHistory(ctx context.Context, userID, conversationID, parentID string, entry *model.QueryHistory) (string, error) {
+	sourcesJSON, err := json.Marshal(entry.Sources)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sources: %w", err)
+	}
+
+	query := `
+		INSERT INTO query_history (user_id, conversation_id, parent_id, question, answer, sources, pinned, favorite, feedback,
+			truncated, read_at, created_at)
+		VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, NULLIF($9, 0), $10, $11, $12)
+		RETURNING id
+	`
+
+	var id string
+	err = r.db.QueryRowContext(ctx, query, userID, conversationID, parentID, entry.Question, entry.Answer, sourcesJSON,
+		entry.Pinned, entry.Favorite, entry.Feedback, entry.Truncated, entry.ReadAt, entry.CreatedAt).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to import query history: %w", err)
+	}
+
+	return id, nil
+}
+
+// ListAllQueryHistory lists every query history entry of a user, in and out of conversations,
+// oldest first
+func (r *DocumentRepository) ListAllQueryHistory(ctx context.Context, userID string) ([]*model.QueryHistory, error) {
+	query := `
+		SELECT ` + queryHistoryColumns + `
+		FROM query_history
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list query history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []*model.QueryHistory{}
+	for rows.Next() {
+		entry, err := scanQueryHistory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan query history: %w", err)
+		}
+		history = append(history, entry)
 	}
 
-	query := `INSERT INTO query_history (user_id, question, answer, sources) VALUES ($1, $2, $3, $4)`
+	return history, rows.Err()
+}
 
-	_, err = r.db.ExecContext(ctx, query, userID, question, answer, sourcesJSON)
+// RestoreDocument sets the upload date, flags and summary of a document imported from an
+// account export
+func (r *DocumentRepository) RestoreDocument(ctx context.Context, doc *model.Document) error {
+	query := `UPDATE documents SET upload_date = $1, pinned = $2, favorite = $3, summary = $4 WHERE id = $5`
+
+	if _, err := r.db.ExecContext(ctx, query, doc.UploadDate, doc.Pinned, doc.Favorite, doc.Summary, doc.ID); err != nil {
+		return fmt.Errorf("failed to restore document: %w", err)
+	}
+
+	return nil
+}
+
+// RecordEmbeddingUsage stores the embedding tokens spent on a document
+func (r *DocumentRepository) RecordEmbeddingUsage(ctx context.Context, usage *model.EmbeddingUsage) error {
+	query := `
+		INSERT INTO embedding_usage (user_id, document_id, filename, source, folder, model, tokens, cost_usd)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, usage.UserID, usage.DocumentID, usage.Filename, usage.Source,
+		usage.Folder, usage.Model, usage.Tokens, usage.CostUSD)
 	if err != nil {
-		return fmt.Errorf("failed to save query history: %w", err)
+		return fmt.Errorf("failed to record embedding usage: %w", err)
+	}
+
+	return nil
+}
+
+// queryHistoryColumns is the column list matching scanQueryHistory
+const queryHistoryColumns = `id, user_id, COALESCE(conversation_id::text, ''), question, COALESCE(answer, ''), sources, pinned, favorite,
+		embedding_tokens, prompt_tokens, completion_tokens, cost_usd, COALESCE(feedback, 0), created_at, COALESCE(parent_id::text, ''),
+		truncated, read_at`
+
+// scanQueryHistory scans a row selected with queryHistoryColumns
+func scanQueryHistory(row rowScanner) (*model.QueryHistory, error) {
+	var entry model.QueryHistory
+	var sourcesJSON []byte
+
+	err := row.Scan(
+		&entry.ID, &entry.UserID, &entry.ConversationID, &entry.Question, &entry.Answer, &sourcesJSON,
+		&entry.Pinned, &entry.Favorite, &entry.Usage.EmbeddingTokens, &entry.Usage.PromptTokens,
+		&entry.Usage.CompletionTokens, &entry.Usage.CostUSD, &entry.Feedback, &entry.CreatedAt, &entry.ParentID,
+		&entry.Truncated, &entry.ReadAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sourcesJSON) > 0 {
+		if err := json.Unmarshal(sourcesJSON, &entry.Sources); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sources: %w", err)
+		}
+	}
+
+	return &entry, nil
+}
+
+// QueryHistoryFilter holds the filters for listing query history
+type QueryHistoryFilter struct {
+	Search string
+	From   *time.Time
+	To     *time.Time
+	// Pinned and Favorite restrict results to flagged entries
+	Pinned   bool
+	Favorite bool
+	// Unread restricts results to answers the client hasn't confirmed receiving
+	Unread bool
+	Limit  int
+	Offset int
+}
+
+// likeEscaper escapes LIKE wildcards, so searches match % and _ literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListQueryHistory lists a user's query history matching the filter, newest first.
+// It also returns the total number of matching rows for pagination.
+func (r *DocumentRepository) ListQueryHistory(ctx context.Context, userID string, filter QueryHistoryFilter) ([]*model.QueryHistory, int, error) {
+	conditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+
+	if filter.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(filter.Search)+"%")
+		conditions = append(conditions, fmt.Sprintf(`(question ILIKE $%d ESCAPE '\' OR answer ILIKE $%d ESCAPE '\')`, len(args), len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	if filter.Pinned {
+		conditions = append(conditions, "pinned")
+	}
+	if filter.Favorite {
+		conditions = append(conditions, "favorite")
+	}
+	if filter.Unread {
+		conditions = append(conditions, "read_at IS NULL")
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM query_history WHERE ` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count query history: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT `+queryHistoryColumns+`
+		FROM query_history
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list query history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []*model.QueryHistory{}
+	for rows.Next() {
+		entry, err := scanQueryHistory(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan query history: %w", err)
+		}
+		history = append(history, entry)
+	}
+
+	return history, total, rows.Err()
+}
+
+// GetQueryHistory retrieves a single query history entry owned by the user
+func (r *DocumentRepository) GetQueryHistory(ctx context.Context, userID, id string) (*model.QueryHistory, error) {
+	query := `SELECT ` + queryHistoryColumns + ` FROM query_history WHERE id = $1 AND user_id = $2`
+
+	entry, err := scanQueryHistory(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("query history entry %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query history: %w", err)
+	}
+
+	return entry, nil
+}
+
+// ListQueryHistoryByIDs retrieves the user's query history entries with the given IDs (in no
+// particular order)
+func (r *DocumentRepository) ListQueryHistoryByIDs(ctx context.Context, userID string, ids []string) ([]*model.QueryHistory, error) {
+	query := `
+		SELECT ` + queryHistoryColumns + `
+		FROM query_history
+		WHERE user_id = $1 AND id::text = ANY($2)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list query history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []*model.QueryHistory{}
+	for rows.Next() {
+		entry, err := scanQueryHistory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan query history: %w", err)
+		}
+		history = append(history, entry)
+	}
+
+	return history, rows.Err()
+}
+
+// DeleteQueryHistory deletes a single query history entry owned by the user. Conversation
+// messages that followed it follow its parent instead, and a conversation showing the branch
+// ending at it shows the branch ending at its parent.
+func (r *DocumentRepository) DeleteQueryHistory(ctx context.Context, userID, id string) error {
+	query := `
+		WITH deleted AS (
+			SELECT id, parent_id FROM query_history WHERE id = $1 AND user_id = $2
+		), reparented AS (
+			UPDATE query_history SET parent_id = (SELECT parent_id FROM deleted)
+			WHERE parent_id = (SELECT id FROM deleted)
+		), reactivated AS (
+			UPDATE conversations SET active_message_id = (SELECT parent_id FROM deleted)
+			WHERE active_message_id = (SELECT id FROM deleted)
+		)
+		DELETE FROM query_history WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete query history: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("query history entry %w", apperror.ErrNotFound)
 	}
 
 	return nil
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// EmbeddingBatchRepository handles embedding batch data operations
+type EmbeddingBatchRepository struct {
+	db *sql.DB
+}
+
+// NewEmbeddingBatchRepository creates a new embedding batch repository
+func NewEmbeddingBatchRepository(db *sql.DB) *EmbeddingBatchRepository {
+	return &EmbeddingBatchRepository{db: db}
+}
+
+// Create records a submitted embedding batch
+func (r *EmbeddingBatchRepository) Create(ctx context.Context, batch *model.EmbeddingBatch) error {
+	query := `
+		INSERT INTO embedding_batches (user_id, document_id, batch_id, input_file_id, model, source, folder)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, status, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		batch.UserID, batch.DocumentID, batch.BatchID, batch.InputFileID, batch.Model, batch.Source, batch.Folder,
+	).Scan(&batch.ID, &batch.Status, &batch.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create embedding batch: %w", err)
+	}
+
+	return nil
+}
+
+// ListPending lists the batches whose results haven't been written yet, oldest first
+func (r *EmbeddingBatchRepository) ListPending(ctx context.Context) ([]*model.EmbeddingBatch, error) {
+	query := `
+		SELECT id, user_id, document_id, batch_id, input_file_id, model, source, folder, status, last_error, created_at, completed_at
+		FROM embedding_batches
+		WHERE status = 'pending'
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list embedding batches: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []*model.EmbeddingBatch
+	for rows.Next() {
+		var batch model.EmbeddingBatch
+		var completedAt sql.NullTime
+		err := rows.Scan(
+			&batch.ID, &batch.UserID, &batch.DocumentID, &batch.BatchID, &batch.InputFileID, &batch.Model,
+			&batch.Source, &batch.Folder, &batch.Status, &batch.LastError, &batch.CreatedAt, &completedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan embedding batch: %w", err)
+		}
+		if completedAt.Valid {
+			batch.CompletedAt = &completedAt.Time
+		}
+		batches = append(batches, &batch)
+	}
+
+	return batches, rows.Err()
+}
+
+// Complete marks a batch as finished with status completed or failed; lastError explains why
+// some or all of its embeddings weren't taken from the batch
+func (r *EmbeddingBatchRepository) Complete(ctx context.Context, id, status, lastError string) error {
+	query := `
+		UPDATE embedding_batches
+		SET status = $2, last_error = $3, completed_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, status, lastError); err != nil {
+		return fmt.Errorf("failed to update embedding batch: %w", err)
+	}
+
+	return nil
+}
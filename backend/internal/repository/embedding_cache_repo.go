@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/lib/pq"
+)
+
+// EmbeddingCacheRepository stores embeddings by the SHA-256 of their text, so unchanged text is
+// never sent to the embedding API twice. Entries are shared by all users: equal text under the
+// same model always has the same embedding.
+type EmbeddingCacheRepository struct {
+	db *sql.DB
+}
+
+// NewEmbeddingCacheRepository creates a new embedding cache repository
+func NewEmbeddingCacheRepository(db *sql.DB) *EmbeddingCacheRepository {
+	return &EmbeddingCacheRepository{db: db}
+}
+
+// EmbeddingCacheKey identifies the model an embedding was generated with. Providers embed the
+// same text differently per input type (document or query) and output size.
+type EmbeddingCacheKey struct {
+	Model      string
+	Dimensions int
+	InputType  string
+}
+
+// GetMany returns the cached embeddings for the given text hashes, keyed by hash. Hashes
+// without a cached embedding are left out.
+func (r *EmbeddingCacheRepository) GetMany(ctx context.Context, key EmbeddingCacheKey, hashes []string) (map[string][]float32, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT content_hash, embedding FROM embedding_cache
+		WHERE model = $1 AND dimensions = $2 AND input_type = $3 AND content_hash = ANY($4)
+	`, key.Model, key.Dimensions, key.InputType, pq.Array(hashes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+	}
+	defer rows.Close()
+
+	embeddings := make(map[string][]float32, len(hashes))
+	for rows.Next() {
+		var hash string
+		var data []byte
+		if err := rows.Scan(&hash, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan cached embedding: %w", err)
+		}
+		embedding := decodeEmbedding(data)
+		if len(embedding) != key.Dimensions {
+			continue
+		}
+		embeddings[hash] = embedding
+	}
+
+	return embeddings, rows.Err()
+}
+
+// PutMany caches embeddings keyed by text hash in a single transaction, replacing any cached
+// before
+func (r *EmbeddingCacheRepository) PutMany(ctx context.Context, key EmbeddingCacheKey, embeddings map[string][]float32) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO embedding_cache (content_hash, model, dimensions, input_type, embedding)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (content_hash, model, dimensions, input_type) DO UPDATE SET embedding = EXCLUDED.embedding, created_at = NOW()
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare embedding cache insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for hash, embedding := range embeddings {
+		if _, err := stmt.ExecContext(ctx, hash, key.Model, key.Dimensions, key.InputType, encodeEmbedding(embedding)); err != nil {
+			return fmt.Errorf("failed to cache embedding: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit embedding cache: %w", err)
+	}
+
+	return nil
+}
+
+// encodeEmbedding packs an embedding as little-endian float32s
+func encodeEmbedding(embedding []float32) []byte {
+	data := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	return data
+}
+
+// decodeEmbedding unpacks an embedding written by encodeEmbedding
+func decodeEmbedding(data []byte) []float32 {
+	embedding := make([]float32, len(data)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return embedding
+}
@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/lib/pq"
+)
+
+// FAQRepository handles the clustering of past questions into personal FAQ entries
+type FAQRepository struct {
+	db *sql.DB
+}
+
+// NewFAQRepository creates a new FAQ repository
+func NewFAQRepository(db *sql.DB) *FAQRepository {
+	return &FAQRepository{db: db}
+}
+
+// FAQQuestion is a query history entry waiting to be clustered
+type FAQQuestion struct {
+	HistoryID string
+	UserID    string
+	Question  string
+	AskedAt   time.Time
+	// FollowUp is set for questions after the first in a conversation, which depend on it
+	FollowUp bool
+}
+
+// FAQCluster is an FAQ entry's centroid, used to assign new questions
+type FAQCluster struct {
+	ID        string
+	Embedding []float32
+	AskCount  int
+}
+
+// ListUnprocessed returns the oldest query history entries not yet clustered
+func (r *FAQRepository) ListUnprocessed(ctx context.Context, limit int) ([]*FAQQuestion, error) {
+	query := `
+		SELECT h.id, h.user_id, h.question, h.created_at,
+			h.conversation_id IS NOT NULL AND EXISTS (
+				SELECT 1 FROM query_history p
+				WHERE p.conversation_id = h.conversation_id AND p.created_at < h.created_at
+			)
+		FROM query_history h
+		WHERE h.faq_processed_at IS NULL
+		ORDER BY h.created_at
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unprocessed questions: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []*FAQQuestion
+	for rows.Next() {
+		var q FAQQuestion
+		if err := rows.Scan(&q.HistoryID, &q.UserID, &q.Question, &q.AskedAt, &q.FollowUp); err != nil {
+			return nil, fmt.Errorf("failed to scan question: %w", err)
+		}
+		questions = append(questions, &q)
+	}
+
+	return questions, rows.Err()
+}
+
+// ListClusters returns the centroids of the user's FAQ entries
+func (r *FAQRepository) ListClusters(ctx context.Context, userID string) ([]*FAQCluster, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, embedding, ask_count FROM faq_entries WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list FAQ clusters: %w", err)
+	}
+	defer rows.Close()
+
+	var clusters []*FAQCluster
+	for rows.Next() {
+		var c FAQCluster
+		if err := rows.Scan(&c.ID, pq.Array(&c.Embedding), &c.AskCount); err != nil {
+			return nil, fmt.Errorf("failed to scan FAQ cluster: %w", err)
+		}
+		clusters = append(clusters, &c)
+	}
+
+	return clusters, rows.Err()
+}
+
+// CreateCluster starts an FAQ entry from a single question
+func (r *FAQRepository) CreateCluster(ctx context.Context, userID string, question *FAQQuestion, embedding []float32) (*FAQCluster, error) {
+	cluster := &FAQCluster{Embedding: embedding, AskCount: 1}
+	query := `
+		INSERT INTO faq_entries (user_id, question, embedding, last_asked_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	err := r.db.QueryRowContext(ctx, query, userID, question.Question, pq.Array(embedding), question.AskedAt).Scan(&cluster.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create FAQ entry: %w", err)
+	}
+
+	return cluster, nil
+}
+
+// UpdateCluster stores an FAQ entry's new centroid and ask count after a question joined it
+func (r *FAQRepository) UpdateCluster(ctx context.Context, cluster *FAQCluster, askedAt time.Time) error {
+	query := `
+		UPDATE faq_entries
+		SET embedding = $2, ask_count = $3, last_asked_at = GREATEST(last_asked_at, $4), updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, cluster.ID, pq.Array(cluster.Embedding), cluster.AskCount, askedAt); err != nil {
+		return fmt.Errorf("failed to update FAQ entry: %w", err)
+	}
+
+	return nil
+}
+
+// MarkProcessed records that a question was clustered, into clusterID or (when empty) none
+func (r *FAQRepository) MarkProcessed(ctx context.Context, historyID, clusterID string) error {
+	query := `UPDATE query_history SET faq_entry_id = NULLIF($2, '')::uuid, faq_processed_at = NOW() WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, historyID, clusterID); err != nil {
+		return fmt.Errorf("failed to mark question processed: %w", err)
+	}
+
+	return nil
+}
+
+// RefreshBestAnswer points an FAQ entry at its best answer: pinned or favorited first, then
+// verified, then the most confident and most recent. The entry takes that answer's question.
+func (r *FAQRepository) RefreshBestAnswer(ctx context.Context, clusterID string) error {
+	query := `
+		UPDATE faq_entries f
+		SET best_history_id = best.id, question = best.question, updated_at = NOW()
+		FROM (
+			SELECT id, question FROM query_history
+			WHERE faq_entry_id = $1 AND COALESCE(answer, '') <> ''
+			ORDER BY (pinned OR favorite) DESC,
+				CASE verified WHEN TRUE THEN 1 WHEN FALSE THEN -1 ELSE 0 END DESC,
+				confidence DESC NULLS LAST,
+				created_at DESC
+			LIMIT 1
+		) best
+		WHERE f.id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, clusterID); err != nil {
+		return fmt.Errorf("failed to refresh FAQ answer: %w", err)
+	}
+
+	return nil
+}
+
+// ResetStale deletes the user's FAQ entries whose embeddings don't have the given size (after the
+// embedding model changed) and queues their questions to be clustered again
+func (r *FAQRepository) ResetStale(ctx context.Context, userID string, dimensions int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE query_history SET faq_processed_at = NULL
+		WHERE faq_entry_id IN (SELECT id FROM faq_entries WHERE user_id = $1 AND cardinality(embedding) <> $2)
+	`, userID, dimensions); err != nil {
+		return fmt.Errorf("failed to requeue FAQ questions: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM faq_entries WHERE user_id = $1 AND cardinality(embedding) <> $2`, userID, dimensions); err != nil {
+		return fmt.Errorf("failed to delete stale FAQ entries: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// faqEntrySelect joins FAQ entries with their best answer
+const faqEntrySelect = `
+	SELECT f.id, f.question, h.answer, h.sources, f.ask_count, h.id,
+		h.pinned OR h.favorite OR COALESCE(h.verified, FALSE), h.confidence, h.created_at, f.last_asked_at
+	FROM faq_entries f
+	JOIN query_history h ON h.id = f.best_history_id
+`
+
+func scanFAQEntry(row rowScanner) (*model.FAQEntry, error) {
+	var e model.FAQEntry
+	var answer sql.NullString
+	var sourcesJSON []byte
+	var confidence sql.NullFloat64
+	err := row.Scan(&e.ID, &e.Question, &answer, &sourcesJSON, &e.AskCount, &e.HistoryID,
+		&e.Endorsed, &confidence, &e.AnsweredAt, &e.LastAskedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Answer = answer.String
+	if confidence.Valid {
+		e.Confidence = &confidence.Float64
+	}
+	// History stores sources as {"sources": [...]}
+	var sources struct {
+		Sources interface{} `json:"sources"`
+	}
+	if len(sourcesJSON) > 0 {
+		if err := json.Unmarshal(sourcesJSON, &sources); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sources: %w", err)
+		}
+	}
+	e.Sources = sources.Sources
+	if e.Sources == nil {
+		e.Sources = []interface{}{}
+	}
+	return &e, nil
+}
+
+// List returns a page of the user's FAQ: entries asked at least minAsks times, most asked first
+func (r *FAQRepository) List(ctx context.Context, userID string, minAsks, limit, offset int) ([]*model.FAQEntry, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM faq_entries WHERE user_id = $1 AND ask_count >= $2 AND best_history_id IS NOT NULL`
+	if err := r.db.QueryRowContext(ctx, countQuery, userID, minAsks).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count FAQ entries: %w", err)
+	}
+
+	query := faqEntrySelect + `
+		WHERE f.user_id = $1 AND f.ask_count >= $2
+		ORDER BY f.ask_count DESC, f.last_asked_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, minAsks, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list FAQ entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*model.FAQEntry{}
+	for rows.Next() {
+		entry, err := scanFAQEntry(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan FAQ entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
+
+// ListAnswerable returns the user's FAQ entries that may answer repeat questions (asked at least
+// minAsks times, with an endorsed answer given since answeredSince) with their centroids
+func (r *FAQRepository) ListAnswerable(ctx context.Context, userID string, minAsks int, answeredSince time.Time) ([]*model.FAQEntry, [][]float32, error) {
+	query := `
+		SELECT f.id, f.question, h.answer, h.sources, f.ask_count, h.id,
+			TRUE, h.confidence, h.created_at, f.last_asked_at, f.embedding
+		FROM faq_entries f
+		JOIN query_history h ON h.id = f.best_history_id
+		WHERE f.user_id = $1 AND f.ask_count >= $2 AND h.created_at >= $3
+			AND (h.pinned OR h.favorite OR COALESCE(h.verified, FALSE))
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, minAsks, answeredSince)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list answerable FAQ entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*model.FAQEntry
+	var embeddings [][]float32
+	for rows.Next() {
+		var embedding []float32
+		entry, err := scanFAQEntry(embeddingScanner{rows, &embedding})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan FAQ entry: %w", err)
+		}
+		entries = append(entries, entry)
+		embeddings = append(embeddings, embedding)
+	}
+
+	return entries, embeddings, rows.Err()
+}
+
+// embeddingScanner scans an extra trailing embedding column after the columns scanFAQEntry reads
+type embeddingScanner struct {
+	row       rowScanner
+	embedding *[]float32
+}
+
+func (s embeddingScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, pq.Array(s.embedding))...)
+}
+
+// Delete removes an FAQ entry; its questions stay in history
+func (r *FAQRepository) Delete(ctx context.Context, userID, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM faq_entries WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete FAQ entry: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("FAQ entry %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/lib/pq"
+)
+
+// FlashcardRepository handles flashcard and review data operations.
+// Times are stored in UTC.
+type FlashcardRepository struct {
+	db *sql.DB
+}
+
+// NewFlashcardRepository creates a new flashcard repository
+func NewFlashcardRepository(db *sql.DB) *FlashcardRepository {
+	return &FlashcardRepository{db: db}
+}
+
+const flashcardColumns = `id, user_id, document_id, question, answer, source, ease_factor, interval_days, repetitions, lapses,
+	due_at, last_reviewed_at, created_at, updated_at`
+
+// Create creates new flashcards in a single transaction
+func (r *FlashcardRepository) Create(ctx context.Context, cards []*model.Flashcard) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, f := range cards {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO flashcards (user_id, document_id, question, answer, source, ease_factor, due_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at, updated_at
+		`, f.UserID, f.DocumentID, f.Question, f.Answer, f.Source, f.EaseFactor, f.DueAt.UTC()).
+			Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create flashcard: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit flashcards: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a flashcard owned by the user
+func (r *FlashcardRepository) GetByID(ctx context.Context, userID, id string) (*model.Flashcard, error) {
+	query := `SELECT ` + flashcardColumns + ` FROM flashcards WHERE id = $1 AND user_id = $2`
+
+	f, err := scanFlashcard(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("flashcard %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flashcard: %w", err)
+	}
+
+	return f, nil
+}
+
+// ListByUserID lists a user's flashcards, newest first, optionally only those from a document
+func (r *FlashcardRepository) ListByUserID(ctx context.Context, userID, documentID string) ([]*model.Flashcard, error) {
+	query := `SELECT ` + flashcardColumns + ` FROM flashcards
+		WHERE user_id = $1 AND ($2 = '' OR document_id::text = $2)
+		ORDER BY created_at DESC`
+
+	return r.list(ctx, query, userID, documentID)
+}
+
+// ListByIDs retrieves the user's flashcards with the given IDs, oldest first
+func (r *FlashcardRepository) ListByIDs(ctx context.Context, userID string, ids []string) ([]*model.Flashcard, error) {
+	query := `SELECT ` + flashcardColumns + ` FROM flashcards
+		WHERE user_id = $1 AND id::text = ANY($2)
+		ORDER BY created_at`
+
+	return r.list(ctx, query, userID, pq.Array(ids))
+}
+
+// ListDue lists the flashcards due for review at now, most overdue first
+func (r *FlashcardRepository) ListDue(ctx context.Context, userID string, now time.Time, limit int) ([]*model.Flashcard, error) {
+	query := `SELECT ` + flashcardColumns + ` FROM flashcards
+		WHERE user_id = $1 AND due_at <= $2
+		ORDER BY due_at
+		LIMIT $3`
+
+	return r.list(ctx, query, userID, now.UTC(), limit)
+}
+
+func (r *FlashcardRepository) list(ctx context.Context, query string, args ...interface{}) ([]*model.Flashcard, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flashcards: %w", err)
+	}
+	defer rows.Close()
+
+	cards := []*model.Flashcard{}
+	for rows.Next() {
+		f, err := scanFlashcard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan flashcard: %w", err)
+		}
+		cards = append(cards, f)
+	}
+
+	return cards, rows.Err()
+}
+
+// SaveReview stores a flashcard's new schedule and records the review
+func (r *FlashcardRepository) SaveReview(ctx context.Context, f *model.Flashcard, grade int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE flashcards
+		SET ease_factor = $1, interval_days = $2, repetitions = $3, lapses = $4, due_at = $5, last_reviewed_at = $6,
+			updated_at = NOW()
+		WHERE id = $7 AND user_id = $8
+		RETURNING updated_at
+	`, f.EaseFactor, f.IntervalDays, f.Repetitions, f.Lapses, f.DueAt.UTC(), f.LastReviewedAt.UTC(), f.ID, f.UserID).
+		Scan(&f.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("flashcard %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update flashcard: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO flashcard_reviews (flashcard_id, user_id, grade, interval_days, reviewed_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, f.ID, f.UserID, grade, f.IntervalDays, f.LastReviewedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to record review: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit review: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a flashcard owned by the user
+func (r *FlashcardRepository) Delete(ctx context.Context, userID, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM flashcards WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete flashcard: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("flashcard %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+// CountCards fills in the card counts of stats: total, due at now, new, learning and mature
+// (an interval of at least matureDays)
+func (r *FlashcardRepository) CountCards(ctx context.Context, userID string, now time.Time, matureDays int, stats *model.FlashcardStats) error {
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE due_at <= $2),
+			COUNT(*) FILTER (WHERE last_reviewed_at IS NULL),
+			COUNT(*) FILTER (WHERE last_reviewed_at IS NOT NULL AND interval_days < $3),
+			COUNT(*) FILTER (WHERE interval_days >= $3)
+		FROM flashcards
+		WHERE user_id = $1
+	`, userID, now.UTC(), matureDays).Scan(&stats.Total, &stats.Due, &stats.New, &stats.Learning, &stats.Mature)
+	if err != nil {
+		return fmt.Errorf("failed to count flashcards: %w", err)
+	}
+
+	return nil
+}
+
+// CountReviews returns the number of reviews since the given time and how many of them passed (graded 3 or higher)
+func (r *FlashcardRepository) CountReviews(ctx context.Context, userID string, since time.Time) (int, int, error) {
+	var total, passed int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE grade >= 3)
+		FROM flashcard_reviews
+		WHERE user_id = $1 AND reviewed_at >= $2
+	`, userID, since.UTC()).Scan(&total, &passed)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count reviews: %w", err)
+	}
+
+	return total, passed, nil
+}
+
+// ReviewDays returns the distinct days with at least one review, most recent first. Days are
+// calendar dates in the named timezone, returned as midnight UTC.
+func (r *FlashcardRepository) ReviewDays(ctx context.Context, userID, timezone string) ([]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT (reviewed_at AT TIME ZONE 'UTC' AT TIME ZONE $2)::date AS day
+		FROM flashcard_reviews
+		WHERE user_id = $1
+		ORDER BY day DESC
+	`, userID, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list review days: %w", err)
+	}
+	defer rows.Close()
+
+	var days []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, fmt.Errorf("failed to scan review day: %w", err)
+		}
+		days = append(days, day)
+	}
+
+	return days, rows.Err()
+}
+
+func scanFlashcard(row rowScanner) (*model.Flashcard, error) {
+	var f model.Flashcard
+	var documentID sql.NullString
+	var lastReviewedAt sql.NullTime
+
+	if err := row.Scan(&f.ID, &f.UserID, &documentID, &f.Question, &f.Answer, &f.Source, &f.EaseFactor, &f.IntervalDays,
+		&f.Repetitions, &f.Lapses, &f.DueAt, &lastReviewedAt, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if documentID.Valid {
+		f.DocumentID = &documentID.String
+	}
+	if lastReviewedAt.Valid {
+		f.LastReviewedAt = &lastReviewedAt.Time
+	}
+
+	return &f, nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/lib/pq"
+)
+
+// GlossaryRepository handles glossary term data operations
+type GlossaryRepository struct {
+	db *sql.DB
+}
+
+// NewGlossaryRepository creates a new glossary repository
+func NewGlossaryRepository(db *sql.DB) *GlossaryRepository {
+	return &GlossaryRepository{db: db}
+}
+
+// ErrDuplicateGlossaryTerm is returned when the user already has an entry for the term
+var ErrDuplicateGlossaryTerm = errors.New("glossary term already exists")
+
+const glossaryTermColumns = `id, user_id, term, expansion, created_at, updated_at`
+
+// scanGlossaryTerm scans a row selected with glossaryTermColumns
+func scanGlossaryTerm(row rowScanner) (*model.GlossaryTerm, error) {
+	var t model.GlossaryTerm
+	if err := row.Scan(&t.ID, &t.UserID, &t.Term, &t.Expansion, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// Create creates a new glossary term
+func (r *GlossaryRepository) Create(ctx context.Context, t *model.GlossaryTerm) error {
+	query := `
+		INSERT INTO glossary_terms (user_id, term, expansion)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, t.UserID, t.Term, t.Expansion).
+		Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicateGlossaryTerm
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create glossary term: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a glossary term owned by the user
+func (r *GlossaryRepository) GetByID(ctx context.Context, userID, id string) (*model.GlossaryTerm, error) {
+	query := `SELECT ` + glossaryTermColumns + ` FROM glossary_terms WHERE id = $1 AND user_id = $2`
+
+	t, err := scanGlossaryTerm(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("glossary term %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get glossary term: %w", err)
+	}
+
+	return t, nil
+}
+
+// ListByUserID lists a user's glossary terms alphabetically
+func (r *GlossaryRepository) ListByUserID(ctx context.Context, userID string) ([]*model.GlossaryTerm, error) {
+	query := `SELECT ` + glossaryTermColumns + ` FROM glossary_terms WHERE user_id = $1 ORDER BY LOWER(term)`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list glossary terms: %w", err)
+	}
+	defer rows.Close()
+
+	terms := []*model.GlossaryTerm{}
+	for rows.Next() {
+		t, err := scanGlossaryTerm(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan glossary term: %w", err)
+		}
+		terms = append(terms, t)
+	}
+
+	return terms, rows.Err()
+}
+
+// Update updates a glossary term's term and expansion
+func (r *GlossaryRepository) Update(ctx context.Context, t *model.GlossaryTerm) error {
+	query := `
+		UPDATE glossary_terms
+		SET term = $3, expansion = $4, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, t.ID, t.UserID, t.Term, t.Expansion).Scan(&t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("glossary term %w", apperror.ErrNotFound)
+	}
+	if isUniqueViolation(err) {
+		return ErrDuplicateGlossaryTerm
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update glossary term: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a glossary term
+func (r *GlossaryRepository) Delete(ctx context.Context, userID, id string) error {
+	query := `DELETE FROM glossary_terms WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete glossary term: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("glossary term %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+// SuggestTerms finds acronyms (2-10 capitals or digits, starting with a capital) that occur at
+// least minOccurrences times in the user's documents and have no glossary entry, most frequent first
+func (r *GlossaryRepository) SuggestTerms(ctx context.Context, userID string, minOccurrences, limit int) ([]*model.GlossarySuggestion, error) {
+	query := `
+		SELECT m[1] AS term, COUNT(*) AS occurrences, COUNT(DISTINCT c.document_id) AS documents
+		FROM document_chunks c, regexp_matches(c.content, '\m([A-Z][A-Z0-9]{1,9})\M', 'g') AS m
+		WHERE c.user_id = $1
+			AND NOT EXISTS (
+				SELECT 1 FROM glossary_terms g
+				WHERE g.user_id = $1 AND LOWER(g.term) = LOWER(m[1])
+			)
+		GROUP BY m[1]
+		HAVING COUNT(*) >= $2
+		ORDER BY occurrences DESC, term
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, minOccurrences, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest glossary terms: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []*model.GlossarySuggestion{}
+	for rows.Next() {
+		var s model.GlossarySuggestion
+		if err := rows.Scan(&s.Term, &s.Occurrences, &s.Documents); err != nil {
+			return nil, fmt.Errorf("failed to scan glossary suggestion: %w", err)
+		}
+		suggestions = append(suggestions, &s)
+	}
+
+	return suggestions, rows.Err()
+}
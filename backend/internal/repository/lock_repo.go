@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+)
+
+// LockRepository provides cluster-wide locks using Postgres advisory locks,
+// so work like scheduled runs and syncs happens on only one replica at a time
+type LockRepository struct {
+	db *sql.DB
+}
+
+// NewLockRepository creates a new lock repository
+func NewLockRepository(db *sql.DB) *LockRepository {
+	return &LockRepository{db: db}
+}
+
+// Lock is a held advisory lock; it is bound to a dedicated connection
+type Lock struct {
+	key  string
+	conn *sql.Conn
+}
+
+// TryAcquire takes the named lock without waiting.
+// It returns nil if another session holds the lock.
+func (r *LockRepository) TryAcquire(ctx context.Context, key string) (*Lock, error) {
+	// Advisory locks belong to the session, so pin a connection until release
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock connection: %w", err)
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, key).Scan(&acquired)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	if !acquired {
+		conn.Close()
+		return nil, nil
+	}
+
+	return &Lock{key: key, conn: conn}, nil
+}
+
+// Release unlocks and returns the connection to the pool
+func (l *Lock) Release() {
+	// Use a fresh context so a cancelled caller still unlocks
+	if _, err := l.conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, l.key); err != nil {
+		// Discard the connection so ending its session drops the lock
+		logger.Error("Failed to release lock", "key", l.key, "error", err)
+		l.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	l.conn.Close()
+}
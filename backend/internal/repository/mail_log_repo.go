@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// MailLogRepository stores the outbound email send log
+type MailLogRepository struct {
+	db *sql.DB
+}
+
+// NewMailLogRepository creates a new mail log repository
+func NewMailLogRepository(db *sql.DB) *MailLogRepository {
+	return &MailLogRepository{db: db}
+}
+
+// Record stores a sent or failed email
+func (r *MailLogRepository) Record(ctx context.Context, entry *model.MailLogEntry) error {
+	query := `
+		INSERT INTO mail_log (user_id, recipient, template, subject, driver, status, error)
+		VALUES (NULLIF($1, '')::uuid, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, entry.UserID, entry.Recipient, entry.Template, entry.Subject,
+		entry.Driver, entry.Status, entry.Error).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record email: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the most recent emails, newest first, optionally only those with the status
+func (r *MailLogRepository) List(ctx context.Context, status string, limit int) ([]*model.MailLogEntry, error) {
+	query := `
+		SELECT id, COALESCE(user_id::text, ''), recipient, template, subject, driver, status, error, created_at
+		FROM mail_log
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mail log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*model.MailLogEntry{}
+	for rows.Next() {
+		var e model.MailLogEntry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Recipient, &e.Template, &e.Subject, &e.Driver, &e.Status, &e.Error, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan mail log: %w", err)
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, rows.Err()
+}
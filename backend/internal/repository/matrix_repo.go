@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// MatrixRepository handles the Matrix bot's room data operations
+type MatrixRepository struct {
+	db *sql.DB
+}
+
+// NewMatrixRepository creates a new Matrix repository
+func NewMatrixRepository(db *sql.DB) *MatrixRepository {
+	return &MatrixRepository{db: db}
+}
+
+// ErrMatrixRoomNotFound is returned when no room is linked
+var ErrMatrixRoomNotFound = fmt.Errorf("matrix room %w", apperror.ErrNotFound)
+
+const matrixRoomColumns = `user_id, matrix_user_id, room_id, created_at`
+
+// scanMatrixRoom scans a row selected with matrixRoomColumns
+func scanMatrixRoom(row rowScanner) (*model.MatrixRoom, error) {
+	var r model.MatrixRoom
+	if err := row.Scan(&r.UserID, &r.MatrixUserID, &r.RoomID, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Save links a user to a room, replacing any previous link
+func (r *MatrixRepository) Save(ctx context.Context, room *model.MatrixRoom) error {
+	query := `
+		INSERT INTO matrix_rooms (user_id, matrix_user_id, room_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET matrix_user_id = EXCLUDED.matrix_user_id, room_id = EXCLUDED.room_id, created_at = NOW()
+		RETURNING created_at
+	`
+
+	if err := r.db.QueryRowContext(ctx, query, room.UserID, room.MatrixUserID, room.RoomID).Scan(&room.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save matrix room: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID retrieves a user's room
+func (r *MatrixRepository) GetByUserID(ctx context.Context, userID string) (*model.MatrixRoom, error) {
+	return r.get(ctx, `SELECT `+matrixRoomColumns+` FROM matrix_rooms WHERE user_id = $1`, userID)
+}
+
+// GetByRoomID retrieves the link for a room
+func (r *MatrixRepository) GetByRoomID(ctx context.Context, roomID string) (*model.MatrixRoom, error) {
+	return r.get(ctx, `SELECT `+matrixRoomColumns+` FROM matrix_rooms WHERE room_id = $1`, roomID)
+}
+
+// Delete removes a user's room link
+func (r *MatrixRepository) Delete(ctx context.Context, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM matrix_rooms WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete matrix room: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrMatrixRoomNotFound
+	}
+
+	return nil
+}
+
+func (r *MatrixRepository) get(ctx context.Context, query string, arg string) (*model.MatrixRoom, error) {
+	room, err := scanMatrixRoom(r.db.QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return nil, ErrMatrixRoomNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get matrix room: %w", err)
+	}
+
+	return room, nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/lib/pq"
+)
+
+// NotificationRepository handles per-user notification targets and event routes
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Target returns the user's target for the channel, or "" when none is set
+func (r *NotificationRepository) Target(ctx context.Context, userID, channel string) (string, error) {
+	var target string
+	query := `SELECT target FROM notification_targets WHERE user_id = $1 AND channel = $2`
+	err := r.db.QueryRowContext(ctx, query, userID, channel).Scan(&target)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get notification target: %w", err)
+	}
+
+	return target, nil
+}
+
+// ListTargets lists the user's channel targets
+func (r *NotificationRepository) ListTargets(ctx context.Context, userID string) ([]*model.NotificationTarget, error) {
+	query := `SELECT channel, target, updated_at FROM notification_targets WHERE user_id = $1 ORDER BY channel`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification targets: %w", err)
+	}
+	defer rows.Close()
+
+	targets := []*model.NotificationTarget{}
+	for rows.Next() {
+		var t model.NotificationTarget
+		if err := rows.Scan(&t.Channel, &t.Target, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification target: %w", err)
+		}
+		targets = append(targets, &t)
+	}
+
+	return targets, rows.Err()
+}
+
+// SetTarget creates or replaces the user's target for the channel
+func (r *NotificationRepository) SetTarget(ctx context.Context, userID, channel, target string) error {
+	query := `
+		INSERT INTO notification_targets (user_id, channel, target)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, channel) DO UPDATE SET target = EXCLUDED.target, updated_at = NOW()
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, channel, target); err != nil {
+		return fmt.Errorf("failed to set notification target: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTarget removes the user's target for the channel
+func (r *NotificationRepository) DeleteTarget(ctx context.Context, userID, channel string) error {
+	query := `DELETE FROM notification_targets WHERE user_id = $1 AND channel = $2`
+	if _, err := r.db.ExecContext(ctx, query, userID, channel); err != nil {
+		return fmt.Errorf("failed to delete notification target: %w", err)
+	}
+
+	return nil
+}
+
+// Channels returns the channels the user routes the event through: the event's own route, else
+// the user's "*" route. ok is false when neither exists, so the caller applies its defaults.
+func (r *NotificationRepository) Channels(ctx context.Context, userID, event string) ([]string, bool, error) {
+	var channels []string
+	query := `
+		SELECT channels FROM notification_routes
+		WHERE user_id = $1 AND event IN ($2, '*')
+		ORDER BY event = '*'
+		LIMIT 1
+	`
+	err := r.db.QueryRowContext(ctx, query, userID, event).Scan(pq.Array(&channels))
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get notification route: %w", err)
+	}
+
+	return channels, true, nil
+}
+
+// ListRoutes lists the user's event routes
+func (r *NotificationRepository) ListRoutes(ctx context.Context, userID string) ([]*model.NotificationRoute, error) {
+	query := `SELECT event, channels, updated_at FROM notification_routes WHERE user_id = $1 ORDER BY event`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification routes: %w", err)
+	}
+	defer rows.Close()
+
+	routes := []*model.NotificationRoute{}
+	for rows.Next() {
+		var route model.NotificationRoute
+		if err := rows.Scan(&route.Event, pq.Array(&route.Channels), &route.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification route: %w", err)
+		}
+		if route.Channels == nil {
+			route.Channels = []string{}
+		}
+		routes = append(routes, &route)
+	}
+
+	return routes, rows.Err()
+}
+
+// SetRoute creates or replaces the user's route for the event
+func (r *NotificationRepository) SetRoute(ctx context.Context, userID, event string, channels []string) error {
+	query := `
+		INSERT INTO notification_routes (user_id, event, channels)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, event) DO UPDATE SET channels = EXCLUDED.channels, updated_at = NOW()
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, event, pq.Array(channels)); err != nil {
+		return fmt.Errorf("failed to set notification route: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRoute removes the user's route for the event
+func (r *NotificationRepository) DeleteRoute(ctx context.Context, userID, event string) error {
+	query := `DELETE FROM notification_routes WHERE user_id = $1 AND event = $2`
+	if _, err := r.db.ExecContext(ctx, query, userID, event); err != nil {
+		return fmt.Errorf("failed to delete notification route: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// PrivacyRepository reports how much personal data is stored for a user
+type PrivacyRepository struct {
+	db *sql.DB
+}
+
+// NewPrivacyRepository creates a new privacy repository
+func NewPrivacyRepository(db *sql.DB) *PrivacyRepository {
+	return &PrivacyRepository{db: db}
+}
+
+// CountData counts a user's rows in each table holding their personal data
+func (r *PrivacyRepository) CountData(ctx context.Context, userID string) (*model.DataCounts, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM documents WHERE user_id = $1),
+			(SELECT COALESCE(SUM(file_size), 0) FROM documents WHERE user_id = $1),
+			(SELECT COUNT(*) FROM document_chunks WHERE user_id = $1),
+			(SELECT COUNT(*) FROM query_history WHERE user_id = $1),
+			(SELECT COUNT(*) FROM conversations WHERE user_id = $1),
+			(SELECT COUNT(*) FROM faq_entries WHERE user_id = $1),
+			(SELECT COUNT(*) FROM embedding_usage WHERE user_id = $1)
+	`
+
+	var counts model.DataCounts
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&counts.Documents, &counts.DocumentBytes, &counts.Chunks, &counts.QueryHistory,
+		&counts.Conversations, &counts.FAQEntries, &counts.EmbeddingUsage,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count user data: %w", err)
+	}
+
+	return &counts, nil
+}
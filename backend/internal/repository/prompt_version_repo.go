@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// PromptVersionRepository handles prompt version data operations
+type PromptVersionRepository struct {
+	db *sql.DB
+}
+
+// NewPromptVersionRepository creates a new prompt version repository
+func NewPromptVersionRepository(db *sql.DB) *PromptVersionRepository {
+	return &PromptVersionRepository{db: db}
+}
+
+// ErrDuplicatePromptVersion is returned when a prompt version with the name already exists
+var ErrDuplicatePromptVersion = errors.New("prompt version already exists")
+
+const promptVersionColumns = `id, name, system_prompt, pipeline, top_k, traffic_percent, created_at, updated_at`
+
+// scanPromptVersion scans a row selected with promptVersionColumns
+func scanPromptVersion(row rowScanner) (*model.PromptVersion, error) {
+	var v model.PromptVersion
+	var pipelineJSON []byte
+
+	if err := row.Scan(&v.ID, &v.Name, &v.SystemPrompt, &pipelineJSON, &v.TopK, &v.TrafficPercent, &v.CreatedAt, &v.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if len(pipelineJSON) > 0 {
+		if err := json.Unmarshal(pipelineJSON, &v.Pipeline); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal pipeline: %w", err)
+		}
+	}
+
+	return &v, nil
+}
+
+// Create creates a new prompt version
+func (r *PromptVersionRepository) Create(ctx context.Context, v *model.PromptVersion) error {
+	pipelineJSON, err := json.Marshal(v.Pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pipeline: %w", err)
+	}
+
+	query := `
+		INSERT INTO prompt_versions (name, system_prompt, pipeline, top_k, traffic_percent)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query, v.Name, v.SystemPrompt, pipelineJSON, v.TopK, v.TrafficPercent).
+		Scan(&v.ID, &v.CreatedAt, &v.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicatePromptVersion
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create prompt version: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a prompt version
+func (r *PromptVersionRepository) GetByID(ctx context.Context, id string) (*model.PromptVersion, error) {
+	query := `SELECT ` + promptVersionColumns + ` FROM prompt_versions WHERE id = $1`
+
+	v, err := scanPromptVersion(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("prompt version %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt version: %w", err)
+	}
+
+	return v, nil
+}
+
+// List lists the prompt versions, oldest first
+func (r *PromptVersionRepository) List(ctx context.Context) ([]*model.PromptVersion, error) {
+	query := `SELECT ` + promptVersionColumns + ` FROM prompt_versions ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []*model.PromptVersion{}
+	for rows.Next() {
+		v, err := scanPromptVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prompt version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+// SetTraffic sets the share of queries routed to a prompt version
+func (r *PromptVersionRepository) SetTraffic(ctx context.Context, id string, percent int) (*model.PromptVersion, error) {
+	query := `
+		UPDATE prompt_versions SET traffic_percent = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING ` + promptVersionColumns
+
+	v, err := scanPromptVersion(r.db.QueryRowContext(ctx, query, percent, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("prompt version %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update prompt version: %w", err)
+	}
+
+	return v, nil
+}
+
+// Delete deletes a prompt version. Its queries are left out of later comparisons.
+func (r *PromptVersionRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM prompt_versions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete prompt version: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("prompt version %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Stats summarizes the queries eligible for routing since the given time per prompt version,
+// the default prompt first with an empty version ID
+func (r *PromptVersionRepository) Stats(ctx context.Context, since time.Time) ([]*model.PromptVersionStats, error) {
+	query := `
+		SELECT COALESCE(h.prompt_version_id::text, ''), COALESCE(v.name, ''), COUNT(*),
+			COALESCE(AVG(h.latency_ms), 0),
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY h.latency_ms), 0),
+			COALESCE(AVG(h.cost_usd), 0), COALESCE(SUM(h.cost_usd), 0),
+			COUNT(*) FILTER (WHERE h.feedback = 1),
+			COUNT(*) FILTER (WHERE h.feedback = -1),
+			COUNT(*) FILTER (WHERE h.pinned OR h.favorite),
+			AVG(h.confidence),
+			AVG(CASE WHEN h.verified THEN 1.0 ELSE 0.0 END) FILTER (WHERE h.verified IS NOT NULL)
+		FROM query_history h
+		LEFT JOIN prompt_versions v ON v.id = h.prompt_version_id
+		WHERE h.latency_ms IS NOT NULL AND h.created_at >= $1
+			AND (h.prompt_version_id IS NULL OR v.id IS NOT NULL)
+		GROUP BY h.prompt_version_id, v.name, v.created_at
+		ORDER BY v.created_at NULLS FIRST
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt version stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []*model.PromptVersionStats{}
+	for rows.Next() {
+		var s model.PromptVersionStats
+		var confidence, verifiedRate sql.NullFloat64
+		if err := rows.Scan(&s.VersionID, &s.Name, &s.Queries, &s.AvgLatencyMS, &s.P95LatencyMS,
+			&s.AvgCostUSD, &s.TotalCostUSD, &s.Helpful, &s.NotHelpful, &s.Endorsed, &confidence, &verifiedRate); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt version stats: %w", err)
+		}
+		if confidence.Valid {
+			s.AvgConfidence = &confidence.Float64
+		}
+		if verifiedRate.Valid {
+			s.VerifiedRate = &verifiedRate.Float64
+		}
+		stats = append(stats, &s)
+	}
+
+	return stats, rows.Err()
+}
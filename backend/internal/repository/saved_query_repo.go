@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// SavedQueryRepository handles saved query data operations
+type SavedQueryRepository struct {
+	db *sql.DB
+}
+
+// NewSavedQueryRepository creates a new saved query repository
+func NewSavedQueryRepository(db *sql.DB) *SavedQueryRepository {
+	return &SavedQueryRepository{db: db}
+}
+
+const savedQueryColumns = `id, user_id, name, template, filters, created_at, updated_at`
+
+// Create creates a new saved query
+func (r *SavedQueryRepository) Create(ctx context.Context, q *model.SavedQuery) error {
+	filtersJSON, err := json.Marshal(q.Filters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal filters: %w", err)
+	}
+
+	query := `
+		INSERT INTO saved_queries (user_id, name, template, filters)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query, q.UserID, q.Name, q.Template, filtersJSON).
+		Scan(&q.ID, &q.CreatedAt, &q.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create saved query: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a saved query owned by the user
+func (r *SavedQueryRepository) GetByID(ctx context.Context, userID, id string) (*model.SavedQuery, error) {
+	query := `SELECT ` + savedQueryColumns + ` FROM saved_queries WHERE id = $1 AND user_id = $2`
+
+	q, err := scanSavedQuery(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("saved query %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved query: %w", err)
+	}
+
+	return q, nil
+}
+
+// ListByUserID lists a user's saved queries by name
+func (r *SavedQueryRepository) ListByUserID(ctx context.Context, userID string) ([]*model.SavedQuery, error) {
+	query := `SELECT ` + savedQueryColumns + ` FROM saved_queries WHERE user_id = $1 ORDER BY LOWER(name)`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved queries: %w", err)
+	}
+	defer rows.Close()
+
+	saved := []*model.SavedQuery{}
+	for rows.Next() {
+		q, err := scanSavedQuery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved query: %w", err)
+		}
+		saved = append(saved, q)
+	}
+
+	return saved, rows.Err()
+}
+
+// Update updates a saved query's editable fields
+func (r *SavedQueryRepository) Update(ctx context.Context, q *model.SavedQuery) error {
+	filtersJSON, err := json.Marshal(q.Filters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal filters: %w", err)
+	}
+
+	query := `
+		UPDATE saved_queries
+		SET name = $1, template = $2, filters = $3, updated_at = NOW()
+		WHERE id = $4 AND user_id = $5
+		RETURNING updated_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query, q.Name, q.Template, filtersJSON, q.ID, q.UserID).
+		Scan(&q.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("saved query %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update saved query: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a saved query owned by the user
+func (r *SavedQueryRepository) Delete(ctx context.Context, userID, id string) error {
+	query := `DELETE FROM saved_queries WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("saved query %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+func scanSavedQuery(row rowScanner) (*model.SavedQuery, error) {
+	var q model.SavedQuery
+	var filtersJSON []byte
+
+	if err := row.Scan(&q.ID, &q.UserID, &q.Name, &q.Template, &filtersJSON, &q.CreatedAt, &q.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if len(filtersJSON) > 0 {
+		if err := json.Unmarshal(filtersJSON, &q.Filters); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal filters: %w", err)
+		}
+	}
+
+	return &q, nil
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// ScheduleRepository handles cron schedule and run history data operations.
+// Times are stored in UTC; timezones only affect how the next run is computed.
+type ScheduleRepository struct {
+	db *sql.DB
+}
+
+// NewScheduleRepository creates a new schedule repository
+func NewScheduleRepository(db *sql.DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+const scheduleColumns = `id, name, cron_expr, timezone, misfire_policy, enabled, next_run_at, last_run_at, created_at, updated_at`
+
+// Ensure creates the schedule if none exists with its name, then loads the stored
+// schedule into s so edits made through the API survive restarts
+func (r *ScheduleRepository) Ensure(ctx context.Context, s *model.Schedule) error {
+	query := `
+		INSERT INTO schedules (name, cron_expr, timezone, misfire_policy, enabled, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (name) DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query,
+		s.Name, s.CronExpr, s.Timezone, s.MisfirePolicy, s.Enabled, s.NextRunAt.UTC()); err != nil {
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	stored, err := scanSchedule(r.db.QueryRowContext(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE name = $1`, s.Name))
+	if err != nil {
+		return fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	*s = *stored
+	return nil
+}
+
+// GetByID retrieves a schedule
+func (r *ScheduleRepository) GetByID(ctx context.Context, id string) (*model.Schedule, error) {
+	s, err := scanSchedule(r.db.QueryRowContext(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("schedule %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	return s, nil
+}
+
+// List lists all schedules
+func (r *ScheduleRepository) List(ctx context.Context) ([]*model.Schedule, error) {
+	return r.list(ctx, `SELECT `+scheduleColumns+` FROM schedules ORDER BY name`)
+}
+
+// ListDue lists enabled schedules whose next run is at or before now
+func (r *ScheduleRepository) ListDue(ctx context.Context, now time.Time) ([]*model.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE enabled AND next_run_at <= $1 ORDER BY next_run_at`
+	return r.list(ctx, query, now.UTC())
+}
+
+// Update updates a schedule's editable fields
+func (r *ScheduleRepository) Update(ctx context.Context, s *model.Schedule) error {
+	query := `
+		UPDATE schedules
+		SET cron_expr = $1, timezone = $2, misfire_policy = $3, enabled = $4, next_run_at = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		s.CronExpr, s.Timezone, s.MisfirePolicy, s.Enabled, s.NextRunAt.UTC(), s.ID).
+		Scan(&s.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("schedule %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	return nil
+}
+
+// Reschedule sets the next run; lastRunAt is recorded when the schedule actually ran
+func (r *ScheduleRepository) Reschedule(ctx context.Context, id string, lastRunAt *time.Time, nextRunAt time.Time) error {
+	query := `UPDATE schedules SET next_run_at = $1, last_run_at = COALESCE($2, last_run_at) WHERE id = $3`
+
+	var ranAt interface{}
+	if lastRunAt != nil {
+		ranAt = lastRunAt.UTC()
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, nextRunAt.UTC(), ranAt, id); err != nil {
+		return fmt.Errorf("failed to reschedule: %w", err)
+	}
+
+	return nil
+}
+
+// CreateRun records the start of a run (or a missed run)
+func (r *ScheduleRepository) CreateRun(ctx context.Context, run *model.ScheduleRun) error {
+	query := `
+		INSERT INTO schedule_runs (schedule_id, scheduled_for, started_at, finished_at, status, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	var finishedAt interface{}
+	if run.FinishedAt != nil {
+		finishedAt = run.FinishedAt.UTC()
+	}
+
+	err := r.db.QueryRowContext(ctx, query,
+		run.ScheduleID, run.ScheduledFor.UTC(), run.StartedAt.UTC(), finishedAt, run.Status, run.Error).
+		Scan(&run.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create schedule run: %w", err)
+	}
+
+	return nil
+}
+
+// FinishRun records a run's outcome
+func (r *ScheduleRepository) FinishRun(ctx context.Context, run *model.ScheduleRun) error {
+	query := `UPDATE schedule_runs SET finished_at = $1, status = $2, error = $3 WHERE id = $4`
+
+	if _, err := r.db.ExecContext(ctx, query, run.FinishedAt.UTC(), run.Status, run.Error, run.ID); err != nil {
+		return fmt.Errorf("failed to finish schedule run: %w", err)
+	}
+
+	return nil
+}
+
+// ListRuns lists a schedule's most recent runs
+func (r *ScheduleRepository) ListRuns(ctx context.Context, scheduleID string, limit int) ([]*model.ScheduleRun, error) {
+	query := `
+		SELECT id, schedule_id, scheduled_for, started_at, finished_at, status, error
+		FROM schedule_runs
+		WHERE schedule_id = $1
+		ORDER BY started_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, scheduleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []*model.ScheduleRun{}
+	for rows.Next() {
+		var run model.ScheduleRun
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.ScheduleID, &run.ScheduledFor, &run.StartedAt,
+			&finishedAt, &run.Status, &run.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule run: %w", err)
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, &run)
+	}
+
+	return runs, rows.Err()
+}
+
+func (r *ScheduleRepository) list(ctx context.Context, query string, args ...interface{}) ([]*model.Schedule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []*model.Schedule{}
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+func scanSchedule(row rowScanner) (*model.Schedule, error) {
+	var s model.Schedule
+	var lastRunAt sql.NullTime
+
+	err := row.Scan(&s.ID, &s.Name, &s.CronExpr, &s.Timezone, &s.MisfirePolicy, &s.Enabled,
+		&s.NextRunAt, &lastRunAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastRunAt.Valid {
+		s.LastRunAt = &lastRunAt.Time
+	}
+
+	return &s, nil
+}
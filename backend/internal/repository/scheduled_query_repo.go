@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// ScheduledQueryRepository handles scheduled query data operations
+type ScheduledQueryRepository struct {
+	db *sql.DB
+}
+
+// NewScheduledQueryRepository creates a new scheduled query repository
+func NewScheduledQueryRepository(db *sql.DB) *ScheduledQueryRepository {
+	return &ScheduledQueryRepository{db: db}
+}
+
+const scheduledQueryColumns = `id, user_id, question, filters, frequency, enabled, next_run_at, last_run_at, created_at, updated_at`
+
+// Create creates a new scheduled query
+func (r *ScheduledQueryRepository) Create(ctx context.Context, sq *model.ScheduledQuery) error {
+	filtersJSON, err := json.Marshal(sq.Filters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal filters: %w", err)
+	}
+
+	query := `
+		INSERT INTO scheduled_queries (user_id, question, filters, frequency, enabled, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query,
+		sq.UserID, sq.Question, filtersJSON, sq.Frequency, sq.Enabled, sq.NextRunAt).
+		Scan(&sq.ID, &sq.CreatedAt, &sq.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create scheduled query: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a scheduled query owned by the user
+func (r *ScheduledQueryRepository) GetByID(ctx context.Context, userID, id string) (*model.ScheduledQuery, error) {
+	query := `SELECT ` + scheduledQueryColumns + ` FROM scheduled_queries WHERE id = $1 AND user_id = $2`
+
+	sq, err := scanScheduledQuery(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("scheduled query %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled query: %w", err)
+	}
+
+	return sq, nil
+}
+
+// ListByUserID lists all scheduled queries for a user
+func (r *ScheduledQueryRepository) ListByUserID(ctx context.Context, userID string) ([]*model.ScheduledQuery, error) {
+	query := `SELECT ` + scheduledQueryColumns + ` FROM scheduled_queries WHERE user_id = $1 ORDER BY created_at DESC`
+	return r.list(ctx, query, userID)
+}
+
+// ListDue lists enabled scheduled queries whose next run is at or before now
+func (r *ScheduledQueryRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*model.ScheduledQuery, error) {
+	query := `SELECT ` + scheduledQueryColumns + ` FROM scheduled_queries WHERE enabled AND next_run_at <= $1 ORDER BY next_run_at LIMIT $2`
+	return r.list(ctx, query, now, limit)
+}
+
+// Update updates a scheduled query's editable fields
+func (r *ScheduledQueryRepository) Update(ctx context.Context, sq *model.ScheduledQuery) error {
+	filtersJSON, err := json.Marshal(sq.Filters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal filters: %w", err)
+	}
+
+	query := `
+		UPDATE scheduled_queries
+		SET question = $1, filters = $2, frequency = $3, enabled = $4, next_run_at = $5, updated_at = NOW()
+		WHERE id = $6 AND user_id = $7
+		RETURNING updated_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query,
+		sq.Question, filtersJSON, sq.Frequency, sq.Enabled, sq.NextRunAt, sq.ID, sq.UserID).
+		Scan(&sq.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("scheduled query %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update scheduled query: %w", err)
+	}
+
+	return nil
+}
+
+// MarkRun records a run and schedules the next one
+func (r *ScheduledQueryRepository) MarkRun(ctx context.Context, id string, ranAt, nextRunAt time.Time) error {
+	query := `UPDATE scheduled_queries SET last_run_at = $1, next_run_at = $2 WHERE id = $3`
+
+	if _, err := r.db.ExecContext(ctx, query, ranAt, nextRunAt, id); err != nil {
+		return fmt.Errorf("failed to mark scheduled query run: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a scheduled query owned by the user
+func (r *ScheduledQueryRepository) Delete(ctx context.Context, userID, id string) error {
+	query := `DELETE FROM scheduled_queries WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete scheduled query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("scheduled query %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *ScheduledQueryRepository) list(ctx context.Context, query string, args ...interface{}) ([]*model.ScheduledQuery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled queries: %w", err)
+	}
+	defer rows.Close()
+
+	scheduled := []*model.ScheduledQuery{}
+	for rows.Next() {
+		sq, err := scanScheduledQuery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled query: %w", err)
+		}
+		scheduled = append(scheduled, sq)
+	}
+
+	return scheduled, rows.Err()
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanScheduledQuery(row rowScanner) (*model.ScheduledQuery, error) {
+	var sq model.ScheduledQuery
+	var filtersJSON []byte
+	var lastRunAt sql.NullTime
+
+	err := row.Scan(&sq.ID, &sq.UserID, &sq.Question, &filtersJSON, &sq.Frequency, &sq.Enabled,
+		&sq.NextRunAt, &lastRunAt, &sq.CreatedAt, &sq.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(filtersJSON) > 0 {
+		if err := json.Unmarshal(filtersJSON, &sq.Filters); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal filters: %w", err)
+		}
+	}
+	if lastRunAt.Valid {
+		sq.LastRunAt = &lastRunAt.Time
+	}
+
+	return &sq, nil
+}
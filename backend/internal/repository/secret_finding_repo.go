@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// SecretFindingRepository handles secret finding data operations
+type SecretFindingRepository struct {
+	db *sql.DB
+}
+
+// NewSecretFindingRepository creates a new secret finding repository
+func NewSecretFindingRepository(db *sql.DB) *SecretFindingRepository {
+	return &SecretFindingRepository{db: db}
+}
+
+// CreateMany records the secrets found in a document
+func (r *SecretFindingRepository) CreateMany(ctx context.Context, findings []*model.SecretFinding) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO secret_findings (user_id, document_id, rule, preview, line, redacted)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	for _, finding := range findings {
+		err := tx.QueryRowContext(ctx, query,
+			finding.UserID, finding.DocumentID, finding.Rule, finding.Preview, finding.Line, finding.Redacted,
+		).Scan(&finding.ID, &finding.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create secret finding: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit secret findings: %w", err)
+	}
+
+	return nil
+}
+
+// ListByUserID lists the secrets found in a user's documents, newest first
+func (r *SecretFindingRepository) ListByUserID(ctx context.Context, userID string) ([]*model.SecretFinding, error) {
+	query := `
+		SELECT f.id, f.user_id, f.document_id, d.filename, f.rule, f.preview, f.line, f.redacted, f.created_at
+		FROM secret_findings f
+		JOIN documents d ON d.id = f.document_id
+		WHERE f.user_id = $1
+		ORDER BY f.created_at DESC, f.line
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secret findings: %w", err)
+	}
+	defer rows.Close()
+
+	findings := []*model.SecretFinding{}
+	for rows.Next() {
+		var finding model.SecretFinding
+		err := rows.Scan(
+			&finding.ID, &finding.UserID, &finding.DocumentID, &finding.Filename, &finding.Rule,
+			&finding.Preview, &finding.Line, &finding.Redacted, &finding.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan secret finding: %w", err)
+		}
+		findings = append(findings, &finding)
+	}
+
+	return findings, rows.Err()
+}
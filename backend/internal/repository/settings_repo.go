@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// SettingsRepository handles user settings data operations
+type SettingsRepository struct {
+	db *sql.DB
+}
+
+// NewSettingsRepository creates a new settings repository
+func NewSettingsRepository(db *sql.DB) *SettingsRepository {
+	return &SettingsRepository{db: db}
+}
+
+// Get retrieves a user's settings, returning empty defaults when none are saved
+func (r *SettingsRepository) Get(ctx context.Context, userID string) (*model.UserSettings, error) {
+	settings := model.UserSettings{UserID: userID}
+	query := `
+		SELECT system_prompt, language, embedding_model, active_embedding_model, updated_at
+		FROM user_settings WHERE user_id = $1
+	`
+
+	err := r.db.QueryRowContext(ctx, query, userID).
+		Scan(&settings.SystemPrompt, &settings.Language, &settings.EmbeddingModel, &settings.ActiveEmbeddingModel, &settings.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// Upsert saves a user's settings. The active embedding model is only changed by
+// SetActiveEmbeddingModel and is returned into settings.
+func (r *SettingsRepository) Upsert(ctx context.Context, settings *model.UserSettings) error {
+	query := `
+		INSERT INTO user_settings (user_id, system_prompt, language, embedding_model, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET system_prompt = EXCLUDED.system_prompt, language = EXCLUDED.language,
+			embedding_model = EXCLUDED.embedding_model, updated_at = NOW()
+		RETURNING active_embedding_model, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, settings.UserID, settings.SystemPrompt, settings.Language, settings.EmbeddingModel).
+		Scan(&settings.ActiveEmbeddingModel, &settings.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save user settings: %w", err)
+	}
+
+	return nil
+}
+
+// SetActiveEmbeddingModel records the embedding model the user's docs collection now holds
+func (r *SettingsRepository) SetActiveEmbeddingModel(ctx context.Context, userID, embeddingModel string) error {
+	query := `
+		INSERT INTO user_settings (user_id, active_embedding_model, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET active_embedding_model = EXCLUDED.active_embedding_model, updated_at = NOW()
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, embeddingModel); err != nil {
+		return fmt.Errorf("failed to save active embedding model: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// SnapshotRepository handles knowledge base snapshot data operations
+type SnapshotRepository struct {
+	db *sql.DB
+}
+
+// NewSnapshotRepository creates a new snapshot repository
+func NewSnapshotRepository(db *sql.DB) *SnapshotRepository {
+	return &SnapshotRepository{db: db}
+}
+
+const snapshotColumns = `id, user_id, label, automatic, document_count, vector_count, created_at`
+
+// scanSnapshot scans a row selected with snapshotColumns
+func scanSnapshot(row rowScanner) (*model.Snapshot, error) {
+	var s model.Snapshot
+	err := row.Scan(&s.ID, &s.UserID, &s.Label, &s.Automatic, &s.DocumentCount, &s.VectorCount, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Create stores a snapshot with its documents
+func (r *SnapshotRepository) Create(ctx context.Context, s *model.Snapshot) error {
+	documentsJSON, err := json.Marshal(s.Documents)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot documents: %w", err)
+	}
+
+	query := `
+		INSERT INTO kb_snapshots (user_id, label, automatic, document_count, vector_count, documents)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query, s.UserID, s.Label, s.Automatic, s.DocumentCount, s.VectorCount, documentsJSON).
+		Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a snapshot owned by the user, with its documents
+func (r *SnapshotRepository) GetByID(ctx context.Context, userID, id string) (*model.Snapshot, error) {
+	query := `SELECT ` + snapshotColumns + `, documents FROM kb_snapshots WHERE id = $1 AND user_id = $2`
+
+	var s model.Snapshot
+	var documentsJSON []byte
+	err := r.db.QueryRowContext(ctx, query, id, userID).
+		Scan(&s.ID, &s.UserID, &s.Label, &s.Automatic, &s.DocumentCount, &s.VectorCount, &s.CreatedAt, &documentsJSON)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("snapshot %w", apperror.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	if err := json.Unmarshal(documentsJSON, &s.Documents); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot documents: %w", err)
+	}
+
+	return &s, nil
+}
+
+// ListByUserID lists a user's snapshots without their documents, newest first
+func (r *SnapshotRepository) ListByUserID(ctx context.Context, userID string, limit, offset int) ([]*model.Snapshot, error) {
+	query := `
+		SELECT ` + snapshotColumns + `
+		FROM kb_snapshots
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []*model.Snapshot{}
+	for rows.Next() {
+		s, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// Delete deletes a snapshot owned by the user
+func (r *SnapshotRepository) Delete(ctx context.Context, userID, id string) error {
+	query := `DELETE FROM kb_snapshots WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("snapshot %w", apperror.ErrNotFound)
+	}
+
+	return nil
+}
+
+// PruneAutomatic deletes the automatic snapshots taken before the given time. Snapshots the
+// user took are kept until they delete them.
+func (r *SnapshotRepository) PruneAutomatic(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM kb_snapshots WHERE automatic AND created_at < $1`
+
+	if _, err := r.db.ExecContext(ctx, query, before); err != nil {
+		return fmt.Errorf("failed to prune snapshots: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// SyncRepository handles the change feed recorded by the sync triggers
+type SyncRepository struct {
+	db *sql.DB
+}
+
+// NewSyncRepository creates a new sync repository
+func NewSyncRepository(db *sql.DB) *SyncRepository {
+	return &SyncRepository{db: db}
+}
+
+// ListChanges lists the latest change of each entity the user changed after since, in sequence
+// order. An entity changed several times appears once, at its last change.
+func (r *SyncRepository) ListChanges(ctx context.Context, userID string, since int64, limit int) ([]*model.SyncChange, error) {
+	query := `
+		SELECT seq, entity, entity_id, operation, changed_at FROM (
+			SELECT DISTINCT ON (entity, entity_id) seq, entity, entity_id, operation, changed_at
+			FROM sync_changes
+			WHERE user_id = $1 AND seq > $2
+			ORDER BY entity, entity_id, seq DESC
+		) latest
+		ORDER BY seq
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []*model.SyncChange{}
+	for rows.Next() {
+		var change model.SyncChange
+		if err := rows.Scan(&change.Seq, &change.Entity, &change.EntityID, &change.Operation, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sync change: %w", err)
+		}
+		changes = append(changes, &change)
+	}
+
+	return changes, rows.Err()
+}
+
+// PrunedSeq returns the highest sequence number of the user's pruned deletions, 0 if none were
+// pruned
+func (r *SyncRepository) PrunedSeq(ctx context.Context, userID string) (int64, error) {
+	var seq int64
+	err := r.db.QueryRowContext(ctx, `SELECT pruned_seq FROM sync_horizons WHERE user_id = $1`, userID).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pruned sequence: %w", err)
+	}
+
+	return seq, nil
+}
+
+// Compact deletes changes superseded by a later change of the same entity, which no client
+// needs since the feed only returns each entity's latest change
+func (r *SyncRepository) Compact(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM sync_changes c
+		WHERE EXISTS (
+			SELECT 1 FROM sync_changes later
+			WHERE later.entity_id = c.entity_id AND later.entity = c.entity AND later.seq > c.seq
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compact sync changes: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// PruneDeletions deletes the deletions recorded before the given time and moves each affected
+// user's horizon past them. Changes of deleted users are removed as well.
+func (r *SyncRepository) PruneDeletions(ctx context.Context, before time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		WITH pruned AS (
+			DELETE FROM sync_changes
+			WHERE operation = 'delete' AND changed_at < $1
+			RETURNING user_id, seq
+		)
+		INSERT INTO sync_horizons (user_id, pruned_seq)
+		SELECT p.user_id, MAX(p.seq)
+		FROM pruned p
+		JOIN users u ON u.id = p.user_id
+		GROUP BY p.user_id
+		ON CONFLICT (user_id) DO UPDATE SET pruned_seq = GREATEST(sync_horizons.pruned_seq, EXCLUDED.pruned_seq)
+	`
+	if _, err := tx.ExecContext(ctx, query, before); err != nil {
+		return fmt.Errorf("failed to prune sync deletions: %w", err)
+	}
+
+	query = `DELETE FROM sync_changes c WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = c.user_id)`
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to delete sync changes of deleted users: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit sync pruning: %w", err)
+	}
+
+	return nil
+}
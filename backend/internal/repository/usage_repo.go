@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// UsageRepository aggregates token usage recorded on query history and document embedding
+type UsageRepository struct {
+	db *sql.DB
+}
+
+// NewUsageRepository creates a new usage repository
+func NewUsageRepository(db *sql.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// Aggregate sums a user's usage per period ("day" or "month") within [from, to), newest first
+func (r *UsageRepository) Aggregate(ctx context.Context, userID, period string, from, to time.Time) ([]*model.UsagePeriod, error) {
+	if period != "day" && period != "month" {
+		return nil, fmt.Errorf("invalid usage period: %s", period)
+	}
+
+	query := `
+		SELECT date_trunc($2, created_at) AS period, COUNT(*),
+			COALESCE(SUM(embedding_tokens), 0), COALESCE(SUM(prompt_tokens), 0),
+			COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cost_usd), 0)
+		FROM query_history
+		WHERE user_id = $1 AND created_at >= $3 AND created_at < $4
+		GROUP BY period
+		ORDER BY period DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, period, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate usage: %w", err)
+	}
+	defer rows.Close()
+
+	periods := []*model.UsagePeriod{}
+	for rows.Next() {
+		var p model.UsagePeriod
+		if err := rows.Scan(&p.Period, &p.Queries, &p.EmbeddingTokens, &p.PromptTokens, &p.CompletionTokens, &p.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		periods = append(periods, &p)
+	}
+
+	return periods, rows.Err()
+}
+
+// embeddingUsageGroupings are the embedding_usage columns usage can be grouped by
+var embeddingUsageGroupings = map[string]bool{"source": true, "folder": true}
+
+// AggregateEmbeddings sums a user's embedding usage within [from, to) per source or folder, most
+// tokens first. Usage without a folder is left out of the folder grouping.
+func (r *UsageRepository) AggregateEmbeddings(ctx context.Context, userID, groupBy string, from, to time.Time) ([]*model.EmbeddingUsageGroup, error) {
+	if !embeddingUsageGroupings[groupBy] {
+		return nil, fmt.Errorf("invalid embedding usage grouping: %s", groupBy)
+	}
+
+	query := `
+		SELECT ` + groupBy + `, COUNT(DISTINCT document_id), COALESCE(SUM(tokens), 0), COALESCE(SUM(cost_usd), 0)
+		FROM embedding_usage
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3 AND ` + groupBy + ` <> ''
+		GROUP BY ` + groupBy + `
+		ORDER BY 3 DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate embedding usage: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []*model.EmbeddingUsageGroup{}
+	for rows.Next() {
+		var g model.EmbeddingUsageGroup
+		if err := rows.Scan(&g.Key, &g.Documents, &g.Tokens, &g.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding usage: %w", err)
+		}
+		groups = append(groups, &g)
+	}
+
+	return groups, rows.Err()
+}
+
+// TopEmbeddedDocuments returns the documents that used the most embedding tokens within
+// [from, to), with their usage summed and CreatedAt set to the latest embedding
+func (r *UsageRepository) TopEmbeddedDocuments(ctx context.Context, userID string, from, to time.Time, limit int) ([]*model.EmbeddingUsage, error) {
+	query := `
+		SELECT COALESCE(document_id::text, ''), filename, source, folder,
+			COALESCE(SUM(tokens), 0), COALESCE(SUM(cost_usd), 0), MAX(created_at)
+		FROM embedding_usage
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY document_id, filename, source, folder
+		ORDER BY 5 DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top embedded documents: %w", err)
+	}
+	defer rows.Close()
+
+	documents := []*model.EmbeddingUsage{}
+	for rows.Next() {
+		u := model.EmbeddingUsage{UserID: userID}
+		if err := rows.Scan(&u.DocumentID, &u.Filename, &u.Source, &u.Folder, &u.Tokens, &u.CostUSD, &u.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding usage: %w", err)
+		}
+		documents = append(documents, &u)
+	}
+
+	return documents, rows.Err()
+}
@@ -3,8 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -31,11 +34,11 @@ func (r *UserRepository) Create(ctx context.Context, email, password string) (*m
 	query := `
 		INSERT INTO users (email, password_hash)
 		VALUES ($1, $2)
-		RETURNING id, email, created_at, updated_at
+		RETURNING id, email, is_admin, created_at, updated_at
 	`
 
 	err = r.db.QueryRowContext(ctx, query, email, string(hashedPassword)).
-		Scan(&user.ID, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+		Scan(&user.ID, &user.Email, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
@@ -47,13 +50,13 @@ func (r *UserRepository) Create(ctx context.Context, email, password string) (*m
 // GetByEmail retrieves a user by email
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	query := `SELECT id, email, password_hash, created_at, updated_at FROM users WHERE email = $1`
+	query := `SELECT id, email, password_hash, is_admin, created_at, updated_at, email_verified_at FROM users WHERE email = $1`
 
 	err := r.db.QueryRowContext(ctx, query, email).
-		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt, &user.EmailVerifiedAt)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("user %w", apperror.ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -65,13 +68,13 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*model.U
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
 	var user model.User
-	query := `SELECT id, email, password_hash, created_at, updated_at FROM users WHERE id = $1`
+	query := `SELECT id, email, password_hash, is_admin, created_at, updated_at, email_verified_at FROM users WHERE id = $1`
 
 	err := r.db.QueryRowContext(ctx, query, id).
-		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt, &user.EmailVerifiedAt)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("user %w", apperror.ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -80,7 +83,90 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*model.User, e
 	return &user, nil
 }
 
+// ListIDs lists the IDs of every user
+func (r *UserRepository) ListIDs(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
 // VerifyPassword verifies a user's password
 func (r *UserRepository) VerifyPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// UpdatePassword replaces a user's password
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	query := `UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, userID, string(hashedPassword)); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records that the user controls their email address
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID string) error {
+	query := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW() WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	return nil
+}
+
+// ErrEmailTokenInvalid is returned for unknown, used or expired email tokens
+var ErrEmailTokenInvalid = errors.New("invalid or expired token")
+
+// CreateEmailToken stores a single-use token (by hash) for verifying an email address or
+// resetting a password
+func (r *UserRepository) CreateEmailToken(ctx context.Context, userID, purpose, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO email_tokens (token_hash, user_id, purpose, expires_at) VALUES ($1, $2, $3, $4)`
+	if _, err := r.db.ExecContext(ctx, query, tokenHash, userID, purpose, expiresAt.UTC()); err != nil {
+		return fmt.Errorf("failed to create email token: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeEmailToken deletes an unexpired token for the purpose and returns its user. Every
+// other token of the user for the same purpose is deleted too, so older emails stop working.
+func (r *UserRepository) ConsumeEmailToken(ctx context.Context, purpose, tokenHash string, now time.Time) (string, error) {
+	var userID string
+	query := `
+		DELETE FROM email_tokens
+		WHERE token_hash = $1 AND purpose = $2 AND expires_at > $3
+		RETURNING user_id
+	`
+	err := r.db.QueryRowContext(ctx, query, tokenHash, purpose, now.UTC()).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", ErrEmailTokenInvalid
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to consume email token: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM email_tokens WHERE user_id = $1 AND purpose = $2`, userID, purpose); err != nil {
+		return "", fmt.Errorf("failed to delete email tokens: %w", err)
+	}
+
+	return userID, nil
+}
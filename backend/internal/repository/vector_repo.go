@@ -3,30 +3,201 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/storage"
 	"github.com/qdrant/go-client/qdrant"
 )
 
+// SparseVectorName names the sparse vector docs collections store alongside the unnamed dense one
+const SparseVectorName = "sparse"
+
+// SparseVectorConfig describes the sparse vectors of a docs collection; nil means dense only
+type SparseVectorConfig struct {
+	// IDF makes Qdrant weight terms by inverse document frequency, for encoders whose vectors
+	// hold term frequencies only (such as BM25)
+	IDF bool
+}
+
+// sparseParams converts a sparse vector config to the storage parameters of a new collection
+func sparseParams(sparse *SparseVectorConfig) []storage.SparseVectorParams {
+	if sparse == nil {
+		return nil
+	}
+	return []storage.SparseVectorParams{{Name: SparseVectorName, IDF: sparse.IDF}}
+}
+
+// Collection scopes: the vector collections each user has
+const (
+	CollectionScopeDocs          = "docs"
+	CollectionScopeConversations = "conversations"
+	CollectionScopeSummaries     = "summaries"
+)
+
+// CollectionScopes lists every collection scope
+var CollectionScopes = []string{CollectionScopeDocs, CollectionScopeConversations, CollectionScopeSummaries}
+
+// DefaultCollectionScheme names the collections of users without registered names; "{user}" and
+// "{scope}" are replaced by the user ID and collection scope
+const DefaultCollectionScheme = "user_{user}_{scope}"
+
+// collectionNameTTL is how long a resolved collection name is cached. A renamed collection's
+// previous name keeps working until it is dropped, so a stale name is harmless meanwhile.
+const collectionNameTTL = time.Minute
+
 // VectorRepository handles vector database operations
 type VectorRepository struct {
-	client *storage.QdrantClient
+	client      *storage.QdrantClient
+	collections *CollectionRepository
+
+	// names caches resolved collection names by user and scope
+	names sync.Map
+}
+
+// cachedName is a resolved collection name and when it expires
+type cachedName struct {
+	name    string
+	expires time.Time
+}
+
+// NewVectorRepository creates a new vector repository that resolves each user's collection
+// names through the collection registry
+func NewVectorRepository(client *storage.QdrantClient, collections *CollectionRepository) *VectorRepository {
+	return &VectorRepository{client: client, collections: collections}
+}
+
+// CollectionNameFromScheme returns the collection name a naming scheme gives a user's collection
+func CollectionNameFromScheme(scheme, userID, scope string) string {
+	return strings.NewReplacer("{user}", userID, "{scope}", scope).Replace(scheme)
+}
+
+// CollectionName returns the name of a user's collection of the given scope. Names are
+// registered the first time they are used, under DefaultCollectionScheme, so later changes of
+// the scheme or renames don't lose existing collections.
+func (r *VectorRepository) CollectionName(ctx context.Context, userID, scope string) (string, error) {
+	key := userID + "/" + scope
+	if cached, ok := r.names.Load(key); ok && time.Now().Before(cached.(cachedName).expires) {
+		return cached.(cachedName).name, nil
+	}
+
+	name, err := r.collections.Register(ctx, userID, scope, CollectionNameFromScheme(DefaultCollectionScheme, userID, scope))
+	if err != nil {
+		return "", err
+	}
+	r.names.Store(key, cachedName{name: name, expires: time.Now().Add(collectionNameTTL)})
+	return name, nil
+}
+
+// RenameCollection gives a user's collection a new name without moving its vectors: the new name
+// becomes an alias of the collection serving it, and the current name keeps working as its
+// previous name until DropPreviousName. A collection that doesn't exist yet is created under the
+// new name when first used.
+func (r *VectorRepository) RenameCollection(ctx context.Context, userID, scope, name string) error {
+	current, err := r.CollectionName(ctx, userID, scope)
+	if err != nil || current == name {
+		return err
+	}
+
+	target, err := r.resolveName(ctx, current)
+	if err != nil {
+		return err
+	}
+	exists, err := r.client.CollectionExists(ctx, target)
+	if err != nil {
+		return err
+	}
+	if exists {
+		taken, err := r.client.CollectionExists(ctx, name)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return fmt.Errorf("collection %s already exists", name)
+		}
+		if err := r.client.PointAlias(ctx, name, target); err != nil {
+			return err
+		}
+	}
+
+	if err := r.collections.Rename(ctx, userID, scope, name); err != nil {
+		return err
+	}
+	r.names.Delete(userID + "/" + scope)
+	return nil
+}
+
+// DropPreviousName removes the alias a renamed collection kept under its previous name. A
+// previous name that is the collection itself, rather than an alias, still holds the vectors
+// and is kept.
+func (r *VectorRepository) DropPreviousName(ctx context.Context, userID, scope string) error {
+	collection, err := r.collections.Get(ctx, userID, scope)
+	if err != nil || collection.PreviousName == "" {
+		return err
+	}
+
+	target, err := r.client.AliasTarget(ctx, collection.PreviousName)
+	if err != nil {
+		return err
+	}
+	if target != "" {
+		if err := r.client.DeleteAlias(ctx, collection.PreviousName); err != nil {
+			return err
+		}
+	}
+	return r.collections.ClearPreviousName(ctx, userID, scope)
 }
 
-// NewVectorRepository creates a new vector repository
-func NewVectorRepository(client *storage.QdrantClient) *VectorRepository {
-	return &VectorRepository{client: client}
+// resolveName returns the collection a name refers to: the target of an alias, or the name
+// itself
+func (r *VectorRepository) resolveName(ctx context.Context, name string) (string, error) {
+	target, err := r.client.AliasTarget(ctx, name)
+	if err != nil || target == "" {
+		return name, err
+	}
+	return target, nil
 }
 
-// GetCollectionName returns the collection name for a user
-func (r *VectorRepository) GetCollectionName(userID string) string {
-	return fmt.Sprintf("user_%s_docs", userID)
+// collectionExists reports whether a name refers to a collection, directly or as an alias
+func (r *VectorRepository) collectionExists(ctx context.Context, name string) (bool, error) {
+	target, err := r.resolveName(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	return r.client.CollectionExists(ctx, target)
 }
 
-// EnsureCollection ensures a collection exists for the user
-func (r *VectorRepository) EnsureCollection(ctx context.Context, userID string, vectorSize uint64) error {
-	collectionName := r.GetCollectionName(userID)
+// GetVersionedCollectionName returns the name of a docs collection built for one embedding model.
+// The user's docs collection name becomes an alias to it once a re-embedding run activates it.
+func (r *VectorRepository) GetVersionedCollectionName(userID, version string) string {
+	return fmt.Sprintf("user_%s_docs_%s", userID, version)
+}
+
+// EnsureCollection ensures a collection exists for the user with vectors of the embedding provider's
+// size. A new collection also gets the sparse vector when one is configured; an existing one keeps
+// its vectors until it is re-embedded.
+func (r *VectorRepository) EnsureCollection(ctx context.Context, userID string, vectorSize uint64, sparse *SparseVectorConfig) error {
+	collectionName, err := r.CollectionName(ctx, userID, CollectionScopeDocs)
+	if err != nil {
+		return err
+	}
+	return r.ensureCollection(ctx, collectionName, vectorSize, sparse)
+}
+
+// ensureCollection creates the named collection if it doesn't exist. An existing collection must
+// have the configured vector size; otherwise the embedding model or dimensions changed since it
+// was created and its vectors can't be compared with new ones.
+func (r *VectorRepository) ensureCollection(ctx context.Context, collectionName string, vectorSize uint64, sparse *SparseVectorConfig) error {
+	// The name may be an alias to a collection built by a re-embedding run
+	target, err := r.client.AliasTarget(ctx, collectionName)
+	if err != nil {
+		return err
+	}
+	if target != "" {
+		collectionName = target
+	}
 
 	exists, err := r.client.CollectionExists(ctx, collectionName)
 	if err != nil {
@@ -34,66 +205,474 @@ func (r *VectorRepository) EnsureCollection(ctx context.Context, userID string,
 	}
 
 	if !exists {
-		return r.client.CreateCollection(ctx, collectionName, vectorSize)
+		return r.client.CreateCollection(ctx, collectionName, vectorSize, sparseParams(sparse)...)
+	}
+
+	existingSize, err := r.client.CollectionVectorSize(ctx, collectionName)
+	if err != nil {
+		return err
+	}
+	if existingSize != 0 && existingSize != vectorSize {
+		return fmt.Errorf("collection %s holds %d-dimension vectors but the embedding model produces %d; "+
+			"re-embed documents with cmd/reembed after changing the embedding model or dimensions",
+			collectionName, existingSize, vectorSize)
 	}
 
 	return nil
 }
 
-// InsertVectors inserts vectors into a user's collection
-func (r *VectorRepository) InsertVectors(ctx context.Context, userID string, points []*model.VectorPoint) error {
-	_ = r.GetCollectionName(userID) // TODO: use when implementing upsert
+// ActiveCollection returns the collection serving a user's docs: the target of their docs alias,
+// a collection created before aliases were used, or "" if they have none
+func (r *VectorRepository) ActiveCollection(ctx context.Context, userID string) (string, error) {
+	name, err := r.CollectionName(ctx, userID, CollectionScopeDocs)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := r.client.AliasTarget(ctx, name)
+	if err != nil || target != "" {
+		return target, err
+	}
+
+	exists, err := r.client.CollectionExists(ctx, name)
+	if err != nil || !exists {
+		return "", err
+	}
+	return name, nil
+}
+
+// RecreateCollection creates an empty collection, dropping any existing collection of that name
+// (or that the name is an alias of, along with its aliases)
+func (r *VectorRepository) RecreateCollection(ctx context.Context, collectionName string, vectorSize uint64, sparse *SparseVectorConfig) error {
+	if err := r.DeleteCollection(ctx, collectionName); err != nil {
+		return err
+	}
+
+	return r.client.CreateCollection(ctx, collectionName, vectorSize, sparseParams(sparse)...)
+}
+
+// UpsertPoints writes points into the named collection. Sparse vectors are dropped when the
+// collection was created without them.
+func (r *VectorRepository) UpsertPoints(ctx context.Context, collectionName string, points []*model.VectorPoint) error {
+	withSparse := false
+	for _, p := range points {
+		if p.Sparse != nil {
+			hasSparse, err := r.hasSparseVector(ctx, collectionName)
+			if err != nil {
+				return err
+			}
+			withSparse = hasSparse
+			break
+		}
+	}
 
-	// Convert to Qdrant points
 	qdrantPoints := make([]*qdrant.PointStruct, len(points))
 	for i, p := range points {
-		qdrantPoints[i] = &qdrant.PointStruct{
-			Id: &qdrant.PointId{
-				PointIdOptions: &qdrant.PointId_Uuid{
-					Uuid: p.ID,
-				},
-			},
-			Vectors: &qdrant.Vectors{
-				VectorsOptions: &qdrant.Vectors_Vector{
-					Vector: &qdrant.Vector{
-						Data: p.Vector,
-					},
-				},
-			},
-			Payload: convertToQdrantPayload(p.Payload),
+		qdrantPoints[i] = toQdrantPoint(p, withSparse)
+	}
+
+	return r.client.Upsert(ctx, collectionName, qdrantPoints)
+}
+
+// hasSparseVector reports whether the named collection (or the collection an alias of that name
+// points to) stores sparse vectors
+func (r *VectorRepository) hasSparseVector(ctx context.Context, collectionName string) (bool, error) {
+	target, err := r.client.AliasTarget(ctx, collectionName)
+	if err != nil {
+		return false, err
+	}
+	if target != "" {
+		collectionName = target
+	}
+
+	return r.client.HasSparseVector(ctx, collectionName, SparseVectorName)
+}
+
+// ActivateCollection points the user's docs alias at a versioned collection in one atomic
+// update. A collection created before aliases were used holds the alias name, so it is deleted
+// first and searches fail for the moment between the two calls. An alias kept under the docs'
+// previous name is moved too.
+func (r *VectorRepository) ActivateCollection(ctx context.Context, userID, collectionName string) error {
+	collection, err := r.collections.Get(ctx, userID, CollectionScopeDocs)
+	if err != nil {
+		return err
+	}
+	alias := collection.Name
+	if collection.PreviousName != "" {
+		previousTarget, err := r.client.AliasTarget(ctx, collection.PreviousName)
+		if err != nil {
+			return err
+		}
+		if previousTarget != "" {
+			if err := r.client.PointAlias(ctx, collection.PreviousName, collectionName); err != nil {
+				return err
+			}
 		}
 	}
 
-	// TODO: Implement upsert vectors to Qdrant
-	// This requires the Points client
-	_ = qdrantPoints
+	target, err := r.client.AliasTarget(ctx, alias)
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		exists, err := r.client.CollectionExists(ctx, alias)
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := r.client.DeleteCollection(ctx, alias); err != nil {
+				return err
+			}
+		}
+	}
+
+	return r.client.PointAlias(ctx, alias, collectionName)
+}
+
+// DeleteCollection deletes the named collection, or the collection the name is an alias of along
+// with its aliases. A name that refers to no collection is ignored.
+func (r *VectorRepository) DeleteCollection(ctx context.Context, collectionName string) error {
+	target, err := r.resolveName(ctx, collectionName)
+	if err != nil {
+		return err
+	}
+	exists, err := r.client.CollectionExists(ctx, target)
+	if err != nil || !exists {
+		return err
+	}
+	return r.client.DeleteCollection(ctx, target)
+}
 
-	return fmt.Errorf("insert vectors not fully implemented yet")
+// DeleteUserCollection deletes a user's collection of the given scope; it is created again, empty,
+// when next used
+func (r *VectorRepository) DeleteUserCollection(ctx context.Context, userID, scope string) error {
+	collectionName, err := r.CollectionName(ctx, userID, scope)
+	if err != nil {
+		return err
+	}
+	return r.DeleteCollection(ctx, collectionName)
+}
+
+// CountUserVectors returns the number of vectors stored for a user across their docs, summary
+// and conversation collections
+func (r *VectorRepository) CountUserVectors(ctx context.Context, userID string) (uint64, error) {
+	active, err := r.ActiveCollection(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	summaries, err := r.CollectionName(ctx, userID, CollectionScopeSummaries)
+	if err != nil {
+		return 0, err
+	}
+	conversations, err := r.CollectionName(ctx, userID, CollectionScopeConversations)
+	if err != nil {
+		return 0, err
+	}
+
+	var total uint64
+	for _, collectionName := range []string{active, summaries, conversations} {
+		if collectionName == "" {
+			continue
+		}
+		exists, err := r.collectionExists(ctx, collectionName)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			continue
+		}
+		count, err := r.client.Count(ctx, collectionName)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+
+	return total, nil
+}
+
+// GetVectors returns the stored dense vectors of points in a user's docs collection by ID.
+// Points that don't exist, or a user without a collection, have none.
+func (r *VectorRepository) GetVectors(ctx context.Context, userID string, ids []string) (map[string][]float32, error) {
+	vectors := make(map[string][]float32, len(ids))
+	collectionName, err := r.ActiveCollection(ctx, userID)
+	if err != nil || collectionName == "" || len(ids) == 0 {
+		return vectors, err
+	}
+
+	points, err := r.client.Get(ctx, collectionName, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, point := range points {
+		if vector := denseVector(point.GetVectors()); len(vector) > 0 {
+			vectors[point.GetId().GetUuid()] = vector
+		}
+	}
+
+	return vectors, nil
+}
+
+// InsertVectors inserts vectors into a user's collection
+func (r *VectorRepository) InsertVectors(ctx context.Context, userID string, points []*model.VectorPoint) error {
+	collectionName, err := r.CollectionName(ctx, userID, CollectionScopeDocs)
+	if err != nil {
+		return err
+	}
+	return r.UpsertPoints(ctx, collectionName, points)
+}
+
+// IndexConversation stores a conversation embedding for semantic conversation search
+func (r *VectorRepository) IndexConversation(ctx context.Context, userID string, point *model.VectorPoint) error {
+	collectionName, err := r.CollectionName(ctx, userID, CollectionScopeConversations)
+	if err != nil {
+		return err
+	}
+
+	if err := r.ensureCollection(ctx, collectionName, uint64(len(point.Vector)), nil); err != nil {
+		return err
+	}
+
+	return r.client.Upsert(ctx, collectionName, []*qdrant.PointStruct{toQdrantPoint(point, false)})
+}
+
+// SearchConversations finds the user's conversations most similar to the query vector
+func (r *VectorRepository) SearchConversations(ctx context.Context, userID string, vector []float32, limit int) ([]*model.VectorPoint, error) {
+	collectionName, err := r.CollectionName(ctx, userID, CollectionScopeConversations)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := r.collectionExists(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []*model.VectorPoint{}, nil
+	}
+
+	return r.search(ctx, collectionName, vector, limit, nil, false)
+}
+
+// DeleteConversation deletes a conversation's embedding. A user without a conversations
+// collection has none to delete.
+func (r *VectorRepository) DeleteConversation(ctx context.Context, userID, conversationID string) error {
+	collectionName, err := r.CollectionName(ctx, userID, CollectionScopeConversations)
+	if err != nil {
+		return err
+	}
+
+	exists, err := r.collectionExists(ctx, collectionName)
+	if err != nil || !exists {
+		return err
+	}
+
+	filter := &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewMatch("conversation_id", conversationID)}}
+	return r.client.Delete(ctx, collectionName, filter)
+}
+
+// IndexDocumentSummary stores a document summary embedding, keyed by document ID
+func (r *VectorRepository) IndexDocumentSummary(ctx context.Context, userID string, point *model.VectorPoint) error {
+	collectionName, err := r.CollectionName(ctx, userID, CollectionScopeSummaries)
+	if err != nil {
+		return err
+	}
+
+	if err := r.ensureCollection(ctx, collectionName, uint64(len(point.Vector)), nil); err != nil {
+		return err
+	}
+
+	return r.client.Upsert(ctx, collectionName, []*qdrant.PointStruct{toQdrantPoint(point, false)})
+}
+
+// SearchDocumentSummaries finds the user's documents whose summaries are most similar to the query vector
+func (r *VectorRepository) SearchDocumentSummaries(ctx context.Context, userID string, vector []float32, limit int) ([]*model.VectorPoint, error) {
+	collectionName, err := r.CollectionName(ctx, userID, CollectionScopeSummaries)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := r.collectionExists(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []*model.VectorPoint{}, nil
+	}
+
+	return r.search(ctx, collectionName, vector, limit, nil, false)
+}
+
+// SearchFilter restricts a similarity search to points whose payload matches
+type SearchFilter struct {
+	// Match maps payload keys to the exact keyword value they must hold
+	Match map[string]string
+	// DocumentIDs, when set, restricts results to chunks of these documents
+	DocumentIDs []string
+	// Exclude maps payload keys to keyword values that must not match
+	Exclude map[string][]string
+	// ExcludeDocumentIDs omits chunks of these documents
+	ExcludeDocumentIDs []string
+	// Ranges maps payload keys to numeric bounds; chunks without the key don't match
+	Ranges map[string]Range
+}
+
+// Range bounds a numeric payload value; nil bounds are open
+type Range struct {
+	Gte *float64
+	Lt  *float64
 }
 
 // Search performs similarity search
-func (r *VectorRepository) Search(ctx context.Context, userID string, vector []float32, limit int) ([]*model.VectorPoint, error) {
-	collectionName := r.GetCollectionName(userID)
+func (r *VectorRepository) Search(ctx context.Context, userID string, vector []float32, limit int, filter *SearchFilter) ([]*model.VectorPoint, error) {
+	collectionName, err := r.CollectionName(ctx, userID, CollectionScopeDocs)
+	if err != nil {
+		return nil, err
+	}
+	return r.search(ctx, collectionName, vector, limit, filter, false)
+}
+
+// SearchWithVectors performs similarity search and also returns each chunk's embedding
+func (r *VectorRepository) SearchWithVectors(ctx context.Context, userID string, vector []float32, limit int, filter *SearchFilter) ([]*model.VectorPoint, error) {
+	collectionName, err := r.CollectionName(ctx, userID, CollectionScopeDocs)
+	if err != nil {
+		return nil, err
+	}
+	return r.search(ctx, collectionName, vector, limit, filter, true)
+}
+
+// SearchSparse searches the user's chunks by sparse vector, returning each chunk's dense embedding
+// with its sparse score. A collection without sparse vectors yields no results.
+func (r *VectorRepository) SearchSparse(ctx context.Context, userID string, sparse *model.SparseVector, limit int, filter *SearchFilter) ([]*model.VectorPoint, error) {
+	collectionName, err := r.CollectionName(ctx, userID, CollectionScopeDocs)
+	if err != nil {
+		return nil, err
+	}
+
+	hasSparse, err := r.hasSparseVector(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if !hasSparse || len(sparse.Indices) == 0 {
+		return []*model.VectorPoint{}, nil
+	}
 
-	// TODO: Implement search
-	// This requires the Points client
+	scored, err := r.client.SearchSparse(ctx, collectionName, SparseVectorName, sparse.Indices, sparse.Values, uint64(limit), buildQdrantFilter(filter))
+	if err != nil {
+		return nil, err
+	}
 
-	_ = collectionName
-	_ = vector
-	_ = limit
+	return toVectorPoints(scored), nil
+}
 
-	return nil, fmt.Errorf("search not fully implemented yet")
+// search performs similarity search against the named collection
+func (r *VectorRepository) search(ctx context.Context, collectionName string, vector []float32, limit int, filter *SearchFilter, withVectors bool) ([]*model.VectorPoint, error) {
+	scored, err := r.client.Search(ctx, collectionName, vector, uint64(limit), buildQdrantFilter(filter), withVectors)
+	if err != nil {
+		return nil, err
+	}
+
+	return toVectorPoints(scored), nil
 }
 
-// DeleteByDocumentID deletes all vectors for a document
+// toVectorPoints converts search results to vector points
+func toVectorPoints(scored []*qdrant.ScoredPoint) []*model.VectorPoint {
+	results := make([]*model.VectorPoint, 0, len(scored))
+	for _, point := range scored {
+		results = append(results, &model.VectorPoint{
+			ID:      point.GetId().GetUuid(),
+			Vector:  denseVector(point.GetVectors()),
+			Payload: convertFromQdrantPayload(point.GetPayload()),
+			Score:   point.GetScore(),
+		})
+	}
+
+	return results
+}
+
+// denseVector extracts the dense vector from a search result, if one was returned. Collections
+// with sparse vectors return it as the unnamed entry of the named vectors.
+func denseVector(vectors *qdrant.VectorsOutput) []float32 {
+	v := vectors.GetVector()
+	if named := vectors.GetVectors().GetVectors(); named != nil {
+		v = named[""]
+	}
+	if dense := v.GetDense().GetData(); len(dense) > 0 {
+		return dense
+	}
+	// Older servers return dense vectors in the deprecated data field
+	return v.GetData() //nolint:staticcheck
+}
+
+// buildQdrantFilter converts a SearchFilter to a Qdrant filter
+func buildQdrantFilter(filter *SearchFilter) *qdrant.Filter {
+	if filter == nil {
+		return nil
+	}
+
+	var must, mustNot []*qdrant.Condition
+	for key, value := range filter.Match {
+		must = append(must, qdrant.NewMatch(key, value))
+	}
+	if len(filter.DocumentIDs) > 0 {
+		must = append(must, qdrant.NewMatchKeywords("document_id", filter.DocumentIDs...))
+	}
+	for key, bounds := range filter.Ranges {
+		must = append(must, qdrant.NewRange(key, &qdrant.Range{Gte: bounds.Gte, Lt: bounds.Lt}))
+	}
+	for key, values := range filter.Exclude {
+		if len(values) > 0 {
+			mustNot = append(mustNot, qdrant.NewMatchKeywords(key, values...))
+		}
+	}
+	if len(filter.ExcludeDocumentIDs) > 0 {
+		mustNot = append(mustNot, qdrant.NewMatchKeywords("document_id", filter.ExcludeDocumentIDs...))
+	}
+
+	if len(must) == 0 && len(mustNot) == 0 {
+		return nil
+	}
+	return &qdrant.Filter{Must: must, MustNot: mustNot}
+}
+
+// DeleteByDocumentID deletes all vectors for a document. A user without a docs collection has
+// none to delete.
 func (r *VectorRepository) DeleteByDocumentID(ctx context.Context, userID, documentID string) error {
-	_ = r.GetCollectionName(userID)
+	collectionName, err := r.ActiveCollection(ctx, userID)
+	if err != nil || collectionName == "" {
+		return err
+	}
+
+	filter := &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewMatch("document_id", documentID)}}
+	return r.client.Delete(ctx, collectionName, filter)
+}
 
-	// TODO: Implement delete by filter using Points client
-	// This requires filtering by document_id in the payload
-	_ = documentID
+// toQdrantPoint converts a vector point to a Qdrant point, with its sparse vector if withSparse
+// is set and it has one
+func toQdrantPoint(p *model.VectorPoint, withSparse bool) *qdrant.PointStruct {
+	vectors := &qdrant.Vectors{
+		VectorsOptions: &qdrant.Vectors_Vector{
+			Vector: &qdrant.Vector{
+				Data: p.Vector,
+			},
+		},
+	}
+	if withSparse && p.Sparse != nil {
+		vectors = qdrant.NewVectorsMap(map[string]*qdrant.Vector{
+			"":               qdrant.NewVectorDense(p.Vector),
+			SparseVectorName: qdrant.NewVectorSparse(p.Sparse.Indices, p.Sparse.Values),
+		})
+	}
 
-	return fmt.Errorf("delete by document ID not fully implemented yet")
+	return &qdrant.PointStruct{
+		Id: &qdrant.PointId{
+			PointIdOptions: &qdrant.PointId_Uuid{
+				Uuid: p.ID,
+			},
+		},
+		Vectors: vectors,
+		Payload: convertToQdrantPayload(p.Payload),
+	}
 }
 
 // convertToQdrantPayload converts a map to Qdrant payload
@@ -122,8 +701,53 @@ func convertToQdrantPayload(payload map[string]interface{}) map[string]*qdrant.V
 			result[key] = &qdrant.Value{
 				Kind: &qdrant.Value_BoolValue{BoolValue: v},
 			}
+		case []string:
+			values := make([]*qdrant.Value, len(v))
+			for i, item := range v {
+				values[i] = &qdrant.Value{
+					Kind: &qdrant.Value_StringValue{StringValue: item},
+				}
+			}
+			result[key] = &qdrant.Value{
+				Kind: &qdrant.Value_ListValue{ListValue: &qdrant.ListValue{Values: values}},
+			}
 		}
 	}
 
 	return result
 }
+
+// convertFromQdrantPayload converts a Qdrant payload back to a map
+func convertFromQdrantPayload(payload map[string]*qdrant.Value) map[string]interface{} {
+	result := make(map[string]interface{}, len(payload))
+
+	for key, value := range payload {
+		result[key] = convertFromQdrantValue(value)
+	}
+
+	return result
+}
+
+// convertFromQdrantValue converts a single Qdrant value to its Go equivalent
+func convertFromQdrantValue(value *qdrant.Value) interface{} {
+	switch v := value.GetKind().(type) {
+	case *qdrant.Value_StringValue:
+		return v.StringValue
+	case *qdrant.Value_IntegerValue:
+		return v.IntegerValue
+	case *qdrant.Value_DoubleValue:
+		return v.DoubleValue
+	case *qdrant.Value_BoolValue:
+		return v.BoolValue
+	case *qdrant.Value_ListValue:
+		items := make([]interface{}, len(v.ListValue.GetValues()))
+		for i, item := range v.ListValue.GetValues() {
+			items[i] = convertFromQdrantValue(item)
+		}
+		return items
+	case *qdrant.Value_StructValue:
+		return convertFromQdrantPayload(v.StructValue.GetFields())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// Agent mode limits
+const (
+	defaultAgentIterations = 4
+	maxAgentIterations     = 8
+)
+
+// searchToolName is the function the model calls to query the knowledge base
+const searchToolName = "search_knowledge_base"
+
+// ChatTool describes a function the model may call
+type ChatTool struct {
+	Type     string           `json:"type"`
+	Function ChatToolFunction `json:"function"`
+}
+
+// ChatToolFunction describes a callable function and its JSON Schema parameters
+type ChatToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ToolCall represents a function call requested by the model
+type ToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// AgentStep records one tool call performed by the agent
+type AgentStep struct {
+	Iteration int                      `json:"iteration"`
+	Tool      string                   `json:"tool"`
+	Query     string                   `json:"query,omitempty"`
+	Output    string                   `json:"output,omitempty"`
+	Results   int                      `json:"results"`
+	Sources   []map[string]interface{} `json:"sources"`
+	Error     string                   `json:"error,omitempty"`
+	// Degraded is set when this search fell back to keyword matching
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// agentSystemPrompt instructs the model to gather evidence through the search tool
+const agentSystemPrompt = `You are a research assistant with access to the user's uploaded documents through the search_knowledge_base tool.
+
+Search the knowledge base as many times as needed to gather enough evidence, refining or splitting the question into focused searches.
+When you have enough evidence, answer the question concisely and cite the documents you used.
+If the knowledge base does not contain the answer, clearly state that.
+
+CRITICAL: Base your answer ONLY on search results. Do not use external knowledge.`
+
+// searchTool is the tool definition exposed to the model in agent mode
+var searchTool = ChatTool{
+	Type: "function",
+	Function: ChatToolFunction{
+		Name:        searchToolName,
+		Description: "Semantic search over the user's documents. Returns the most relevant text chunks.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "A focused search query",
+				},
+			},
+			"required": []string{"query"},
+		},
+	},
+}
+
+// runAgent lets the model iteratively search the knowledge base until it produces an answer.
+// It returns the answer, every chunk retrieved along the way, and a trace of each step.
+func (s *RAGService) runAgent(ctx context.Context, userID, question string, retrieval RetrievalOptions, opts GenerationOptions, maxIterations int) (string, []*model.VectorPoint, []AgentStep, error) {
+	if maxIterations <= 0 {
+		maxIterations = defaultAgentIterations
+	}
+	if maxIterations > maxAgentIterations {
+		maxIterations = maxAgentIterations
+	}
+
+	messages := []ChatMessage{
+		{Role: "system", Content: buildSystemPrompt(agentSystemPrompt, opts)},
+		{Role: "user", Content: question},
+	}
+
+	var steps []AgentStep
+	var collected []*model.VectorPoint
+	seen := make(map[string]bool)
+	tools := append([]ChatTool{searchTool}, s.tools.Definitions()...)
+
+	for iteration := 1; iteration <= maxIterations; iteration++ {
+		reply, err := s.chatCompletion(ctx, ChatCompletionRequest{
+			Model:       opts.Model,
+			Temperature: opts.Temperature,
+			Messages:    messages,
+			Tools:       tools,
+		})
+		if err != nil {
+			return "", nil, steps, fmt.Errorf("failed to call LLM: %w", err)
+		}
+
+		if len(reply.ToolCalls) == 0 {
+			return reply.Content, collected, steps, nil
+		}
+
+		messages = append(messages, *reply)
+		for _, call := range reply.ToolCalls {
+			step := AgentStep{Iteration: iteration, Tool: call.Function.Name}
+			var results []*model.VectorPoint
+
+			// Non-search tools are delegated to the registry
+			if call.Function.Name != searchToolName {
+				output, err := s.tools.Call(ctx, call.Function.Name, call.Function.Arguments)
+				if err != nil {
+					step.Error = err.Error()
+					output = "Error: " + err.Error()
+				}
+				step.Output = output
+				steps = append(steps, step)
+				messages = append(messages, ChatMessage{
+					Role:       "tool",
+					Content:    output,
+					ToolCallID: call.ID,
+				})
+				continue
+			}
+
+			var args struct {
+				Query string `json:"query"`
+			}
+			switch {
+			case json.Unmarshal([]byte(call.Function.Arguments), &args) != nil || args.Query == "":
+				step.Error = "invalid tool arguments"
+			default:
+				step.Query = args.Query
+				results, step.Degraded, err = s.retrieve(ctx, userID, args.Query, retrieval)
+				if err != nil {
+					step.Error = err.Error()
+					logger.Error("Agent search failed", "user_id", userID, "query", args.Query, "error", err)
+				}
+			}
+
+			for _, result := range results {
+				if !seen[result.ID] {
+					seen[result.ID] = true
+					collected = append(collected, result)
+				}
+			}
+
+			toolOutput := buildContextText(results)
+			if step.Error != "" {
+				toolOutput = "Error: " + step.Error
+			} else if toolOutput == "" {
+				toolOutput = "No matching documents found."
+			}
+
+			step.Results = len(results)
+			step.Sources = buildSources(results)
+			steps = append(steps, step)
+
+			messages = append(messages, ChatMessage{
+				Role:       "tool",
+				Content:    toolOutput,
+				ToolCallID: call.ID,
+			})
+		}
+	}
+
+	// Iteration budget exhausted: force a final answer from the evidence gathered so far
+	messages = append(messages, ChatMessage{
+		Role:    "user",
+		Content: "Stop searching and answer the original question using only the search results above.",
+	})
+	reply, err := s.chatCompletion(ctx, ChatCompletionRequest{
+		Model:       opts.Model,
+		Temperature: opts.Temperature,
+		Messages:    messages,
+		Tools:       tools,
+		ToolChoice:  "none",
+	})
+	if err != nil {
+		return "", nil, steps, fmt.Errorf("failed to call LLM: %w", err)
+	}
+
+	return reply.Content, collected, steps, nil
+}
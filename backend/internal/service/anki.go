@@ -0,0 +1,70 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// defaultAnkiDeck is the deck notes are imported into when none is named
+const defaultAnkiDeck = "Personal Assistant"
+
+// ankiNote is a Basic (front/back) Anki note. front and back are HTML; build them with ankiField
+// and ankiSources.
+type ankiNote struct {
+	front string
+	back  string
+	tags  []string
+}
+
+// renderAnkiCSV renders notes in Anki's text import format: a CSV file whose header lines tell
+// Anki (2.1.55 or later) the separator, note type, target deck and tags column, so File > Import
+// needs no field mapping.
+func renderAnkiCSV(deck string, notes []ankiNote) ([]byte, error) {
+	if deck = strings.TrimSpace(deck); deck == "" {
+		deck = defaultAnkiDeck
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "#separator:comma\n#html:true\n#notetype:Basic\n#deck:%s\n#tags column:3\n", strings.ReplaceAll(deck, "\n", " "))
+
+	w := csv.NewWriter(&buf)
+	for _, note := range notes {
+		tags := make([]string, len(note.tags))
+		for i, tag := range note.tags {
+			tags[i] = ankiTag(tag)
+		}
+		if err := w.Write([]string{note.front, note.back, strings.Join(tags, " ")}); err != nil {
+			return nil, fmt.Errorf("failed to write Anki note: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write Anki notes: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ankiField escapes plain text as an HTML field, keeping line breaks as <br>
+func ankiField(s string) string {
+	return strings.ReplaceAll(html.EscapeString(strings.TrimSpace(s)), "\n", "<br>")
+}
+
+// ankiSources appends the cited sources to a note's back as a small list
+func ankiSources(back string, sources []string) string {
+	if len(sources) == 0 {
+		return ankiField(back)
+	}
+	escaped := make([]string, len(sources))
+	for i, source := range sources {
+		escaped[i] = html.EscapeString(source)
+	}
+	return ankiField(back) + "<br><br><small>Sources: " + strings.Join(escaped, "; ") + "</small>"
+}
+
+// ankiTag turns a label into an Anki tag, which can't contain spaces
+func ankiTag(label string) string {
+	return strings.Join(strings.Fields(label), "_")
+}
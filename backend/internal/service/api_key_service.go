@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// APIKeyPrefix marks bearer credentials that are API keys rather than JWTs
+const APIKeyPrefix = "rag_"
+
+// APIKeyService manages scoped API keys
+type APIKeyService struct {
+	apiKeyRepo *repository.APIKeyRepository
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(apiKeyRepo *repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+// CreateAPIKeyInput represents the fields of a new API key
+type CreateAPIKeyInput struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresInDays sets an expiry; zero creates a key that doesn't expire
+	ExpiresInDays int `json:"expires_in_days"`
+	// AllowedOrigins lists the websites that may embed a widget key; required for widget keys
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+// Create issues a new API key limited to scopes the caller holds.
+// The plaintext key is returned only once; only its hash is stored.
+func (s *APIKeyService) Create(ctx context.Context, userID string, callerScopes []string, input CreateAPIKeyInput) (*model.APIKey, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, "", fmt.Errorf("name is required")
+	}
+	if len(name) > 255 {
+		return nil, "", fmt.Errorf("name must be at most 255 characters")
+	}
+	if len(input.Scopes) == 0 {
+		return nil, "", fmt.Errorf("at least one scope is required")
+	}
+	if input.ExpiresInDays < 0 {
+		return nil, "", fmt.Errorf("expires_in_days must not be negative")
+	}
+
+	scopes, err := narrowScopes(callerScopes, input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Widget keys are published in web pages, so they can't carry any other scope
+	origins := []string{}
+	if containsString(scopes, ScopeWidgetAsk) {
+		if len(scopes) > 1 {
+			return nil, "", fmt.Errorf("%s keys can't hold other scopes", ScopeWidgetAsk)
+		}
+		if len(input.AllowedOrigins) == 0 {
+			return nil, "", fmt.Errorf("allowed_origins is required for %s keys", ScopeWidgetAsk)
+		}
+		for _, raw := range input.AllowedOrigins {
+			origin, err := normalizeOrigin(raw)
+			if err != nil {
+				return nil, "", err
+			}
+			origins = append(origins, origin)
+		}
+	} else if len(input.AllowedOrigins) > 0 {
+		return nil, "", fmt.Errorf("allowed_origins only applies to %s keys", ScopeWidgetAsk)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	plaintext := APIKeyPrefix + hex.EncodeToString(secret)
+
+	key := &model.APIKey{
+		UserID:  userID,
+		Name:    name,
+		Prefix:  plaintext[:len(APIKeyPrefix)+8],
+		KeyHash: hashAPIKey(plaintext),
+		Scopes:  scopes,
+
+		AllowedOrigins: origins,
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, plaintext, nil
+}
+
+// List lists a user's API keys
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]*model.APIKey, error) {
+	return s.apiKeyRepo.ListByUserID(ctx, userID)
+}
+
+// Delete revokes an API key
+func (s *APIKeyService) Delete(ctx context.Context, userID, id string) error {
+	return s.apiKeyRepo.Delete(ctx, userID, id)
+}
+
+// Authenticate resolves a plaintext API key to its stored record
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext string) (*model.APIKey, error) {
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(plaintext))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return nil, fmt.Errorf("API key expired")
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID); err != nil {
+		logger.Warn("Failed to record API key usage", "key_id", key.ID, "error", err)
+	}
+
+	return key, nil
+}
+
+// IsWidgetKey reports whether an API key may only be used by the embeddable widget
+func IsWidgetKey(key *model.APIKey) bool {
+	return containsString(key.Scopes, ScopeWidgetAsk)
+}
+
+// AllowsOrigin reports whether a widget key may be used from the given origin
+func AllowsOrigin(key *model.APIKey, origin string) bool {
+	origin, err := normalizeOrigin(origin)
+	if err != nil {
+		return false
+	}
+	return containsString(key.AllowedOrigins, origin)
+}
+
+// normalizeOrigin reduces an origin or URL to its lowercase scheme://host[:port] form
+func normalizeOrigin(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid origin: %s", raw)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+// containsString reports whether list holds value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// hashAPIKey returns the hex SHA-256 of an API key
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Application statuses, in the order an application usually moves through them
+const (
+	ApplicationSaved        = "saved"
+	ApplicationApplied      = "applied"
+	ApplicationInterviewing = "interviewing"
+	ApplicationOffer        = "offer"
+	ApplicationRejected     = "rejected"
+	ApplicationWithdrawn    = "withdrawn"
+)
+
+// ApplicationStatuses lists the valid application statuses
+var ApplicationStatuses = []string{
+	ApplicationSaved, ApplicationApplied, ApplicationInterviewing, ApplicationOffer, ApplicationRejected, ApplicationWithdrawn,
+}
+
+// Cover letter grounding limits
+const (
+	coverLetterPostingChars  = 6000
+	coverLetterResumeChars   = 6000
+	coverLetterSupportChunks = 5
+)
+
+// ApplicationService tracks job applications and drafts cover letters from the user's documents
+type ApplicationService struct {
+	applicationRepo *repository.ApplicationRepository
+	documentRepo    *repository.DocumentRepository
+	chunkRepo       *repository.ChunkRepository
+	ragService      *RAGService
+}
+
+// NewApplicationService creates a new application service
+func NewApplicationService(
+	applicationRepo *repository.ApplicationRepository,
+	documentRepo *repository.DocumentRepository,
+	chunkRepo *repository.ChunkRepository,
+	ragService *RAGService,
+) *ApplicationService {
+	return &ApplicationService{
+		applicationRepo: applicationRepo,
+		documentRepo:    documentRepo,
+		chunkRepo:       chunkRepo,
+		ragService:      ragService,
+	}
+}
+
+// ApplicationInput represents the editable fields of an application
+type ApplicationInput struct {
+	PostingDocumentID string  `json:"posting_document_id"`
+	ResumeDocumentID  string  `json:"resume_document_id"`
+	Company           string  `json:"company"`
+	Role              string  `json:"role"`
+	Status            string  `json:"status"`
+	Notes             *string `json:"notes"`
+}
+
+// Create starts tracking an application for an ingested job posting. The role defaults to the
+// posting's filename and the status to "saved".
+func (s *ApplicationService) Create(ctx context.Context, userID string, input ApplicationInput) (*model.Application, error) {
+	posting, err := s.userDocument(ctx, userID, input.PostingDocumentID)
+	if err != nil {
+		return nil, fmt.Errorf("posting: %w", err)
+	}
+
+	a := &model.Application{
+		UserID:            userID,
+		PostingDocumentID: posting.ID,
+		Company:           strings.TrimSpace(input.Company),
+		Role:              strings.TrimSpace(input.Role),
+		Status:            input.Status,
+	}
+	if a.Role == "" {
+		a.Role = strings.TrimSuffix(posting.Filename, filepath.Ext(posting.Filename))
+	}
+	if a.Status == "" {
+		a.Status = ApplicationSaved
+	}
+	if input.Notes != nil {
+		a.Notes = *input.Notes
+	}
+	if input.ResumeDocumentID != "" {
+		resume, err := s.userDocument(ctx, userID, input.ResumeDocumentID)
+		if err != nil {
+			return nil, fmt.Errorf("resume: %w", err)
+		}
+		a.ResumeDocumentID = &resume.ID
+	}
+
+	if err := validateApplication(a); err != nil {
+		return nil, err
+	}
+	setAppliedAt(a)
+
+	if err := s.applicationRepo.Create(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// List lists a user's applications, optionally only those with the given status
+func (s *ApplicationService) List(ctx context.Context, userID, status string) ([]*model.Application, error) {
+	if status != "" && !isApplicationStatus(status) {
+		return nil, fmt.Errorf("status must be one of: %s", strings.Join(ApplicationStatuses, ", "))
+	}
+	return s.applicationRepo.ListByUserID(ctx, userID, status)
+}
+
+// Get gets a single application
+func (s *ApplicationService) Get(ctx context.Context, userID, id string) (*model.Application, error) {
+	return s.applicationRepo.GetByID(ctx, userID, id)
+}
+
+// Update updates an application; empty fields keep their current values. The posting can't be changed.
+func (s *ApplicationService) Update(ctx context.Context, userID, id string, input ApplicationInput) (*model.Application, error) {
+	a, err := s.applicationRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if company := strings.TrimSpace(input.Company); company != "" {
+		a.Company = company
+	}
+	if role := strings.TrimSpace(input.Role); role != "" {
+		a.Role = role
+	}
+	if input.Status != "" {
+		a.Status = input.Status
+	}
+	if input.Notes != nil {
+		a.Notes = *input.Notes
+	}
+	if input.ResumeDocumentID != "" {
+		resume, err := s.userDocument(ctx, userID, input.ResumeDocumentID)
+		if err != nil {
+			return nil, fmt.Errorf("resume: %w", err)
+		}
+		a.ResumeDocumentID = &resume.ID
+	}
+
+	if err := validateApplication(a); err != nil {
+		return nil, err
+	}
+	setAppliedAt(a)
+
+	if err := s.applicationRepo.Update(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Delete deletes an application
+func (s *ApplicationService) Delete(ctx context.Context, userID, id string) error {
+	return s.applicationRepo.Delete(ctx, userID, id)
+}
+
+// coverLetterSystemPrompt keeps the draft grounded in the user's own documents
+const coverLetterSystemPrompt = `You draft cover letters for the user.
+
+Rules:
+1. Tailor the letter to the job posting: address its key requirements in order of importance
+2. Use ONLY experience, skills and achievements found in the resume and supporting documents; never invent employers, dates, numbers or qualifications
+3. Where the documents don't show a requirement, leave it out rather than claim it
+4. Keep it under 400 words, in a professional but personal tone, with no placeholders except [Your Name] for the signature`
+
+// GenerateCoverLetter drafts a cover letter for the application from the posting, the linked resume
+// and the most relevant chunks of the user's other documents, and saves it on the application
+func (s *ApplicationService) GenerateCoverLetter(ctx context.Context, userID, id string) (*model.Application, error) {
+	a, err := s.applicationRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	posting, err := s.chunkRepo.GetDocumentText(ctx, a.PostingDocumentID, coverLetterPostingChars)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(posting) == "" {
+		return nil, fmt.Errorf("job posting has no text")
+	}
+
+	sourceIDs := []string{a.PostingDocumentID}
+	resume := ""
+	if a.ResumeDocumentID != nil {
+		if resume, err = s.chunkRepo.GetDocumentText(ctx, *a.ResumeDocumentID, coverLetterResumeChars); err != nil {
+			return nil, err
+		}
+		sourceIDs = append(sourceIDs, *a.ResumeDocumentID)
+	}
+
+	// Other documents (project notes, performance reviews, certificates) can back up the resume
+	supporting, _, err := s.ragService.retrieve(ctx, userID, a.Role+" "+a.Company+"\n"+truncate(posting, 1000), RetrievalOptions{
+		Filter: &repository.SearchFilter{ExcludeDocumentIDs: sourceIDs},
+		TopK:   coverLetterSupportChunks,
+	})
+	if err != nil {
+		// The posting and resume are enough for a draft
+		logger.Error("Failed to retrieve supporting documents", "application_id", a.ID, "error", err)
+	}
+	if resume == "" && len(supporting) == 0 {
+		return nil, fmt.Errorf("link a resume or upload documents describing your experience first")
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Role: %s\n", a.Role)
+	if a.Company != "" {
+		fmt.Fprintf(&prompt, "Company: %s\n", a.Company)
+	}
+	fmt.Fprintf(&prompt, "\nJob posting:\n%s\n", posting)
+	if resume != "" {
+		fmt.Fprintf(&prompt, "\nResume:\n%s\n", resume)
+	}
+	if len(supporting) > 0 {
+		fmt.Fprintf(&prompt, "\nSupporting documents:\n%s\n", buildContextText(supporting))
+	}
+	prompt.WriteString("\nWrite the cover letter:")
+
+	letter, err := s.ragService.callLLM(ctx, coverLetterSystemPrompt, prompt.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate cover letter: %w", err)
+	}
+
+	a.CoverLetter = strings.TrimSpace(letter)
+	if err := s.applicationRepo.Update(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// userDocument gets a document and checks that it belongs to the user
+func (s *ApplicationService) userDocument(ctx context.Context, userID, documentID string) (*model.Document, error) {
+	if documentID == "" {
+		return nil, fmt.Errorf("document ID is required")
+	}
+	doc, err := s.documentRepo.GetByID(ctx, documentID)
+	if err != nil || doc.UserID != userID {
+		return nil, fmt.Errorf("document %w", apperror.ErrNotFound)
+	}
+	return doc, nil
+}
+
+// validateApplication validates an application's fields
+func validateApplication(a *model.Application) error {
+	if a.Role == "" {
+		return fmt.Errorf("role is required")
+	}
+	if len(a.Role) > 255 || len(a.Company) > 255 {
+		return fmt.Errorf("role and company must be at most 255 characters")
+	}
+	if !isApplicationStatus(a.Status) {
+		return fmt.Errorf("status must be one of: %s", strings.Join(ApplicationStatuses, ", "))
+	}
+	return nil
+}
+
+func isApplicationStatus(status string) bool {
+	for _, known := range ApplicationStatuses {
+		if status == known {
+			return true
+		}
+	}
+	return false
+}
+
+// setAppliedAt records when an application first moves past "saved"
+func setAppliedAt(a *model.Application) {
+	if a.AppliedAt == nil && a.Status != ApplicationSaved {
+		now := time.Now()
+		a.AppliedAt = &now
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/notification"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Audited actions
+const (
+	AuditActionLogin            = "auth.login"
+	AuditActionPasswordReset    = "auth.password_reset"
+	AuditActionDocumentDownload = "document.download"
+	AuditActionCanaryAccess     = "document.canary_access"
+	AuditActionAccountExport    = "account.export"
+)
+
+// Anomaly flag reasons
+const (
+	FlagBulkDownload = "bulk_download"
+	FlagExportAll    = "export_all_documents"
+	FlagNewCountry   = "new_country"
+	FlagCanary       = "canary_triggered"
+)
+
+// EventSecurityAnomaly is the notification event sent when account activity is flagged
+const EventSecurityAnomaly = "security.anomaly"
+
+// Anomaly detection thresholds
+const (
+	// bulkDownloadWindow and bulkDownloadThreshold flag many downloads in a short time
+	bulkDownloadWindow    = 10 * time.Minute
+	bulkDownloadThreshold = 20
+	// exportWindow flags downloading every document within an hour
+	exportWindow = time.Hour
+	// exportMinDocuments avoids flagging users with only a handful of documents
+	exportMinDocuments = 5
+)
+
+// AuditService records account activity and flags unusual patterns
+type AuditService struct {
+	auditRepo    *repository.AuditRepository
+	documentRepo *repository.DocumentRepository
+	notifier     notification.Notifier
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(
+	auditRepo *repository.AuditRepository,
+	documentRepo *repository.DocumentRepository,
+	notifier notification.Notifier,
+) *AuditService {
+	return &AuditService{
+		auditRepo:    auditRepo,
+		documentRepo: documentRepo,
+		notifier:     notifier,
+	}
+}
+
+// AuditPage is a page of audit events with the total count
+type AuditPage struct {
+	Items  []*model.AuditEvent `json:"items"`
+	Total  int                 `json:"total"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
+}
+
+// Record stores an audit event, flagging it and notifying the user when it looks anomalous.
+// Detection failures are logged and never prevent the event from being recorded.
+func (s *AuditService) Record(ctx context.Context, event *model.AuditEvent) error {
+	reason, err := s.detect(ctx, event)
+	if err != nil {
+		logger.Error("Anomaly detection failed", "user_id", event.UserID, "action", event.Action, "error", err)
+	}
+	if reason != "" {
+		event.Flagged = true
+		event.FlagReason = reason
+	}
+
+	if err := s.auditRepo.Create(ctx, event); err != nil {
+		return err
+	}
+
+	if event.Flagged {
+		s.notify(ctx, event)
+	}
+
+	return nil
+}
+
+// RecordCanaryAccess records and alerts on access to a canary document.
+// via describes how it was accessed ("retrieval" or "download").
+func (s *AuditService) RecordCanaryAccess(ctx context.Context, event *model.AuditEvent, filename, via string) error {
+	event.Action = AuditActionCanaryAccess
+	if event.Metadata == nil {
+		event.Metadata = make(map[string]interface{})
+	}
+	event.Metadata["filename"] = filename
+	event.Metadata["via"] = via
+
+	return s.Record(ctx, event)
+}
+
+// ListFlagged lists flagged events across all users for the admin view
+func (s *AuditService) ListFlagged(ctx context.Context, limit, offset int) (*AuditPage, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	events, total, err := s.auditRepo.ListFlagged(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditPage{Items: events, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// detect returns the flag reason for an event about to be recorded, or "" when it looks normal
+func (s *AuditService) detect(ctx context.Context, event *model.AuditEvent) (string, error) {
+	switch event.Action {
+	case AuditActionCanaryAccess:
+		// Canary access is always suspicious and alerts every time
+		return FlagCanary, nil
+	case AuditActionLogin:
+		return s.detectNewCountry(ctx, event)
+	case AuditActionDocumentDownload:
+		if reason, err := s.detectExportAll(ctx, event); reason != "" || err != nil {
+			return reason, err
+		}
+		return s.detectBulkDownload(ctx, event)
+	case AuditActionAccountExport:
+		return s.detectAccountExport(ctx, event)
+	}
+	return "", nil
+}
+
+// detectNewCountry flags a login from a country the user has never logged in from
+func (s *AuditService) detectNewCountry(ctx context.Context, event *model.AuditEvent) (string, error) {
+	if event.Country == "" {
+		return "", nil
+	}
+
+	hasHistory, seen, err := s.auditRepo.CountryHistory(ctx, event.UserID, AuditActionLogin, event.Country)
+	if err != nil {
+		return "", err
+	}
+
+	// The first login with a known country establishes the baseline
+	if hasHistory && !seen {
+		return FlagNewCountry, nil
+	}
+	return "", nil
+}
+
+// detectBulkDownload flags the download that crosses the bulk threshold, once per window
+func (s *AuditService) detectBulkDownload(ctx context.Context, event *model.AuditEvent) (string, error) {
+	since := time.Now().Add(-bulkDownloadWindow)
+
+	count, err := s.auditRepo.CountActions(ctx, event.UserID, AuditActionDocumentDownload, since)
+	if err != nil {
+		return "", err
+	}
+	if count+1 < bulkDownloadThreshold {
+		return "", nil
+	}
+
+	return s.flagOnce(ctx, event.UserID, FlagBulkDownload, since)
+}
+
+// detectExportAll flags the download that completes a copy of every document, once per window
+func (s *AuditService) detectExportAll(ctx context.Context, event *model.AuditEvent) (string, error) {
+	if event.ResourceID == "" {
+		return "", nil
+	}
+	since := time.Now().Add(-exportWindow)
+
+	total, err := s.documentRepo.CountByUserID(ctx, event.UserID)
+	if err != nil {
+		return "", err
+	}
+	if total < exportMinDocuments {
+		return "", nil
+	}
+
+	downloaded, err := s.auditRepo.CountDistinctResources(ctx, event.UserID, AuditActionDocumentDownload, since)
+	if err != nil {
+		return "", err
+	}
+	repeat, err := s.auditRepo.HasResourceAction(ctx, event.UserID, AuditActionDocumentDownload, event.ResourceID, since)
+	if err != nil {
+		return "", err
+	}
+	if !repeat {
+		downloaded++
+	}
+	if downloaded < total {
+		return "", nil
+	}
+
+	return s.flagOnce(ctx, event.UserID, FlagExportAll, since)
+}
+
+// detectAccountExport flags an account export, which copies every document at once, once per
+// window
+func (s *AuditService) detectAccountExport(ctx context.Context, event *model.AuditEvent) (string, error) {
+	total, err := s.documentRepo.CountByUserID(ctx, event.UserID)
+	if err != nil {
+		return "", err
+	}
+	if total < exportMinDocuments {
+		return "", nil
+	}
+
+	return s.flagOnce(ctx, event.UserID, FlagExportAll, time.Now().Add(-exportWindow))
+}
+
+// flagOnce returns reason unless the user was already flagged for it since the given time
+func (s *AuditService) flagOnce(ctx context.Context, userID, reason string, since time.Time) (string, error) {
+	flagged, err := s.auditRepo.HasFlag(ctx, userID, reason, since)
+	if err != nil || flagged {
+		return "", err
+	}
+	return reason, nil
+}
+
+// notify alerts the user about a flagged event
+func (s *AuditService) notify(ctx context.Context, event *model.AuditEvent) {
+	var body string
+	switch event.FlagReason {
+	case FlagNewCountry:
+		body = fmt.Sprintf("Your account was signed in to from a new country (%s). If this wasn't you, change your password and revoke your API keys.", event.Country)
+	case FlagBulkDownload:
+		body = fmt.Sprintf("%d or more documents were downloaded from your account in the last %d minutes.", bulkDownloadThreshold, int(bulkDownloadWindow.Minutes()))
+	case FlagExportAll:
+		body = "Every document in your knowledge base was downloaded within the last hour."
+		if event.Action == AuditActionAccountExport {
+			body = "Your account data, with every document in your knowledge base, was exported."
+		}
+	case FlagCanary:
+		body = fmt.Sprintf("Canary document %q was accessed via %v. Your credentials may be compromised; change your password and revoke your API keys.",
+			event.Metadata["filename"], event.Metadata["via"])
+	default:
+		body = "Unusual activity was detected on your account."
+	}
+
+	err := s.notifier.Notify(ctx, notification.Notification{
+		UserID: event.UserID,
+		Event:  EventSecurityAnomaly,
+		Title:  "Unusual account activity",
+		Body:   body,
+		Data: map[string]interface{}{
+			"audit_event_id": event.ID,
+			"reason":         event.FlagReason,
+			"ip_address":     event.IPAddress,
+			"country":        event.Country,
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to send anomaly notification", "user_id", event.UserID, "error", err)
+	}
+}
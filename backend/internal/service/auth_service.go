@@ -2,9 +2,16 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/mailer"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
@@ -14,20 +21,42 @@ import (
 type AuthService struct {
 	userRepo  *repository.UserRepository
 	jwtSecret string
+	mailer    *mailer.Mailer
+	appURL    string
 }
 
-// NewAuthService creates a new auth service
-func NewAuthService(userRepo *repository.UserRepository, jwtSecret string) *AuthService {
+// NewAuthService creates a new auth service. mailer may be nil, which disables verification
+// and password reset emails; appURL is the frontend the emailed links point to.
+func NewAuthService(userRepo *repository.UserRepository, jwtSecret string, m *mailer.Mailer, appURL string) *AuthService {
 	return &AuthService{
 		userRepo:  userRepo,
 		jwtSecret: jwtSecret,
+		mailer:    m,
+		appURL:    strings.TrimRight(appURL, "/"),
 	}
 }
 
+// Email token purposes
+const (
+	emailTokenVerify = "verify_email"
+	emailTokenReset  = "password_reset"
+)
+
+// Emailed links expire after these durations
+const (
+	verifyEmailTTL   = 24 * time.Hour
+	passwordResetTTL = time.Hour
+)
+
+// ErrEmailDisabled is returned when an email flow is used without a mail driver configured
+var ErrEmailDisabled = errors.New("email is not configured")
+
 // Claims represents JWT claims
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	// Scopes limits what the token may access; tokens issued without scopes get UserScopes
+	Scopes []string `json:"scopes,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -45,36 +74,163 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (*mo
 		return nil, err
 	}
 
+	// Registration succeeds even if the email can't be sent; it can be resent later
+	if s.mailer != nil {
+		if err := s.sendVerificationEmail(ctx, user); err != nil {
+			logger.Error("Failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}
+
 	return user, nil
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
+// SendVerificationEmail (re)sends the email address verification link to a user
+func (s *AuthService) SendVerificationEmail(ctx context.Context, userID string) error {
+	if s.mailer == nil {
+		return ErrEmailDisabled
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return fmt.Errorf("email is already verified")
+	}
+
+	return s.sendVerificationEmail(ctx, user)
+}
+
+func (s *AuthService) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	link, err := s.createEmailLink(ctx, user.ID, emailTokenVerify, verifyEmailTTL, "/verify-email")
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, user.ID, user.Email, mailer.TemplateVerifyEmail, mailer.LinkData{
+		Link:      link,
+		ExpiresIn: "24 hours",
+	})
+}
+
+// VerifyEmail marks the email address of the token's user as verified
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := s.userRepo.ConsumeEmailToken(ctx, emailTokenVerify, hashEmailToken(token), time.Now())
+	if err != nil {
+		return err
+	}
+
+	return s.userRepo.MarkEmailVerified(ctx, userID)
+}
+
+// RequestPasswordReset emails a password reset link. Unknown addresses are ignored without an
+// error so the endpoint can't be used to discover accounts.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	if s.mailer == nil {
+		return ErrEmailDisabled
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil
+	}
+
+	link, err := s.createEmailLink(ctx, user.ID, emailTokenReset, passwordResetTTL, "/reset-password")
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, user.ID, user.Email, mailer.TemplatePasswordReset, mailer.LinkData{
+		Link:      link,
+		ExpiresIn: "1 hour",
+	})
+}
+
+// ResetPassword sets a new password for the token's user and returns the user's ID. Receiving
+// the reset email proves the address, so it is marked verified as well.
+func (s *AuthService) ResetPassword(ctx context.Context, token, password string) (string, error) {
+	if len(password) < 8 {
+		return "", fmt.Errorf("password must be at least 8 characters")
+	}
+
+	userID, err := s.userRepo.ConsumeEmailToken(ctx, emailTokenReset, hashEmailToken(token), time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userID, password); err != nil {
+		return "", err
+	}
+	if err := s.userRepo.MarkEmailVerified(ctx, userID); err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+// createEmailLink stores a new single-use token and returns the frontend link carrying it
+func (s *AuthService) createEmailLink(ctx context.Context, userID, purpose string, ttl time.Duration, path string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := s.userRepo.CreateEmailToken(ctx, userID, purpose, hashEmailToken(token), time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+
+	return s.appURL + path + "?token=" + token, nil
+}
+
+// hashEmailToken returns the hex SHA-256 of an emailed token
+func hashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Login authenticates a user and returns a JWT token with the user.
+// requestedScopes narrows the token; when empty it carries every scope the user holds.
+func (s *AuthService) Login(ctx context.Context, email, password string, requestedScopes []string) (string, *model.User, error) {
 	// Get user
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return "", fmt.Errorf("invalid credentials")
+		return "", nil, fmt.Errorf("invalid credentials")
 	}
 
 	// Verify password
 	if err := s.userRepo.VerifyPassword(user.PasswordHash, password); err != nil {
-		return "", fmt.Errorf("invalid credentials")
+		return "", nil, fmt.Errorf("invalid credentials")
+	}
+
+	scopes, err := narrowScopes(GrantedScopes(user), requestedScopes)
+	if err != nil {
+		return "", nil, err
 	}
 
 	// Generate token
-	token, err := s.GenerateToken(user.ID, user.Email)
+	token, err := s.GenerateToken(user.ID, user.Email, scopes)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return token, nil
+	return token, user, nil
+}
+
+// GrantedScopes returns every scope a user may hold
+func GrantedScopes(user *model.User) []string {
+	if user.IsAdmin {
+		return append([]string{ScopeAdmin}, UserScopes...)
+	}
+	return UserScopes
 }
 
-// GenerateToken generates a JWT token for a user
-func (s *AuthService) GenerateToken(userID, email string) (string, error) {
+// GenerateToken generates a JWT token for a user with the given scopes
+func (s *AuthService) GenerateToken(userID, email string, scopes []string) (string, error) {
 	claims := &Claims{
 		UserID: userID,
 		Email:  email,
+		Scopes: scopes,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
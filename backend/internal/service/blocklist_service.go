@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// maxBlocklistPatternChars caps the length of a blocklist phrase or regex
+const maxBlocklistPatternChars = 500
+
+// BlocklistService manages a user's retrieval blocklist: phrases and regexes whose matching
+// chunks are never put in prompts, such as passwords accidentally left in notes
+type BlocklistService struct {
+	blocklistRepo *repository.BlocklistRepository
+}
+
+// NewBlocklistService creates a new blocklist service
+func NewBlocklistService(blocklistRepo *repository.BlocklistRepository) *BlocklistService {
+	return &BlocklistService{blocklistRepo: blocklistRepo}
+}
+
+// BlocklistRuleInput represents a new blocklist rule
+type BlocklistRuleInput struct {
+	Pattern string `json:"pattern"`
+	// Regex treats the pattern as a Go regular expression; otherwise it is a phrase matched
+	// ignoring case
+	Regex bool `json:"regex"`
+}
+
+// Create adds a rule to the user's blocklist
+func (s *BlocklistService) Create(ctx context.Context, userID string, input BlocklistRuleInput) (*model.BlocklistRule, error) {
+	pattern := strings.TrimSpace(input.Pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	if len(pattern) > maxBlocklistPatternChars {
+		return nil, fmt.Errorf("pattern must be at most %d characters", maxBlocklistPatternChars)
+	}
+	if input.Regex {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+
+	rule := &model.BlocklistRule{
+		UserID:  userID,
+		Pattern: pattern,
+		Regex:   input.Regex,
+	}
+	if err := s.blocklistRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// List lists the user's blocklist rules with how often each blocked a chunk
+func (s *BlocklistService) List(ctx context.Context, userID string) ([]*model.BlocklistRule, error) {
+	return s.blocklistRepo.ListByUserID(ctx, userID)
+}
+
+// Delete removes a rule from the user's blocklist
+func (s *BlocklistService) Delete(ctx context.Context, userID, ruleID string) error {
+	return s.blocklistRepo.Delete(ctx, userID, ruleID)
+}
+
+// blocklistRule is a blocklist rule ready for matching
+type blocklistRule struct {
+	id string
+	// phrase is the lowercased phrase of a phrase rule
+	phrase string
+	regex  *regexp.Regexp
+}
+
+// compileBlocklist prepares rules for matching. Regexes were validated when the rules were
+// created, so one that no longer compiles is skipped with a warning.
+func compileBlocklist(rules []*model.BlocklistRule) []blocklistRule {
+	compiled := make([]blocklistRule, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.Regex {
+			compiled = append(compiled, blocklistRule{id: rule.ID, phrase: strings.ToLower(rule.Pattern)})
+			continue
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			logger.Warn("Skipping invalid blocklist regex", "rule_id", rule.ID, "error", err)
+			continue
+		}
+		compiled = append(compiled, blocklistRule{id: rule.ID, regex: re})
+	}
+	return compiled
+}
+
+// matchBlocklist returns the ID of the first rule matching the text, or ""
+func matchBlocklist(rules []blocklistRule, text string) string {
+	lower := strings.ToLower(text)
+	for _, rule := range rules {
+		if rule.regex != nil {
+			if rule.regex.MatchString(text) {
+				return rule.id
+			}
+		} else if strings.Contains(lower, rule.phrase) {
+			return rule.id
+		}
+	}
+	return ""
+}
+
+// applyBlocklist drops retrieved chunks matching the user's blocklist. Each blocked chunk is
+// logged (without its text) and counted on the rule. If the blocklist can't be loaded nothing is
+// returned, as an unanswered question is better than a blocked chunk in a prompt.
+func (s *RAGService) applyBlocklist(ctx context.Context, userID string, results []*model.VectorPoint) []*model.VectorPoint {
+	if s.blocklistRepo == nil || len(results) == 0 {
+		return results
+	}
+
+	rules, err := s.blocklistRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to load retrieval blocklist; dropping results", "user_id", userID, "error", err)
+		return nil
+	}
+	if len(rules) == 0 {
+		return results
+	}
+	compiled := compileBlocklist(rules)
+
+	kept := results[:0]
+	matches := make(map[string]int)
+	for _, result := range results {
+		content, _ := result.Payload["content"].(string)
+		ruleID := matchBlocklist(compiled, content)
+		if ruleID == "" {
+			kept = append(kept, result)
+			continue
+		}
+		matches[ruleID]++
+		documentID, _ := result.Payload["document_id"].(string)
+		logger.Info("Blocked chunk from retrieval", "user_id", userID, "rule_id", ruleID, "document_id", documentID, "chunk_id", result.ID)
+	}
+
+	if len(matches) > 0 {
+		if err := s.blocklistRepo.RecordMatches(ctx, matches); err != nil {
+			logger.Error("Failed to record blocklist matches", "user_id", userID, "error", err)
+		}
+	}
+
+	return kept
+}
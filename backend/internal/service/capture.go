@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// Captured note limits
+const (
+	MaxCaptureLength      = 100000
+	maxCaptureTitleLength = 100
+)
+
+// CaptureNote saves a piece of text (a note, a shared web page, dictation) as a Markdown
+// document. The title names the file; an empty title uses the capture time.
+func (s *DocumentService) CaptureNote(ctx context.Context, userID, title, text string) (*model.Document, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+	if len(text) > MaxCaptureLength {
+		return nil, fmt.Errorf("text must be at most %d characters", MaxCaptureLength)
+	}
+
+	title = captureTitle(title)
+	content := text
+	if title == "" {
+		title = "Note " + time.Now().UTC().Format("2006-01-02 15.04.05")
+	} else {
+		content = "# " + title + "\n\n" + text
+	}
+
+	return s.ingestUpload(ctx, userID, title+".md", strings.NewReader(content), int64(len(content)), "", ChunkingOptions{}, EmbeddingSourceShortcuts)
+}
+
+// captureTitle makes a title safe to use as a filename: a single line without path separators
+func captureTitle(title string) string {
+	title = strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\':
+			return '-'
+		case unicode.IsControl(r):
+			return ' '
+		}
+		return r
+	}, title)
+	title = strings.Join(strings.Fields(title), " ")
+	title = strings.TrimLeft(title, ".")
+	return strings.TrimSpace(truncate(title, maxCaptureTitleLength))
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// CollectionService moves users' vector collections to a new naming scheme without downtime.
+// Collections are renamed through the registry and Qdrant aliases, so no vectors are copied and
+// the previous names keep working until they are dropped.
+type CollectionService struct {
+	userRepo   *repository.UserRepository
+	vectorRepo *repository.VectorRepository
+	lockRepo   *repository.LockRepository
+}
+
+// NewCollectionService creates a collection naming service
+func NewCollectionService(
+	userRepo *repository.UserRepository,
+	vectorRepo *repository.VectorRepository,
+	lockRepo *repository.LockRepository,
+) *CollectionService {
+	return &CollectionService{
+		userRepo:   userRepo,
+		vectorRepo: vectorRepo,
+		lockRepo:   lockRepo,
+	}
+}
+
+// CollectionMigrationOptions controls a collection naming run
+type CollectionMigrationOptions struct {
+	// UserID limits the run to one user; empty covers every user
+	UserID string
+	// Scopes limits the run to these collection scopes; empty covers every scope
+	Scopes []string
+	// Scheme is the naming scheme to rename collections to, e.g. "rag_{scope}_{user}"; empty
+	// renames nothing
+	Scheme string
+	// DropPrevious removes the aliases left under previous names by an earlier run, once every
+	// server has picked up the new names
+	DropPrevious bool
+}
+
+// CollectionMigrationResult reports the run for one user's collection
+type CollectionMigrationResult struct {
+	UserID string
+	Scope  string
+	// Name is the collection's name after the run and Previous the one before, if renamed
+	Name     string
+	Previous string
+	Renamed  bool
+	Dropped  bool
+}
+
+// Migrate renames every user's collections (or one user's) to the naming scheme and/or drops
+// their previous names
+func (s *CollectionService) Migrate(ctx context.Context, opts CollectionMigrationOptions) ([]*CollectionMigrationResult, error) {
+	scopes := opts.Scopes
+	if len(scopes) == 0 {
+		scopes = repository.CollectionScopes
+	}
+	if err := validateCollectionScheme(opts.Scheme, scopes); err != nil {
+		return nil, err
+	}
+
+	lock, err := s.lockRepo.TryAcquire(ctx, "collection_migration")
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, fmt.Errorf("another collection naming run is in progress")
+	}
+	defer lock.Release()
+
+	userIDs := []string{opts.UserID}
+	if opts.UserID == "" {
+		if userIDs, err = s.userRepo.ListIDs(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []*CollectionMigrationResult
+	for _, userID := range userIDs {
+		for _, scope := range scopes {
+			result, err := s.migrateCollection(ctx, userID, scope, opts)
+			if err != nil {
+				return results, fmt.Errorf("failed to migrate %s collection of user %s: %w", scope, userID, err)
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// migrateCollection renames one of a user's collections and/or drops its previous name
+func (s *CollectionService) migrateCollection(ctx context.Context, userID, scope string, opts CollectionMigrationOptions) (*CollectionMigrationResult, error) {
+	current, err := s.vectorRepo.CollectionName(ctx, userID, scope)
+	if err != nil {
+		return nil, err
+	}
+	result := &CollectionMigrationResult{UserID: userID, Scope: scope, Name: current}
+
+	if opts.DropPrevious {
+		if err := s.vectorRepo.DropPreviousName(ctx, userID, scope); err != nil {
+			return nil, err
+		}
+		result.Dropped = true
+	}
+
+	if opts.Scheme == "" {
+		return result, nil
+	}
+	name := repository.CollectionNameFromScheme(opts.Scheme, userID, scope)
+	if name == current {
+		return result, nil
+	}
+	if err := s.vectorRepo.RenameCollection(ctx, userID, scope, name); err != nil {
+		return nil, err
+	}
+	result.Name, result.Previous, result.Renamed = name, current, true
+	logger.Info("Renamed vector collection", "user_id", userID, "scope", scope, "name", name, "previous", current)
+
+	return result, nil
+}
+
+// validateCollectionScheme checks that a naming scheme gives each collection its own name
+func validateCollectionScheme(scheme string, scopes []string) error {
+	for _, scope := range scopes {
+		valid := false
+		for _, known := range repository.CollectionScopes {
+			valid = valid || scope == known
+		}
+		if !valid {
+			return fmt.Errorf("unknown collection scope %q (expected one of: %s)", scope, strings.Join(repository.CollectionScopes, ", "))
+		}
+	}
+
+	if scheme == "" {
+		return nil
+	}
+	if !strings.Contains(scheme, "{user}") {
+		return fmt.Errorf("collection naming scheme must contain {user}")
+	}
+	if len(scopes) > 1 && !strings.Contains(scheme, "{scope}") {
+		return fmt.Errorf("collection naming scheme must contain {scope} when renaming more than one scope")
+	}
+	return nil
+}
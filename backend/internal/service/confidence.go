@@ -0,0 +1,155 @@
+package service
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// Confidence levels
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Confidence signal weights; signals that are unavailable are left out and the rest renormalized
+const (
+	retrievalWeight = 0.4
+	coverageWeight  = 0.3
+	logprobWeight   = 0.3
+)
+
+// Confidence estimates how well an answer is supported by the retrieved documents
+type Confidence struct {
+	// Score is the weighted combination of the available signals, from 0 to 1
+	Score float64 `json:"score"`
+	// Level buckets Score for display: high (>= 0.75), medium (>= 0.5) or low
+	Level string `json:"level"`
+	// Retrieval is the mean similarity of the top retrieved chunks; omitted in degraded mode
+	Retrieval *float64 `json:"retrieval,omitempty"`
+	// Coverage is the fraction of the question's terms found in the retrieved chunks
+	Coverage float64 `json:"coverage"`
+	// Logprob is the geometric-mean token probability of the answer, when the model returns logprobs
+	Logprob *float64 `json:"logprob,omitempty"`
+}
+
+// confidenceTopK is how many of the best chunks the retrieval signal averages
+const confidenceTopK = 3
+
+// computeConfidence scores an answer from its retrieval results, question term coverage and
+// token logprobs. Keyword (degraded) scores are not similarities, so they are not used.
+func computeConfidence(question string, results []*model.VectorPoint, degraded bool, logprobs []float64) *Confidence {
+	c := &Confidence{Coverage: termCoverage(question, results)}
+	score := coverageWeight * c.Coverage
+	weight := coverageWeight
+
+	if !degraded && len(results) > 0 {
+		n := len(results)
+		if n > confidenceTopK {
+			n = confidenceTopK
+		}
+		var sum float64
+		for _, result := range results[:n] {
+			sum += clamp01(float64(result.Score))
+		}
+		retrieval := sum / float64(n)
+		c.Retrieval = &retrieval
+		score += retrievalWeight * retrieval
+		weight += retrievalWeight
+	}
+
+	if len(logprobs) > 0 {
+		var sum float64
+		for _, lp := range logprobs {
+			sum += lp
+		}
+		logprob := math.Exp(sum / float64(len(logprobs)))
+		c.Logprob = &logprob
+		score += logprobWeight * logprob
+		weight += logprobWeight
+	}
+
+	c.Score = score / weight
+	switch {
+	case c.Score >= 0.75:
+		c.Level = ConfidenceHigh
+	case c.Score >= 0.5:
+		c.Level = ConfidenceMedium
+	default:
+		c.Level = ConfidenceLow
+	}
+
+	return c
+}
+
+// termCoverage returns the fraction of the question's significant terms that appear in the retrieved chunks
+func termCoverage(question string, results []*model.VectorPoint) float64 {
+	terms := significantTerms(question)
+	if len(terms) == 0 {
+		return 0
+	}
+
+	var text strings.Builder
+	for _, result := range results {
+		if content, ok := result.Payload["content"].(string); ok {
+			text.WriteString(strings.ToLower(content))
+			text.WriteByte(' ')
+		}
+	}
+	corpus := text.String()
+
+	found := 0
+	for _, term := range terms {
+		if strings.Contains(corpus, term) {
+			found++
+		}
+	}
+
+	return float64(found) / float64(len(terms))
+}
+
+// questionStopwords are common words that say nothing about whether the context answers the question
+var questionStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "were": true,
+	"what": true, "when": true, "where": true, "which": true, "who": true, "whom": true,
+	"why": true, "how": true, "does": true, "did": true, "can": true, "could": true,
+	"should": true, "would": true, "with": true, "from": true, "about": true, "into": true,
+	"this": true, "that": true, "these": true, "those": true, "there": true, "their": true,
+	"have": true, "has": true, "had": true, "you": true, "your": true, "our": true,
+	"any": true, "all": true, "not": true, "but": true, "its": true, "tell": true,
+	"please": true, "give": true, "list": true, "show": true, "explain": true,
+}
+
+// significantTerms lowercases the question and keeps distinct words of three or more letters that are not stopwords
+func significantTerms(question string) []string {
+	words := strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range words {
+		if len([]rune(word)) < 3 || questionStopwords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// supportsLogprobs reports whether the chat model accepts the logprobs option;
+// reasoning models reject it, so it is only requested from GPT-3.5 and GPT-4 family models
+func supportsLogprobs(chatModel string) bool {
+	if chatModel == "" {
+		chatModel = defaultChatModel
+	}
+	return strings.HasPrefix(chatModel, "gpt-3.5") || strings.HasPrefix(chatModel, "gpt-4")
+}
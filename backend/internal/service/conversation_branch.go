@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// ConversationBranch is a path through a conversation's messages, from its first message to one
+// that no message follows
+type ConversationBranch struct {
+	// LeafID is the branch's last message; switching to it shows the branch
+	LeafID     string   `json:"leaf_id"`
+	MessageIDs []string `json:"message_ids"`
+	// Question is the last question asked on the branch
+	Question  string    `json:"question"`
+	Active    bool      `json:"active"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// messageTree indexes a conversation's messages by ID and by the message they follow
+type messageTree struct {
+	byID map[string]*model.QueryHistory
+	// children lists the messages following each message, oldest first; "" holds the first
+	// messages of the conversation
+	children map[string][]*model.QueryHistory
+	// messages are all the messages, oldest first
+	messages []*model.QueryHistory
+}
+
+// newMessageTree indexes messages listed in chronological order
+func newMessageTree(messages []*model.QueryHistory) *messageTree {
+	tree := &messageTree{
+		byID:     make(map[string]*model.QueryHistory, len(messages)),
+		children: make(map[string][]*model.QueryHistory),
+		messages: messages,
+	}
+	for _, message := range messages {
+		tree.byID[message.ID] = message
+	}
+	for _, message := range messages {
+		parentID := message.ParentID
+		if _, ok := tree.byID[parentID]; !ok {
+			parentID = ""
+		}
+		tree.children[parentID] = append(tree.children[parentID], message)
+	}
+	return tree
+}
+
+// activeLeaf returns the last message of the branch the conversation shows: its active message,
+// or the latest message when none is set
+func (t *messageTree) activeLeaf(conv *model.Conversation) string {
+	if _, ok := t.byID[conv.ActiveMessageID]; ok {
+		return conv.ActiveMessageID
+	}
+	if len(t.messages) == 0 {
+		return ""
+	}
+	return t.messages[len(t.messages)-1].ID
+}
+
+// latestLeaf follows the most recent message after each message from the given one, returning
+// the last message of the branch last continued through it
+func (t *messageTree) latestLeaf(messageID string) string {
+	for range t.messages {
+		children := t.children[messageID]
+		if len(children) == 0 {
+			break
+		}
+		messageID = children[len(children)-1].ID
+	}
+	return messageID
+}
+
+// path returns the messages from the conversation's first message to the given one
+func (t *messageTree) path(leafID string) []*model.QueryHistory {
+	var path []*model.QueryHistory
+	for message, ok := t.byID[leafID]; ok && len(path) < len(t.messages); message, ok = t.byID[message.ParentID] {
+		path = append(path, message)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// versions returns, for each message on the path with siblings, the IDs of every message
+// sharing its parent, oldest first
+func (t *messageTree) versions(path []*model.QueryHistory) map[string][]string {
+	versions := make(map[string][]string)
+	for _, message := range path {
+		parentID := message.ParentID
+		if _, ok := t.byID[parentID]; !ok {
+			parentID = ""
+		}
+		siblings := t.children[parentID]
+		if len(siblings) < 2 {
+			continue
+		}
+		ids := make([]string, len(siblings))
+		for i, sibling := range siblings {
+			ids[i] = sibling.ID
+		}
+		versions[message.ID] = ids
+	}
+	return versions
+}
+
+// Branches lists a conversation's branches, the one last continued first
+func (s *ConversationService) Branches(ctx context.Context, userID, conversationID string) ([]ConversationBranch, error) {
+	conv, err := s.conversationRepo.GetByID(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.conversationRepo.ListMessages(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := newMessageTree(messages)
+	active := tree.activeLeaf(conv)
+	branches := []ConversationBranch{}
+	for i := len(messages) - 1; i >= 0; i-- {
+		leaf := messages[i]
+		if len(tree.children[leaf.ID]) > 0 {
+			continue
+		}
+		path := tree.path(leaf.ID)
+		ids := make([]string, len(path))
+		for j, message := range path {
+			ids[j] = message.ID
+		}
+		branches = append(branches, ConversationBranch{
+			LeafID:     leaf.ID,
+			MessageIDs: ids,
+			Question:   leaf.Question,
+			Active:     leaf.ID == active,
+			UpdatedAt:  leaf.CreatedAt,
+		})
+	}
+	return branches, nil
+}
+
+// SwitchBranch shows the branch through a message of the conversation, continuing to the latest
+// message after it, so that new questions continue that branch
+func (s *ConversationService) SwitchBranch(ctx context.Context, userID, conversationID, messageID string) (*ConversationDetail, error) {
+	if strings.TrimSpace(messageID) == "" {
+		return nil, fmt.Errorf("message_id is required")
+	}
+	conv, err := s.conversationRepo.GetByID(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.conversationRepo.ListMessages(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := newMessageTree(messages)
+	if _, ok := tree.byID[messageID]; !ok {
+		return nil, fmt.Errorf("message %w", apperror.ErrNotFound)
+	}
+	conv.ActiveMessageID = tree.latestLeaf(messageID)
+	if err := s.conversationRepo.SetActiveMessage(ctx, conversationID, conv.ActiveMessageID); err != nil {
+		return nil, err
+	}
+	return branchDetail(conv, tree), nil
+}
+
+// branchDetail returns the conversation with the messages of the branch it shows
+func branchDetail(conv *model.Conversation, tree *messageTree) *ConversationDetail {
+	path := tree.path(tree.activeLeaf(conv))
+	if path == nil {
+		path = []*model.QueryHistory{}
+	}
+	detail := &ConversationDetail{Conversation: conv, Messages: path}
+	if versions := tree.versions(path); len(versions) > 0 {
+		detail.Versions = versions
+	}
+	return detail
+}
+
+// EditMessage asks an edited version of a conversation's question, answering it on a new branch
+// from the message before it; the original branch is kept. An empty question regenerates the
+// answer to the original question.
+func (s *RAGService) EditMessage(ctx context.Context, userID, conversationID, messageID string, req QueryRequest) (*QueryResponse, error) {
+	message, err := s.documentRepo.GetQueryHistory(ctx, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.ConversationID != conversationID {
+		return nil, fmt.Errorf("message %w", apperror.ErrNotFound)
+	}
+
+	if strings.TrimSpace(req.Question) == "" {
+		req.Question = message.Question
+	}
+	req.ConversationID = conversationID
+	req.Standalone = false
+	req.editOf = message
+	return s.Query(ctx, userID, req)
+}
+
+// branchParent returns the message a new exchange in the conversation follows: the parent of an
+// edited message, or the last message of the branch the conversation shows
+func (s *RAGService) branchParent(ctx context.Context, conv *model.Conversation, editOf *model.QueryHistory) (string, error) {
+	if editOf != nil {
+		return editOf.ParentID, nil
+	}
+	if conv.ActiveMessageID != "" {
+		return conv.ActiveMessageID, nil
+	}
+	messages, err := s.conversationRepo.ListMessages(ctx, conv.ID)
+	if err != nil {
+		return "", err
+	}
+	return newMessageTree(messages).activeLeaf(conv), nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// Conversation export formats
+const (
+	ExportFormatMarkdown = "md"
+	ExportFormatJSON     = "json"
+	// ExportFormatAnki exports each question and answer as an Anki note, in Anki's CSV import format
+	ExportFormatAnki = "anki"
+)
+
+// ConversationExport is a rendered conversation ready to download
+type ConversationExport struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Export renders a conversation, including each answer's citations, as Markdown, JSON or an Anki
+// deck. messageIDs selects the exchanges to export; empty exports them all.
+func (s *ConversationService) Export(ctx context.Context, userID, conversationID, format string, messageIDs []string) (*ConversationExport, error) {
+	if format == "" {
+		format = ExportFormatMarkdown
+	}
+	if format != ExportFormatMarkdown && format != ExportFormatJSON && format != ExportFormatAnki {
+		return nil, fmt.Errorf("format must be %q, %q or %q", ExportFormatMarkdown, ExportFormatJSON, ExportFormatAnki)
+	}
+
+	detail, err := s.Get(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(messageIDs) > 0 {
+		selected := make(map[string]bool, len(messageIDs))
+		for _, id := range messageIDs {
+			selected[id] = true
+		}
+		var messages []*model.QueryHistory
+		for _, message := range detail.Messages {
+			if selected[message.ID] {
+				messages = append(messages, message)
+			}
+		}
+		if len(messages) == 0 {
+			return nil, fmt.Errorf("no selected messages found in conversation")
+		}
+		detail = &ConversationDetail{Conversation: detail.Conversation, Messages: messages}
+	}
+
+	export := &ConversationExport{Filename: exportFilename(detail.Conversation) + "." + format}
+	exportedAt := time.Now().UTC()
+
+	if format == ExportFormatAnki {
+		notes := make([]ankiNote, len(detail.Messages))
+		for i, message := range detail.Messages {
+			notes[i] = ankiNote{
+				front: ankiField(message.Question),
+				back:  ankiSources(message.Answer, messageCitations(message)),
+				tags:  []string{"conversation", exportFilename(detail.Conversation)},
+			}
+		}
+		content, err := renderAnkiCSV(detail.Title, notes)
+		if err != nil {
+			return nil, err
+		}
+		export.Filename = exportFilename(detail.Conversation) + "-anki.csv"
+		export.ContentType = "text/csv; charset=utf-8"
+		export.Content = content
+		return export, nil
+	}
+
+	if format == ExportFormatJSON {
+		content, err := json.MarshalIndent(struct {
+			*ConversationDetail
+			ExportedAt time.Time `json:"exported_at"`
+		}{detail, exportedAt}, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal conversation: %w", err)
+		}
+		export.ContentType = "application/json"
+		export.Content = content
+		return export, nil
+	}
+
+	export.ContentType = "text/markdown; charset=utf-8"
+	export.Content = []byte(renderConversationMarkdown(detail, exportedAt))
+	return export, nil
+}
+
+// renderConversationMarkdown renders each exchange with its numbered sources
+func renderConversationMarkdown(detail *ConversationDetail, exportedAt time.Time) string {
+	var b strings.Builder
+
+	title := detail.Title
+	if title == "" {
+		title = "Conversation"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "_Started %s · %d messages · exported %s_\n",
+		detail.CreatedAt.Format("2006-01-02 15:04"), len(detail.Messages), exportedAt.Format("2006-01-02 15:04 MST"))
+
+	for _, message := range detail.Messages {
+		fmt.Fprintf(&b, "\n---\n\n### You · %s\n\n%s\n\n### Assistant\n\n%s\n",
+			message.CreatedAt.Format("2006-01-02 15:04"), message.Question, message.Answer)
+
+		citations := messageCitations(message)
+		if len(citations) > 0 {
+			b.WriteString("\n**Sources**\n\n")
+			for i, citation := range citations {
+				fmt.Fprintf(&b, "%d. %s\n", i+1, citation)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// messageCitations formats the distinct sources stored with a message as "file (page N)"
+func messageCitations(message *model.QueryHistory) []string {
+	sources, _ := message.Sources["sources"].([]interface{})
+
+	seen := make(map[string]bool)
+	var citations []string
+	for _, item := range sources {
+		source, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		filename, _ := source["filename"].(string)
+		if filename == "" {
+			continue
+		}
+
+		citation := filename
+		if page, ok := source["page"].(float64); ok && page > 0 {
+			citation = fmt.Sprintf("%s (page %d)", filename, int(page))
+		}
+		if slide, ok := source["slide"].(float64); ok && slide > 0 {
+			citation = fmt.Sprintf("%s (slide %d)", filename, int(slide))
+		}
+		if headingPath, ok := source["heading_path"].(string); ok && headingPath != "" {
+			citation = fmt.Sprintf("%s (%s)", filename, headingPath)
+		}
+		if rowStart, ok := source["row_start"].(float64); ok {
+			rowEnd, _ := source["row_end"].(float64)
+			rows := fmt.Sprintf("rows %d-%d", int(rowStart), int(rowEnd))
+			if sheet, ok := source["sheet"].(string); ok {
+				rows = sheet + ", " + rows
+			}
+			citation = fmt.Sprintf("%s (%s)", filename, rows)
+		}
+		if subject, ok := source["subject"].(string); ok && subject != "" {
+			email := subject
+			if date, ok := source["date"].(string); ok && date != "" {
+				email += ", " + date
+			}
+			citation = fmt.Sprintf("%s (%s)", filename, email)
+		}
+		if !seen[citation] {
+			seen[citation] = true
+			citations = append(citations, citation)
+		}
+	}
+	return citations
+}
+
+// exportFilename builds a filesystem-safe name from the conversation title
+func exportFilename(conv *model.Conversation) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case r == ' ' || r == '-' || r == '_':
+			return '-'
+		}
+		return -1
+	}, strings.TrimSpace(conv.Title))
+
+	slug = strings.Trim(slug, "-")
+	if len(slug) > 60 {
+		slug = strings.TrimRight(slug[:60], "-")
+	}
+	if slug == "" {
+		return "conversation-" + conv.ID
+	}
+	return slug
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// ConversationService handles conversation listing and search
+type ConversationService struct {
+	conversationRepo *repository.ConversationRepository
+	vectorRepo       *repository.VectorRepository
+	embeddings       *EmbeddingProviders
+}
+
+// NewConversationService creates a new conversation service
+func NewConversationService(
+	conversationRepo *repository.ConversationRepository,
+	vectorRepo *repository.VectorRepository,
+	embeddings *EmbeddingProviders,
+) *ConversationService {
+	return &ConversationService{
+		conversationRepo: conversationRepo,
+		vectorRepo:       vectorRepo,
+		embeddings:       embeddings,
+	}
+}
+
+// ConversationDetail represents a conversation with the messages of the branch it shows
+type ConversationDetail struct {
+	*model.Conversation
+	Messages []*model.QueryHistory `json:"messages"`
+	// Versions lists, for each message that was edited or regenerated, the IDs of all its
+	// versions, oldest first
+	Versions map[string][]string `json:"versions,omitempty"`
+}
+
+// ConversationSettings represents the editable settings of a conversation
+type ConversationSettings struct {
+	Title       string            `json:"title"`
+	Model       string            `json:"model"`
+	Temperature *float64          `json:"temperature"`
+	Language    string            `json:"language"`
+	Filters     map[string]string `json:"filters"`
+}
+
+// Create starts a new conversation with locked settings
+func (s *ConversationService) Create(ctx context.Context, userID string, settings ConversationSettings) (*model.Conversation, error) {
+	if err := validateConversationSettings(settings); err != nil {
+		return nil, err
+	}
+
+	conv := &model.Conversation{
+		UserID:      userID,
+		Title:       strings.TrimSpace(settings.Title),
+		Model:       strings.TrimSpace(settings.Model),
+		Temperature: settings.Temperature,
+		Language:    strings.TrimSpace(settings.Language),
+		Filters:     settings.Filters,
+	}
+
+	if err := s.conversationRepo.Create(ctx, conv); err != nil {
+		return nil, err
+	}
+
+	return conv, nil
+}
+
+// UpdateSettings replaces a conversation's settings; an empty title keeps the current one
+func (s *ConversationService) UpdateSettings(ctx context.Context, userID, conversationID string, settings ConversationSettings) (*model.Conversation, error) {
+	if err := validateConversationSettings(settings); err != nil {
+		return nil, err
+	}
+
+	conv, err := s.conversationRepo.GetByID(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if title := strings.TrimSpace(settings.Title); title != "" {
+		conv.Title = title
+	}
+	conv.Model = strings.TrimSpace(settings.Model)
+	conv.Temperature = settings.Temperature
+	conv.Language = strings.TrimSpace(settings.Language)
+	conv.Filters = settings.Filters
+
+	if err := s.conversationRepo.UpdateSettings(ctx, conv); err != nil {
+		return nil, err
+	}
+
+	return conv, nil
+}
+
+// validateConversationSettings validates conversation settings
+func validateConversationSettings(settings ConversationSettings) error {
+	if len(settings.Title) > 255 {
+		return fmt.Errorf("title must be at most 255 characters")
+	}
+	if len(settings.Model) > 100 {
+		return fmt.Errorf("model must be at most 100 characters")
+	}
+	if settings.Temperature != nil && (*settings.Temperature < 0 || *settings.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2")
+	}
+	if len(settings.Language) > 50 {
+		return fmt.Errorf("language must be at most 50 characters")
+	}
+	return nil
+}
+
+// List lists a user's conversations, most recently active first
+func (s *ConversationService) List(ctx context.Context, userID string, limit, offset int) ([]*model.Conversation, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.conversationRepo.ListByUserID(ctx, userID, limit, offset)
+}
+
+// Search performs semantic search over a user's past conversations, best match first
+func (s *ConversationService) Search(ctx context.Context, userID, query string, limit int) ([]*model.Conversation, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	embedding, err := s.embeddings.Embed(ctx, userID, strings.TrimSpace(query), EmbeddingQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.vectorRepo.SearchConversations(ctx, userID, embedding, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return []*model.Conversation{}, nil
+	}
+
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+
+	conversations, err := s.conversationRepo.ListByIDs(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restore search ranking; conversations deleted since indexing are skipped
+	byID := make(map[string]*model.Conversation, len(conversations))
+	for _, conv := range conversations {
+		byID[conv.ID] = conv
+	}
+
+	ranked := make([]*model.Conversation, 0, len(conversations))
+	for _, result := range results {
+		if conv, ok := byID[result.ID]; ok {
+			conv.Score = result.Score
+			ranked = append(ranked, conv)
+		}
+	}
+
+	return ranked, nil
+}
+
+// Get gets a conversation with the messages of the branch it shows
+func (s *ConversationService) Get(ctx context.Context, userID, conversationID string) (*ConversationDetail, error) {
+	conv, err := s.conversationRepo.GetByID(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.conversationRepo.ListMessages(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return branchDetail(conv, newMessageTree(messages)), nil
+}
+
+// Delete deletes a conversation, its messages and its search embedding
+func (s *ConversationService) Delete(ctx context.Context, userID, conversationID string) error {
+	if _, err := s.conversationRepo.GetByID(ctx, userID, conversationID); err != nil {
+		return err
+	}
+
+	if err := s.vectorRepo.DeleteConversation(ctx, userID, conversationID); err != nil {
+		return fmt.Errorf("failed to delete conversation embedding: %w", err)
+	}
+
+	return s.conversationRepo.Delete(ctx, userID, conversationID)
+}
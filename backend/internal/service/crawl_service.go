@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/crawler"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/queue"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Crawl limits
+const (
+	DefaultCrawlMaxDepth = 2
+	DefaultCrawlMaxPages = 50
+	MaxCrawlDepth        = 5
+	MaxCrawlPages        = 500
+	// crawlDelay spaces out requests to the crawled site
+	crawlDelay = 500 * time.Millisecond
+)
+
+// Crawl statuses
+const (
+	CrawlQueued    = "queued"
+	CrawlRunning   = "running"
+	CrawlCompleted = "completed"
+	CrawlFailed    = "failed"
+	CrawlCancelled = "cancelled"
+)
+
+// errCrawlCancelled stops a crawl the user cancelled
+var errCrawlCancelled = errors.New("crawl cancelled")
+
+// JobCrawlSite is the queue job type for crawling a website
+const JobCrawlSite = "crawl.site"
+
+// CrawlSiteJob is the payload of a JobCrawlSite job
+type CrawlSiteJob struct {
+	CrawlID string `json:"crawl_id"`
+}
+
+// CrawlService imports websites, such as documentation sites or a blog, by crawling them from a
+// seed URL and indexing each page as a Markdown document
+type CrawlService struct {
+	crawlRepo    *repository.CrawlRepository
+	documentRepo *repository.DocumentRepository
+	documents    *DocumentService
+	jobs         queue.Queue
+	crawler      *crawler.Crawler
+}
+
+// NewCrawlService creates a new crawl service
+func NewCrawlService(
+	crawlRepo *repository.CrawlRepository,
+	documentRepo *repository.DocumentRepository,
+	documents *DocumentService,
+	jobs queue.Queue,
+) *CrawlService {
+	return &CrawlService{
+		crawlRepo:    crawlRepo,
+		documentRepo: documentRepo,
+		documents:    documents,
+		jobs:         jobs,
+		crawler:      crawler.New(nil),
+	}
+}
+
+// CreateCrawlInput represents the fields of a new crawl
+type CreateCrawlInput struct {
+	URL string `json:"url"`
+	// MaxDepth is how many links away from the seed URL pages are followed; 0 indexes the seed
+	// page only. Nil uses DefaultCrawlMaxDepth.
+	MaxDepth *int `json:"max_depth"`
+	// MaxPages caps the pages fetched; 0 uses DefaultCrawlMaxPages
+	MaxPages int `json:"max_pages"`
+	// SameDomain only follows links on the seed URL's host; nil means true
+	SameDomain *bool `json:"same_domain"`
+}
+
+// Start validates a crawl and queues it
+func (s *CrawlService) Start(ctx context.Context, userID string, input CreateCrawlInput) (*model.CrawlJob, error) {
+	seed, err := crawler.ParseSeed(input.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.CrawlJob{
+		UserID:     userID,
+		SeedURL:    seed.String(),
+		MaxDepth:   DefaultCrawlMaxDepth,
+		MaxPages:   input.MaxPages,
+		SameDomain: true,
+	}
+	if input.MaxDepth != nil {
+		job.MaxDepth = *input.MaxDepth
+	}
+	if job.MaxPages == 0 {
+		job.MaxPages = DefaultCrawlMaxPages
+	}
+	if input.SameDomain != nil {
+		job.SameDomain = *input.SameDomain
+	}
+	if job.MaxDepth < 0 || job.MaxDepth > MaxCrawlDepth {
+		return nil, fmt.Errorf("max_depth must be between 0 and %d", MaxCrawlDepth)
+	}
+	if job.MaxPages < 1 || job.MaxPages > MaxCrawlPages {
+		return nil, fmt.Errorf("max_pages must be between 1 and %d", MaxCrawlPages)
+	}
+
+	if err := s.crawlRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	if err := s.jobs.Enqueue(ctx, JobCrawlSite, CrawlSiteJob{CrawlID: job.ID}); err != nil {
+		return nil, fmt.Errorf("failed to queue crawl: %w", err)
+	}
+
+	return job, nil
+}
+
+// Get returns a crawl with its progress
+func (s *CrawlService) Get(ctx context.Context, userID, id string) (*model.CrawlJob, error) {
+	return s.crawlRepo.GetByID(ctx, userID, id)
+}
+
+// List lists the user's crawls, newest first
+func (s *CrawlService) List(ctx context.Context, userID string, limit, offset int) ([]*model.CrawlJob, error) {
+	return s.crawlRepo.ListByUserID(ctx, userID, limit, offset)
+}
+
+// Cancel stops a queued or running crawl; pages already indexed are kept
+func (s *CrawlService) Cancel(ctx context.Context, userID, id string) error {
+	return s.crawlRepo.Cancel(ctx, userID, id)
+}
+
+// HandleCrawlSite processes a JobCrawlSite job. A crawl runs once: a redelivered job, such as
+// after the queue's lock timeout on a long crawl, finds it already started and does nothing.
+func (s *CrawlService) HandleCrawlSite(ctx context.Context, job *queue.Job) error {
+	var payload CrawlSiteJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("invalid crawl job payload: %w", err))
+	}
+
+	crawl, err := s.crawlRepo.GetByID(ctx, "", payload.CrawlID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return queue.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	started, err := s.crawlRepo.Start(ctx, crawl.ID)
+	if err != nil {
+		return err
+	}
+	if !started {
+		logger.Debug("Skipped crawl that is no longer queued", "crawl_id", crawl.ID)
+		return nil
+	}
+
+	err = s.crawl(ctx, crawl)
+	if errors.Is(err, errCrawlCancelled) {
+		logger.Info("Crawl cancelled", "crawl_id", crawl.ID, "pages", crawl.PagesCrawled)
+		return nil
+	}
+
+	// The job's context may be what ended the crawl, so the outcome is recorded without it
+	finishCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err != nil {
+		if finishErr := s.crawlRepo.Finish(finishCtx, crawl.ID, CrawlFailed, err.Error()); finishErr != nil {
+			logger.Error("Failed to record crawl failure", "crawl_id", crawl.ID, "error", finishErr)
+		}
+		return queue.Permanent(err)
+	}
+	if err := s.crawlRepo.Finish(finishCtx, crawl.ID, CrawlCompleted, ""); err != nil {
+		return queue.Permanent(err)
+	}
+
+	logger.Info("Crawled website",
+		"crawl_id", crawl.ID,
+		"seed_url", crawl.SeedURL,
+		"pages", crawl.PagesCrawled,
+		"indexed", crawl.PagesIndexed,
+		"unchanged", crawl.PagesUnchanged,
+		"failed", crawl.PagesFailed,
+	)
+	return nil
+}
+
+// crawl fetches and indexes the pages of a running crawl, recording its progress after each one
+func (s *CrawlService) crawl(ctx context.Context, crawl *model.CrawlJob) error {
+	seed, err := crawler.ParseSeed(crawl.SeedURL)
+	if err != nil {
+		return err
+	}
+	opts := crawler.Options{
+		MaxDepth:   crawl.MaxDepth,
+		MaxPages:   crawl.MaxPages,
+		SameDomain: crawl.SameDomain,
+		Delay:      crawlDelay,
+	}
+
+	return s.crawler.Crawl(ctx, seed, opts, func(page *crawler.Page, fetchErr error) error {
+		crawl.PagesCrawled++
+		crawl.CurrentURL = page.URL
+		switch {
+		case fetchErr != nil:
+			crawl.PagesFailed++
+			logger.Warn("Failed to fetch page", "crawl_id", crawl.ID, "url", page.URL, "error", fetchErr)
+		default:
+			unchanged, err := s.indexPage(ctx, crawl.UserID, page)
+			switch {
+			case err != nil:
+				crawl.PagesFailed++
+				logger.Warn("Failed to index page", "crawl_id", crawl.ID, "url", page.URL, "error", err)
+			case unchanged:
+				crawl.PagesUnchanged++
+			default:
+				crawl.PagesIndexed++
+			}
+		}
+
+		running, err := s.crawlRepo.UpdateProgress(ctx, crawl)
+		if err != nil {
+			return err
+		}
+		if !running {
+			return errCrawlCancelled
+		}
+		return nil
+	})
+}
+
+// indexPage indexes a page as a Markdown document named after its URL. A page crawled before is
+// unchanged when its text is; otherwise the new version replaces the document of the old one.
+func (s *CrawlService) indexPage(ctx context.Context, userID string, page *crawler.Page) (unchanged bool, err error) {
+	if strings.TrimSpace(page.Text) == "" {
+		return false, fmt.Errorf("no text content found in page")
+	}
+	pageURL, err := url.Parse(page.URL)
+	if err != nil {
+		return false, fmt.Errorf("invalid page URL: %w", err)
+	}
+
+	var content strings.Builder
+	if page.Title != "" {
+		content.WriteString("# " + page.Title + "\n\n")
+	}
+	content.WriteString("Source: " + page.URL + "\n\n")
+	content.WriteString(page.Text)
+	text := content.String()
+
+	// Skip embedding a page whose text is already indexed
+	fileHash, err := hashContent(strings.NewReader(text))
+	if err != nil {
+		return false, err
+	}
+	_, err = s.documentRepo.GetByHash(ctx, userID, fileHash)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return false, err
+	}
+
+	filename := crawlFilename(pageURL)
+	previous, err := s.documentRepo.GetLatestByFilename(ctx, userID, filename)
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		return false, err
+	}
+
+	_, err = s.documents.ingestUpload(ctx, userID, filename, strings.NewReader(text), int64(len(text)), "", ChunkingOptions{}, EmbeddingSourceCrawl)
+	if errors.Is(err, repository.ErrDuplicateDocument) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if previous != nil {
+		if err := s.documents.DeleteDocument(ctx, userID, previous.ID); err != nil {
+			logger.Warn("Failed to delete previous version of page", "document_id", previous.ID, "error", err)
+		}
+	}
+	return false, nil
+}
+
+// crawlFilename names a page's document after its URL, e.g. "docs.example.com - guide - install.md"
+func crawlFilename(pageURL *url.URL) string {
+	parts := []string{strings.ToLower(pageURL.Hostname())}
+	for _, segment := range strings.Split(pageURL.Path, "/") {
+		segment = strings.TrimSuffix(strings.TrimSuffix(segment, ".html"), ".htm")
+		if segment != "" && segment != "index" {
+			parts = append(parts, segment)
+		}
+	}
+	if pageURL.RawQuery != "" {
+		parts = append(parts, pageURL.RawQuery)
+	}
+
+	return captureTitle(strings.Join(parts, " - ")) + ".md"
+}
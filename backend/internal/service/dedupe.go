@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Chunk deduplication scopes
+const (
+	// ChunkDedupDocument drops repeated chunks within each document
+	ChunkDedupDocument = "document"
+	// ChunkDedupCorpus also drops chunks repeating one already stored for the user
+	ChunkDedupCorpus = "corpus"
+	// ChunkDedupOff embeds every chunk
+	ChunkDedupOff = "off"
+)
+
+// simHashMaxDistance is the most bits in which two chunks' fingerprints may differ for them to be
+// near-duplicates, e.g. a footer that differs only by its page number
+const simHashMaxDistance = 3
+
+// ChunkDeduplicator drops identical and near-identical chunks before they are embedded, so
+// boilerplate such as repeated headers, footers and disclaimers doesn't fill the collection with
+// redundant vectors. Near-duplicates are found by comparing 64-bit SimHash fingerprints of the
+// chunks' words.
+type ChunkDeduplicator struct {
+	scope     string
+	chunkRepo *repository.ChunkRepository
+}
+
+// NewChunkDeduplicator creates a deduplicator for the given scope: document, corpus or off
+func NewChunkDeduplicator(scope string, chunkRepo *repository.ChunkRepository) (*ChunkDeduplicator, error) {
+	switch scope {
+	case ChunkDedupDocument, ChunkDedupCorpus, ChunkDedupOff:
+	default:
+		return nil, fmt.Errorf("unknown chunk dedup scope: %s (valid options: document, corpus, off)", scope)
+	}
+
+	return &ChunkDeduplicator{
+		scope:     scope,
+		chunkRepo: chunkRepo,
+	}, nil
+}
+
+// Dedupe returns the chunks of a new document without duplicates, fingerprinted so later
+// documents can be compared with them. The first of each group of duplicates is kept. At least
+// one chunk is always kept so the document itself stays searchable.
+func (d *ChunkDeduplicator) Dedupe(ctx context.Context, userID string, chunks []model.DocumentChunk) []model.DocumentChunk {
+	if d == nil || d.scope == ChunkDedupOff || len(chunks) == 0 {
+		return chunks
+	}
+
+	var seen []uint64
+	if d.scope == ChunkDedupCorpus {
+		stored, err := d.chunkRepo.ListSimHashes(ctx, userID)
+		if err != nil {
+			// Deduplicating within the document still helps
+			logger.Warn("Failed to load chunk fingerprints, deduplicating within the document only", "user_id", userID, "error", err)
+		}
+		for _, hash := range stored {
+			if h, err := parseSimHash(hash); err == nil {
+				seen = append(seen, h)
+			}
+		}
+	}
+
+	exact := make(map[string]bool, len(chunks))
+	kept := make([]model.DocumentChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		normalized := strings.Join(strings.Fields(strings.ToLower(chunk.Content)), " ")
+		chunk.SimHash = simHash(chunk.Content)
+		if exact[normalized] || nearDuplicate(chunk.SimHash, seen) {
+			continue
+		}
+		exact[normalized] = true
+		seen = append(seen, chunk.SimHash)
+		kept = append(kept, chunk)
+	}
+
+	if len(kept) == 0 {
+		kept = append(kept, chunks[0])
+		kept[0].SimHash = simHash(chunks[0].Content)
+	}
+	if dropped := len(chunks) - len(kept); dropped > 0 {
+		logger.Info("Skipped duplicate chunks", "user_id", userID, "chunks", len(chunks), "duplicates", dropped, "scope", d.scope)
+	}
+
+	return kept
+}
+
+// nearDuplicate reports whether hash is within simHashMaxDistance bits of any of seen. Texts
+// without words have no fingerprint and are never near-duplicates.
+func nearDuplicate(hash uint64, seen []uint64) bool {
+	if hash == 0 {
+		return false
+	}
+	for _, other := range seen {
+		if bits.OnesCount64(hash^other) <= simHashMaxDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// simHash fingerprints text by its words: each bit is set when more of the words' hashes have it
+// set than not, so texts sharing most words get fingerprints differing in few bits
+func simHash(text string) uint64 {
+	var weights [64]int
+	words := 0
+	for _, word := range basicTokenize(text) {
+		if !hasLetterOrDigit(word) {
+			continue
+		}
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<i) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+		words++
+	}
+	if words == 0 {
+		return 0
+	}
+
+	var hash uint64
+	for i, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << i
+		}
+	}
+	return hash
+}
+
+// formatSimHash encodes a fingerprint for chunk metadata. JSON numbers can't hold all 64 bits
+// exactly, so it is stored as hex.
+func formatSimHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// parseSimHash decodes a fingerprint stored by formatSimHash
+func parseSimHash(hash string) (uint64, error) {
+	return strconv.ParseUint(hash, 16, 64)
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/notification"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Digest generation parameters
+const (
+	digestPeriod = 24 * time.Hour
+	// digestExcerptChars is how much of an unsummarized document is given to the model
+	digestExcerptChars = 2000
+	// digestMaxDocuments caps the documents described individually in one digest
+	digestMaxDocuments = 30
+)
+
+// EventDigestCreated is the notification event sent when a digest is generated
+const EventDigestCreated = "digest.created"
+
+// digestSystemPrompt instructs the model to write the daily digest
+const digestSystemPrompt = `You write a short daily digest of the documents a user added to their knowledge base.
+Start with a one-paragraph overview of the common themes, then give one bullet per document with its name in bold and one or two sentences on what it contains and why it may matter.
+Use Markdown. Base the digest ONLY on the provided document descriptions.`
+
+// DigestService generates and serves daily digests of newly added documents
+type DigestService struct {
+	digestRepo   *repository.DigestRepository
+	documentRepo *repository.DocumentRepository
+	chunkRepo    *repository.ChunkRepository
+	ragService   *RAGService
+	notifier     notification.Notifier
+}
+
+// NewDigestService creates a new digest service
+func NewDigestService(
+	digestRepo *repository.DigestRepository,
+	documentRepo *repository.DocumentRepository,
+	chunkRepo *repository.ChunkRepository,
+	ragService *RAGService,
+	notifier notification.Notifier,
+) *DigestService {
+	return &DigestService{
+		digestRepo:   digestRepo,
+		documentRepo: documentRepo,
+		chunkRepo:    chunkRepo,
+		ragService:   ragService,
+		notifier:     notifier,
+	}
+}
+
+// RunDaily generates a digest for every user who added documents in the last
+// complete UTC day, stores it and notifies the user. Users that already have a
+// digest for the day are skipped, so the job is safe to rerun.
+func (s *DigestService) RunDaily(ctx context.Context) error {
+	periodEnd := time.Now().UTC().Truncate(digestPeriod)
+	periodStart := periodEnd.Add(-digestPeriod)
+
+	docs, err := s.documentRepo.ListUploadedBetween(ctx, periodStart, periodEnd)
+	if err != nil {
+		return err
+	}
+
+	// Documents are ordered by user, so each user's run is contiguous
+	for start := 0; start < len(docs); {
+		end := start
+		for end < len(docs) && docs[end].UserID == docs[start].UserID {
+			end++
+		}
+		userID := docs[start].UserID
+
+		if err := s.generate(ctx, userID, periodStart, periodEnd, docs[start:end]); err != nil {
+			logger.Error("Failed to generate digest", "user_id", userID, "error", err)
+		}
+		start = end
+	}
+
+	return nil
+}
+
+// generate writes, stores and delivers one user's digest
+func (s *DigestService) generate(ctx context.Context, userID string, periodStart, periodEnd time.Time, docs []*model.Document) error {
+	exists, err := s.digestRepo.Exists(ctx, userID, periodStart)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	described := docs
+	if len(described) > digestMaxDocuments {
+		described = described[:digestMaxDocuments]
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "%d documents were added between %s and %s UTC.\n",
+		len(docs), periodStart.Format("2006-01-02 15:04"), periodEnd.Format("2006-01-02 15:04"))
+	for i, doc := range described {
+		fmt.Fprintf(&prompt, "\n[Document %d] %s\n%s\n", i+1, doc.Filename, s.describe(ctx, doc))
+	}
+	if len(docs) > len(described) {
+		fmt.Fprintf(&prompt, "\n%d more documents were added but are not described here; mention them in the overview.\n", len(docs)-len(described))
+	}
+
+	content, err := s.ragService.callLLM(ctx, digestSystemPrompt, prompt.String())
+	if err != nil {
+		return fmt.Errorf("failed to write digest: %w", err)
+	}
+
+	digest := &model.Digest{
+		UserID:      userID,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Content:     strings.TrimSpace(content),
+		DocumentIDs: make([]string, len(docs)),
+	}
+	for i, doc := range docs {
+		digest.DocumentIDs[i] = doc.ID
+	}
+
+	created, err := s.digestRepo.Create(ctx, digest)
+	if err != nil || !created {
+		return err
+	}
+
+	if err := s.notifier.Notify(ctx, notification.Notification{
+		UserID: userID,
+		Event:  EventDigestCreated,
+		Title:  fmt.Sprintf("Your daily digest for %s", periodStart.Format("2 January 2006")),
+		Body:   digest.Content,
+		Data: map[string]interface{}{
+			"digest_id":      digest.ID,
+			"document_count": digest.DocumentCount,
+		},
+	}); err != nil {
+		logger.Error("Failed to deliver digest", "digest_id", digest.ID, "error", err)
+	}
+
+	return nil
+}
+
+// describe returns a document's summary, or an excerpt of its text when it hasn't been summarized yet
+func (s *DigestService) describe(ctx context.Context, doc *model.Document) string {
+	if doc.Summary != "" {
+		return doc.Summary
+	}
+
+	text, err := s.chunkRepo.GetDocumentText(ctx, doc.ID, digestExcerptChars)
+	if err != nil {
+		logger.Error("Failed to load document text for digest", "document_id", doc.ID, "error", err)
+	}
+	if text = strings.TrimSpace(text); text == "" {
+		return "(no text available)"
+	}
+	return truncate(text, digestExcerptChars)
+}
+
+// List lists a user's digests, newest first
+func (s *DigestService) List(ctx context.Context, userID string, limit, offset int) ([]*model.Digest, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.digestRepo.ListByUserID(ctx, userID, limit, offset)
+}
+
+// Get gets one of a user's digests
+func (s *DigestService) Get(ctx context.Context, userID, digestID string) (*model.Digest, error) {
+	return s.digestRepo.GetByID(ctx, userID, digestID)
+}
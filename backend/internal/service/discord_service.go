@@ -0,0 +1,425 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/discord"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Discord bot parameters
+const (
+	discordLinkCodeLength = 8
+	discordLinkCodeTTL    = 10 * time.Minute
+	// discordAnswerTimeout bounds answering a deferred /ask; Discord keeps the token for 15 minutes
+	discordAnswerTimeout = 2 * time.Minute
+	// discordEditInterval spaces out streamed edits to stay within Discord's rate limits
+	discordEditInterval = time.Second
+	// discordPollInterval is how often the ingest channel is checked for new files
+	discordPollInterval = 10 * time.Second
+	// discordLockRetry is how often a replica that isn't running the bot checks whether it should
+	discordLockRetry         = time.Minute
+	maxDiscordQuestionLength = 1000
+	maxDiscordThreadName     = 100
+)
+
+// discordLinkCodeAlphabet leaves out characters that are easily confused (0/O, 1/I/L)
+const discordLinkCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// discordCommands are the bot's slash commands
+var discordCommands = []discord.Command{
+	{
+		Name:        "ask",
+		Description: "Ask a question about your documents",
+		Options: []discord.CommandOption{{
+			Type:        discord.CommandOptionString,
+			Name:        "question",
+			Description: "What you want to know",
+			Required:    true,
+			MaxLength:   maxDiscordQuestionLength,
+		}},
+	},
+	{
+		Name:        "link",
+		Description: "Link your Discord account with a code from the assistant",
+		Options: []discord.CommandOption{{
+			Type:        discord.CommandOptionString,
+			Name:        "code",
+			Description: "The link code",
+			Required:    true,
+			MaxLength:   discordLinkCodeLength,
+		}},
+	},
+}
+
+// DiscordService runs the Discord bot: linked users ask questions with /ask, answered in a
+// thread as the answer streams in, and files they post in the ingest channel are ingested
+type DiscordService struct {
+	client          *discord.Client
+	publicKey       string
+	ingestChannelID string
+	discordRepo     *repository.DiscordRepository
+	lockRepo        *repository.LockRepository
+	documentService *DocumentService
+	ragService      *RAGService
+}
+
+// NewDiscordService creates a new Discord service. A nil client disables the bot; an empty
+// ingestChannelID disables file ingestion.
+func NewDiscordService(
+	client *discord.Client,
+	publicKey string,
+	ingestChannelID string,
+	discordRepo *repository.DiscordRepository,
+	lockRepo *repository.LockRepository,
+	documentService *DocumentService,
+	ragService *RAGService,
+) *DiscordService {
+	return &DiscordService{
+		client:          client,
+		publicKey:       publicKey,
+		ingestChannelID: ingestChannelID,
+		discordRepo:     discordRepo,
+		lockRepo:        lockRepo,
+		documentService: documentService,
+		ragService:      ragService,
+	}
+}
+
+// Enabled reports whether a bot is configured
+func (s *DiscordService) Enabled() bool {
+	return s.client != nil
+}
+
+// DiscordStatus reports whether the bot is available and the user's link, if any
+type DiscordStatus struct {
+	Enabled         bool               `json:"enabled"`
+	IngestChannelID string             `json:"ingest_channel_id,omitempty"`
+	Link            *model.DiscordLink `json:"link,omitempty"`
+}
+
+// Status returns the bot status for a user
+func (s *DiscordService) Status(ctx context.Context, userID string) (*DiscordStatus, error) {
+	if !s.Enabled() {
+		return &DiscordStatus{}, nil
+	}
+
+	status := &DiscordStatus{Enabled: true, IngestChannelID: s.ingestChannelID}
+	link, err := s.discordRepo.GetByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrDiscordLinkNotFound) {
+		return nil, err
+	}
+	if link != nil && link.DiscordUserID != "" {
+		status.Link = link
+	}
+	return status, nil
+}
+
+// DiscordLinkCode is a one-time code the user enters with the bot's /link command
+type DiscordLinkCode struct {
+	Code      string    `json:"code"`
+	Command   string    `json:"command"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateLinkCode issues a link code, replacing any earlier one. Linking through the bot proves
+// the user controls the Discord account, so nobody can attach someone else's account.
+func (s *DiscordService) CreateLinkCode(ctx context.Context, userID string) (*DiscordLinkCode, error) {
+	if !s.Enabled() {
+		return nil, fmt.Errorf("discord bot is not configured")
+	}
+
+	random := make([]byte, discordLinkCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate link code: %w", err)
+	}
+	code := make([]byte, discordLinkCodeLength)
+	for i, b := range random {
+		code[i] = discordLinkCodeAlphabet[int(b)%len(discordLinkCodeAlphabet)]
+	}
+
+	expiresAt := time.Now().Add(discordLinkCodeTTL).UTC()
+	if err := s.discordRepo.SaveLinkCode(ctx, userID, hashLinkCode(string(code)), expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &DiscordLinkCode{
+		Code:      string(code),
+		Command:   "/link code:" + string(code),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Unlink removes the user's Discord link
+func (s *DiscordService) Unlink(ctx context.Context, userID string) error {
+	return s.discordRepo.Delete(ctx, userID)
+}
+
+// VerifyInteraction checks that an interaction request was signed by Discord
+func (s *DiscordService) VerifyInteraction(signature, timestamp string, body []byte) bool {
+	return discord.VerifyInteraction(s.publicKey, signature, timestamp, body, time.Now())
+}
+
+// HandleInteraction responds to a verified interaction. /ask is answered in the background:
+// the response defers, then the answer is streamed into a thread on the question.
+func (s *DiscordService) HandleInteraction(ctx context.Context, interaction *discord.Interaction) *discord.InteractionResponse {
+	if interaction.Type == discord.InteractionPing {
+		return &discord.InteractionResponse{Type: discord.ResponsePong}
+	}
+	if interaction.Type != discord.InteractionApplicationCommand {
+		return ephemeral("Unsupported interaction.")
+	}
+
+	invoker := interaction.Invoker()
+	switch interaction.Data.Name {
+	case "link":
+		code := strings.ToUpper(strings.TrimSpace(interaction.StringOption("code")))
+		if _, err := s.discordRepo.CompleteLink(ctx, hashLinkCode(code), invoker.ID, time.Now()); err != nil {
+			if errors.Is(err, repository.ErrDiscordLinkCodeInvalid) {
+				return ephemeral("That code is invalid or has expired. Create a new one in the assistant and try again.")
+			}
+			logger.Error("Failed to link Discord account", "discord_user_id", invoker.ID, "error", err)
+			return ephemeral("Sorry, linking failed. Please try again.")
+		}
+		return ephemeral("Linked! Use /ask to ask about your documents.")
+
+	case "ask":
+		link, err := s.discordRepo.GetByDiscordUserID(ctx, invoker.ID)
+		if err != nil {
+			if errors.Is(err, repository.ErrDiscordLinkNotFound) {
+				return ephemeral("Your Discord account isn't linked yet. Create a link code in the assistant, then run /link.")
+			}
+			logger.Error("Failed to look up Discord link", "discord_user_id", invoker.ID, "error", err)
+			return ephemeral("Sorry, something went wrong. Please try again.")
+		}
+
+		question := strings.TrimSpace(interaction.StringOption("question"))
+		if question == "" {
+			return ephemeral("Ask a question, e.g. /ask question: When does my passport expire?")
+		}
+
+		go s.answer(*interaction, link.UserID, question)
+		return &discord.InteractionResponse{Type: discord.ResponseDeferredChannelMessage}
+
+	default:
+		return ephemeral("Unknown command.")
+	}
+}
+
+// answer streams the answer to a deferred /ask into a thread started from the question. Threads
+// can't be started in DMs, so there the answer streams into the response itself.
+func (s *DiscordService) answer(interaction discord.Interaction, userID, question string) {
+	ctx, cancel := context.WithTimeout(context.Background(), discordAnswerTimeout)
+	defer cancel()
+
+	heading := "**Q:** " + truncate(question, 300)
+	original, err := s.client.EditOriginalResponse(ctx, interaction.Token, heading)
+	if err != nil {
+		logger.Error("Failed to post Discord question", "error", err)
+		return
+	}
+
+	write := func(content string) error {
+		_, err := s.client.EditOriginalResponse(ctx, interaction.Token, clipDiscordMessage(heading+"\n\n"+content))
+		return err
+	}
+	if interaction.GuildID != "" {
+		thread, err := s.client.StartThread(ctx, original.ChannelID, original.ID, truncate(question, maxDiscordThreadName))
+		if err != nil {
+			logger.Warn("Failed to start Discord thread; answering in the channel", "error", err)
+		} else if message, err := s.client.CreateMessage(ctx, thread.ID, "…", ""); err != nil {
+			logger.Warn("Failed to post in Discord thread; answering in the channel", "error", err)
+		} else {
+			write = func(content string) error {
+				return s.client.EditMessage(ctx, thread.ID, message.ID, clipDiscordMessage(content))
+			}
+		}
+	}
+
+	var lastEdit time.Time
+	result, err := s.ragService.StreamAnswer(ctx, userID, question, nil, func(answer string) {
+		if time.Since(lastEdit) < discordEditInterval {
+			return
+		}
+		lastEdit = time.Now()
+		if err := write(answer + " …"); err != nil {
+			logger.Warn("Failed to update streamed Discord answer", "error", err)
+		}
+	})
+
+	reply := "Sorry, I couldn't answer that right now."
+	if err != nil {
+		logger.Error("Discord query failed", "user_id", userID, "error", err)
+	} else {
+		reply = result.Answer
+		if len(result.Sources) > 0 {
+			reply += "\n\n**Sources:** " + strings.Join(result.Sources, ", ")
+		}
+	}
+	if err := write(reply); err != nil {
+		logger.Error("Failed to post Discord answer", "error", err)
+	}
+}
+
+// Run registers the slash commands and watches the ingest channel until ctx is cancelled. Only
+// one replica runs the bot at a time; the others wait for the lock.
+func (s *DiscordService) Run(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+
+	for {
+		lock, err := s.lockRepo.TryAcquire(ctx, "discord_bot")
+		if err != nil {
+			logger.Error("Failed to acquire Discord bot lock", "error", err)
+		}
+		if lock != nil {
+			if err := s.client.RegisterCommands(ctx, discordCommands); err != nil {
+				logger.Error("Failed to register Discord commands", "error", err)
+			}
+			logger.Info("Discord bot started", "ingest_channel_id", s.ingestChannelID)
+			if s.ingestChannelID == "" {
+				lock.Release()
+				return
+			}
+			s.pollIngestChannel(ctx)
+			lock.Release()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(discordLockRetry):
+		}
+	}
+}
+
+// pollIngestChannel ingests files posted to the ingest channel until ctx is cancelled
+func (s *DiscordService) pollIngestChannel(ctx context.Context) {
+	ticker := time.NewTicker(discordPollInterval)
+	defer ticker.Stop()
+
+	// Start after the latest message; files posted while the bot was down need posting again
+	var after string
+	for after == "" {
+		latest, err := s.client.ListMessages(ctx, s.ingestChannelID, "", 1)
+		if err != nil {
+			logger.Error("Failed to read Discord ingest channel", "error", err)
+		} else if len(latest) > 0 {
+			after = latest[0].ID
+		} else {
+			after = "0"
+		}
+
+		if after == "" {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		messages, err := s.client.ListMessages(ctx, s.ingestChannelID, after, 100)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error("Failed to read Discord ingest channel", "error", err)
+			}
+			continue
+		}
+		for _, message := range messages {
+			s.ingestDrop(ctx, message)
+			after = message.ID
+		}
+	}
+}
+
+// ingestDrop ingests the files attached to a message from a linked user and replies with the
+// outcome of each
+func (s *DiscordService) ingestDrop(ctx context.Context, message discord.Message) {
+	if message.Author.Bot || len(message.Attachments) == 0 {
+		return
+	}
+
+	var reply string
+	link, err := s.discordRepo.GetByDiscordUserID(ctx, message.Author.ID)
+	switch {
+	case errors.Is(err, repository.ErrDiscordLinkNotFound):
+		reply = "Your Discord account isn't linked, so I can't add these files. Create a link code in the assistant, then run /link."
+	case err != nil:
+		logger.Error("Failed to look up Discord link", "discord_user_id", message.Author.ID, "error", err)
+		return
+	default:
+		lines := make([]string, 0, len(message.Attachments))
+		for _, attachment := range message.Attachments {
+			lines = append(lines, s.ingestAttachment(ctx, link.UserID, attachment))
+		}
+		reply = strings.Join(lines, "\n")
+	}
+
+	if _, err := s.client.CreateMessage(ctx, message.ChannelID, clipDiscordMessage(reply), message.ID); err != nil {
+		logger.Error("Failed to reply in Discord ingest channel", "error", err)
+	}
+}
+
+// ingestAttachment downloads an attachment into the user's documents and describes the outcome
+func (s *DiscordService) ingestAttachment(ctx context.Context, userID string, attachment discord.Attachment) string {
+	filename := filepath.Base(strings.TrimSpace(attachment.Filename))
+
+	if err := validateUpload(filename, attachment.Size); err != nil {
+		return fmt.Sprintf("Couldn't add %s: %s", filename, err)
+	}
+
+	data, err := s.client.Download(ctx, attachment.URL, MaxUploadSize)
+	if err != nil {
+		logger.Error("Failed to download Discord attachment", "user_id", userID, "error", err)
+		return fmt.Sprintf("Couldn't download %s.", filename)
+	}
+
+	doc, err := s.documentService.ingestUpload(ctx, userID, filename, bytes.NewReader(data), int64(len(data)), "", ChunkingOptions{}, EmbeddingSourceDiscord)
+	if err != nil {
+		logger.Error("Failed to ingest Discord attachment", "user_id", userID, "filename", filename, "error", err)
+		return fmt.Sprintf("Couldn't add %s: %s", filename, err)
+	}
+	return fmt.Sprintf("Added %s (%d chunks).", doc.Filename, doc.TotalChunks)
+}
+
+// ephemeral is an interaction response only the invoking user sees
+func ephemeral(content string) *discord.InteractionResponse {
+	return &discord.InteractionResponse{
+		Type: discord.ResponseChannelMessage,
+		Data: &discord.InteractionResponseData{Content: content, Flags: discord.MessageFlagEphemeral},
+	}
+}
+
+// clipDiscordMessage shortens content to Discord's message length limit
+func clipDiscordMessage(content string) string {
+	if len([]rune(content)) <= discord.MaxMessageLength {
+		return content
+	}
+	return truncate(content, discord.MaxMessageLength-1) + "…"
+}
+
+// hashLinkCode returns the hex SHA-256 of a link code
+func hashLinkCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,10 +1,9 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -12,52 +11,90 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/parser"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/profile"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/queue"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/storage"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/utils"
-	"github.com/ledongthuc/pdf"
+	"github.com/google/uuid"
 )
 
 // DocumentService handles document operations
 type DocumentService struct {
-	documentRepo     *repository.DocumentRepository
-	vectorRepo       *repository.VectorRepository
-	storageDriver    storage.StorageDriver
-	embeddingService *EmbeddingService
+	documentRepo  *repository.DocumentRepository
+	vectorRepo    *repository.VectorRepository
+	chunkRepo     *repository.ChunkRepository
+	storageDriver storage.StorageDriver
+	embeddings    *EmbeddingProviders
+	sparseEncoder SparseEncoder
+	lockRepo      *repository.LockRepository
+	batches       *EmbeddingBatchService
+	secrets       *SecretScanner
+	dedupe        *ChunkDeduplicator
+	ocr           parser.OCR
+	// memoryLimit caps the bytes of text extracted and chunked per document
+	memoryLimit int64
+	// chunking is the default chunking strategy
+	chunking string
 }
 
 // NewDocumentService creates a new document service
 func NewDocumentService(
 	documentRepo *repository.DocumentRepository,
 	vectorRepo *repository.VectorRepository,
+	chunkRepo *repository.ChunkRepository,
 	storageDriver storage.StorageDriver,
-	embeddingService *EmbeddingService,
+	embeddings *EmbeddingProviders,
+	sparseEncoder SparseEncoder,
+	lockRepo *repository.LockRepository,
+	batches *EmbeddingBatchService,
+	secrets *SecretScanner,
+	dedupe *ChunkDeduplicator,
+	ocr parser.OCR,
+	memoryLimit int64,
+	chunking string,
 ) *DocumentService {
 	return &DocumentService{
-		documentRepo:     documentRepo,
-		vectorRepo:       vectorRepo,
-		storageDriver:    storageDriver,
-		embeddingService: embeddingService,
+		documentRepo:  documentRepo,
+		vectorRepo:    vectorRepo,
+		chunkRepo:     chunkRepo,
+		storageDriver: storageDriver,
+		embeddings:    embeddings,
+		sparseEncoder: sparseEncoder,
+		lockRepo:      lockRepo,
+		batches:       batches,
+		secrets:       secrets,
+		dedupe:        dedupe,
+		ocr:           ocr,
+		memoryLimit:   memoryLimit,
+		chunking:      chunking,
 	}
 }
 
-// UploadDocument handles document upload and processing
-func (s *DocumentService) UploadDocument(ctx context.Context, userID string, file *multipart.FileHeader) (*model.Document, error) {
-	// Validate file type
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowedTypes := map[string]bool{
-		".pdf": true, ".txt": true, ".md": true,
-		".json": true, ".csv": true,
+// MaxUploadSize is the largest file accepted for upload (10MB)
+const MaxUploadSize = 10 * 1024 * 1024
+
+// validateUpload checks an uploaded file's type and size
+func validateUpload(filename string, size int64) error {
+	if !parser.Supported(filename) {
+		return fmt.Errorf("%w: %s", apperror.ErrUnsupportedFileType, strings.ToLower(filepath.Ext(filename)))
 	}
-	if !allowedTypes[ext] {
-		return nil, fmt.Errorf("unsupported file type: %s", ext)
+	if size > MaxUploadSize {
+		return fmt.Errorf("%w: file too large (max 10MB)", apperror.ErrQuotaExceeded)
 	}
+	return nil
+}
 
-	// Validate file size (10MB max)
-	const maxSize = 10 * 1024 * 1024
-	if file.Size > maxSize {
-		return nil, fmt.Errorf("file too large (max 10MB)")
+// UploadDocument handles document upload and processing.
+// profileName selects an ingestion profile; when empty the profile is auto-detected.
+// chunking overrides the chunking strategy and chunk size.
+func (s *DocumentService) UploadDocument(ctx context.Context, userID string, file *multipart.FileHeader, profileName string, chunking ChunkingOptions) (*model.Document, error) {
+	if err := validateUpload(file.Filename, file.Size); err != nil {
+		return nil, err
 	}
 
 	// Open file
@@ -67,75 +104,88 @@ func (s *DocumentService) UploadDocument(ctx context.Context, userID string, fil
 	}
 	defer src.Close()
 
-	// Read file content
-	content, err := io.ReadAll(src)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
-	}
+	return s.ingestUpload(ctx, userID, file.Filename, src, file.Size, profileName, chunking, EmbeddingSourceUpload)
+}
+
+// ingestUpload chunks, embeds and stores an uploaded file's content of the given size as a new
+// document. source records where it came from in embedding usage.
+func (s *DocumentService) ingestUpload(ctx context.Context, userID, filename string, content fileContent, size int64, profileName string, options ChunkingOptions, source string) (*model.Document, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
 
 	// Calculate hash
-	hash := sha256.Sum256(content)
-	fileHash := hex.EncodeToString(hash[:])
-
-	// Extract text based on file type
-	var text string
-	switch ext {
-	case ".txt", ".md", ".json", ".csv":
-		text = string(content)
-	case ".pdf":
-		// Write to temporary file for PDF extraction
-		tempFile, err := os.CreateTemp("", "upload-*.pdf")
-		if err != nil {
-			return nil, fmt.Errorf("failed to create temp file: %w", err)
-		}
-		defer os.Remove(tempFile.Name())
-		if _, err := tempFile.Write(content); err != nil {
-			return nil, fmt.Errorf("failed to write temp file: %w", err)
-		}
-		tempFile.Close()
+	fileHash, err := hashContent(content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicate(ctx, userID, fileHash); err != nil {
+		return nil, err
+	}
 
-		text, err = s.extractTextFromPDF(tempFile.Name())
-		if err != nil {
-			return nil, fmt.Errorf("failed to extract text from PDF: %w", err)
-		}
+	chunking, err := s.chunkingFor(ctx, userID, filename, options)
+	if err != nil {
+		return nil, err
 	}
 
-	// Chunk the text
-	chunks := utils.ChunkText(text, 500, 50)
+	// Extract and chunk the text page by page, reading scans and photos with OCR
+	chunks, secrets, err := s.extractChunks(ctx, userID, filename, content, size, profileName, chunking)
+	if err != nil {
+		return nil, err
+	}
 	if len(chunks) == 0 {
 		return nil, fmt.Errorf("no text content found in document")
 	}
 
-	// Generate embeddings
-	embeddings, err := s.embeddingService.GenerateEmbeddings(ctx, chunks)
+	// Repeated boilerplate is embedded once
+	chunks = s.dedupe.Dedupe(ctx, userID, chunks)
+
+	// Generate embeddings with the model of the user's collection
+	provider, err := s.embeddings.ForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	embedCtx, tracker := withUsageTracker(ctx)
+	embeddings, err := provider.GenerateEmbeddings(embedCtx, chunkContents(chunks), EmbeddingDocument)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
 	}
 
 	// Upload to storage
-	storagePath := fmt.Sprintf("%s/%s/%s", userID, fileHash, file.Filename)
-	if err := s.storageDriver.UploadFile(ctx, storagePath, bytes.NewReader(content)); err != nil {
+	storagePath := fmt.Sprintf("%s/%s/%s", userID, fileHash, filename)
+	if err := s.storageDriver.UploadFile(ctx, storagePath, content); err != nil {
 		return nil, fmt.Errorf("failed to upload file: %w", err)
 	}
 
 	// Create document record
 	doc := &model.Document{
 		UserID:      userID,
-		Filename:    file.Filename,
+		Filename:    filename,
 		FileType:    ext,
-		FileSize:    file.Size,
+		FileSize:    size,
 		FileHash:    fileHash,
 		StoragePath: storagePath,
 		TotalChunks: len(chunks),
 	}
+	chunking.record(doc)
 
 	if err := s.documentRepo.Create(ctx, doc); err != nil {
+		// A duplicate's storage path is that of the document already holding the content
+		if !errors.Is(err, repository.ErrDuplicateDocument) {
+			s.discardFile(ctx, storagePath)
+		}
 		return nil, fmt.Errorf("failed to create document record: %w", err)
 	}
+	recordEmbeddingUsage(ctx, s.documentRepo, tracker, model.EmbeddingUsage{
+		UserID:     userID,
+		DocumentID: doc.ID,
+		Filename:   filename,
+		Source:     source,
+		Model:      provider.Model(),
+	})
 
 	// Ensure vector collection exists
-	vectorSize := uint64(s.embeddingService.GetDimensions())
-	if err := s.vectorRepo.EnsureCollection(ctx, userID, vectorSize); err != nil {
+	vectorSize := uint64(provider.Dimensions())
+	if err := s.vectorRepo.EnsureCollection(ctx, userID, vectorSize, sparseVectorConfig(s.sparseEncoder)); err != nil {
+		s.discardDocument(ctx, doc)
 		return nil, fmt.Errorf("failed to ensure collection: %w", err)
 	}
 
@@ -143,78 +193,103 @@ func (s *DocumentService) UploadDocument(ctx context.Context, userID string, fil
 	var points []*model.VectorPoint
 	for i, embedding := range embeddings {
 		point := &model.VectorPoint{
-			ID:     fmt.Sprintf("%s_chunk_%d", doc.ID, i),
+			ID:     chunkPointID(doc.ID, i),
 			Vector: embedding,
-			Payload: map[string]interface{}{
+			Payload: chunkPayload(chunks[i], map[string]interface{}{
 				"document_id": doc.ID,
 				"user_id":     userID,
-				"filename":    file.Filename,
+				"filename":    filename,
 				"file_type":   ext,
-				"chunk_index": i,
-				"content":     chunks[i],
-			},
+			}),
 		}
 		points = append(points, point)
 	}
 
+	// Sparse vectors enable hybrid retrieval; without them the chunks are still found by embedding
+	if err := attachSparseVectors(ctx, s.sparseEncoder, points); err != nil {
+		logger.Warn("Failed to generate sparse vectors", "document_id", doc.ID, "error", err)
+	}
+
 	if err := s.vectorRepo.InsertVectors(ctx, userID, points); err != nil {
+		s.discardDocument(ctx, doc)
 		return nil, fmt.Errorf("failed to insert vectors: %w", err)
 	}
 
+	// Keep the chunk text searchable for the keyword fallback
+	if err := s.chunkRepo.InsertChunks(ctx, userID, points); err != nil {
+		logger.Error("Failed to store chunk text", "document_id", doc.ID, "error", err)
+	}
+
+	s.secrets.Report(ctx, doc, secrets)
 	return doc, nil
 }
 
-// ProcessLocalFile processes a file from the local filesystem
-func (s *DocumentService) ProcessLocalFile(ctx context.Context, userID string, filePath string) (*model.Document, error) {
+// ProcessLocalFile processes a file from the local filesystem. With batch set its chunks are
+// embedded through the OpenAI Batch API and join vector search once the batch completes.
+// options override the chunking strategy and chunk size.
+func (s *DocumentService) ProcessLocalFile(ctx context.Context, userID string, filePath string, batch bool, options ChunkingOptions) (*model.Document, error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
-	allowedTypes := map[string]bool{
-		".pdf": true, ".txt": true, ".md": true,
-		".json": true, ".csv": true,
-	}
-	if !allowedTypes[ext] {
-		return nil, fmt.Errorf("unsupported file type: %s", ext)
+	if !parser.Supported(filePath) {
+		return nil, fmt.Errorf("%w: %s", apperror.ErrUnsupportedFileType, ext)
 	}
 
-	// Read file
-	content, err := os.ReadFile(filePath)
+	// Open file; it's read as needed rather than into memory
+	content, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer content.Close()
+	info, err := content.Stat()
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
 	// Calculate hash
-	hash := sha256.Sum256(content)
-	fileHash := hex.EncodeToString(hash[:])
-
-	// Extract text based on file type
-	var text string
-	switch ext {
-	case ".txt", ".md", ".json", ".csv":
-		text = string(content)
-	case ".pdf":
-		text, err = s.extractTextFromPDF(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to extract text from PDF: %w", err)
-		}
+	fileHash, err := hashContent(content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicate(ctx, userID, fileHash); err != nil {
+		return nil, err
 	}
 
-	// Chunk the text
-	chunks := utils.ChunkText(text, 500, 50)
+	chunking, err := s.chunkingFor(ctx, userID, filePath, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract and chunk the text page by page, reading scans and photos with OCR
+	chunks, secrets, err := s.extractChunks(ctx, userID, filePath, content, info.Size(), "", chunking)
+	if err != nil {
+		return nil, err
+	}
 	if len(chunks) == 0 {
 		return nil, fmt.Errorf("no text content found in document")
 	}
 
-	// Generate embeddings
-	embeddings, err := s.embeddingService.GenerateEmbeddings(ctx, chunks)
+	// Repeated boilerplate is embedded once
+	chunks = s.dedupe.Dedupe(ctx, userID, chunks)
+
+	// Generate embeddings with the model of the user's collection
+	provider, err := s.embeddings.ForUser(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+		return nil, err
+	}
+	embedCtx, tracker := withUsageTracker(ctx)
+	var embeddings [][]float32
+	if !batch {
+		embeddings, err = provider.GenerateEmbeddings(embedCtx, chunkContents(chunks), EmbeddingDocument)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+		}
 	}
 
 	// Storage path (use relative path from knowledge base if possible)
 	filename := filepath.Base(filePath)
 	storagePath := fmt.Sprintf("%s/%s/%s", userID, fileHash, filename)
-	
+
 	// Upload to storage if it's not already there (or just use local driver)
-	if err := s.storageDriver.UploadFile(ctx, storagePath, bytes.NewReader(content)); err != nil {
+	if err := s.storageDriver.UploadFile(ctx, storagePath, content); err != nil {
 		return nil, fmt.Errorf("failed to upload file: %w", err)
 	}
 
@@ -223,66 +298,273 @@ func (s *DocumentService) ProcessLocalFile(ctx context.Context, userID string, f
 		UserID:      userID,
 		Filename:    filename,
 		FileType:    ext,
-		FileSize:    int64(len(content)),
+		FileSize:    info.Size(),
 		FileHash:    fileHash,
 		StoragePath: storagePath,
 		TotalChunks: len(chunks),
 	}
+	chunking.record(doc)
 
 	if err := s.documentRepo.Create(ctx, doc); err != nil {
+		// A duplicate's storage path is that of the document already holding the content
+		if !errors.Is(err, repository.ErrDuplicateDocument) {
+			s.discardFile(ctx, storagePath)
+		}
 		return nil, fmt.Errorf("failed to create document record: %w", err)
 	}
+	recordEmbeddingUsage(ctx, s.documentRepo, tracker, model.EmbeddingUsage{
+		UserID:     userID,
+		DocumentID: doc.ID,
+		Filename:   filename,
+		Source:     EmbeddingSourceKnowledgeBase,
+		Folder:     filepath.Dir(filePath),
+		Model:      provider.Model(),
+	})
 
 	// Ensure vector collection exists
-	vectorSize := uint64(s.embeddingService.GetDimensions())
-	if err := s.vectorRepo.EnsureCollection(ctx, userID, vectorSize); err != nil {
+	vectorSize := uint64(provider.Dimensions())
+	if err := s.vectorRepo.EnsureCollection(ctx, userID, vectorSize, sparseVectorConfig(s.sparseEncoder)); err != nil {
+		s.discardDocument(ctx, doc)
 		return nil, fmt.Errorf("failed to ensure collection: %w", err)
 	}
 
 	// Store vectors
 	var points []*model.VectorPoint
-	for i, embedding := range embeddings {
+	for i := range chunks {
 		point := &model.VectorPoint{
-			ID:     fmt.Sprintf("%s_chunk_%d", doc.ID, i),
-			Vector: embedding,
-			Payload: map[string]interface{}{
+			ID: chunkPointID(doc.ID, i),
+			Payload: chunkPayload(chunks[i], map[string]interface{}{
 				"document_id": doc.ID,
 				"user_id":     userID,
 				"filename":    filename,
 				"file_type":   ext,
-				"chunk_index": i,
-				"content":     chunks[i],
-			},
+			}),
+		}
+		if !batch {
+			point.Vector = embeddings[i]
 		}
 		points = append(points, point)
 	}
 
+	// Batched chunks get their vectors once OpenAI completes the batch
+	if batch {
+		if err := s.batches.Submit(ctx, provider, doc, points, EmbeddingSourceKnowledgeBase, filepath.Dir(filePath)); err != nil {
+			s.discardDocument(ctx, doc)
+			return nil, err
+		}
+		s.secrets.Report(ctx, doc, secrets)
+		return doc, nil
+	}
+
+	// Sparse vectors enable hybrid retrieval; without them the chunks are still found by embedding
+	if err := attachSparseVectors(ctx, s.sparseEncoder, points); err != nil {
+		logger.Warn("Failed to generate sparse vectors", "document_id", doc.ID, "error", err)
+	}
+
 	if err := s.vectorRepo.InsertVectors(ctx, userID, points); err != nil {
+		s.discardDocument(ctx, doc)
 		return nil, fmt.Errorf("failed to insert vectors: %w", err)
 	}
 
+	// Keep the chunk text searchable for the keyword fallback
+	if err := s.chunkRepo.InsertChunks(ctx, userID, points); err != nil {
+		logger.Error("Failed to store chunk text", "document_id", doc.ID, "error", err)
+	}
+
+	s.secrets.Report(ctx, doc, secrets)
 	return doc, nil
 }
 
-func (s *DocumentService) extractTextFromPDF(path string) (string, error) {
-	f, r, err := pdf.Open(path)
-	if err != nil {
-		return "", err
+// JobIngestLocalFile is the queue job type for indexing a knowledge base file
+const JobIngestLocalFile = "ingest.local_file"
+
+// IngestLocalFileJob is the payload of a JobIngestLocalFile job
+type IngestLocalFileJob struct {
+	UserID string `json:"user_id"`
+	Path   string `json:"path"`
+	// Batch embeds the file through the OpenAI Batch API, for large imports
+	Batch bool `json:"batch,omitempty"`
+	// Chunking overrides the chunking strategy and chunk size
+	Chunking *ChunkingOptions `json:"chunking,omitempty"`
+}
+
+// HandleIngestLocalFile processes a JobIngestLocalFile job.
+// Failures that a retry cannot fix are marked permanent.
+func (s *DocumentService) HandleIngestLocalFile(ctx context.Context, job *queue.Job) error {
+	var payload IngestLocalFileJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("invalid ingest job payload: %w", err))
+	}
+
+	if !parser.Supported(payload.Path) {
+		return queue.Permanent(fmt.Errorf("%w: %s", apperror.ErrUnsupportedFileType, filepath.Ext(payload.Path)))
+	}
+	if _, err := os.Stat(payload.Path); err != nil {
+		return queue.Permanent(fmt.Errorf("file not found: %s", payload.Path))
+	}
+	var chunking ChunkingOptions
+	if payload.Chunking != nil {
+		chunking = *payload.Chunking
+	}
+	if err := chunking.Validate(); err != nil {
+		return queue.Permanent(err)
 	}
-	defer f.Close()
 
-	var buf bytes.Buffer
-	b, err := r.GetPlainText()
+	// Replicas watching the same folder may queue the same file; index it once
+	lock, err := s.lockRepo.TryAcquire(ctx, "ingest:"+payload.UserID+":"+payload.Path)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if lock == nil {
+		return fmt.Errorf("file is being ingested by another worker: %s", payload.Path)
+	}
+	defer lock.Release()
+
+	doc, err := s.ProcessLocalFile(ctx, payload.UserID, payload.Path, payload.Batch, chunking)
+	if errors.Is(err, repository.ErrDuplicateDocument) {
+		logger.Debug("Skipped already indexed file", "file", payload.Path)
+		return nil
 	}
-	
-	_, err = io.Copy(&buf, b)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	logger.Info("Indexed local file", "file", payload.Path, "document_id", doc.ID)
+	return nil
+}
+
+// buildChunks segments the pages' text with the selected (or detected) ingestion profile and
+// chunks each segment, recording the page each chunk starts on
+func buildChunks(filename string, pages []parser.Page, profileName string, chunking chunkingParams, split splitter) ([]model.DocumentChunk, error) {
+	text := parser.Join(pages)
+
+	var p profile.Profile
+	if profileName != "" {
+		var err error
+		p, err = profile.Get(profileName)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		p = profile.Detect(filename, text)
+	}
+
+	segments := []profile.Segment{{Content: text}}
+	if p != nil {
+		segments = p.Segment(text)
+	} else if strings.EqualFold(filepath.Ext(filename), ".md") {
+		segments = markdownSegments(text, chunking)
 	}
 
-	return buf.String(), nil
+	// Chunks follow the text in order, so each is looked up from where the previous one starts.
+	// A chunk of a segment the profile rewrote isn't found and keeps the previous chunk's page.
+	var chunks []model.DocumentChunk
+	offset := 0
+	for _, segment := range segments {
+		contents, err := split(segment.Content)
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range contents {
+			if i := strings.Index(text[offset:], content); i >= 0 {
+				offset += i
+			}
+			chunks = append(chunks, model.DocumentChunk{
+				Content:    content,
+				Page:       parser.PageAt(pages, offset),
+				ChunkIndex: len(chunks),
+				Metadata:   segment.Metadata,
+			})
+		}
+	}
+
+	return chunks, nil
+}
+
+// markdownSegments splits Markdown into chunk-sized segments by heading and code block, each
+// recording its heading path (e.g. "Projects > RAG > TODO") in its metadata
+func markdownSegments(text string, chunking chunkingParams) []profile.Segment {
+	var segments []profile.Segment
+	for _, chunk := range utils.ChunkMarkdown(text, chunking.size, chunking.overlap) {
+		var metadata map[string]interface{}
+		if len(chunk.HeadingPath) > 0 {
+			metadata = map[string]interface{}{"heading_path": strings.Join(chunk.HeadingPath, " > ")}
+		}
+		segments = append(segments, profile.Segment{Content: chunk.Content, Metadata: metadata})
+	}
+	return segments
+}
+
+// chunkPointID returns the ID of a document's chunk in the vector store and chunk table. Qdrant
+// only accepts UUIDs, so it is derived from the document ID and chunk position.
+func chunkPointID(documentID string, index int) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("%s_chunk_%d", documentID, index))).String()
+}
+
+// discardFile removes a stored file whose document couldn't be created
+func (s *DocumentService) discardFile(ctx context.Context, storagePath string) {
+	if storagePath == "" {
+		return
+	}
+	if err := s.storageDriver.DeleteFile(context.WithoutCancel(ctx), storagePath); err != nil {
+		logger.Error("Failed to delete file of failed ingestion", "path", storagePath, "error", err)
+	}
+}
+
+// checkDuplicate returns repository.ErrDuplicateDocument when the user already has a document
+// with the content's hash, before any work is done to ingest it again
+func (s *DocumentService) checkDuplicate(ctx context.Context, userID, fileHash string) error {
+	_, err := s.documentRepo.GetByHash(ctx, userID, fileHash)
+	if err == nil {
+		return repository.ErrDuplicateDocument
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// discardDocument removes whatever was stored for a document whose ingestion failed part way,
+// so no record is left without vectors or file. Failures are only logged.
+func (s *DocumentService) discardDocument(ctx context.Context, doc *model.Document) {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.vectorRepo.DeleteByDocumentID(ctx, doc.UserID, doc.ID); err != nil {
+		logger.Error("Failed to delete vectors of failed ingestion", "document_id", doc.ID, "error", err)
+	}
+	// Chunk text and batches are deleted with the record
+	if err := s.documentRepo.Delete(ctx, doc.ID); err != nil {
+		logger.Error("Failed to delete record of failed ingestion", "document_id", doc.ID, "error", err)
+	}
+	s.discardFile(ctx, doc.StoragePath)
+}
+
+// chunkContents returns the text content of each chunk
+func chunkContents(chunks []model.DocumentChunk) []string {
+	contents := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		contents[i] = chunk.Content
+	}
+	return contents
+}
+
+// chunkPayload builds the vector payload for a chunk from document-level fields and chunk metadata
+func chunkPayload(chunk model.DocumentChunk, fields map[string]interface{}) map[string]interface{} {
+	payload := make(map[string]interface{}, len(fields)+len(chunk.Metadata)+2)
+	for key, value := range chunk.Metadata {
+		payload[key] = value
+	}
+	for key, value := range fields {
+		payload[key] = value
+	}
+	payload["chunk_index"] = chunk.ChunkIndex
+	if chunk.Page > 0 {
+		payload["page"] = chunk.Page
+	}
+	payload["content"] = chunk.Content
+	if chunk.SimHash != 0 {
+		payload["simhash"] = formatSimHash(chunk.SimHash)
+	}
+	return payload
 }
 
 // ListDocuments lists all documents for a user
@@ -290,6 +572,31 @@ func (s *DocumentService) ListDocuments(ctx context.Context, userID string) ([]*
 	return s.documentRepo.ListByUserID(ctx, userID)
 }
 
+// ListPinnedDocuments lists a user's pinned documents
+func (s *DocumentService) ListPinnedDocuments(ctx context.Context, userID string) ([]*model.Document, error) {
+	return s.documentRepo.ListPinned(ctx, userID)
+}
+
+// ListFavoriteDocuments lists a user's favorite documents
+func (s *DocumentService) ListFavoriteDocuments(ctx context.Context, userID string) ([]*model.Document, error) {
+	return s.documentRepo.ListFavorites(ctx, userID)
+}
+
+// SetPinned pins or unpins a document; pinned documents are boosted in retrieval
+func (s *DocumentService) SetPinned(ctx context.Context, userID, documentID string, pinned bool) error {
+	return s.documentRepo.SetPinned(ctx, userID, documentID, pinned)
+}
+
+// SetFavorite marks or unmarks a document as favorite
+func (s *DocumentService) SetFavorite(ctx context.Context, userID, documentID string, favorite bool) error {
+	return s.documentRepo.SetFavorite(ctx, userID, documentID, favorite)
+}
+
+// SetCanary marks or unmarks a document as a canary; accessing a canary alerts the user
+func (s *DocumentService) SetCanary(ctx context.Context, userID, documentID string, canary bool) error {
+	return s.documentRepo.SetCanary(ctx, userID, documentID, canary)
+}
+
 // GetDocument gets a single document
 func (s *DocumentService) GetDocument(ctx context.Context, userID, documentID string) (*model.Document, error) {
 	doc, err := s.documentRepo.GetByID(ctx, documentID)
@@ -297,14 +604,34 @@ func (s *DocumentService) GetDocument(ctx context.Context, userID, documentID st
 		return nil, err
 	}
 
-	// Verify ownership
+	// Verify ownership; another user's document is reported missing so its ID isn't confirmed
 	if doc.UserID != userID {
-		return nil, fmt.Errorf("unauthorized")
+		return nil, fmt.Errorf("document %w", apperror.ErrNotFound)
 	}
 
 	return doc, nil
 }
 
+// OpenDocument returns a document with a reader over its stored file; the caller closes the reader
+func (s *DocumentService) OpenDocument(ctx context.Context, userID, documentID string) (*model.Document, io.ReadCloser, error) {
+	doc, err := s.GetDocument(ctx, userID, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Documents imported without their file only have their chunks
+	if doc.StoragePath == "" {
+		return nil, nil, fmt.Errorf("document file %w", apperror.ErrNotFound)
+	}
+
+	file, err := s.storageDriver.GetFile(ctx, doc.StoragePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	return doc, file, nil
+}
+
 // DeleteDocument deletes a document and its vectors
 func (s *DocumentService) DeleteDocument(ctx context.Context, userID, documentID string) error {
 	// Get document
@@ -314,8 +641,10 @@ func (s *DocumentService) DeleteDocument(ctx context.Context, userID, documentID
 	}
 
 	// Delete from storage
-	if err := s.storageDriver.DeleteFile(ctx, doc.StoragePath); err != nil {
-		return fmt.Errorf("failed to delete file: %w", err)
+	if doc.StoragePath != "" {
+		if err := s.storageDriver.DeleteFile(ctx, doc.StoragePath); err != nil {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
 	}
 
 	// Delete vectors
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/utils"
+)
+
+// dryRunMaxChunks caps the chunks returned with their content; counts and cost cover them all
+const dryRunMaxChunks = 200
+
+// DryRunChunk is a chunk an upload would be split into
+type DryRunChunk struct {
+	Index    int                    `json:"index"`
+	Page     int                    `json:"page"`
+	Tokens   int                    `json:"tokens"`
+	Content  string                 `json:"content"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// DryRunResult describes how a file would be ingested, without embedding or storing anything
+type DryRunResult struct {
+	Filename string `json:"filename"`
+	FileType string `json:"file_type"`
+	FileSize int64  `json:"file_size"`
+	// ChunkingStrategy, ChunkSize and ChunkOverlap are the parameters the file would be chunked
+	// with, as recorded on the document
+	ChunkingStrategy string `json:"chunking_strategy"`
+	ChunkSize        int    `json:"chunk_size"`
+	ChunkOverlap     int    `json:"chunk_overlap"`
+	// TotalChunks counts the chunks that would be embedded, after DuplicateChunks were dropped
+	TotalChunks     int `json:"total_chunks"`
+	DuplicateChunks int `json:"duplicate_chunks"`
+	// Chunks holds the first dryRunMaxChunks chunks
+	Chunks      []DryRunChunk `json:"chunks"`
+	TotalTokens int           `json:"total_tokens"`
+	// MinTokens, MaxTokens and AvgTokens describe the chunk sizes
+	MinTokens int     `json:"min_tokens"`
+	MaxTokens int     `json:"max_tokens"`
+	AvgTokens float64 `json:"avg_tokens"`
+	// Model is the user's embedding model and EstimatedCostUSD the price of embedding the chunks
+	// with it (zero for local and unpriced models); cached embeddings would cost less
+	Model            string  `json:"model"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+	// SecretsFound counts credentials that would be redacted before embedding
+	SecretsFound int      `json:"secrets_found"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+// DryRunDocument extracts and chunks an upload as UploadDocument would, reporting the chunks,
+// their token counts and the estimated embedding cost without embedding or storing anything.
+// Semantic chunking needs sentence embeddings, so it is previewed with fixed chunks.
+func (s *DocumentService) DryRunDocument(ctx context.Context, userID string, file *multipart.FileHeader, profileName string, options ChunkingOptions) (*DryRunResult, error) {
+	if err := validateUpload(file.Filename, file.Size); err != nil {
+		return nil, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	chunking, err := s.chunkingFor(ctx, userID, file.Filename, options)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := s.embeddings.ForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DryRunResult{
+		Filename:         file.Filename,
+		FileType:         strings.ToLower(filepath.Ext(file.Filename)),
+		FileSize:         file.Size,
+		ChunkingStrategy: chunking.strategy,
+		ChunkSize:        chunking.size,
+		ChunkOverlap:     chunking.overlap,
+		Model:            provider.Model(),
+		Chunks:           []DryRunChunk{},
+	}
+
+	preview := chunking
+	if chunking.strategy == ChunkingSemantic {
+		preview.strategy = ChunkingFixed
+		result.Warnings = append(result.Warnings,
+			"semantic chunks depend on sentence embeddings, so fixed chunks are shown; embedding the sentences costs about as much again")
+	}
+
+	chunks, secrets, err := s.extractChunks(ctx, userID, file.Filename, src, file.Size, profileName, preview)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no text content found in document")
+	}
+	kept := s.dedupe.Dedupe(ctx, userID, chunks)
+	result.DuplicateChunks = len(chunks) - len(kept)
+	result.SecretsFound = len(secrets)
+
+	summarizeDryRun(result, kept)
+	cost := float64(result.TotalTokens) * priceFor(result.Model).input / 1e6
+	if chunking.strategy == ChunkingSemantic {
+		cost *= 2
+	}
+	result.EstimatedCostUSD = cost
+	return result, nil
+}
+
+// summarizeDryRun counts the chunks' tokens and keeps the first dryRunMaxChunks of them
+func summarizeDryRun(result *DryRunResult, chunks []model.DocumentChunk) {
+	result.TotalChunks = len(chunks)
+	for i, chunk := range chunks {
+		tokens := utils.CountTokens(chunk.Content)
+		result.TotalTokens += tokens
+		if i == 0 || tokens < result.MinTokens {
+			result.MinTokens = tokens
+		}
+		result.MaxTokens = max(result.MaxTokens, tokens)
+
+		if i < dryRunMaxChunks {
+			result.Chunks = append(result.Chunks, DryRunChunk{
+				Index:    i,
+				Page:     chunk.Page,
+				Tokens:   tokens,
+				Content:  chunk.Content,
+				Metadata: chunk.Metadata,
+			})
+		}
+	}
+	if len(chunks) > 0 {
+		result.AvgTokens = float64(result.TotalTokens) / float64(len(chunks))
+	}
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Embedding batch statuses
+const (
+	EmbeddingBatchPending   = "pending"
+	EmbeddingBatchCompleted = "completed"
+	// EmbeddingBatchDiscarded batches finished after the user moved to another embedding model
+	EmbeddingBatchDiscarded = "discarded"
+)
+
+// EmbeddingBatchService embeds documents through the OpenAI Batch API, at half the price of the
+// embeddings API, for large imports that don't need to be searchable right away. Chunk text is
+// stored on submission, so documents are found by keyword search in the meantime, and a scheduled
+// poll writes each batch's embeddings into Qdrant once OpenAI has completed it.
+type EmbeddingBatchService struct {
+	batchRepo     *repository.EmbeddingBatchRepository
+	documentRepo  *repository.DocumentRepository
+	chunkRepo     *repository.ChunkRepository
+	vectorRepo    *repository.VectorRepository
+	embeddings    *EmbeddingProviders
+	sparseEncoder SparseEncoder
+}
+
+// NewEmbeddingBatchService creates a new embedding batch service
+func NewEmbeddingBatchService(
+	batchRepo *repository.EmbeddingBatchRepository,
+	documentRepo *repository.DocumentRepository,
+	chunkRepo *repository.ChunkRepository,
+	vectorRepo *repository.VectorRepository,
+	embeddings *EmbeddingProviders,
+	sparseEncoder SparseEncoder,
+) *EmbeddingBatchService {
+	return &EmbeddingBatchService{
+		batchRepo:     batchRepo,
+		documentRepo:  documentRepo,
+		chunkRepo:     chunkRepo,
+		vectorRepo:    vectorRepo,
+		embeddings:    embeddings,
+		sparseEncoder: sparseEncoder,
+	}
+}
+
+// Submit stores the text of a new document's chunks and submits their embeddings as a batch.
+// When provider can't embed in batches, or the batch can't be submitted, the chunks are embedded
+// right away instead. source and folder are recorded with the embedding usage.
+func (s *EmbeddingBatchService) Submit(ctx context.Context, provider EmbeddingProvider, doc *model.Document, points []*model.VectorPoint, source, folder string) error {
+	if err := s.chunkRepo.InsertChunks(ctx, doc.UserID, points); err != nil {
+		return fmt.Errorf("failed to store chunk text: %w", err)
+	}
+
+	usage := model.EmbeddingUsage{
+		UserID:     doc.UserID,
+		DocumentID: doc.ID,
+		Filename:   doc.Filename,
+		Source:     source,
+		Folder:     folder,
+		Model:      provider.Model(),
+	}
+
+	openAI := batchEmbeddingProvider(provider)
+	if openAI == nil {
+		logger.Warn("Embedding provider has no batch API, embedding directly", "document_id", doc.ID, "model", provider.Model())
+		return s.writeVectors(ctx, provider, usage, points, 0)
+	}
+
+	ids := make([]string, len(points))
+	texts := make([]string, len(points))
+	for i, point := range points {
+		ids[i] = point.ID
+		texts[i], _ = point.Payload["content"].(string)
+	}
+	batchID, fileID, err := openAI.SubmitBatch(ctx, ids, texts)
+	if err != nil {
+		logger.Warn("Failed to submit embedding batch, embedding directly", "document_id", doc.ID, "error", err)
+		return s.writeVectors(ctx, provider, usage, points, 0)
+	}
+
+	batch := &model.EmbeddingBatch{
+		UserID:      doc.UserID,
+		DocumentID:  doc.ID,
+		BatchID:     batchID,
+		InputFileID: fileID,
+		Model:       provider.Model(),
+		Source:      source,
+		Folder:      folder,
+	}
+	if err := s.batchRepo.Create(ctx, batch); err != nil {
+		// Nothing would pick the batch's results up
+		logger.Warn("Failed to record embedding batch, embedding directly", "document_id", doc.ID, "batch_id", batchID, "error", err)
+		return s.writeVectors(ctx, provider, usage, points, 0)
+	}
+
+	logger.Info("Submitted embedding batch", "document_id", doc.ID, "batch_id", batchID, "chunks", len(points))
+	return nil
+}
+
+// Poll writes the embeddings of every pending batch OpenAI has finished. Chunks a batch has no
+// embedding for, because it failed, expired or was cancelled, are embedded directly so no
+// document is left out of vector search.
+func (s *EmbeddingBatchService) Poll(ctx context.Context) error {
+	batches, err := s.batchRepo.ListPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, batch := range batches {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// A failing batch is retried on the next poll without holding up the others
+		if err := s.poll(ctx, batch); err != nil {
+			logger.Error("Failed to process embedding batch", "batch_id", batch.BatchID, "document_id", batch.DocumentID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// poll checks one batch and writes its document's vectors once OpenAI has finished it
+func (s *EmbeddingBatchService) poll(ctx context.Context, batch *model.EmbeddingBatch) error {
+	provider, err := s.embeddings.ForUser(ctx, batch.UserID)
+	if err != nil {
+		return err
+	}
+	if provider.Model() != batch.Model {
+		// Re-embedding the user's documents for their new model already covered these chunks
+		logger.Info("Discarded embedding batch of a replaced model", "batch_id", batch.BatchID, "model", batch.Model)
+		return s.batchRepo.Complete(ctx, batch.ID, EmbeddingBatchDiscarded, "embedding model changed to "+provider.Model())
+	}
+
+	var status *OpenAIBatch
+	openAI := batchEmbeddingProvider(provider)
+	if openAI == nil {
+		// The server no longer embeds with OpenAI; the batch can't be read
+		status = &OpenAIBatch{Status: "unavailable"}
+	} else {
+		status, err = openAI.GetBatch(ctx, batch.BatchID)
+		if err != nil {
+			return err
+		}
+		if !status.Done() {
+			return nil
+		}
+	}
+
+	doc, err := s.documentRepo.GetByID(ctx, batch.DocumentID)
+	if err != nil {
+		return err
+	}
+	points, err := s.chunkRepo.ListByDocumentID(ctx, doc.ID)
+	if err != nil {
+		return err
+	}
+
+	tokens := 0
+	if status.OutputFileID != "" {
+		texts := make(map[string]string, len(points))
+		for _, point := range points {
+			texts[point.ID], _ = point.Payload["content"].(string)
+		}
+		embeddings, batchTokens, err := openAI.BatchResults(ctx, status.OutputFileID, texts)
+		if err != nil {
+			return err
+		}
+		tokens = batchTokens
+		for _, point := range points {
+			if embedding, ok := embeddings[point.ID]; ok && len(embedding) == provider.Dimensions() {
+				point.Vector = embedding
+			}
+		}
+	}
+
+	missing := 0
+	for _, point := range points {
+		if point.Vector == nil {
+			missing++
+		}
+	}
+	lastError := ""
+	switch {
+	case status.Status != "completed":
+		lastError = status.Reason()
+	case missing > 0:
+		lastError = fmt.Sprintf("%d of %d embeddings failed", missing, len(points))
+	}
+	if lastError != "" {
+		logger.Warn("Embedding batch incomplete, embedding the rest directly", "batch_id", batch.BatchID, "document_id", doc.ID, "missing", missing, "reason", lastError)
+	}
+
+	usage := model.EmbeddingUsage{
+		UserID:     doc.UserID,
+		DocumentID: doc.ID,
+		Filename:   doc.Filename,
+		Source:     batch.Source,
+		Folder:     batch.Folder,
+		Model:      batch.Model,
+	}
+	if err := s.writeVectors(ctx, provider, usage, points, tokens); err != nil {
+		return err
+	}
+	if err := s.batchRepo.Complete(ctx, batch.ID, EmbeddingBatchCompleted, lastError); err != nil {
+		return err
+	}
+
+	// OpenAI keeps batch files until they're deleted
+	if openAI != nil {
+		for _, fileID := range []string{batch.InputFileID, status.OutputFileID} {
+			if fileID == "" {
+				continue
+			}
+			if err := openAI.DeleteBatchFile(ctx, fileID); err != nil {
+				logger.Warn("Failed to delete batch file", "batch_id", batch.BatchID, "file_id", fileID, "error", err)
+			}
+		}
+	}
+
+	logger.Info("Stored embeddings of batch", "batch_id", batch.BatchID, "document_id", doc.ID, "chunks", len(points))
+	return nil
+}
+
+// writeVectors embeds the points that have no vector yet, writes all of them into the user's docs
+// collection and records the document's embedding usage, including batchTokens processed by the
+// Batch API
+func (s *EmbeddingBatchService) writeVectors(ctx context.Context, provider EmbeddingProvider, usage model.EmbeddingUsage, points []*model.VectorPoint, batchTokens int) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	embedCtx, tracker := withUsageTracker(ctx)
+	trackBatchEmbeddingUsage(embedCtx, usage.Model, batchTokens)
+
+	var missing []*model.VectorPoint
+	var texts []string
+	for _, point := range points {
+		if point.Vector == nil {
+			content, _ := point.Payload["content"].(string)
+			missing = append(missing, point)
+			texts = append(texts, content)
+		}
+	}
+	if len(missing) > 0 {
+		embeddings, err := provider.GenerateEmbeddings(embedCtx, texts, EmbeddingDocument)
+		if err != nil {
+			return fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+		if len(embeddings) != len(missing) {
+			return fmt.Errorf("expected %d embeddings, got %d", len(missing), len(embeddings))
+		}
+		for i, point := range missing {
+			point.Vector = embeddings[i]
+		}
+	}
+
+	// Sparse vectors enable hybrid retrieval; without them the chunks are still found by embedding
+	if err := attachSparseVectors(ctx, s.sparseEncoder, points); err != nil {
+		logger.Warn("Failed to generate sparse vectors", "document_id", usage.DocumentID, "error", err)
+	}
+
+	if err := s.vectorRepo.EnsureCollection(ctx, usage.UserID, uint64(provider.Dimensions()), sparseVectorConfig(s.sparseEncoder)); err != nil {
+		return fmt.Errorf("failed to ensure collection: %w", err)
+	}
+	if err := s.vectorRepo.InsertVectors(ctx, usage.UserID, points); err != nil {
+		return fmt.Errorf("failed to store vectors: %w", err)
+	}
+
+	recordEmbeddingUsage(ctx, s.documentRepo, tracker, usage)
+	return nil
+}
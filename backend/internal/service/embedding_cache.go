@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// CachedEmbeddingProvider wraps an embedding provider with a persistent cache keyed by the
+// SHA-256 of each text, so re-uploading or re-syncing unchanged files doesn't call the embedding
+// API again. Only document embeddings are cached; queries rarely repeat.
+type CachedEmbeddingProvider struct {
+	provider EmbeddingProvider
+	cache    *repository.EmbeddingCacheRepository
+}
+
+// cacheRefreshKey marks a context whose document embeddings are generated again and replace the
+// cached ones
+type cacheRefreshKey struct{}
+
+// withCacheRefresh returns a context in which cached embeddings are ignored and overwritten, for
+// when the provider updated a model's weights without renaming it
+func withCacheRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheRefreshKey{}, true)
+}
+
+// NewCachedEmbeddingProvider creates a caching wrapper around provider
+func NewCachedEmbeddingProvider(provider EmbeddingProvider, cache *repository.EmbeddingCacheRepository) *CachedEmbeddingProvider {
+	return &CachedEmbeddingProvider{
+		provider: provider,
+		cache:    cache,
+	}
+}
+
+// GenerateEmbeddings returns cached embeddings where available and generates the rest in one
+// call to the provider. A failing cache is logged and bypassed rather than failing ingestion.
+func (p *CachedEmbeddingProvider) GenerateEmbeddings(ctx context.Context, texts []string, input EmbeddingInput) ([][]float32, error) {
+	if input != EmbeddingDocument || len(texts) == 0 {
+		return p.provider.GenerateEmbeddings(ctx, texts, input)
+	}
+
+	key := repository.EmbeddingCacheKey{
+		Model:      p.provider.Model(),
+		Dimensions: p.provider.Dimensions(),
+		InputType:  string(input),
+	}
+	hashes := make([]string, len(texts))
+	for i, text := range texts {
+		sum := sha256.Sum256([]byte(text))
+		hashes[i] = hex.EncodeToString(sum[:])
+	}
+
+	cached := map[string][]float32{}
+	if refresh, _ := ctx.Value(cacheRefreshKey{}).(bool); !refresh {
+		var err error
+		cached, err = p.cache.GetMany(ctx, key, hashes)
+		if err != nil {
+			logger.Warn("Embedding cache unavailable", "error", err)
+			cached = map[string][]float32{}
+		}
+	}
+
+	// Embed each uncached text once, even if it repeats within the batch
+	var missing []string
+	missingIndex := map[string]int{}
+	for i, hash := range hashes {
+		if _, ok := cached[hash]; ok {
+			continue
+		}
+		if _, ok := missingIndex[hash]; !ok {
+			missingIndex[hash] = len(missing)
+			missing = append(missing, texts[i])
+		}
+	}
+
+	if len(missing) > 0 {
+		generated, err := p.provider.GenerateEmbeddings(ctx, missing, input)
+		if err != nil {
+			return nil, err
+		}
+		if len(generated) != len(missing) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(missing), len(generated))
+		}
+
+		fresh := make(map[string][]float32, len(missingIndex))
+		for hash, i := range missingIndex {
+			fresh[hash] = generated[i]
+			cached[hash] = generated[i]
+		}
+		if err := p.cache.PutMany(ctx, key, fresh); err != nil {
+			logger.Warn("Failed to cache embeddings", "error", err)
+		}
+	}
+
+	logger.Debug("Embedding cache lookup", "model", key.Model, "texts", len(texts), "generated", len(missing))
+
+	embeddings := make([][]float32, len(texts))
+	for i, hash := range hashes {
+		embeddings[i] = cached[hash]
+	}
+	return embeddings, nil
+}
+
+// Dimensions returns the wrapped provider's embedding dimensions
+func (p *CachedEmbeddingProvider) Dimensions() int {
+	return p.provider.Dimensions()
+}
+
+// Model returns the wrapped provider's model name
+func (p *CachedEmbeddingProvider) Model() string {
+	return p.provider.Model()
+}
+
+// uncachedEmbeddingProvider returns the provider behind the cache, for embeddings that must come
+// from the model itself
+func uncachedEmbeddingProvider(provider EmbeddingProvider) EmbeddingProvider {
+	if cached, ok := provider.(*CachedEmbeddingProvider); ok {
+		return cached.provider
+	}
+	return provider
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// defaultCohereEmbeddingModel is used when no embedding model is configured
+const defaultCohereEmbeddingModel = "embed-english-v3.0"
+
+// cohereModelDimensions lists the embedding sizes of the Cohere v3 embedding models
+var cohereModelDimensions = map[string]int{
+	"embed-english-v3.0":            1024,
+	"embed-multilingual-v3.0":       1024,
+	"embed-english-light-v3.0":      384,
+	"embed-multilingual-light-v3.0": 384,
+}
+
+// cohereInputTypes maps embedding inputs to Cohere input types. v3 models are trained to
+// match search_query embeddings against search_document embeddings.
+var cohereInputTypes = map[EmbeddingInput]string{
+	EmbeddingDocument: "search_document",
+	EmbeddingQuery:    "search_query",
+}
+
+// CohereEmbeddingProvider generates embeddings with the Cohere embed API
+type CohereEmbeddingProvider struct {
+	apiKey     string
+	model      string
+	dimensions int
+	httpClient *httpretry.Client
+}
+
+// NewCohereEmbeddingProvider creates a new Cohere embedding provider. A dimensions value of 0 uses the
+// known size of the model; models not in cohereModelDimensions must set it.
+func NewCohereEmbeddingProvider(apiKey, model string, dimensions int) *CohereEmbeddingProvider {
+	if model == "" {
+		model = defaultCohereEmbeddingModel
+	}
+	if dimensions == 0 {
+		dimensions = cohereModelDimensions[model]
+	}
+	return &CohereEmbeddingProvider{
+		apiKey:     apiKey,
+		model:      model,
+		dimensions: dimensions,
+		httpClient: httpretry.New("cohere", 30*time.Second),
+	}
+}
+
+// cohereEmbedRequest represents a Cohere /v2/embed request
+type cohereEmbedRequest struct {
+	Model          string   `json:"model"`
+	Texts          []string `json:"texts"`
+	InputType      string   `json:"input_type"`
+	EmbeddingTypes []string `json:"embedding_types"`
+}
+
+// cohereEmbedResponse represents a Cohere /v2/embed response
+type cohereEmbedResponse struct {
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+	Meta struct {
+		BilledUnits struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"billed_units"`
+	} `json:"meta"`
+}
+
+// GenerateEmbeddings generates embeddings for multiple texts in batches, embedding them as
+// search documents or search queries according to input
+func (p *CohereEmbeddingProvider) GenerateEmbeddings(ctx context.Context, texts []string, input EmbeddingInput) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+	inputType, ok := cohereInputTypes[input]
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding input: %q", input)
+	}
+
+	// Cohere accepts up to 96 texts per request
+	const batchSize = 96
+	var allEmbeddings [][]float32
+
+	for i := 0; i < len(texts); i += batchSize {
+		end := i + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		embeddings, err := p.generateBatch(ctx, texts[i:end], inputType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate batch %d: %w", i/batchSize, err)
+		}
+
+		allEmbeddings = append(allEmbeddings, embeddings...)
+	}
+
+	return allEmbeddings, nil
+}
+
+// generateBatch generates embeddings for a batch of texts
+func (p *CohereEmbeddingProvider) generateBatch(ctx context.Context, texts []string, inputType string) ([][]float32, error) {
+	jsonData, err := json.Marshal(cohereEmbedRequest{
+		Model:          p.model,
+		Texts:          texts,
+		InputType:      inputType,
+		EmbeddingTypes: []string{"float"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.cohere.com/v2/embed", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpretry.ResponseError("cohere", resp)
+	}
+
+	var embedResp cohereEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(embedResp.Embeddings.Float) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embedResp.Embeddings.Float))
+	}
+	trackEmbeddingUsage(ctx, p.model, embedResp.Meta.BilledUnits.InputTokens)
+
+	return embedResp.Embeddings.Float, nil
+}
+
+// Dimensions returns the embedding dimensions for the model
+func (p *CohereEmbeddingProvider) Dimensions() int {
+	return p.dimensions
+}
+
+// Model returns the embedding model name
+func (p *CohereEmbeddingProvider) Model() string {
+	return p.model
+}
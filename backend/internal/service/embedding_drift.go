@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/notification"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// EventEmbeddingDrift is the notification event sent when a user's stored embeddings no longer
+// match what their model produces
+const EventEmbeddingDrift = "embedding.drift"
+
+// Embedding drift monitor parameters
+const (
+	// embeddingDriftSampleSize is the number of chunks re-embedded per user and check
+	embeddingDriftSampleSize = 32
+	// embeddingDriftMinSamples is the fewest chunks with a stored vector for a check to count
+	embeddingDriftMinSamples = 5
+	// embeddingDriftThreshold is the mean cosine distance between stored and fresh embeddings
+	// above which the user is alerted. Hosted models aren't perfectly deterministic, but their
+	// noise stays well below it.
+	embeddingDriftThreshold = 0.02
+)
+
+// EmbeddingDriftMonitor detects when an embedding model was updated upstream under the same
+// name: it re-embeds a sample of each user's chunks and compares the result with the vectors
+// stored for them. Queries embedded with the new weights match documents embedded with the old
+// ones poorly, so a drifted user is told to re-embed their documents.
+type EmbeddingDriftMonitor struct {
+	documentRepo *repository.DocumentRepository
+	chunkRepo    *repository.ChunkRepository
+	vectorRepo   *repository.VectorRepository
+	embeddings   *EmbeddingProviders
+	notifier     notification.Notifier
+}
+
+// NewEmbeddingDriftMonitor creates a new embedding drift monitor
+func NewEmbeddingDriftMonitor(
+	documentRepo *repository.DocumentRepository,
+	chunkRepo *repository.ChunkRepository,
+	vectorRepo *repository.VectorRepository,
+	embeddings *EmbeddingProviders,
+	notifier notification.Notifier,
+) *EmbeddingDriftMonitor {
+	return &EmbeddingDriftMonitor{
+		documentRepo: documentRepo,
+		chunkRepo:    chunkRepo,
+		vectorRepo:   vectorRepo,
+		embeddings:   embeddings,
+		notifier:     notifier,
+	}
+}
+
+// embeddingDrift is the outcome of one user's check
+type embeddingDrift struct {
+	Model   string
+	Samples int
+	// Mean and Max are cosine distances between stored and fresh embeddings
+	Mean float64
+	Max  float64
+}
+
+// Check measures the drift of every user with documents and alerts those above the threshold
+func (m *EmbeddingDriftMonitor) Check(ctx context.Context) error {
+	userIDs, err := m.documentRepo.ListOwnerIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// One user's failure shouldn't skip the others
+		drift, err := m.check(ctx, userID)
+		if err != nil {
+			logger.Error("Failed to check embedding drift", "user_id", userID, "error", err)
+			continue
+		}
+		if drift == nil {
+			continue
+		}
+
+		logger.Info("Checked embedding drift", "user_id", userID, "model", drift.Model, "samples", drift.Samples, "mean", drift.Mean, "max", drift.Max)
+		if drift.Mean > embeddingDriftThreshold {
+			m.alert(ctx, userID, drift)
+		}
+	}
+
+	return nil
+}
+
+// check re-embeds a sample of the user's chunks, bypassing the embedding cache, and compares the
+// embeddings with the stored ones. It returns nil when the user has too few stored vectors.
+func (m *EmbeddingDriftMonitor) check(ctx context.Context, userID string) (*embeddingDrift, error) {
+	chunks, err := m.chunkRepo.SampleChunks(ctx, userID, embeddingDriftSampleSize)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		ids[i] = chunk.ID
+	}
+	stored, err := m.vectorRepo.GetVectors(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chunks of batches still pending have no vector yet
+	var vectors [][]float32
+	var texts []string
+	for _, chunk := range chunks {
+		if vector, ok := stored[chunk.ID]; ok {
+			content, _ := chunk.Payload["content"].(string)
+			vectors = append(vectors, vector)
+			texts = append(texts, content)
+		}
+	}
+	if len(texts) < embeddingDriftMinSamples {
+		return nil, nil
+	}
+
+	provider, err := m.embeddings.ForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	embedCtx, tracker := withUsageTracker(ctx)
+	fresh, err := uncachedEmbeddingProvider(provider).GenerateEmbeddings(embedCtx, texts, EmbeddingDocument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	recordEmbeddingUsage(ctx, m.documentRepo, tracker, model.EmbeddingUsage{
+		UserID: userID,
+		Source: EmbeddingSourceDriftCheck,
+		Model:  provider.Model(),
+	})
+	if len(fresh) != len(vectors) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(vectors), len(fresh))
+	}
+
+	drift := &embeddingDrift{Model: provider.Model(), Samples: len(vectors)}
+	for i, vector := range vectors {
+		distance := 1 - cosineSimilarity(vector, fresh[i])
+		drift.Mean += distance
+		if distance > drift.Max {
+			drift.Max = distance
+		}
+	}
+	drift.Mean /= float64(len(vectors))
+
+	return drift, nil
+}
+
+// alert tells the user their documents should be re-embedded
+func (m *EmbeddingDriftMonitor) alert(ctx context.Context, userID string, drift *embeddingDrift) {
+	logger.Warn("Embedding drift above threshold", "user_id", userID, "model", drift.Model, "mean", drift.Mean, "threshold", embeddingDriftThreshold)
+
+	err := m.notifier.Notify(ctx, notification.Notification{
+		UserID: userID,
+		Event:  EventEmbeddingDrift,
+		Title:  "Your documents should be re-embedded",
+		Body: fmt.Sprintf("Embeddings %s produces now differ from the ones stored for your documents (mean cosine distance %.3f over %d chunks, "+
+			"alert above %.3f), so the model was probably updated by its provider and searches may miss relevant documents. "+
+			"Re-embed them with cmd/reembed -refresh -user %s.", drift.Model, drift.Mean, drift.Samples, embeddingDriftThreshold, userID),
+		Data: map[string]interface{}{
+			"model":     drift.Model,
+			"samples":   drift.Samples,
+			"mean":      drift.Mean,
+			"max":       drift.Max,
+			"threshold": embeddingDriftThreshold,
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to send embedding drift notification", "user_id", userID, "error", err)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// defaultOllamaEmbeddingModel is used when no embedding model is configured
+const defaultOllamaEmbeddingModel = "nomic-embed-text"
+
+// ollamaModelDimensions lists the embedding sizes of common Ollama embedding models
+var ollamaModelDimensions = map[string]int{
+	"nomic-embed-text":       768,
+	"mxbai-embed-large":      1024,
+	"all-minilm":             384,
+	"snowflake-arctic-embed": 1024,
+	"bge-m3":                 1024,
+	// Multilingual models, for documents in languages such as Malay and Chinese
+	"paraphrase-multilingual": 768,
+}
+
+// OllamaEmbeddingProvider generates embeddings with a local Ollama server, so no OpenAI key is needed
+type OllamaEmbeddingProvider struct {
+	baseURL    string
+	model      string
+	dimensions int
+	httpClient *httpretry.Client
+}
+
+// NewOllamaEmbeddingProvider creates a new Ollama embedding provider. A dimensions value of 0 uses the
+// known size of the model; models not in ollamaModelDimensions must set it.
+func NewOllamaEmbeddingProvider(baseURL, model string, dimensions int) *OllamaEmbeddingProvider {
+	if model == "" {
+		model = defaultOllamaEmbeddingModel
+	}
+	if dimensions == 0 {
+		// Tags such as nomic-embed-text:latest share the base model's size
+		base, _, _ := strings.Cut(model, ":")
+		dimensions = ollamaModelDimensions[base]
+	}
+	return &OllamaEmbeddingProvider{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		dimensions: dimensions,
+		// Local models on CPU can be slow on large batches
+		httpClient: httpretry.New("ollama", 120*time.Second),
+	}
+}
+
+// ollamaEmbedRequest represents an Ollama /api/embed request
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// ollamaEmbedResponse represents an Ollama /api/embed response
+type ollamaEmbedResponse struct {
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+// GenerateEmbeddings generates embeddings for multiple texts in batches
+func (p *OllamaEmbeddingProvider) GenerateEmbeddings(ctx context.Context, texts []string, _ EmbeddingInput) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+
+	const batchSize = 32
+	var allEmbeddings [][]float32
+
+	for i := 0; i < len(texts); i += batchSize {
+		end := i + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		embeddings, err := p.generateBatch(ctx, texts[i:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate batch %d: %w", i/batchSize, err)
+		}
+
+		allEmbeddings = append(allEmbeddings, embeddings...)
+	}
+
+	return allEmbeddings, nil
+}
+
+// generateBatch generates embeddings for a batch of texts
+func (p *OllamaEmbeddingProvider) generateBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	jsonData, err := json.Marshal(ollamaEmbedRequest{Model: p.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/embed", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpretry.ResponseError("ollama", resp)
+	}
+
+	var embedResp ollamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embedResp.Embeddings))
+	}
+	trackEmbeddingUsage(ctx, p.model, embedResp.PromptEvalCount)
+
+	return embedResp.Embeddings, nil
+}
+
+// Dimensions returns the embedding dimensions for the model
+func (p *OllamaEmbeddingProvider) Dimensions() int {
+	return p.dimensions
+}
+
+// Model returns the embedding model name
+func (p *OllamaEmbeddingProvider) Model() string {
+	return p.model
+}
@@ -0,0 +1,302 @@
+//go:build onnx
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// defaultONNXEmbeddingModel is used when no embedding model is configured
+const defaultONNXEmbeddingModel = "all-MiniLM-L6-v2"
+
+// onnxModel describes how a sentence embedding model turns token states into one embedding
+type onnxModel struct {
+	// clsPooling uses the [CLS] token's state; otherwise token states are averaged
+	clsPooling bool
+	// maxTokens is the longest input the model was trained on, including [CLS] and [SEP]
+	maxTokens int
+	// queryPrefix is prepended to search queries by models trained with an instruction
+	queryPrefix string
+}
+
+// onnxModels lists the supported local models. Other BERT-style exports use mean pooling over
+// at most 512 tokens.
+var onnxModels = map[string]onnxModel{
+	"all-MiniLM-L6-v2":  {maxTokens: 256},
+	"all-MiniLM-L12-v2": {maxTokens: 256},
+	"bge-small-en-v1.5": {clsPooling: true, maxTokens: 512, queryPrefix: "Represent this sentence for searching relevant passages: "},
+	"bge-base-en-v1.5":  {clsPooling: true, maxTokens: 512, queryPrefix: "Represent this sentence for searching relevant passages: "},
+}
+
+// onnxEnvironment initializes the onnxruntime library once per process
+var onnxEnvironment struct {
+	once sync.Once
+	err  error
+}
+
+// ONNXEmbeddingProvider generates embeddings in-process with onnxruntime, so embedding needs no
+// network access at all. Embeddings are pooled from the model's token states and normalized to
+// unit length.
+type ONNXEmbeddingProvider struct {
+	model      string
+	spec       onnxModel
+	tokenizer  *wordPieceTokenizer
+	session    *ort.DynamicAdvancedSession
+	inputNames []string
+	dimensions int
+	// mu serializes inference; onnxruntime already spreads one batch across the CPU cores
+	mu sync.Mutex
+}
+
+// NewONNXEmbeddingProvider loads model.onnx and vocab.txt from modelDir (a Hugging Face ONNX
+// export, where the model may sit in an onnx/ subdirectory) using the onnxruntime shared library
+// at runtimeLib. A dimensions value of 0 uses the model's output size.
+func NewONNXEmbeddingProvider(modelDir, runtimeLib, model string, dimensions int) (EmbeddingProvider, error) {
+	if modelDir == "" {
+		return nil, fmt.Errorf("ONNX_MODEL_DIR is required for the onnx embedding provider")
+	}
+	if model == "" {
+		model = defaultONNXEmbeddingModel
+	}
+	spec, ok := onnxModels[model]
+	if !ok {
+		spec = onnxModel{maxTokens: 512}
+	}
+
+	modelPath, tokenizer, err := loadONNXModelDir(modelDir, runtimeLib)
+	if err != nil {
+		return nil, err
+	}
+
+	inputNames, outputs, err := onnxModelIO(modelPath)
+	if err != nil {
+		return nil, err
+	}
+
+	output := outputs[0]
+	for _, o := range outputs {
+		if o.Name == "last_hidden_state" {
+			output = o
+		}
+	}
+	if len(output.Dimensions) != 3 {
+		return nil, fmt.Errorf("model %s output %q is not a [batch, tokens, hidden] tensor", modelPath, output.Name)
+	}
+	native := int(output.Dimensions[2])
+	switch {
+	case native <= 0 && dimensions <= 0:
+		return nil, fmt.Errorf("model %s has a dynamic hidden size; set EMBEDDING_DIMENSIONS", modelPath)
+	case native <= 0:
+		native = dimensions
+	case dimensions != 0 && dimensions != native:
+		return nil, fmt.Errorf("%s produces %d-dimension embeddings; set EMBEDDING_TRUNCATE=true to shorten them", model, native)
+	}
+
+	session, err := ort.NewDynamicAdvancedSession(modelPath, inputNames, []string{output.Name}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model %s: %w", modelPath, err)
+	}
+
+	return &ONNXEmbeddingProvider{
+		model:      model,
+		spec:       spec,
+		tokenizer:  tokenizer,
+		session:    session,
+		inputNames: inputNames,
+		dimensions: native,
+	}, nil
+}
+
+// loadONNXModelDir finds the model in a Hugging Face ONNX export directory, loads its vocabulary
+// and initializes onnxruntime from runtimeLib
+func loadONNXModelDir(modelDir, runtimeLib string) (string, *wordPieceTokenizer, error) {
+	modelPath := filepath.Join(modelDir, "model.onnx")
+	if _, err := os.Stat(modelPath); err != nil {
+		modelPath = filepath.Join(modelDir, "onnx", "model.onnx")
+	}
+	tokenizer, err := loadWordPieceTokenizer(filepath.Join(modelDir, "vocab.txt"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	onnxEnvironment.once.Do(func() {
+		ort.SetSharedLibraryPath(runtimeLib)
+		onnxEnvironment.err = ort.InitializeEnvironment()
+	})
+	if onnxEnvironment.err != nil {
+		return "", nil, fmt.Errorf("failed to initialize onnxruntime from %s: %w", runtimeLib, onnxEnvironment.err)
+	}
+
+	return modelPath, tokenizer, nil
+}
+
+// onnxModelIO returns the token inputs a BERT-style model takes and its outputs. Exports differ in
+// whether they take token_type_ids, so only the inputs the model declares are passed.
+func onnxModelIO(modelPath string) ([]string, []ort.InputOutputInfo, error) {
+	inputs, outputs, err := ort.GetInputOutputInfo(modelPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read model %s: %w", modelPath, err)
+	}
+
+	var inputNames []string
+	for _, input := range inputs {
+		switch input.Name {
+		case "input_ids", "attention_mask", "token_type_ids":
+			inputNames = append(inputNames, input.Name)
+		default:
+			return nil, nil, fmt.Errorf("model %s has unsupported input %q", modelPath, input.Name)
+		}
+	}
+
+	return inputNames, outputs, nil
+}
+
+// GenerateEmbeddings generates embeddings for multiple texts in batches
+func (p *ONNXEmbeddingProvider) GenerateEmbeddings(ctx context.Context, texts []string, input EmbeddingInput) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+
+	const batchSize = 16
+	allEmbeddings := make([][]float32, 0, len(texts))
+
+	for i := 0; i < len(texts); i += batchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		end := i + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		embeddings, err := p.generateBatch(ctx, texts[i:end], input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate batch %d: %w", i/batchSize, err)
+		}
+
+		allEmbeddings = append(allEmbeddings, embeddings...)
+	}
+
+	return allEmbeddings, nil
+}
+
+// generateBatch runs one padded batch through the model and pools each text's token states
+func (p *ONNXEmbeddingProvider) generateBatch(ctx context.Context, texts []string, input EmbeddingInput) ([][]float32, error) {
+	encoded := make([][]int64, len(texts))
+	seqLen, tokens := 0, 0
+	for i, text := range texts {
+		if input == EmbeddingQuery {
+			text = p.spec.queryPrefix + text
+		}
+		encoded[i] = p.tokenizer.Encode(text, p.spec.maxTokens)
+		seqLen = max(seqLen, len(encoded[i]))
+		tokens += len(encoded[i])
+	}
+
+	batch := len(texts)
+	dims := p.dimensions
+	embeddings := make([][]float32, batch)
+	err := runONNXBatch(p.session, &p.mu, p.inputNames, p.tokenizer.pad, encoded, seqLen, func(states []float32) {
+		for i := range encoded {
+			embedding := make([]float32, dims)
+			if p.spec.clsPooling {
+				copy(embedding, states[i*seqLen*dims:i*seqLen*dims+dims])
+			} else {
+				// Mean of the states of real (unpadded) tokens
+				for j := range encoded[i] {
+					offset := (i*seqLen + j) * dims
+					for k := 0; k < dims; k++ {
+						embedding[k] += states[offset+k]
+					}
+				}
+				count := float32(len(encoded[i]))
+				for k := range embedding {
+					embedding[k] /= count
+				}
+			}
+			embeddings[i] = normalizeEmbedding(embedding)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	trackEmbeddingUsage(ctx, p.model, tokens)
+
+	return embeddings, nil
+}
+
+// runONNXBatch pads the encoded texts to seqLen, runs them through the session as one batch and
+// passes the output tensor's data to read, which must not keep it
+func runONNXBatch(session *ort.DynamicAdvancedSession, mu *sync.Mutex, inputNames []string, pad int64, encoded [][]int64, seqLen int, read func(output []float32)) error {
+	batch := len(encoded)
+	ids := make([]int64, batch*seqLen)
+	mask := make([]int64, batch*seqLen)
+	for i, tokenIDs := range encoded {
+		for j := 0; j < seqLen; j++ {
+			if j < len(tokenIDs) {
+				ids[i*seqLen+j] = tokenIDs[j]
+				mask[i*seqLen+j] = 1
+			} else {
+				ids[i*seqLen+j] = pad
+			}
+		}
+	}
+
+	shape := ort.NewShape(int64(batch), int64(seqLen))
+	inputs := make([]ort.Value, len(inputNames))
+	defer func() {
+		for _, v := range inputs {
+			if v != nil {
+				v.Destroy()
+			}
+		}
+	}()
+	for i, name := range inputNames {
+		data := mask
+		switch name {
+		case "input_ids":
+			data = ids
+		case "token_type_ids":
+			data = make([]int64, batch*seqLen)
+		}
+		tensor, err := ort.NewTensor(shape, data)
+		if err != nil {
+			return fmt.Errorf("failed to create %s tensor: %w", name, err)
+		}
+		inputs[i] = tensor
+	}
+
+	outputs := []ort.Value{nil}
+	mu.Lock()
+	err := session.Run(inputs, outputs)
+	mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("inference failed: %w", err)
+	}
+	defer outputs[0].Destroy()
+
+	tensor, ok := outputs[0].(*ort.Tensor[float32])
+	if !ok {
+		return fmt.Errorf("unexpected output type %T", outputs[0])
+	}
+	read(tensor.GetData())
+
+	return nil
+}
+
+// Dimensions returns the embedding dimensions for the model
+func (p *ONNXEmbeddingProvider) Dimensions() int {
+	return p.dimensions
+}
+
+// Model returns the embedding model name
+func (p *ONNXEmbeddingProvider) Model() string {
+	return p.model
+}
@@ -0,0 +1,11 @@
+//go:build !onnx
+
+package service
+
+import "fmt"
+
+// NewONNXEmbeddingProvider reports that local ONNX inference is unavailable. It needs cgo and the
+// onnxruntime library, so it is only compiled into builds made with -tags onnx.
+func NewONNXEmbeddingProvider(modelDir, runtimeLib, model string, dimensions int) (EmbeddingProvider, error) {
+	return nil, fmt.Errorf("this build has no ONNX support; rebuild with CGO_ENABLED=1 go build -tags onnx")
+}
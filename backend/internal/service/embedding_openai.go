@@ -0,0 +1,281 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// Load tokenizer vocabularies from the embedded copies instead of downloading them at runtime
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// OpenAI embeddings API limits
+const (
+	openAIMaxInputTokens   = 8191
+	openAIMaxRequestTokens = 300000
+	openAIMaxBatchInputs   = 2048
+)
+
+// OpenAIEmbeddingProvider generates embeddings with the OpenAI embeddings API
+type OpenAIEmbeddingProvider struct {
+	endpoint   OpenAIEndpoint
+	httpClient *httpretry.Client
+	// batchClient allows for the larger files of the Batch API
+	batchClient *httpretry.Client
+	model       string
+	dimensions  int
+	// requestDimensions is sent as the dimensions parameter when shorter embeddings are configured
+	requestDimensions int
+	// encoding tokenizes texts to batch requests by size and split oversized inputs
+	encoding *tiktoken.Tiktoken
+}
+
+// defaultOpenAIEmbeddingModel is used when no embedding model is configured
+const defaultOpenAIEmbeddingModel = "text-embedding-3-small"
+
+// openAIModelDimensions lists the native embedding sizes of the OpenAI embedding models
+var openAIModelDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
+// NewOpenAIEmbeddingProvider creates a new OpenAI embedding provider. A dimensions value of 0 uses
+// the model's native size; text-embedding-3 models can also return shorter embeddings, requested
+// with the dimensions parameter.
+func NewOpenAIEmbeddingProvider(endpoint OpenAIEndpoint, model string, dimensions int) (*OpenAIEmbeddingProvider, error) {
+	if model == "" {
+		model = defaultOpenAIEmbeddingModel
+	}
+	// Every OpenAI embedding model uses cl100k_base; it stands in for models tiktoken doesn't know
+	encoding, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		encoding, err = tiktoken.GetEncoding("cl100k_base")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer: %w", err)
+	}
+
+	p := &OpenAIEmbeddingProvider{
+		endpoint:    endpoint,
+		httpClient:  endpoint.client(30 * time.Second),
+		batchClient: endpoint.client(openAIBatchFileTimeout),
+		model:       model,
+		dimensions:  dimensions,
+		encoding:    encoding,
+	}
+
+	native, ok := openAIModelDimensions[model]
+	if !ok {
+		// Newer models may support the dimensions parameter; let the API validate it
+		p.requestDimensions = dimensions
+		return p, nil
+	}
+	switch {
+	case dimensions == 0 || dimensions == native:
+		p.dimensions = native
+	case model == "text-embedding-ada-002":
+		return nil, fmt.Errorf("%s only produces %d-dimension embeddings", model, native)
+	case dimensions < 0 || dimensions > native:
+		return nil, fmt.Errorf("%s supports at most %d dimensions", model, native)
+	default:
+		p.requestDimensions = dimensions
+	}
+	return p, nil
+}
+
+// EmbeddingRequest represents an OpenAI embedding request
+type EmbeddingRequest struct {
+	Input      []string `json:"input"`
+	Model      string   `json:"model"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+// EmbeddingResponse represents an OpenAI embedding response
+type EmbeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+	Model string `json:"model"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// embeddingPiece is a text, or a slice of one that exceeds the input token limit, to embed
+type embeddingPiece struct {
+	text   string
+	tokens int
+	// source is the index of the text the piece belongs to
+	source int
+}
+
+// GenerateEmbeddings generates embeddings for multiple texts. Requests are batched by the
+// tokenized size of the texts so they stay within OpenAI's per-request token limit. Texts longer
+// than the per-input limit are split, and their embedding is the token-weighted average of the
+// pieces' embeddings.
+func (s *OpenAIEmbeddingProvider) GenerateEmbeddings(ctx context.Context, texts []string, _ EmbeddingInput) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+
+	var pieces []embeddingPiece
+	for i, text := range texts {
+		pieces = append(pieces, s.splitInput(text, i)...)
+	}
+
+	pieceEmbeddings := make([][]float32, 0, len(pieces))
+	for start, batch := 0, 0; start < len(pieces); batch++ {
+		end, tokens := start, 0
+		for end < len(pieces) && end-start < openAIMaxBatchInputs && tokens+pieces[end].tokens <= openAIMaxRequestTokens {
+			tokens += pieces[end].tokens
+			end++
+		}
+
+		batchTexts := make([]string, end-start)
+		for i, piece := range pieces[start:end] {
+			batchTexts[i] = piece.text
+		}
+		embeddings, err := s.generateBatch(ctx, batchTexts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate batch %d: %w", batch, err)
+		}
+		if len(embeddings) != len(batchTexts) {
+			return nil, fmt.Errorf("batch %d: expected %d embeddings, got %d", batch, len(batchTexts), len(embeddings))
+		}
+
+		pieceEmbeddings = append(pieceEmbeddings, embeddings...)
+		start = end
+	}
+
+	return combinePieceEmbeddings(pieces, pieceEmbeddings, len(texts)), nil
+}
+
+// splitInput returns a text as one piece, or as several when it exceeds the input token limit.
+// source is the index of the text the pieces belong to.
+func (s *OpenAIEmbeddingProvider) splitInput(text string, source int) []embeddingPiece {
+	tokens := s.encoding.EncodeOrdinary(text)
+	if len(tokens) <= openAIMaxInputTokens {
+		return []embeddingPiece{{text: text, tokens: len(tokens), source: source}}
+	}
+
+	var pieces []embeddingPiece
+	for start := 0; start < len(tokens); start += openAIMaxInputTokens {
+		end := min(start+openAIMaxInputTokens, len(tokens))
+		pieces = append(pieces, embeddingPiece{text: s.encoding.Decode(tokens[start:end]), tokens: end - start, source: source})
+	}
+	return pieces
+}
+
+// combinePieceEmbeddings merges the embeddings of split texts into one per text, weighting each
+// piece by its tokens and re-normalizing, and passes unsplit texts' embeddings through unchanged
+func combinePieceEmbeddings(pieces []embeddingPiece, embeddings [][]float32, count int) [][]float32 {
+	combined := make([][]float32, count)
+	split := make([]bool, count)
+	for i, piece := range pieces {
+		if combined[piece.source] == nil {
+			combined[piece.source] = embeddings[i]
+			continue
+		}
+		if !split[piece.source] {
+			// Copy before accumulating so the first piece's embedding isn't modified in place
+			first := pieces[i-1]
+			sum := make([]float32, len(combined[piece.source]))
+			for d, v := range combined[piece.source] {
+				sum[d] = v * float32(first.tokens)
+			}
+			combined[piece.source] = sum
+			split[piece.source] = true
+		}
+		for d, v := range embeddings[i] {
+			combined[piece.source][d] += v * float32(piece.tokens)
+		}
+	}
+
+	for i, embedding := range combined {
+		if !split[i] {
+			continue
+		}
+		var norm float64
+		for _, v := range embedding {
+			norm += float64(v) * float64(v)
+		}
+		if norm = math.Sqrt(norm); norm > 0 {
+			for d := range embedding {
+				embedding[d] = float32(float64(embedding[d]) / norm)
+			}
+		}
+	}
+	return combined
+}
+
+// generateBatch generates embeddings for a batch of texts
+func (s *OpenAIEmbeddingProvider) generateBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	requestBody := EmbeddingRequest{
+		Input:      texts,
+		Model:      s.model,
+		Dimensions: s.requestDimensions,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.endpoint.URL("/embeddings"), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	s.endpoint.authorize(req)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, httpretry.ResponseError("API", resp)
+	}
+
+	var embeddingResp EmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	trackEmbeddingUsage(ctx, s.model, embeddingResp.Usage.TotalTokens)
+
+	// Extract embeddings in order
+	embeddings := make([][]float32, len(embeddingResp.Data))
+	for _, data := range embeddingResp.Data {
+		if data.Index >= len(embeddings) {
+			return nil, fmt.Errorf("invalid embedding index: %d", data.Index)
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+
+	return embeddings, nil
+}
+
+// Dimensions returns the embedding dimensions for the model
+func (s *OpenAIEmbeddingProvider) Dimensions() int {
+	return s.dimensions
+}
+
+// Model returns the embedding model name
+func (s *OpenAIEmbeddingProvider) Model() string {
+	return s.model
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// OpenAI Batch API parameters
+const (
+	// openAIBatchURL is the embeddings path as the Batch API expects it, relative to the host
+	openAIBatchURL = "/v1/embeddings"
+	// openAIBatchWindow is the only completion window the Batch API offers
+	openAIBatchWindow = "24h"
+	// openAIBatchMaxRequests is the most requests one batch may hold
+	openAIBatchMaxRequests = 50000
+	// openAIBatchDiscount is the share of the regular price charged for batched requests
+	openAIBatchDiscount = 0.5
+	// openAIBatchFileTimeout bounds uploads and downloads of batch files, which hold a whole document
+	openAIBatchFileTimeout = 5 * time.Minute
+)
+
+// Batch statuses after which the batch won't change any more
+var openAIBatchDone = map[string]bool{
+	"completed": true,
+	"failed":    true,
+	"expired":   true,
+	"cancelled": true,
+}
+
+// openAIBatchRequest is one line of a batch input file
+type openAIBatchRequest struct {
+	CustomID string           `json:"custom_id"`
+	Method   string           `json:"method"`
+	URL      string           `json:"url"`
+	Body     EmbeddingRequest `json:"body"`
+}
+
+// openAIBatchResult is one line of a batch output file
+type openAIBatchResult struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int               `json:"status_code"`
+		Body       EmbeddingResponse `json:"body"`
+	} `json:"response"`
+}
+
+// OpenAIBatch is the state of a submitted batch
+type OpenAIBatch struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	OutputFileID string `json:"output_file_id"`
+	Errors       *struct {
+		Data []struct {
+			Message string `json:"message"`
+		} `json:"data"`
+	} `json:"errors"`
+}
+
+// Done reports whether OpenAI has finished with the batch, successfully or not
+func (b *OpenAIBatch) Done() bool {
+	return openAIBatchDone[b.Status]
+}
+
+// Reason describes why a batch didn't complete
+func (b *OpenAIBatch) Reason() string {
+	if b.Errors == nil || len(b.Errors.Data) == 0 {
+		return "batch " + b.Status
+	}
+	messages := make([]string, len(b.Errors.Data))
+	for i, e := range b.Errors.Data {
+		messages[i] = e.Message
+	}
+	return "batch " + b.Status + ": " + strings.Join(messages, "; ")
+}
+
+// batchEmbeddingProvider returns the OpenAI provider behind provider, or nil when provider can't
+// embed through the Batch API. Batched embeddings bypass the embedding cache.
+func batchEmbeddingProvider(provider EmbeddingProvider) *OpenAIEmbeddingProvider {
+	openAI, _ := uncachedEmbeddingProvider(provider).(*OpenAIEmbeddingProvider)
+	return openAI
+}
+
+// SubmitBatch uploads one embeddings request per text, identified by the matching entry of ids,
+// and starts a batch for them. Texts over the input token limit are sent as several inputs of
+// their request. It returns the batch ID and the ID of the uploaded input file.
+func (s *OpenAIEmbeddingProvider) SubmitBatch(ctx context.Context, ids, texts []string) (string, string, error) {
+	if len(texts) == 0 {
+		return "", "", fmt.Errorf("no texts provided")
+	}
+	if len(texts) > openAIBatchMaxRequests {
+		return "", "", fmt.Errorf("too many texts for one batch: %d (max %d)", len(texts), openAIBatchMaxRequests)
+	}
+
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for i, text := range texts {
+		pieces := s.splitInput(text, i)
+		inputs := make([]string, len(pieces))
+		for j, piece := range pieces {
+			inputs[j] = piece.text
+		}
+		err := encoder.Encode(openAIBatchRequest{
+			CustomID: ids[i],
+			Method:   http.MethodPost,
+			URL:      openAIBatchURL,
+			Body: EmbeddingRequest{
+				Input:      inputs,
+				Model:      s.model,
+				Dimensions: s.requestDimensions,
+			},
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to encode batch request: %w", err)
+		}
+	}
+
+	fileID, err := s.uploadBatchFile(ctx, input.Bytes())
+	if err != nil {
+		return "", "", err
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"input_file_id":     fileID,
+		"endpoint":          openAIBatchURL,
+		"completion_window": openAIBatchWindow,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal batch: %w", err)
+	}
+
+	var batch OpenAIBatch
+	if err := s.batchRequest(ctx, http.MethodPost, "/batches", bytes.NewReader(body), "application/json", &batch); err != nil {
+		s.DeleteBatchFile(ctx, fileID)
+		return "", "", fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	return batch.ID, fileID, nil
+}
+
+// uploadBatchFile uploads a batch input file and returns its ID
+func (s *OpenAIEmbeddingProvider) uploadBatchFile(ctx context.Context, content []byte) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("purpose", "batch"); err != nil {
+		return "", fmt.Errorf("failed to write purpose: %w", err)
+	}
+	part, err := writer.CreateFormFile("file", "embeddings.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file part: %w", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return "", fmt.Errorf("failed to write file part: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to close multipart body: %w", err)
+	}
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := s.batchRequest(ctx, http.MethodPost, "/files", &body, writer.FormDataContentType(), &file); err != nil {
+		return "", fmt.Errorf("failed to upload batch file: %w", err)
+	}
+	return file.ID, nil
+}
+
+// GetBatch returns the current state of a batch
+func (s *OpenAIEmbeddingProvider) GetBatch(ctx context.Context, batchID string) (*OpenAIBatch, error) {
+	var batch OpenAIBatch
+	if err := s.batchRequest(ctx, http.MethodGet, "/batches/"+batchID, nil, "", &batch); err != nil {
+		return nil, fmt.Errorf("failed to get batch %s: %w", batchID, err)
+	}
+	return &batch, nil
+}
+
+// BatchResults downloads a batch's output file and returns the embedding of each text by its ID,
+// with the tokens the batch used. texts maps IDs to the texts that were submitted, which are
+// needed to combine the pieces of split texts. Requests that failed have no embedding.
+func (s *OpenAIEmbeddingProvider) BatchResults(ctx context.Context, fileID string, texts map[string]string) (map[string][]float32, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint.URL("/files/"+fileID+"/content"), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	s.endpoint.authorize(req)
+
+	resp, err := s.batchClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, httpretry.ResponseError("API", resp)
+	}
+
+	embeddings := make(map[string][]float32)
+	tokens := 0
+	scanner := bufio.NewScanner(resp.Body)
+	// Each line holds a whole embedding response
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var result openAIBatchResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode batch result: %w", err)
+		}
+		text, ok := texts[result.CustomID]
+		if !ok || result.Response == nil || result.Response.StatusCode != http.StatusOK {
+			continue
+		}
+		tokens += result.Response.Body.Usage.TotalTokens
+
+		pieces := s.splitInput(text, 0)
+		pieceEmbeddings := make([][]float32, len(pieces))
+		for _, data := range result.Response.Body.Data {
+			if data.Index < len(pieceEmbeddings) {
+				pieceEmbeddings[data.Index] = data.Embedding
+			}
+		}
+		complete := true
+		for _, embedding := range pieceEmbeddings {
+			complete = complete && embedding != nil
+		}
+		if complete {
+			embeddings[result.CustomID] = combinePieceEmbeddings(pieces, pieceEmbeddings, 1)[0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read batch results: %w", err)
+	}
+
+	return embeddings, tokens, nil
+}
+
+// DeleteBatchFile deletes an uploaded or output file once it's no longer needed, so document text
+// isn't kept by OpenAI longer than necessary
+func (s *OpenAIEmbeddingProvider) DeleteBatchFile(ctx context.Context, fileID string) error {
+	return s.batchRequest(ctx, http.MethodDelete, "/files/"+fileID, nil, "", nil)
+}
+
+// batchRequest sends a Batch API request and decodes the JSON response into out, if not nil
+func (s *OpenAIEmbeddingProvider) batchRequest(ctx context.Context, method, path string, body io.Reader, contentType string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint.URL(path), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.endpoint.authorize(req)
+
+	resp, err := s.batchClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return httpretry.ResponseError("API", resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
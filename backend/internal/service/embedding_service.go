@@ -1,55 +1,41 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
 )
 
-// EmbeddingService handles embedding generation
-type EmbeddingService struct {
-	apiKey     string
-	httpClient *http.Client
-	model      string
-}
+// EmbeddingInput tells the provider what the embedded text is for. Providers with asymmetric
+// models (such as Cohere) embed stored text and search queries differently; others ignore it.
+type EmbeddingInput string
 
-// NewEmbeddingService creates a new embedding service
-func NewEmbeddingService(apiKey string) *EmbeddingService {
-	return &EmbeddingService{
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		model: "text-embedding-3-small",
-	}
-}
+// Embedding inputs
+const (
+	// EmbeddingDocument is text stored for retrieval: document chunks, summaries and conversations
+	EmbeddingDocument EmbeddingInput = "document"
+	// EmbeddingQuery is text searched with at query time
+	EmbeddingQuery EmbeddingInput = "query"
+)
 
-// EmbeddingRequest represents an OpenAI embedding request
-type EmbeddingRequest struct {
-	Input []string `json:"input"`
-	Model string   `json:"model"`
-}
+// EmbeddingProvider generates vector embeddings for text. Documents and queries must be
+// embedded by the same provider and model, so changing either requires re-embedding documents
+// (see cmd/reembed).
+type EmbeddingProvider interface {
+	// GenerateEmbeddings returns one embedding per text, in order
+	GenerateEmbeddings(ctx context.Context, texts []string, input EmbeddingInput) ([][]float32, error)
+
+	// Dimensions returns the length of the embeddings, used to size vector collections
+	Dimensions() int
 
-// EmbeddingResponse represents an OpenAI embedding response
-type EmbeddingResponse struct {
-	Data []struct {
-		Embedding []float32 `json:"embedding"`
-		Index     int       `json:"index"`
-	} `json:"data"`
-	Model string `json:"model"`
-	Usage struct {
-		PromptTokens int `json:"prompt_tokens"`
-		TotalTokens  int `json:"total_tokens"`
-	} `json:"usage"`
+	// Model returns the embedding model name, recorded in usage tracking
+	Model() string
 }
 
-// GenerateEmbedding generates an embedding for a single text
-func (s *EmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
-	embeddings, err := s.GenerateEmbeddings(ctx, []string{text})
+// generateEmbedding embeds a single text with the provider
+func generateEmbedding(ctx context.Context, provider EmbeddingProvider, text string, input EmbeddingInput) ([]float32, error) {
+	embeddings, err := provider.GenerateEmbeddings(ctx, []string{text}, input)
 	if err != nil {
 		return nil, err
 	}
@@ -59,105 +45,59 @@ func (s *EmbeddingService) GenerateEmbedding(ctx context.Context, text string) (
 	return embeddings[0], nil
 }
 
-// GenerateEmbeddings generates embeddings for multiple texts (batch processing)
-func (s *EmbeddingService) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
-	if len(texts) == 0 {
-		return nil, fmt.Errorf("no texts provided")
-	}
-
-	// OpenAI supports up to 8191 tokens per request, we'll batch by count
-	const batchSize = 100
-	var allEmbeddings [][]float32
-
-	for i := 0; i < len(texts); i += batchSize {
-		end := i + batchSize
-		if end > len(texts) {
-			end = len(texts)
+// NewEmbeddingProvider creates the embedding provider selected by the configuration. With
+// EmbeddingTruncate set, the provider produces full-size embeddings that are shortened to
+// EmbeddingDimensions client-side.
+func NewEmbeddingProvider(cfg *config.Config) (EmbeddingProvider, error) {
+	dimensions := cfg.EmbeddingDimensions
+	if cfg.EmbeddingTruncate {
+		if dimensions <= 0 {
+			return nil, fmt.Errorf("EMBEDDING_TRUNCATE requires EMBEDDING_DIMENSIONS")
 		}
-
-		batch := texts[i:end]
-		embeddings, err := s.generateBatch(ctx, batch)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate batch %d: %w", i/batchSize, err)
-		}
-
-		allEmbeddings = append(allEmbeddings, embeddings...)
-	}
-
-	return allEmbeddings, nil
-}
-
-// generateBatch generates embeddings for a batch of texts
-func (s *EmbeddingService) generateBatch(ctx context.Context, texts []string) ([][]float32, error) {
-	requestBody := EmbeddingRequest{
-		Input: texts,
-		Model: s.model,
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		dimensions = 0
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-
-	// Retry logic with exponential backoff
-	var resp *http.Response
-	maxRetries := 3
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		resp, err = s.httpClient.Do(req)
-		if err == nil && resp.StatusCode == 200 {
-			break
+	var provider EmbeddingProvider
+	switch cfg.EmbeddingProvider {
+	case "openai":
+		endpoint, err := NewOpenAIEndpoint(cfg)
+		if err != nil {
+			return nil, err
 		}
-
-		if resp != nil && resp.StatusCode == 429 {
-			// Rate limited, wait and retry
-			waitTime := time.Duration(1<<uint(attempt)) * time.Second
-			time.Sleep(waitTime)
-			continue
+		openAI, err := NewOpenAIEmbeddingProvider(endpoint, cfg.EmbeddingModel, dimensions)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OpenAI embedding configuration: %w", err)
 		}
-
+		provider = openAI
+	case "ollama":
+		provider = NewOllamaEmbeddingProvider(cfg.OllamaURL, cfg.EmbeddingModel, dimensions)
+	case "cohere":
+		provider = NewCohereEmbeddingProvider(cfg.CohereKey, cfg.EmbeddingModel, dimensions)
+	case "voyage":
+		voyage, err := NewVoyageEmbeddingProvider(cfg.VoyageKey, cfg.EmbeddingModel, dimensions)
 		if err != nil {
-			return nil, fmt.Errorf("request failed: %w", err)
+			return nil, fmt.Errorf("invalid Voyage embedding configuration: %w", err)
 		}
-
-		if resp.StatusCode != 200 {
-			body, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		provider = voyage
+	case "onnx":
+		onnx, err := NewONNXEmbeddingProvider(cfg.ONNXModelDir, cfg.ONNXRuntimeLib, cfg.EmbeddingModel, dimensions)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ONNX embedding configuration: %w", err)
 		}
+		provider = onnx
+	default:
+		return nil, fmt.Errorf("unknown embedding provider: %s (valid options: openai, ollama, cohere, voyage, onnx)", cfg.EmbeddingProvider)
 	}
 
-	if resp == nil {
-		return nil, fmt.Errorf("no response after %d retries", maxRetries)
-	}
-	defer resp.Body.Close()
-
-	var embeddingResp EmbeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Extract embeddings in order
-	embeddings := make([][]float32, len(embeddingResp.Data))
-	for _, data := range embeddingResp.Data {
-		if data.Index >= len(embeddings) {
-			return nil, fmt.Errorf("invalid embedding index: %d", data.Index)
+	if cfg.EmbeddingTruncate {
+		if native := provider.Dimensions(); native != 0 && cfg.EmbeddingDimensions > native {
+			return nil, fmt.Errorf("cannot truncate %d-dimension %s embeddings to %d dimensions", native, provider.Model(), cfg.EmbeddingDimensions)
 		}
-		embeddings[data.Index] = data.Embedding
+		provider = NewTruncatedEmbeddingProvider(provider, cfg.EmbeddingDimensions)
 	}
 
-	return embeddings, nil
-}
-
-// GetDimensions returns the embedding dimensions for the model
-func (s *EmbeddingService) GetDimensions() int {
-	// text-embedding-3-small has 1536 dimensions
-	return 1536
+	if provider.Dimensions() == 0 {
+		return nil, fmt.Errorf("unknown embedding size for model %s; set EMBEDDING_DIMENSIONS", provider.Model())
+	}
+	return provider, nil
 }
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// TruncatedEmbeddingProvider shortens a provider's embeddings to their first dimensions values and
+// rescales them to unit length. Models trained with Matryoshka representation learning (such as
+// nomic-embed-text v1.5 and mxbai-embed-large) keep most of their retrieval quality this way, so
+// large archives can use much smaller vector collections. Providers with a native size option
+// (OpenAI, Voyage) do the same server-side and don't need it.
+type TruncatedEmbeddingProvider struct {
+	provider   EmbeddingProvider
+	dimensions int
+}
+
+// NewTruncatedEmbeddingProvider creates a wrapper that shortens provider's embeddings to dimensions
+func NewTruncatedEmbeddingProvider(provider EmbeddingProvider, dimensions int) *TruncatedEmbeddingProvider {
+	return &TruncatedEmbeddingProvider{
+		provider:   provider,
+		dimensions: dimensions,
+	}
+}
+
+// GenerateEmbeddings generates full-size embeddings with the wrapped provider and shortens them
+func (p *TruncatedEmbeddingProvider) GenerateEmbeddings(ctx context.Context, texts []string, input EmbeddingInput) ([][]float32, error) {
+	embeddings, err := p.provider.GenerateEmbeddings(ctx, texts, input)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, embedding := range embeddings {
+		if len(embedding) < p.dimensions {
+			return nil, fmt.Errorf("%s returned %d-dimension embeddings, fewer than the %d configured",
+				p.provider.Model(), len(embedding), p.dimensions)
+		}
+		embeddings[i] = normalizeEmbedding(embedding[:p.dimensions])
+	}
+	return embeddings, nil
+}
+
+// Dimensions returns the shortened embedding size
+func (p *TruncatedEmbeddingProvider) Dimensions() int {
+	return p.dimensions
+}
+
+// Model returns the wrapped provider's model name
+func (p *TruncatedEmbeddingProvider) Model() string {
+	return p.provider.Model()
+}
+
+// normalizeEmbedding scales v to unit length in place, so cosine and dot-product scores stay
+// comparable after truncation
+func normalizeEmbedding(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+
+	scale := float32(1 / math.Sqrt(sum))
+	for i := range v {
+		v[i] *= scale
+	}
+	return v
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// EmbeddingProviders resolves the embedding provider for each user: the configured default, or
+// a model the user chose in their settings, such as a multilingual model for documents in Malay
+// or Chinese. Models come from the configured provider, and each user's docs collection is sized
+// for the model it holds.
+type EmbeddingProviders struct {
+	cfg          *config.Config
+	settingsRepo *repository.SettingsRepository
+	cacheRepo    *repository.EmbeddingCacheRepository
+	defaults     EmbeddingProvider
+	// allowed lists the models users may choose; empty allows any model of known size
+	allowed map[string]bool
+
+	mu        sync.Mutex
+	providers map[string]EmbeddingProvider
+	// queries caches question embeddings
+	queries *queryEmbeddingCache
+}
+
+// NewEmbeddingProviders creates the default embedding provider from the configuration, caching
+// document embeddings when enabled (cacheRepo may be nil otherwise)
+func NewEmbeddingProviders(cfg *config.Config, settingsRepo *repository.SettingsRepository, cacheRepo *repository.EmbeddingCacheRepository) (*EmbeddingProviders, error) {
+	p := &EmbeddingProviders{
+		cfg:          cfg,
+		settingsRepo: settingsRepo,
+		cacheRepo:    cacheRepo,
+		providers:    make(map[string]EmbeddingProvider),
+		queries:      newQueryEmbeddingCache(),
+	}
+
+	for _, name := range strings.Split(cfg.EmbeddingUserModels, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			if p.allowed == nil {
+				p.allowed = make(map[string]bool)
+			}
+			p.allowed[name] = true
+		}
+	}
+
+	defaults, err := NewEmbeddingProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.defaults = p.withCache(defaults)
+
+	return p, nil
+}
+
+// withCache wraps a provider with the embedding cache when it's enabled
+func (p *EmbeddingProviders) withCache(provider EmbeddingProvider) EmbeddingProvider {
+	if !p.cfg.EmbeddingCache || p.cacheRepo == nil {
+		return provider
+	}
+	return NewCachedEmbeddingProvider(provider, p.cacheRepo)
+}
+
+// Default returns the provider used by users without a model of their own
+func (p *EmbeddingProviders) Default() EmbeddingProvider {
+	return p.defaults
+}
+
+// Model returns the provider for a model users may choose; an empty name is the default
+func (p *EmbeddingProviders) Model(name string) (EmbeddingProvider, error) {
+	name = strings.TrimSpace(name)
+	if name != "" && name != p.defaults.Model() && p.allowed != nil && !p.allowed[name] {
+		return nil, fmt.Errorf("embedding model %s is not available", name)
+	}
+	return p.provider(name)
+}
+
+// provider returns the provider for a model of the configured embedding provider, creating it on
+// first use. Chosen models use their native size.
+func (p *EmbeddingProviders) provider(name string) (EmbeddingProvider, error) {
+	if name == "" || name == p.defaults.Model() {
+		return p.defaults, nil
+	}
+	if p.cfg.EmbeddingProvider == "onnx" {
+		// The model directory holds one model, so ONNX users share it
+		return nil, fmt.Errorf("the onnx embedding provider has no per-user models")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if provider, ok := p.providers[name]; ok {
+		return provider, nil
+	}
+
+	cfg := *p.cfg
+	cfg.EmbeddingModel = name
+	cfg.EmbeddingDimensions = 0
+	cfg.EmbeddingTruncate = false
+	provider, err := NewEmbeddingProvider(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	provider = p.withCache(provider)
+	p.providers[name] = provider
+
+	return provider, nil
+}
+
+// ForUser returns the provider of the model the user's docs collection holds, which stays usable
+// even if it is no longer offered. A newly chosen model takes over once re-embedding has moved
+// the user's documents to it.
+func (p *EmbeddingProviders) ForUser(ctx context.Context, userID string) (EmbeddingProvider, error) {
+	settings, err := p.settingsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return p.provider(settings.ActiveEmbeddingModel)
+}
+
+// Embed embeds a single text with the user's model. Questions embedded in the last half hour
+// are served from memory.
+func (p *EmbeddingProviders) Embed(ctx context.Context, userID, text string, input EmbeddingInput) ([]float32, error) {
+	provider, err := p.ForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if input != EmbeddingQuery {
+		return generateEmbedding(ctx, provider, text, input)
+	}
+
+	model := fmt.Sprintf("%s/%d", provider.Model(), provider.Dimensions())
+	if embedding, ok := p.queries.get(model, text); ok {
+		return embedding, nil
+	}
+	embedding, err := generateEmbedding(ctx, provider, text, input)
+	if err != nil {
+		return nil, err
+	}
+	p.queries.put(model, text, embedding)
+	return embedding, nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// defaultVoyageEmbeddingModel is used when no embedding model is configured
+const defaultVoyageEmbeddingModel = "voyage-3.5"
+
+// voyageModel describes a Voyage embedding model: its default size, whether output_dimension can
+// change it, and the most tokens a single request may carry
+type voyageModel struct {
+	dimensions       int
+	flexibleSize     bool
+	maxRequestTokens int
+}
+
+// voyageModels lists the Voyage embedding models and their limits
+var voyageModels = map[string]voyageModel{
+	"voyage-3.5":            {1024, true, 320000},
+	"voyage-3.5-lite":       {1024, true, 1000000},
+	"voyage-3-large":        {1024, true, 120000},
+	"voyage-3":              {1024, false, 320000},
+	"voyage-3-lite":         {512, false, 1000000},
+	"voyage-code-3":         {1024, true, 120000},
+	"voyage-code-2":         {1536, false, 120000},
+	"voyage-finance-2":      {1024, false, 120000},
+	"voyage-law-2":          {1024, false, 120000},
+	"voyage-multilingual-2": {1024, false, 120000},
+}
+
+// Voyage request limits. Models not in voyageModels use the lowest token limit.
+const (
+	voyageMaxBatchTexts        = 1000
+	voyageDefaultRequestTokens = 120000
+	// voyageCharsPerToken underestimates characters per token so batches stay under the limit
+	// for code and non-English text, which tokenize more densely than prose
+	voyageCharsPerToken = 3
+)
+
+// voyageInputTypes maps embedding inputs to Voyage input types, which add a retrieval prompt
+// suited to each side of the search
+var voyageInputTypes = map[EmbeddingInput]string{
+	EmbeddingDocument: "document",
+	EmbeddingQuery:    "query",
+}
+
+// VoyageEmbeddingProvider generates embeddings with the Voyage AI embeddings API
+type VoyageEmbeddingProvider struct {
+	apiKey     string
+	model      string
+	dimensions int
+	// outputDimension is sent when the configured size differs from the model's default
+	outputDimension int
+	maxTokens       int
+	httpClient      *httpretry.Client
+}
+
+// NewVoyageEmbeddingProvider creates a new Voyage embedding provider. A dimensions value of 0 uses
+// the model's default size; models with flexible sizes also accept 256, 512, 1024 or 2048.
+func NewVoyageEmbeddingProvider(apiKey, model string, dimensions int) (*VoyageEmbeddingProvider, error) {
+	if model == "" {
+		model = defaultVoyageEmbeddingModel
+	}
+	p := &VoyageEmbeddingProvider{
+		apiKey:     apiKey,
+		model:      model,
+		dimensions: dimensions,
+		maxTokens:  voyageDefaultRequestTokens,
+		httpClient: httpretry.New("voyage", 60*time.Second),
+	}
+
+	known, ok := voyageModels[model]
+	if !ok {
+		return p, nil
+	}
+	p.maxTokens = known.maxRequestTokens
+	switch {
+	case dimensions == 0 || dimensions == known.dimensions:
+		p.dimensions = known.dimensions
+	case !known.flexibleSize:
+		return nil, fmt.Errorf("%s only produces %d-dimension embeddings", model, known.dimensions)
+	case dimensions == 256 || dimensions == 512 || dimensions == 2048:
+		p.outputDimension = dimensions
+	default:
+		return nil, fmt.Errorf("%s supports 256, 512, 1024 or 2048 dimensions", model)
+	}
+	return p, nil
+}
+
+// voyageEmbedRequest represents a Voyage /v1/embeddings request
+type voyageEmbedRequest struct {
+	Input           []string `json:"input"`
+	Model           string   `json:"model"`
+	InputType       string   `json:"input_type"`
+	OutputDimension int      `json:"output_dimension,omitempty"`
+}
+
+// voyageEmbedResponse represents a Voyage /v1/embeddings response
+type voyageEmbedResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+	Usage struct {
+		TotalTokens int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// GenerateEmbeddings generates embeddings for multiple texts, batching by both text count and
+// estimated tokens since Voyage limits each request by both
+func (p *VoyageEmbeddingProvider) GenerateEmbeddings(ctx context.Context, texts []string, input EmbeddingInput) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+	inputType, ok := voyageInputTypes[input]
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding input: %q", input)
+	}
+
+	var allEmbeddings [][]float32
+	for start, batch := 0, 0; start < len(texts); batch++ {
+		end, tokens := start, 0
+		for end < len(texts) && end-start < voyageMaxBatchTexts {
+			textTokens := len(texts[end])/voyageCharsPerToken + 1
+			if end > start && tokens+textTokens > p.maxTokens {
+				break
+			}
+			tokens += textTokens
+			end++
+		}
+
+		embeddings, err := p.generateBatch(ctx, texts[start:end], inputType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate batch %d: %w", batch, err)
+		}
+
+		allEmbeddings = append(allEmbeddings, embeddings...)
+		start = end
+	}
+
+	return allEmbeddings, nil
+}
+
+// generateBatch generates embeddings for a batch of texts
+func (p *VoyageEmbeddingProvider) generateBatch(ctx context.Context, texts []string, inputType string) ([][]float32, error) {
+	jsonData, err := json.Marshal(voyageEmbedRequest{
+		Input:           texts,
+		Model:           p.model,
+		InputType:       inputType,
+		OutputDimension: p.outputDimension,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.voyageai.com/v1/embeddings", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpretry.ResponseError("voyage", resp)
+	}
+
+	var embedResp voyageEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	trackEmbeddingUsage(ctx, p.model, embedResp.Usage.TotalTokens)
+
+	embeddings := make([][]float32, len(texts))
+	for _, data := range embedResp.Data {
+		if data.Index < 0 || data.Index >= len(embeddings) {
+			return nil, fmt.Errorf("invalid embedding index: %d", data.Index)
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			return nil, fmt.Errorf("missing embedding for text %d", i)
+		}
+	}
+
+	return embeddings, nil
+}
+
+// Dimensions returns the embedding dimensions for the model
+func (p *VoyageEmbeddingProvider) Dimensions() int {
+	return p.dimensions
+}
+
+// Model returns the embedding model name
+func (p *VoyageEmbeddingProvider) Model() string {
+	return p.model
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// exclusionPattern matches "-key:value" and "-key:"quoted value"" operators in a question
+var exclusionPattern = regexp.MustCompile(`(^|\s)-([A-Za-z_]\w*):(?:"([^"]*)"|(\S+))`)
+
+// parseExclusions removes exclusion operators such as "-tag:archive" or "-filename:"old notes.md""
+// from a question and returns the cleaned question with the excluded values per metadata key
+func parseExclusions(question string) (string, map[string][]string) {
+	var exclude map[string][]string
+	cleaned := exclusionPattern.ReplaceAllStringFunc(question, func(match string) string {
+		groups := exclusionPattern.FindStringSubmatch(match)
+		value := groups[3]
+		if value == "" {
+			value = groups[4]
+		}
+		if value != "" {
+			if exclude == nil {
+				exclude = make(map[string][]string)
+			}
+			exclude[groups[2]] = append(exclude[groups[2]], value)
+		}
+		return groups[1]
+	})
+
+	return strings.Join(strings.Fields(cleaned), " "), exclude
+}
+
+// mergeExclusions combines exclusions from the request body with those parsed from the question
+func mergeExclusions(base, extra map[string][]string) map[string][]string {
+	if len(base) == 0 {
+		return extra
+	}
+	merged := make(map[string][]string, len(base)+len(extra))
+	for key, values := range base {
+		merged[key] = append(merged[key], values...)
+	}
+	for key, values := range extra {
+		merged[key] = append(merged[key], values...)
+	}
+	return merged
+}
+
+// buildSearchFilter combines metadata matches and exclusions into a search filter; nil when empty
+func buildSearchFilter(match map[string]string, exclude map[string][]string, excludeDocumentIDs []string) *repository.SearchFilter {
+	if len(match) == 0 && len(exclude) == 0 && len(excludeDocumentIDs) == 0 {
+		return nil
+	}
+	return &repository.SearchFilter{
+		Match:              match,
+		Exclude:            exclude,
+		ExcludeDocumentIDs: excludeDocumentIDs,
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// CitationExplanation says why a cited chunk was retrieved, so users can judge and tune retrieval
+type CitationExplanation struct {
+	// Source is the index of the citation in the response's sources
+	Source int `json:"source"`
+	// Similarity is the chunk's retrieval score: vector similarity (boosted for pinned
+	// documents), or text relevance when retrieval fell back to keyword search
+	Similarity float32 `json:"similarity"`
+	// MatchedKeywords are the question's significant terms found in the chunk
+	MatchedKeywords []string `json:"matched_keywords"`
+	// Filters are the metadata filters retrieval was restricted by, e.g. "speaker=Alex"
+	Filters []string `json:"filters,omitempty"`
+	// RerankScore is the LLM reranker's 0-10 relevance score, when the rerank stage ran
+	RerankScore *float64 `json:"rerank_score,omitempty"`
+	// Justification is a one-line LLM explanation of how the chunk relates to the question
+	Justification string `json:"justification,omitempty"`
+}
+
+// justifySystemPrompt asks for one short reason per numbered chunk
+const justifySystemPrompt = `For each numbered document, explain in one short sentence why it is relevant to the question, or say that it isn't.
+Reply with a JSON array of strings only, one per document, in document order.`
+
+// explainResults explains each retrieved chunk of a pipeline run, in source order. The
+// justifications take one LLM call; if it fails they are left out.
+func (s *RAGService) explainResults(ctx context.Context, state *PipelineState) []CitationExplanation {
+	terms := significantTerms(state.Question)
+	filters := describeFilter(state.Retrieval.Filter)
+
+	explanations := make([]CitationExplanation, len(state.Results))
+	for i, result := range state.Results {
+		content, _ := result.Payload["content"].(string)
+		lower := strings.ToLower(content)
+		matched := []string{}
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				matched = append(matched, term)
+			}
+		}
+
+		explanations[i] = CitationExplanation{
+			Source:          i,
+			Similarity:      result.Score,
+			MatchedKeywords: matched,
+			Filters:         filters,
+		}
+		if score, ok := state.RerankScores[result]; ok {
+			explanations[i].RerankScore = &score
+		}
+	}
+
+	if len(state.Results) == 0 {
+		return explanations
+	}
+	userPrompt := fmt.Sprintf("Question: %s\n\nDocuments:\n%s", state.Question, buildContextText(state.Results))
+	reply, err := s.callLLM(ctx, justifySystemPrompt, userPrompt)
+
+	var justifications []string
+	if err == nil {
+		err = json.Unmarshal([]byte(stripCodeFence(reply)), &justifications)
+	}
+	if err == nil && len(justifications) != len(state.Results) {
+		err = fmt.Errorf("got %d justifications for %d chunks", len(justifications), len(state.Results))
+	}
+	if err != nil {
+		logger.Error("Failed to justify retrieved chunks", "user_id", state.UserID, "error", err)
+		return explanations
+	}
+	for i, justification := range justifications {
+		explanations[i].Justification = strings.TrimSpace(justification)
+	}
+	return explanations
+}
+
+// describeFilter renders a search filter's conditions as sorted "key=value" strings; excluded
+// values are prefixed with "-"
+func describeFilter(filter *repository.SearchFilter) []string {
+	if filter == nil {
+		return nil
+	}
+
+	var conditions []string
+	for key, value := range filter.Match {
+		conditions = append(conditions, fmt.Sprintf("%s=%s", key, value))
+	}
+	for key, values := range filter.Exclude {
+		for _, value := range values {
+			conditions = append(conditions, fmt.Sprintf("-%s=%s", key, value))
+		}
+	}
+	for _, documentID := range filter.DocumentIDs {
+		conditions = append(conditions, "document_id="+documentID)
+	}
+	for _, documentID := range filter.ExcludeDocumentIDs {
+		conditions = append(conditions, "-document_id="+documentID)
+	}
+	for key, bounds := range filter.Ranges {
+		if bounds.Gte != nil {
+			conditions = append(conditions, fmt.Sprintf("%s>=%g", key, *bounds.Gte))
+		}
+		if bounds.Lt != nil {
+			conditions = append(conditions, fmt.Sprintf("%s<%g", key, *bounds.Lt))
+		}
+	}
+	sort.Strings(conditions)
+	return conditions
+}
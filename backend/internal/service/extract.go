@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/parser"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/utils"
+)
+
+// wholeTextLimit is the most extracted text a document is segmented with an ingestion profile
+// as a whole. Past it, pages are chunked as they are extracted and not kept. Files read whole are
+// already in memory, so they're always chunked as a whole.
+const wholeTextLimit = 1024 * 1024
+
+// fileContent is a file's content, readable from any offset so it can be hashed, parsed and
+// stored without being read into memory
+type fileContent interface {
+	io.ReadSeeker
+	io.ReaderAt
+}
+
+// hashContent returns the hex SHA-256 of a file's content, leaving it rewound
+func hashContent(content fileContent) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// extractChunks streams a file's pages through secret scanning into chunks, holding at most the
+// service's memory limit of text. Text formats and images are read whole, so they must fit in
+// the limit; PDFs, presentations and spreadsheets are streamed, and PDFs only OCR'd when they
+// fit, since pdfcpu loads the whole PDF to extract its images. chunking holds the chunking
+// strategy and chunk size.
+func (s *DocumentService) extractChunks(ctx context.Context, userID, filename string, content fileContent, size int64, profileName string, chunking chunkingParams) ([]model.DocumentChunk, []*model.SecretFinding, error) {
+	if parser.ReadsWhole(filename) && size > s.memoryLimit {
+		return nil, nil, fmt.Errorf("%w: file too large to extract (max %dMB for this type)", apperror.ErrQuotaExceeded, s.memoryLimit>>20)
+	}
+	ocr := s.ocr
+	if ocr != nil && size > s.memoryLimit {
+		logger.Warn("Skipping OCR of scanned pages in PDF over the memory limit", "file", filename, "size", size)
+		ocr = nil
+	}
+
+	split, err := s.splitterFor(ctx, userID, filename, chunking)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stream := &chunkStream{
+		filename:    filename,
+		profileName: profileName,
+		chunking:    chunking,
+		split:       split,
+		limit:       s.memoryLimit,
+		whole:       parser.ReadsWhole(filename),
+	}
+	var secrets []*model.SecretFinding
+	line := 0
+	err = parser.Extract(ctx, filename, content, size, ocr, func(page parser.Page) error {
+		// Keep credentials out of the embedding API and the vector store
+		scanned, findings := s.secrets.ScanPage(page, line)
+		secrets = append(secrets, findings...)
+		line += pageLines(page)
+		return stream.add(scanned)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract text: %w", err)
+	}
+
+	chunks, err := stream.finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	return chunks, secrets, nil
+}
+
+// chunkStream chunks a document's pages as they are extracted. Pages are kept while their text
+// fits in wholeTextLimit, so a short document is segmented by its ingestion profile as a whole;
+// past it, each page is chunked on arrival and only its last, unfinished chunk is carried over
+// to the next page. Pages with metadata, such as slides, are chunked on their own.
+type chunkStream struct {
+	filename    string
+	profileName string
+	// chunking holds the chunking parameters and split is their splitter; streamed pages are
+	// always split by the fixed strategy, since their text is never held whole
+	chunking chunkingParams
+	split    splitter
+	// limit caps the bytes of text held: pages kept, chunks built and the carried chunk
+	limit int64
+	// whole keeps every page regardless of wholeTextLimit, for files read into memory whole
+	whole bool
+
+	pages     []parser.Page
+	pageBytes int
+	streaming bool
+
+	chunks     []model.DocumentChunk
+	chunkBytes int64
+	carry      string
+	carryPage  int
+}
+
+// add takes the next page of the document
+func (c *chunkStream) add(page parser.Page) error {
+	switch {
+	case page.Metadata != nil:
+		if err := c.stream("it has separately chunked sections"); err != nil {
+			return err
+		}
+		if err := c.chunkSection(page); err != nil {
+			return err
+		}
+	case !c.streaming:
+		c.pages = append(c.pages, page)
+		c.pageBytes += len(page.Text) + len(parser.PageSeparator)
+		if c.whole || c.pageBytes <= wholeTextLimit {
+			return nil
+		}
+		if c.chunking.strategy != ChunkingFixed {
+			logger.Warn("Chunking long document in fixed windows", "file", c.filename, "chunking", c.chunking.strategy)
+		}
+		if err := c.stream(fmt.Sprintf("its text exceeds %dMB", wholeTextLimit>>20)); err != nil {
+			return err
+		}
+	default:
+		c.chunkPage(page)
+	}
+
+	if c.chunkBytes+int64(len(c.carry)) > c.limit {
+		return fmt.Errorf("%w: document text exceeds the %dMB ingestion memory limit", apperror.ErrQuotaExceeded, c.limit>>20)
+	}
+	return nil
+}
+
+// stream switches to chunking pages as they arrive, chunking the pages kept so far. A profile
+// segments the whole text, so it can't be used once the document is streamed.
+func (c *chunkStream) stream(reason string) error {
+	if c.streaming {
+		return nil
+	}
+	if c.profileName != "" {
+		return fmt.Errorf("ingestion profile %s can't segment this document: %s", c.profileName, reason)
+	}
+
+	c.streaming = true
+	pages := c.pages
+	c.pages, c.pageBytes = nil, 0
+	for _, page := range pages {
+		c.chunkPage(page)
+	}
+	return nil
+}
+
+// chunkPage chunks the carried chunk and a page's text, carrying the last chunk over since the
+// next page may continue it
+func (c *chunkStream) chunkPage(page parser.Page) {
+	text := c.carry
+	if text != "" {
+		text += parser.PageSeparator
+	}
+	pageStart := len(text)
+	text += page.Text
+
+	contents := utils.ChunkText(text, c.chunking.size, c.chunking.overlap)
+	if len(contents) == 0 {
+		return
+	}
+
+	// Chunks follow the text in order, so each is looked up from where the previous one starts
+	offset := 0
+	pageAt := func(content string) int {
+		if i := strings.Index(text[offset:], content); i >= 0 {
+			offset += i
+		}
+		if offset < pageStart {
+			return c.carryPage
+		}
+		return page.Number
+	}
+	for _, content := range contents[:len(contents)-1] {
+		c.appendChunk(content, pageAt(content), nil)
+	}
+	last := contents[len(contents)-1]
+	c.carry, c.carryPage = last, pageAt(last)
+}
+
+// chunkSection chunks a page on its own, ending the running text before it
+func (c *chunkStream) chunkSection(page parser.Page) error {
+	c.flushCarry()
+	contents, err := c.split(page.Text)
+	if err != nil {
+		return err
+	}
+	for _, content := range contents {
+		c.appendChunk(content, page.Number, page.Metadata)
+	}
+	return nil
+}
+
+// flushCarry makes the carried chunk a chunk of its own
+func (c *chunkStream) flushCarry() {
+	if c.carry != "" {
+		c.appendChunk(c.carry, c.carryPage, nil)
+		c.carry = ""
+	}
+}
+
+func (c *chunkStream) appendChunk(content string, page int, metadata map[string]interface{}) {
+	c.chunks = append(c.chunks, model.DocumentChunk{
+		Content:    content,
+		Page:       page,
+		ChunkIndex: len(c.chunks),
+		Metadata:   metadata,
+	})
+	c.chunkBytes += int64(len(content))
+}
+
+// finish returns the document's chunks
+func (c *chunkStream) finish() ([]model.DocumentChunk, error) {
+	if !c.streaming {
+		return buildChunks(c.filename, c.pages, c.profileName, c.chunking, c.split)
+	}
+	c.flushCarry()
+	return c.chunks, nil
+}
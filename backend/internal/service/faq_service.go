@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Personal FAQ parameters
+const (
+	// faqBatchSize is the number of history entries clustered per run
+	faqBatchSize = 200
+	// faqClusterThreshold is the similarity above which a question joins an existing entry
+	faqClusterThreshold = 0.9
+	// faqMatchThreshold is the stricter similarity above which a new question is answered from
+	// the FAQ, since a wrong match returns a confident answer to a different question
+	faqMatchThreshold = 0.95
+	// faqMinAsks is how often a question must be asked to appear in the FAQ
+	faqMinAsks = 2
+	// faqAnswerMaxAge limits reused answers to recent ones, as documents change
+	faqAnswerMaxAge = 30 * 24 * time.Hour
+)
+
+// FAQService maintains each user's personal FAQ by clustering the questions in their history
+type FAQService struct {
+	faqRepo    *repository.FAQRepository
+	embeddings *EmbeddingProviders
+}
+
+// NewFAQService creates a new FAQ service
+func NewFAQService(faqRepo *repository.FAQRepository, embeddings *EmbeddingProviders) *FAQService {
+	return &FAQService{
+		faqRepo:    faqRepo,
+		embeddings: embeddings,
+	}
+}
+
+// FAQPage is a page of FAQ entries with the total count
+type FAQPage struct {
+	Items  []*model.FAQEntry `json:"items"`
+	Total  int               `json:"total"`
+	Limit  int               `json:"limit"`
+	Offset int               `json:"offset"`
+}
+
+// List returns a page of the user's FAQ, most asked questions first
+func (s *FAQService) List(ctx context.Context, userID string, limit, offset int) (*FAQPage, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	items, total, err := s.faqRepo.List(ctx, userID, faqMinAsks, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FAQPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// Delete removes an FAQ entry, e.g. one grouping unrelated questions
+func (s *FAQService) Delete(ctx context.Context, userID, id string) error {
+	return s.faqRepo.Delete(ctx, userID, id)
+}
+
+// Refresh clusters a batch of new history entries into FAQ entries and re-picks the best answer
+// of each entry that grew. It runs on a schedule; each question is clustered once.
+func (s *FAQService) Refresh(ctx context.Context) error {
+	questions, err := s.faqRepo.ListUnprocessed(ctx, faqBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var userIDs []string
+	byUser := make(map[string][]*repository.FAQQuestion)
+	for _, q := range questions {
+		if _, ok := byUser[q.UserID]; !ok {
+			userIDs = append(userIDs, q.UserID)
+		}
+		byUser[q.UserID] = append(byUser[q.UserID], q)
+	}
+
+	for _, userID := range userIDs {
+		if err := s.clusterQuestions(ctx, userID, byUser[userID]); err != nil {
+			logger.Error("Failed to update FAQ", "user_id", userID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// clusterQuestions assigns each question to the most similar of the user's FAQ entries, or starts
+// a new entry when none is similar enough
+func (s *FAQService) clusterQuestions(ctx context.Context, userID string, questions []*repository.FAQQuestion) error {
+	// Follow-ups ("and the second one?") only make sense within their conversation
+	var standalone []*repository.FAQQuestion
+	var texts []string
+	for _, q := range questions {
+		if q.FollowUp {
+			if err := s.faqRepo.MarkProcessed(ctx, q.HistoryID, ""); err != nil {
+				return err
+			}
+			continue
+		}
+		standalone = append(standalone, q)
+		texts = append(texts, q.Question)
+	}
+	if len(standalone) == 0 {
+		return nil
+	}
+
+	provider, err := s.embeddings.ForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	// Entries embedded by the user's previous model can't be compared, so they are rebuilt
+	if err := s.faqRepo.ResetStale(ctx, userID, provider.Dimensions()); err != nil {
+		return err
+	}
+
+	embeddings, err := provider.GenerateEmbeddings(ctx, texts, EmbeddingQuery)
+	if err != nil {
+		return fmt.Errorf("failed to embed questions: %w", err)
+	}
+
+	clusters, err := s.faqRepo.ListClusters(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	touched := make(map[string]bool)
+	for i, q := range standalone {
+		embedding := normalizeEmbedding(append([]float32(nil), embeddings[i]...))
+
+		var best *repository.FAQCluster
+		bestScore := faqClusterThreshold
+		for _, cluster := range clusters {
+			if score := cosineSimilarity(embedding, cluster.Embedding); score >= bestScore {
+				best, bestScore = cluster, score
+			}
+		}
+
+		if best == nil {
+			best, err = s.faqRepo.CreateCluster(ctx, userID, q, embedding)
+			if err != nil {
+				return err
+			}
+			clusters = append(clusters, best)
+		} else {
+			// The centroid is the normalized mean of the entry's question embeddings
+			for j := range best.Embedding {
+				best.Embedding[j] = best.Embedding[j]*float32(best.AskCount) + embedding[j]
+			}
+			normalizeEmbedding(best.Embedding)
+			best.AskCount++
+			if err := s.faqRepo.UpdateCluster(ctx, best, q.AskedAt); err != nil {
+				return err
+			}
+		}
+
+		if err := s.faqRepo.MarkProcessed(ctx, q.HistoryID, best.ID); err != nil {
+			return err
+		}
+		touched[best.ID] = true
+	}
+
+	for clusterID := range touched {
+		if err := s.faqRepo.RefreshBestAnswer(ctx, clusterID); err != nil {
+			return err
+		}
+	}
+
+	logger.Debug("Updated FAQ", "user_id", userID, "questions", len(standalone), "entries", len(touched))
+	return nil
+}
+
+// FAQMatch identifies the FAQ entry a repeat question was answered from
+type FAQMatch struct {
+	ID         string  `json:"id"`
+	Question   string  `json:"question"`
+	Similarity float64 `json:"similarity"`
+
+	answer  string
+	sources []map[string]interface{}
+}
+
+// answerFromFAQ returns the user's FAQ entry matching the question closely enough to reuse its
+// endorsed answer, or nil. Failures are logged and treated as no match.
+func (s *RAGService) answerFromFAQ(ctx context.Context, userID, question string) *FAQMatch {
+	if s.faqRepo == nil {
+		return nil
+	}
+
+	entries, embeddings, err := s.faqRepo.ListAnswerable(ctx, userID, faqMinAsks, time.Now().Add(-faqAnswerMaxAge))
+	if err != nil {
+		logger.Error("Failed to load FAQ", "user_id", userID, "error", err)
+		return nil
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	embedding, err := s.embeddings.Embed(ctx, userID, question, EmbeddingQuery)
+	if err != nil {
+		logger.Warn("Failed to embed question for FAQ lookup", "user_id", userID, "error", err)
+		return nil
+	}
+
+	var match *FAQMatch
+	for i, entry := range entries {
+		score := cosineSimilarity(embedding, embeddings[i])
+		if score < faqMatchThreshold || (match != nil && score <= match.Similarity) {
+			continue
+		}
+		match = &FAQMatch{
+			ID:         entry.ID,
+			Question:   entry.Question,
+			Similarity: score,
+			answer:     entry.Answer,
+			sources:    faqSources(entry.Sources),
+		}
+	}
+	return match
+}
+
+// faqSources converts sources decoded from query history back to the shape buildSources returns
+func faqSources(raw interface{}) []map[string]interface{} {
+	items, _ := raw.([]interface{})
+	sources := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if source, ok := item.(map[string]interface{}); ok {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// faqEligible reports whether a query is a plain question that a FAQ answer fits. Follow-ups
+// and queries that change retrieval or generation always run the pipeline.
+func faqEligible(req QueryRequest, filters map[string]string, exclude map[string][]string) bool {
+	return req.ConversationID == "" && !req.Agent &&
+		len(filters) == 0 && len(exclude) == 0 && len(req.ExcludeDocumentIDs) == 0 &&
+		req.Mode == "" && req.Warranty == "" && req.ClauseType == "" && req.Language == "" &&
+		req.Model == "" && req.Temperature == nil && req.Diversity == 0 && len(req.Pipeline) == 0
+}
+
+// answerQuality records the confidence and verification of a generated answer for history
+func answerQuality(confidence *Confidence, verification *Verification) model.AnswerQuality {
+	var quality model.AnswerQuality
+	if confidence != nil {
+		quality.Confidence = &confidence.Score
+	}
+	if verification != nil {
+		quality.Verified = &verification.Supported
+	}
+	return quality
+}
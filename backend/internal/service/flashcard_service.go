@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// SM-2 scheduling parameters
+const (
+	initialEaseFactor = 2.5
+	minEaseFactor     = 1.3
+	// passingGrade is the lowest grade (0-5) that counts as remembered
+	passingGrade = 3
+	// matureIntervalDays is the interval from which a card counts as mature in stats
+	matureIntervalDays = 21
+)
+
+// Flashcard generation limits
+const (
+	defaultFlashcardCount   = 10
+	maxFlashcardCount       = 30
+	flashcardDocumentChars  = 12000
+	maxDueFlashcards        = 100
+	flashcardRetentionDays  = 30
+	maxFlashcardFieldLength = 2000
+)
+
+// FlashcardService generates flashcards from documents and schedules their reviews with SM-2
+type FlashcardService struct {
+	flashcardRepo *repository.FlashcardRepository
+	documentRepo  *repository.DocumentRepository
+	chunkRepo     *repository.ChunkRepository
+	ragService    *RAGService
+}
+
+// NewFlashcardService creates a new flashcard service
+func NewFlashcardService(
+	flashcardRepo *repository.FlashcardRepository,
+	documentRepo *repository.DocumentRepository,
+	chunkRepo *repository.ChunkRepository,
+	ragService *RAGService,
+) *FlashcardService {
+	return &FlashcardService{
+		flashcardRepo: flashcardRepo,
+		documentRepo:  documentRepo,
+		chunkRepo:     chunkRepo,
+		ragService:    ragService,
+	}
+}
+
+// FlashcardInput represents a flashcard written by the user
+type FlashcardInput struct {
+	Question   string `json:"question"`
+	Answer     string `json:"answer"`
+	DocumentID string `json:"document_id"`
+}
+
+// Create adds a flashcard written by the user, due for review now
+func (s *FlashcardService) Create(ctx context.Context, userID string, input FlashcardInput) (*model.Flashcard, error) {
+	f := newFlashcard(userID, input.Question, input.Answer, time.Now())
+	if f.Question == "" || f.Answer == "" {
+		return nil, fmt.Errorf("question and answer are required")
+	}
+	if len(f.Question) > maxFlashcardFieldLength || len(f.Answer) > maxFlashcardFieldLength {
+		return nil, fmt.Errorf("question and answer must be at most %d characters", maxFlashcardFieldLength)
+	}
+	if input.DocumentID != "" {
+		doc, err := s.userDocument(ctx, userID, input.DocumentID)
+		if err != nil {
+			return nil, err
+		}
+		f.DocumentID = &doc.ID
+		f.Source = doc.Filename
+	}
+
+	if err := s.flashcardRepo.Create(ctx, []*model.Flashcard{f}); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// flashcardSystemPrompt asks for self-contained cards grounded in the document
+const flashcardSystemPrompt = `You write study flashcards from a document.
+
+Rules:
+1. Each card tests one fact, definition or idea that is worth remembering from the document
+2. Questions must make sense without the document; answers are short (one or two sentences) and come only from the document
+3. Don't repeat a fact across cards
+4. Reply with a JSON array of objects with "question" and "answer" fields, and nothing else`
+
+// Generate writes up to count flashcards from a document, all due for review now
+func (s *FlashcardService) Generate(ctx context.Context, userID, documentID string, count int) ([]*model.Flashcard, error) {
+	if count <= 0 {
+		count = defaultFlashcardCount
+	}
+	if count > maxFlashcardCount {
+		return nil, fmt.Errorf("count must be at most %d", maxFlashcardCount)
+	}
+
+	doc, err := s.userDocument(ctx, userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	text, err := s.chunkRepo.GetDocumentText(ctx, doc.ID, flashcardDocumentChars)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("document has no text")
+	}
+
+	reply, err := s.ragService.callLLM(ctx, flashcardSystemPrompt,
+		fmt.Sprintf("Document: %s\n\n%s\n\nWrite up to %d flashcards:", doc.Filename, text, count))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate flashcards: %w", err)
+	}
+
+	var generated []struct {
+		Question string `json:"question"`
+		Answer   string `json:"answer"`
+	}
+	if err := json.Unmarshal([]byte(stripCodeFence(reply)), &generated); err != nil {
+		return nil, fmt.Errorf("failed to parse generated flashcards: %w", err)
+	}
+
+	now := time.Now()
+	var cards []*model.Flashcard
+	for _, g := range generated {
+		f := newFlashcard(userID, truncate(g.Question, maxFlashcardFieldLength), truncate(g.Answer, maxFlashcardFieldLength), now)
+		if f.Question == "" || f.Answer == "" {
+			continue
+		}
+		f.DocumentID = &doc.ID
+		f.Source = doc.Filename
+		cards = append(cards, f)
+		if len(cards) == count {
+			break
+		}
+	}
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("no flashcards could be generated from this document")
+	}
+
+	if err := s.flashcardRepo.Create(ctx, cards); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// List lists a user's flashcards, optionally only those from a document
+func (s *FlashcardService) List(ctx context.Context, userID, documentID string) ([]*model.Flashcard, error) {
+	return s.flashcardRepo.ListByUserID(ctx, userID, documentID)
+}
+
+// Due lists the flashcards due for review, most overdue first
+func (s *FlashcardService) Due(ctx context.Context, userID string, limit int) ([]*model.Flashcard, error) {
+	if limit <= 0 || limit > maxDueFlashcards {
+		limit = maxDueFlashcards
+	}
+	return s.flashcardRepo.ListDue(ctx, userID, time.Now(), limit)
+}
+
+// Review grades a recall of the flashcard from 0 (forgot completely) to 5 (perfect recall)
+// and schedules its next review
+func (s *FlashcardService) Review(ctx context.Context, userID, id string, grade int) (*model.Flashcard, error) {
+	if grade < 0 || grade > 5 {
+		return nil, fmt.Errorf("grade must be between 0 and 5")
+	}
+
+	f, err := s.flashcardRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduleReview(f, grade, time.Now())
+	if err := s.flashcardRepo.SaveReview(ctx, f, grade); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// FlashcardExportRequest selects the flashcards to export: the given IDs, else the cards from a
+// document, else all of the user's cards
+type FlashcardExportRequest struct {
+	FlashcardIDs []string `json:"flashcard_ids"`
+	DocumentID   string   `json:"document_id"`
+	Deck         string   `json:"deck"`
+}
+
+// ExportAnki renders the selected flashcards as an Anki deck in Anki's CSV import format, tagged
+// with their source document
+func (s *FlashcardService) ExportAnki(ctx context.Context, userID string, req FlashcardExportRequest) ([]byte, error) {
+	var cards []*model.Flashcard
+	var err error
+	if len(req.FlashcardIDs) > 0 {
+		cards, err = s.flashcardRepo.ListByIDs(ctx, userID, req.FlashcardIDs)
+	} else {
+		cards, err = s.flashcardRepo.ListByUserID(ctx, userID, req.DocumentID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("no flashcards to export")
+	}
+
+	notes := make([]ankiNote, len(cards))
+	for i, card := range cards {
+		note := ankiNote{front: ankiField(card.Question), back: ankiField(card.Answer)}
+		if card.Source != "" {
+			note.back = ankiSources(card.Answer, []string{card.Source})
+			note.tags = []string{card.Source}
+		}
+		notes[i] = note
+	}
+	return renderAnkiCSV(req.Deck, notes)
+}
+
+// Delete deletes a flashcard
+func (s *FlashcardService) Delete(ctx context.Context, userID, id string) error {
+	return s.flashcardRepo.Delete(ctx, userID, id)
+}
+
+// Stats reports card counts, today's reviews, the review streak and recent retention. Days
+// (for today's reviews and the streak) follow the given IANA timezone, defaulting to UTC.
+func (s *FlashcardService) Stats(ctx context.Context, userID, timezone string) (*model.FlashcardStats, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %s", timezone)
+	}
+
+	now := time.Now()
+	stats := &model.FlashcardStats{}
+	if err := s.flashcardRepo.CountCards(ctx, userID, now, matureIntervalDays, stats); err != nil {
+		return nil, err
+	}
+
+	local := now.In(loc)
+	todayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if stats.ReviewsToday, _, err = s.flashcardRepo.CountReviews(ctx, userID, todayStart); err != nil {
+		return nil, err
+	}
+
+	reviews, passed, err := s.flashcardRepo.CountReviews(ctx, userID, now.AddDate(0, 0, -flashcardRetentionDays))
+	if err != nil {
+		return nil, err
+	}
+	if reviews > 0 {
+		stats.Retention = math.Round(float64(passed)/float64(reviews)*1000) / 1000
+	}
+
+	days, err := s.flashcardRepo.ReviewDays(ctx, userID, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	stats.CurrentStreak, stats.LongestStreak = reviewStreaks(days, today)
+
+	return stats, nil
+}
+
+// reviewStreaks counts consecutive review days. days are distinct dates, most recent first. The
+// current streak still counts when today has no review yet but yesterday did.
+func reviewStreaks(days []time.Time, today time.Time) (int, int) {
+	current, longest, run := 0, 0, 0
+	for i, day := range days {
+		if i > 0 && days[i-1].Sub(day) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		// The current streak is the run that starts at today or yesterday
+		if i+1-run == 0 && today.Sub(days[0]) <= 24*time.Hour {
+			current = run
+		}
+	}
+	return current, longest
+}
+
+// scheduleReview applies the SM-2 algorithm: a passing grade grows the interval (1 day, 6 days,
+// then by the ease factor); a failing grade restarts the card at 1 day. The ease factor moves
+// with every grade and never drops below 1.3.
+func scheduleReview(f *model.Flashcard, grade int, now time.Time) {
+	if grade >= passingGrade {
+		switch f.Repetitions {
+		case 0:
+			f.IntervalDays = 1
+		case 1:
+			f.IntervalDays = 6
+		default:
+			f.IntervalDays = int(math.Round(float64(f.IntervalDays) * f.EaseFactor))
+		}
+		f.Repetitions++
+	} else {
+		f.Repetitions = 0
+		f.IntervalDays = 1
+		f.Lapses++
+	}
+
+	miss := float64(5 - grade)
+	f.EaseFactor = math.Max(minEaseFactor, f.EaseFactor+0.1-miss*(0.08+miss*0.02))
+
+	f.LastReviewedAt = &now
+	f.DueAt = now.AddDate(0, 0, f.IntervalDays)
+}
+
+// newFlashcard creates an unreviewed flashcard due now
+func newFlashcard(userID, question, answer string, now time.Time) *model.Flashcard {
+	return &model.Flashcard{
+		UserID:     userID,
+		Question:   strings.TrimSpace(question),
+		Answer:     strings.TrimSpace(answer),
+		EaseFactor: initialEaseFactor,
+		DueAt:      now,
+	}
+}
+
+// userDocument gets a document and checks that it belongs to the user
+func (s *FlashcardService) userDocument(ctx context.Context, userID, documentID string) (*model.Document, error) {
+	if documentID == "" {
+		return nil, fmt.Errorf("document ID is required")
+	}
+	doc, err := s.documentRepo.GetByID(ctx, documentID)
+	if err != nil || doc.UserID != userID {
+		return nil, fmt.Errorf("document %w", apperror.ErrNotFound)
+	}
+	return doc, nil
+}
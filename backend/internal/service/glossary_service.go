@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Glossary suggestion parameters
+const (
+	glossarySuggestionMinOccurrences = 3
+	glossarySuggestionLimit          = 20
+)
+
+// GlossaryService manages a user's glossary of terms expanded in queries
+type GlossaryService struct {
+	glossaryRepo *repository.GlossaryRepository
+}
+
+// NewGlossaryService creates a new glossary service
+func NewGlossaryService(glossaryRepo *repository.GlossaryRepository) *GlossaryService {
+	return &GlossaryService{glossaryRepo: glossaryRepo}
+}
+
+// GlossaryTermInput represents the editable fields of a glossary term
+type GlossaryTermInput struct {
+	Term      string `json:"term"`
+	Expansion string `json:"expansion"`
+}
+
+// Create adds a term to the user's glossary
+func (s *GlossaryService) Create(ctx context.Context, userID string, input GlossaryTermInput) (*model.GlossaryTerm, error) {
+	if err := validateGlossaryTerm(input); err != nil {
+		return nil, err
+	}
+
+	term := &model.GlossaryTerm{
+		UserID:    userID,
+		Term:      strings.TrimSpace(input.Term),
+		Expansion: strings.TrimSpace(input.Expansion),
+	}
+	if err := s.glossaryRepo.Create(ctx, term); err != nil {
+		return nil, err
+	}
+
+	return term, nil
+}
+
+// List lists the user's glossary terms
+func (s *GlossaryService) List(ctx context.Context, userID string) ([]*model.GlossaryTerm, error) {
+	return s.glossaryRepo.ListByUserID(ctx, userID)
+}
+
+// Update replaces a glossary term's term and expansion
+func (s *GlossaryService) Update(ctx context.Context, userID, termID string, input GlossaryTermInput) (*model.GlossaryTerm, error) {
+	if err := validateGlossaryTerm(input); err != nil {
+		return nil, err
+	}
+
+	term, err := s.glossaryRepo.GetByID(ctx, userID, termID)
+	if err != nil {
+		return nil, err
+	}
+
+	term.Term = strings.TrimSpace(input.Term)
+	term.Expansion = strings.TrimSpace(input.Expansion)
+	if err := s.glossaryRepo.Update(ctx, term); err != nil {
+		return nil, err
+	}
+
+	return term, nil
+}
+
+// Delete removes a term from the user's glossary
+func (s *GlossaryService) Delete(ctx context.Context, userID, termID string) error {
+	return s.glossaryRepo.Delete(ctx, userID, termID)
+}
+
+// Suggest lists frequent acronyms in the user's documents that are not in the glossary yet
+func (s *GlossaryService) Suggest(ctx context.Context, userID string) ([]*model.GlossarySuggestion, error) {
+	return s.glossaryRepo.SuggestTerms(ctx, userID, glossarySuggestionMinOccurrences, glossarySuggestionLimit)
+}
+
+// validateGlossaryTerm validates glossary term input
+func validateGlossaryTerm(input GlossaryTermInput) error {
+	term := strings.TrimSpace(input.Term)
+	if term == "" {
+		return fmt.Errorf("term is required")
+	}
+	if len(term) > 100 {
+		return fmt.Errorf("term must be at most 100 characters")
+	}
+	expansion := strings.TrimSpace(input.Expansion)
+	if expansion == "" {
+		return fmt.Errorf("expansion is required")
+	}
+	if len(expansion) > 500 {
+		return fmt.Errorf("expansion must be at most 500 characters")
+	}
+	return nil
+}
+
+// expandGlossary appends the expansion of every glossary term the query mentions as a whole
+// word (ignoring case), so the embedding captures what acronyms and codenames stand for.
+// Terms whose expansion already appears in the query are left alone.
+func expandGlossary(query string, terms []*model.GlossaryTerm) string {
+	lowerQuery := strings.ToLower(query)
+
+	var expansions []string
+	for _, term := range terms {
+		pattern := `(?i)(^|[^\pL\pN])` + regexp.QuoteMeta(term.Term) + `($|[^\pL\pN])`
+		if matched, err := regexp.MatchString(pattern, query); err != nil || !matched {
+			continue
+		}
+		if strings.Contains(lowerQuery, strings.ToLower(term.Expansion)) {
+			continue
+		}
+		expansions = append(expansions, term.Term+": "+term.Expansion)
+	}
+
+	if len(expansions) == 0 {
+		return query
+	}
+	return query + "\n(" + strings.Join(expansions, "; ") + ")"
+}
+
+// expandQuery applies the user's glossary to a query; on failure the query is used as is
+func (s *RAGService) expandQuery(ctx context.Context, userID, query string) string {
+	terms, err := s.glossaryRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to load glossary", "user_id", userID, "error", err)
+		return query
+	}
+	return expandGlossary(query, terms)
+}
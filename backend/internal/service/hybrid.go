@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// hybridDenseWeight is the share of a chunk's hybrid score from dense similarity; the rest comes
+// from its sparse score relative to the best sparse match
+const hybridDenseWeight = 0.7
+
+// addSparseMatches searches the user's chunks by the query's sparse vector and fuses the matches
+// with the dense results. Failures are logged and leave the dense results unchanged.
+func (s *RAGService) addSparseMatches(ctx context.Context, userID, query string, queryEmbedding []float32, dense []*model.VectorPoint, limit int, filter *repository.SearchFilter) []*model.VectorPoint {
+	vectors, err := s.sparseEncoder.Encode(ctx, []string{query}, EmbeddingQuery)
+	if err != nil || len(vectors) == 0 {
+		logger.Warn("Sparse encoding failed, using dense retrieval only", "user_id", userID, "error", err)
+		return dense
+	}
+
+	sparse, err := s.vectorRepo.SearchSparse(ctx, userID, vectors[0], limit, filter)
+	if err != nil {
+		logger.Warn("Sparse search failed, using dense retrieval only", "user_id", userID, "error", err)
+		return dense
+	}
+
+	return fuseHybrid(queryEmbedding, dense, sparse)
+}
+
+// fuseHybrid merges dense and sparse results into one ranking. Each chunk scores a weighted sum of
+// its cosine similarity and its normalized sparse score, so scores stay in the 0-1 range that
+// ranking and confidence expect. Chunks found only by sparse search are scored by their returned
+// embedding.
+func fuseHybrid(queryEmbedding []float32, dense, sparse []*model.VectorPoint) []*model.VectorPoint {
+	if len(sparse) == 0 {
+		return dense
+	}
+
+	var maxSparse float32
+	sparseScores := make(map[string]float32, len(sparse))
+	for _, point := range sparse {
+		sparseScores[point.ID] = point.Score
+		maxSparse = max(maxSparse, point.Score)
+	}
+	normalized := func(id string) float64 {
+		if maxSparse <= 0 {
+			return 0
+		}
+		return float64(sparseScores[id] / maxSparse)
+	}
+
+	seen := make(map[string]bool, len(dense))
+	results := make([]*model.VectorPoint, 0, len(dense)+len(sparse))
+	for _, point := range dense {
+		seen[point.ID] = true
+		point.Score = float32(hybridDenseWeight*float64(point.Score) + (1-hybridDenseWeight)*normalized(point.ID))
+		results = append(results, point)
+	}
+	for _, point := range sparse {
+		if seen[point.ID] {
+			continue
+		}
+		var similarity float64
+		if len(point.Vector) == len(queryEmbedding) {
+			similarity = cosineSimilarity(queryEmbedding, point.Vector)
+		}
+		point.Score = float32(hybridDenseWeight*similarity + (1-hybridDenseWeight)*normalized(point.ID))
+		results = append(results, point)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// MailLogService exposes the outbound email send log to admins
+type MailLogService struct {
+	mailLogRepo *repository.MailLogRepository
+}
+
+// NewMailLogService creates a new mail log service
+func NewMailLogService(mailLogRepo *repository.MailLogRepository) *MailLogService {
+	return &MailLogService{mailLogRepo: mailLogRepo}
+}
+
+// List returns the most recent emails, optionally only those with the status (sent or failed)
+func (s *MailLogService) List(ctx context.Context, status string, limit int) ([]*model.MailLogEntry, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.mailLogRepo.List(ctx, status, limit)
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/matrix"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Matrix bot parameters
+const (
+	matrixRoomName   = "Personal Assistant"
+	matrixMaxSources = 3
+	// matrixLockRetry is how often a replica that isn't running the bot checks whether it should
+	matrixLockRetry = time.Minute
+	// matrixSyncRetry is the pause after a failed sync
+	matrixSyncRetry = 5 * time.Second
+)
+
+var matrixUserIDPattern = regexp.MustCompile(`^@[^:\s]+:[^\s]+$`)
+
+// MatrixService runs the Matrix bot: each user links their Matrix account and gets a private room
+// where text messages are answered from their documents and shared files are ingested
+type MatrixService struct {
+	client          *matrix.Client
+	matrixRepo      *repository.MatrixRepository
+	lockRepo        *repository.LockRepository
+	documentService *DocumentService
+	ragService      *RAGService
+}
+
+// NewMatrixService creates a new Matrix service. A nil client disables the bot.
+func NewMatrixService(
+	client *matrix.Client,
+	matrixRepo *repository.MatrixRepository,
+	lockRepo *repository.LockRepository,
+	documentService *DocumentService,
+	ragService *RAGService,
+) *MatrixService {
+	return &MatrixService{
+		client:          client,
+		matrixRepo:      matrixRepo,
+		lockRepo:        lockRepo,
+		documentService: documentService,
+		ragService:      ragService,
+	}
+}
+
+// Enabled reports whether a bot account is configured
+func (s *MatrixService) Enabled() bool {
+	return s.client != nil
+}
+
+// MatrixStatus reports whether the bot is available and the user's linked room, if any
+type MatrixStatus struct {
+	Enabled   bool              `json:"enabled"`
+	BotUserID string            `json:"bot_user_id,omitempty"`
+	Room      *model.MatrixRoom `json:"room,omitempty"`
+}
+
+// Status returns the bot status for a user
+func (s *MatrixService) Status(ctx context.Context, userID string) (*MatrixStatus, error) {
+	if !s.Enabled() {
+		return &MatrixStatus{}, nil
+	}
+
+	status := &MatrixStatus{Enabled: true, BotUserID: s.client.UserID()}
+	room, err := s.matrixRepo.GetByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrMatrixRoomNotFound) {
+		return nil, err
+	}
+	status.Room = room
+	return status, nil
+}
+
+// Link creates a private room with the Matrix account and invites it. Linking a different
+// account replaces the previous room; linking the same account again keeps it.
+func (s *MatrixService) Link(ctx context.Context, userID, matrixUserID string) (*model.MatrixRoom, error) {
+	if !s.Enabled() {
+		return nil, fmt.Errorf("matrix bot is not configured")
+	}
+	matrixUserID = strings.TrimSpace(matrixUserID)
+	if !matrixUserIDPattern.MatchString(matrixUserID) {
+		return nil, fmt.Errorf("matrix_user_id must look like @name:server")
+	}
+	if matrixUserID == s.client.UserID() {
+		return nil, fmt.Errorf("matrix_user_id can't be the bot's own account")
+	}
+
+	previous, err := s.matrixRepo.GetByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrMatrixRoomNotFound) {
+		return nil, err
+	}
+	if previous != nil && previous.MatrixUserID == matrixUserID {
+		return previous, nil
+	}
+
+	roomID, err := s.client.CreateDirectRoom(ctx, matrixUserID, matrixRoomName)
+	if err != nil {
+		return nil, err
+	}
+
+	room := &model.MatrixRoom{
+		UserID:       userID,
+		MatrixUserID: matrixUserID,
+		RoomID:       roomID,
+	}
+	if err := s.matrixRepo.Save(ctx, room); err != nil {
+		return nil, err
+	}
+
+	if err := s.client.SendNotice(ctx, roomID, "Hi! Ask me anything about your documents, or share a file (PDF, PPTX, XLSX, TXT, MD, JSON, CSV, EML, MBOX, or a PNG or JPG photo) to add it."); err != nil {
+		logger.Warn("Failed to send Matrix welcome message", "room_id", roomID, "error", err)
+	}
+	if previous != nil {
+		if err := s.client.LeaveRoom(ctx, previous.RoomID); err != nil {
+			logger.Warn("Failed to leave previous Matrix room", "room_id", previous.RoomID, "error", err)
+		}
+	}
+
+	return room, nil
+}
+
+// Unlink removes the user's link and leaves their room
+func (s *MatrixService) Unlink(ctx context.Context, userID string) error {
+	if !s.Enabled() {
+		return fmt.Errorf("matrix bot is not configured")
+	}
+
+	room, err := s.matrixRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.matrixRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.client.LeaveRoom(ctx, room.RoomID); err != nil {
+		logger.Warn("Failed to leave Matrix room", "room_id", room.RoomID, "error", err)
+	}
+	return nil
+}
+
+// Run syncs with the homeserver and handles messages until ctx is cancelled. Only one replica
+// runs the bot at a time; the others wait for the lock.
+func (s *MatrixService) Run(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+
+	for {
+		lock, err := s.lockRepo.TryAcquire(ctx, "matrix_bot")
+		if err != nil {
+			logger.Error("Failed to acquire Matrix bot lock", "error", err)
+		}
+		if lock != nil {
+			logger.Info("Matrix bot started", "user_id", s.client.UserID())
+			s.syncLoop(ctx)
+			lock.Release()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(matrixLockRetry):
+		}
+	}
+}
+
+// syncLoop long-polls for new events until ctx is cancelled
+func (s *MatrixService) syncLoop(ctx context.Context) {
+	// Start from "now" so messages sent while the bot was down aren't answered late
+	var since string
+	for {
+		resp, err := s.client.Sync(ctx, "", 0)
+		if err == nil {
+			since = resp.NextBatch
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Error("Matrix sync failed", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(matrixSyncRetry):
+		}
+	}
+
+	for {
+		resp, err := s.client.Sync(ctx, since, matrix.SyncTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error("Matrix sync failed", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(matrixSyncRetry):
+			}
+			continue
+		}
+
+		for roomID, joined := range resp.Rooms.Join {
+			for _, event := range joined.Timeline.Events {
+				s.handleEvent(ctx, roomID, event)
+			}
+		}
+		since = resp.NextBatch
+	}
+}
+
+// handleEvent answers or ingests a message from a linked user in their room
+func (s *MatrixService) handleEvent(ctx context.Context, roomID string, event matrix.Event) {
+	if event.Type != "m.room.message" || event.Sender == s.client.UserID() {
+		return
+	}
+
+	room, err := s.matrixRepo.GetByRoomID(ctx, roomID)
+	if err != nil || room.MatrixUserID != event.Sender {
+		return
+	}
+
+	var content matrix.MessageContent
+	if err := json.Unmarshal(event.Content, &content); err != nil {
+		return
+	}
+
+	var reply string
+	switch content.MsgType {
+	case "m.text":
+		reply = s.answer(ctx, room.UserID, content.Body)
+	case "m.file", "m.image":
+		reply = s.ingest(ctx, room.UserID, content)
+	case "m.notice":
+		return
+	default:
+		reply = "Send me a question as text, or a document as a file (PDF, PPTX, XLSX, TXT, MD, JSON, CSV, EML, MBOX, or a PNG or JPG photo)."
+	}
+
+	if err := s.client.SendNotice(ctx, roomID, reply); err != nil {
+		logger.Error("Failed to send Matrix reply", "room_id", roomID, "error", err)
+	}
+}
+
+// answer answers a question with the titles of the documents it came from
+func (s *MatrixService) answer(ctx context.Context, userID, question string) string {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return "Ask me a question about your documents."
+	}
+
+	response, err := s.ragService.Query(ctx, userID, QueryRequest{
+		Question:   question,
+		Standalone: true,
+	})
+	if err != nil {
+		logger.Error("Matrix query failed", "user_id", userID, "error", err)
+		return "Sorry, I couldn't answer that right now."
+	}
+
+	reply := response.Answer
+	if sources := sourceTitles(response.Sources, matrixMaxSources); len(sources) > 0 {
+		reply += "\n\nSources: " + strings.Join(sources, ", ")
+	}
+	return reply
+}
+
+// ingest downloads a shared file and adds it to the user's documents
+func (s *MatrixService) ingest(ctx context.Context, userID string, content matrix.MessageContent) string {
+	filename := content.Filename
+	if filename == "" {
+		filename = content.Body
+	}
+	filename = filepath.Base(strings.TrimSpace(filename))
+
+	if err := validateUpload(filename, content.Info.Size); err != nil {
+		return fmt.Sprintf("Couldn't add %s: %s", filename, err)
+	}
+
+	data, err := s.client.Download(ctx, content.URL, MaxUploadSize)
+	if err != nil {
+		logger.Error("Failed to download Matrix file", "user_id", userID, "error", err)
+		return fmt.Sprintf("Couldn't download %s.", filename)
+	}
+
+	doc, err := s.documentService.ingestUpload(ctx, userID, filename, bytes.NewReader(data), int64(len(data)), "", ChunkingOptions{}, EmbeddingSourceMatrix)
+	if err != nil {
+		logger.Error("Failed to ingest Matrix file", "user_id", userID, "filename", filename, "error", err)
+		return fmt.Sprintf("Couldn't add %s: %s", filename, err)
+	}
+	return fmt.Sprintf("Added %s (%d chunks).", doc.Filename, doc.TotalChunks)
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"math"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// mmrCandidateFactor sizes the candidate pool MMR selects from, relative to the result limit
+const mmrCandidateFactor = 4
+
+// maximalMarginalRelevance selects up to k candidates that balance relevance to the query
+// against similarity to the chunks already selected. diversity is 1-λ in the usual MMR
+// formulation: 0 keeps the relevance order, 1 picks the most dissimilar chunks.
+// Candidates must be sorted by relevance; those without vectors are only chosen by relevance.
+func maximalMarginalRelevance(candidates []*model.VectorPoint, k int, diversity float64) []*model.VectorPoint {
+	if k <= 0 || len(candidates) == 0 {
+		return nil
+	}
+	if len(candidates) <= k {
+		return candidates
+	}
+
+	lambda := 1 - diversity
+	selected := make([]*model.VectorPoint, 0, k)
+	used := make([]bool, len(candidates))
+	// maxSim[i] is candidate i's highest similarity to any selected chunk
+	maxSim := make([]float64, len(candidates))
+
+	for len(selected) < k {
+		best := -1
+		bestScore := math.Inf(-1)
+		for i, candidate := range candidates {
+			if used[i] {
+				continue
+			}
+			score := lambda*float64(candidate.Score) - (1-lambda)*maxSim[i]
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		used[best] = true
+		selected = append(selected, candidates[best])
+
+		for i, candidate := range candidates {
+			if !used[i] {
+				if sim := cosineSimilarity(candidate.Vector, candidates[best].Vector); sim > maxSim[i] {
+					maxSim[i] = sim
+				}
+			}
+		}
+	}
+
+	return selected
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 if either is empty or mismatched
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/notification"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// EventNotificationTest is the notification event sent to test a channel
+const EventNotificationTest = "notification.test"
+
+// NotificationEvents lists the events users can route. Subsystems emitting a new event add it
+// here so it can be given its own channels; until then it follows the user's "*" route.
+var NotificationEvents = []string{
+	EventDigestCreated,
+	EventScheduledQueryCompleted,
+	EventSecurityAnomaly,
+	EventSecretsFound,
+	EventEmbeddingDrift,
+}
+
+// notificationChannels lists every channel a route may name, available in this deployment or not
+var notificationChannels = []string{
+	notification.ChannelEmail,
+	notification.ChannelPush,
+	notification.ChannelTelegram,
+	notification.ChannelWebhook,
+}
+
+// Target formats: a Telegram chat ID (negative for groups) or @channel, and an ntfy topic
+var (
+	telegramChatIDPattern = regexp.MustCompile(`^(-?\d{1,20}|@[A-Za-z0-9_]{5,32})$`)
+	pushTopicPattern      = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+// NotificationService manages how each user receives notifications
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	bus              *notification.Bus
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(notificationRepo *repository.NotificationRepository, bus *notification.Bus) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		bus:              bus,
+	}
+}
+
+// NotificationPreferences is a user's notification setup alongside what can be configured
+type NotificationPreferences struct {
+	// Channels are the channels available in this deployment
+	Channels []string `json:"channels"`
+	// Events are the events that can be routed, besides "*"
+	Events  []string                    `json:"events"`
+	Targets []*model.NotificationTarget `json:"targets"`
+	// Routes without an entry for an event (and no "*" route) deliver it through every channel
+	Routes []*model.NotificationRoute `json:"routes"`
+}
+
+// Preferences returns the user's notification targets and routes
+func (s *NotificationService) Preferences(ctx context.Context, userID string) (*NotificationPreferences, error) {
+	targets, err := s.notificationRepo.ListTargets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := s.notificationRepo.ListRoutes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationPreferences{
+		Channels: s.bus.Channels(),
+		Events:   NotificationEvents,
+		Targets:  targets,
+		Routes:   routes,
+	}, nil
+}
+
+// SetTarget sets where the user receives a channel: a Telegram chat ID or a push topic
+func (s *NotificationService) SetTarget(ctx context.Context, userID, channel, target string) error {
+	target = strings.TrimSpace(target)
+	switch channel {
+	case notification.ChannelTelegram:
+		if !telegramChatIDPattern.MatchString(target) {
+			return fmt.Errorf("target must be a Telegram chat ID or @channel name")
+		}
+	case notification.ChannelPush:
+		if !pushTopicPattern.MatchString(target) {
+			return fmt.Errorf("target must be a push topic of up to 64 letters, digits, dashes and underscores")
+		}
+	default:
+		return fmt.Errorf("channel %s has no target to set", channel)
+	}
+
+	return s.notificationRepo.SetTarget(ctx, userID, channel, target)
+}
+
+// DeleteTarget stops delivery on a channel by removing the user's target
+func (s *NotificationService) DeleteTarget(ctx context.Context, userID, channel string) error {
+	return s.notificationRepo.DeleteTarget(ctx, userID, channel)
+}
+
+// SetRoute sets the channels an event (or "*" for all others) is delivered through; no channels
+// mutes it
+func (s *NotificationService) SetRoute(ctx context.Context, userID, event string, channels []string) error {
+	if event != "*" && !slices.Contains(NotificationEvents, event) {
+		return fmt.Errorf("unknown event: %s", event)
+	}
+
+	unique := []string{}
+	for _, channel := range channels {
+		if !slices.Contains(notificationChannels, channel) {
+			return fmt.Errorf("unknown channel: %s (valid options: %s)", channel, strings.Join(notificationChannels, ", "))
+		}
+		if !slices.Contains(unique, channel) {
+			unique = append(unique, channel)
+		}
+	}
+
+	return s.notificationRepo.SetRoute(ctx, userID, event, unique)
+}
+
+// DeleteRoute returns an event to the user's "*" route (or every channel)
+func (s *NotificationService) DeleteRoute(ctx context.Context, userID, event string) error {
+	return s.notificationRepo.DeleteRoute(ctx, userID, event)
+}
+
+// Test sends a test notification through one channel, ignoring the user's routes
+func (s *NotificationService) Test(ctx context.Context, userID, channel string) error {
+	if channel == notification.ChannelTelegram || channel == notification.ChannelPush {
+		target, err := s.notificationRepo.Target(ctx, userID, channel)
+		if err != nil {
+			return err
+		}
+		if target == "" {
+			return fmt.Errorf("set a %s target first", channel)
+		}
+	}
+
+	return s.bus.Send(ctx, channel, notification.Notification{
+		UserID: userID,
+		Event:  EventNotificationTest,
+		Title:  "Test notification",
+		Body:   fmt.Sprintf("Notifications on the %s channel are working.", channel),
+	})
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// ocrPrompt asks the vision model for a plain transcription, so OCR'd text is chunked and
+// embedded like any other document's
+const ocrPrompt = "Transcribe all text in this image, including handwriting, exactly as written. " +
+	"Keep line breaks and table rows. Reply with the text only, or nothing if the image has no text."
+
+// describePrompt asks the vision model for a caption as well as the text, so images are found by
+// what they show, such as a whiteboard sketch or a screenshot's error dialog
+const describePrompt = "Describe this image for a search index. Reply with a JSON object with two fields: " +
+	`"caption", one or two sentences on what the image shows (e.g. "A whiteboard diagram of a login flow ` +
+	`with three services" or "A screenshot of a failed payment error in a banking app"), and "text", ` +
+	"all text in the image, including handwriting, exactly as written with line breaks and table rows kept, " +
+	"or an empty string if the image has no text."
+
+// OpenAIOCR reads the text in scanned pages and photos with an OpenAI vision model
+type OpenAIOCR struct {
+	endpoint   OpenAIEndpoint
+	model      string
+	httpClient *httpretry.Client
+	// captions describes uploaded images as well as transcribing them
+	captions bool
+}
+
+// NewOpenAIOCR creates a new OpenAI OCR provider; with captions, image uploads are described as
+// well as transcribed
+func NewOpenAIOCR(endpoint OpenAIEndpoint, model string, captions bool) *OpenAIOCR {
+	return &OpenAIOCR{
+		endpoint:   endpoint,
+		model:      model,
+		httpClient: endpoint.client(120 * time.Second),
+		captions:   captions,
+	}
+}
+
+// Text transcribes the text in an image
+func (o *OpenAIOCR) Text(ctx context.Context, image []byte, contentType string) (string, error) {
+	return o.complete(ctx, image, contentType, ocrPrompt, false)
+}
+
+// Describe returns a caption of an image followed by the text in it, or only the text when
+// captions are disabled
+func (o *OpenAIOCR) Describe(ctx context.Context, image []byte, contentType string) (string, error) {
+	if !o.captions {
+		return o.Text(ctx, image, contentType)
+	}
+
+	content, err := o.complete(ctx, image, contentType, describePrompt, true)
+	if err != nil {
+		return "", err
+	}
+	var description struct {
+		Caption string `json:"caption"`
+		Text    string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(content), &description); err != nil {
+		return "", fmt.Errorf("failed to decode image description: %w", err)
+	}
+
+	caption := strings.TrimSpace(description.Caption)
+	text := strings.TrimSpace(description.Text)
+	if caption == "" {
+		return text, nil
+	}
+	if text == "" {
+		return "Image: " + caption, nil
+	}
+	return "Image: " + caption + "\n\n" + text, nil
+}
+
+// complete sends the image with the prompt to the vision model, asking for a JSON object reply
+// with jsonReply
+func (o *OpenAIOCR) complete(ctx context.Context, image []byte, contentType, prompt string, jsonReply bool) (string, error) {
+	dataURL := "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(image)
+	request := map[string]any{
+		"model": o.model,
+		"messages": []map[string]any{{
+			"role": "user",
+			"content": []map[string]any{
+				{"type": "text", "text": prompt},
+				{"type": "image_url", "image_url": map[string]string{"url": dataURL, "detail": "high"}},
+			},
+		}},
+		"temperature": 0,
+	}
+	if jsonReply {
+		request["response_format"] = map[string]string{"type": "json_object"}
+	}
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.endpoint.URL("/chat/completions"), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	o.endpoint.authorize(req)
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", httpretry.ResponseError("API", resp)
+	}
+
+	var completionResp ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completionResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	billedModel := completionResp.Model
+	if billedModel == "" {
+		billedModel = o.model
+	}
+	trackChatUsage(ctx, billedModel, completionResp.Usage.PromptTokens, completionResp.Usage.CompletionTokens)
+
+	if len(completionResp.Choices) == 0 {
+		return "", fmt.Errorf("no completion choices returned")
+	}
+
+	return strings.TrimSpace(completionResp.Choices[0].Message.Content), nil
+}
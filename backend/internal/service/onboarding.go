@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// onboardingFiles holds the bundled demo documents
+//
+//go:embed onboarding/*.md
+var onboardingFiles embed.FS
+
+// OnboardingDemo is a bundled sample document with a first question that it answers
+type OnboardingDemo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Filename    string `json:"filename"`
+	Question    string `json:"question"`
+	// Suggestions are follow-up questions the document also answers
+	Suggestions []string `json:"suggestions"`
+}
+
+// DefaultOnboardingDemo is indexed when onboarding is started without a file or demo
+const DefaultOnboardingDemo = "getting-started"
+
+// onboardingDemos are the bundled demos; each Filename is a file in onboarding/
+var onboardingDemos = []OnboardingDemo{
+	{
+		Name:        "getting-started",
+		Description: "A short guide to what the assistant can do",
+		Filename:    "getting-started.md",
+		Question:    "What kinds of documents can I add, and how should I ask questions about them?",
+		Suggestions: []string{
+			"How can I tell whether an answer is trustworthy?",
+			"What should I do after uploading my first document?",
+		},
+	},
+	{
+		Name:        "team-meeting",
+		Description: "Notes from a weekly product meeting",
+		Filename:    "team-meeting.md",
+		Question:    "What did the team decide, and who owns each action item?",
+		Suggestions: []string{
+			"What is blocking the search rollout?",
+			"What did usability testing show about onboarding?",
+		},
+	},
+	{
+		Name:        "trip-itinerary",
+		Description: "A four-day city trip with flights, hotel and bookings",
+		Filename:    "trip-itinerary.md",
+		Question:    "When do my flights leave, and what is booked for each day?",
+		Suggestions: []string{
+			"Until when can I cancel the accommodation for free?",
+			"How do I get to Sintra?",
+		},
+	},
+}
+
+// onboardingQuestion is asked about a user's own sample file when they don't give a question
+const onboardingQuestion = "What is this document about? Summarize its key points."
+
+// onboardingSuggestions are follow-up questions for a user's own sample file
+var onboardingSuggestions = []string{
+	"List the dates and deadlines mentioned in this document",
+	"Who is mentioned in this document, and what is their role?",
+}
+
+// ErrUnknownOnboardingDemo is returned when no bundled demo has the requested name
+var ErrUnknownOnboardingDemo = errors.New("unknown onboarding demo")
+
+// OnboardingRequest selects what onboarding indexes: the user's own file, or a bundled demo
+type OnboardingRequest struct {
+	// File is the user's sample file; when nil Demo is used
+	File *multipart.FileHeader
+	// Demo names a bundled demo; empty selects DefaultOnboardingDemo
+	Demo string
+	// Question replaces the canned first question
+	Question string
+}
+
+// OnboardingStep is one stage of the first run, described for display
+type OnboardingStep struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// OnboardingResult is a first run from indexing to answer, laid out as steps for a guided
+// first-run screen
+type OnboardingResult struct {
+	Document *model.Document `json:"document"`
+	// AlreadyIndexed is set when the sample was indexed by an earlier run and reused
+	AlreadyIndexed bool             `json:"already_indexed"`
+	Question       string           `json:"question"`
+	Response       *QueryResponse   `json:"response"`
+	Steps          []OnboardingStep `json:"steps"`
+	// Suggestions are follow-up questions to try next
+	Suggestions []string `json:"suggestions"`
+}
+
+// OnboardingService shows a new user the assistant working in one call: it indexes a sample
+// document and answers a first question about it
+type OnboardingService struct {
+	documentService *DocumentService
+	ragService      *RAGService
+}
+
+// NewOnboardingService creates a new onboarding service
+func NewOnboardingService(documentService *DocumentService, ragService *RAGService) *OnboardingService {
+	return &OnboardingService{
+		documentService: documentService,
+		ragService:      ragService,
+	}
+}
+
+// Demos lists the bundled demos
+func (s *OnboardingService) Demos() []OnboardingDemo {
+	return onboardingDemos
+}
+
+// Start indexes the sample, waiting until its chunks are searchable, then asks the first
+// question about it alone. A sample the user already has is reused rather than indexed again, so
+// onboarding can be run more than once.
+func (s *OnboardingService) Start(ctx context.Context, userID string, req OnboardingRequest) (*OnboardingResult, error) {
+	var (
+		doc         *model.Document
+		reused      bool
+		question    = onboardingQuestion
+		suggestions = onboardingSuggestions
+		err         error
+	)
+	if req.File != nil {
+		doc, reused, err = s.documentService.ingestSample(ctx, userID, req.File)
+	} else {
+		demo, ok := findOnboardingDemo(req.Demo)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownOnboardingDemo, req.Demo)
+		}
+		question, suggestions = demo.Question, demo.Suggestions
+		doc, reused, err = s.documentService.ingestDemo(ctx, userID, demo)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if q := strings.TrimSpace(req.Question); q != "" {
+		question = q
+	}
+
+	response, err := s.ragService.Query(ctx, userID, QueryRequest{
+		Question: question,
+		Filters:  map[string]string{"document_id": doc.ID},
+		Explain:  true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer the first question: %w", err)
+	}
+
+	return &OnboardingResult{
+		Document:       doc,
+		AlreadyIndexed: reused,
+		Question:       question,
+		Response:       response,
+		Steps:          onboardingSteps(doc, reused, question, response),
+		Suggestions:    suggestions,
+	}, nil
+}
+
+// findOnboardingDemo looks up a bundled demo by name; empty selects the default
+func findOnboardingDemo(name string) (OnboardingDemo, bool) {
+	if name == "" {
+		name = DefaultOnboardingDemo
+	}
+	for _, demo := range onboardingDemos {
+		if demo.Name == name {
+			return demo, true
+		}
+	}
+	return OnboardingDemo{}, false
+}
+
+// onboardingSteps describes what the first run did
+func onboardingSteps(doc *model.Document, reused bool, question string, response *QueryResponse) []OnboardingStep {
+	indexed := OnboardingStep{
+		Title: "Indexed " + doc.Filename,
+		Detail: fmt.Sprintf("Split into %d passages and indexed by meaning, so questions find them even in different words.",
+			doc.TotalChunks),
+	}
+	if reused {
+		indexed.Detail = "Already indexed by an earlier run, so it was reused as is."
+	}
+
+	answered := OnboardingStep{
+		Title: "Answered from your document",
+		Detail: fmt.Sprintf("The answer cites %d passages. Open a source to read the text it came from; the explanations say why each one was picked.",
+			len(response.Sources)),
+	}
+	switch {
+	case len(response.Sources) == 0:
+		answered.Detail = "No passage matched the question. Try asking about something the document mentions by name."
+	case response.Degraded:
+		answered.Detail += " The embedding provider was unavailable, so passages were found by keyword instead."
+	}
+
+	return []OnboardingStep{
+		indexed,
+		{Title: "Asked a first question", Detail: question},
+		answered,
+	}
+}
+
+// ingestSample indexes a user's onboarding file, reusing the document if they already have it
+func (s *DocumentService) ingestSample(ctx context.Context, userID string, file *multipart.FileHeader) (*model.Document, bool, error) {
+	if err := validateUpload(file.Filename, file.Size); err != nil {
+		return nil, false, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	return s.ingestOnce(ctx, userID, file.Filename, src, file.Size)
+}
+
+// ingestDemo indexes a bundled demo, reusing the document if the user already has it
+func (s *DocumentService) ingestDemo(ctx context.Context, userID string, demo OnboardingDemo) (*model.Document, bool, error) {
+	content, err := onboardingFiles.ReadFile("onboarding/" + demo.Filename)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read demo: %w", err)
+	}
+	return s.ingestOnce(ctx, userID, demo.Filename, strings.NewReader(string(content)), int64(len(content)))
+}
+
+// ingestOnce returns the user's document with the content's hash, or ingests the content as a
+// new document. The second result reports whether an existing document was returned.
+func (s *DocumentService) ingestOnce(ctx context.Context, userID, filename string, content fileContent, size int64) (*model.Document, bool, error) {
+	fileHash, err := hashContent(content)
+	if err != nil {
+		return nil, false, err
+	}
+	doc, err := s.documentRepo.GetByHash(ctx, userID, fileHash)
+	if err == nil {
+		return doc, true, nil
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return nil, false, err
+	}
+
+	doc, err = s.ingestUpload(ctx, userID, filename, content, size, "", ChunkingOptions{}, EmbeddingSourceOnboarding)
+	if err != nil {
+		return nil, false, err
+	}
+	return doc, false, nil
+}
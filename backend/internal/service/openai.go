@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// OpenAIEndpoint is the OpenAI-compatible API used for chat completions, embeddings and speech:
+// OpenAI itself, or a gateway such as LiteLLM, OpenRouter or a self-hosted server
+type OpenAIEndpoint struct {
+	APIKey string
+	// BaseURL is the API root including the version, without a trailing slash
+	BaseURL string
+	// Proxy routes requests through an HTTP(S) proxy; nil uses HTTP_PROXY/HTTPS_PROXY
+	Proxy *url.URL
+}
+
+// NewOpenAIEndpoint reads the OpenAI endpoint from the configuration
+func NewOpenAIEndpoint(cfg *config.Config) (OpenAIEndpoint, error) {
+	endpoint := OpenAIEndpoint{
+		APIKey:  cfg.OpenAIKey,
+		BaseURL: strings.TrimRight(cfg.OpenAIBaseURL, "/"),
+	}
+
+	base, err := url.Parse(endpoint.BaseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return OpenAIEndpoint{}, fmt.Errorf("invalid OPENAI_BASE_URL: %q", cfg.OpenAIBaseURL)
+	}
+
+	if cfg.OpenAIProxyURL != "" {
+		proxy, err := url.Parse(cfg.OpenAIProxyURL)
+		if err != nil || proxy.Host == "" {
+			return OpenAIEndpoint{}, fmt.Errorf("invalid OPENAI_PROXY_URL: %q", cfg.OpenAIProxyURL)
+		}
+		endpoint.Proxy = proxy
+	}
+
+	return endpoint, nil
+}
+
+// URL returns the address of an API path such as "/chat/completions"
+func (e OpenAIEndpoint) URL(path string) string {
+	return e.BaseURL + path
+}
+
+// authorize adds the API key to a request. Self-hosted servers may not need one.
+func (e OpenAIEndpoint) authorize(req *http.Request) {
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+}
+
+// client creates a retrying HTTP client for the endpoint with the given per-attempt timeout
+func (e OpenAIEndpoint) client(timeout time.Duration) *httpretry.Client {
+	return httpretry.New("openai", timeout).WithProxy(e.Proxy)
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// Pipeline slots, run in this order for every query. Each slot is filled by one
+// registered stage implementation, selected by name.
+const (
+	StageRewrite  = "rewrite"
+	StageRetrieve = "retrieve"
+	StageRerank   = "rerank"
+	StageCompress = "compress"
+	StageGenerate = "generate"
+	StageVerify   = "verify"
+)
+
+// pipelineSlots lists the slots in execution order
+var pipelineSlots = []string{StageRewrite, StageRetrieve, StageRerank, StageCompress, StageGenerate, StageVerify}
+
+// StageNone is the no-op implementation available in the optional slots
+const StageNone = "none"
+
+// rerankCandidateFactor is how many more chunks are retrieved when a reranker will pick the top-k
+const rerankCandidateFactor = 3
+
+// PipelineConfig maps pipeline slots to the name of the stage implementation that fills them
+type PipelineConfig map[string]string
+
+// defaultPipeline is the plain retrieve-then-generate pipeline
+var defaultPipeline = PipelineConfig{
+	StageRewrite:  StageNone,
+	StageRetrieve: "vector",
+	StageRerank:   StageNone,
+	StageCompress: StageNone,
+	StageGenerate: "default",
+	StageVerify:   StageNone,
+}
+
+// PipelineState carries a query through the pipeline; each stage reads and updates it
+type PipelineState struct {
+	UserID   string
+	Question string
+	// SearchQuery is what retrieval searches for; rewrite stages may change it
+	SearchQuery string
+	Retrieval   RetrievalOptions
+	// TopK is the number of chunks kept for generation after reranking
+	TopK       int
+	Generation GenerationOptions
+	// SystemPrompt replaces the default answer instructions when the query is routed to a prompt
+	// version
+	SystemPrompt string
+
+	Results  []*model.VectorPoint
+	Degraded bool
+	// RerankScores holds the LLM reranker's score of each retrieved chunk it scored
+	RerankScores map[*model.VectorPoint]float64
+	// Facts is structured data from document metadata, given to the model ahead of Context
+	Facts string
+	// Context is the document text given to the model, built from Results
+	Context  string
+	Answer   string
+	Logprobs []float64
+
+	Verification *Verification
+}
+
+// Stage is one pipeline step implementation
+type Stage func(ctx context.Context, s *RAGService, state *PipelineState) error
+
+// stages holds the registered implementations per slot
+var stages = make(map[string]map[string]Stage)
+
+// RegisterStage makes a stage implementation selectable by name for a pipeline slot
+func RegisterStage(slot, name string, stage Stage) {
+	if stages[slot] == nil {
+		stages[slot] = make(map[string]Stage)
+	}
+	stages[slot][name] = stage
+}
+
+// StageNames returns the registered implementation names for a slot
+func StageNames(slot string) []string {
+	names := make([]string, 0, len(stages[slot]))
+	for name := range stages[slot] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParsePipelineConfig parses a pipeline spec such as "rewrite=llm,rerank=llm,verify=llm".
+// Slots that are not mentioned keep their default implementation.
+func ParsePipelineConfig(spec string) (PipelineConfig, error) {
+	config := PipelineConfig{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		slot, name, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid pipeline stage %q (expected slot=name)", part)
+		}
+		config[strings.TrimSpace(slot)] = strings.TrimSpace(name)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// validate checks that every slot and implementation name is registered
+func (c PipelineConfig) validate() error {
+	for slot, name := range c {
+		implementations, ok := stages[slot]
+		if !ok {
+			return fmt.Errorf("unknown pipeline slot %q (expected one of: %s)", slot, strings.Join(pipelineSlots, ", "))
+		}
+		if _, ok := implementations[name]; !ok {
+			return fmt.Errorf("unknown %s stage %q (expected one of: %s)", slot, name, strings.Join(StageNames(slot), ", "))
+		}
+	}
+	return nil
+}
+
+// with returns a copy of c overridden by the non-empty entries of override
+func (c PipelineConfig) with(override PipelineConfig) PipelineConfig {
+	merged := make(PipelineConfig, len(c)+len(override))
+	for slot, name := range c {
+		merged[slot] = name
+	}
+	for slot, name := range override {
+		if name != "" {
+			merged[slot] = name
+		}
+	}
+	return merged
+}
+
+// resolvePipeline combines the defaults, the service configuration and a per-request override
+func (s *RAGService) resolvePipeline(override map[string]string) (PipelineConfig, error) {
+	config := defaultPipeline.with(s.pipeline).with(override)
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// runStages runs the given pipeline slots in order with the configured implementations
+func (s *RAGService) runStages(ctx context.Context, config PipelineConfig, state *PipelineState, slots ...string) error {
+	if state.TopK <= 0 {
+		state.TopK = retrievalLimit
+	}
+	if state.SearchQuery == "" {
+		state.SearchQuery = state.Question
+	}
+
+	for _, slot := range slots {
+		if slot == StageRetrieve && state.Retrieval.TopK == 0 {
+			state.Retrieval.TopK = state.TopK
+			// Rerankers need a wider candidate pool than the chunks they keep
+			if config[StageRerank] != StageNone {
+				state.Retrieval.TopK *= rerankCandidateFactor
+			}
+		}
+
+		if err := stages[slot][config[slot]](ctx, s, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+func init() {
+	RegisterStage(StageRewrite, StageNone, noopStage)
+	RegisterStage(StageRewrite, "llm", rewriteWithLLM)
+	RegisterStage(StageRetrieve, "vector", retrieveVector)
+	RegisterStage(StageRerank, StageNone, rerankNone)
+	RegisterStage(StageRerank, "llm", rerankWithLLM)
+	RegisterStage(StageCompress, StageNone, compressNone)
+	RegisterStage(StageCompress, "extractive", compressExtractive)
+	RegisterStage(StageGenerate, "default", generateAnswer)
+	RegisterStage(StageVerify, StageNone, noopStage)
+	RegisterStage(StageVerify, "llm", verifyWithLLM)
+}
+
+// Verification reports whether the answer's claims are backed by the retrieved context
+type Verification struct {
+	Supported bool `json:"supported"`
+	// UnsupportedClaims lists statements in the answer that the context does not support
+	UnsupportedClaims []string `json:"unsupported_claims,omitempty"`
+}
+
+func noopStage(ctx context.Context, s *RAGService, state *PipelineState) error {
+	return nil
+}
+
+// rewriteSystemPrompt asks for a search query that retrieves well on its own
+const rewriteSystemPrompt = `Rewrite the user's question as a short, self-contained search query for a document search engine.
+Expand abbreviations where obvious and drop filler words. Reply with the query only.`
+
+// rewriteWithLLM replaces the search query with an LLM rewrite; the question itself is unchanged
+func rewriteWithLLM(ctx context.Context, s *RAGService, state *PipelineState) error {
+	rewritten, err := s.callLLM(ctx, rewriteSystemPrompt, state.Question)
+	if err != nil {
+		// Retrieval still works with the original question
+		logger.Error("Failed to rewrite query", "user_id", state.UserID, "error", err)
+		return nil
+	}
+
+	if rewritten = strings.Trim(strings.TrimSpace(rewritten), `"`); rewritten != "" {
+		state.SearchQuery = rewritten
+	}
+	return nil
+}
+
+func retrieveVector(ctx context.Context, s *RAGService, state *PipelineState) error {
+	var err error
+	state.Results, state.Degraded, err = s.retrieve(ctx, state.UserID, state.SearchQuery, state.Retrieval)
+	return err
+}
+
+// rerankNone keeps the top-k chunks in retrieval order
+func rerankNone(ctx context.Context, s *RAGService, state *PipelineState) error {
+	if len(state.Results) > state.TopK {
+		state.Results = state.Results[:state.TopK]
+	}
+	return nil
+}
+
+// rerankSystemPrompt asks for one relevance score per numbered chunk
+const rerankSystemPrompt = `You rate how relevant each numbered document is to the question, from 0 (irrelevant) to 10 (answers it directly).
+Reply with a JSON array of numbers only, one per document, in document order.`
+
+// rerankWithLLM scores each retrieved chunk against the question with the LLM and keeps the top-k
+func rerankWithLLM(ctx context.Context, s *RAGService, state *PipelineState) error {
+	if len(state.Results) <= 1 {
+		return nil
+	}
+
+	userPrompt := fmt.Sprintf("Question: %s\n\nDocuments:\n%s", state.Question, buildContextText(state.Results))
+	reply, err := s.callLLM(ctx, rerankSystemPrompt, userPrompt)
+
+	var scores []float64
+	if err == nil {
+		err = json.Unmarshal([]byte(stripCodeFence(reply)), &scores)
+	}
+	if err == nil && len(scores) != len(state.Results) {
+		err = fmt.Errorf("got %d scores for %d chunks", len(scores), len(state.Results))
+	}
+	if err != nil {
+		logger.Error("Failed to rerank chunks", "user_id", state.UserID, "error", err)
+		return rerankNone(ctx, s, state)
+	}
+
+	state.RerankScores = make(map[*model.VectorPoint]float64, len(state.Results))
+	ranked := make([]int, len(state.Results))
+	for i := range ranked {
+		ranked[i] = i
+		state.RerankScores[state.Results[i]] = scores[i]
+	}
+	// Stable so ties keep their retrieval order
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return scores[ranked[a]] > scores[ranked[b]]
+	})
+
+	results := make([]*model.VectorPoint, 0, state.TopK)
+	for _, i := range ranked {
+		if len(results) == state.TopK {
+			break
+		}
+		results = append(results, state.Results[i])
+	}
+	state.Results = results
+	return nil
+}
+
+func compressNone(ctx context.Context, s *RAGService, state *PipelineState) error {
+	state.Context = buildContextText(state.Results)
+	return nil
+}
+
+var sentenceBoundary = regexp.MustCompile(`[.!?]\s+`)
+
+// compressExtractive keeps only the sentences of each chunk that mention a question term,
+// falling back to the chunk's first sentence so every source stays numbered in the context
+func compressExtractive(ctx context.Context, s *RAGService, state *PipelineState) error {
+	terms := significantTerms(state.Question)
+
+	contextText := ""
+	i := 0
+	for _, result := range state.Results {
+		content, ok := result.Payload["content"].(string)
+		if !ok {
+			continue
+		}
+		i++
+
+		sentences := sentenceBoundary.Split(content, -1)
+		var kept []string
+		for _, sentence := range sentences {
+			lower := strings.ToLower(sentence)
+			for _, term := range terms {
+				if strings.Contains(lower, term) {
+					kept = append(kept, strings.TrimSpace(sentence))
+					break
+				}
+			}
+		}
+		if len(kept) == 0 {
+			kept = []string{strings.TrimSpace(sentences[0])}
+		}
+
+		contextText += fmt.Sprintf("\n[Document %d]: %s\n", i, strings.Join(kept, " ... "))
+	}
+
+	state.Context = contextText
+	return nil
+}
+
+func generateAnswer(ctx context.Context, s *RAGService, state *PipelineState) error {
+	userPrompt := fmt.Sprintf("Context from user's documents:\n%s\n\nQuestion: %s\n\nAnswer based on the above context:", promptContext(state), state.Question)
+
+	systemPrompt := ragSystemPrompt
+	if state.SystemPrompt != "" {
+		systemPrompt = state.SystemPrompt
+	}
+	message, err := s.generate(ctx, state.Generation, systemPrompt, userPrompt)
+	if err != nil {
+		return fmt.Errorf("failed to call LLM: %w", err)
+	}
+	state.Answer = message.Content
+	state.Logprobs = message.TokenLogprobs
+	return nil
+}
+
+// verifySystemPrompt asks the model to fact-check an answer against the context
+const verifySystemPrompt = `You check whether an answer is supported by the given context.
+Reply with JSON only: {"supported": true|false, "unsupported_claims": ["..."]}, listing each claim in the answer that the context does not support.`
+
+// verifyWithLLM checks the answer against the context; a failed check leaves Verification unset
+func verifyWithLLM(ctx context.Context, s *RAGService, state *PipelineState) error {
+	if state.Answer == "" {
+		return nil
+	}
+
+	userPrompt := fmt.Sprintf("Context:\n%s\n\nQuestion: %s\n\nAnswer:\n%s", promptContext(state), state.Question, state.Answer)
+	reply, err := s.callLLM(ctx, verifySystemPrompt, userPrompt)
+
+	var verification Verification
+	if err == nil {
+		err = json.Unmarshal([]byte(stripCodeFence(reply)), &verification)
+	}
+	if err != nil {
+		logger.Error("Failed to verify answer", "user_id", state.UserID, "error", err)
+		return nil
+	}
+
+	state.Verification = &verification
+	return nil
+}
+
+// promptContext puts any structured facts ahead of the document text
+func promptContext(state *PipelineState) string {
+	if state.Facts == "" {
+		return state.Context
+	}
+	return fmt.Sprintf("\n[Structured data]:\n%s%s", state.Facts, state.Context)
+}
+
+// stripCodeFence removes a markdown code fence the model may wrap JSON replies in
+func stripCodeFence(reply string) string {
+	reply = strings.TrimSpace(reply)
+	if !strings.HasPrefix(reply, "```") {
+		return reply
+	}
+	reply = strings.TrimPrefix(reply, "```")
+	reply = strings.TrimPrefix(reply, "json")
+	return strings.TrimSpace(strings.TrimSuffix(reply, "```"))
+}
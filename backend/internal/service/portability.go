@@ -0,0 +1,749 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Account export format. The version is MAJOR.MINOR: minor versions only add optional fields,
+// which older importers ignore, while a new major version may change or remove them.
+const (
+	AccountExportFormat  = "personal-rag-agent/account-export"
+	AccountExportVersion = "1.0"
+	accountExportMajor   = 1
+	// minImportMajor is the oldest major version imports accept. When the major version is
+	// bumped it stays one behind, and exports of the older version are upgraded on import.
+	minImportMajor = 1
+)
+
+// AccountExportSchema is the JSON Schema documenting the current export format
+//
+//go:embed portability/account-export.v1.schema.json
+var AccountExportSchema []byte
+
+// accountExportVersionPattern matches a MAJOR.MINOR version
+var accountExportVersionPattern = regexp.MustCompile(`^(\d+)\.(\d+)$`)
+
+// fileHashPattern matches a hex SHA-256
+var fileHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// exportedChunkFields are the chunk payload fields stored on the document or chunk itself
+// rather than exported as chunk metadata
+var exportedChunkFields = map[string]bool{
+	"content": true, "document_id": true, "user_id": true, "filename": true, "file_type": true,
+	"chunk_index": true, "page": true, "simhash": true,
+}
+
+// AccountExport is a user's account data in the documented, versioned export format (see
+// AccountExportSchema). IDs are only meaningful within the export; imports assign new ones.
+type AccountExport struct {
+	Format        string                 `json:"format"`
+	Version       string                 `json:"version"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	User          ExportedUser           `json:"user"`
+	Documents     []ExportedDocument     `json:"documents"`
+	Conversations []ExportedConversation `json:"conversations"`
+	// History holds the questions asked outside conversations, oldest first
+	History []ExportedMessage `json:"history"`
+}
+
+// ExportedUser is the account's profile and settings
+type ExportedUser struct {
+	Email     string           `json:"email"`
+	CreatedAt time.Time        `json:"created_at"`
+	Settings  ExportedSettings `json:"settings"`
+}
+
+// ExportedSettings are the user's assistant preferences
+type ExportedSettings struct {
+	SystemPrompt string `json:"system_prompt"`
+	Language     string `json:"language"`
+	// EmbeddingModel is informational; imported chunks are embedded with the importing
+	// account's model
+	EmbeddingModel string `json:"embedding_model"`
+}
+
+// ExportedDocument is a document with its chunks and, when files are included, its file
+type ExportedDocument struct {
+	ID               string          `json:"id"`
+	Filename         string          `json:"filename"`
+	FileType         string          `json:"file_type"`
+	FileSize         int64           `json:"file_size"`
+	FileHash         string          `json:"file_hash"`
+	UploadDate       time.Time       `json:"upload_date"`
+	Pinned           bool            `json:"pinned"`
+	Favorite         bool            `json:"favorite"`
+	Summary          string          `json:"summary,omitempty"`
+	ChunkingStrategy string          `json:"chunking_strategy,omitempty"`
+	ChunkSize        int             `json:"chunk_size,omitempty"`
+	ChunkOverlap     int             `json:"chunk_overlap,omitempty"`
+	Chunks           []ExportedChunk `json:"chunks"`
+	// File is the original file, base64-encoded in JSON
+	File []byte `json:"file,omitempty"`
+}
+
+// ExportedChunk is a passage of a document as it was chunked
+type ExportedChunk struct {
+	Index    int                    `json:"index"`
+	Page     int                    `json:"page,omitempty"`
+	Content  string                 `json:"content"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ExportedConversation is a conversation with the messages of all its branches, oldest first
+type ExportedConversation struct {
+	ID              string            `json:"id"`
+	Title           string            `json:"title"`
+	Model           string            `json:"model,omitempty"`
+	Temperature     *float64          `json:"temperature,omitempty"`
+	Language        string            `json:"language,omitempty"`
+	Filters         map[string]string `json:"filters,omitempty"`
+	ActiveMessageID string            `json:"active_message_id,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	Messages        []ExportedMessage `json:"messages"`
+}
+
+// ExportedMessage is a question and its answer, in or out of a conversation
+type ExportedMessage struct {
+	ID        string                 `json:"id"`
+	ParentID  string                 `json:"parent_id,omitempty"`
+	Question  string                 `json:"question"`
+	Answer    string                 `json:"answer"`
+	Sources   map[string]interface{} `json:"sources,omitempty"`
+	Pinned    bool                   `json:"pinned"`
+	Favorite  bool                   `json:"favorite"`
+	Feedback  int                    `json:"feedback"`
+	Truncated bool                   `json:"truncated"`
+	ReadAt    *time.Time             `json:"read_at,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AccountImportResult counts what an import added to the account
+type AccountImportResult struct {
+	// Version is the format version of the imported export
+	Version   string `json:"version"`
+	Documents int    `json:"documents"`
+	// DocumentsSkipped counts documents the account already had (by file hash)
+	DocumentsSkipped int      `json:"documents_skipped"`
+	Chunks           int      `json:"chunks"`
+	Conversations    int      `json:"conversations"`
+	Messages         int      `json:"messages"`
+	Warnings         []string `json:"warnings,omitempty"`
+}
+
+// PortabilityService exports a user's account data in a documented format and imports it into
+// an account, on this server or another
+type PortabilityService struct {
+	documents        *DocumentService
+	documentRepo     *repository.DocumentRepository
+	chunkRepo        *repository.ChunkRepository
+	conversationRepo *repository.ConversationRepository
+	userRepo         *repository.UserRepository
+	settingsRepo     *repository.SettingsRepository
+}
+
+// NewPortabilityService creates a new portability service
+func NewPortabilityService(
+	documents *DocumentService,
+	documentRepo *repository.DocumentRepository,
+	chunkRepo *repository.ChunkRepository,
+	conversationRepo *repository.ConversationRepository,
+	userRepo *repository.UserRepository,
+	settingsRepo *repository.SettingsRepository,
+) *PortabilityService {
+	return &PortabilityService{
+		documents:        documents,
+		documentRepo:     documentRepo,
+		chunkRepo:        chunkRepo,
+		conversationRepo: conversationRepo,
+		userRepo:         userRepo,
+		settingsRepo:     settingsRepo,
+	}
+}
+
+// Export returns the user's account data; withFiles includes each document's original file
+func (s *PortabilityService) Export(ctx context.Context, userID string, withFiles bool) (*AccountExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.settingsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &AccountExport{
+		Format:     AccountExportFormat,
+		Version:    AccountExportVersion,
+		ExportedAt: time.Now().UTC(),
+		User: ExportedUser{
+			Email:     user.Email,
+			CreatedAt: user.CreatedAt,
+			Settings: ExportedSettings{
+				SystemPrompt:   settings.SystemPrompt,
+				Language:       settings.Language,
+				EmbeddingModel: settings.EmbeddingModel,
+			},
+		},
+		Documents:     []ExportedDocument{},
+		Conversations: []ExportedConversation{},
+		History:       []ExportedMessage{},
+	}
+
+	docs, err := s.documentRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		exported, err := s.exportDocument(ctx, doc, withFiles)
+		if err != nil {
+			return nil, err
+		}
+		if len(exported.Chunks) == 0 {
+			// Still being indexed, or indexed through a batch that hasn't completed
+			continue
+		}
+		export.Documents = append(export.Documents, *exported)
+	}
+
+	history, err := s.documentRepo.ListAllQueryHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	messages := make(map[string][]ExportedMessage)
+	for _, entry := range history {
+		if entry.ConversationID == "" {
+			export.History = append(export.History, exportMessage(entry))
+			continue
+		}
+		messages[entry.ConversationID] = append(messages[entry.ConversationID], exportMessage(entry))
+	}
+
+	for offset := 0; ; offset += 100 {
+		conversations, err := s.conversationRepo.ListByUserID(ctx, userID, 100, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, conv := range conversations {
+			exported := ExportedConversation{
+				ID:              conv.ID,
+				Title:           conv.Title,
+				Model:           conv.Model,
+				Temperature:     conv.Temperature,
+				Language:        conv.Language,
+				Filters:         conv.Filters,
+				ActiveMessageID: conv.ActiveMessageID,
+				CreatedAt:       conv.CreatedAt,
+				UpdatedAt:       conv.UpdatedAt,
+				Messages:        messages[conv.ID],
+			}
+			if exported.Messages == nil {
+				exported.Messages = []ExportedMessage{}
+			}
+			export.Conversations = append(export.Conversations, exported)
+		}
+		if len(conversations) < 100 {
+			break
+		}
+	}
+
+	return export, nil
+}
+
+// exportDocument returns a document with its stored chunks and, with withFiles, its file
+func (s *PortabilityService) exportDocument(ctx context.Context, doc *model.Document, withFiles bool) (*ExportedDocument, error) {
+	exported := &ExportedDocument{
+		ID:               doc.ID,
+		Filename:         doc.Filename,
+		FileType:         doc.FileType,
+		FileSize:         doc.FileSize,
+		FileHash:         doc.FileHash,
+		UploadDate:       doc.UploadDate,
+		Pinned:           doc.Pinned,
+		Favorite:         doc.Favorite,
+		Summary:          doc.Summary,
+		ChunkingStrategy: doc.ChunkingStrategy,
+		ChunkSize:        doc.ChunkSize,
+		ChunkOverlap:     doc.ChunkOverlap,
+		Chunks:           []ExportedChunk{},
+	}
+
+	chunks, err := s.chunkRepo.ListByDocumentID(ctx, doc.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i, chunk := range chunks {
+		content, _ := chunk.Payload["content"].(string)
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		exportedChunk := ExportedChunk{Index: i, Page: payloadInt(chunk.Payload["page"]), Content: content}
+		for key, value := range chunk.Payload {
+			if exportedChunkFields[key] {
+				continue
+			}
+			if exportedChunk.Metadata == nil {
+				exportedChunk.Metadata = make(map[string]interface{})
+			}
+			exportedChunk.Metadata[key] = value
+		}
+		exported.Chunks = append(exported.Chunks, exportedChunk)
+	}
+
+	if withFiles && doc.StoragePath != "" {
+		_, file, err := s.documents.OpenDocument(ctx, doc.UserID, doc.ID)
+		if err != nil {
+			logger.Warn("Failed to export document file", "document_id", doc.ID, "error", err)
+			return exported, nil
+		}
+		defer file.Close()
+		var buf bytes.Buffer
+		if _, err := buf.ReadFrom(file); err != nil {
+			return nil, fmt.Errorf("failed to read file of %s: %w", doc.Filename, err)
+		}
+		exported.File = buf.Bytes()
+	}
+
+	return exported, nil
+}
+
+// exportMessage converts a query history entry to an exported message
+func exportMessage(entry *model.QueryHistory) ExportedMessage {
+	return ExportedMessage{
+		ID:        entry.ID,
+		ParentID:  entry.ParentID,
+		Question:  entry.Question,
+		Answer:    entry.Answer,
+		Sources:   entry.Sources,
+		Pinned:    entry.Pinned,
+		Favorite:  entry.Favorite,
+		Feedback:  entry.Feedback,
+		Truncated: entry.Truncated,
+		ReadAt:    entry.ReadAt,
+		CreatedAt: entry.CreatedAt,
+	}
+}
+
+// payloadInt reads an integer payload field, which decodes from JSON as a float64
+func payloadInt(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// importedMetadata restores the payload types of chunk metadata decoded from an export, as
+// stored metadata is decoded: whole numbers as int64 and lists of strings as []string
+func importedMetadata(metadata map[string]interface{}) map[string]interface{} {
+	restored := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		switch v := value.(type) {
+		case float64:
+			if v == float64(int64(v)) {
+				restored[key] = int64(v)
+			} else {
+				restored[key] = v
+			}
+		case []interface{}:
+			values := make([]string, 0, len(v))
+			for _, item := range v {
+				if str, ok := item.(string); ok {
+					values = append(values, str)
+				}
+			}
+			restored[key] = values
+		default:
+			restored[key] = v
+		}
+	}
+	return restored
+}
+
+// Import adds an export's documents, conversations and history to the user's account and
+// applies its settings. Documents the account already has, by file hash, are skipped, so
+// importing the same export twice only duplicates conversations and history. Chunks are
+// embedded with the account's embedding model.
+func (s *PortabilityService) Import(ctx context.Context, userID string, export *AccountExport) (*AccountImportResult, error) {
+	if err := ValidateAccountExport(export); err != nil {
+		return nil, err
+	}
+	result := &AccountImportResult{Version: export.Version}
+
+	settings, err := s.settingsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.SystemPrompt = export.User.Settings.SystemPrompt
+	settings.Language = export.User.Settings.Language
+	if err := s.settingsRepo.Upsert(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	for _, exported := range export.Documents {
+		_, err := s.documentRepo.GetByHash(ctx, userID, exported.FileHash)
+		if err == nil {
+			result.DocumentsSkipped++
+			continue
+		}
+		if !errors.Is(err, apperror.ErrNotFound) {
+			return result, err
+		}
+		if err := s.documents.importDocument(ctx, userID, &exported); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("document %s was not imported: %v", exported.Filename, err))
+			continue
+		}
+		result.Documents++
+		result.Chunks += len(exported.Chunks)
+	}
+
+	for _, exported := range export.Conversations {
+		messages, err := s.importConversation(ctx, userID, &exported)
+		if err != nil {
+			return result, err
+		}
+		result.Conversations++
+		result.Messages += messages
+	}
+
+	for _, message := range export.History {
+		if _, err := s.documentRepo.ImportQueryHistory(ctx, userID, "", "", importedMessage(message)); err != nil {
+			return result, err
+		}
+		result.Messages++
+	}
+
+	return result, nil
+}
+
+// importConversation creates a conversation with its messages, keeping its branches, and
+// returns how many messages were imported
+func (s *PortabilityService) importConversation(ctx context.Context, userID string, exported *ExportedConversation) (int, error) {
+	conv := &model.Conversation{
+		UserID:      userID,
+		Title:       exported.Title,
+		Model:       exported.Model,
+		Temperature: exported.Temperature,
+		Language:    exported.Language,
+		Filters:     exported.Filters,
+		CreatedAt:   exported.CreatedAt,
+		UpdatedAt:   exported.UpdatedAt,
+	}
+	if err := s.conversationRepo.Import(ctx, conv); err != nil {
+		return 0, err
+	}
+
+	// Messages are imported oldest first, so each one's parent already has its new ID
+	ids := make(map[string]string, len(exported.Messages))
+	messages := make([]*model.QueryHistory, 0, len(exported.Messages))
+	for _, message := range exported.Messages {
+		imported := importedMessage(message)
+		id, err := s.documentRepo.ImportQueryHistory(ctx, userID, conv.ID, ids[message.ParentID], imported)
+		if err != nil {
+			return 0, err
+		}
+		ids[message.ID] = id
+		imported.ID, imported.ParentID = id, ids[message.ParentID]
+		messages = append(messages, imported)
+	}
+
+	if activeID := ids[exported.ActiveMessageID]; activeID != "" {
+		if err := s.conversationRepo.RestoreActiveMessage(ctx, conv.ID, activeID); err != nil {
+			return 0, err
+		}
+		conv.ActiveMessageID = activeID
+	}
+	if len(messages) > 0 {
+		s.documents.indexImportedConversation(ctx, userID, conv, branchDetail(conv, newMessageTree(messages)).Messages)
+	}
+
+	return len(exported.Messages), nil
+}
+
+// importedMessage converts an exported message to a query history entry
+func importedMessage(message ExportedMessage) *model.QueryHistory {
+	return &model.QueryHistory{
+		Question:  message.Question,
+		Answer:    message.Answer,
+		Sources:   message.Sources,
+		Pinned:    message.Pinned,
+		Favorite:  message.Favorite,
+		Feedback:  message.Feedback,
+		Truncated: message.Truncated,
+		ReadAt:    message.ReadAt,
+		CreatedAt: message.CreatedAt,
+	}
+}
+
+// importDocument stores an exported document with its file, when included, and embeds its
+// chunks as they were chunked
+func (s *DocumentService) importDocument(ctx context.Context, userID string, exported *ExportedDocument) error {
+	chunks := make([]model.DocumentChunk, len(exported.Chunks))
+	for i, chunk := range exported.Chunks {
+		chunks[i] = model.DocumentChunk{
+			Content:    chunk.Content,
+			Page:       chunk.Page,
+			ChunkIndex: i,
+			Metadata:   importedMetadata(chunk.Metadata),
+		}
+	}
+
+	provider, err := s.embeddings.ForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	embedCtx, tracker := withUsageTracker(ctx)
+	embeddings, err := provider.GenerateEmbeddings(embedCtx, chunkContents(chunks), EmbeddingDocument)
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+
+	if !validExportFilename(exported.Filename) {
+		return fmt.Errorf("invalid filename %q", exported.Filename)
+	}
+	var storagePath string
+	if exported.File != nil {
+		storagePath = fmt.Sprintf("%s/%s/%s", userID, exported.FileHash, exported.Filename)
+		if err := s.storageDriver.UploadFile(ctx, storagePath, bytes.NewReader(exported.File)); err != nil {
+			return fmt.Errorf("failed to upload file: %w", err)
+		}
+	}
+
+	doc := &model.Document{
+		UserID:           userID,
+		Filename:         exported.Filename,
+		FileType:         exported.FileType,
+		FileSize:         exported.FileSize,
+		FileHash:         exported.FileHash,
+		StoragePath:      storagePath,
+		TotalChunks:      len(chunks),
+		ChunkingStrategy: exported.ChunkingStrategy,
+		ChunkSize:        exported.ChunkSize,
+		ChunkOverlap:     exported.ChunkOverlap,
+	}
+	if err := s.documentRepo.Create(ctx, doc); err != nil {
+		// A duplicate's storage path is that of the document already holding the content
+		if !errors.Is(err, repository.ErrDuplicateDocument) {
+			s.discardFile(ctx, storagePath)
+		}
+		return fmt.Errorf("failed to create document record: %w", err)
+	}
+	doc.UploadDate = exported.UploadDate
+	doc.Pinned = exported.Pinned
+	doc.Favorite = exported.Favorite
+	doc.Summary = exported.Summary
+	if err := s.documentRepo.RestoreDocument(ctx, doc); err != nil {
+		s.discardDocument(ctx, doc)
+		return err
+	}
+	recordEmbeddingUsage(ctx, s.documentRepo, tracker, model.EmbeddingUsage{
+		UserID:     userID,
+		DocumentID: doc.ID,
+		Filename:   doc.Filename,
+		Source:     EmbeddingSourceImport,
+		Model:      provider.Model(),
+	})
+
+	if err := s.vectorRepo.EnsureCollection(ctx, userID, uint64(provider.Dimensions()), sparseVectorConfig(s.sparseEncoder)); err != nil {
+		s.discardDocument(ctx, doc)
+		return fmt.Errorf("failed to ensure collection: %w", err)
+	}
+
+	points := make([]*model.VectorPoint, len(embeddings))
+	for i, embedding := range embeddings {
+		points[i] = &model.VectorPoint{
+			ID:     chunkPointID(doc.ID, i),
+			Vector: embedding,
+			Payload: chunkPayload(chunks[i], map[string]interface{}{
+				"document_id": doc.ID,
+				"user_id":     userID,
+				"filename":    doc.Filename,
+				"file_type":   doc.FileType,
+			}),
+		}
+	}
+	if err := attachSparseVectors(ctx, s.sparseEncoder, points); err != nil {
+		logger.Warn("Failed to generate sparse vectors", "document_id", doc.ID, "error", err)
+	}
+	if err := s.vectorRepo.InsertVectors(ctx, userID, points); err != nil {
+		s.discardDocument(ctx, doc)
+		return fmt.Errorf("failed to insert vectors: %w", err)
+	}
+	if err := s.chunkRepo.InsertChunks(ctx, userID, points); err != nil {
+		logger.Error("Failed to store chunk text", "document_id", doc.ID, "error", err)
+	}
+
+	return nil
+}
+
+// indexImportedConversation indexes an imported conversation for search by the messages of the
+// branch it shows, as conversations are indexed after each exchange. Failures are only logged.
+func (s *DocumentService) indexImportedConversation(ctx context.Context, userID string, conv *model.Conversation, messages []*model.QueryHistory) {
+	embedding, err := s.embeddings.Embed(ctx, userID, conversationSearchText(conv.Title, messages), EmbeddingDocument)
+	if err != nil {
+		logger.Warn("Failed to embed imported conversation", "conversation_id", conv.ID, "error", err)
+		return
+	}
+
+	if err := s.vectorRepo.IndexConversation(ctx, userID, &model.VectorPoint{
+		ID:     conv.ID,
+		Vector: embedding,
+		Payload: map[string]interface{}{
+			"conversation_id": conv.ID,
+			"title":           conv.Title,
+		},
+	}); err != nil {
+		logger.Warn("Failed to index imported conversation", "conversation_id", conv.ID, "error", err)
+	}
+}
+
+// validExportFilename reports whether an exported document's filename is a plain file name. It
+// becomes part of the file's storage key, so a path could write outside the user's files.
+func validExportFilename(name string) bool {
+	return filepath.Base(name) == name && !strings.ContainsAny(name, `/\`) && !strings.Contains(name, "..")
+}
+
+// ValidateAccountExport checks an export against the documented schema: its format and a
+// version imports accept, the fields the schema requires, and that message and branch
+// references stay within their conversation
+func ValidateAccountExport(export *AccountExport) error {
+	if export.Format != AccountExportFormat {
+		return fmt.Errorf("format must be %q", AccountExportFormat)
+	}
+	match := accountExportVersionPattern.FindStringSubmatch(export.Version)
+	if match == nil {
+		return fmt.Errorf("version must be MAJOR.MINOR, got %q", export.Version)
+	}
+	major, _ := strconv.Atoi(match[1])
+	if major < minImportMajor || major > accountExportMajor {
+		return fmt.Errorf("version %s is not supported; imports accept versions %d.x to %d.x", export.Version, minImportMajor, accountExportMajor)
+	}
+	if export.ExportedAt.IsZero() {
+		return fmt.Errorf("exported_at is required")
+	}
+	if strings.TrimSpace(export.User.Email) == "" {
+		return fmt.Errorf("user.email is required")
+	}
+	if export.User.CreatedAt.IsZero() {
+		return fmt.Errorf("user.created_at is required")
+	}
+	// Lists decode as nil only when they are missing; empty ones are exported as []
+	switch {
+	case export.Documents == nil:
+		return fmt.Errorf("documents is required")
+	case export.Conversations == nil:
+		return fmt.Errorf("conversations is required")
+	case export.History == nil:
+		return fmt.Errorf("history is required")
+	}
+
+	for i, doc := range export.Documents {
+		path := fmt.Sprintf("documents[%d]", i)
+		switch {
+		case doc.ID == "":
+			return fmt.Errorf("%s.id is required", path)
+		case strings.TrimSpace(doc.Filename) == "" || len(doc.Filename) > 255:
+			return fmt.Errorf("%s.filename must be 1 to 255 characters", path)
+		case !validExportFilename(doc.Filename):
+			return fmt.Errorf("%s.filename must be a file name without a path", path)
+		case !strings.HasPrefix(doc.FileType, "."):
+			return fmt.Errorf("%s.file_type must be a file extension", path)
+		case doc.FileSize < 0:
+			return fmt.Errorf("%s.file_size must not be negative", path)
+		case !fileHashPattern.MatchString(doc.FileHash):
+			return fmt.Errorf("%s.file_hash must be a hex SHA-256", path)
+		case doc.UploadDate.IsZero():
+			return fmt.Errorf("%s.upload_date is required", path)
+		case len(doc.Chunks) == 0:
+			return fmt.Errorf("%s.chunks must not be empty", path)
+		}
+		if doc.File != nil {
+			sum := sha256.Sum256(doc.File)
+			if hex.EncodeToString(sum[:]) != doc.FileHash {
+				return fmt.Errorf("%s.file does not match file_hash", path)
+			}
+		}
+		for j, chunk := range doc.Chunks {
+			if strings.TrimSpace(chunk.Content) == "" {
+				return fmt.Errorf("%s.chunks[%d].content is required", path, j)
+			}
+		}
+	}
+
+	for i, conv := range export.Conversations {
+		path := fmt.Sprintf("conversations[%d]", i)
+		switch {
+		case conv.ID == "":
+			return fmt.Errorf("%s.id is required", path)
+		case conv.CreatedAt.IsZero():
+			return fmt.Errorf("%s.created_at is required", path)
+		case conv.UpdatedAt.IsZero():
+			return fmt.Errorf("%s.updated_at is required", path)
+		case conv.Messages == nil:
+			return fmt.Errorf("%s.messages is required", path)
+		}
+		if conv.Temperature != nil && (*conv.Temperature < 0 || *conv.Temperature > 2) {
+			return fmt.Errorf("%s.temperature must be between 0 and 2", path)
+		}
+		// A parent must come before its message, so messages are imported in order
+		seen := make(map[string]bool, len(conv.Messages))
+		for j, message := range conv.Messages {
+			if err := validateExportedMessage(message, fmt.Sprintf("%s.messages[%d]", path, j)); err != nil {
+				return err
+			}
+			if seen[message.ID] {
+				return fmt.Errorf("%s.messages[%d].id is repeated", path, j)
+			}
+			if message.ParentID != "" && !seen[message.ParentID] {
+				return fmt.Errorf("%s.messages[%d].parent_id must be an earlier message of the conversation", path, j)
+			}
+			seen[message.ID] = true
+		}
+		if conv.ActiveMessageID != "" && !seen[conv.ActiveMessageID] {
+			return fmt.Errorf("%s.active_message_id must be a message of the conversation", path)
+		}
+	}
+
+	for i, message := range export.History {
+		if err := validateExportedMessage(message, fmt.Sprintf("history[%d]", i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateExportedMessage checks the fields the schema requires of a message
+func validateExportedMessage(message ExportedMessage, path string) error {
+	switch {
+	case message.ID == "":
+		return fmt.Errorf("%s.id is required", path)
+	case strings.TrimSpace(message.Question) == "":
+		return fmt.Errorf("%s.question is required", path)
+	case message.Feedback < -1 || message.Feedback > 1:
+		return fmt.Errorf("%s.feedback must be -1, 0 or 1", path)
+	case message.CreatedAt.IsZero():
+		return fmt.Errorf("%s.created_at is required", path)
+	}
+	return nil
+}
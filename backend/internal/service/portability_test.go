@@ -0,0 +1,469 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/storage"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger("test")
+	os.Exit(m.Run())
+}
+
+// Columns the repositories scan, in order
+var (
+	mockUserColumns     = []string{"id", "email", "password_hash", "is_admin", "created_at", "updated_at", "email_verified_at"}
+	mockSettingsColumns = []string{"system_prompt", "language", "embedding_model", "active_embedding_model", "updated_at"}
+	mockDocumentColumns = []string{"id", "user_id", "filename", "file_type", "file_size", "file_hash", "storage_path", "total_chunks",
+		"chunking_strategy", "chunk_size", "chunk_overlap", "pinned", "favorite", "canary", "summary", "upload_date"}
+	mockChunkColumns        = []string{"id", "content", "metadata"}
+	mockQueryHistoryColumns = []string{"id", "user_id", "conversation_id", "question", "answer", "sources", "pinned", "favorite",
+		"embedding_tokens", "prompt_tokens", "completion_tokens", "cost_usd", "feedback", "created_at", "parent_id", "truncated", "read_at"}
+	mockConversationColumns = []string{"id", "user_id", "title", "model", "temperature", "language", "filters", "created_at", "updated_at",
+		"active_message_id"}
+)
+
+// testRunbook is the file of the document exportTestAccount exports
+const testRunbook = "# Runbook\n\nRotate the API keys on the first of the month.\n"
+
+var (
+	testCreatedAt = time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	testAskedAt   = time.Date(2026, 2, 3, 14, 0, 0, 0, time.UTC)
+)
+
+// newMockDB returns a database whose statements are answered by the returned mock
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+// newTestPortabilityService builds a portability service on a mock database. Embeddings aren't
+// configured, so only documents the importing account already has can be imported.
+func newTestPortabilityService(db *sql.DB, store storage.StorageDriver) *PortabilityService {
+	documentRepo := repository.NewDocumentRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db)
+	documents := &DocumentService{
+		documentRepo:  documentRepo,
+		storageDriver: store,
+		embeddings:    &EmbeddingProviders{settingsRepo: settingsRepo},
+	}
+	return NewPortabilityService(documents, documentRepo, repository.NewChunkRepository(db),
+		repository.NewConversationRepository(db), repository.NewUserRepository(db), settingsRepo)
+}
+
+// compileAccountExportSchema compiles the embedded schema, asserting formats and encodings
+func compileAccountExportSchema(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	compiler.AssertContent = true
+	if err := compiler.AddResource("account-export.v1.schema.json", bytes.NewReader(AccountExportSchema)); err != nil {
+		t.Fatalf("failed to load schema: %v", err)
+	}
+	schema, err := compiler.Compile("account-export.v1.schema.json")
+	if err != nil {
+		t.Fatalf("failed to compile schema: %v", err)
+	}
+	return schema
+}
+
+// validateJSON validates an encoded export against the schema
+func validateJSON(t *testing.T, schema *jsonschema.Schema, data []byte) error {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		t.Fatalf("failed to decode export: %v", err)
+	}
+	return schema.Validate(doc)
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func minutesAfter(t time.Time, minutes int) time.Time {
+	return t.Add(time.Duration(minutes) * time.Minute)
+}
+
+// exportTestAccount runs Export, with files, on an account with a document, a conversation that
+// branches and a question asked outside it
+func exportTestAccount(t *testing.T) *AccountExport {
+	t.Helper()
+	db, mock := newMockDB(t)
+	store, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256Hex(testRunbook)
+	path := "user-1/" + hash + "/runbook.md"
+	if err := store.UploadFile(context.Background(), path, strings.NewReader(testRunbook)); err != nil {
+		t.Fatal(err)
+	}
+	document := []driver.Value{"doc-1", "user-1", "runbook.md", ".md", len(testRunbook), hash, path, 1,
+		"markdown", 512, 64, true, false, false, "How API keys are rotated.", testCreatedAt}
+
+	mock.ExpectQuery(`FROM users WHERE id`).WithArgs("user-1").WillReturnRows(
+		sqlmock.NewRows(mockUserColumns).AddRow("user-1", "ada@example.com", "hash", false, testCreatedAt, testCreatedAt, nil))
+	mock.ExpectQuery(`FROM user_settings`).WithArgs("user-1").WillReturnRows(
+		sqlmock.NewRows(mockSettingsColumns).AddRow("Answer briefly.", "en", "", "", testCreatedAt))
+	mock.ExpectQuery(`FROM documents\s+WHERE user_id`).WithArgs("user-1").WillReturnRows(
+		sqlmock.NewRows(mockDocumentColumns).AddRow(document...))
+	mock.ExpectQuery(`FROM document_chunks`).WithArgs("doc-1").WillReturnRows(
+		sqlmock.NewRows(mockChunkColumns).AddRow("chunk-1", "Rotate the API keys on the first of the month.",
+			`{"chunk_index": 0, "page": 1, "section": "Runbook", "keywords": ["keys", "rotation"], "document_id": "doc-1",
+			"user_id": "user-1", "filename": "runbook.md", "file_type": ".md", "simhash": 12345}`))
+	mock.ExpectQuery(`FROM documents WHERE id`).WithArgs("doc-1").WillReturnRows(
+		sqlmock.NewRows(mockDocumentColumns).AddRow(document...))
+	mock.ExpectQuery(`FROM query_history`).WithArgs("user-1").WillReturnRows(
+		sqlmock.NewRows(mockQueryHistoryColumns).
+			AddRow("h-1", "user-1", "", "What is the VPN address?", "vpn.example.com", nil, false, false,
+				10, 100, 20, 0.001, 0, testAskedAt, "", false, minutesAfter(testAskedAt, 1)).
+			AddRow("m-1", "user-1", "conv-1", "How often are keys rotated?", "Monthly, on the first.",
+				`{"documents": ["runbook.md"]}`, true, false, 10, 100, 20, 0.001, 1, minutesAfter(testAskedAt, 5), "", false, nil).
+			AddRow("m-2", "user-1", "conv-1", "Who rotates them?", "The on-call engineer.", nil, false, true,
+				10, 100, 20, 0.001, 0, minutesAfter(testAskedAt, 6), "m-1", false, nil).
+			// The follow-up edited, branching from the first message
+			AddRow("m-3", "user-1", "conv-1", "Who rotates the keys?", "Whoever is on call.", nil, false, false,
+				10, 100, 20, 0.001, -1, minutesAfter(testAskedAt, 7), "m-1", true, nil))
+	mock.ExpectQuery(`FROM conversations`).WithArgs("user-1", 100, 0).WillReturnRows(
+		sqlmock.NewRows(mockConversationColumns).AddRow("conv-1", "user-1", "Key rotation", "gpt-4o-mini", 0.3, "en",
+			`{"file_type": ".md"}`, minutesAfter(testAskedAt, 5), minutesAfter(testAskedAt, 7), "m-3"))
+
+	export, err := newTestPortabilityService(db, store).Export(context.Background(), "user-1", true)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	return export
+}
+
+func TestExportMatchesSchema(t *testing.T) {
+	export := exportTestAccount(t)
+
+	data, err := json.Marshal(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateJSON(t, compileAccountExportSchema(t), data); err != nil {
+		t.Fatalf("export doesn't match the schema: %v", err)
+	}
+	if err := ValidateAccountExport(export); err != nil {
+		t.Fatalf("export doesn't pass validation: %v", err)
+	}
+
+	if len(export.Documents) != 1 || string(export.Documents[0].File) != testRunbook {
+		t.Fatalf("expected the document with its file, got %+v", export.Documents)
+	}
+	chunk := export.Documents[0].Chunks[0]
+	wantMetadata := map[string]interface{}{"section": "Runbook", "keywords": []string{"keys", "rotation"}}
+	if chunk.Page != 1 || !reflect.DeepEqual(chunk.Metadata, wantMetadata) {
+		t.Errorf("expected page 1 and metadata %v, got page %d and %v", wantMetadata, chunk.Page, chunk.Metadata)
+	}
+	if len(export.Conversations) != 1 || len(export.Conversations[0].Messages) != 3 || len(export.History) != 1 {
+		t.Fatalf("expected a conversation of 3 messages and 1 question outside it, got %+v", export)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	data, err := json.Marshal(exportTestAccount(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export AccountExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatal(err)
+	}
+
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(`FROM user_settings`).WithArgs("user-2").WillReturnRows(
+		sqlmock.NewRows(mockSettingsColumns).AddRow("", "", "text-embedding-3-large", "text-embedding-3-large", testCreatedAt))
+	// Settings are imported, except the embedding model, which stays the account's own
+	mock.ExpectQuery(`INSERT INTO user_settings`).WithArgs("user-2", "Answer briefly.", "en", "text-embedding-3-large").
+		WillReturnRows(sqlmock.NewRows([]string{"active_embedding_model", "updated_at"}).AddRow("text-embedding-3-large", testAskedAt))
+	// The account already has the document, by hash
+	mock.ExpectQuery(`FROM documents WHERE user_id = \$1 AND file_hash`).WithArgs("user-2", sha256Hex(testRunbook)).
+		WillReturnRows(sqlmock.NewRows(mockDocumentColumns).AddRow("doc-9", "user-2", "runbook.md", ".md", len(testRunbook),
+			sha256Hex(testRunbook), "", 1, "", 0, 0, false, false, false, "", testAskedAt))
+	mock.ExpectQuery(`INSERT INTO conversations`).
+		WithArgs("user-2", "Key rotation", "gpt-4o-mini", 0.3, "en", []byte(`{"file_type":".md"}`),
+			minutesAfter(testAskedAt, 5), minutesAfter(testAskedAt, 7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("conv-new"))
+	// Messages keep their branches, with parents referring to the new IDs
+	mock.ExpectQuery(`INSERT INTO query_history`).
+		WithArgs("user-2", "conv-new", "", "How often are keys rotated?", "Monthly, on the first.",
+			[]byte(`{"documents":["runbook.md"]}`), true, false, 1, false, nil, minutesAfter(testAskedAt, 5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("new-1"))
+	mock.ExpectQuery(`INSERT INTO query_history`).
+		WithArgs("user-2", "conv-new", "new-1", "Who rotates them?", "The on-call engineer.",
+			[]byte("null"), false, true, 0, false, nil, minutesAfter(testAskedAt, 6)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("new-2"))
+	mock.ExpectQuery(`INSERT INTO query_history`).
+		WithArgs("user-2", "conv-new", "new-1", "Who rotates the keys?", "Whoever is on call.",
+			[]byte("null"), false, false, -1, true, nil, minutesAfter(testAskedAt, 7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("new-3"))
+	mock.ExpectExec(`UPDATE conversations SET active_message_id`).WithArgs("new-3", "conv-new").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Indexing the conversation for search needs embeddings, and is skipped when it fails
+	mock.ExpectQuery(`FROM user_settings`).WithArgs("user-2").WillReturnError(errors.New("embeddings unavailable"))
+	mock.ExpectQuery(`INSERT INTO query_history`).
+		WithArgs("user-2", "", "", "What is the VPN address?", "vpn.example.com",
+			[]byte("null"), false, false, 0, false, minutesAfter(testAskedAt, 1), testAskedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("new-4"))
+
+	result, err := newTestPortabilityService(db, nil).Import(context.Background(), "user-2", &export)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	want := &AccountImportResult{Version: AccountExportVersion, DocumentsSkipped: 1, Conversations: 1, Messages: 4}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("expected %+v, got %+v", want, result)
+	}
+}
+
+func TestImportNewerMinorVersion(t *testing.T) {
+	data, err := os.ReadFile("testdata/account-export-v1.3.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Minor versions only add optional properties, which the schema allows
+	if err := validateJSON(t, compileAccountExportSchema(t), data); err != nil {
+		t.Fatalf("1.3 export doesn't match the 1.0 schema: %v", err)
+	}
+	var export AccountExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatal(err)
+	}
+
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(`FROM user_settings`).WithArgs("user-3").WillReturnRows(
+		sqlmock.NewRows(mockSettingsColumns).AddRow("", "", "", "", testCreatedAt))
+	mock.ExpectQuery(`INSERT INTO user_settings`).WithArgs("user-3", "You are a careful research assistant.", "fr", "").
+		WillReturnRows(sqlmock.NewRows([]string{"active_embedding_model", "updated_at"}).AddRow("", testAskedAt))
+	mock.ExpectQuery(`FROM documents WHERE user_id = \$1 AND file_hash`).WithArgs("user-3", export.Documents[0].FileHash).
+		WillReturnRows(sqlmock.NewRows(mockDocumentColumns).AddRow("doc-9", "user-3", "reading-list.txt", ".txt", 42,
+			export.Documents[0].FileHash, "", 1, "", 0, 0, false, false, false, "", testAskedAt))
+	mock.ExpectQuery(`INSERT INTO conversations`).WithArgs("user-3", "Transformers", "", nil, "", []byte(nil),
+		sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("conv-new"))
+	mock.ExpectQuery(`INSERT INTO query_history`).WithArgs("user-3", "conv-new", "", "What introduced the transformer?",
+		"The 2017 paper Attention Is All You Need.", []byte("null"), false, false, 1, false, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("new-1"))
+	mock.ExpectExec(`UPDATE conversations SET active_message_id`).WithArgs("new-1", "conv-new").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`FROM user_settings`).WithArgs("user-3").WillReturnError(errors.New("embeddings unavailable"))
+
+	result, err := newTestPortabilityService(db, nil).Import(context.Background(), "user-3", &export)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	want := &AccountImportResult{Version: "1.3", DocumentsSkipped: 1, Conversations: 1, Messages: 1}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("expected %+v, got %+v", want, result)
+	}
+}
+
+// zeroValueRequired are required fields whose zero value is valid, so a decoded export can't
+// tell them from missing ones
+var zeroValueRequired = map[string]bool{
+	"user.settings":      true,
+	"document.file_size": true,
+	"chunk.index":        true,
+	"conversation.title": true,
+	"message.answer":     true,
+}
+
+// TestValidatorMatchesSchemaRequiredFields removes each property of an export in turn: the
+// validator must reject the export when the schema requires the property, and accept it when
+// the property is optional
+func TestValidatorMatchesSchemaRequiredFields(t *testing.T) {
+	schema := compileAccountExportSchema(t)
+	type definition struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	var root struct {
+		definition
+		Defs map[string]definition `json:"$defs"`
+	}
+	if err := json.Unmarshal(AccountExportSchema, &root); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(exportTestAccount(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Where an object of each definition is in the export
+	locations := []struct{ def, path string }{
+		{"", ""},
+		{"user", "user"},
+		{"document", "documents.0"},
+		{"chunk", "documents.0.chunks.0"},
+		{"conversation", "conversations.0"},
+		{"message", "conversations.0.messages.1"},
+		{"message", "history.0"},
+	}
+	for _, location := range locations {
+		def := root.definition
+		if location.def != "" {
+			def = root.Defs[location.def]
+		}
+		required := make(map[string]bool, len(def.Required))
+		for _, name := range def.Required {
+			required[name] = true
+		}
+		names := make([]string, 0, len(def.Properties))
+		for name := range def.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			field := name
+			if location.def != "" {
+				field = location.def + "." + name
+			}
+			t.Run(location.path+"/"+name, func(t *testing.T) {
+				var doc map[string]interface{}
+				if err := json.Unmarshal(data, &doc); err != nil {
+					t.Fatal(err)
+				}
+				object := lookupJSON(t, doc, location.path)
+				if _, ok := object[name]; !ok {
+					t.Skip("not in the export")
+				}
+				delete(object, name)
+				mutated, err := json.Marshal(doc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				schemaErr := validateJSON(t, schema, mutated)
+				var export AccountExport
+				if err := json.Unmarshal(mutated, &export); err != nil {
+					t.Fatal(err)
+				}
+				validatorErr := ValidateAccountExport(&export)
+
+				switch {
+				case !required[name]:
+					if schemaErr != nil || validatorErr != nil {
+						t.Errorf("optional %s: schema error %v, validator error %v", field, schemaErr, validatorErr)
+					}
+				case schemaErr == nil:
+					t.Errorf("schema doesn't require %s", field)
+				case validatorErr == nil && !zeroValueRequired[field]:
+					t.Errorf("validator accepts an export without %s", field)
+				}
+			})
+		}
+	}
+}
+
+// lookupJSON returns the object at a dotted path of keys and indexes in a decoded document
+func lookupJSON(t *testing.T, doc map[string]interface{}, path string) map[string]interface{} {
+	t.Helper()
+	var current interface{} = doc
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			if index, err := strconv.Atoi(key); err == nil {
+				current = current.([]interface{})[index]
+			} else {
+				current = current.(map[string]interface{})[key]
+			}
+		}
+	}
+	object, ok := current.(map[string]interface{})
+	if !ok {
+		t.Fatalf("%s is not an object", path)
+	}
+	return object
+}
+
+func TestValidateAccountExportRejectsPathFilenames(t *testing.T) {
+	schema := compileAccountExportSchema(t)
+	export := exportTestAccount(t)
+
+	tests := []struct {
+		filename string
+		valid    bool
+	}{
+		{"runbook.md", true},
+		{".env.example", true},
+		{"notes.", true},
+		{"../../../../etc/cron.d/job", false},
+		{"reports/q1.pdf", false},
+		{`reports\q1.pdf`, false},
+		{"..", false},
+		{"notes..md", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			export.Documents[0].Filename = tt.filename
+			if err := ValidateAccountExport(export); (err == nil) != tt.valid {
+				t.Errorf("validator: expected valid %v, got %v", tt.valid, err)
+			}
+			data, err := json.Marshal(export)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := validateJSON(t, schema, data); (err == nil) != tt.valid {
+				t.Errorf("schema: expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestChunkMetadataSurvivesExportAndImport(t *testing.T) {
+	// Metadata as the chunk repository decodes it
+	stored := map[string]interface{}{
+		"section":  "Runbook",
+		"keywords": []string{"keys", "rotation"},
+		"line":     int64(12),
+		"score":    0.75,
+	}
+	data, err := json.Marshal(ExportedChunk{Content: "Rotate the API keys.", Metadata: stored})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunk ExportedChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		t.Fatal(err)
+	}
+	if got := importedMetadata(chunk.Metadata); !reflect.DeepEqual(got, stored) {
+		t.Errorf("expected %v, got %v", stored, got)
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// PrivacyService reports what personal data is stored for a user, which third parties it is
+// sent to under the server's configuration, and how to purge it
+type PrivacyService struct {
+	privacyRepo *repository.PrivacyRepository
+	vectorRepo  *repository.VectorRepository
+	cfg         *config.Config
+	pipeline    PipelineConfig
+}
+
+// NewPrivacyService creates a new privacy service
+func NewPrivacyService(privacyRepo *repository.PrivacyRepository, vectorRepo *repository.VectorRepository, cfg *config.Config, pipeline PipelineConfig) *PrivacyService {
+	return &PrivacyService{
+		privacyRepo: privacyRepo,
+		vectorRepo:  vectorRepo,
+		cfg:         cfg,
+		pipeline:    pipeline,
+	}
+}
+
+// PrivacyReport summarizes a user's stored data and where it goes
+type PrivacyReport struct {
+	GeneratedAt  time.Time              `json:"generated_at"`
+	Data         []*PrivacyDataCategory `json:"data"`
+	ThirdParties []*PrivacyThirdParty   `json:"third_parties"`
+}
+
+// PrivacyDataCategory describes one kind of stored data
+type PrivacyDataCategory struct {
+	Category    string   `json:"category"`
+	Description string   `json:"description"`
+	Count       int64    `json:"count"`
+	Bytes       int64    `json:"bytes,omitempty"`
+	StoredIn    []string `json:"stored_in"`
+	Purge       string   `json:"purge"`
+}
+
+// PrivacyThirdParty describes an external service that receives the user's data
+type PrivacyThirdParty struct {
+	Name    string `json:"name"`
+	Purpose string `json:"purpose"`
+	Data    string `json:"data"`
+	When    string `json:"when"`
+}
+
+// Report builds the user's privacy report. Services the server talks to on every upload or
+// question are only listed once the user has data that was sent to them.
+func (s *PrivacyService) Report(ctx context.Context, userID string) (*PrivacyReport, error) {
+	counts, err := s.privacyRepo.CountData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Qdrant being unreachable shouldn't hide the rest of the report
+	vectors, err := s.vectorRepo.CountUserVectors(ctx, userID)
+	if err != nil {
+		logger.Warn("Failed to count vectors for privacy report", "user_id", userID, "error", err)
+	}
+
+	report := &PrivacyReport{
+		GeneratedAt: time.Now().UTC(),
+		Data: []*PrivacyDataCategory{
+			{
+				Category:    "documents",
+				Description: "Uploaded and synced files with their names, sizes, summaries and extracted details",
+				Count:       int64(counts.Documents),
+				Bytes:       counts.DocumentBytes,
+				StoredIn:    []string{"PostgreSQL", s.fileStorage()},
+				Purge:       "DELETE /api/documents/:id deletes a document with its file, chunks and embeddings",
+			},
+			{
+				Category:    "chunks",
+				Description: "The text of each document, split into passages for retrieval and keyword search",
+				Count:       int64(counts.Chunks),
+				StoredIn:    []string{"PostgreSQL"},
+				Purge:       "Deleted with their document",
+			},
+			{
+				Category: "embeddings",
+				Description: "Vectors of document passages, summaries and conversations, with passage text as payload. " +
+					"The embedding cache holds vectors of document text keyed by a hash of the text, without the text itself.",
+				Count:    int64(vectors),
+				StoredIn: []string{"Qdrant", "PostgreSQL (embedding cache)"},
+				Purge: "Passage and summary vectors are deleted with their document. Conversation vectors are rebuilt " +
+					"when the embedding model changes; cached vectors are shared between users and kept.",
+			},
+			{
+				Category:    "history",
+				Description: "Questions, answers, cited sources and conversations, and the personal FAQ built from them",
+				Count:       int64(counts.QueryHistory + counts.Conversations + counts.FAQEntries),
+				StoredIn:    []string{"PostgreSQL"},
+				Purge: "DELETE /api/query/history/:id deletes a question, DELETE /api/conversations/:id a conversation " +
+					"with its messages and DELETE /api/faq/:id an FAQ entry",
+			},
+			{
+				Category:    "usage",
+				Description: "Token counts and costs of questions and of embedding documents",
+				Count:       int64(counts.EmbeddingUsage),
+				StoredIn:    []string{"PostgreSQL"},
+				Purge: "Question usage is deleted with the question; document embedding usage is kept for cost " +
+					"reporting until the account is deleted",
+			},
+		},
+		ThirdParties: s.thirdParties(counts.Documents > 0, counts.QueryHistory > 0),
+	}
+
+	return report, nil
+}
+
+// fileStorage names where uploaded files are kept
+func (s *PrivacyService) fileStorage() string {
+	switch s.cfg.StorageDriver {
+	case "local":
+		return "Server disk"
+	case "localstack":
+		return "S3-compatible storage on the server (LocalStack)"
+	default:
+		return "Amazon S3 (bucket " + s.cfg.AWSConfig.Bucket + ")"
+	}
+}
+
+// thirdParties lists the external services that receive the user's data
+func (s *PrivacyService) thirdParties(hasDocuments, hasHistory bool) []*PrivacyThirdParty {
+	parties := []*PrivacyThirdParty{}
+
+	// Gateways and self-hosted servers may not need a key
+	openAIEnabled := s.cfg.OpenAIKey != "" || hostname(s.cfg.OpenAIBaseURL) != "api.openai.com"
+	if openAIEnabled && (hasDocuments || hasHistory) {
+		purposes := []string{"answer generation", "document summaries", "conversation titles"}
+		for _, stage := range []struct{ slot, purpose string }{
+			{StageRewrite, "query rewriting"},
+			{StageRerank, "reranking"},
+			{StageVerify, "answer verification"},
+		} {
+			if s.pipeline[stage.slot] == "llm" {
+				purposes = append(purposes, stage.purpose)
+			}
+		}
+		parties = append(parties, &PrivacyThirdParty{
+			Name:    s.openAIName("chat completions"),
+			Purpose: strings.Join(purposes, ", "),
+			Data:    "Questions, earlier messages of the conversation and passages of retrieved documents",
+			When:    "Each question, and once per document for its summary",
+		})
+	}
+
+	embeddingNames := map[string]string{
+		"openai": s.openAIName("embeddings"),
+		"cohere": "Cohere",
+		"voyage": "Voyage AI",
+	}
+	if name, ok := embeddingNames[s.cfg.EmbeddingProvider]; ok && (hasDocuments || hasHistory) {
+		parties = append(parties, &PrivacyThirdParty{
+			Name:    name,
+			Purpose: "embeddings",
+			Data:    "Document text and questions",
+			When:    "Each upload, synced file and question",
+		})
+	}
+
+	if s.cfg.StorageDriver == "s3" && hasDocuments {
+		parties = append(parties, &PrivacyThirdParty{
+			Name:    "Amazon S3",
+			Purpose: "file storage",
+			Data:    "Uploaded files",
+			When:    "Each upload",
+		})
+	}
+
+	if s.cfg.TTSProvider == "openai" {
+		parties = append(parties, &PrivacyThirdParty{
+			Name:    s.openAIName("text-to-speech"),
+			Purpose: "reading answers aloud",
+			Data:    "Answers and documents played as audio",
+			When:    "Only when audio is requested",
+		})
+	}
+
+	if s.cfg.WebSearchAPIKey != "" {
+		parties = append(parties, &PrivacyThirdParty{
+			Name:    "Brave Search",
+			Purpose: "web search",
+			Data:    "Search queries written by the assistant from your questions",
+			When:    "Only when agent mode uses the web_search tool",
+		})
+	}
+
+	switch s.cfg.MailDriver {
+	case "smtp":
+		parties = append(parties, mailParty("SMTP server "+s.cfg.SMTPHost))
+	case "ses":
+		parties = append(parties, mailParty("Amazon SES"))
+	case "mailgun":
+		parties = append(parties, mailParty("Mailgun"))
+	}
+
+	if s.cfg.TelegramBotToken != "" {
+		parties = append(parties, notificationParty("Telegram", "If a Telegram chat is registered for notifications"))
+	}
+	if s.cfg.NtfyURL != "" {
+		parties = append(parties, notificationParty("ntfy ("+hostname(s.cfg.NtfyURL)+")", "If a push topic is registered for notifications"))
+	}
+	if s.cfg.MatrixHomeserverURL != "" {
+		parties = append(parties, &PrivacyThirdParty{
+			Name:    "Matrix homeserver (" + hostname(s.cfg.MatrixHomeserverURL) + ")",
+			Purpose: "chat bot",
+			Data:    "Questions, answers and files exchanged with the bot",
+			When:    "If a Matrix account is linked",
+		})
+	}
+	if s.cfg.DiscordBotToken != "" {
+		parties = append(parties, &PrivacyThirdParty{
+			Name:    "Discord",
+			Purpose: "chat bot",
+			Data:    "Questions, answers and files exchanged with the bot",
+			When:    "If a Discord account is linked",
+		})
+	}
+
+	return parties
+}
+
+// openAIName names the OpenAI-compatible API used for a purpose, which may be a gateway
+func (s *PrivacyService) openAIName(use string) string {
+	host := hostname(s.cfg.OpenAIBaseURL)
+	if host == "api.openai.com" {
+		return "OpenAI (" + use + ")"
+	}
+	return "OpenAI-compatible API at " + host + " (" + use + ")"
+}
+
+// mailParty describes an outbound email service
+func mailParty(name string) *PrivacyThirdParty {
+	return &PrivacyThirdParty{
+		Name:    name,
+		Purpose: "email",
+		Data:    "Email address, account emails and notifications",
+		When:    "For verification and password reset emails, and email notifications",
+	}
+}
+
+// notificationParty describes a push notification channel
+func notificationParty(name, when string) *PrivacyThirdParty {
+	return &PrivacyThirdParty{
+		Name:    name,
+		Purpose: "notifications",
+		Data:    "Notification titles and messages, which may quote questions and documents",
+		When:    when,
+	}
+}
+
+// hostname returns the host of a URL, or the URL itself if it can't be parsed
+func hostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
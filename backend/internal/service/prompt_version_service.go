@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// defaultPromptVersionName names the built-in prompt and configured pipeline in comparisons
+const defaultPromptVersionName = "default"
+
+// maxPromptVersionTopK caps the chunks a prompt version may give the model
+const maxPromptVersionTopK = 20
+
+// PromptVersionService manages prompt versions: system prompts and retrieval configs that a
+// share of queries is routed to, so feedback, latency and cost can be compared with the default
+// before a version is rolled out to every query
+type PromptVersionService struct {
+	promptVersionRepo *repository.PromptVersionRepository
+}
+
+// NewPromptVersionService creates a new prompt version service
+func NewPromptVersionService(promptVersionRepo *repository.PromptVersionRepository) *PromptVersionService {
+	return &PromptVersionService{promptVersionRepo: promptVersionRepo}
+}
+
+// PromptVersionInput represents a new prompt version. Versions can't be edited, so their
+// queries stay comparable; create a new version instead.
+type PromptVersionInput struct {
+	Name         string `json:"name"`
+	SystemPrompt string `json:"system_prompt"`
+	// Pipeline overrides the configured stage implementations (e.g. {"rerank": "llm"})
+	Pipeline       map[string]string `json:"pipeline"`
+	TopK           int               `json:"top_k"`
+	TrafficPercent int               `json:"traffic_percent"`
+}
+
+// PromptVersionComparison compares the queries answered by each prompt version since a time
+type PromptVersionComparison struct {
+	Since    time.Time                   `json:"since"`
+	Versions []*model.PromptVersionStats `json:"versions"`
+}
+
+// Create adds a prompt version, routing its traffic share of queries to it right away
+func (s *PromptVersionService) Create(ctx context.Context, input PromptVersionInput) (*model.PromptVersion, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(name) > 100 {
+		return nil, fmt.Errorf("name must be at most 100 characters")
+	}
+	if strings.TrimSpace(input.SystemPrompt) == "" {
+		return nil, fmt.Errorf("system prompt is required")
+	}
+	if err := PipelineConfig(input.Pipeline).validate(); err != nil {
+		return nil, err
+	}
+	if input.TopK < 0 || input.TopK > maxPromptVersionTopK {
+		return nil, fmt.Errorf("top_k must be between 0 and %d", maxPromptVersionTopK)
+	}
+	if err := s.checkTraffic(ctx, "", input.TrafficPercent); err != nil {
+		return nil, err
+	}
+
+	version := &model.PromptVersion{
+		Name:           name,
+		SystemPrompt:   strings.TrimSpace(input.SystemPrompt),
+		Pipeline:       input.Pipeline,
+		TopK:           input.TopK,
+		TrafficPercent: input.TrafficPercent,
+	}
+	if version.Pipeline == nil {
+		version.Pipeline = map[string]string{}
+	}
+	if err := s.promptVersionRepo.Create(ctx, version); err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+// List lists the prompt versions
+func (s *PromptVersionService) List(ctx context.Context) ([]*model.PromptVersion, error) {
+	return s.promptVersionRepo.List(ctx)
+}
+
+// SetTraffic changes the share of queries routed to a version. 100 rolls it out to every
+// eligible query, 0 stops routing to it.
+func (s *PromptVersionService) SetTraffic(ctx context.Context, id string, percent int) (*model.PromptVersion, error) {
+	if err := s.checkTraffic(ctx, id, percent); err != nil {
+		return nil, err
+	}
+	return s.promptVersionRepo.SetTraffic(ctx, id, percent)
+}
+
+// Delete removes a prompt version; its queries are left out of later comparisons
+func (s *PromptVersionService) Delete(ctx context.Context, id string) error {
+	return s.promptVersionRepo.Delete(ctx, id)
+}
+
+// Compare summarizes feedback, latency and cost of the queries each version answered in the
+// last days, starting with the default prompt
+func (s *PromptVersionService) Compare(ctx context.Context, days int) (*PromptVersionComparison, error) {
+	if days <= 0 || days > 365 {
+		days = 7
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	stats, err := s.promptVersionRepo.Stats(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, stat := range stats {
+		if stat.VersionID == "" {
+			stat.Name = defaultPromptVersionName
+		}
+	}
+
+	return &PromptVersionComparison{Since: since, Versions: stats}, nil
+}
+
+// checkTraffic validates a traffic share and that, with the other versions' shares, it doesn't
+// exceed every query. id is the version being changed, empty for a new one.
+func (s *PromptVersionService) checkTraffic(ctx context.Context, id string, percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("traffic_percent must be between 0 and 100")
+	}
+
+	versions, err := s.promptVersionRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	total := percent
+	for _, version := range versions {
+		if version.ID != id {
+			total += version.TrafficPercent
+		}
+	}
+	if total > 100 {
+		return fmt.Errorf("prompt versions would receive %d%% of queries; lower another version's traffic first", total)
+	}
+	return nil
+}
+
+// routePromptVersion picks the prompt version answering a query by the versions' traffic
+// shares, nil for the default prompt. Failures are logged and fall back to the default.
+func (s *RAGService) routePromptVersion(ctx context.Context) *model.PromptVersion {
+	versions, err := s.promptVersionRepo.List(ctx)
+	if err != nil {
+		logger.Error("Failed to load prompt versions", "error", err)
+		return nil
+	}
+	return pickPromptVersion(versions, rand.Intn(100))
+}
+
+// pickPromptVersion returns the version whose traffic share covers roll (0-99), laying the
+// shares out one after another
+func pickPromptVersion(versions []*model.PromptVersion, roll int) *model.PromptVersion {
+	for _, version := range versions {
+		if roll < version.TrafficPercent {
+			return version
+		}
+		roll -= version.TrafficPercent
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// Query embedding cache limits: pinned and repeated questions are embedded once per session
+const (
+	queryEmbeddingCacheTTL  = 30 * time.Minute
+	queryEmbeddingCacheSize = 2048
+)
+
+// queryEmbeddingCache holds recent question embeddings in memory, keyed by model and text, so a
+// question asked again, or embedded ahead of time when its user logs in, skips the embedding API
+type queryEmbeddingCache struct {
+	mu      sync.Mutex
+	entries map[string]queryEmbeddingEntry
+}
+
+// queryEmbeddingEntry is a cached embedding and when it expires
+type queryEmbeddingEntry struct {
+	embedding []float32
+	expires   time.Time
+}
+
+// newQueryEmbeddingCache creates an empty query embedding cache
+func newQueryEmbeddingCache() *queryEmbeddingCache {
+	return &queryEmbeddingCache{entries: make(map[string]queryEmbeddingEntry)}
+}
+
+// get returns the cached embedding of a text for the model, if it hasn't expired
+func (c *queryEmbeddingCache) get(model, text string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[model+"\x00"+text]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.embedding, true
+}
+
+// put caches the embedding of a text for the model. When the cache is full, expired entries are
+// dropped first and then the entries closest to expiring.
+func (c *queryEmbeddingCache) put(model, text string, embedding []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= queryEmbeddingCacheSize {
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	for len(c.entries) >= queryEmbeddingCacheSize {
+		var oldestKey string
+		var oldest time.Time
+		for key, entry := range c.entries {
+			if oldestKey == "" || entry.expires.Before(oldest) {
+				oldestKey, oldest = key, entry.expires
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+
+	c.entries[model+"\x00"+text] = queryEmbeddingEntry{embedding: embedding, expires: now.Add(queryEmbeddingCacheTTL)}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// Quick queries trade depth for latency: few chunks, a small model, a short answer and no
+// optional pipeline stages, tools or conversation
+const (
+	quickQueryModel     = "gpt-4o-mini"
+	quickQueryTopK      = 3
+	quickQueryMaxTokens = 150
+	maxQuickSources     = 3
+	// QuickQueryTimeout is the latency budget for a quick query, retrieval and answer included
+	QuickQueryTimeout = 8 * time.Second
+)
+
+// quickQuerySystemPrompt asks for an answer short enough for a launcher result
+const quickQuerySystemPrompt = `You answer questions from the user's documents for a quick-lookup launcher.
+
+Answer in one or two short sentences of plain text, with no markdown, lists or citations.
+Use only the provided context; if it doesn't contain the answer, say so in one sentence.`
+
+// QuickAnswer is a short plain-text answer with the titles of the documents it came from
+type QuickAnswer struct {
+	Answer  string   `json:"answer"`
+	Sources []string `json:"sources"`
+}
+
+// QuickQuery answers a question within QuickQueryTimeout for launcher integrations (Raycast,
+// Alfred). It's recorded in query history like any other query, without a conversation.
+func (s *RAGService) QuickQuery(ctx context.Context, userID, question string) (*QuickAnswer, error) {
+	question, exclude := parseExclusions(question)
+	if question == "" {
+		return nil, fmt.Errorf("question is required")
+	}
+	historyCtx, tracker := withUsageTracker(ctx)
+	ctx, cancel := context.WithTimeout(historyCtx, QuickQueryTimeout)
+	defer cancel()
+
+	settings, err := s.settingsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	opts := GenerationOptions{Model: quickQueryModel, Language: settings.Language, Persona: settings.SystemPrompt}
+
+	results, _, err := s.retrieve(ctx, userID, question, RetrievalOptions{
+		Filter: buildSearchFilter(nil, exclude, nil),
+		TopK:   quickQueryTopK,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.chatCompletion(ctx, ChatCompletionRequest{
+		Model: quickQueryModel,
+		Messages: []ChatMessage{
+			{Role: "system", Content: buildSystemPrompt(quickQuerySystemPrompt, opts)},
+			{Role: "user", Content: fmt.Sprintf("Context:\n%s\n\nQuestion: %s", buildContextText(results), question)},
+		},
+		MaxTokens: quickQueryMaxTokens,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	answer := strings.TrimSpace(message.Content)
+	sources := buildSources(results)
+	if _, err := s.documentRepo.SaveQueryHistory(historyCtx, userID, "", "", question, answer, map[string]interface{}{
+		"sources": sources,
+	}, tracker.Usage(), model.AnswerQuality{}, model.QueryRun{}); err != nil {
+		logger.Error("Failed to save query history", "user_id", userID, "error", err)
+	}
+
+	return &QuickAnswer{
+		Answer:  answer,
+		Sources: sourceTitles(sources, maxQuickSources),
+	}, nil
+}
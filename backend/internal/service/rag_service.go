@@ -5,185 +5,915 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"slices"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/profile"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
 )
 
 // RAGService handles RAG query operations
 type RAGService struct {
-	vectorRepo       *repository.VectorRepository
-	embeddingService *EmbeddingService
-	documentRepo     *repository.DocumentRepository
-	llmAPIKey        string
-	httpClient       *http.Client
+	vectorRepo        *repository.VectorRepository
+	chunkRepo         *repository.ChunkRepository
+	embeddings        *EmbeddingProviders
+	documentRepo      *repository.DocumentRepository
+	conversationRepo  *repository.ConversationRepository
+	settingsRepo      *repository.SettingsRepository
+	tools             *ToolRegistry
+	auditService      *AuditService
+	glossaryRepo      *repository.GlossaryRepository
+	faqRepo           *repository.FAQRepository
+	blocklistRepo     *repository.BlocklistRepository
+	promptVersionRepo *repository.PromptVersionRepository
+	sparseEncoder     SparseEncoder
+	pipeline          PipelineConfig
+	openAI            OpenAIEndpoint
+	httpClient        *httpretry.Client
 }
 
 // NewRAGService creates a new RAG service
 func NewRAGService(
 	vectorRepo *repository.VectorRepository,
-	embeddingService *EmbeddingService,
-	llmAPIKey string,
+	chunkRepo *repository.ChunkRepository,
+	embeddings *EmbeddingProviders,
+	openAI OpenAIEndpoint,
 	documentRepo *repository.DocumentRepository,
+	conversationRepo *repository.ConversationRepository,
+	settingsRepo *repository.SettingsRepository,
+	tools *ToolRegistry,
+	auditService *AuditService,
+	glossaryRepo *repository.GlossaryRepository,
+	faqRepo *repository.FAQRepository,
+	blocklistRepo *repository.BlocklistRepository,
+	promptVersionRepo *repository.PromptVersionRepository,
+	sparseEncoder SparseEncoder,
+	pipeline PipelineConfig,
 ) *RAGService {
 	return &RAGService{
-		vectorRepo:       vectorRepo,
-		embeddingService: embeddingService,
-		documentRepo:     documentRepo,
-		llmAPIKey:        llmAPIKey,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		vectorRepo:        vectorRepo,
+		chunkRepo:         chunkRepo,
+		embeddings:        embeddings,
+		documentRepo:      documentRepo,
+		conversationRepo:  conversationRepo,
+		settingsRepo:      settingsRepo,
+		tools:             tools,
+		auditService:      auditService,
+		glossaryRepo:      glossaryRepo,
+		faqRepo:           faqRepo,
+		blocklistRepo:     blocklistRepo,
+		promptVersionRepo: promptVersionRepo,
+		sparseEncoder:     sparseEncoder,
+		pipeline:          pipeline,
+		openAI:            openAI,
+		httpClient:        openAI.client(60 * time.Second),
 	}
 }
 
 // QueryRequest represents a RAG query request
 type QueryRequest struct {
 	Question string `json:"question"`
+	// Filters restricts retrieval to chunks whose metadata matches exactly (e.g. {"speaker": "Alex"})
+	Filters map[string]string `json:"filters,omitempty"`
+	// ConversationID continues an existing conversation; when empty a new one is started
+	ConversationID string `json:"conversation_id,omitempty"`
+	// Standalone records the query in history without attaching it to a conversation
+	Standalone bool `json:"-"`
+	// editOf is the conversation message an edited question replaces; the exchange starts a new
+	// branch from the message before it
+	editOf *model.QueryHistory
+	// Agent lets the model issue additional knowledge base searches before answering
+	Agent bool `json:"agent,omitempty"`
+	// MaxIterations caps the number of agent retrieval rounds (defaults to 4)
+	MaxIterations int `json:"max_iterations,omitempty"`
+	// Model and Temperature override the conversation's (or default) generation settings
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	// Diversity (0-1) trades relevance for variety among retrieved chunks using
+	// Maximal Marginal Relevance; 0 disables diversification
+	Diversity float64 `json:"diversity,omitempty"`
+	// Language makes the model answer in this language (e.g. "English", "Malay")
+	// regardless of the language of the retrieved documents
+	Language string `json:"language,omitempty"`
+	// ClauseType restricts retrieval to contract clauses of this type (e.g. "termination")
+	ClauseType string `json:"clause_type,omitempty"`
+	// Exclude omits chunks whose metadata matches any listed value (e.g. {"tag": ["archive"]});
+	// "-key:value" operators in the question are added to it
+	Exclude map[string][]string `json:"exclude,omitempty"`
+	// ExcludeDocumentIDs omits chunks of these documents
+	ExcludeDocumentIDs []string `json:"exclude_document_ids,omitempty"`
+	// Mode selects retrieval: "chunks" (default) searches all chunks; "documents" first picks
+	// candidate documents by summary similarity and then searches only their chunks
+	Mode string `json:"mode,omitempty"`
+	// Warranty restricts retrieval to documents whose warranty is "active" or "expired" today.
+	// Questions about warranty status also get the user's warranty list as structured data.
+	Warranty string `json:"warranty,omitempty"`
+	// Pipeline overrides the configured stage implementations for this query
+	// (e.g. {"rerank": "llm", "verify": "llm"})
+	Pipeline map[string]string `json:"pipeline,omitempty"`
+	// Explain adds an explanation of why each source was retrieved to the response
+	Explain bool `json:"explain,omitempty"`
+}
+
+// defaultChatModel is used when neither the request nor the conversation selects a model
+const defaultChatModel = "gpt-3.5-turbo"
+
+// GenerationOptions controls chat completion model and sampling
+type GenerationOptions struct {
+	Model       string
+	Temperature *float64
+	// Language is the answer language; empty lets the model follow the question
+	Language string
+	// Persona is the user's standing instruction, prepended to the system prompt
+	Persona string
 }
 
 // QueryResponse represents a RAG query response
 type QueryResponse struct {
-	Answer  string                   `json:"answer"`
-	Sources []map[string]interface{} `json:"sources"`
+	Answer         string                   `json:"answer"`
+	Sources        []map[string]interface{} `json:"sources"`
+	ConversationID string                   `json:"conversation_id,omitempty"`
+	// Steps traces each retrieval round when agent mode is used
+	Steps []AgentStep `json:"steps,omitempty"`
+	// Usage is the tokens consumed by embedding and chat calls for this query
+	Usage model.TokenUsage `json:"usage"`
+	// Degraded is set when the embedding provider failed and retrieval fell back to keyword search
+	Degraded bool `json:"degraded,omitempty"`
+	// Confidence estimates how well the answer is supported, so clients can flag weak answers
+	Confidence *Confidence `json:"confidence,omitempty"`
+	// Verification is the verify stage's check of the answer, when one is configured
+	Verification *Verification `json:"verification,omitempty"`
+	// FAQ is set when the answer was reused from the user's FAQ instead of generated
+	FAQ *FAQMatch `json:"faq,omitempty"`
+	// Explanations says why each source was retrieved, in source order, when explain was requested
+	Explanations []CitationExplanation `json:"explanations,omitempty"`
 }
 
 // ChatCompletionRequest represents an OpenAI chat completion request
 type ChatCompletionRequest struct {
 	Model    string        `json:"model"`
 	Messages []ChatMessage `json:"messages"`
+	Tools    []ChatTool    `json:"tools,omitempty"`
+	// Temperature is omitted to use the API default when nil
+	Temperature *float64 `json:"temperature,omitempty"`
+	// ToolChoice controls tool use ("auto", "none"); empty leaves the API default
+	ToolChoice string `json:"tool_choice,omitempty"`
+	// Logprobs asks for per-token log probabilities of the reply
+	Logprobs bool `json:"logprobs,omitempty"`
+	// MaxTokens caps the reply length; zero leaves the API default
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// Stream sends the reply as server-sent event deltas (see streamCompletion)
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions configures a streamed chat completion
+type StreamOptions struct {
+	// IncludeUsage adds a final event carrying the token usage
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // ChatMessage represents a chat message
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	// TokenLogprobs holds the reply's token log probabilities when requested; never sent to the API
+	TokenLogprobs []float64 `json:"-"`
 }
 
 // ChatCompletionResponse represents an OpenAI chat completion response
 type ChatCompletionResponse struct {
+	Model   string `json:"model"`
 	Choices []struct {
-		Message ChatMessage `json:"message"`
+		Message  ChatMessage `json:"message"`
+		Logprobs *struct {
+			Content []struct {
+				Logprob float64 `json:"logprob"`
+			} `json:"content"`
+		} `json:"logprobs"`
 	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
 }
 
 // Query performs a RAG query
-func (s *RAGService) Query(ctx context.Context, userID, question string) (*QueryResponse, error) {
-	// 1. Generate embedding for the question
-	questionEmbedding, err := s.embeddingService.GenerateEmbedding(ctx, question)
+func (s *RAGService) Query(ctx context.Context, userID string, req QueryRequest) (*QueryResponse, error) {
+	question, exclude := parseExclusions(req.Question)
+	if question == "" {
+		return nil, fmt.Errorf("question is required")
+	}
+	exclude = mergeExclusions(req.Exclude, exclude)
+	ctx, tracker := withUsageTracker(ctx)
+	start := time.Now()
+
+	// Resolve the conversation this query belongs to; its locked settings apply unless overridden
+	opts := GenerationOptions{Model: req.Model, Temperature: req.Temperature, Language: strings.TrimSpace(req.Language)}
+	filters := req.Filters
+
+	conversationID := req.ConversationID
+	var parentID string
+	if conversationID != "" {
+		conv, err := s.conversationRepo.GetByID(ctx, userID, conversationID)
+		if err != nil {
+			return nil, err
+		}
+		if parentID, err = s.branchParent(ctx, conv, req.editOf); err != nil {
+			return nil, err
+		}
+		if opts.Model == "" {
+			opts.Model = conv.Model
+		}
+		if opts.Temperature == nil {
+			opts.Temperature = conv.Temperature
+		}
+		if opts.Language == "" {
+			opts.Language = conv.Language
+		}
+		filters = mergeFilters(conv.Filters, req.Filters)
+	}
+
+	// The user's persona always applies; their default language is the final fallback
+	settings, err := s.settingsRepo.Get(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate question embedding: %w", err)
+		return nil, err
+	}
+	opts.Persona = settings.SystemPrompt
+	if opts.Language == "" {
+		opts.Language = settings.Language
+	}
+
+	if req.Diversity < 0 || req.Diversity > 1 {
+		return nil, fmt.Errorf("diversity must be between 0 and 1")
+	}
+
+	if err := validateQueryMode(req.Mode); err != nil {
+		return nil, err
 	}
 
-	// 2. Search for similar chunks
-	results, err := s.vectorRepo.Search(ctx, userID, questionEmbedding, 5)
+	filters, err = withClauseType(filters, req.ClauseType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search vectors: %w", err)
+		return nil, err
 	}
 
-	// 3. Build context from results
-	var contextChunks []string
-	var sources []map[string]interface{}
+	if err := validateWarrantyStatus(req.Warranty); err != nil {
+		return nil, err
+	}
 
-	for _, result := range results {
-		if content, ok := result.Payload["content"].(string); ok {
-			contextChunks = append(contextChunks, content)
+	pipeline, err := s.resolvePipeline(req.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		answer     string
+		sources    []map[string]interface{}
+		steps      []AgentStep
+		state      = &PipelineState{}
+		confidence *Confidence
+		quality    model.AnswerQuality
+		run        model.QueryRun
+
+		explanations []CitationExplanation
+	)
+
+	// A plain repeat of a question in the user's FAQ reuses its endorsed answer
+	var faq *FAQMatch
+	if faqEligible(req, filters, exclude) {
+		faq = s.answerFromFAQ(ctx, userID, question)
+	}
+
+	if faq != nil {
+		answer, sources = faq.answer, faq.sources
+	} else {
+		now := time.Now()
+		state = &PipelineState{
+			UserID:   userID,
+			Question: question,
+			Retrieval: RetrievalOptions{
+				Filter:        withWarrantyStatus(buildSearchFilter(filters, exclude, req.ExcludeDocumentIDs), req.Warranty, now),
+				Diversity:     req.Diversity,
+				DocumentFirst: req.Mode == QueryModeDocuments,
+			},
+			Generation: opts,
 		}
+		state.Facts = s.structuredFacts(ctx, userID, question, req.Warranty, now)
 
-		// Extract source metadata
-		source := map[string]interface{}{
-			"filename": result.Payload["filename"],
-			"page":     result.Payload["page"],
+		if req.Agent {
+			// The agent retrieves and answers itself; only verification runs as a stage
+			state.Answer, state.Results, steps, err = s.runAgent(ctx, userID, question, state.Retrieval, opts, req.MaxIterations)
+			if err != nil {
+				return nil, err
+			}
+			for _, step := range steps {
+				state.Degraded = state.Degraded || step.Degraded
+			}
+			state.Context = buildContextText(state.Results)
+			if err := s.runStages(ctx, pipeline, state, StageVerify); err != nil {
+				return nil, err
+			}
+		} else {
+			// A share of queries tries out a prompt version; the request's own overrides still apply
+			if version := s.routePromptVersion(ctx); version != nil {
+				versionPipeline, err := s.resolvePipeline(PipelineConfig(version.Pipeline).with(req.Pipeline))
+				if err != nil {
+					logger.Error("Invalid prompt version pipeline", "prompt_version_id", version.ID, "error", err)
+				} else {
+					pipeline = versionPipeline
+					state.SystemPrompt = version.SystemPrompt
+					state.TopK = version.TopK
+					run.PromptVersionID = version.ID
+				}
+			}
+
+			// Rewrite, retrieve, rerank, compress, generate and verify
+			if err := s.runStages(ctx, pipeline, state, pipelineSlots...); err != nil {
+				return nil, err
+			}
+			run.Latency = time.Since(start)
 		}
-		sources = append(sources, source)
+
+		answer = state.Answer
+		sources = buildSources(state.Results)
+		confidence = computeConfidence(question, state.Results, state.Degraded, state.Logprobs)
+		quality = answerQuality(confidence, state.Verification)
+		if req.Explain {
+			explanations = s.explainResults(ctx, state)
+		}
+	}
+
+	// 6. Start a conversation for the first exchange
+	newConversation := conversationID == "" && !req.Standalone
+	if newConversation {
+		conv := &model.Conversation{UserID: userID}
+		if err := s.conversationRepo.Create(ctx, conv); err != nil {
+			logger.Error("Failed to create conversation", "user_id", userID, "error", err)
+		} else {
+			conversationID = conv.ID
+		}
+	}
+
+	// 7. Save to query history with the tokens it consumed
+	usage := tracker.Usage()
+	messageID, err := s.documentRepo.SaveQueryHistory(ctx, userID, conversationID, parentID, question, answer, map[string]interface{}{
+		"sources": sources,
+	}, usage, quality, run)
+	if err != nil {
+		// Log error but don't fail the request
+		logger.Error("Failed to save query history",
+			"user_id", userID,
+			"error", err,
+		)
+	}
+
+	if conversationID != "" {
+		// The conversation shows and continues the branch this exchange ends
+		if messageID != "" {
+			if err := s.conversationRepo.SetActiveMessage(ctx, conversationID, messageID); err != nil {
+				logger.Error("Failed to update conversation", "conversation_id", conversationID, "error", err)
+			}
+		}
+		// Title generation and indexing call external APIs, so don't block the response
+		go s.finalizeConversation(userID, conversationID, newConversation, question, answer)
 	}
 
-	// 4. Build prompt with context
-	systemPrompt := `You are a helpful AI assistant with access to the user's uploaded documents.
+	return &QueryResponse{
+		Answer:         answer,
+		Sources:        sources,
+		ConversationID: conversationID,
+		Steps:          steps,
+		Usage:          usage,
+		Degraded:       state.Degraded,
+		Confidence:     confidence,
+		Verification:   state.Verification,
+		FAQ:            faq,
+		Explanations:   explanations,
+	}, nil
+}
+
+// ragSystemPrompt instructs the model to answer from retrieved context only
+const ragSystemPrompt = `You are a helpful AI assistant with access to the user's uploaded documents.
 
 Your role is to:
 1. Answer questions accurately using information from the provided context
 2. Cite specific sources when providing information
 3. Be concise and actionable in your responses
 4. If the information isn't in the context, clearly state that
+5. Use the available tools for calculations, dates and unit conversions instead of computing them yourself
 
 CRITICAL: Base your answer ONLY on the provided context. Do not use external knowledge.`
 
+// Retrieval ranking parameters
+const (
+	retrievalLimit = 5
+	// pinnedBoost slightly raises the score of chunks from pinned documents
+	pinnedBoost = 1.05
+)
+
+// RetrievalOptions controls which chunks are retrieved for a query
+type RetrievalOptions struct {
+	Filter *repository.SearchFilter
+	// Diversity is the MMR trade-off; 0 ranks purely by relevance
+	Diversity float64
+	// TopK is the number of chunks to return (defaults to retrievalLimit)
+	TopK int
+	// DocumentFirst restricts chunk search to the documents whose summaries best match the query
+	DocumentFirst bool
+}
+
+// retrieve embeds the query and returns the most similar chunks, boosting pinned documents
+// and diversifying with MMR when requested. If the embedding provider fails it falls back
+// to full-text search over the stored chunk text and reports degraded.
+func (s *RAGService) retrieve(ctx context.Context, userID, query string, retrieval RetrievalOptions) (results []*model.VectorPoint, degraded bool, err error) {
+	limit := retrieval.TopK
+	if limit <= 0 {
+		limit = retrievalLimit
+	}
+
+	// Glossary expansions help the embedding; keyword search keeps the literal query
+	queryEmbedding, err := s.embeddings.Embed(ctx, userID, s.expandQuery(ctx, userID, query), EmbeddingQuery)
+	if err != nil {
+		logger.Warn("Embedding failed, falling back to keyword search", "user_id", userID, "error", err)
+		keywordResults, kwErr := s.chunkRepo.SearchText(ctx, userID, query, limit*2, retrieval.Filter)
+		if kwErr != nil {
+			logger.Error("Keyword search failed", "user_id", userID, "error", kwErr)
+			return nil, false, fmt.Errorf("failed to generate question embedding: %w", err)
+		}
+		// MMR needs embeddings, so keyword results are ranked by text relevance only
+		return s.rankResults(ctx, userID, keywordResults, limit, 0), true, nil
+	}
+
+	if retrieval.DocumentFirst {
+		retrieval.Filter = s.restrictToCandidateDocuments(ctx, userID, queryEmbedding, retrieval.Filter)
+	}
+
+	// Over-fetch so pinned chunks just outside the top-k can be promoted
+	candidates := limit * 2
+	if retrieval.Diversity > 0 {
+		// MMR needs a wider candidate pool and the chunk embeddings to compare them
+		candidates = limit * mmrCandidateFactor
+		results, err = s.vectorRepo.SearchWithVectors(ctx, userID, queryEmbedding, candidates, retrieval.Filter)
+	} else {
+		results, err = s.vectorRepo.Search(ctx, userID, queryEmbedding, candidates, retrieval.Filter)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to search vectors: %w", err)
+	}
+
+	// Sparse vectors match the literal query's exact terms, like keyword search
+	if s.sparseEncoder != nil {
+		results = s.addSparseMatches(ctx, userID, query, queryEmbedding, results, candidates, retrieval.Filter)
+	}
+
+	return s.rankResults(ctx, userID, results, limit, retrieval.Diversity), false, nil
+}
+
+// rankResults drops blocklisted chunks, boosts pinned documents, keeps the top limit chunks
+// (with MMR when diversity > 0) and checks them for canaries
+func (s *RAGService) rankResults(ctx context.Context, userID string, results []*model.VectorPoint, limit int, diversity float64) []*model.VectorPoint {
+	results = s.applyBlocklist(ctx, userID, results)
+
+	pinned, err := s.documentRepo.ListPinnedIDs(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to load pinned documents", "user_id", userID, "error", err)
+	}
+	if len(pinned) > 0 {
+		for _, result := range results {
+			if documentID, ok := result.Payload["document_id"].(string); ok && pinned[documentID] {
+				result.Score *= pinnedBoost
+			}
+		}
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Score > results[j].Score
+		})
+	}
+
+	if diversity > 0 {
+		results = maximalMarginalRelevance(results, limit, diversity)
+	} else if len(results) > limit {
+		results = results[:limit]
+	}
+
+	s.checkCanaries(ctx, userID, results)
+
+	return results
+}
+
+// checkCanaries alerts when retrieved chunks come from canary documents
+func (s *RAGService) checkCanaries(ctx context.Context, userID string, results []*model.VectorPoint) {
+	canaries, err := s.documentRepo.ListCanaryIDs(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to load canary documents", "user_id", userID, "error", err)
+		return
+	}
+	if len(canaries) == 0 {
+		return
+	}
+
+	alerted := make(map[string]bool)
+	for _, result := range results {
+		documentID, _ := result.Payload["document_id"].(string)
+		if !canaries[documentID] || alerted[documentID] {
+			continue
+		}
+		alerted[documentID] = true
+
+		filename, _ := result.Payload["filename"].(string)
+		event := &model.AuditEvent{UserID: userID, ResourceID: documentID}
+		if err := s.auditService.RecordCanaryAccess(ctx, event, filename, "retrieval"); err != nil {
+			logger.Error("Failed to record canary access", "user_id", userID, "document_id", documentID, "error", err)
+		}
+	}
+}
+
+// buildContextText renders retrieved chunks as numbered documents for the prompt
+func buildContextText(results []*model.VectorPoint) string {
 	contextText := ""
-	for i, chunk := range contextChunks {
-		contextText += fmt.Sprintf("\n[Document %d]: %s\n", i+1, chunk)
+	i := 0
+	for _, result := range results {
+		if content, ok := result.Payload["content"].(string); ok {
+			i++
+			contextText += fmt.Sprintf("\n[Document %d]: %s\n", i, content)
+		}
 	}
+	return contextText
+}
 
-	userPrompt := fmt.Sprintf("Context from user's documents:\n%s\n\nQuestion: %s\n\nAnswer based on the above context:", contextText, question)
+// buildSources extracts source metadata from retrieved chunks
+func buildSources(results []*model.VectorPoint) []map[string]interface{} {
+	var sources []map[string]interface{}
+	for _, result := range results {
+		source := map[string]interface{}{
+			"filename": result.Payload["filename"],
+			"page":     result.Payload["page"],
+		}
+		// Presentations are cited by slide rather than page
+		if slide, ok := result.Payload["slide"]; ok {
+			source["slide"] = slide
+			source["section"] = result.Payload["section"]
+		}
+		// Markdown notes are cited by the headings a chunk falls under
+		if headingPath, ok := result.Payload["heading_path"]; ok {
+			source["heading_path"] = headingPath
+		}
+		// Spreadsheets are cited by rows, and workbooks by sheet too
+		if rowStart, ok := result.Payload["row_start"]; ok {
+			source["row_start"] = rowStart
+			source["row_end"] = result.Payload["row_end"]
+			if sheet, ok := result.Payload["sheet"]; ok {
+				source["sheet"] = sheet
+			}
+		}
+		// Emails are cited by subject, sender and date
+		if subject, ok := result.Payload["subject"]; ok {
+			source["subject"] = subject
+			source["from"] = result.Payload["from"]
+			source["date"] = result.Payload["date"]
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// sourceTitles lists the distinct filenames of sources built by buildSources, in ranking order
+func sourceTitles(sources []map[string]interface{}, limit int) []string {
+	titles := []string{}
+	seen := map[string]bool{}
+	for _, source := range sources {
+		filename, _ := source["filename"].(string)
+		if filename == "" || seen[filename] {
+			continue
+		}
+		seen[filename] = true
+		titles = append(titles, filename)
+		if len(titles) == limit {
+			break
+		}
+	}
+	return titles
+}
 
-	// 5. Call LLM
-	answer, err := s.callLLM(ctx, systemPrompt, userPrompt)
+// finalizeConversation runs after each exchange: it generates a title from the first exchange
+// of a new conversation, and indexes the conversation for search again, so topics raised in
+// later turns are found too
+func (s *RAGService) finalizeConversation(userID, conversationID string, first bool, question, answer string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if first {
+		title, err := s.callLLM(ctx,
+			"Write a concise title (at most 6 words) for a conversation that starts with the exchange below. Reply with the title only, no quotes or punctuation at the end.",
+			fmt.Sprintf("Question: %s\n\nAnswer: %s", question, truncate(answer, 1000)),
+		)
+		if err != nil {
+			logger.Error("Failed to generate conversation title", "conversation_id", conversationID, "error", err)
+			title = truncate(question, 60)
+		}
+		title = truncate(strings.Trim(strings.TrimSpace(title), `"'`), 255)
+
+		if err := s.conversationRepo.UpdateTitle(ctx, conversationID, title); err != nil {
+			logger.Error("Failed to save conversation title", "conversation_id", conversationID, "error", err)
+		}
+	}
+
+	conv, err := s.conversationRepo.GetByID(ctx, userID, conversationID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call LLM: %w", err)
+		logger.Error("Failed to load conversation to index", "conversation_id", conversationID, "error", err)
+		return
 	}
+	messages, err := s.conversationRepo.ListMessages(ctx, conversationID)
+	if err != nil {
+		logger.Error("Failed to load conversation to index", "conversation_id", conversationID, "error", err)
+		return
+	}
+	branch := branchDetail(conv, newMessageTree(messages)).Messages
 
-	// 6. Save to query history
-	if err := s.documentRepo.SaveQueryHistory(ctx, userID, question, answer, map[string]interface{}{
-		"sources": sources,
+	embedding, err := s.embeddings.Embed(ctx, userID, conversationSearchText(conv.Title, branch), EmbeddingDocument)
+	if err != nil {
+		logger.Error("Failed to embed conversation", "conversation_id", conversationID, "error", err)
+		return
+	}
+
+	if err := s.vectorRepo.IndexConversation(ctx, userID, &model.VectorPoint{
+		ID:     conversationID,
+		Vector: embedding,
+		Payload: map[string]interface{}{
+			"conversation_id": conversationID,
+			"title":           conv.Title,
+		},
 	}); err != nil {
-		// Log error but don't fail the request
-		logger.Error("Failed to save query history",
-			"user_id", userID,
-			"error", err,
-		)
+		logger.Error("Failed to index conversation", "conversation_id", conversationID, "error", err)
+	}
+}
+
+// Conversation search text limits
+const (
+	// conversationIndexAnswers is how many of the latest answers are indexed with the questions
+	conversationIndexAnswers = 3
+	// conversationIndexMaxRunes caps the text embedded for a conversation
+	conversationIndexMaxRunes = 6000
+)
+
+// conversationSearchText is the text a conversation is indexed for search by: its title, the
+// questions of its messages, oldest first, and the latest answers. When there are too many
+// questions to fit, the earliest after the first are left out, so the topic it started with and
+// the recent ones are kept.
+func conversationSearchText(title string, messages []*model.QueryHistory) string {
+	if len(messages) == 0 {
+		return title
 	}
 
-	return &QueryResponse{
-		Answer:  answer,
-		Sources: sources,
+	var answers []string
+	for _, message := range messages[max(len(messages)-conversationIndexAnswers, 0):] {
+		answers = append(answers, truncate(message.Answer, 1000))
+	}
+
+	first := truncate(messages[0].Question, 500)
+	budget := conversationIndexMaxRunes - utf8.RuneCountInString(title) - utf8.RuneCountInString(first)
+	for _, answer := range answers {
+		budget -= utf8.RuneCountInString(answer)
+	}
+
+	var latest []string
+	for i := len(messages) - 1; i > 0; i-- {
+		question := truncate(messages[i].Question, 500)
+		if budget -= utf8.RuneCountInString(question); budget < 0 {
+			break
+		}
+		latest = append(latest, question)
+	}
+	slices.Reverse(latest)
+
+	parts := append([]string{title, first}, latest...)
+	return strings.Join(append(parts, answers...), "\n")
+}
+
+// truncate shortens s to at most max runes
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
+
+// QueryHistoryPage represents a page of query history results
+type QueryHistoryPage struct {
+	Items  []*model.QueryHistory `json:"items"`
+	Total  int                   `json:"total"`
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset"`
+}
+
+// ListHistory returns a page of the user's query history
+func (s *RAGService) ListHistory(ctx context.Context, userID string, filter repository.QueryHistoryFilter) (*QueryHistoryPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	filter.Limit = min(filter.Limit, 100)
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	items, total, err := s.documentRepo.ListQueryHistory(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueryHistoryPage{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
 	}, nil
 }
 
-// callLLM calls the OpenAI API for chat completion
+// DeleteHistory deletes a single query history entry
+func (s *RAGService) DeleteHistory(ctx context.Context, userID, historyID string) error {
+	return s.documentRepo.DeleteQueryHistory(ctx, userID, historyID)
+}
+
+// SetHistoryPinned pins or unpins a query history entry
+func (s *RAGService) SetHistoryPinned(ctx context.Context, userID, historyID string, pinned bool) error {
+	return s.documentRepo.SetQueryHistoryPinned(ctx, userID, historyID, pinned)
+}
+
+// SetHistoryFavorite marks or unmarks a query history entry as favorite
+func (s *RAGService) SetHistoryFavorite(ctx context.Context, userID, historyID string, favorite bool) error {
+	return s.documentRepo.SetQueryHistoryFavorite(ctx, userID, historyID, favorite)
+}
+
+// SetHistoryFeedback records whether the user found an answer helpful: 1 helpful, -1 not
+// helpful, 0 to clear the rating
+func (s *RAGService) SetHistoryFeedback(ctx context.Context, userID, historyID string, feedback int) error {
+	return s.documentRepo.SetQueryHistoryFeedback(ctx, userID, historyID, feedback)
+}
+
+// MarkHistoryRead records that the client received an answer, such as a streamed answer after
+// it reconnects
+func (s *RAGService) MarkHistoryRead(ctx context.Context, userID, historyID string) error {
+	return s.documentRepo.MarkQueryHistoryRead(ctx, userID, historyID)
+}
+
+// GetHistory returns a single query history entry, such as the answer so far of a stream the
+// client disconnected from
+func (s *RAGService) GetHistory(ctx context.Context, userID, historyID string) (*model.QueryHistory, error) {
+	return s.documentRepo.GetQueryHistory(ctx, userID, historyID)
+}
+
+// mergeFilters overlays override filters on top of base filters
+func mergeFilters(base, override map[string]string) map[string]string {
+	if len(base) == 0 {
+		return override
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+	return merged
+}
+
+// withClauseType adds a contract clause_type filter, validating it against the contract profile's types
+func withClauseType(filters map[string]string, clauseType string) (map[string]string, error) {
+	clauseType = strings.TrimSpace(clauseType)
+	if clauseType == "" {
+		return filters, nil
+	}
+
+	for _, known := range profile.ClauseTypes {
+		if clauseType == known {
+			return mergeFilters(filters, map[string]string{"clause_type": clauseType}), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown clause_type %q (expected one of: %s)", clauseType, strings.Join(profile.ClauseTypes, ", "))
+}
+
+// callLLM calls the OpenAI API for a plain chat completion with the default model and no tools
 func (s *RAGService) callLLM(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
-	requestBody := ChatCompletionRequest{
-		Model: "gpt-3.5-turbo",
+	message, err := s.chatCompletion(ctx, ChatCompletionRequest{
 		Messages: []ChatMessage{
 			{Role: "system", Content: systemPrompt},
 			{Role: "user", Content: userPrompt},
 		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return message.Content, nil
+}
+
+// buildSystemPrompt prepends the user's persona to a system prompt and appends
+// the answer-language instruction
+func buildSystemPrompt(systemPrompt string, opts GenerationOptions) string {
+	if opts.Persona != "" {
+		systemPrompt = fmt.Sprintf("The user has asked you to follow these instructions:\n%s\n\n%s", opts.Persona, systemPrompt)
+	}
+	if opts.Language != "" {
+		systemPrompt = fmt.Sprintf("%s\n\nAlways write your answer in %s, even when the context or the question is in another language. Translate quoted material as needed but keep document names unchanged.", systemPrompt, opts.Language)
+	}
+	return systemPrompt
+}
+
+// generate calls the OpenAI API for chat completion with the given options
+// Registered tools are exposed via function calling and their results fed back to the model.
+// The final message carries token logprobs when the model supports them.
+func (s *RAGService) generate(ctx context.Context, opts GenerationOptions, systemPrompt, userPrompt string) (*ChatMessage, error) {
+	messages := []ChatMessage{
+		{Role: "system", Content: buildSystemPrompt(systemPrompt, opts)},
+		{Role: "user", Content: userPrompt},
+	}
+	tools := s.tools.Definitions()
+
+	for round := 0; ; round++ {
+		request := ChatCompletionRequest{
+			Model:       opts.Model,
+			Temperature: opts.Temperature,
+			Messages:    messages,
+			Tools:       tools,
+			Logprobs:    supportsLogprobs(opts.Model),
+		}
+		if len(tools) > 0 && round >= maxToolRounds {
+			request.ToolChoice = "none"
+		}
+
+		message, err := s.chatCompletion(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(message.ToolCalls) == 0 {
+			return message, nil
+		}
+
+		messages = append(messages, *message)
+		messages = append(messages, s.tools.runTools(ctx, message.ToolCalls)...)
+	}
+}
+
+// chatCompletion sends a chat completion request and returns the first choice's message
+func (s *RAGService) chatCompletion(ctx context.Context, requestBody ChatCompletionRequest) (*ChatMessage, error) {
+	if requestBody.Model == "" {
+		requestBody.Model = defaultChatModel
 	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", s.openAI.URL("/chat/completions"), bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.llmAPIKey)
+	s.openAI.authorize(req)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, httpretry.ResponseError("API", resp)
 	}
 
 	var completionResp ChatCompletionResponse
 	if err := json.NewDecoder(resp.Body).Decode(&completionResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	// The response names the exact snapshot used, which prices more precisely than the alias requested
+	billedModel := completionResp.Model
+	if billedModel == "" {
+		billedModel = requestBody.Model
 	}
+	trackChatUsage(ctx, billedModel, completionResp.Usage.PromptTokens, completionResp.Usage.CompletionTokens)
 
 	if len(completionResp.Choices) == 0 {
-		return "", fmt.Errorf("no completion choices returned")
+		return nil, fmt.Errorf("no completion choices returned")
+	}
+
+	choice := completionResp.Choices[0]
+	if choice.Logprobs != nil {
+		for _, token := range choice.Logprobs.Content {
+			choice.Message.TokenLogprobs = append(choice.Message.TokenLogprobs, token.Logprob)
+		}
 	}
 
-	return completionResp.Choices[0].Message.Content, nil
+	return &choice.Message, nil
 }
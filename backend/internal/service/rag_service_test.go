@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// testExchanges returns a conversation's messages, one per question, each following the one before
+func testExchanges(questions ...string) []*model.QueryHistory {
+	messages := make([]*model.QueryHistory, len(questions))
+	for i, question := range questions {
+		messages[i] = &model.QueryHistory{
+			ID:       fmt.Sprintf("m-%d", i+1),
+			Question: question,
+			Answer:   strings.Repeat("According to your notes, nothing else changed. ", 50),
+		}
+		if i > 0 {
+			messages[i].ParentID = messages[i-1].ID
+		}
+	}
+	return messages
+}
+
+func TestConversationSearchTextIncludesLaterTurns(t *testing.T) {
+	messages := testExchanges(
+		"What's on the grocery list this week?",
+		"Which of those are on offer?",
+		"When does the lease on the flat end, and what are the notice terms?",
+		"Remind me what the internet contract costs",
+		"And the electricity bill?",
+		"Anything due tomorrow?",
+	)
+
+	text := conversationSearchText("Weekly groceries", messages)
+	if !strings.Contains(text, "notice terms") {
+		t.Errorf("search text doesn't include the third question:\n%s", text)
+	}
+}
+
+func TestConversationSearchTextKeepsFirstAndLatestQuestions(t *testing.T) {
+	questions := make([]string, 100)
+	for i := range questions {
+		questions[i] = fmt.Sprintf("question %03d %s", i+1, strings.Repeat("x", 480))
+	}
+
+	text := conversationSearchText("A long chat", testExchanges(questions...))
+	if n := utf8.RuneCountInString(text) - strings.Count(text, "\n"); n > conversationIndexMaxRunes {
+		t.Errorf("search text is %d runes without line breaks, want at most %d", n, conversationIndexMaxRunes)
+	}
+	for _, kept := range []string{"question 001", "question 099", "question 100"} {
+		if !strings.Contains(text, kept) {
+			t.Errorf("search text doesn't include %q", kept)
+		}
+	}
+	if strings.Contains(text, "question 002") {
+		t.Errorf("search text includes the second question, which should make room for the latest")
+	}
+}
+
+func TestConversationSearchTextFollowsShownBranch(t *testing.T) {
+	messages := testExchanges("What does the insurance policy cover?", "Does it cover water damage?")
+	edited := &model.QueryHistory{ID: "m-3", ParentID: "m-1", Question: "Does it cover theft from the car?"}
+	messages = append(messages, edited)
+	conv := &model.Conversation{ID: "c-1", Title: "Insurance", ActiveMessageID: edited.ID}
+
+	text := conversationSearchText(conv.Title, branchDetail(conv, newMessageTree(messages)).Messages)
+	if !strings.Contains(text, "theft from the car") {
+		t.Errorf("search text doesn't include the shown branch:\n%s", text)
+	}
+	if strings.Contains(text, "water damage") {
+		t.Errorf("search text includes a branch the conversation doesn't show:\n%s", text)
+	}
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/queue"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// ReembedService moves users to a new embedding model without downtime: each user's chunks are
+// embedded into a fresh docs collection while the current one keeps serving searches, then the
+// user's docs alias is switched to the new collection
+type ReembedService struct {
+	documentRepo  *repository.DocumentRepository
+	chunkRepo     *repository.ChunkRepository
+	vectorRepo    *repository.VectorRepository
+	lockRepo      *repository.LockRepository
+	settingsRepo  *repository.SettingsRepository
+	embeddings    *EmbeddingProviders
+	sparseEncoder SparseEncoder
+}
+
+// NewReembedService creates a re-embedding service that moves each user to the configured
+// embedding model or the one chosen in their settings
+func NewReembedService(
+	documentRepo *repository.DocumentRepository,
+	chunkRepo *repository.ChunkRepository,
+	vectorRepo *repository.VectorRepository,
+	lockRepo *repository.LockRepository,
+	settingsRepo *repository.SettingsRepository,
+	embeddings *EmbeddingProviders,
+	sparseEncoder SparseEncoder,
+) *ReembedService {
+	return &ReembedService{
+		documentRepo:  documentRepo,
+		chunkRepo:     chunkRepo,
+		vectorRepo:    vectorRepo,
+		lockRepo:      lockRepo,
+		settingsRepo:  settingsRepo,
+		embeddings:    embeddings,
+		sparseEncoder: sparseEncoder,
+	}
+}
+
+// ReembedOptions controls a re-embedding run
+type ReembedOptions struct {
+	// UserID limits the run to one user; empty re-embeds every user with documents
+	UserID string
+	// Activate switches each user's docs alias to the new collection once it's filled
+	Activate bool
+	// DropOld deletes the collection that was serving a user's docs before the switch
+	DropOld bool
+	// Refresh re-embeds users already on their model's collection, ignoring cached embeddings,
+	// for when the provider updated the model's weights without renaming it
+	Refresh bool
+}
+
+// ReembedResult reports the run for one user
+type ReembedResult struct {
+	UserID     string
+	Model      string
+	Collection string
+	// Previous is the collection that served the user's docs before the run, if any
+	Previous  string
+	Documents int
+	Chunks    int
+	Activated bool
+	// Skipped is set when the user's docs are already served from this model's collection
+	Skipped bool
+}
+
+// Run re-embeds every user's documents (or one user's) with the configured embedding model or
+// the user's own
+func (s *ReembedService) Run(ctx context.Context, opts ReembedOptions) ([]*ReembedResult, error) {
+	lock, err := s.lockRepo.TryAcquire(ctx, "reembed")
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, fmt.Errorf("another re-embedding run is in progress")
+	}
+	defer lock.Release()
+
+	userIDs := []string{opts.UserID}
+	if opts.UserID == "" {
+		if userIDs, err = s.documentRepo.ListOwnerIDs(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []*ReembedResult
+	for _, userID := range userIDs {
+		result, err := s.reembedUser(ctx, userID, opts)
+		if err != nil {
+			return results, fmt.Errorf("failed to re-embed documents of user %s: %w", userID, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// reembedUser fills the user's collection for their model and optionally activates it
+func (s *ReembedService) reembedUser(ctx context.Context, userID string, opts ReembedOptions) (*ReembedResult, error) {
+	settings, err := s.settingsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := s.embeddings.provider(settings.EmbeddingModel)
+	if err != nil {
+		return nil, err
+	}
+
+	version := embeddingVersion(provider, s.sparseEncoder)
+	result := &ReembedResult{
+		UserID:     userID,
+		Model:      provider.Model(),
+		Collection: s.vectorRepo.GetVersionedCollectionName(userID, version),
+	}
+	// A refresh can't rebuild the collection serving searches either, so it alternates between
+	// the model's collection and a second one
+	refreshed := s.vectorRepo.GetVersionedCollectionName(userID, version+"_refresh")
+
+	previous, err := s.vectorRepo.ActiveCollection(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result.Previous = previous
+	if opts.Refresh {
+		ctx = withCacheRefresh(ctx)
+		if previous == result.Collection {
+			result.Collection = refreshed
+		}
+	} else if previous == result.Collection || previous == refreshed {
+		// Rebuilding the collection in place would empty it while it serves searches. The user may
+		// have chosen a model and switched back before the run.
+		result.Skipped = true
+		if opts.Activate && settings.ActiveEmbeddingModel != settings.EmbeddingModel {
+			return result, s.settingsRepo.SetActiveEmbeddingModel(ctx, userID, settings.EmbeddingModel)
+		}
+		return result, nil
+	}
+
+	if err := s.vectorRepo.RecreateCollection(ctx, result.Collection, uint64(provider.Dimensions()), sparseVectorConfig(s.sparseEncoder)); err != nil {
+		return nil, err
+	}
+
+	// Documents uploaded during the pass went to the old collection, so pick them up until a
+	// pass finds nothing new
+	done := make(map[string]bool)
+	for {
+		docs, err := s.documentRepo.ListByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, doc := range docs {
+			if done[doc.ID] {
+				continue
+			}
+			chunks, err := s.reembedDocument(ctx, provider, result.Collection, doc)
+			if err != nil {
+				return nil, err
+			}
+			done[doc.ID] = true
+			result.Documents++
+			result.Chunks += chunks
+			added++
+		}
+		if added == 0 {
+			break
+		}
+	}
+
+	if !opts.Activate {
+		return result, nil
+	}
+	if err := s.vectorRepo.ActivateCollection(ctx, userID, result.Collection); err != nil {
+		return nil, err
+	}
+	result.Activated = true
+	logger.Info("Activated re-embedded collection", "user_id", userID, "collection", result.Collection, "previous", previous)
+
+	modelChanged := settings.ActiveEmbeddingModel != settings.EmbeddingModel
+	if modelChanged {
+		if err := s.settingsRepo.SetActiveEmbeddingModel(ctx, userID, settings.EmbeddingModel); err != nil {
+			return nil, err
+		}
+	}
+	if modelChanged || opts.Refresh {
+		// The summary and conversation indexes hold vectors of the previous model (or weights).
+		// Summaries are stored, so they are re-embedded; conversations are indexed again as they
+		// continue.
+		if err := s.reindexSummaries(ctx, provider, userID); err != nil {
+			logger.Warn("Failed to re-index document summaries", "user_id", userID, "error", err)
+		}
+		if err := s.vectorRepo.DeleteUserCollection(ctx, userID, repository.CollectionScopeConversations); err != nil {
+			logger.Warn("Failed to delete conversation index", "user_id", userID, "error", err)
+		}
+	}
+
+	// A collection created before aliases were used was already deleted to free the alias name
+	docsName, err := s.vectorRepo.CollectionName(ctx, userID, repository.CollectionScopeDocs)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DropOld && previous != "" && previous != docsName {
+		if err := s.vectorRepo.DeleteCollection(ctx, previous); err != nil {
+			logger.Warn("Failed to delete previous collection", "collection", previous, "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+// reembedDocument embeds a document's stored chunks into the collection and returns how many
+// were written
+func (s *ReembedService) reembedDocument(ctx context.Context, provider EmbeddingProvider, collectionName string, doc *model.Document) (int, error) {
+	chunks, err := s.chunkRepo.ListByDocumentID(ctx, doc.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	points := make([]*model.VectorPoint, 0, len(chunks))
+	texts := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		content, _ := chunk.Payload["content"].(string)
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		points = append(points, chunk)
+		texts = append(texts, content)
+	}
+	if len(points) == 0 {
+		return 0, nil
+	}
+
+	embedCtx, tracker := withUsageTracker(ctx)
+	embeddings, err := provider.GenerateEmbeddings(embedCtx, texts, EmbeddingDocument)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate embeddings for %s: %w", doc.Filename, err)
+	}
+	recordEmbeddingUsage(ctx, s.documentRepo, tracker, model.EmbeddingUsage{
+		UserID:     doc.UserID,
+		DocumentID: doc.ID,
+		Filename:   doc.Filename,
+		Source:     EmbeddingSourceReembed,
+		Model:      provider.Model(),
+	})
+	if len(embeddings) != len(points) {
+		return 0, fmt.Errorf("expected %d embeddings for %s, got %d", len(points), doc.Filename, len(embeddings))
+	}
+	for i, point := range points {
+		point.Vector = embeddings[i]
+	}
+	if err := attachSparseVectors(ctx, s.sparseEncoder, points); err != nil {
+		return 0, fmt.Errorf("failed to generate sparse vectors for %s: %w", doc.Filename, err)
+	}
+
+	if err := s.vectorRepo.UpsertPoints(ctx, collectionName, points); err != nil {
+		return 0, fmt.Errorf("failed to store vectors for %s: %w", doc.Filename, err)
+	}
+
+	return len(points), nil
+}
+
+// reindexSummaries embeds the user's stored document summaries into a new summary index
+func (s *ReembedService) reindexSummaries(ctx context.Context, provider EmbeddingProvider, userID string) error {
+	collectionName, err := s.vectorRepo.CollectionName(ctx, userID, repository.CollectionScopeSummaries)
+	if err != nil {
+		return err
+	}
+	if err := s.vectorRepo.RecreateCollection(ctx, collectionName, uint64(provider.Dimensions()), nil); err != nil {
+		return err
+	}
+
+	docs, err := s.documentRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var points []*model.VectorPoint
+	var texts []string
+	for _, doc := range docs {
+		if strings.TrimSpace(doc.Summary) == "" {
+			continue
+		}
+		points = append(points, &model.VectorPoint{
+			ID: doc.ID,
+			Payload: map[string]interface{}{
+				"document_id": doc.ID,
+				"filename":    doc.Filename,
+			},
+		})
+		texts = append(texts, doc.Filename+"\n"+doc.Summary)
+	}
+	if len(points) == 0 {
+		return nil
+	}
+
+	embedCtx, tracker := withUsageTracker(ctx)
+	embeddings, err := provider.GenerateEmbeddings(embedCtx, texts, EmbeddingDocument)
+	if err != nil {
+		return fmt.Errorf("failed to generate summary embeddings: %w", err)
+	}
+	recordEmbeddingUsage(ctx, s.documentRepo, tracker, model.EmbeddingUsage{
+		UserID: userID,
+		Source: EmbeddingSourceSummary,
+		Model:  provider.Model(),
+	})
+	if len(embeddings) != len(points) {
+		return fmt.Errorf("expected %d summary embeddings, got %d", len(points), len(embeddings))
+	}
+	for i, point := range points {
+		point.Vector = embeddings[i]
+	}
+
+	return s.vectorRepo.UpsertPoints(ctx, collectionName, points)
+}
+
+// JobReembedUser is the queue job type for moving a user to the embedding model they chose
+const JobReembedUser = "embedding.reembed_user"
+
+// ReembedUserJob is the payload of a JobReembedUser job
+type ReembedUserJob struct {
+	UserID string `json:"user_id"`
+}
+
+// HandleReembedUser processes a JobReembedUser job, activating the user's new collection and
+// dropping the old one. It is retried while another re-embedding run holds the lock.
+func (s *ReembedService) HandleReembedUser(ctx context.Context, job *queue.Job) error {
+	var payload ReembedUserJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("invalid re-embed job payload: %w", err))
+	}
+
+	results, err := s.Run(ctx, ReembedOptions{UserID: payload.UserID, Activate: true, DropOld: true})
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		logger.Info("Re-embedded documents for new embedding model",
+			"user_id", result.UserID,
+			"model", result.Model,
+			"documents", result.Documents,
+			"chunks", result.Chunks,
+			"skipped", result.Skipped,
+		)
+	}
+
+	return nil
+}
+
+// embeddingVersion names a collection after the model and dimensions of its vectors and its
+// sparse encoder, if any, e.g. "text_embedding_3_large_3072" or "text_embedding_3_large_3072_bm25"
+func embeddingVersion(provider EmbeddingProvider, sparse SparseEncoder) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '_'
+	}, provider.Model())
+	version := fmt.Sprintf("%s_%d", slug, provider.Dimensions())
+	if sparse != nil {
+		version += "_" + sparse.Name()
+	}
+	return version
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page size and margins in PDF points
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 40.0
+)
+
+// pdfWriter lays out text reports on A4 pages using the standard Helvetica fonts, so no fonts
+// need embedding. Text is encoded as WinAnsi; characters outside it are replaced with "?".
+type pdfWriter struct {
+	pages []*pdfPage
+	// y is the baseline of the next line on the current page
+	y float64
+}
+
+// pdfPage holds a page's content stream and link annotations
+type pdfPage struct {
+	content bytes.Buffer
+	links   []pdfLink
+}
+
+// pdfLink is a clickable area opening a URI
+type pdfLink struct {
+	x1, y1, x2, y2 float64
+	uri            string
+}
+
+func newPDFWriter() *pdfWriter {
+	w := &pdfWriter{}
+	w.newPage()
+	return w
+}
+
+// newPage starts a new page with the cursor at the top margin
+func (w *pdfWriter) newPage() {
+	w.pages = append(w.pages, &pdfPage{})
+	w.y = pdfPageHeight - pdfMargin
+}
+
+// line moves the cursor down by height, starting a new page when the bottom margin is reached,
+// and returns the baseline to draw the line at
+func (w *pdfWriter) line(height float64) float64 {
+	if w.y-height < pdfMargin {
+		w.newPage()
+	}
+	w.y -= height
+	return w.y
+}
+
+// text draws s at (x, y) in Helvetica, or Helvetica-Bold when bold is set
+func (w *pdfWriter) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&w.current().content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(s))
+}
+
+// textRight draws s so that it ends at x
+func (w *pdfWriter) textRight(x, y, size float64, bold bool, s string) {
+	w.text(x-pdfTextWidth(s, size), y, size, bold, s)
+}
+
+// link draws s at (x, y) in blue and makes it open uri when clicked
+func (w *pdfWriter) link(x, y, size float64, s, uri string) {
+	page := w.current()
+	fmt.Fprintf(&page.content, "0 0 0.8 rg\n")
+	w.text(x, y, size, false, s)
+	fmt.Fprintf(&page.content, "0 g\n")
+	page.links = append(page.links, pdfLink{x1: x, y1: y - 2, x2: x + pdfTextWidth(s, size), y2: y + size, uri: uri})
+}
+
+// rule draws a horizontal line across the page just below y
+func (w *pdfWriter) rule(y float64) {
+	fmt.Fprintf(&w.current().content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, y-3, pdfPageWidth-pdfMargin, y-3)
+}
+
+func (w *pdfWriter) current() *pdfPage {
+	return w.pages[len(w.pages)-1]
+}
+
+// bytes assembles the PDF file: catalog, page tree, fonts, then each page with its content
+// stream and link annotations, followed by the cross-reference table
+func (w *pdfWriter) bytes() []byte {
+	var objects []string
+	add := func(object string) int {
+		objects = append(objects, object)
+		return len(objects)
+	}
+
+	add("<< /Type /Catalog /Pages 2 0 R >>")
+	add("") // page tree, filled in once the page objects are numbered
+	add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	var kids []string
+	for _, page := range w.pages {
+		var annots []string
+		for _, l := range page.links {
+			id := add(fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [%.2f %.2f %.2f %.2f] /Border [0 0 0] /A << /S /URI /URI (%s) >> >>",
+				l.x1, l.y1, l.x2, l.y2, pdfEscape(l.uri)))
+			annots = append(annots, fmt.Sprintf("%d 0 R", id))
+		}
+		content := add(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()))
+		id := add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R /Annots [%s] >>",
+			pdfPageWidth, pdfPageHeight, content, strings.Join(annots, " ")))
+		kids = append(kids, fmt.Sprintf("%d 0 R", id))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// pdfEscape encodes s as a WinAnsi PDF string body
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '€':
+			b.WriteString(`\200`)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// pdfTextWidth estimates the width of s in Helvetica. Digits and separators use their exact
+// widths so right-aligned amounts line up; other characters use an average width.
+func pdfTextWidth(s string, size float64) float64 {
+	width := 0.0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			width += 0.556
+		case r == '.' || r == ',' || r == ' ':
+			width += 0.278
+		case r == '-':
+			width += 0.333
+		default:
+			width += 0.56
+		}
+	}
+	return width * size
+}
+
+// truncateForWidth shortens s with an ellipsis so it fits within width
+func truncateForWidth(s string, size, width float64) string {
+	if pdfTextWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Report formats
+const (
+	ReportFormatCSV = "csv"
+	ReportFormatPDF = "pdf"
+)
+
+// ExpenseCategories lists the categories expenses are sorted into
+var ExpenseCategories = []string{
+	"meals", "groceries", "transport", "travel", "accommodation", "office", "software",
+	"utilities", "healthcare", "entertainment", "shopping", "other",
+}
+
+// Expense report limits
+const (
+	maxExpenseReportDays       = 366
+	expenseCategorizeBatchSize = 40
+)
+
+// ReportService generates reports from the structured fields extracted at ingestion
+type ReportService struct {
+	chunkRepo  *repository.ChunkRepository
+	ragService *RAGService
+}
+
+// NewReportService creates a new report service
+func NewReportService(chunkRepo *repository.ChunkRepository, ragService *RAGService) *ReportService {
+	return &ReportService{
+		chunkRepo:  chunkRepo,
+		ragService: ragService,
+	}
+}
+
+// ExpenseReportRequest selects the period and format of an expense report. Dates are
+// YYYY-MM-DD and inclusive; the period defaults to the current month so far.
+type ExpenseReportRequest struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Format string `json:"format"`
+}
+
+// ExpenseLine is one receipt in an expense report, linked to its source document
+type ExpenseLine struct {
+	Date       time.Time `json:"date"`
+	Merchant   string    `json:"merchant"`
+	Category   string    `json:"category"`
+	Amount     float64   `json:"amount"`
+	Currency   string    `json:"currency"`
+	DocumentID string    `json:"document_id"`
+	Filename   string    `json:"filename"`
+	SourceURL  string    `json:"source_url"`
+}
+
+// ExpenseTotal is the total spent in a category, per currency
+type ExpenseTotal struct {
+	Category string  `json:"category"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	Count    int     `json:"count"`
+}
+
+// ExpenseReport is a period's receipts with totals by category
+type ExpenseReport struct {
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Lines       []ExpenseLine  `json:"lines"`
+	Totals      []ExpenseTotal `json:"totals"`
+}
+
+// ReportFile is a rendered report ready to download
+type ReportFile struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// ExpenseReport builds an expense report from the receipts and invoices dated within the period
+// and renders it as CSV or PDF. Each line links to the receipt's download URL under baseURL.
+func (s *ReportService) ExpenseReport(ctx context.Context, userID string, req ExpenseReportRequest, baseURL string) (*ReportFile, error) {
+	format := req.Format
+	if format == "" {
+		format = ReportFormatCSV
+	}
+	if format != ReportFormatCSV && format != ReportFormatPDF {
+		return nil, fmt.Errorf("format must be %q or %q", ReportFormatCSV, ReportFormatPDF)
+	}
+
+	from, to, err := parseReportPeriod(req.From, req.To, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	receipts, err := s.chunkRepo.ListReceipts(ctx, userID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	categories := s.categorizeExpenses(ctx, receipts)
+	report := &ExpenseReport{From: from, To: to, GeneratedAt: time.Now().UTC()}
+	totals := make(map[[2]string]*ExpenseTotal)
+	for i, receipt := range receipts {
+		merchant := receipt.Merchant
+		if merchant == "" {
+			merchant = receipt.Filename
+		}
+		line := ExpenseLine{
+			Date:       receipt.Date,
+			Merchant:   merchant,
+			Category:   categories[i],
+			Amount:     receipt.Total,
+			Currency:   receipt.Currency,
+			DocumentID: receipt.DocumentID,
+			Filename:   receipt.Filename,
+			SourceURL:  strings.TrimRight(baseURL, "/") + "/api/documents/" + receipt.DocumentID + "/download",
+		}
+		report.Lines = append(report.Lines, line)
+
+		key := [2]string{line.Currency, line.Category}
+		if totals[key] == nil {
+			totals[key] = &ExpenseTotal{Category: line.Category, Currency: line.Currency}
+		}
+		totals[key].Amount += line.Amount
+		totals[key].Count++
+	}
+	for _, total := range totals {
+		total.Amount = roundCents(total.Amount)
+		report.Totals = append(report.Totals, *total)
+	}
+	sort.Slice(report.Totals, func(i, j int) bool {
+		a, b := report.Totals[i], report.Totals[j]
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.Amount > b.Amount
+	})
+
+	file := &ReportFile{Filename: fmt.Sprintf("expenses-%s-to-%s.%s", from.Format("2006-01-02"), to.Format("2006-01-02"), format)}
+	if format == ReportFormatPDF {
+		file.ContentType = "application/pdf"
+		file.Content = renderExpenseReportPDF(report)
+		return file, nil
+	}
+
+	content, err := renderExpenseReportCSV(report)
+	if err != nil {
+		return nil, err
+	}
+	file.ContentType = "text/csv; charset=utf-8"
+	file.Content = content
+	return file, nil
+}
+
+// parseReportPeriod parses the inclusive report period, defaulting to the month so far
+func parseReportPeriod(fromStr, toStr string, now time.Time) (time.Time, time.Time, error) {
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := startOfDay(now)
+
+	var err error
+	if fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must be a date in YYYY-MM-DD format")
+		}
+	}
+	if toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to must be a date in YYYY-MM-DD format")
+		}
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) > maxExpenseReportDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("report period must be at most %d days", maxExpenseReportDays)
+	}
+	return from, to, nil
+}
+
+// expenseCategorySystemPrompt asks for one category per numbered receipt
+var expenseCategorySystemPrompt = `You categorize expenses from receipts.
+
+Categories: ` + strings.Join(ExpenseCategories, ", ") + `
+
+Reply with a JSON object mapping each receipt number to exactly one category, e.g. {"1": "meals", "2": "transport"}. Reply with the JSON only.`
+
+// categorizeExpenses asks the LLM to categorize the receipts from their merchant and items,
+// returning one category per receipt. Receipts the LLM can't categorize fall back to "other".
+func (s *ReportService) categorizeExpenses(ctx context.Context, receipts []*model.Receipt) []string {
+	categories := make([]string, len(receipts))
+	for i := range categories {
+		categories[i] = "other"
+	}
+
+	for start := 0; start < len(receipts); start += expenseCategorizeBatchSize {
+		end := start + expenseCategorizeBatchSize
+		if end > len(receipts) {
+			end = len(receipts)
+		}
+
+		var prompt strings.Builder
+		for i, receipt := range receipts[start:end] {
+			fmt.Fprintf(&prompt, "%d. %s", i+1, receipt.Merchant)
+			if len(receipt.Items) > 0 {
+				items := receipt.Items
+				if len(items) > 5 {
+					items = items[:5]
+				}
+				fmt.Fprintf(&prompt, " (items: %s)", strings.Join(items, "; "))
+			}
+			fmt.Fprintf(&prompt, " [%s]\n", receipt.Filename)
+		}
+
+		reply, err := s.ragService.callLLM(ctx, expenseCategorySystemPrompt, prompt.String())
+		if err != nil {
+			// The report is still useful with the receipts left uncategorized
+			logger.Error("Failed to categorize expenses", "error", err)
+			continue
+		}
+		var assigned map[string]string
+		if err := json.Unmarshal([]byte(stripCodeFence(reply)), &assigned); err != nil {
+			logger.Error("Failed to parse expense categories", "error", err)
+			continue
+		}
+		for key, category := range assigned {
+			n, err := strconv.Atoi(key)
+			category = strings.ToLower(strings.TrimSpace(category))
+			if err != nil || n < 1 || n > end-start || !isExpenseCategory(category) {
+				continue
+			}
+			categories[start+n-1] = category
+		}
+	}
+	return categories
+}
+
+func isExpenseCategory(category string) bool {
+	for _, known := range ExpenseCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+// renderExpenseReportCSV writes one row per receipt, then the totals by category
+func renderExpenseReportCSV(report *ExpenseReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{{"date", "merchant", "category", "amount", "currency", "filename", "document_id", "source_url"}}
+	for _, line := range report.Lines {
+		rows = append(rows, []string{
+			line.Date.Format("2006-01-02"), line.Merchant, line.Category, formatAmount(line.Amount),
+			line.Currency, line.Filename, line.DocumentID, line.SourceURL,
+		})
+	}
+	rows = append(rows, []string{}, []string{"category", "currency", "receipts", "total"})
+	for _, total := range report.Totals {
+		rows = append(rows, []string{total.Category, total.Currency, strconv.Itoa(total.Count), formatAmount(total.Amount)})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderExpenseReportPDF lays out the receipts as a table, each filename linking to the receipt,
+// followed by the totals by category
+func renderExpenseReportPDF(report *ExpenseReport) []byte {
+	const size = 9.0
+	// Column positions: date, merchant, category, amount (right edge), currency, source
+	const colDate, colMerchant, colCategory, colAmount, colCurrency, colSource = pdfMargin, 100.0, 250.0, 380.0, 388.0, 420.0
+
+	w := newPDFWriter()
+	w.text(pdfMargin, w.line(16), 16, true, "Expense report")
+	w.text(pdfMargin, w.line(16), 10, false, fmt.Sprintf("%s to %s  ·  %d receipts  ·  generated %s",
+		report.From.Format("2 Jan 2006"), report.To.Format("2 Jan 2006"), len(report.Lines), report.GeneratedAt.Format("2006-01-02 15:04 MST")))
+
+	header := func() {
+		y := w.line(22)
+		w.text(colDate, y, size, true, "Date")
+		w.text(colMerchant, y, size, true, "Merchant")
+		w.text(colCategory, y, size, true, "Category")
+		w.textRight(colAmount, y, size, true, "Amount")
+		w.text(colSource, y, size, true, "Receipt")
+		w.rule(y)
+	}
+	header()
+	for _, line := range report.Lines {
+		if w.y-14 < pdfMargin {
+			w.newPage()
+			header()
+		}
+		y := w.line(14)
+		w.text(colDate, y, size, false, line.Date.Format("2006-01-02"))
+		w.text(colMerchant, y, size, false, truncateForWidth(line.Merchant, size, colCategory-colMerchant-8))
+		w.text(colCategory, y, size, false, line.Category)
+		w.textRight(colAmount, y, size, false, formatAmount(line.Amount))
+		w.text(colCurrency, y, size, false, line.Currency)
+		w.link(colSource, y, size, truncateForWidth(line.Filename, size, pdfPageWidth-pdfMargin-colSource), line.SourceURL)
+	}
+
+	y := w.line(30)
+	w.text(pdfMargin, y, 12, true, "Totals by category")
+	w.rule(y)
+	for _, total := range report.Totals {
+		y := w.line(14)
+		w.text(colDate, y, size, false, total.Category)
+		w.text(colCategory, y, size, false, fmt.Sprintf("%d receipts", total.Count))
+		w.textRight(colAmount, y, size, false, formatAmount(total.Amount))
+		w.text(colCurrency, y, size, false, total.Currency)
+	}
+
+	return w.bytes()
+}
+
+// formatAmount formats an amount with two decimals
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// roundCents rounds a sum to two decimals, removing floating point drift
+func roundCents(amount float64) float64 {
+	rounded, _ := strconv.ParseFloat(formatAmount(amount), 64)
+	return rounded
+}
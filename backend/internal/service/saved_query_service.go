@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// placeholderPattern matches {name} placeholders in a saved query template
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// SavedQueryService manages saved query templates and executes them
+type SavedQueryService struct {
+	savedRepo  *repository.SavedQueryRepository
+	ragService *RAGService
+}
+
+// NewSavedQueryService creates a new saved query service
+func NewSavedQueryService(savedRepo *repository.SavedQueryRepository, ragService *RAGService) *SavedQueryService {
+	return &SavedQueryService{
+		savedRepo:  savedRepo,
+		ragService: ragService,
+	}
+}
+
+// SavedQueryInput represents the editable fields of a saved query
+type SavedQueryInput struct {
+	Name     string            `json:"name"`
+	Template string            `json:"template"`
+	Filters  map[string]string `json:"filters"`
+}
+
+// ExecuteSavedQueryRequest supplies the parameter values for running a saved query
+type ExecuteSavedQueryRequest struct {
+	Params         map[string]string `json:"params"`
+	ConversationID string            `json:"conversation_id,omitempty"`
+	Agent          bool              `json:"agent,omitempty"`
+}
+
+// Create saves a new query template
+func (s *SavedQueryService) Create(ctx context.Context, userID string, input SavedQueryInput) (*model.SavedQuery, error) {
+	if err := validateSavedQuery(input); err != nil {
+		return nil, err
+	}
+
+	q := &model.SavedQuery{
+		UserID:   userID,
+		Name:     strings.TrimSpace(input.Name),
+		Template: strings.TrimSpace(input.Template),
+		Filters:  input.Filters,
+	}
+	if err := s.savedRepo.Create(ctx, q); err != nil {
+		return nil, err
+	}
+
+	q.Parameters = templateParameters(q.Template)
+	return q, nil
+}
+
+// List lists a user's saved queries
+func (s *SavedQueryService) List(ctx context.Context, userID string) ([]*model.SavedQuery, error) {
+	saved, err := s.savedRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range saved {
+		q.Parameters = templateParameters(q.Template)
+	}
+	return saved, nil
+}
+
+// Get gets a single saved query
+func (s *SavedQueryService) Get(ctx context.Context, userID, id string) (*model.SavedQuery, error) {
+	q, err := s.savedRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	q.Parameters = templateParameters(q.Template)
+	return q, nil
+}
+
+// Update updates a saved query; empty fields keep their current values
+func (s *SavedQueryService) Update(ctx context.Context, userID, id string, input SavedQueryInput) (*model.SavedQuery, error) {
+	q, err := s.savedRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(input.Name) == "" {
+		input.Name = q.Name
+	}
+	if strings.TrimSpace(input.Template) == "" {
+		input.Template = q.Template
+	}
+	if err := validateSavedQuery(input); err != nil {
+		return nil, err
+	}
+
+	q.Name = strings.TrimSpace(input.Name)
+	q.Template = strings.TrimSpace(input.Template)
+	if input.Filters != nil {
+		q.Filters = input.Filters
+	}
+
+	if err := s.savedRepo.Update(ctx, q); err != nil {
+		return nil, err
+	}
+
+	q.Parameters = templateParameters(q.Template)
+	return q, nil
+}
+
+// Delete deletes a saved query
+func (s *SavedQueryService) Delete(ctx context.Context, userID, id string) error {
+	return s.savedRepo.Delete(ctx, userID, id)
+}
+
+// Execute fills in the saved query's placeholders and runs it as a normal query
+func (s *SavedQueryService) Execute(ctx context.Context, userID, id string, req ExecuteSavedQueryRequest) (*QueryResponse, error) {
+	q, err := s.savedRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	question, err := fillTemplate(q.Template, req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ragService.Query(ctx, userID, QueryRequest{
+		Question:       question,
+		Filters:        q.Filters,
+		ConversationID: req.ConversationID,
+		Agent:          req.Agent,
+	})
+}
+
+// validateSavedQuery validates saved query input
+func validateSavedQuery(input SavedQueryInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(name) > 255 {
+		return fmt.Errorf("name must be at most 255 characters")
+	}
+	if strings.TrimSpace(input.Template) == "" {
+		return fmt.Errorf("template is required")
+	}
+	return nil
+}
+
+// templateParameters returns the distinct placeholder names in a template, in order of first use
+func templateParameters(template string) []string {
+	params := []string{}
+	seen := make(map[string]bool)
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			params = append(params, match[1])
+		}
+	}
+	return params
+}
+
+// fillTemplate substitutes every placeholder with its parameter value; all parameters are required
+func fillTemplate(template string, params map[string]string) (string, error) {
+	var missing []string
+	for _, name := range templateParameters(template) {
+		if strings.TrimSpace(params[name]) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing parameters: %s", strings.Join(missing, ", "))
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return strings.TrimSpace(params[placeholder[1:len(placeholder)-1]])
+	}), nil
+}
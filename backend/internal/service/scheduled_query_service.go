@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/notification"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Supported scheduled query frequencies
+const (
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+// EventScheduledQueryCompleted is the notification event sent with a scheduled query's answer
+const EventScheduledQueryCompleted = "scheduled_query.completed"
+
+// ScheduledQueryService manages standing questions and runs them on schedule
+type ScheduledQueryService struct {
+	scheduledRepo *repository.ScheduledQueryRepository
+	lockRepo      *repository.LockRepository
+	ragService    *RAGService
+	notifier      notification.Notifier
+}
+
+// NewScheduledQueryService creates a new scheduled query service
+func NewScheduledQueryService(
+	scheduledRepo *repository.ScheduledQueryRepository,
+	lockRepo *repository.LockRepository,
+	ragService *RAGService,
+	notifier notification.Notifier,
+) *ScheduledQueryService {
+	return &ScheduledQueryService{
+		scheduledRepo: scheduledRepo,
+		lockRepo:      lockRepo,
+		ragService:    ragService,
+		notifier:      notifier,
+	}
+}
+
+// ScheduledQueryInput represents the editable fields of a scheduled query
+type ScheduledQueryInput struct {
+	Question  string            `json:"question"`
+	Filters   map[string]string `json:"filters"`
+	Frequency string            `json:"frequency"`
+	Enabled   *bool             `json:"enabled"`
+}
+
+// Create creates a new scheduled query; the first run happens one period from now
+func (s *ScheduledQueryService) Create(ctx context.Context, userID string, input ScheduledQueryInput) (*model.ScheduledQuery, error) {
+	if err := validateScheduledQuery(input); err != nil {
+		return nil, err
+	}
+
+	enabled := true
+	if input.Enabled != nil {
+		enabled = *input.Enabled
+	}
+
+	sq := &model.ScheduledQuery{
+		UserID:    userID,
+		Question:  strings.TrimSpace(input.Question),
+		Filters:   input.Filters,
+		Frequency: input.Frequency,
+		Enabled:   enabled,
+		NextRunAt: nextRun(input.Frequency, time.Now()),
+	}
+
+	if err := s.scheduledRepo.Create(ctx, sq); err != nil {
+		return nil, err
+	}
+
+	return sq, nil
+}
+
+// List lists a user's scheduled queries
+func (s *ScheduledQueryService) List(ctx context.Context, userID string) ([]*model.ScheduledQuery, error) {
+	return s.scheduledRepo.ListByUserID(ctx, userID)
+}
+
+// Get gets a single scheduled query
+func (s *ScheduledQueryService) Get(ctx context.Context, userID, id string) (*model.ScheduledQuery, error) {
+	return s.scheduledRepo.GetByID(ctx, userID, id)
+}
+
+// Update updates a scheduled query; changing the frequency reschedules the next run
+func (s *ScheduledQueryService) Update(ctx context.Context, userID, id string, input ScheduledQueryInput) (*model.ScheduledQuery, error) {
+	sq, err := s.scheduledRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Question == "" {
+		input.Question = sq.Question
+	}
+	if input.Frequency == "" {
+		input.Frequency = sq.Frequency
+	}
+	if err := validateScheduledQuery(input); err != nil {
+		return nil, err
+	}
+
+	if input.Frequency != sq.Frequency {
+		sq.NextRunAt = nextRun(input.Frequency, time.Now())
+	}
+	sq.Question = strings.TrimSpace(input.Question)
+	sq.Frequency = input.Frequency
+	if input.Filters != nil {
+		sq.Filters = input.Filters
+	}
+	if input.Enabled != nil {
+		sq.Enabled = *input.Enabled
+	}
+
+	if err := s.scheduledRepo.Update(ctx, sq); err != nil {
+		return nil, err
+	}
+
+	return sq, nil
+}
+
+// Delete deletes a scheduled query
+func (s *ScheduledQueryService) Delete(ctx context.Context, userID, id string) error {
+	return s.scheduledRepo.Delete(ctx, userID, id)
+}
+
+// RunDue executes all scheduled queries that are due.
+// Only one replica runs them at a time; the others skip the tick.
+func (s *ScheduledQueryService) RunDue(ctx context.Context) error {
+	lock, err := s.lockRepo.TryAcquire(ctx, "scheduled_queries")
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		logger.Debug("Scheduled queries are running on another instance")
+		return nil
+	}
+	defer lock.Release()
+
+	now := time.Now()
+	due, err := s.scheduledRepo.ListDue(ctx, now, 50)
+	if err != nil {
+		return err
+	}
+
+	for _, sq := range due {
+		// Reschedule first so a failing query doesn't retry on every tick
+		if err := s.scheduledRepo.MarkRun(ctx, sq.ID, now, nextRun(sq.Frequency, now)); err != nil {
+			logger.Error("Failed to reschedule query", "scheduled_query_id", sq.ID, "error", err)
+			continue
+		}
+
+		// Query saves the result to query history
+		response, err := s.ragService.Query(ctx, sq.UserID, QueryRequest{
+			Question:   sq.Question,
+			Filters:    sq.Filters,
+			Standalone: true,
+		})
+		if err != nil {
+			logger.Error("Scheduled query failed",
+				"scheduled_query_id", sq.ID,
+				"user_id", sq.UserID,
+				"error", err,
+			)
+			continue
+		}
+
+		if err := s.notifier.Notify(ctx, notification.Notification{
+			UserID: sq.UserID,
+			Event:  EventScheduledQueryCompleted,
+			Title:  sq.Question,
+			Body:   response.Answer,
+			Data: map[string]interface{}{
+				"scheduled_query_id": sq.ID,
+				"sources":            response.Sources,
+			},
+		}); err != nil {
+			logger.Error("Failed to deliver scheduled query result",
+				"scheduled_query_id", sq.ID,
+				"error", err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// validateScheduledQuery validates scheduled query input
+func validateScheduledQuery(input ScheduledQueryInput) error {
+	if strings.TrimSpace(input.Question) == "" {
+		return fmt.Errorf("question is required")
+	}
+	if input.Frequency != FrequencyDaily && input.Frequency != FrequencyWeekly {
+		return fmt.Errorf("frequency must be %q or %q", FrequencyDaily, FrequencyWeekly)
+	}
+	return nil
+}
+
+// nextRun returns the next run time after from for the given frequency
+func nextRun(frequency string, from time.Time) time.Time {
+	if frequency == FrequencyWeekly {
+		return from.AddDate(0, 0, 7)
+	}
+	return from.AddDate(0, 0, 1)
+}
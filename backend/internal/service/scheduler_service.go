@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Misfire policies for runs missed while no scheduler was running
+const (
+	// MisfireRunOnce runs a missed schedule once, however many runs were missed
+	MisfireRunOnce = "run_once"
+	// MisfireSkip records the missed run and waits for the next scheduled time
+	MisfireSkip = "skip"
+)
+
+// Schedule run statuses
+const (
+	ScheduleRunRunning   = "running"
+	ScheduleRunSucceeded = "succeeded"
+	ScheduleRunFailed    = "failed"
+	ScheduleRunMissed    = "missed"
+)
+
+// Scheduler timing
+const (
+	schedulerPollInterval = 15 * time.Second
+	// schedulerConcurrency caps the schedules one instance runs at once
+	schedulerConcurrency = 4
+	// misfireGrace is how late a run may start before it counts as missed
+	misfireGrace = time.Minute
+)
+
+// ScheduledJob is the work performed when a schedule fires
+type ScheduledJob func(ctx context.Context) error
+
+// SchedulerService runs background jobs on persisted cron schedules.
+// Each schedule runs on one replica at a time, independently of the others.
+type SchedulerService struct {
+	scheduleRepo *repository.ScheduleRepository
+	lockRepo     *repository.LockRepository
+
+	mu   sync.RWMutex
+	jobs map[string]ScheduledJob
+
+	// running tracks the runs in progress, each holding one of the slots
+	running sync.WaitGroup
+	slots   chan struct{}
+}
+
+// NewSchedulerService creates a new scheduler service
+func NewSchedulerService(scheduleRepo *repository.ScheduleRepository, lockRepo *repository.LockRepository) *SchedulerService {
+	return &SchedulerService{
+		scheduleRepo: scheduleRepo,
+		lockRepo:     lockRepo,
+		jobs:         make(map[string]ScheduledJob),
+		slots:        make(chan struct{}, schedulerConcurrency),
+	}
+}
+
+// Register binds a job to a named schedule, creating the schedule with the given
+// cron expression and timezone unless it is already stored
+func (s *SchedulerService) Register(ctx context.Context, name, cronExpr, timezone string, job ScheduledJob) error {
+	next, err := nextCronRun(cronExpr, timezone, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", name, err)
+	}
+
+	schedule := &model.Schedule{
+		Name:          name,
+		CronExpr:      cronExpr,
+		Timezone:      timezone,
+		MisfirePolicy: MisfireRunOnce,
+		Enabled:       true,
+		NextRunAt:     next,
+	}
+	if err := s.scheduleRepo.Ensure(ctx, schedule); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.jobs[name] = job
+	s.mu.Unlock()
+
+	logger.Info("Schedule registered",
+		"name", name,
+		"cron", schedule.CronExpr,
+		"timezone", schedule.Timezone,
+		"next_run_at", schedule.NextRunAt,
+	)
+	return nil
+}
+
+// Start runs due schedules until the context is cancelled. The returned channel is closed once
+// the scheduler has stopped and the runs in progress have finished.
+func (s *SchedulerService) Start(ctx context.Context) <-chan struct{} {
+	logger.Info("Scheduler started", "poll_interval", schedulerPollInterval.String())
+
+	done := make(chan struct{})
+	ticker := time.NewTicker(schedulerPollInterval)
+	go func() {
+		defer close(done)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.RunDue(ctx); err != nil {
+					logger.Error("Failed to run schedules", "error", err)
+				}
+			case <-ctx.Done():
+				s.running.Wait()
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// RunDue starts every schedule that is due, or skips it per its misfire policy. Schedules run
+// in the background, each under its own lock, so a slow job doesn't hold up the others. Up to
+// schedulerConcurrency run at once; schedules beyond that stay due for a later poll.
+func (s *SchedulerService) RunDue(ctx context.Context) error {
+	due, err := s.scheduleRepo.ListDue(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range due {
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			return nil
+		}
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			defer func() { <-s.slots }()
+			s.runLocked(ctx, schedule)
+		}()
+	}
+
+	return nil
+}
+
+// runLocked runs a due schedule unless another replica, or an earlier poll, is running it
+func (s *SchedulerService) runLocked(ctx context.Context, schedule *model.Schedule) {
+	lock, err := s.lockRepo.TryAcquire(ctx, "schedule:"+schedule.Name)
+	if err != nil {
+		logger.Error("Failed to lock schedule", "name", schedule.Name, "error", err)
+		return
+	}
+	if lock == nil {
+		return
+	}
+	defer lock.Release()
+
+	// Another replica may have run it between listing and locking
+	current, err := s.scheduleRepo.GetByID(ctx, schedule.ID)
+	if err != nil {
+		logger.Error("Failed to reload schedule", "name", schedule.Name, "error", err)
+		return
+	}
+	if !current.Enabled || current.NextRunAt.After(time.Now()) {
+		return
+	}
+
+	s.runSchedule(ctx, current)
+}
+
+// runSchedule executes one due schedule and records the run
+func (s *SchedulerService) runSchedule(ctx context.Context, schedule *model.Schedule) {
+	s.mu.RLock()
+	job, ok := s.jobs[schedule.Name]
+	s.mu.RUnlock()
+	if !ok {
+		// Registered by a different build; leave it for an instance that knows the job
+		return
+	}
+
+	now := time.Now()
+	next, err := nextCronRun(schedule.CronExpr, schedule.Timezone, now)
+	if err != nil {
+		logger.Error("Invalid schedule", "name", schedule.Name, "error", err)
+		return
+	}
+
+	run := &model.ScheduleRun{
+		ScheduleID:   schedule.ID,
+		ScheduledFor: schedule.NextRunAt,
+		StartedAt:    now,
+		Status:       ScheduleRunRunning,
+	}
+
+	if now.Sub(schedule.NextRunAt) > misfireGrace && schedule.MisfirePolicy == MisfireSkip {
+		logger.Warn("Skipping missed schedule run", "name", schedule.Name, "scheduled_for", schedule.NextRunAt)
+		run.Status = ScheduleRunMissed
+		run.FinishedAt = &now
+		if err := s.scheduleRepo.CreateRun(ctx, run); err != nil {
+			logger.Error("Failed to record missed run", "name", schedule.Name, "error", err)
+		}
+		if err := s.scheduleRepo.Reschedule(ctx, schedule.ID, nil, next); err != nil {
+			logger.Error("Failed to reschedule", "name", schedule.Name, "error", err)
+		}
+		return
+	}
+
+	// Reschedule first so a failing job doesn't retry on every poll
+	if err := s.scheduleRepo.Reschedule(ctx, schedule.ID, &now, next); err != nil {
+		logger.Error("Failed to reschedule", "name", schedule.Name, "error", err)
+		return
+	}
+	if err := s.scheduleRepo.CreateRun(ctx, run); err != nil {
+		logger.Error("Failed to record schedule run", "name", schedule.Name, "error", err)
+	}
+
+	jobErr := job(ctx)
+
+	// Record the result even when shutdown cancelled the job
+	ctx = context.WithoutCancel(ctx)
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Status = ScheduleRunSucceeded
+	if jobErr != nil {
+		run.Status = ScheduleRunFailed
+		run.Error = jobErr.Error()
+		logger.Error("Scheduled job failed", "name", schedule.Name, "error", jobErr)
+	}
+
+	if run.ID != "" {
+		if err := s.scheduleRepo.FinishRun(ctx, run); err != nil {
+			logger.Error("Failed to record schedule run result", "name", schedule.Name, "error", err)
+		}
+	}
+}
+
+// ScheduleInput represents the editable fields of a schedule
+type ScheduleInput struct {
+	CronExpr      string `json:"cron_expr"`
+	Timezone      string `json:"timezone"`
+	MisfirePolicy string `json:"misfire_policy"`
+	Enabled       *bool  `json:"enabled"`
+}
+
+// List lists all schedules
+func (s *SchedulerService) List(ctx context.Context) ([]*model.Schedule, error) {
+	return s.scheduleRepo.List(ctx)
+}
+
+// ListRuns lists a schedule's most recent runs
+func (s *SchedulerService) ListRuns(ctx context.Context, id string, limit int) ([]*model.ScheduleRun, error) {
+	if _, err := s.scheduleRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.scheduleRepo.ListRuns(ctx, id, limit)
+}
+
+// Update updates a schedule; changing the cron expression or timezone recomputes the next run
+func (s *SchedulerService) Update(ctx context.Context, id string, input ScheduleInput) (*model.Schedule, error) {
+	schedule, err := s.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cronExpr := strings.TrimSpace(input.CronExpr)
+	if cronExpr == "" {
+		cronExpr = schedule.CronExpr
+	}
+	timezone := strings.TrimSpace(input.Timezone)
+	if timezone == "" {
+		timezone = schedule.Timezone
+	}
+
+	if cronExpr != schedule.CronExpr || timezone != schedule.Timezone {
+		next, err := nextCronRun(cronExpr, timezone, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		schedule.CronExpr = cronExpr
+		schedule.Timezone = timezone
+		schedule.NextRunAt = next
+	}
+
+	if input.MisfirePolicy != "" {
+		if input.MisfirePolicy != MisfireRunOnce && input.MisfirePolicy != MisfireSkip {
+			return nil, fmt.Errorf("misfire_policy must be %q or %q", MisfireRunOnce, MisfireSkip)
+		}
+		schedule.MisfirePolicy = input.MisfirePolicy
+	}
+	if input.Enabled != nil {
+		schedule.Enabled = *input.Enabled
+	}
+
+	if err := s.scheduleRepo.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// nextCronRun returns the first time after from that matches the cron expression in the timezone
+func nextCronRun(cronExpr, timezone string, from time.Time) (time.Time, error) {
+	spec, err := cron.ParseStandard(cronExpr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timezone: %s", timezone)
+	}
+
+	return spec.Next(from.In(loc)), nil
+}
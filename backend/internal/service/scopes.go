@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Access scopes carried by JWTs and API keys
+const (
+	ScopeDocumentsRead  = "documents:read"
+	ScopeDocumentsWrite = "documents:write"
+	ScopeQueryExecute   = "query:execute"
+	// ScopeWidgetAsk lets a key answer questions through the embeddable widget and nothing else
+	ScopeWidgetAsk = "widget:ask"
+	// ScopeAdmin grants every scope, including admin-only endpoints
+	ScopeAdmin = "admin:*"
+)
+
+// UserScopes are granted to a regular user signing in with a password
+var UserScopes = []string{ScopeDocumentsRead, ScopeDocumentsWrite, ScopeQueryExecute, ScopeWidgetAsk}
+
+// knownScopes lists every scope that can be granted
+var knownScopes = map[string]bool{
+	ScopeDocumentsRead:  true,
+	ScopeDocumentsWrite: true,
+	ScopeQueryExecute:   true,
+	ScopeWidgetAsk:      true,
+	ScopeAdmin:          true,
+}
+
+// HasScope reports whether the granted scopes satisfy the required scope.
+// A "resource:*" scope satisfies every scope of that resource and admin:* satisfies all.
+func HasScope(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scope == required || scope == ScopeAdmin {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(scope, ":*"); ok && strings.HasPrefix(required, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// narrowScopes validates requested scopes and ensures they don't exceed the granted ones.
+// An empty request keeps all granted scopes.
+func narrowScopes(granted, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return granted, nil
+	}
+
+	scopes := make([]string, 0, len(requested))
+	seen := make(map[string]bool, len(requested))
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if !knownScopes[scope] {
+			return nil, fmt.Errorf("unknown scope: %s", scope)
+		}
+		if !HasScope(granted, scope) {
+			return nil, fmt.Errorf("scope not permitted: %s", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MaxSearchTopK caps the number of chunks a retrieval-only search may return
+const MaxSearchTopK = 50
+
+// SearchRequest represents a retrieval-only search
+type SearchRequest struct {
+	Query string `json:"query"`
+	// Filters restricts retrieval to chunks whose metadata matches exactly
+	Filters map[string]string `json:"filters,omitempty"`
+	// TopK is the number of chunks to return (defaults to the query retrieval limit)
+	TopK int `json:"top_k,omitempty"`
+	// Diversity (0-1) applies Maximal Marginal Relevance as in Query
+	Diversity float64 `json:"diversity,omitempty"`
+	// ClauseType restricts results to contract clauses of this type, as in Query
+	ClauseType string `json:"clause_type,omitempty"`
+	// Exclude and ExcludeDocumentIDs omit content as in Query, including "-key:value" operators in the query
+	Exclude            map[string][]string `json:"exclude,omitempty"`
+	ExcludeDocumentIDs []string            `json:"exclude_document_ids,omitempty"`
+	// Mode selects retrieval as in Query ("chunks" or "documents")
+	Mode string `json:"mode,omitempty"`
+	// Warranty restricts results to active or expired warranties, as in Query
+	Warranty string `json:"warranty,omitempty"`
+	// Pipeline overrides the rewrite, retrieve and rerank stages as in Query
+	Pipeline map[string]string `json:"pipeline,omitempty"`
+}
+
+// SearchResult is a retrieved chunk with its score and metadata
+type SearchResult struct {
+	ID         string                 `json:"id"`
+	DocumentID string                 `json:"document_id"`
+	Score      float32                `json:"score"`
+	Content    string                 `json:"content"`
+	Metadata   map[string]interface{} `json:"metadata"`
+}
+
+// SearchResponse represents the chunks retrieved for a search
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+	// Degraded is set when results come from keyword search because embeddings failed
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// Search returns the top-k chunks for a query without calling the LLM.
+// Ranking matches Query (pinned boost, optional MMR) so it can be used to debug retrieval.
+func (s *RAGService) Search(ctx context.Context, userID string, req SearchRequest) (*SearchResponse, error) {
+	query, exclude := parseExclusions(req.Query)
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if req.TopK < 0 || req.TopK > MaxSearchTopK {
+		return nil, fmt.Errorf("top_k must be between 1 and %d", MaxSearchTopK)
+	}
+	if req.Diversity < 0 || req.Diversity > 1 {
+		return nil, fmt.Errorf("diversity must be between 0 and 1")
+	}
+
+	if err := validateQueryMode(req.Mode); err != nil {
+		return nil, err
+	}
+
+	filters, err := withClauseType(req.Filters, req.ClauseType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateWarrantyStatus(req.Warranty); err != nil {
+		return nil, err
+	}
+
+	pipeline, err := s.resolvePipeline(req.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &PipelineState{
+		UserID:   userID,
+		Question: query,
+		Retrieval: RetrievalOptions{
+			Filter:        withWarrantyStatus(buildSearchFilter(filters, mergeExclusions(req.Exclude, exclude), req.ExcludeDocumentIDs), req.Warranty, time.Now()),
+			Diversity:     req.Diversity,
+			DocumentFirst: req.Mode == QueryModeDocuments,
+		},
+		TopK: req.TopK,
+	}
+
+	// Only the retrieval half of the pipeline runs; there is no answer to compress context for
+	if err := s.runStages(ctx, pipeline, state, StageRewrite, StageRetrieve, StageRerank); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(state.Results))
+	for _, point := range state.Results {
+		result := SearchResult{
+			ID:       point.ID,
+			Score:    point.Score,
+			Metadata: make(map[string]interface{}, len(point.Payload)),
+		}
+		for key, value := range point.Payload {
+			switch key {
+			case "content":
+				result.Content, _ = value.(string)
+			case "document_id":
+				result.DocumentID, _ = value.(string)
+			default:
+				result.Metadata[key] = value
+			}
+		}
+		results = append(results, result)
+	}
+
+	return &SearchResponse{Results: results, Degraded: state.Degraded}, nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/notification"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/parser"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// EventSecretsFound is the notification event sent when a new document contains credentials
+const EventSecretsFound = "document.secrets_found"
+
+// Secret scan modes
+const (
+	// SecretScanRedact replaces secrets in a document's text before it is chunked and embedded
+	SecretScanRedact = "redact"
+	// SecretScanFlag records and reports secrets but embeds the text unchanged
+	SecretScanFlag = "flag"
+	// SecretScanOff disables scanning
+	SecretScanOff = "off"
+)
+
+// secretRule detects one kind of credential, in the style of gitleaks rules
+type secretRule struct {
+	id      string
+	name    string
+	pattern *regexp.Regexp
+	// group is the submatch holding the secret itself; 0 is the whole match
+	group int
+}
+
+// secretRules are applied in order, so specific token formats are tried before the generic
+// assignment rules that would also match them. Values already replaced by a marker are skipped.
+var secretRules = []secretRule{
+	{"private_key", "private key", regexp.MustCompile(`-----BEGIN[ A-Z0-9_-]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END[ A-Z0-9_-]*PRIVATE KEY(?: BLOCK)?-----`), 0},
+	{"aws_access_key", "AWS access key", regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`), 1},
+	{"aws_secret_key", "AWS secret key", regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`), 1},
+	{"github_token", "GitHub token", regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{82})\b`), 1},
+	{"gitlab_token", "GitLab token", regexp.MustCompile(`\b(glpat-[A-Za-z0-9_-]{20,})`), 1},
+	{"anthropic_api_key", "Anthropic API key", regexp.MustCompile(`\b(sk-ant-[A-Za-z0-9_-]{32,})`), 1},
+	{"openai_api_key", "OpenAI API key", regexp.MustCompile(`\b(sk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_-]{20,})`), 1},
+	{"stripe_key", "Stripe key", regexp.MustCompile(`\b((?:sk|rk)_(?:live|test)_[0-9A-Za-z]{24,})\b`), 1},
+	{"slack_token", "Slack token", regexp.MustCompile(`\b(xox[abeprs]-[0-9A-Za-z-]{10,})`), 1},
+	{"slack_webhook", "Slack webhook", regexp.MustCompile(`https://hooks\.slack\.com/(?:services|workflows)/[A-Za-z0-9/_-]+`), 0},
+	{"google_api_key", "Google API key", regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})`), 1},
+	{"sendgrid_api_key", "SendGrid API key", regexp.MustCompile(`\b(SG\.[0-9A-Za-z_-]{22}\.[0-9A-Za-z_-]{43})`), 1},
+	{"jwt", "JSON web token", regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`), 1},
+	{"url_password", "password in a URL", regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.-]*://[^/\s:@]+:([^@\s/]{3,})@`), 1},
+	{"password", "password or secret", regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|passphrase|client_?secret|secret_?key|api_?key|access_?token|auth_?token)\b["']?\s*[:=]\s*["']?([^\s"',;\[][^\s"',;]{7,})`), 1},
+}
+
+// SecretScanner finds credentials in documents before they are embedded, since embedding sends
+// the text to a third-party API and stores it in the vector database. Depending on the mode,
+// secrets are redacted from the text or only reported; either way the user is notified.
+type SecretScanner struct {
+	mode        string
+	findingRepo *repository.SecretFindingRepository
+	notifier    notification.Notifier
+}
+
+// NewSecretScanner creates a secret scanner in the given mode: redact, flag or off
+func NewSecretScanner(mode string, findingRepo *repository.SecretFindingRepository, notifier notification.Notifier) (*SecretScanner, error) {
+	switch mode {
+	case SecretScanRedact, SecretScanFlag, SecretScanOff:
+	default:
+		return nil, fmt.Errorf("unknown secret scan mode: %s (valid options: redact, flag, off)", mode)
+	}
+
+	return &SecretScanner{
+		mode:        mode,
+		findingRepo: findingRepo,
+		notifier:    notifier,
+	}, nil
+}
+
+// ScanPage finds the secrets in a page of a document extracted page by page. It returns the page
+// to chunk and embed, with the secrets replaced by a [REDACTED:rule] marker in redact mode, and
+// the findings to report once the document is stored. line is the number of lines before the
+// page in the pages' joined text, by which findings are numbered.
+func (s *SecretScanner) ScanPage(page parser.Page, line int) (parser.Page, []*model.SecretFinding) {
+	if s == nil || s.mode == SecretScanOff {
+		return page, nil
+	}
+
+	redacted, findings := scanSecrets(page.Text)
+	for _, finding := range findings {
+		finding.Line += line
+		finding.Redacted = s.mode == SecretScanRedact
+	}
+	if s.mode == SecretScanRedact {
+		page.Text = redacted
+	}
+	return page, findings
+}
+
+// pageLines returns the number of lines a page takes up in the pages' joined text
+func pageLines(page parser.Page) int {
+	return strings.Count(page.Text+parser.PageSeparator, "\n")
+}
+
+// scanSecrets applies every rule to text and returns it with each secret replaced by a marker.
+// Each rule runs on the previous rules' output, so a secret is reported only once.
+func scanSecrets(text string) (string, []*model.SecretFinding) {
+	var findings []*model.SecretFinding
+	for _, rule := range secretRules {
+		matches := rule.pattern.FindAllStringSubmatchIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, match := range matches {
+			start, end := match[2*rule.group], match[2*rule.group+1]
+			if start < 0 {
+				continue
+			}
+			findings = append(findings, &model.SecretFinding{
+				Rule:    rule.id,
+				Preview: secretPreview(text[start:end]),
+				Line:    strings.Count(text[:start], "\n") + 1,
+			})
+			b.WriteString(text[last:start])
+			b.WriteString("[REDACTED:" + rule.id + "]")
+			last = end
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+	return text, findings
+}
+
+// secretPreview keeps the first few characters of a secret, which usually identify its kind
+// without revealing it
+func secretPreview(secret string) string {
+	runes := []rune(secret)
+	if len(runes) <= 8 {
+		return "****"
+	}
+	return string(runes[:4]) + "****"
+}
+
+// Report records the secrets found in a new document and notifies the user. Failures are only
+// logged so reporting never fails ingestion.
+func (s *SecretScanner) Report(ctx context.Context, doc *model.Document, findings []*model.SecretFinding) {
+	if s == nil || len(findings) == 0 {
+		return
+	}
+
+	for _, finding := range findings {
+		finding.UserID = doc.UserID
+		finding.DocumentID = doc.ID
+		finding.Filename = doc.Filename
+	}
+	if err := s.findingRepo.CreateMany(ctx, findings); err != nil {
+		logger.Error("Failed to record secret findings", "document_id", doc.ID, "error", err)
+	}
+
+	kinds := secretKinds(findings)
+	logger.Warn("Secrets found in document", "document_id", doc.ID, "user_id", doc.UserID, "count", len(findings), "rules", kinds, "redacted", s.mode == SecretScanRedact)
+
+	var body string
+	if s.mode == SecretScanRedact {
+		body = fmt.Sprintf("%s contains %d possible secrets (%s). They were redacted before the document was embedded, "+
+			"but the original file is still stored. Rotate them if they are still in use.", doc.Filename, len(findings), strings.Join(kinds, ", "))
+	} else {
+		body = fmt.Sprintf("%s contains %d possible secrets (%s), which were embedded unchanged and sent to the embedding "+
+			"provider. Rotate them if they are still in use.", doc.Filename, len(findings), strings.Join(kinds, ", "))
+	}
+
+	err := s.notifier.Notify(ctx, notification.Notification{
+		UserID: doc.UserID,
+		Event:  EventSecretsFound,
+		Title:  "Secrets found in " + doc.Filename,
+		Body:   body,
+		Data: map[string]interface{}{
+			"document_id": doc.ID,
+			"filename":    doc.Filename,
+			"count":       len(findings),
+			"redacted":    s.mode == SecretScanRedact,
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to send secrets notification", "user_id", doc.UserID, "document_id", doc.ID, "error", err)
+	}
+}
+
+// ListSecretFindings lists the secrets found in the user's documents, newest first
+func (s *DocumentService) ListSecretFindings(ctx context.Context, userID string) ([]*model.SecretFinding, error) {
+	return s.secrets.findingRepo.ListByUserID(ctx, userID)
+}
+
+// secretKinds names the distinct kinds of secret found, in rule order
+func secretKinds(findings []*model.SecretFinding) []string {
+	found := make(map[string]bool, len(findings))
+	for _, finding := range findings {
+		found[finding.Rule] = true
+	}
+
+	var kinds []string
+	for _, rule := range secretRules {
+		if found[rule.id] {
+			kinds = append(kinds, rule.name)
+		}
+	}
+	return kinds
+}
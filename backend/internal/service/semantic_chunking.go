@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/utils"
+)
+
+// Chunking strategies: how a document's text is split into chunks
+const (
+	// ChunkingFixed packs sentences into chunks of up to a chunk size of tokens with overlap
+	ChunkingFixed = "fixed"
+	// ChunkingSemantic embeds each sentence and splits where adjacent sentences are least similar
+	ChunkingSemantic = "semantic"
+)
+
+// Chunk sizes in tokens; chunkSize is about the 500 characters chunks held when they were
+// measured in characters
+const (
+	chunkSize    = 128
+	chunkOverlap = 16
+	// minChunkSize and maxChunkSize bound a document's own chunk size
+	minChunkSize = 32
+	maxChunkSize = 1024
+	// semanticMinChunk keeps semantic chunks from being cut off after a sentence or two
+	semanticMinChunk = 25
+)
+
+// semanticBreakPercentile is the share of a document's adjacent sentence pairs, least similar
+// first, that chunks may be split between
+const semanticBreakPercentile = 0.2
+
+// ValidateChunking checks a chunking strategy name; empty selects the configured default
+func ValidateChunking(strategy string) error {
+	switch strategy {
+	case "", ChunkingFixed, ChunkingSemantic:
+		return nil
+	}
+	return fmt.Errorf("unknown chunking strategy %q (expected %s or %s)", strategy, ChunkingFixed, ChunkingSemantic)
+}
+
+// ChunkingOptions override how a document is chunked; unset fields take the defaults
+type ChunkingOptions struct {
+	// Strategy is ChunkingFixed or ChunkingSemantic; empty selects the configured default
+	Strategy string `json:"strategy,omitempty"`
+	// ChunkSize is the most tokens in a chunk; 0 selects chunkSize
+	ChunkSize int `json:"chunk_size,omitempty"`
+	// Overlap is the tokens of sentences a chunk repeats from the end of the one before; nil
+	// selects an eighth of the chunk size
+	Overlap *int `json:"overlap,omitempty"`
+}
+
+// Validate checks the options' strategy and bounds
+func (o ChunkingOptions) Validate() error {
+	if err := ValidateChunking(o.Strategy); err != nil {
+		return err
+	}
+	if o.ChunkSize != 0 && (o.ChunkSize < minChunkSize || o.ChunkSize > maxChunkSize) {
+		return fmt.Errorf("chunk_size must be between %d and %d tokens", minChunkSize, maxChunkSize)
+	}
+	if o.Overlap != nil {
+		size := o.ChunkSize
+		if size == 0 {
+			size = chunkSize
+		}
+		if *o.Overlap < 0 || *o.Overlap > size/2 {
+			return fmt.Errorf("overlap must be between 0 and %d tokens, half the chunk size", size/2)
+		}
+	}
+	return nil
+}
+
+// chunkingParams are a document's chunking options with the defaults filled in
+type chunkingParams struct {
+	strategy string
+	// size and overlap are in tokens
+	size    int
+	overlap int
+}
+
+// chunkingFor resolves the chunking parameters of a file. Without options, a file indexed again
+// under the same name keeps the parameters its previous version was chunked with.
+func (s *DocumentService) chunkingFor(ctx context.Context, userID, filename string, options ChunkingOptions) (chunkingParams, error) {
+	if err := options.Validate(); err != nil {
+		return chunkingParams{}, err
+	}
+	if options == (ChunkingOptions{}) {
+		previous, err := s.documentRepo.GetLatestByFilename(ctx, userID, filepath.Base(filename))
+		if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+			return chunkingParams{}, err
+		}
+		if previous != nil && previous.ChunkSize != 0 {
+			return chunkingParams{
+				strategy: previous.ChunkingStrategy,
+				size:     previous.ChunkSize,
+				overlap:  previous.ChunkOverlap,
+			}, nil
+		}
+	}
+
+	params := chunkingParams{strategy: options.Strategy, size: options.ChunkSize}
+	if params.strategy == "" {
+		params.strategy = s.chunking
+	}
+	if params.size == 0 {
+		params.size = chunkSize
+	}
+	if options.Overlap != nil {
+		params.overlap = *options.Overlap
+	} else {
+		params.overlap = params.size * chunkOverlap / chunkSize
+	}
+	return params, nil
+}
+
+// record stores the parameters on the document chunked with them
+func (p chunkingParams) record(doc *model.Document) {
+	doc.ChunkingStrategy = p.strategy
+	doc.ChunkSize = p.size
+	doc.ChunkOverlap = p.overlap
+}
+
+// splitter splits a section of text into chunk contents, each a substring of the text
+type splitter func(text string) ([]string, error)
+
+// fixedSplitter returns the fixed size splitter of the parameters
+func fixedSplitter(params chunkingParams) splitter {
+	return func(text string) ([]string, error) {
+		return utils.ChunkText(text, params.size, params.overlap), nil
+	}
+}
+
+// splitterFor returns the splitter of the chunking parameters. The semantic splitter embeds
+// sentences with the user's model and records the tokens as chunking usage of the file.
+func (s *DocumentService) splitterFor(ctx context.Context, userID, filename string, params chunkingParams) (splitter, error) {
+	fixedSplit := fixedSplitter(params)
+	if params.strategy != ChunkingSemantic {
+		return fixedSplit, nil
+	}
+
+	provider, err := s.embeddings.ForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return func(text string) ([]string, error) {
+		units := textUnits(text, params.size)
+		if len(units) < 3 {
+			return fixedSplit(text)
+		}
+
+		sentences := make([]string, len(units))
+		for i, unit := range units {
+			sentences[i] = text[unit.start:unit.end]
+		}
+		embedCtx, tracker := withUsageTracker(ctx)
+		vectors, err := provider.GenerateEmbeddings(embedCtx, sentences, EmbeddingDocument)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed sentences for semantic chunking: %w", err)
+		}
+		recordEmbeddingUsage(ctx, s.documentRepo, tracker, model.EmbeddingUsage{
+			UserID:   userID,
+			Filename: filename,
+			Source:   EmbeddingSourceChunking,
+			Model:    provider.Model(),
+		})
+		if len(vectors) != len(units) {
+			return nil, fmt.Errorf("expected %d sentence embeddings, got %d", len(units), len(vectors))
+		}
+
+		similarities := make([]float64, len(units)-1)
+		for i := range similarities {
+			similarities[i] = cosineSimilarity(vectors[i], vectors[i+1])
+		}
+		return semanticChunks(text, units, similarities, params.size), nil
+	}, nil
+}
+
+// textUnit is a span of text that is never split by the semantic splitter: a sentence, a table,
+// or a piece of an overlong sentence
+type textUnit struct {
+	start, end int
+}
+
+var (
+	// paragraphBreak separates paragraphs
+	paragraphBreak = regexp.MustCompile(`\n\s*\n`)
+	// sentenceEnd matches the punctuation and space ending a sentence
+	sentenceEnd = regexp.MustCompile(`[.!?]["')\]]?\s+`)
+)
+
+// textUnits splits text into sentences, keeping each table (a paragraph whose lines all hold "|"
+// or tab separated cells) whole. Units longer than size tokens are split into pieces of that size.
+func textUnits(text string, size int) []textUnit {
+	var units []textUnit
+	add := func(start, end int) {
+		for start < end && isSpace(text[start]) {
+			start++
+		}
+		for end > start && isSpace(text[end-1]) {
+			end--
+		}
+		if start == end {
+			return
+		}
+		if utils.CountTokens(text[start:end]) <= size {
+			units = append(units, textUnit{start, end})
+			return
+		}
+		// Pieces without overlap follow each other, so each is found after the previous one
+		offset := start
+		for _, piece := range utils.ChunkText(text[start:end], size, 0) {
+			if i := strings.Index(text[offset:end], piece); i >= 0 {
+				units = append(units, textUnit{offset + i, offset + i + len(piece)})
+				offset += i + len(piece)
+			}
+		}
+	}
+
+	paragraphStart := 0
+	breaks := append(paragraphBreak.FindAllStringIndex(text, -1), []int{len(text), len(text)})
+	for _, brk := range breaks {
+		paragraph := text[paragraphStart:brk[0]]
+		if isTable(paragraph) {
+			add(paragraphStart, brk[0])
+		} else {
+			sentenceStart := paragraphStart
+			for _, end := range sentenceEnd.FindAllStringIndex(paragraph, -1) {
+				add(sentenceStart, paragraphStart+end[1])
+				sentenceStart = paragraphStart + end[1]
+			}
+			add(sentenceStart, brk[0])
+		}
+		paragraphStart = brk[1]
+	}
+	return units
+}
+
+// isTable reports whether every line of a multi-line paragraph holds cells separated by "|" or
+// tabs
+func isTable(paragraph string) bool {
+	lines := strings.Split(strings.TrimSpace(paragraph), "\n")
+	if len(lines) < 2 {
+		return false
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "|") && !strings.Contains(line, "\t") {
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
+}
+
+// semanticChunks packs consecutive units into chunks of at most size tokens, ending a chunk of at
+// least semanticMinChunk tokens (a quarter of smaller chunks) where the similarity to the next
+// unit is among the document's lowest
+func semanticChunks(text string, units []textUnit, similarities []float64, size int) []string {
+	minChunk := min(semanticMinChunk, size/4)
+
+	sorted := append([]float64(nil), similarities...)
+	sort.Float64s(sorted)
+	threshold := sorted[int(float64(len(sorted)-1)*semanticBreakPercentile)]
+
+	tokens := make([]int, len(units))
+	for i, unit := range units {
+		tokens[i] = utils.CountTokens(text[unit.start:unit.end])
+	}
+
+	var chunks []string
+	start, length := 0, tokens[0]
+	for i := 1; i <= len(units); i++ {
+		if i < len(units) {
+			fits := length+tokens[i] <= size
+			topicShift := similarities[i-1] <= threshold && length >= minChunk
+			if fits && !topicShift {
+				length += tokens[i]
+				continue
+			}
+		}
+		chunks = append(chunks, text[units[start].start:units[i-1].end])
+		if i < len(units) {
+			start, length = i, tokens[i]
+		}
+	}
+	return chunks
+}
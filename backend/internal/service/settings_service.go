@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/queue"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// maxSystemPromptChars caps the persona so it can't crowd out retrieved context
+const maxSystemPromptChars = 4000
+
+// SettingsService manages per-user assistant settings
+type SettingsService struct {
+	settingsRepo *repository.SettingsRepository
+	embeddings   *EmbeddingProviders
+	jobs         queue.Queue
+}
+
+// NewSettingsService creates a new settings service. Changing the embedding model enqueues a
+// re-embedding of the user's documents on jobs.
+func NewSettingsService(settingsRepo *repository.SettingsRepository, embeddings *EmbeddingProviders, jobs queue.Queue) *SettingsService {
+	return &SettingsService{
+		settingsRepo: settingsRepo,
+		embeddings:   embeddings,
+		jobs:         jobs,
+	}
+}
+
+// UserSettingsInput represents the editable user settings
+type UserSettingsInput struct {
+	SystemPrompt string `json:"system_prompt"`
+	Language     string `json:"language"`
+	// EmbeddingModel switches the user's documents to another embedding model; nil keeps the
+	// current one and empty selects the default
+	EmbeddingModel *string `json:"embedding_model"`
+}
+
+// Get returns a user's settings
+func (s *SettingsService) Get(ctx context.Context, userID string) (*model.UserSettings, error) {
+	return s.settingsRepo.Get(ctx, userID)
+}
+
+// Update replaces a user's settings
+func (s *SettingsService) Update(ctx context.Context, userID string, input UserSettingsInput) (*model.UserSettings, error) {
+	settings := &model.UserSettings{
+		UserID:       userID,
+		SystemPrompt: strings.TrimSpace(input.SystemPrompt),
+		Language:     strings.TrimSpace(input.Language),
+	}
+
+	if len(settings.SystemPrompt) > maxSystemPromptChars {
+		return nil, fmt.Errorf("system_prompt must be at most %d characters", maxSystemPromptChars)
+	}
+	if len(settings.Language) > 50 {
+		return nil, fmt.Errorf("language must be at most 50 characters")
+	}
+
+	current, err := s.settingsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.EmbeddingModel = current.EmbeddingModel
+	if input.EmbeddingModel != nil {
+		settings.EmbeddingModel = strings.TrimSpace(*input.EmbeddingModel)
+		if _, err := s.embeddings.Model(settings.EmbeddingModel); err != nil {
+			return nil, fmt.Errorf("invalid embedding_model: %w", err)
+		}
+	}
+
+	if err := s.settingsRepo.Upsert(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	// Searches keep using the active model until the user's documents are re-embedded
+	if settings.EmbeddingModel != settings.ActiveEmbeddingModel && settings.EmbeddingModel != current.EmbeddingModel {
+		if err := s.jobs.Enqueue(ctx, JobReembedUser, ReembedUserJob{UserID: userID}); err != nil {
+			return nil, fmt.Errorf("failed to queue re-embedding: %w", err)
+		}
+	}
+
+	return settings, nil
+}
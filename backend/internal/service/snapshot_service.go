@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// snapshotRetention is how long automatic snapshots are kept
+const snapshotRetention = 90 * 24 * time.Hour
+
+// SnapshotCurrent names the knowledge base as it is now in place of a snapshot ID
+const SnapshotCurrent = "current"
+
+// SnapshotService records snapshots of users' knowledge bases and compares them, so users can
+// audit how their knowledge base evolved
+type SnapshotService struct {
+	snapshotRepo *repository.SnapshotRepository
+	documentRepo *repository.DocumentRepository
+	vectorRepo   *repository.VectorRepository
+}
+
+// NewSnapshotService creates a new snapshot service
+func NewSnapshotService(
+	snapshotRepo *repository.SnapshotRepository,
+	documentRepo *repository.DocumentRepository,
+	vectorRepo *repository.VectorRepository,
+) *SnapshotService {
+	return &SnapshotService{
+		snapshotRepo: snapshotRepo,
+		documentRepo: documentRepo,
+		vectorRepo:   vectorRepo,
+	}
+}
+
+// SnapshotChange is a document that differs between two snapshots. A document uploaded again
+// under the same filename is a change of the earlier one, with a new document ID.
+type SnapshotChange struct {
+	Filename string                 `json:"filename"`
+	Before   model.SnapshotDocument `json:"before"`
+	After    model.SnapshotDocument `json:"after"`
+	// Fields names what changed: document_id, filename, file_hash or total_chunks
+	Fields []string `json:"fields"`
+}
+
+// SnapshotDiff is how a knowledge base changed from one snapshot to another
+type SnapshotDiff struct {
+	// From and To are the compared snapshots, without their documents
+	From      *model.Snapshot          `json:"from"`
+	To        *model.Snapshot          `json:"to"`
+	Added     []model.SnapshotDocument `json:"added"`
+	Removed   []model.SnapshotDocument `json:"removed"`
+	Changed   []SnapshotChange         `json:"changed"`
+	Unchanged int                      `json:"unchanged"`
+	// DocumentDelta and VectorDelta are the changes in document and vector count
+	DocumentDelta int   `json:"document_delta"`
+	VectorDelta   int64 `json:"vector_delta"`
+}
+
+// Create takes a snapshot of the user's knowledge base as it is now
+func (s *SnapshotService) Create(ctx context.Context, userID, label string) (*model.Snapshot, error) {
+	label = strings.TrimSpace(label)
+	if len(label) > 255 {
+		return nil, fmt.Errorf("label must be at most 255 characters")
+	}
+
+	snapshot, err := s.current(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Label = label
+	if err := s.snapshotRepo.Create(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// List lists a user's snapshots, newest first
+func (s *SnapshotService) List(ctx context.Context, userID string, limit, offset int) ([]*model.Snapshot, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.snapshotRepo.ListByUserID(ctx, userID, limit, offset)
+}
+
+// Get gets one of a user's snapshots with its documents
+func (s *SnapshotService) Get(ctx context.Context, userID, snapshotID string) (*model.Snapshot, error) {
+	return s.snapshotRepo.GetByID(ctx, userID, snapshotID)
+}
+
+// Delete deletes one of a user's snapshots
+func (s *SnapshotService) Delete(ctx context.Context, userID, snapshotID string) error {
+	return s.snapshotRepo.Delete(ctx, userID, snapshotID)
+}
+
+// Diff compares two of a user's snapshots. Either ID may be SnapshotCurrent, and an empty to
+// compares with the knowledge base as it is now.
+func (s *SnapshotService) Diff(ctx context.Context, userID, fromID, toID string) (*SnapshotDiff, error) {
+	if toID == "" {
+		toID = SnapshotCurrent
+	}
+
+	from, err := s.load(ctx, userID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.load(ctx, userID, toID)
+	if err != nil {
+		return nil, err
+	}
+	return diffSnapshots(from, to), nil
+}
+
+// RunDaily takes an automatic snapshot of every user with documents and prunes automatic
+// snapshots older than snapshotRetention
+func (s *SnapshotService) RunDaily(ctx context.Context) error {
+	userIDs, err := s.documentRepo.ListOwnerIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// One user's failure shouldn't skip the others
+		snapshot, err := s.current(ctx, userID)
+		if err == nil {
+			snapshot.Automatic = true
+			err = s.snapshotRepo.Create(ctx, snapshot)
+		}
+		if err != nil {
+			logger.Error("Failed to take knowledge base snapshot", "user_id", userID, "error", err)
+		}
+	}
+
+	return s.snapshotRepo.PruneAutomatic(ctx, time.Now().Add(-snapshotRetention))
+}
+
+// load returns a stored snapshot, or the current state for SnapshotCurrent
+func (s *SnapshotService) load(ctx context.Context, userID, id string) (*model.Snapshot, error) {
+	if id == SnapshotCurrent {
+		snapshot, err := s.current(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.ID = SnapshotCurrent
+		return snapshot, nil
+	}
+	return s.snapshotRepo.GetByID(ctx, userID, id)
+}
+
+// current records the user's knowledge base as it is now, without storing it
+func (s *SnapshotService) current(ctx context.Context, userID string) (*model.Snapshot, error) {
+	docs, err := s.documentRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := s.vectorRepo.CountUserVectors(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count vectors: %w", err)
+	}
+
+	documents := make([]model.SnapshotDocument, len(docs))
+	for i, doc := range docs {
+		documents[i] = model.SnapshotDocument{
+			DocumentID:  doc.ID,
+			Filename:    doc.Filename,
+			FileHash:    doc.FileHash,
+			FileSize:    doc.FileSize,
+			TotalChunks: doc.TotalChunks,
+			UploadDate:  doc.UploadDate,
+		}
+	}
+	return &model.Snapshot{
+		UserID:        userID,
+		DocumentCount: len(documents),
+		VectorCount:   int64(vectors),
+		Documents:     documents,
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+// diffSnapshots compares two snapshots' documents, matching them by document ID and then the
+// remaining ones by filename
+func diffSnapshots(from, to *model.Snapshot) *SnapshotDiff {
+	diff := &SnapshotDiff{
+		From:          withoutDocuments(from),
+		To:            withoutDocuments(to),
+		Added:         []model.SnapshotDocument{},
+		Removed:       []model.SnapshotDocument{},
+		Changed:       []SnapshotChange{},
+		DocumentDelta: to.DocumentCount - from.DocumentCount,
+		VectorDelta:   to.VectorCount - from.VectorCount,
+	}
+
+	before := make(map[string]model.SnapshotDocument, len(from.Documents))
+	for _, doc := range from.Documents {
+		before[doc.DocumentID] = doc
+	}
+	var added []model.SnapshotDocument
+	for _, doc := range to.Documents {
+		previous, ok := before[doc.DocumentID]
+		if !ok {
+			added = append(added, doc)
+			continue
+		}
+		delete(before, doc.DocumentID)
+		if change, changed := compareDocuments(previous, doc); changed {
+			diff.Changed = append(diff.Changed, change)
+		} else {
+			diff.Unchanged++
+		}
+	}
+
+	// A removed and an added document with the same filename were uploaded again
+	removedByName := make(map[string][]model.SnapshotDocument)
+	for _, doc := range from.Documents {
+		if _, ok := before[doc.DocumentID]; ok {
+			removedByName[doc.Filename] = append(removedByName[doc.Filename], doc)
+		}
+	}
+	for _, doc := range added {
+		if previous := removedByName[doc.Filename]; len(previous) > 0 {
+			change, _ := compareDocuments(previous[0], doc)
+			diff.Changed = append(diff.Changed, change)
+			removedByName[doc.Filename] = previous[1:]
+			continue
+		}
+		diff.Added = append(diff.Added, doc)
+	}
+	for _, docs := range removedByName {
+		diff.Removed = append(diff.Removed, docs...)
+	}
+
+	sortSnapshotDocuments(diff.Added)
+	sortSnapshotDocuments(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].Filename < diff.Changed[j].Filename
+	})
+	return diff
+}
+
+// compareDocuments reports the fields that differ between two versions of a document
+func compareDocuments(before, after model.SnapshotDocument) (SnapshotChange, bool) {
+	change := SnapshotChange{Filename: after.Filename, Before: before, After: after, Fields: []string{}}
+	if before.DocumentID != after.DocumentID {
+		change.Fields = append(change.Fields, "document_id")
+	}
+	if before.Filename != after.Filename {
+		change.Fields = append(change.Fields, "filename")
+	}
+	if before.FileHash != after.FileHash {
+		change.Fields = append(change.Fields, "file_hash")
+	}
+	if before.TotalChunks != after.TotalChunks {
+		change.Fields = append(change.Fields, "total_chunks")
+	}
+	return change, len(change.Fields) > 0
+}
+
+// withoutDocuments copies a snapshot without its documents, for the summary of a diff
+func withoutDocuments(snapshot *model.Snapshot) *model.Snapshot {
+	summary := *snapshot
+	summary.Documents = nil
+	return &summary
+}
+
+// sortSnapshotDocuments orders documents by filename, then upload date
+func sortSnapshotDocuments(docs []model.SnapshotDocument) {
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].Filename != docs[j].Filename {
+			return docs[i].Filename < docs[j].Filename
+		}
+		return docs[i].UploadDate.Before(docs[j].UploadDate)
+	})
+}
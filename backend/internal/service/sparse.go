@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"unicode"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// SparseEncoder generates sparse (term weight) vectors, stored next to dense embeddings so
+// retrieval can also match exact terms such as names, codes and numbers. Like dense embeddings,
+// chunks and queries must be encoded by the same encoder.
+type SparseEncoder interface {
+	// Encode returns one sparse vector per text, in order
+	Encode(ctx context.Context, texts []string, input EmbeddingInput) ([]*model.SparseVector, error)
+
+	// IDF reports whether vectors hold raw term weights that Qdrant must scale by inverse document
+	// frequency
+	IDF() bool
+
+	// Name identifies the encoder in the names of the collections holding its vectors
+	Name() string
+}
+
+// NewSparseEncoder creates the sparse encoder selected by the configuration, or nil when sparse
+// vectors are disabled
+func NewSparseEncoder(cfg *config.Config) (SparseEncoder, error) {
+	switch cfg.SparseEncoder {
+	case "":
+		return nil, nil
+	case "bm25":
+		return NewBM25Encoder(), nil
+	case "splade":
+		splade, err := NewSPLADEEncoder(cfg.SparseModelDir, cfg.ONNXRuntimeLib)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SPLADE configuration: %w", err)
+		}
+		return splade, nil
+	default:
+		return nil, fmt.Errorf("unknown sparse encoder: %s (valid options: bm25, splade)", cfg.SparseEncoder)
+	}
+}
+
+// sparseVectorConfig returns the sparse vector configuration of new docs collections
+func sparseVectorConfig(encoder SparseEncoder) *repository.SparseVectorConfig {
+	if encoder == nil {
+		return nil
+	}
+	return &repository.SparseVectorConfig{IDF: encoder.IDF()}
+}
+
+// attachSparseVectors encodes the points' chunk text into sparse vectors. Sparse vectors only add
+// to dense retrieval, so a failure is returned for logging and the points are left dense-only.
+func attachSparseVectors(ctx context.Context, encoder SparseEncoder, points []*model.VectorPoint) error {
+	if encoder == nil || len(points) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(points))
+	for i, point := range points {
+		texts[i], _ = point.Payload["content"].(string)
+	}
+
+	vectors, err := encoder.Encode(ctx, texts, EmbeddingDocument)
+	if err != nil {
+		return err
+	}
+	if len(vectors) != len(points) {
+		return fmt.Errorf("expected %d sparse vectors, got %d", len(points), len(vectors))
+	}
+	for i, point := range points {
+		point.Sparse = vectors[i]
+	}
+
+	return nil
+}
+
+// BM25 parameters, matching Qdrant's own BM25 model
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// bm25AvgLength is the assumed average chunk length in words, as true corpus statistics
+	// aren't available when a single document is encoded
+	bm25AvgLength = 256
+)
+
+// BM25Encoder encodes text as BM25 term frequency weights over hashed words. It needs no model;
+// Qdrant applies the IDF part of the score from the collection's statistics at query time.
+type BM25Encoder struct{}
+
+// NewBM25Encoder creates a BM25 sparse encoder
+func NewBM25Encoder() *BM25Encoder {
+	return &BM25Encoder{}
+}
+
+// Encode returns BM25-saturated term frequencies for documents and unit weights for the distinct
+// terms of queries
+func (e *BM25Encoder) Encode(ctx context.Context, texts []string, input EmbeddingInput) ([]*model.SparseVector, error) {
+	vectors := make([]*model.SparseVector, len(texts))
+	for i, text := range texts {
+		counts := make(map[uint32]int)
+		length := 0
+		for _, term := range bm25Terms(text) {
+			counts[bm25TermIndex(term)]++
+			length++
+		}
+
+		weights := make(map[uint32]float32, len(counts))
+		for index, count := range counts {
+			if input == EmbeddingQuery {
+				weights[index] = 1
+				continue
+			}
+			tf := float64(count)
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(length)/bm25AvgLength)
+			weights[index] = float32(tf * (bm25K1 + 1) / (tf + norm))
+		}
+		vectors[i] = newSparseVector(weights)
+	}
+
+	return vectors, nil
+}
+
+// IDF is set as BM25 vectors hold term frequencies only
+func (e *BM25Encoder) IDF() bool {
+	return true
+}
+
+// Name returns "bm25"
+func (e *BM25Encoder) Name() string {
+	return "bm25"
+}
+
+// bm25Terms splits text into lowercase words, dropping punctuation and stopwords
+func bm25Terms(text string) []string {
+	var terms []string
+	for _, word := range basicTokenize(text) {
+		if questionStopwords[word] || !hasLetterOrDigit(word) {
+			continue
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// hasLetterOrDigit reports whether a word contains a letter or digit
+func hasLetterOrDigit(word string) bool {
+	for _, r := range word {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// bm25TermIndex maps a term to its sparse vector index
+func bm25TermIndex(term string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(term))
+	return h.Sum32()
+}
+
+// newSparseVector converts term weights to a sparse vector sorted by index
+func newSparseVector(weights map[uint32]float32) *model.SparseVector {
+	v := &model.SparseVector{
+		Indices: make([]uint32, 0, len(weights)),
+		Values:  make([]float32, 0, len(weights)),
+	}
+	for index := range weights {
+		v.Indices = append(v.Indices, index)
+	}
+	sort.Slice(v.Indices, func(i, j int) bool { return v.Indices[i] < v.Indices[j] })
+	for _, index := range v.Indices {
+		v.Values = append(v.Values, weights[index])
+	}
+	return v
+}
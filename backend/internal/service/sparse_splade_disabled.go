@@ -0,0 +1,11 @@
+//go:build !onnx
+
+package service
+
+import "fmt"
+
+// NewSPLADEEncoder reports that SPLADE encoding is unavailable. Like the ONNX embedding provider,
+// it is only compiled into builds made with -tags onnx.
+func NewSPLADEEncoder(modelDir, runtimeLib string) (SparseEncoder, error) {
+	return nil, fmt.Errorf("this build has no ONNX support; rebuild with CGO_ENABLED=1 go build -tags onnx")
+}
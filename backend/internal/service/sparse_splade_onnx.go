@@ -0,0 +1,136 @@
+//go:build onnx
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// SPLADE limits. The model's output holds a score for every vocabulary word at every token, so
+// batches are kept small.
+const (
+	spladeMaxTokens = 256
+	spladeBatchSize = 4
+)
+
+// SPLADEEncoder generates learned sparse vectors in-process with onnxruntime. A SPLADE model
+// scores every vocabulary word for each token; a text's weight for a word is the highest
+// log-saturated score across its tokens, which also expands the text with related words.
+type SPLADEEncoder struct {
+	tokenizer  *wordPieceTokenizer
+	session    *ort.DynamicAdvancedSession
+	inputNames []string
+	vocabSize  int
+	// mu serializes inference, as for the ONNX embedding provider
+	mu sync.Mutex
+}
+
+// NewSPLADEEncoder loads a SPLADE model (a Hugging Face ONNX export with model.onnx and
+// vocab.txt, such as naver/splade-v3) from modelDir using the onnxruntime library at runtimeLib
+func NewSPLADEEncoder(modelDir, runtimeLib string) (SparseEncoder, error) {
+	if modelDir == "" {
+		return nil, fmt.Errorf("SPARSE_MODEL_DIR is required for the splade encoder")
+	}
+
+	modelPath, tokenizer, err := loadONNXModelDir(modelDir, runtimeLib)
+	if err != nil {
+		return nil, err
+	}
+
+	inputNames, outputs, err := onnxModelIO(modelPath)
+	if err != nil {
+		return nil, err
+	}
+
+	output := outputs[0]
+	for _, o := range outputs {
+		if o.Name == "logits" {
+			output = o
+		}
+	}
+	if len(output.Dimensions) != 3 || output.Dimensions[2] <= 0 {
+		return nil, fmt.Errorf("model %s output %q is not a [batch, tokens, vocabulary] tensor", modelPath, output.Name)
+	}
+
+	session, err := ort.NewDynamicAdvancedSession(modelPath, inputNames, []string{output.Name}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model %s: %w", modelPath, err)
+	}
+
+	return &SPLADEEncoder{
+		tokenizer:  tokenizer,
+		session:    session,
+		inputNames: inputNames,
+		vocabSize:  int(output.Dimensions[2]),
+	}, nil
+}
+
+// Encode returns the SPLADE vector of each text; documents and queries are encoded alike
+func (e *SPLADEEncoder) Encode(ctx context.Context, texts []string, input EmbeddingInput) ([]*model.SparseVector, error) {
+	vectors := make([]*model.SparseVector, 0, len(texts))
+	for i := 0; i < len(texts); i += spladeBatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		end := min(i+spladeBatchSize, len(texts))
+		batch, err := e.encodeBatch(texts[i:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode batch %d: %w", i/spladeBatchSize, err)
+		}
+		vectors = append(vectors, batch...)
+	}
+
+	return vectors, nil
+}
+
+// encodeBatch max-pools log(1 + relu(score)) over each text's tokens
+func (e *SPLADEEncoder) encodeBatch(texts []string) ([]*model.SparseVector, error) {
+	encoded := make([][]int64, len(texts))
+	seqLen := 0
+	for i, text := range texts {
+		encoded[i] = e.tokenizer.Encode(text, spladeMaxTokens)
+		seqLen = max(seqLen, len(encoded[i]))
+	}
+
+	vocab := e.vocabSize
+	vectors := make([]*model.SparseVector, len(texts))
+	err := runONNXBatch(e.session, &e.mu, e.inputNames, e.tokenizer.pad, encoded, seqLen, func(logits []float32) {
+		for i := range encoded {
+			weights := make(map[uint32]float32)
+			for j := range encoded[i] {
+				offset := (i*seqLen + j) * vocab
+				for k, score := range logits[offset : offset+vocab] {
+					if score <= 0 {
+						continue
+					}
+					if w := float32(math.Log1p(float64(score))); w > weights[uint32(k)] {
+						weights[uint32(k)] = w
+					}
+				}
+			}
+			vectors[i] = newSparseVector(weights)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vectors, nil
+}
+
+// IDF is unset as SPLADE weights already reflect term importance
+func (e *SPLADEEncoder) IDF() bool {
+	return false
+}
+
+// Name returns "splade"
+func (e *SPLADEEncoder) Name() string {
+	return "splade"
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/storage"
+)
+
+// TTSProvider synthesizes speech from text
+type TTSProvider interface {
+	// Synthesize returns encoded audio for the text
+	Synthesize(ctx context.Context, text string) ([]byte, error)
+
+	// CacheKey identifies the provider settings so cached audio is regenerated when they change
+	CacheKey() string
+
+	// ContentType returns the MIME type of the synthesized audio
+	ContentType() string
+}
+
+// SpeechService synthesizes stored answers to audio and caches the result in storage
+type SpeechService struct {
+	documentRepo  *repository.DocumentRepository
+	storageDriver storage.StorageDriver
+	provider      TTSProvider
+}
+
+// NewSpeechService creates a new speech service; provider may be nil when TTS is disabled
+func NewSpeechService(
+	documentRepo *repository.DocumentRepository,
+	storageDriver storage.StorageDriver,
+	provider TTSProvider,
+) *SpeechService {
+	return &SpeechService{
+		documentRepo:  documentRepo,
+		storageDriver: storageDriver,
+		provider:      provider,
+	}
+}
+
+// maxSpeechChars is the longest answer sent to the TTS provider
+const maxSpeechChars = 4096
+
+// AnswerAudio returns the audio for a stored answer, synthesizing and caching it on first request
+func (s *SpeechService) AnswerAudio(ctx context.Context, userID, historyID string) ([]byte, string, error) {
+	if s.provider == nil {
+		return nil, "", fmt.Errorf("text-to-speech is not configured")
+	}
+
+	entry, err := s.documentRepo.GetQueryHistory(ctx, userID, historyID)
+	if err != nil {
+		return nil, "", err
+	}
+	if entry.Answer == "" {
+		return nil, "", fmt.Errorf("query has no answer to read")
+	}
+
+	cacheKey := fmt.Sprintf("%s/audio/%s-%s", userID, historyID, s.provider.CacheKey())
+
+	if cached, err := s.storageDriver.GetFile(ctx, cacheKey); err == nil {
+		defer cached.Close()
+		audio, err := io.ReadAll(cached)
+		if err == nil {
+			return audio, s.provider.ContentType(), nil
+		}
+		logger.Warn("Failed to read cached audio", "key", cacheKey, "error", err)
+	}
+
+	audio, err := s.provider.Synthesize(ctx, truncate(entry.Answer, maxSpeechChars))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to synthesize speech: %w", err)
+	}
+
+	if err := s.storageDriver.UploadFile(ctx, cacheKey, bytes.NewReader(audio)); err != nil {
+		// Serving the audio matters more than caching it
+		logger.Error("Failed to cache synthesized audio", "key", cacheKey, "error", err)
+	}
+
+	return audio, s.provider.ContentType(), nil
+}
+
+// OpenAITTSProvider synthesizes speech with the OpenAI audio API
+type OpenAITTSProvider struct {
+	endpoint   OpenAIEndpoint
+	model      string
+	voice      string
+	httpClient *httpretry.Client
+}
+
+// NewOpenAITTSProvider creates a new OpenAI TTS provider
+func NewOpenAITTSProvider(endpoint OpenAIEndpoint, model, voice string) *OpenAITTSProvider {
+	return &OpenAITTSProvider{
+		endpoint:   endpoint,
+		model:      model,
+		voice:      voice,
+		httpClient: endpoint.client(60 * time.Second),
+	}
+}
+
+// CacheKey returns the model/voice combination
+func (p *OpenAITTSProvider) CacheKey() string {
+	return fmt.Sprintf("openai-%s-%s.mp3", p.model, p.voice)
+}
+
+// ContentType returns the MIME type of the synthesized audio
+func (p *OpenAITTSProvider) ContentType() string {
+	return "audio/mpeg"
+}
+
+// Synthesize calls the OpenAI speech endpoint
+func (p *OpenAITTSProvider) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	jsonData, err := json.Marshal(map[string]string{
+		"model":           p.model,
+		"voice":           p.voice,
+		"input":           text,
+		"response_format": "mp3",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint.URL("/audio/speech"), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	p.endpoint.authorize(req)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, httpretry.ResponseError("API", resp)
+	}
+
+	return io.ReadAll(resp.Body)
+}
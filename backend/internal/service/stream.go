@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// maxStreamedSources caps the document titles listed under a streamed answer
+const maxStreamedSources = 3
+
+// StreamedAnswer is a completed streamed answer with the titles of the documents it came from
+type StreamedAnswer struct {
+	// ID is the answer's query history entry; empty when it couldn't be recorded
+	ID      string   `json:"id,omitempty"`
+	Answer  string   `json:"answer"`
+	Sources []string `json:"sources"`
+}
+
+// StreamAnswer answers a question for chat channels, calling onDelta with the answer so far as
+// it is generated. Retrieval and the user's persona and language apply as for Query, but the
+// optional pipeline stages and tools are skipped so the answer can be streamed. It's recorded
+// in query history without a conversation before generation starts, and onStart, when set, is
+// called with the entry's ID and the answer's sources. An answer that stops early, such as when
+// ctx is cancelled because the client disconnected, keeps what was generated, marked truncated.
+func (s *RAGService) StreamAnswer(ctx context.Context, userID, question string, onStart func(historyID string, sources []map[string]interface{}), onDelta func(answer string)) (*StreamedAnswer, error) {
+	question, exclude := parseExclusions(question)
+	if question == "" {
+		return nil, fmt.Errorf("question is required")
+	}
+	ctx, tracker := withUsageTracker(ctx)
+
+	settings, err := s.settingsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	opts := GenerationOptions{Language: settings.Language, Persona: settings.SystemPrompt}
+
+	var answer string
+	var sources []map[string]interface{}
+	var results []*model.VectorPoint
+	var faq *FAQMatch
+	if len(exclude) == 0 {
+		faq = s.answerFromFAQ(ctx, userID, question)
+	}
+	if faq != nil {
+		answer, sources = faq.answer, faq.sources
+	} else {
+		results, _, err = s.retrieve(ctx, userID, question, RetrievalOptions{
+			Filter: buildSearchFilter(nil, exclude, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+		sources = buildSources(results)
+	}
+
+	historyID, err := s.documentRepo.StartQueryHistory(ctx, userID, question, map[string]interface{}{
+		"sources": sources,
+	})
+	if err != nil {
+		logger.Error("Failed to save query history", "user_id", userID, "error", err)
+	}
+	if onStart != nil {
+		onStart(historyID, sources)
+	}
+
+	if faq != nil {
+		// A repeat of a question in the user's FAQ is sent whole
+		onDelta(answer)
+	} else {
+		answer, err = s.streamCompletion(ctx, ChatCompletionRequest{
+			Messages: []ChatMessage{
+				{Role: "system", Content: buildSystemPrompt(ragSystemPrompt, opts)},
+				{Role: "user", Content: fmt.Sprintf("Context:\n%s\n\nQuestion: %s", buildContextText(results), question)},
+			},
+		}, onDelta)
+		answer = strings.TrimSpace(answer)
+		if err != nil {
+			s.finishStreamedAnswer(ctx, historyID, answer, tracker.Usage(), true)
+			return nil, err
+		}
+	}
+	s.finishStreamedAnswer(ctx, historyID, answer, tracker.Usage(), false)
+
+	return &StreamedAnswer{
+		ID:      historyID,
+		Answer:  answer,
+		Sources: sourceTitles(sources, maxStreamedSources),
+	}, nil
+}
+
+// finishStreamedAnswer saves a streamed answer to its query history entry, even after ctx is
+// cancelled
+func (s *RAGService) finishStreamedAnswer(ctx context.Context, historyID, answer string, usage model.TokenUsage, truncated bool) {
+	if historyID == "" {
+		return
+	}
+	if err := s.documentRepo.FinishQueryHistory(context.WithoutCancel(ctx), historyID, answer, usage, truncated); err != nil {
+		logger.Error("Failed to save streamed answer", "history_id", historyID, "error", err)
+	}
+}
+
+// streamCompletion sends a streamed chat completion request, calling onDelta with the reply so
+// far after each content delta, and returns the full reply. When the stream breaks off, such as
+// when ctx is cancelled, it returns the reply so far with the error.
+func (s *RAGService) streamCompletion(ctx context.Context, requestBody ChatCompletionRequest, onDelta func(reply string)) (string, error) {
+	if requestBody.Model == "" {
+		requestBody.Model = defaultChatModel
+	}
+	requestBody.Stream = true
+	requestBody.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.openAI.URL("/chat/completions"), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	s.openAI.authorize(req)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", httpretry.ResponseError("API", resp)
+	}
+
+	// Each event is a "data: {...}" line; the stream ends with "data: [DONE]"
+	var reply strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+
+		var event struct {
+			Model   string `json:"model"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return reply.String(), fmt.Errorf("failed to decode stream event: %w", err)
+		}
+
+		// The usage event comes last, with no choices
+		if event.Usage != nil {
+			billedModel := event.Model
+			if billedModel == "" {
+				billedModel = requestBody.Model
+			}
+			trackChatUsage(ctx, billedModel, event.Usage.PromptTokens, event.Usage.CompletionTokens)
+		}
+		if len(event.Choices) > 0 && event.Choices[0].Delta.Content != "" {
+			reply.WriteString(event.Choices[0].Delta.Content)
+			onDelta(reply.String())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return reply.String(), fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return reply.String(), nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Retrieval modes
+const (
+	// QueryModeChunks searches every chunk in the user's collection
+	QueryModeChunks = "chunks"
+	// QueryModeDocuments first selects candidate documents by summary similarity,
+	// then searches only their chunks
+	QueryModeDocuments = "documents"
+)
+
+// Document summary index parameters
+const (
+	summaryBatchSize  = 20
+	summaryInputChars = 12000
+	// summaryCandidates is the number of documents kept by the first stage of documents mode
+	summaryCandidates = 5
+)
+
+// documentSummaryPrompt asks for a summary that captures what a document is about and what it mentions
+const documentSummaryPrompt = `Summarize the document below in at most 150 words.
+Describe what kind of document it is and its main topics, and name the key people, organisations, products, places and dates it mentions.
+Reply with the summary only.`
+
+// validateQueryMode checks a request's retrieval mode
+func validateQueryMode(mode string) error {
+	switch mode {
+	case "", QueryModeChunks, QueryModeDocuments:
+		return nil
+	}
+	return fmt.Errorf("mode must be %q or %q", QueryModeChunks, QueryModeDocuments)
+}
+
+// IndexDocumentSummaries summarizes a batch of documents that have no summary yet and indexes
+// one embedding per summary in the user's summary collection. It runs on a schedule, so
+// existing documents are backfilled and new uploads are picked up within a few minutes.
+func (s *RAGService) IndexDocumentSummaries(ctx context.Context) error {
+	docs, err := s.documentRepo.ListUnsummarized(ctx, summaryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		if err := s.indexDocumentSummary(ctx, doc); err != nil {
+			logger.Error("Failed to index document summary", "document_id", doc.ID, "error", err)
+			continue
+		}
+		logger.Debug("Indexed document summary", "document_id", doc.ID)
+	}
+
+	return nil
+}
+
+// indexDocumentSummary summarizes a single document and stores its summary embedding
+func (s *RAGService) indexDocumentSummary(ctx context.Context, doc *model.Document) error {
+	text, err := s.chunkRepo.GetDocumentText(ctx, doc.ID, summaryInputChars)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		// Nothing to summarize; mark it so it isn't retried on every run
+		return s.documentRepo.SetSummary(ctx, doc.ID, "")
+	}
+
+	summary, err := s.callLLM(ctx, documentSummaryPrompt,
+		fmt.Sprintf("Document: %s\n\n%s", doc.Filename, truncate(text, summaryInputChars)))
+	if err != nil {
+		return fmt.Errorf("failed to summarize document: %w", err)
+	}
+	summary = strings.TrimSpace(summary)
+
+	provider, err := s.embeddings.ForUser(ctx, doc.UserID)
+	if err != nil {
+		return err
+	}
+	embedCtx, tracker := withUsageTracker(ctx)
+	embedding, err := generateEmbedding(embedCtx, provider, doc.Filename+"\n"+summary, EmbeddingDocument)
+	if err != nil {
+		return err
+	}
+	recordEmbeddingUsage(ctx, s.documentRepo, tracker, model.EmbeddingUsage{
+		UserID:     doc.UserID,
+		DocumentID: doc.ID,
+		Filename:   doc.Filename,
+		Source:     EmbeddingSourceSummary,
+		Model:      provider.Model(),
+	})
+
+	if err := s.vectorRepo.IndexDocumentSummary(ctx, doc.UserID, &model.VectorPoint{
+		ID:     doc.ID,
+		Vector: embedding,
+		Payload: map[string]interface{}{
+			"document_id": doc.ID,
+			"filename":    doc.Filename,
+		},
+	}); err != nil {
+		return err
+	}
+
+	return s.documentRepo.SetSummary(ctx, doc.ID, summary)
+}
+
+// restrictToCandidateDocuments narrows a filter to the documents whose summaries best match
+// the query. The filter is returned unchanged when no summaries have been indexed yet.
+func (s *RAGService) restrictToCandidateDocuments(ctx context.Context, userID string, queryEmbedding []float32, filter *repository.SearchFilter) *repository.SearchFilter {
+	candidates, err := s.vectorRepo.SearchDocumentSummaries(ctx, userID, queryEmbedding, summaryCandidates)
+	if err != nil {
+		logger.Error("Failed to search document summaries", "user_id", userID, "error", err)
+		return filter
+	}
+	if len(candidates) == 0 {
+		return filter
+	}
+
+	restricted := &repository.SearchFilter{}
+	if filter != nil {
+		*restricted = *filter
+	}
+	restricted.DocumentIDs = nil
+	for _, candidate := range candidates {
+		if documentID, ok := candidate.Payload["document_id"].(string); ok {
+			restricted.DocumentIDs = append(restricted.DocumentIDs, documentID)
+		}
+	}
+
+	return restricted
+}
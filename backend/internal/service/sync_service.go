@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Sync entities and operations, as recorded by the sync triggers
+const (
+	SyncEntityDocument     = "document"
+	SyncEntityConversation = "conversation"
+	SyncEntityMessage      = "message"
+
+	SyncUpsert = "upsert"
+	SyncDelete = "delete"
+)
+
+// syncDeletionMaxAge is how long deletions are kept in the change feed; clients that haven't
+// synced for longer must fetch everything again
+const syncDeletionMaxAge = 90 * 24 * time.Hour
+
+// SyncService serves a change feed over the user's documents (including captured notes),
+// conversations and messages, so offline clients fetch only what changed since their last sync.
+// Every change gets a server-wide sequence number; since the server orders all writes, a
+// client's position in the feed is a single number rather than a vector clock.
+type SyncService struct {
+	syncRepo         *repository.SyncRepository
+	documentRepo     *repository.DocumentRepository
+	conversationRepo *repository.ConversationRepository
+}
+
+// NewSyncService creates a new sync service
+func NewSyncService(syncRepo *repository.SyncRepository, documentRepo *repository.DocumentRepository, conversationRepo *repository.ConversationRepository) *SyncService {
+	return &SyncService{
+		syncRepo:         syncRepo,
+		documentRepo:     documentRepo,
+		conversationRepo: conversationRepo,
+	}
+}
+
+// SyncPage is a page of the change feed
+type SyncPage struct {
+	Changes []*model.SyncChange `json:"changes"`
+	// NextSeq is the since value for the next request, and the client's cursor once HasMore is false
+	NextSeq int64 `json:"next_seq"`
+	HasMore bool  `json:"has_more"`
+	// Reset tells the client its cursor is older than the retained deletions: it must drop its
+	// local data and sync again from 0
+	Reset bool `json:"reset,omitempty"`
+}
+
+// Changes returns the user's changes after since, each entity at most once with its current
+// state. since 0 returns every existing entity.
+func (s *SyncService) Changes(ctx context.Context, userID string, since int64, limit int) (*SyncPage, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	if since < 0 {
+		since = 0
+	}
+
+	if since > 0 {
+		pruned, err := s.syncRepo.PrunedSeq(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if since < pruned {
+			return &SyncPage{Changes: []*model.SyncChange{}, Reset: true}, nil
+		}
+	}
+
+	changes, err := s.syncRepo.ListChanges(ctx, userID, since, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &SyncPage{NextSeq: since}
+	if len(changes) > limit {
+		changes = changes[:limit]
+		page.HasMore = true
+	}
+	if len(changes) > 0 {
+		page.NextSeq = changes[len(changes)-1].Seq
+	}
+
+	page.Changes, err = s.attachData(ctx, userID, changes)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// attachData sets the current state of each upserted entity. An entity deleted since its change
+// was listed is left out; its deletion follows in a later page.
+func (s *SyncService) attachData(ctx context.Context, userID string, changes []*model.SyncChange) ([]*model.SyncChange, error) {
+	ids := make(map[string][]string)
+	for _, change := range changes {
+		if change.Operation == SyncUpsert {
+			ids[change.Entity] = append(ids[change.Entity], change.EntityID)
+		}
+	}
+
+	data := make(map[string]interface{})
+	if len(ids[SyncEntityDocument]) > 0 {
+		docs, err := s.documentRepo.ListByIDs(ctx, userID, ids[SyncEntityDocument])
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			data[doc.ID] = doc
+		}
+	}
+	if len(ids[SyncEntityConversation]) > 0 {
+		convs, err := s.conversationRepo.ListByIDs(ctx, userID, ids[SyncEntityConversation])
+		if err != nil {
+			return nil, err
+		}
+		for _, conv := range convs {
+			data[conv.ID] = conv
+		}
+	}
+	if len(ids[SyncEntityMessage]) > 0 {
+		messages, err := s.documentRepo.ListQueryHistoryByIDs(ctx, userID, ids[SyncEntityMessage])
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			data[message.ID] = message
+		}
+	}
+
+	kept := make([]*model.SyncChange, 0, len(changes))
+	for _, change := range changes {
+		if change.Operation == SyncUpsert {
+			entity, ok := data[change.EntityID]
+			if !ok {
+				continue
+			}
+			change.Data = entity
+		}
+		kept = append(kept, change)
+	}
+	return kept, nil
+}
+
+// Maintain keeps the change feed small: it drops changes superseded by a later one and
+// deletions older than syncDeletionMaxAge, and the changes of deleted users
+func (s *SyncService) Maintain(ctx context.Context) error {
+	compacted, err := s.syncRepo.Compact(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.syncRepo.PruneDeletions(ctx, time.Now().Add(-syncDeletionMaxAge)); err != nil {
+		return err
+	}
+
+	logger.Info("Compacted sync change feed", "superseded", compacted)
+	return nil
+}
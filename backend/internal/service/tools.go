@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxToolRounds caps how many times the model may call tools before it must answer
+const maxToolRounds = 3
+
+// Tool is a function the LLM can call during generation
+type Tool interface {
+	// Definition describes the tool to the model
+	Definition() ChatTool
+
+	// Call executes the tool with JSON-encoded arguments and returns text for the model
+	Call(ctx context.Context, arguments string) (string, error)
+}
+
+// ToolRegistry holds the tools exposed to the LLM
+type ToolRegistry struct {
+	tools map[string]Tool
+	order []string
+}
+
+// NewToolRegistry creates a tool registry with the given tools
+func NewToolRegistry(tools ...Tool) *ToolRegistry {
+	r := &ToolRegistry{tools: make(map[string]Tool)}
+	for _, tool := range tools {
+		r.Register(tool)
+	}
+	return r
+}
+
+// Register adds a tool, replacing any tool with the same name
+func (r *ToolRegistry) Register(tool Tool) {
+	name := tool.Definition().Function.Name
+	if _, exists := r.tools[name]; !exists {
+		r.order = append(r.order, name)
+	}
+	r.tools[name] = tool
+}
+
+// Definitions returns the definitions of all registered tools in registration order
+func (r *ToolRegistry) Definitions() []ChatTool {
+	if r == nil {
+		return nil
+	}
+	definitions := make([]ChatTool, 0, len(r.order))
+	for _, name := range r.order {
+		definitions = append(definitions, r.tools[name].Definition())
+	}
+	return definitions
+}
+
+// Has reports whether a tool with the given name is registered
+func (r *ToolRegistry) Has(name string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.tools[name]
+	return ok
+}
+
+// Call executes the named tool
+func (r *ToolRegistry) Call(ctx context.Context, name, arguments string) (string, error) {
+	if r == nil {
+		return "", fmt.Errorf("unknown tool: %s", name)
+	}
+	tool, ok := r.tools[name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool: %s", name)
+	}
+	return tool.Call(ctx, arguments)
+}
+
+// runTools executes the model's tool calls and returns the tool result messages
+func (r *ToolRegistry) runTools(ctx context.Context, calls []ToolCall) []ChatMessage {
+	messages := make([]ChatMessage, 0, len(calls))
+	for _, call := range calls {
+		output, err := r.Call(ctx, call.Function.Name, call.Function.Arguments)
+		if err != nil {
+			output = "Error: " + err.Error()
+		}
+		messages = append(messages, ChatMessage{
+			Role:       "tool",
+			Content:    output,
+			ToolCallID: call.ID,
+		})
+	}
+	return messages
+}
@@ -0,0 +1,507 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+)
+
+// CalculatorTool evaluates arithmetic expressions
+type CalculatorTool struct{}
+
+// NewCalculatorTool creates a new calculator tool
+func NewCalculatorTool() *CalculatorTool {
+	return &CalculatorTool{}
+}
+
+// Definition describes the calculator tool
+func (t *CalculatorTool) Definition() ChatTool {
+	return ChatTool{
+		Type: "function",
+		Function: ChatToolFunction{
+			Name:        "calculator",
+			Description: "Evaluate an arithmetic expression. Supports + - * / % ^, parentheses and sqrt, abs, ln, log, round.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"expression": map[string]interface{}{
+						"type":        "string",
+						"description": "The expression to evaluate, e.g. (1200 * 0.06) / 12",
+					},
+				},
+				"required": []string{"expression"},
+			},
+		},
+	}
+}
+
+// Call evaluates the expression
+func (t *CalculatorTool) Call(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Expression string `json:"expression"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	result, err := evaluateExpression(args.Expression)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatFloat(result, 'f', -1, 64), nil
+}
+
+// evaluateExpression parses and evaluates an arithmetic expression
+func evaluateExpression(expression string) (float64, error) {
+	p := &exprParser{input: expression}
+	value, err := p.parseSum()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("result is not a finite number")
+	}
+	return value, nil
+}
+
+// exprParser is a recursive-descent parser for arithmetic expressions
+type exprParser struct {
+	input string
+	pos   int
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+// parseSum handles + and -
+func (p *exprParser) parseSum() (float64, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+}
+
+// parseProduct handles *, / and %
+func (p *exprParser) parseProduct() (float64, error) {
+	left, err := p.parsePower()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parsePower()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '*':
+			left *= right
+		case '/':
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left /= right
+		case '%':
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left = math.Mod(left, right)
+		}
+	}
+}
+
+// parsePower handles right-associative ^
+func (p *exprParser) parsePower() (float64, error) {
+	base, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	if p.peek() == '^' {
+		p.pos++
+		exponent, err := p.parsePower()
+		if err != nil {
+			return 0, err
+		}
+		return math.Pow(base, exponent), nil
+	}
+	return base, nil
+}
+
+// parseUnary handles leading + and -
+func (p *exprParser) parseUnary() (float64, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		value, err := p.parseUnary()
+		return -value, err
+	case '+':
+		p.pos++
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary handles numbers, parentheses and function calls
+func (p *exprParser) parsePrimary() (float64, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		value, err := p.parseSum()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return value, nil
+
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '.' || p.input[p.pos] == ',' || (p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+			p.pos++
+		}
+		// Allow thousands separators like 1,200
+		return strconv.ParseFloat(strings.ReplaceAll(p.input[start:p.pos], ",", ""), 64)
+
+	case unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) && unicode.IsLetter(rune(p.input[p.pos])) {
+			p.pos++
+		}
+		name := strings.ToLower(p.input[start:p.pos])
+		if name == "pi" {
+			return math.Pi, nil
+		}
+		if name == "e" {
+			return math.E, nil
+		}
+		if p.peek() != '(' {
+			return 0, fmt.Errorf("unknown identifier: %s", name)
+		}
+		arg, err := p.parsePrimary()
+		if err != nil {
+			return 0, err
+		}
+		switch name {
+		case "sqrt":
+			return math.Sqrt(arg), nil
+		case "abs":
+			return math.Abs(arg), nil
+		case "ln":
+			return math.Log(arg), nil
+		case "log":
+			return math.Log10(arg), nil
+		case "round":
+			return math.Round(arg), nil
+		}
+		return 0, fmt.Errorf("unknown function: %s", name)
+	}
+
+	if c == 0 {
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+	return 0, fmt.Errorf("unexpected %q at position %d", c, p.pos)
+}
+
+// CurrentDateTool reports the current date and time
+type CurrentDateTool struct{}
+
+// NewCurrentDateTool creates a new current date tool
+func NewCurrentDateTool() *CurrentDateTool {
+	return &CurrentDateTool{}
+}
+
+// Definition describes the current date tool
+func (t *CurrentDateTool) Definition() ChatTool {
+	return ChatTool{
+		Type: "function",
+		Function: ChatToolFunction{
+			Name:        "current_date",
+			Description: "Get the current date, time and weekday, optionally in an IANA timezone such as Asia/Kuala_Lumpur.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"timezone": map[string]interface{}{
+						"type":        "string",
+						"description": "IANA timezone name; defaults to UTC",
+					},
+				},
+			},
+		},
+	}
+}
+
+// Call returns the current date and time
+func (t *CurrentDateTool) Call(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Timezone string `json:"timezone"`
+	}
+	if arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	location := time.UTC
+	if args.Timezone != "" {
+		loc, err := time.LoadLocation(args.Timezone)
+		if err != nil {
+			return "", fmt.Errorf("unknown timezone: %s", args.Timezone)
+		}
+		location = loc
+	}
+
+	now := time.Now().In(location)
+	return fmt.Sprintf("%s (%s)", now.Format(time.RFC3339), now.Weekday()), nil
+}
+
+// UnitConversionTool converts between common units
+type UnitConversionTool struct{}
+
+// NewUnitConversionTool creates a new unit conversion tool
+func NewUnitConversionTool() *UnitConversionTool {
+	return &UnitConversionTool{}
+}
+
+// unitFactors maps each unit to its dimension and factor relative to the dimension's base unit
+var unitFactors = map[string]struct {
+	dimension string
+	factor    float64
+}{
+	// Length (base: meter)
+	"mm": {"length", 0.001}, "cm": {"length", 0.01}, "m": {"length", 1}, "km": {"length", 1000},
+	"in": {"length", 0.0254}, "ft": {"length", 0.3048}, "yd": {"length", 0.9144}, "mi": {"length", 1609.344},
+	// Mass (base: kilogram)
+	"mg": {"mass", 1e-6}, "g": {"mass", 0.001}, "kg": {"mass", 1}, "t": {"mass", 1000},
+	"oz": {"mass", 0.028349523125}, "lb": {"mass", 0.45359237},
+	// Volume (base: liter)
+	"ml": {"volume", 0.001}, "l": {"volume", 1}, "floz": {"volume", 0.0295735295625},
+	"cup": {"volume", 0.2365882365}, "pt": {"volume", 0.473176473}, "qt": {"volume", 0.946352946}, "gal": {"volume", 3.785411784},
+	// Speed (base: meters per second)
+	"m/s": {"speed", 1}, "km/h": {"speed", 1 / 3.6}, "mph": {"speed", 0.44704}, "kn": {"speed", 0.514444},
+	// Data (base: byte)
+	"b": {"data", 1}, "kb": {"data", 1e3}, "mb": {"data", 1e6}, "gb": {"data", 1e9}, "tb": {"data", 1e12},
+}
+
+// Definition describes the unit conversion tool
+func (t *UnitConversionTool) Definition() ChatTool {
+	return ChatTool{
+		Type: "function",
+		Function: ChatToolFunction{
+			Name:        "convert_units",
+			Description: "Convert a value between units of length (mm, cm, m, km, in, ft, yd, mi), mass (mg, g, kg, t, oz, lb), volume (ml, l, floz, cup, pt, qt, gal), speed (m/s, km/h, mph, kn), data (b, kb, mb, gb, tb) or temperature (c, f, k).",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"value": map[string]interface{}{"type": "number"},
+					"from":  map[string]interface{}{"type": "string"},
+					"to":    map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"value", "from", "to"},
+			},
+		},
+	}
+}
+
+// Call converts the value
+func (t *UnitConversionTool) Call(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Value float64 `json:"value"`
+		From  string  `json:"from"`
+		To    string  `json:"to"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	result, err := convertUnits(args.Value, args.From, args.To)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s %s = %s %s",
+		strconv.FormatFloat(args.Value, 'f', -1, 64), args.From,
+		strconv.FormatFloat(result, 'f', 6, 64), args.To), nil
+}
+
+// convertUnits converts value from one unit to another
+func convertUnits(value float64, from, to string) (float64, error) {
+	from = strings.ToLower(strings.TrimSpace(from))
+	to = strings.ToLower(strings.TrimSpace(to))
+
+	if isTemperatureUnit(from) || isTemperatureUnit(to) {
+		if !isTemperatureUnit(from) || !isTemperatureUnit(to) {
+			return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+		}
+		return convertTemperature(value, from, to), nil
+	}
+
+	source, ok := unitFactors[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit: %s", from)
+	}
+	target, ok := unitFactors[to]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit: %s", to)
+	}
+	if source.dimension != target.dimension {
+		return 0, fmt.Errorf("cannot convert %s (%s) to %s (%s)", from, source.dimension, to, target.dimension)
+	}
+
+	return value * source.factor / target.factor, nil
+}
+
+func isTemperatureUnit(unit string) bool {
+	return unit == "c" || unit == "f" || unit == "k"
+}
+
+// convertTemperature converts between Celsius, Fahrenheit and Kelvin
+func convertTemperature(value float64, from, to string) float64 {
+	celsius := value
+	switch from {
+	case "f":
+		celsius = (value - 32) * 5 / 9
+	case "k":
+		celsius = value - 273.15
+	}
+
+	switch to {
+	case "f":
+		return celsius*9/5 + 32
+	case "k":
+		return celsius + 273.15
+	}
+	return celsius
+}
+
+// WebSearchTool searches the web via the Brave Search API
+type WebSearchTool struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewWebSearchTool creates a new web search tool
+func NewWebSearchTool(apiKey string) *WebSearchTool {
+	return &WebSearchTool{
+		apiKey: apiKey,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// Definition describes the web search tool
+func (t *WebSearchTool) Definition() ChatTool {
+	return ChatTool{
+		Type: "function",
+		Function: ChatToolFunction{
+			Name:        "web_search",
+			Description: "Search the public web for up-to-date information not found in the user's documents. Returns titles, URLs and snippets.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"query"},
+			},
+		},
+	}
+}
+
+// Call performs the web search
+func (t *WebSearchTool) Call(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil || args.Query == "" {
+		return "", fmt.Errorf("invalid arguments")
+	}
+
+	endpoint := "https://api.search.brave.com/res/v1/web/search?count=5&q=" + url.QueryEscape(args.Query)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", t.apiKey)
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", httpretry.ResponseError("API", resp)
+	}
+
+	var searchResp struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(searchResp.Web.Results) == 0 {
+		return "No web results found.", nil
+	}
+
+	var sb strings.Builder
+	for i, result := range searchResp.Web.Results {
+		fmt.Fprintf(&sb, "[%d] %s (%s)\n%s\n\n", i+1, result.Title, result.URL, result.Description)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// travelQuestion matches questions about trips, bookings and travel documents
+var travelQuestion = regexp.MustCompile(`(?i)\b(trips?|travel\w*|flights?|hotels?|bookings?|reservations?|confirmation|pnr|itinerar\w+|check-?in|boarding)\b`)
+
+// maxTravelFacts caps the bookings listed for a travel question that names no place or reference
+const maxTravelFacts = 10
+
+// UpcomingTravel lists the bookings from the user's travel documents for trips that haven't ended, soonest first
+func (s *DocumentService) UpcomingTravel(ctx context.Context, userID string) ([]*model.TravelBooking, error) {
+	return s.chunkRepo.ListUpcomingTravel(ctx, userID, startOfDay(time.Now()))
+}
+
+// travelFacts renders the user's upcoming bookings as structured data for the prompt, so references
+// and dates are quoted exactly. Bookings mentioning a term from the question (a city, hotel or
+// reference) are preferred; otherwise the next few trips are listed.
+func (s *RAGService) travelFacts(ctx context.Context, userID, question string, now time.Time) string {
+	bookings, err := s.chunkRepo.ListUpcomingTravel(ctx, userID, startOfDay(now))
+	if err != nil {
+		// The question can still be answered from the retrieved chunks
+		logger.Error("Failed to list travel bookings", "user_id", userID, "error", err)
+		return ""
+	}
+
+	terms := significantTerms(question)
+	var matching []*model.TravelBooking
+	for _, booking := range bookings {
+		fields := strings.ToLower(strings.Join(append(append([]string{booking.Filename, booking.Hotel}, booking.Places...), booking.BookingRefs...), " "))
+		for _, term := range terms {
+			if strings.Contains(fields, term) {
+				matching = append(matching, booking)
+				break
+			}
+		}
+	}
+	if len(matching) == 0 {
+		matching = bookings
+	}
+	if len(matching) > maxTravelFacts {
+		matching = matching[:maxTravelFacts]
+	}
+	if len(matching) == 0 {
+		return ""
+	}
+
+	var facts strings.Builder
+	fmt.Fprintf(&facts, "Upcoming travel bookings as of %s:\n", now.Format("2006-01-02"))
+	for _, booking := range matching {
+		fields := []string{booking.DocType}
+		if booking.Hotel != "" {
+			fields = append(fields, "hotel "+booking.Hotel)
+		}
+		if len(booking.Places) > 0 {
+			fields = append(fields, "places "+strings.Join(booking.Places, " / "))
+		}
+		if len(booking.FlightNumbers) > 0 {
+			fields = append(fields, "flights "+strings.Join(booking.FlightNumbers, ", "))
+		}
+		if len(booking.BookingRefs) > 0 {
+			fields = append(fields, "booking/confirmation "+strings.Join(booking.BookingRefs, ", "))
+		}
+		fields = append(fields, fmt.Sprintf("%s to %s", booking.StartsAt.Format("2006-01-02"), booking.EndsAt.Format("2006-01-02")))
+
+		fmt.Fprintf(&facts, "- %s [%s]\n", strings.Join(fields, "; "), booking.Filename)
+	}
+	return facts.String()
+}
+
+// structuredFacts gathers data from document metadata that answers the question more exactly than
+// retrieved chunks, such as warranty expiries and booking references
+func (s *RAGService) structuredFacts(ctx context.Context, userID, question, warranty string, now time.Time) string {
+	var facts []string
+	if warranty != "" || warrantyStatusQuestion.MatchString(question) {
+		facts = append(facts, s.warrantyFacts(ctx, userID, warranty, now))
+	}
+	if travelQuestion.MatchString(question) {
+		facts = append(facts, s.travelFacts(ctx, userID, question, now))
+	}
+	return strings.Join(facts, "")
+}
+
+// startOfDay truncates t to midnight UTC, so trips ending today still count as upcoming
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
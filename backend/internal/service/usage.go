@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// modelPrice is the USD price per million tokens for a model
+type modelPrice struct {
+	input  float64
+	output float64
+}
+
+// modelPrices lists known OpenAI prices; models are matched by longest prefix
+// so dated snapshots (e.g. gpt-4o-mini-2024-07-18) resolve to their family.
+var modelPrices = map[string]modelPrice{
+	"gpt-3.5-turbo":          {input: 0.50, output: 1.50},
+	"gpt-4o":                 {input: 2.50, output: 10.00},
+	"gpt-4o-mini":            {input: 0.15, output: 0.60},
+	"gpt-4.1":                {input: 2.00, output: 8.00},
+	"gpt-4.1-mini":           {input: 0.40, output: 1.60},
+	"gpt-4-turbo":            {input: 10.00, output: 30.00},
+	"text-embedding-3-small": {input: 0.02},
+	"text-embedding-3-large": {input: 0.13},
+}
+
+// priceFor returns the price of a model, or zero when it is unknown
+func priceFor(modelName string) modelPrice {
+	best := ""
+	for name := range modelPrices {
+		if strings.HasPrefix(modelName, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	return modelPrices[best]
+}
+
+// usageTracker accumulates token usage across the API calls made for one query
+type usageTracker struct {
+	mu    sync.Mutex
+	usage model.TokenUsage
+}
+
+type usageTrackerKey struct{}
+
+// withUsageTracker returns a context that records token usage of API calls made with it
+func withUsageTracker(ctx context.Context) (context.Context, *usageTracker) {
+	tracker := &usageTracker{}
+	return context.WithValue(ctx, usageTrackerKey{}, tracker), tracker
+}
+
+// Usage returns the usage recorded so far
+func (t *usageTracker) Usage() model.TokenUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+// trackEmbeddingUsage records embedding tokens on the context's tracker, if any
+func trackEmbeddingUsage(ctx context.Context, modelName string, tokens int) {
+	tracker, ok := ctx.Value(usageTrackerKey{}).(*usageTracker)
+	if !ok {
+		return
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.usage.EmbeddingTokens += tokens
+	tracker.usage.CostUSD += float64(tokens) * priceFor(modelName).input / 1e6
+}
+
+// trackBatchEmbeddingUsage records embedding tokens processed by the OpenAI Batch API, which are
+// billed at a discount, on the context's tracker, if any
+func trackBatchEmbeddingUsage(ctx context.Context, modelName string, tokens int) {
+	tracker, ok := ctx.Value(usageTrackerKey{}).(*usageTracker)
+	if !ok {
+		return
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.usage.EmbeddingTokens += tokens
+	tracker.usage.CostUSD += float64(tokens) * priceFor(modelName).input * openAIBatchDiscount / 1e6
+}
+
+// trackChatUsage records chat completion tokens on the context's tracker, if any
+func trackChatUsage(ctx context.Context, modelName string, promptTokens, completionTokens int) {
+	tracker, ok := ctx.Value(usageTrackerKey{}).(*usageTracker)
+	if !ok {
+		return
+	}
+
+	price := priceFor(modelName)
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.usage.PromptTokens += promptTokens
+	tracker.usage.CompletionTokens += completionTokens
+	tracker.usage.CostUSD += (float64(promptTokens)*price.input + float64(completionTokens)*price.output) / 1e6
+}
+
+// Embedding usage sources: how a document arrived, or why it was embedded again
+const (
+	EmbeddingSourceUpload        = "upload"
+	EmbeddingSourceKnowledgeBase = "knowledge_base"
+	EmbeddingSourceShortcuts     = "shortcuts"
+	EmbeddingSourceMatrix        = "matrix"
+	EmbeddingSourceDiscord       = "discord"
+	EmbeddingSourceSummary       = "summary"
+	EmbeddingSourceReembed       = "reembed"
+	EmbeddingSourceDriftCheck    = "drift_check"
+	EmbeddingSourceChunking      = "chunking"
+	EmbeddingSourceOnboarding    = "onboarding"
+	EmbeddingSourceImport        = "import"
+	EmbeddingSourceCrawl         = "crawl"
+)
+
+// recordEmbeddingUsage stores the embedding tokens on tracker against a document. Nothing is
+// stored when every embedding came from the cache, and failures are only logged so usage
+// accounting never fails ingestion.
+func recordEmbeddingUsage(ctx context.Context, documentRepo *repository.DocumentRepository, tracker *usageTracker, usage model.EmbeddingUsage) {
+	tracked := tracker.Usage()
+	if tracked.EmbeddingTokens == 0 {
+		return
+	}
+	usage.Tokens = tracked.EmbeddingTokens
+	usage.CostUSD = tracked.CostUSD
+
+	if err := documentRepo.RecordEmbeddingUsage(ctx, &usage); err != nil {
+		logger.Error("Failed to record embedding usage", "user_id", usage.UserID, "document_id", usage.DocumentID, "error", err)
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Usage granularities
+const (
+	UsageDaily   = "daily"
+	UsageMonthly = "monthly"
+)
+
+// UsageService reports token usage and cost
+type UsageService struct {
+	usageRepo *repository.UsageRepository
+}
+
+// NewUsageService creates a new usage service
+func NewUsageService(usageRepo *repository.UsageRepository) *UsageService {
+	return &UsageService{usageRepo: usageRepo}
+}
+
+// topEmbeddedDocuments is the number of documents listed in the ingestion usage breakdown
+const topEmbeddedDocuments = 20
+
+// UsageReport is the usage of a user per period with totals over the whole range. Periods and
+// Total cover queries; Ingestion covers embedding documents.
+type UsageReport struct {
+	Granularity string               `json:"granularity"`
+	From        time.Time            `json:"from"`
+	To          time.Time            `json:"to"`
+	Periods     []*model.UsagePeriod `json:"periods"`
+	Total       model.UsagePeriod    `json:"total"`
+	Ingestion   IngestionUsage       `json:"ingestion"`
+}
+
+// IngestionUsage breaks down the embedding tokens spent on documents by source (upload,
+// knowledge_base, ...), by knowledge base folder and by document
+type IngestionUsage struct {
+	Tokens       int                          `json:"tokens"`
+	CostUSD      float64                      `json:"cost_usd"`
+	BySource     []*model.EmbeddingUsageGroup `json:"by_source"`
+	ByFolder     []*model.EmbeddingUsageGroup `json:"by_folder"`
+	TopDocuments []*model.EmbeddingUsage      `json:"top_documents"`
+}
+
+// Report aggregates a user's usage by day or month. A nil from defaults to the last
+// 30 days (daily) or 12 months (monthly); a nil to defaults to now.
+func (s *UsageService) Report(ctx context.Context, userID, granularity string, from, to *time.Time) (*UsageReport, error) {
+	var period string
+	switch granularity {
+	case "", UsageDaily:
+		granularity, period = UsageDaily, "day"
+	case UsageMonthly:
+		period = "month"
+	default:
+		return nil, fmt.Errorf("granularity must be %q or %q", UsageDaily, UsageMonthly)
+	}
+
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	start := end.AddDate(0, 0, -30)
+	if granularity == UsageMonthly {
+		start = end.AddDate(-1, 0, 0)
+	}
+	if from != nil {
+		start = *from
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	periods, err := s.usageRepo.Aggregate(ctx, userID, period, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UsageReport{Granularity: granularity, From: start, To: end, Periods: periods}
+	for _, p := range periods {
+		report.Total.Queries += p.Queries
+		report.Total.EmbeddingTokens += p.EmbeddingTokens
+		report.Total.PromptTokens += p.PromptTokens
+		report.Total.CompletionTokens += p.CompletionTokens
+		report.Total.CostUSD += p.CostUSD
+	}
+	report.Total.Period = start
+
+	if report.Ingestion.BySource, err = s.usageRepo.AggregateEmbeddings(ctx, userID, "source", start, end); err != nil {
+		return nil, err
+	}
+	if report.Ingestion.ByFolder, err = s.usageRepo.AggregateEmbeddings(ctx, userID, "folder", start, end); err != nil {
+		return nil, err
+	}
+	if report.Ingestion.TopDocuments, err = s.usageRepo.TopEmbeddedDocuments(ctx, userID, start, end, topEmbeddedDocuments); err != nil {
+		return nil, err
+	}
+	for _, g := range report.Ingestion.BySource {
+		report.Ingestion.Tokens += g.Tokens
+		report.Ingestion.CostUSD += g.CostUSD
+	}
+
+	return report, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Login warm-up limits
+const (
+	warmupTimeout = time.Minute
+	// warmupQuestions caps the pinned questions embedded ahead of time
+	warmupQuestions = 5
+)
+
+// WarmUp prepares a user's first query of a session in the background, so it doesn't pay for
+// cold caches: it resolves their embedding model and docs collection, embeds their pinned
+// questions (or, without any, their latest question) into the query embedding cache, as asked
+// and with glossary expansions as FAQ lookup and retrieval embed them, and runs a search so
+// Qdrant loads the collection.
+// Failures are only logged.
+func (s *RAGService) WarmUp(userID string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), warmupTimeout)
+		defer cancel()
+
+		start := time.Now()
+		warmed := s.warmUp(ctx, userID)
+		logger.Debug("Warmed up query path", "user_id", userID, "questions", warmed, "duration", time.Since(start))
+	}()
+}
+
+// warmUp runs the warm-up and returns how many questions were embedded
+func (s *RAGService) warmUp(ctx context.Context, userID string) int {
+	collection, err := s.vectorRepo.ActiveCollection(ctx, userID)
+	if err != nil {
+		logger.Warn("Failed to load collection for warm-up", "user_id", userID, "error", err)
+		return 0
+	}
+	if collection == "" {
+		// Nothing to search until the user adds a document
+		return 0
+	}
+
+	pinned, _, err := s.documentRepo.ListQueryHistory(ctx, userID, repository.QueryHistoryFilter{Pinned: true, Limit: warmupQuestions})
+	if err != nil {
+		logger.Warn("Failed to load pinned questions for warm-up", "user_id", userID, "error", err)
+	}
+	questions := make([]string, 0, len(pinned)+1)
+	for _, entry := range pinned {
+		questions = append(questions, entry.Question)
+	}
+	if len(questions) == 0 {
+		// Without pinned questions the latest question opens the search path
+		latest, _, err := s.documentRepo.ListQueryHistory(ctx, userID, repository.QueryHistoryFilter{Limit: 1})
+		if err != nil || len(latest) == 0 {
+			return 0
+		}
+		questions = append(questions, latest[0].Question)
+	}
+
+	warmed := 0
+	var first []float32
+	for _, question := range questions {
+		if _, err := s.embeddings.Embed(ctx, userID, question, EmbeddingQuery); err != nil {
+			logger.Warn("Failed to embed question for warm-up", "user_id", userID, "error", err)
+			return warmed
+		}
+		embedding, err := s.embeddings.Embed(ctx, userID, s.expandQuery(ctx, userID, question), EmbeddingQuery)
+		if err != nil {
+			logger.Warn("Failed to embed question for warm-up", "user_id", userID, "error", err)
+			return warmed
+		}
+		if first == nil {
+			first = embedding
+		}
+		warmed++
+	}
+
+	if _, err := s.vectorRepo.Search(ctx, userID, first, 1, nil); err != nil {
+		logger.Warn("Failed to search for warm-up", "user_id", userID, "error", err)
+	}
+	return warmed
+}
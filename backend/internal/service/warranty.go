@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/profile"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// Warranty status filters
+const (
+	WarrantyActive  = "active"
+	WarrantyExpired = "expired"
+)
+
+// warrantyStatusQuestion matches questions about which warranties are still valid or have lapsed
+var warrantyStatusQuestion = regexp.MustCompile(`(?i)\b(under|in|out\s+of)\s+warranty\b|\bwarrant(y|ies)\b.*\b(expir\w*|valid|active|lapsed)\b`)
+
+// validateWarrantyStatus checks a warranty status filter; empty means no filter
+func validateWarrantyStatus(status string) error {
+	switch status {
+	case "", WarrantyActive, WarrantyExpired:
+		return nil
+	}
+	return fmt.Errorf("warranty must be %s or %s", WarrantyActive, WarrantyExpired)
+}
+
+// withWarrantyStatus restricts a search filter to chunks whose warranty is active (or expired) at now.
+// Chunks without a warranty expiry never match.
+func withWarrantyStatus(filter *repository.SearchFilter, status string, now time.Time) *repository.SearchFilter {
+	if status == "" {
+		return filter
+	}
+
+	restricted := &repository.SearchFilter{}
+	if filter != nil {
+		*restricted = *filter
+	}
+	ranges := make(map[string]repository.Range, len(restricted.Ranges)+1)
+	for key, bounds := range restricted.Ranges {
+		ranges[key] = bounds
+	}
+
+	cutoff := float64(now.Unix())
+	if status == WarrantyActive {
+		ranges[profile.WarrantyExpiresAtField] = repository.Range{Gte: &cutoff}
+	} else {
+		ranges[profile.WarrantyExpiresAtField] = repository.Range{Lt: &cutoff}
+	}
+	restricted.Ranges = ranges
+	return restricted
+}
+
+// filterWarranties marks each warranty active or expired at now and keeps those with the given status (all when empty)
+func filterWarranties(warranties []*model.Warranty, status string, now time.Time) []*model.Warranty {
+	filtered := make([]*model.Warranty, 0, len(warranties))
+	for _, warranty := range warranties {
+		warranty.Active = !warranty.ExpiresAt.Before(now)
+		if status == "" || (status == WarrantyActive) == warranty.Active {
+			filtered = append(filtered, warranty)
+		}
+	}
+	return filtered
+}
+
+// ListWarranties lists the warranties extracted from a user's manuals and warranty documents,
+// soonest expiry first, optionally only those that are active or expired
+func (s *DocumentService) ListWarranties(ctx context.Context, userID, status string) ([]*model.Warranty, error) {
+	if err := validateWarrantyStatus(status); err != nil {
+		return nil, err
+	}
+
+	warranties, err := s.chunkRepo.ListWarranties(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return filterWarranties(warranties, status, time.Now()), nil
+}
+
+// warrantyFacts renders the user's warranties with the given status as structured data for the prompt,
+// so questions about which products are covered are answered from every document, not just the retrieved chunks
+func (s *RAGService) warrantyFacts(ctx context.Context, userID, status string, now time.Time) string {
+	warranties, err := s.chunkRepo.ListWarranties(ctx, userID)
+	if err != nil {
+		// The question can still be answered from the retrieved chunks
+		logger.Error("Failed to list warranties", "user_id", userID, "error", err)
+		return ""
+	}
+	warranties = filterWarranties(warranties, status, now)
+	if len(warranties) == 0 {
+		return ""
+	}
+
+	var facts strings.Builder
+	fmt.Fprintf(&facts, "Warranties as of %s:\n", now.Format("2006-01-02"))
+	for _, warranty := range warranties {
+		name := warranty.Product
+		if name == "" {
+			name = warranty.Filename
+		}
+		if len(warranty.ModelNumbers) > 0 {
+			name = fmt.Sprintf("%s (model %s)", name, strings.Join(warranty.ModelNumbers, ", "))
+		}
+
+		state := "expired on"
+		if warranty.Active {
+			state = "active until"
+		}
+		fmt.Fprintf(&facts, "- %s: %s %s [%s]\n", name, state, warranty.ExpiresAt.Format("2006-01-02"), warranty.Filename)
+	}
+	return facts.String()
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/apperror"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/netguard"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/notification"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/queue"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
+)
+
+// JobWebhookDeliver is the queue job type for sending one webhook delivery
+const JobWebhookDeliver = "webhook.deliver"
+
+// WebhookDeliverJob is the payload of a JobWebhookDeliver job
+type WebhookDeliverJob struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// Headers sent with every webhook delivery. The signature is
+// "v1=" + hex(HMAC-SHA256(secret, timestamp + "." + body)); receivers should
+// recompute it and reject timestamps older than a few minutes.
+const (
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// webhookSecretPrefix marks webhook signing secrets
+const webhookSecretPrefix = "whsec_"
+
+// WebhookService manages webhooks and delivers notification events to them.
+// Deliveries run as queue jobs, so failed attempts are retried with exponential backoff.
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	jobs        queue.Queue
+	httpClient  *http.Client
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(webhookRepo *repository.WebhookRepository, jobs queue.Queue) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		jobs:        jobs,
+		// Webhook URLs come from users, so deliveries only go to public addresses
+		httpClient: netguard.NewClient(10 * time.Second),
+	}
+}
+
+// CreateWebhookInput represents the fields of a new webhook
+type CreateWebhookInput struct {
+	URL string `json:"url"`
+	// Events subscribes to specific events (e.g. "scheduled_query.completed"); empty means all
+	Events []string `json:"events"`
+}
+
+// Create registers a webhook. The signing secret is returned only once.
+func (s *WebhookService) Create(ctx context.Context, userID string, input CreateWebhookInput) (*model.Webhook, string, error) {
+	endpoint, err := url.Parse(strings.TrimSpace(input.URL))
+	if err != nil {
+		return nil, "", fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if err := netguard.CheckURL(endpoint); err != nil {
+		return nil, "", err
+	}
+
+	events := []string{}
+	for _, event := range input.Events {
+		if event = strings.TrimSpace(event); event != "" {
+			events = append(events, event)
+		}
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	webhook := &model.Webhook{
+		UserID:  userID,
+		URL:     endpoint.String(),
+		Secret:  webhookSecretPrefix + hex.EncodeToString(secret),
+		Events:  events,
+		Enabled: true,
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, "", err
+	}
+
+	return webhook, webhook.Secret, nil
+}
+
+// List lists a user's webhooks
+func (s *WebhookService) List(ctx context.Context, userID string) ([]*model.Webhook, error) {
+	return s.webhookRepo.ListByUserID(ctx, userID)
+}
+
+// Delete deletes a webhook and its delivery log
+func (s *WebhookService) Delete(ctx context.Context, userID, id string) error {
+	return s.webhookRepo.Delete(ctx, userID, id)
+}
+
+// ListDeliveries lists a webhook's delivery log
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID, webhookID string, limit, offset int) ([]*model.WebhookDelivery, error) {
+	if _, err := s.getOwned(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.webhookRepo.ListDeliveries(ctx, webhookID, limit, offset)
+}
+
+// Redeliver queues a logged delivery to be sent again with a fresh signature
+func (s *WebhookService) Redeliver(ctx context.Context, userID, deliveryID string) (*model.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getOwned(ctx, userID, delivery.WebhookID); err != nil {
+		return nil, fmt.Errorf("webhook delivery %w", apperror.ErrNotFound)
+	}
+
+	if err := s.webhookRepo.ResetDelivery(ctx, delivery.ID); err != nil {
+		return nil, err
+	}
+	if err := s.jobs.Enqueue(ctx, JobWebhookDeliver, WebhookDeliverJob{DeliveryID: delivery.ID}); err != nil {
+		return nil, err
+	}
+
+	delivery.Status = WebhookDeliveryPending
+	return delivery, nil
+}
+
+// getOwned loads a webhook and checks that it belongs to the user
+func (s *WebhookService) getOwned(ctx context.Context, userID, id string) (*model.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.UserID != userID {
+		return nil, fmt.Errorf("webhook %w", apperror.ErrNotFound)
+	}
+	return webhook, nil
+}
+
+// Notify implements notification.Notifier by logging and queueing a delivery
+// to each of the user's webhooks subscribed to the event
+func (s *WebhookService) Notify(ctx context.Context, n notification.Notification) error {
+	webhooks, err := s.webhookRepo.ListSubscribed(ctx, n.UserID, n.Event)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		delivery := &model.WebhookDelivery{
+			WebhookID: webhook.ID,
+			Event:     n.Event,
+			Payload: map[string]interface{}{
+				"title": n.Title,
+				"body":  n.Body,
+				"data":  n.Data,
+			},
+		}
+		if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+		if err := s.jobs.Enqueue(ctx, JobWebhookDeliver, WebhookDeliverJob{DeliveryID: delivery.ID}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HandleDeliver processes a JobWebhookDeliver job, recording each attempt in the delivery log
+func (s *WebhookService) HandleDeliver(ctx context.Context, job *queue.Job) error {
+	var payload WebhookDeliverJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return queue.Permanent(fmt.Errorf("invalid webhook job payload: %w", err))
+	}
+
+	delivery, err := s.webhookRepo.GetDelivery(ctx, payload.DeliveryID)
+	if err != nil {
+		return queue.Permanent(err)
+	}
+	webhook, err := s.webhookRepo.GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		return queue.Permanent(err)
+	}
+
+	statusCode, deliveryErr := s.send(ctx, webhook, delivery)
+	if deliveryErr == nil {
+		return s.webhookRepo.RecordAttempt(ctx, delivery.ID, WebhookDeliverySucceeded, statusCode, "")
+	}
+
+	// Client errors won't succeed on retry, except timeouts and rate limiting, and neither will
+	// requests to, or redirected to, a non-public address
+	permanent := errors.Is(deliveryErr, netguard.ErrBlockedHost) ||
+		(statusCode >= 400 && statusCode < 500 &&
+			statusCode != http.StatusRequestTimeout && statusCode != http.StatusTooManyRequests)
+
+	status := WebhookDeliveryPending
+	if permanent || job.Attempt >= queue.MaxAttempts {
+		status = WebhookDeliveryFailed
+	}
+	if err := s.webhookRepo.RecordAttempt(ctx, delivery.ID, status, statusCode, deliveryErr.Error()); err != nil {
+		return err
+	}
+
+	if permanent {
+		return queue.Permanent(deliveryErr)
+	}
+	return deliveryErr
+}
+
+// send posts a signed delivery and returns the response status
+func (s *WebhookService) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"id":         delivery.ID,
+		"event":      delivery.Event,
+		"created_at": delivery.CreatedAt,
+		"data":       delivery.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal webhook body: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Webhooks created before hosts were checked may point at internal ones
+	if err := netguard.CheckURL(req.URL); err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "rag-personal-assistant-webhooks")
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "v1="+signWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// signWebhookPayload computes the hex HMAC-SHA256 of timestamp + "." + body
+func signWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
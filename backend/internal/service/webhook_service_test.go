@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/netguard"
+)
+
+// roundTripFunc answers requests without a network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// testDelivery is a delivery to send to a test webhook
+func testDelivery() *model.WebhookDelivery {
+	return &model.WebhookDelivery{
+		ID:        "delivery-1",
+		Event:     "scheduled_query.completed",
+		Payload:   map[string]interface{}{"title": "Weekly deadlines"},
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestWebhookCreateRejectsPrivateHosts(t *testing.T) {
+	s := NewWebhookService(nil, nil)
+
+	for _, raw := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.20/hook",
+		"http://postgres:5432/",
+		"ftp://hooks.example.com/",
+	} {
+		if _, _, err := s.Create(context.Background(), "user-1", CreateWebhookInput{URL: raw}); err == nil {
+			t.Errorf("Create(%q) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestWebhookSendRefusesLoopback(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	s := NewWebhookService(nil, nil)
+	webhook := &model.Webhook{ID: "webhook-1", URL: server.URL + "/hook", Secret: "whsec_test"}
+
+	_, err := s.send(context.Background(), webhook, testDelivery())
+	if !errors.Is(err, netguard.ErrBlockedHost) {
+		t.Fatalf("send to %s: got %v, want %v", server.URL, err, netguard.ErrBlockedHost)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("loopback server received %d requests, want none", n)
+	}
+}
+
+func TestWebhookSendRefusesRedirectToPrivateAddress(t *testing.T) {
+	var hosts []string
+	s := NewWebhookService(nil, nil)
+	// Stand in for the network, keeping the guarded client's redirect policy
+	s.httpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+		return &http.Response{
+			StatusCode: http.StatusTemporaryRedirect,
+			Header:     http.Header{"Location": []string{"http://169.254.169.254/latest/meta-data/"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	})
+	webhook := &model.Webhook{ID: "webhook-1", URL: "https://hooks.example.com/hook", Secret: "whsec_test"}
+
+	_, err := s.send(context.Background(), webhook, testDelivery())
+	if !errors.Is(err, netguard.ErrBlockedHost) {
+		t.Fatalf("send: got %v, want %v", err, netguard.ErrBlockedHost)
+	}
+	if len(hosts) != 1 || hosts[0] != "hooks.example.com" {
+		t.Errorf("requested hosts %v, want only hooks.example.com", hosts)
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// Widget limits
+const (
+	// MaxWidgetQuestionLength caps visitor questions, which spend the key owner's tokens
+	MaxWidgetQuestionLength = 500
+	maxWidgetSources        = 3
+)
+
+// WidgetService answers questions asked through the embeddable "ask my docs" widget
+type WidgetService struct {
+	apiKeyService *APIKeyService
+	ragService    *RAGService
+}
+
+// NewWidgetService creates a new widget service
+func NewWidgetService(apiKeyService *APIKeyService, ragService *RAGService) *WidgetService {
+	return &WidgetService{
+		apiKeyService: apiKeyService,
+		ragService:    ragService,
+	}
+}
+
+// WidgetAnswer is the answer shown in the widget, with the titles of the documents it came from
+type WidgetAnswer struct {
+	Answer  string   `json:"answer"`
+	Sources []string `json:"sources"`
+}
+
+// Authorize resolves a widget key and checks that origin may use it. An empty origin skips the
+// check, for requests from the widget page itself and browsers that send no referrer.
+func (s *WidgetService) Authorize(ctx context.Context, plaintext, origin string) (*model.APIKey, error) {
+	if !strings.HasPrefix(plaintext, APIKeyPrefix) {
+		return nil, fmt.Errorf("invalid API key")
+	}
+	key, err := s.apiKeyService.Authenticate(ctx, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if !IsWidgetKey(key) {
+		return nil, fmt.Errorf("API key is not a widget key")
+	}
+	if origin != "" && !AllowsOrigin(key, origin) {
+		return nil, fmt.Errorf("origin not allowed for this key")
+	}
+	return key, nil
+}
+
+// Ask answers a visitor's question from the key owner's documents. Questions are kept in the
+// owner's query history but don't start conversations.
+func (s *WidgetService) Ask(ctx context.Context, key *model.APIKey, question string) (*WidgetAnswer, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, fmt.Errorf("question is required")
+	}
+	if len(question) > MaxWidgetQuestionLength {
+		return nil, fmt.Errorf("question must be at most %d characters", MaxWidgetQuestionLength)
+	}
+
+	response, err := s.ragService.Query(ctx, key.UserID, QueryRequest{
+		Question:   question,
+		Standalone: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &WidgetAnswer{
+		Answer:  response.Answer,
+		Sources: sourceTitles(response.Sources, maxWidgetSources),
+	}, nil
+}
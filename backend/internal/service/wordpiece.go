@@ -0,0 +1,127 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// wordPieceMaxWordChars is the longest word split into pieces; longer words become [UNK]
+const wordPieceMaxWordChars = 100
+
+// wordPieceTokenizer is the uncased BERT tokenizer used by small local embedding models such as
+// all-MiniLM-L6-v2 and bge-small-en-v1.5. It reads the model's vocab.txt, one token per line.
+type wordPieceTokenizer struct {
+	vocab map[string]int64
+	cls   int64
+	sep   int64
+	unk   int64
+	pad   int64
+}
+
+// loadWordPieceTokenizer loads a tokenizer from a vocab.txt file
+func loadWordPieceTokenizer(path string) (*wordPieceTokenizer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocabulary: %w", err)
+	}
+	defer file.Close()
+
+	vocab := make(map[string]int64)
+	scanner := bufio.NewScanner(file)
+	for id := int64(0); scanner.Scan(); id++ {
+		vocab[strings.TrimRight(scanner.Text(), "\r")] = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+
+	t := &wordPieceTokenizer{vocab: vocab}
+	for token, id := range map[string]*int64{"[CLS]": &t.cls, "[SEP]": &t.sep, "[UNK]": &t.unk, "[PAD]": &t.pad} {
+		tokenID, ok := vocab[token]
+		if !ok {
+			return nil, fmt.Errorf("vocabulary %s has no %s token", path, token)
+		}
+		*id = tokenID
+	}
+	return t, nil
+}
+
+// Encode returns the token IDs of text wrapped in [CLS] and [SEP], truncated to maxTokens
+func (t *wordPieceTokenizer) Encode(text string, maxTokens int) []int64 {
+	ids := []int64{t.cls}
+	for _, word := range basicTokenize(text) {
+		ids = append(ids, t.wordPieces(word)...)
+		if len(ids) >= maxTokens-1 {
+			ids = ids[:maxTokens-1]
+			break
+		}
+	}
+	return append(ids, t.sep)
+}
+
+// wordPieces splits a word into the longest vocabulary pieces, continuation pieces prefixed with ##
+func (t *wordPieceTokenizer) wordPieces(word string) []int64 {
+	runes := []rune(word)
+	if len(runes) > wordPieceMaxWordChars {
+		return []int64{t.unk}
+	}
+
+	var ids []int64
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		var id int64 = -1
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if pieceID, ok := t.vocab[piece]; ok {
+				id = pieceID
+				break
+			}
+		}
+		if id < 0 {
+			return []int64{t.unk}
+		}
+		ids = append(ids, id)
+		start = end
+	}
+	return ids
+}
+
+// basicTokenize lowercases text, strips accents and splits it into words and punctuation,
+// putting each CJK character on its own, as BERT's uncased basic tokenizer does
+func basicTokenize(text string) []string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar || (unicode.IsControl(r) && !unicode.IsSpace(r)):
+			continue
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		case isBERTPunctuation(r) || unicode.Is(unicode.Han, r):
+			b.WriteRune(' ')
+			b.WriteRune(r)
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Fields(b.String())
+}
+
+// isBERTPunctuation reports whether BERT splits on the character: Unicode punctuation plus every
+// non-alphanumeric ASCII symbol (such as $ and ^)
+func isBERTPunctuation(r rune) bool {
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/model"
+)
+
+// WorkspaceTemplate is a starting setup for a common persona: an assistant persona, glossary
+// terms, saved query templates, standing scheduled questions and retrieval blocklist rules
+type WorkspaceTemplate struct {
+	Name             string                `json:"name"`
+	Description      string                `json:"description"`
+	SystemPrompt     string                `json:"system_prompt"`
+	GlossaryTerms    []GlossaryTermInput   `json:"glossary_terms"`
+	SavedQueries     []SavedQueryInput     `json:"saved_queries"`
+	ScheduledQueries []ScheduledQueryInput `json:"scheduled_queries"`
+	BlocklistRules   []BlocklistRuleInput  `json:"blocklist_rules"`
+}
+
+// WorkspaceTemplateOmissions are parts of a workspace templates don't create, with why
+var WorkspaceTemplateOmissions = map[string]string{
+	"collections": "documents aren't grouped into collections; saved and scheduled queries filter by ingestion profile instead",
+	"tags":        "documents aren't tagged by hand; the ingestion profile detected or chosen at upload is their category",
+}
+
+// ErrUnknownWorkspaceTemplate is returned when no template has the requested name
+var ErrUnknownWorkspaceTemplate = errors.New("unknown workspace template")
+
+// workspaceTemplates are the available templates. Filters use the metadata of the ingestion
+// profiles, so they match documents uploaded with (or detected as) that profile.
+var workspaceTemplates = []WorkspaceTemplate{
+	{
+		Name:        "student",
+		Description: "Lecture notes, readings and assignments",
+		SystemPrompt: "You are a study assistant. Explain concepts step by step, define jargon the first time it appears, " +
+			"and cite the lecture notes or readings, with page numbers, that each answer comes from.",
+		GlossaryTerms: []GlossaryTermInput{
+			{Term: "TA", Expansion: "teaching assistant"},
+			{Term: "GPA", Expansion: "grade point average"},
+			{Term: "ECTS", Expansion: "European credit transfer system credits"},
+		},
+		SavedQueries: []SavedQueryInput{
+			{Name: "Summarize a topic", Template: "Summarize the key points about {topic} from my lecture notes and readings"},
+			{Name: "Exam practice", Template: "Write five likely exam questions about {topic}, each with a short model answer"},
+			{Name: "Explain simply", Template: "Explain {concept} as if I were seeing it for the first time, with an example"},
+		},
+		ScheduledQueries: []ScheduledQueryInput{
+			{Question: "Which assignments, exams and deadlines are coming up in the next two weeks?", Frequency: FrequencyWeekly},
+		},
+		BlocklistRules: []BlocklistRuleInput{
+			{Pattern: "password"},
+		},
+	},
+	{
+		Name:        "freelancer",
+		Description: "Client contracts, invoices and business expenses",
+		SystemPrompt: "You are a business assistant for a freelancer. Be precise about amounts, dates and payment terms, " +
+			"quote the contract clause or invoice an answer relies on, and say so when a document doesn't settle the question.",
+		GlossaryTerms: []GlossaryTermInput{
+			{Term: "SOW", Expansion: "statement of work"},
+			{Term: "NDA", Expansion: "non-disclosure agreement"},
+			{Term: "PO", Expansion: "purchase order"},
+			{Term: "net 30", Expansion: "payment due within 30 days of the invoice date"},
+		},
+		SavedQueries: []SavedQueryInput{
+			{Name: "Contract terms", Template: "What are the deliverables, payment terms and termination clauses in my contract with {client}?", Filters: map[string]string{"profile": "contract"}},
+			{Name: "Invoices for a client", Template: "List the invoices I sent to {client} with their amounts and due dates", Filters: map[string]string{"profile": "expense", "doc_type": "invoice"}},
+			{Name: "Spending at a merchant", Template: "How much did I spend at {merchant} in {period}?", Filters: map[string]string{"profile": "expense"}},
+		},
+		ScheduledQueries: []ScheduledQueryInput{
+			{Question: "Which contracts have deadlines, renewals or notice periods in the next month?", Filters: map[string]string{"profile": "contract"}, Frequency: FrequencyWeekly},
+		},
+		BlocklistRules: []BlocklistRuleInput{
+			{Pattern: "password"},
+			// IBANs, which invoices and contracts often carry
+			{Pattern: `\b[A-Z]{2}[0-9]{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,4})?\b`, Regex: true},
+		},
+	},
+	{
+		Name:        "homeowner",
+		Description: "Appliance manuals, warranties, recipes and household bills",
+		SystemPrompt: "You are a household assistant. Give practical step-by-step answers, include model numbers and " +
+			"warranty dates when they matter, and point to the manual or receipt each answer comes from.",
+		GlossaryTerms: []GlossaryTermInput{
+			{Term: "HVAC", Expansion: "heating, ventilation and air conditioning"},
+			{Term: "HOA", Expansion: "homeowners association"},
+			{Term: "GFCI", Expansion: "ground fault circuit interrupter outlet"},
+		},
+		SavedQueries: []SavedQueryInput{
+			{Name: "Warranty check", Template: "Is my {appliance} still under warranty, and what does the warranty cover?", Filters: map[string]string{"profile": "household"}},
+			{Name: "Manual lookup", Template: "How do I {task} according to the {appliance} manual?", Filters: map[string]string{"profile": "household"}},
+			{Name: "Home spending", Template: "How much have I spent on {category} for the house this year?", Filters: map[string]string{"profile": "expense"}},
+		},
+		ScheduledQueries: []ScheduledQueryInput{
+			{Question: "Which appliance warranties expire in the next 60 days?", Filters: map[string]string{"profile": "household"}, Frequency: FrequencyWeekly},
+		},
+		BlocklistRules: []BlocklistRuleInput{
+			{Pattern: "wifi password"},
+			{Pattern: "alarm code"},
+			{Pattern: "gate code"},
+		},
+	},
+}
+
+// WorkspaceService sets up a user's workspace from a template in one call
+type WorkspaceService struct {
+	settingsService       *SettingsService
+	glossaryService       *GlossaryService
+	savedQueryService     *SavedQueryService
+	scheduledQueryService *ScheduledQueryService
+	blocklistService      *BlocklistService
+}
+
+// NewWorkspaceService creates a new workspace service
+func NewWorkspaceService(
+	settingsService *SettingsService,
+	glossaryService *GlossaryService,
+	savedQueryService *SavedQueryService,
+	scheduledQueryService *ScheduledQueryService,
+	blocklistService *BlocklistService,
+) *WorkspaceService {
+	return &WorkspaceService{
+		settingsService:       settingsService,
+		glossaryService:       glossaryService,
+		savedQueryService:     savedQueryService,
+		scheduledQueryService: scheduledQueryService,
+		blocklistService:      blocklistService,
+	}
+}
+
+// WorkspaceSetup lists what applying a template created. Items the user already had are
+// counted in Skipped instead.
+type WorkspaceSetup struct {
+	Template         string                  `json:"template"`
+	SystemPromptSet  bool                    `json:"system_prompt_set"`
+	GlossaryTerms    []*model.GlossaryTerm   `json:"glossary_terms"`
+	SavedQueries     []*model.SavedQuery     `json:"saved_queries"`
+	ScheduledQueries []*model.ScheduledQuery `json:"scheduled_queries"`
+	BlocklistRules   []*model.BlocklistRule  `json:"blocklist_rules"`
+	Skipped          int                     `json:"skipped"`
+}
+
+// Templates lists the available workspace templates
+func (s *WorkspaceService) Templates() []WorkspaceTemplate {
+	return workspaceTemplates
+}
+
+// FromTemplate applies a template to the user's workspace. It only adds: an existing system
+// prompt, and glossary terms, saved queries, scheduled queries and blocklist rules matching the
+// template's, are kept as they are, so applying a template again (or after a partial failure) is
+// safe.
+func (s *WorkspaceService) FromTemplate(ctx context.Context, userID, name string) (*WorkspaceSetup, error) {
+	var template *WorkspaceTemplate
+	for i := range workspaceTemplates {
+		if workspaceTemplates[i].Name == name {
+			template = &workspaceTemplates[i]
+		}
+	}
+	if template == nil {
+		names := make([]string, len(workspaceTemplates))
+		for i, t := range workspaceTemplates {
+			names[i] = t.Name
+		}
+		return nil, fmt.Errorf("%w: %s (valid options: %s)", ErrUnknownWorkspaceTemplate, name, strings.Join(names, ", "))
+	}
+
+	setup := &WorkspaceSetup{
+		Template:         template.Name,
+		GlossaryTerms:    []*model.GlossaryTerm{},
+		SavedQueries:     []*model.SavedQuery{},
+		ScheduledQueries: []*model.ScheduledQuery{},
+		BlocklistRules:   []*model.BlocklistRule{},
+	}
+
+	settings, err := s.settingsService.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.SystemPrompt == "" {
+		_, err := s.settingsService.Update(ctx, userID, UserSettingsInput{
+			SystemPrompt: template.SystemPrompt,
+			Language:     settings.Language,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set system prompt: %w", err)
+		}
+		setup.SystemPromptSet = true
+	} else {
+		setup.Skipped++
+	}
+
+	terms, err := s.glossaryService.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existingTerms := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		existingTerms[strings.ToLower(term.Term)] = true
+	}
+	for _, input := range template.GlossaryTerms {
+		if existingTerms[strings.ToLower(input.Term)] {
+			setup.Skipped++
+			continue
+		}
+		term, err := s.glossaryService.Create(ctx, userID, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add glossary term %s: %w", input.Term, err)
+		}
+		setup.GlossaryTerms = append(setup.GlossaryTerms, term)
+	}
+
+	saved, err := s.savedQueryService.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existingSaved := make(map[string]bool, len(saved))
+	for _, q := range saved {
+		existingSaved[q.Name] = true
+	}
+	for _, input := range template.SavedQueries {
+		if existingSaved[input.Name] {
+			setup.Skipped++
+			continue
+		}
+		q, err := s.savedQueryService.Create(ctx, userID, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add saved query %s: %w", input.Name, err)
+		}
+		setup.SavedQueries = append(setup.SavedQueries, q)
+	}
+
+	scheduled, err := s.scheduledQueryService.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existingScheduled := make(map[string]bool, len(scheduled))
+	for _, sq := range scheduled {
+		existingScheduled[sq.Question] = true
+	}
+	for _, input := range template.ScheduledQueries {
+		if existingScheduled[input.Question] {
+			setup.Skipped++
+			continue
+		}
+		sq, err := s.scheduledQueryService.Create(ctx, userID, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add scheduled query: %w", err)
+		}
+		setup.ScheduledQueries = append(setup.ScheduledQueries, sq)
+	}
+
+	rules, err := s.blocklistService.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existingRules := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		existingRules[blocklistRuleKey(rule.Pattern, rule.Regex)] = true
+	}
+	for _, input := range template.BlocklistRules {
+		if existingRules[blocklistRuleKey(input.Pattern, input.Regex)] {
+			setup.Skipped++
+			continue
+		}
+		rule, err := s.blocklistService.Create(ctx, userID, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add blocklist rule: %w", err)
+		}
+		setup.BlocklistRules = append(setup.BlocklistRules, rule)
+	}
+
+	return setup, nil
+}
+
+// blocklistRuleKey identifies a blocklist rule; phrases match ignoring case, so they compare so
+func blocklistRuleKey(pattern string, regex bool) string {
+	if regex {
+		return "regex:" + pattern
+	}
+	return "phrase:" + strings.ToLower(pattern)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/chaos"
 	"github.com/qdrant/go-client/qdrant"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -12,12 +13,18 @@ import (
 // QdrantClient wraps Qdrant vector database operations
 type QdrantClient struct {
 	client qdrant.CollectionsClient
+	points qdrant.PointsClient
 	conn   *grpc.ClientConn
 }
 
 // NewQdrantClient creates a new Qdrant client
 func NewQdrantClient(url string) (*QdrantClient, error) {
-	conn, err := grpc.Dial(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	options := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if chaos.Enabled() {
+		options = append(options, grpc.WithUnaryInterceptor(chaos.UnaryClientInterceptor(chaos.TargetQdrant)))
+	}
+
+	conn, err := grpc.Dial(url, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Qdrant: %w", err)
 	}
@@ -26,6 +33,7 @@ func NewQdrantClient(url string) (*QdrantClient, error) {
 
 	return &QdrantClient{
 		client: client,
+		points: qdrant.NewPointsClient(conn),
 		conn:   conn,
 	}, nil
 }
@@ -35,9 +43,16 @@ func (q *QdrantClient) Close() error {
 	return q.conn.Close()
 }
 
-// CreateCollection creates a new collection for a user
-func (q *QdrantClient) CreateCollection(ctx context.Context, collectionName string, vectorSize uint64) error {
-	_, err := q.client.Create(ctx, &qdrant.CreateCollection{
+// SparseVectorParams names a sparse vector stored alongside a collection's unnamed dense vector
+type SparseVectorParams struct {
+	Name string
+	// IDF makes Qdrant weight the sparse vector's terms by inverse document frequency
+	IDF bool
+}
+
+// CreateCollection creates a new collection for a user, with any sparse vectors given
+func (q *QdrantClient) CreateCollection(ctx context.Context, collectionName string, vectorSize uint64, sparse ...SparseVectorParams) error {
+	request := &qdrant.CreateCollection{
 		CollectionName: collectionName,
 		VectorsConfig: &qdrant.VectorsConfig{
 			Config: &qdrant.VectorsConfig_Params{
@@ -47,7 +62,19 @@ func (q *QdrantClient) CreateCollection(ctx context.Context, collectionName stri
 				},
 			},
 		},
-	})
+	}
+	if len(sparse) > 0 {
+		params := make(map[string]*qdrant.SparseVectorParams, len(sparse))
+		for _, vector := range sparse {
+			params[vector.Name] = &qdrant.SparseVectorParams{}
+			if vector.IDF {
+				params[vector.Name].Modifier = qdrant.Modifier_Idf.Enum()
+			}
+		}
+		request.SparseVectorsConfig = qdrant.NewSparseVectorsConfig(params)
+	}
+
+	_, err := q.client.Create(ctx, request)
 
 	if err != nil {
 		return fmt.Errorf("failed to create collection: %w", err)
@@ -72,6 +99,31 @@ func (q *QdrantClient) CollectionExists(ctx context.Context, collectionName stri
 	return false, nil
 }
 
+// CollectionVectorSize returns the vector size a collection was created with
+func (q *QdrantClient) CollectionVectorSize(ctx context.Context, collectionName string) (uint64, error) {
+	response, err := q.client.Get(ctx, &qdrant.GetCollectionInfoRequest{
+		CollectionName: collectionName,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get collection info: %w", err)
+	}
+
+	return response.GetResult().GetConfig().GetParams().GetVectorsConfig().GetParams().GetSize(), nil
+}
+
+// HasSparseVector reports whether a collection was created with the named sparse vector
+func (q *QdrantClient) HasSparseVector(ctx context.Context, collectionName, vectorName string) (bool, error) {
+	response, err := q.client.Get(ctx, &qdrant.GetCollectionInfoRequest{
+		CollectionName: collectionName,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get collection info: %w", err)
+	}
+
+	_, ok := response.GetResult().GetConfig().GetParams().GetSparseVectorsConfig().GetMap()[vectorName]
+	return ok, nil
+}
+
 // DeleteCollection deletes a collection
 func (q *QdrantClient) DeleteCollection(ctx context.Context, collectionName string) error {
 	_, err := q.client.Delete(ctx, &qdrant.DeleteCollection{
@@ -84,3 +136,173 @@ func (q *QdrantClient) DeleteCollection(ctx context.Context, collectionName stri
 
 	return nil
 }
+
+// Search performs a similarity search in a collection, optionally restricted by a payload filter.
+// withVectors also returns each point's stored vector.
+func (q *QdrantClient) Search(ctx context.Context, collectionName string, vector []float32, limit uint64, filter *qdrant.Filter, withVectors bool) ([]*qdrant.ScoredPoint, error) {
+	response, err := q.points.Search(ctx, &qdrant.SearchPoints{
+		CollectionName: collectionName,
+		Vector:         vector,
+		Filter:         filter,
+		Limit:          limit,
+		WithPayload: &qdrant.WithPayloadSelector{
+			SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true},
+		},
+		WithVectors: qdrant.NewWithVectors(withVectors),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to search points: %w", err)
+	}
+
+	return response.Result, nil
+}
+
+// SearchSparse performs a search over a named sparse vector, optionally restricted by a payload
+// filter. Points are returned with their stored vectors.
+func (q *QdrantClient) SearchSparse(ctx context.Context, collectionName, vectorName string, indices []uint32, values []float32, limit uint64, filter *qdrant.Filter) ([]*qdrant.ScoredPoint, error) {
+	response, err := q.points.Query(ctx, &qdrant.QueryPoints{
+		CollectionName: collectionName,
+		Query:          qdrant.NewQuerySparse(indices, values),
+		Using:          &vectorName,
+		Filter:         filter,
+		Limit:          &limit,
+		WithPayload: &qdrant.WithPayloadSelector{
+			SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true},
+		},
+		WithVectors: qdrant.NewWithVectors(true),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sparse vectors: %w", err)
+	}
+
+	return response.Result, nil
+}
+
+// Upsert inserts or overwrites points in a collection and waits for the write to be applied
+func (q *QdrantClient) Upsert(ctx context.Context, collectionName string, points []*qdrant.PointStruct) error {
+	wait := true
+	_, err := q.points.Upsert(ctx, &qdrant.UpsertPoints{
+		CollectionName: collectionName,
+		Wait:           &wait,
+		Points:         points,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to upsert points: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves points of a collection by ID with their vectors, without payloads. IDs that
+// don't exist are left out.
+func (q *QdrantClient) Get(ctx context.Context, collectionName string, ids []string) ([]*qdrant.RetrievedPoint, error) {
+	pointIDs := make([]*qdrant.PointId, len(ids))
+	for i, id := range ids {
+		pointIDs[i] = qdrant.NewIDUUID(id)
+	}
+
+	response, err := q.points.Get(ctx, &qdrant.GetPoints{
+		CollectionName: collectionName,
+		Ids:            pointIDs,
+		WithVectors:    qdrant.NewWithVectors(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get points: %w", err)
+	}
+
+	return response.GetResult(), nil
+}
+
+// Delete deletes the points of a collection matching a filter and waits for the write to be
+// applied
+func (q *QdrantClient) Delete(ctx context.Context, collectionName string, filter *qdrant.Filter) error {
+	wait := true
+	_, err := q.points.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: collectionName,
+		Wait:           &wait,
+		Points:         qdrant.NewPointsSelectorFilter(filter),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to delete points: %w", err)
+	}
+
+	return nil
+}
+
+// Count returns the exact number of points in a collection
+func (q *QdrantClient) Count(ctx context.Context, collectionName string) (uint64, error) {
+	exact := true
+	response, err := q.points.Count(ctx, &qdrant.CountPoints{
+		CollectionName: collectionName,
+		Exact:          &exact,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count points: %w", err)
+	}
+
+	return response.GetResult().GetCount(), nil
+}
+
+// AliasTarget returns the collection an alias points to, or "" if no such alias exists
+func (q *QdrantClient) AliasTarget(ctx context.Context, alias string) (string, error) {
+	response, err := q.client.ListAliases(ctx, &qdrant.ListAliasesRequest{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list aliases: %w", err)
+	}
+
+	for _, description := range response.GetAliases() {
+		if description.GetAliasName() == alias {
+			return description.GetCollectionName(), nil
+		}
+	}
+
+	return "", nil
+}
+
+// PointAlias points an alias at a collection. An existing alias is moved in the same request,
+// so readers see either the old collection or the new one, never neither.
+func (q *QdrantClient) PointAlias(ctx context.Context, alias, collectionName string) error {
+	target, err := q.AliasTarget(ctx, alias)
+	if err != nil {
+		return err
+	}
+
+	var actions []*qdrant.AliasOperations
+	if target != "" {
+		actions = append(actions, &qdrant.AliasOperations{
+			Action: &qdrant.AliasOperations_DeleteAlias{
+				DeleteAlias: &qdrant.DeleteAlias{AliasName: alias},
+			},
+		})
+	}
+	actions = append(actions, &qdrant.AliasOperations{
+		Action: &qdrant.AliasOperations_CreateAlias{
+			CreateAlias: &qdrant.CreateAlias{CollectionName: collectionName, AliasName: alias},
+		},
+	})
+
+	if _, err := q.client.UpdateAliases(ctx, &qdrant.ChangeAliases{Actions: actions}); err != nil {
+		return fmt.Errorf("failed to update alias: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAlias removes an alias; the collection it points to is kept
+func (q *QdrantClient) DeleteAlias(ctx context.Context, alias string) error {
+	_, err := q.client.UpdateAliases(ctx, &qdrant.ChangeAliases{
+		Actions: []*qdrant.AliasOperations{{
+			Action: &qdrant.AliasOperations_DeleteAlias{
+				DeleteAlias: &qdrant.DeleteAlias{AliasName: alias},
+			},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete alias: %w", err)
+	}
+	return nil
+}
@@ -1,22 +1,50 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/chaos"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/config"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/httpretry"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 )
 
-// S3Client wraps AWS S3 operations
+// S3 upload parameters
+const (
+	// multipartThreshold is the size from which files are uploaded in parts
+	multipartThreshold = 8 * 1024 * 1024
+	// partSize is the size of each part but the last (S3's minimum is 5MB)
+	partSize = 5 * 1024 * 1024
+	// s3MaxAttempts is how often a request is tried, backing off with jitter in between
+	s3MaxAttempts = 5
+	// s3MaxBackoff caps the wait between attempts
+	s3MaxBackoff = 20 * time.Second
+)
+
+// S3Client wraps AWS S3 operations. Requests are retried with backoff, and repeated failures
+// open the "s3" circuit breaker reported by the health check.
 type S3Client struct {
-	client *s3.Client
-	bucket string
+	client  *s3.Client
+	bucket  string
+	breaker *httpretry.Breaker
 }
 
 // NewS3Client creates a new S3 client
@@ -58,30 +86,200 @@ func NewS3Client(cfg config.AWSConfig) (*S3Client, error) {
 
 	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
 		o.UsePathStyle = cfg.Endpoint != "" // Required for LocalStack
+		o.Retryer = retry.NewStandard(func(ro *retry.StandardOptions) {
+			ro.MaxAttempts = s3MaxAttempts
+			ro.MaxBackoff = s3MaxBackoff
+		})
+		// Injected faults fail requests below the SDK's retries, like real S3 errors
+		if chaos.Enabled() {
+			o.HTTPClient = &http.Client{Transport: chaos.Transport(chaos.TargetS3, nil)}
+		}
 	})
 
 	return &S3Client{
-		client: client,
-		bucket: cfg.Bucket,
+		client:  client,
+		bucket:  cfg.Bucket,
+		breaker: httpretry.NewBreaker("s3"),
 	}, nil
 }
 
-// UploadFile uploads a file to S3
+// call runs an S3 request through the circuit breaker
+func (s *S3Client) call(ctx context.Context, fn func() error) error {
+	return s.breaker.Call(ctx, fn, s3Fault)
+}
+
+// s3Fault reports whether an error means S3 is unavailable: a server error or no response at
+// all. Client errors such as a missing key mean it answered.
+func s3Fault(err error) bool {
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode() >= 500
+	}
+	return true
+}
+
+// UploadFile uploads a file to S3, in parts from multipartThreshold on. Each request carries a
+// SHA-256 checksum S3 verifies, and the returned ETags are checked against the content's MD5.
 func (s *S3Client) UploadFile(ctx context.Context, key string, file io.Reader) error {
-	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:               aws.String(s.bucket),
-		Key:                  aws.String(key),
-		Body:                 file,
-		ServerSideEncryption: "AES256",
-	})
+	head := make([]byte, multipartThreshold)
+	n, err := io.ReadFull(file, head)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return s.putObject(ctx, key, head[:n])
+	case err != nil:
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return s.multipartUpload(ctx, key, io.MultiReader(bytes.NewReader(head), file))
+}
+
+// putObject uploads a file in a single request
+func (s *S3Client) putObject(ctx context.Context, key string, data []byte) error {
+	digest := md5.Sum(data)
+	checksum := sha256.Sum256(data)
 
+	var out *s3.PutObjectOutput
+	err := s.call(ctx, func() error {
+		var err error
+		out, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:               aws.String(s.bucket),
+			Key:                  aws.String(key),
+			Body:                 bytes.NewReader(data),
+			ServerSideEncryption: types.ServerSideEncryptionAes256,
+			ChecksumSHA256:       aws.String(base64.StdEncoding.EncodeToString(checksum[:])),
+		})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to upload file: %w", err)
 	}
 
+	if etag := unquoteETag(out.ETag); etag != hex.EncodeToString(digest[:]) {
+		s.discard(ctx, key)
+		return fmt.Errorf("failed to upload file: integrity check failed (ETag %s)", etag)
+	}
+
+	return nil
+}
+
+// multipartUpload uploads a file in parts of partSize, aborting the upload if a part fails.
+// S3's ETag of a multipart object is the MD5 of its parts' MD5s, suffixed with the part count.
+func (s *S3Client) multipartUpload(ctx context.Context, key string, r io.Reader) error {
+	var created *s3.CreateMultipartUploadOutput
+	err := s.call(ctx, func() error {
+		var err error
+		created, err = s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:               aws.String(s.bucket),
+			Key:                  aws.String(key),
+			ServerSideEncryption: types.ServerSideEncryptionAes256,
+			ChecksumAlgorithm:    types.ChecksumAlgorithmSha256,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	uploadID := created.UploadId
+
+	var parts []types.CompletedPart
+	var digests []byte
+	buf := make([]byte, partSize)
+	for number := int32(1); ; number++ {
+		n, readErr := io.ReadFull(r, buf)
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil && readErr != io.ErrUnexpectedEOF {
+			s.abort(ctx, key, uploadID)
+			return fmt.Errorf("failed to read file: %w", readErr)
+		}
+
+		part := buf[:n]
+		digest := md5.Sum(part)
+		checksum := sha256.Sum256(part)
+		var out *s3.UploadPartOutput
+		err := s.call(ctx, func() error {
+			var err error
+			out, err = s.client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:         aws.String(s.bucket),
+				Key:            aws.String(key),
+				UploadId:       uploadID,
+				PartNumber:     aws.Int32(number),
+				Body:           bytes.NewReader(part),
+				ChecksumSHA256: aws.String(base64.StdEncoding.EncodeToString(checksum[:])),
+			})
+			return err
+		})
+		if err != nil {
+			s.abort(ctx, key, uploadID)
+			return fmt.Errorf("failed to upload part %d: %w", number, err)
+		}
+		if etag := unquoteETag(out.ETag); etag != hex.EncodeToString(digest[:]) {
+			s.abort(ctx, key, uploadID)
+			return fmt.Errorf("failed to upload part %d: integrity check failed (ETag %s)", number, etag)
+		}
+
+		parts = append(parts, types.CompletedPart{
+			ETag:           out.ETag,
+			PartNumber:     aws.Int32(number),
+			ChecksumSHA256: out.ChecksumSHA256,
+		})
+		digests = append(digests, digest[:]...)
+		if readErr == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+
+	var completed *s3.CompleteMultipartUploadOutput
+	err = s.call(ctx, func() error {
+		var err error
+		completed, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(s.bucket),
+			Key:             aws.String(key),
+			UploadId:        uploadID,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+		return err
+	})
+	if err != nil {
+		s.abort(ctx, key, uploadID)
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	digest := md5.Sum(digests)
+	expected := fmt.Sprintf("%s-%d", hex.EncodeToString(digest[:]), len(parts))
+	if etag := unquoteETag(completed.ETag); etag != expected {
+		s.discard(ctx, key)
+		return fmt.Errorf("failed to upload file: integrity check failed (ETag %s)", etag)
+	}
+
 	return nil
 }
 
+// abort cancels a failed multipart upload so its parts aren't stored (and billed) indefinitely
+func (s *S3Client) abort(ctx context.Context, key string, uploadID *string) {
+	_, err := s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+	if err != nil {
+		logger.Warn("Failed to abort multipart upload", "key", key, "error", err)
+	}
+}
+
+// discard deletes an object that failed its integrity check
+func (s *S3Client) discard(ctx context.Context, key string) {
+	if err := s.DeleteFile(context.WithoutCancel(ctx), key); err != nil {
+		logger.Warn("Failed to delete corrupt upload", "key", key, "error", err)
+	}
+}
+
+// unquoteETag returns an ETag without the quotes S3 wraps it in
+func unquoteETag(etag *string) string {
+	return strings.Trim(aws.ToString(etag), `"`)
+}
+
 // GetPresignedURL generates a presigned URL for downloading a file
 func (s *S3Client) GetPresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
 	presignClient := s3.NewPresignClient(s.client)
@@ -102,9 +300,12 @@ func (s *S3Client) GetPresignedURL(ctx context.Context, key string, expiry time.
 
 // DeleteFile deletes a file from S3
 func (s *S3Client) DeleteFile(ctx context.Context, key string) error {
-	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+	err := s.call(ctx, func() error {
+		_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(key),
+		})
+		return err
 	})
 
 	if err != nil {
@@ -116,9 +317,14 @@ func (s *S3Client) DeleteFile(ctx context.Context, key string) error {
 
 // GetFile retrieves a file from S3
 func (s *S3Client) GetFile(ctx context.Context, key string) (io.ReadCloser, error) {
-	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+	var result *s3.GetObjectOutput
+	err := s.call(ctx, func() error {
+		var err error
+		result, err = s.client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(key),
+		})
+		return err
 	})
 
 	if err != nil {
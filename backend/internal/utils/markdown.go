@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MarkdownChunk is a chunk of a Markdown document with the headings it falls under
+type MarkdownChunk struct {
+	Content string
+	// HeadingPath lists the chunk's enclosing headings from the top level down
+	HeadingPath []string
+}
+
+var (
+	// atxHeading matches "## Title", capturing the level's hashes and the title without
+	// closing hashes
+	atxHeading = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	// codeFence matches the opening or closing line of a fenced code block
+	codeFence = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+)
+
+// ChunkMarkdown splits Markdown into chunks of at most chunkSize tokens that never cross a heading
+// and never split a fenced code block that fits in a chunk. Each section's paragraphs are packed
+// into chunks whole; a paragraph longer than chunkSize is split by ChunkText with overlap, and a
+// longer code block is split by lines with its fence repeated around each part. Headings inside
+// code blocks are ignored.
+func ChunkMarkdown(text string, chunkSize, overlap int) []MarkdownChunk {
+	var chunks []MarkdownChunk
+	var path []string
+	var blocks []string
+	// hasBody is set once the current section has more than its heading
+	hasBody := false
+
+	// flush packs the current section's blocks into chunks. A heading with nothing under it
+	// stays in the path of the sections below it without a chunk of its own.
+	flush := func() {
+		if hasBody {
+			headingPath := append([]string(nil), path...)
+			for _, content := range packBlocks(blocks, chunkSize, overlap) {
+				chunks = append(chunks, MarkdownChunk{Content: content, HeadingPath: headingPath})
+			}
+		}
+		blocks = nil
+		hasBody = false
+	}
+
+	lines := strings.Split(text, "\n")
+	var block []string
+	endBlock := func() {
+		if content := strings.TrimSpace(strings.Join(block, "\n")); content != "" {
+			blocks = append(blocks, content)
+		}
+		block = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := codeFence.FindStringSubmatch(line); m != nil {
+			// The fence runs to a closing line of the same character at least as long, or the end
+			endBlock()
+			fence := []string{line}
+			for i++; i < len(lines); i++ {
+				fence = append(fence, lines[i])
+				if closing := codeFence.FindStringSubmatch(lines[i]); closing != nil &&
+					closing[1][0] == m[1][0] && len(closing[1]) >= len(m[1]) &&
+					strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(lines[i]), closing[1][:1])) == "" {
+					break
+				}
+			}
+			blocks = append(blocks, strings.Join(fence, "\n"))
+			hasBody = true
+			continue
+		}
+
+		if m := atxHeading.FindStringSubmatch(line); m != nil {
+			endBlock()
+			flush()
+			level := len(m[1])
+			if len(path) >= level {
+				path = path[:level-1]
+			}
+			for len(path) < level-1 {
+				path = append(path, "")
+			}
+			path = append(path, strings.TrimSpace(m[2]))
+			block = append(block, line)
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			endBlock()
+			continue
+		}
+		block = append(block, line)
+		hasBody = true
+	}
+	endBlock()
+	flush()
+
+	for i := range chunks {
+		chunks[i].HeadingPath = compactPath(chunks[i].HeadingPath)
+	}
+	return chunks
+}
+
+// compactPath drops the placeholders of skipped heading levels, e.g. a "###" right under a "#"
+func compactPath(path []string) []string {
+	compact := path[:0:0]
+	for _, heading := range path {
+		if heading != "" {
+			compact = append(compact, heading)
+		}
+	}
+	return compact
+}
+
+// packBlocks joins consecutive blocks into chunks of at most chunkSize tokens, splitting blocks
+// that don't fit in one chunk on their own
+func packBlocks(blocks []string, chunkSize, overlap int) []string {
+	var chunks []string
+	var current strings.Builder
+	size := 0
+
+	endChunk := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			size = 0
+		}
+	}
+
+	for _, block := range blocks {
+		n := CountTokens(block)
+		if n > chunkSize {
+			endChunk()
+			if codeFence.MatchString(block) {
+				chunks = append(chunks, splitCodeBlock(block, chunkSize)...)
+			} else {
+				chunks = append(chunks, ChunkText(block, chunkSize, overlap)...)
+			}
+			continue
+		}
+
+		// The blank line between blocks is one more token
+		if current.Len() > 0 && size+1+n > chunkSize {
+			endChunk()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+			size++
+		}
+		current.WriteString(block)
+		size += n
+	}
+	endChunk()
+
+	return chunks
+}
+
+// splitCodeBlock splits a fenced code block by lines into parts that each repeat its opening
+// and closing fence, so every part is still a code block of at most chunkSize tokens. A single
+// line longer than a part gets a part of its own.
+func splitCodeBlock(block string, chunkSize int) []string {
+	lines := strings.Split(block, "\n")
+	opening := lines[0]
+	body := lines[1:]
+	closing := strings.TrimSpace(codeFence.FindStringSubmatch(opening)[1])
+	if last := len(body) - 1; last >= 0 && codeFence.MatchString(body[last]) {
+		closing = body[last]
+		body = body[:last]
+	}
+
+	var parts []string
+	var current []string
+	// Each line is counted with the newline ending it
+	fences := CountTokens(opening) + CountTokens(closing) + 1
+	size := fences
+	for _, line := range body {
+		n := CountTokens(line) + 1
+		if len(current) > 0 && size+n > chunkSize {
+			parts = append(parts, strings.Join(append(append([]string{opening}, current...), closing), "\n"))
+			current = nil
+			size = fences
+		}
+		current = append(current, line)
+		size += n
+	}
+	if len(current) > 0 {
+		parts = append(parts, strings.Join(append(append([]string{opening}, current...), closing), "\n"))
+	}
+	return parts
+}
@@ -1,71 +1,130 @@
 package utils
 
 import (
+	"regexp"
 	"strings"
-	"unicode"
 )
 
-// ChunkText splits text into chunks with overlap, trying to break at natural boundaries
-func ChunkText(text string, chunkSize, overlap int) []string {
-	if len(text) == 0 {
+var (
+	// sentenceBreak matches the punctuation, closing quotes or brackets and space ending a
+	// sentence, or a paragraph break
+	sentenceBreak = regexp.MustCompile(`[.!?]+["')\]]*\s+|\n\s*\n`)
+	// lineSpan and wordSpan match a line's text and a word
+	lineSpan = regexp.MustCompile(`[^\n]+`)
+	wordSpan = regexp.MustCompile(`\S+`)
+)
+
+// span is the part of a text from start up to end
+type span struct {
+	start, end int
+}
+
+// ChunkText splits text on sentence boundaries into chunks of at most maxTokens tokens, each
+// after the first starting with whole sentences of up to overlap tokens from the end of the one
+// before. A sentence longer than maxTokens is split at line breaks, then between words. Chunks
+// are substrings of text, so callers can find each one in it.
+func ChunkText(text string, maxTokens, overlap int) []string {
+	sentences := sentenceSpans(text, maxTokens)
+	if len(sentences) == 0 {
 		return nil
 	}
-
-	// If text is smaller than chunk size, return as is
-	if len(text) <= chunkSize {
-		return []string{text}
+	tokens := make([]int, len(sentences))
+	for i, sentence := range sentences {
+		tokens[i] = CountTokens(text[sentence.start:sentence.end])
 	}
 
 	var chunks []string
 	start := 0
-	
-	for start < len(text) {
-		end := start + chunkSize
-		if end >= len(text) {
-			chunks = append(chunks, text[start:])
-			break
+	for {
+		end, size := start, 0
+		for end < len(sentences) && (end == start || size+tokens[end] <= maxTokens) {
+			size += tokens[end]
+			end++
+		}
+		chunks = append(chunks, text[sentences[start].start:sentences[end-1].end])
+		if end == len(sentences) {
+			return chunks
 		}
 
-		// Look for a good break point (newline, period, space) within the last 20% of the chunk
-		breakPoint := end
-		searchRange := chunkSize / 5
-		if searchRange < 20 {
-			searchRange = 20
+		// The next chunk starts with the chunk's last sentences, leaving room for one more
+		next, carried := end, 0
+		for next-1 > start && carried+tokens[next-1] <= overlap && carried+tokens[next-1]+tokens[end] <= maxTokens {
+			next--
+			carried += tokens[next]
 		}
-		
-		found := false
-		for _, sep := range []string{"\n\n", "\n", ". ", " "} {
-			if idx := strings.LastIndex(text[start+chunkSize-searchRange:end], sep); idx != -1 {
-				breakPoint = start + chunkSize - searchRange + idx + len(sep)
-				found = true
-				break
-			}
+		start = next
+	}
+}
+
+// sentenceSpans splits text into sentences without their surrounding space, splitting those
+// longer than maxTokens
+func sentenceSpans(text string, maxTokens int) []span {
+	var spans []span
+	start := 0
+	breaks := append(sentenceBreak.FindAllStringIndex(text, -1), []int{len(text), len(text)})
+	for _, brk := range breaks {
+		sentence := trimSpan(text, span{start, brk[1]})
+		start = brk[1]
+		if sentence.start == sentence.end {
+			continue
+		}
+		if CountTokens(text[sentence.start:sentence.end]) > maxTokens {
+			spans = append(spans, splitSpan(text, sentence, maxTokens, lineSpan)...)
+			continue
+		}
+		spans = append(spans, sentence)
+	}
+	return spans
+}
+
+// splitSpan splits a span longer than maxTokens into pieces of at most maxTokens, packing its
+// parts (lines, then words) greedily. Parts too long on their own are split further: lines into
+// words, words between tokens.
+func splitSpan(text string, s span, maxTokens int, part *regexp.Regexp) []span {
+	var spans []span
+	current, size := span{-1, -1}, 0
+	for _, loc := range part.FindAllStringIndex(text[s.start:s.end], -1) {
+		p := trimSpan(text, span{s.start + loc[0], s.start + loc[1]})
+		if p.start == p.end {
+			continue
 		}
 
-		if !found {
-			// If no natural separator found, just break at chunkSize
-			breakPoint = end
+		n := CountTokens(text[p.start:p.end])
+		if n > maxTokens {
+			if current.start >= 0 {
+				spans = append(spans, current)
+				current = span{-1, -1}
+			}
+			if part == lineSpan {
+				spans = append(spans, splitSpan(text, p, maxTokens, wordSpan)...)
+			} else {
+				spans = append(spans, splitTokens(text, p, maxTokens)...)
+			}
+			continue
 		}
 
-		chunks = append(chunks, text[start:breakPoint])
-		
-		// Move start forward, accounting for overlap
-		start = breakPoint - overlap
-		if start < 0 {
-			start = 0
+		if current.start >= 0 && size+n > maxTokens {
+			spans = append(spans, current)
+			current = span{-1, -1}
 		}
-		
-		// Avoid infinite loops if breakPoint doesn't move forward
-		if start >= breakPoint {
-			start = breakPoint
+		if current.start < 0 {
+			current.start, size = p.start, 0
 		}
+		current.end = p.end
+		size += n
 	}
-
-	return chunks
+	if current.start >= 0 {
+		spans = append(spans, current)
+	}
+	return spans
 }
 
-// EstimateTokens estimates the number of tokens in text (rough approximation)
-func EstimateTokens(text string) int {
-	// Rough approximation: 1 token ≈ 4 characters
-	return len(text) / 4
+// trimSpan narrows a span to exclude leading and trailing white space
+func trimSpan(text string, s span) span {
+	trimmed := strings.TrimSpace(text[s.start:s.end])
+	if trimmed == "" {
+		return span{s.start, s.start}
+	}
+	start := s.start + strings.Index(text[s.start:s.end], trimmed)
+	return span{start, start + len(trimmed)}
 }
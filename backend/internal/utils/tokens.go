@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// Load BPE ranks from the embedded files instead of downloading them at runtime
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+var (
+	tokenizerOnce sync.Once
+	// tokenizer is the cl100k_base encoding of OpenAI's embedding and chat models. Other
+	// providers' tokenizers differ a little, but count closely enough to size chunks.
+	tokenizer *tiktoken.Tiktoken
+)
+
+// encoding returns the tokenizer, or nil if it failed to load
+func encoding() *tiktoken.Tiktoken {
+	tokenizerOnce.Do(func() {
+		if enc, err := tiktoken.GetEncoding("cl100k_base"); err == nil {
+			tokenizer = enc
+		}
+	})
+	return tokenizer
+}
+
+// CountTokens counts the tokens in text. Should the tokenizer fail to load, it falls back to
+// four bytes per token.
+func CountTokens(text string) int {
+	enc := encoding()
+	if enc == nil {
+		return (len(text) + 3) / 4
+	}
+	return len(enc.Encode(text, nil, nil))
+}
+
+// splitTokens cuts a span with no spaces, such as a long URL or encoded blob, into pieces of at
+// most maxTokens tokens. Cuts are moved back to rune boundaries so every piece is valid text.
+func splitTokens(text string, s span, maxTokens int) []span {
+	piece := text[s.start:s.end]
+	var ends []int
+	if enc := encoding(); enc != nil {
+		ids := enc.Encode(piece, nil, nil)
+		end := s.start
+		for i := 0; i < len(ids); i += maxTokens {
+			end += len(enc.Decode(ids[i:min(i+maxTokens, len(ids))]))
+			ends = append(ends, end)
+		}
+	} else {
+		for end := s.start + maxTokens*4; end < s.end; end += maxTokens * 4 {
+			ends = append(ends, end)
+		}
+	}
+
+	var spans []span
+	start := s.start
+	for _, end := range ends {
+		for end < s.end && end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		if end > start && end < s.end {
+			spans = append(spans, span{start, end})
+			start = end
+		}
+	}
+	return append(spans, span{start, s.end})
+}
@@ -9,20 +9,53 @@ import (
 	"time"
 
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/logger"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/parser"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/queue"
+	"github.com/PuvaanRaaj/personal-rag-agent/internal/repository"
 	"github.com/PuvaanRaaj/personal-rag-agent/internal/service"
 	"github.com/fsnotify/fsnotify"
 )
 
-// Watcher monitors a local directory for changes
+// Watcher monitors a local directory for changes and enqueues ingestion jobs
 type Watcher struct {
-	path            string
-	userID          string
-	documentService *service.DocumentService
-	watcher         *fsnotify.Watcher
+	path   string
+	userID string
+	// folderUsers maps top-level folders to the users their files are indexed for; other files
+	// are indexed for userID
+	folderUsers map[string]string
+	jobs        queue.Queue
+	locks       *repository.LockRepository
+	watcher     *fsnotify.Watcher
 }
 
-// NewWatcher creates a new watcher service
-func NewWatcher(path, userID string, documentService *service.DocumentService) (*Watcher, error) {
+// ParseFolderUsers parses a comma-separated list of folder=user-id pairs, each mapping a
+// top-level folder of the knowledge base to the account its files are indexed for
+func ParseFolderUsers(spec string) (map[string]string, error) {
+	folderUsers := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		folder, userID, ok := strings.Cut(pair, "=")
+		folder, userID = strings.TrimSpace(folder), strings.TrimSpace(userID)
+		if !ok || folder == "" || userID == "" {
+			return nil, fmt.Errorf("invalid folder mapping %q (expected folder=user-id)", pair)
+		}
+		if folder == "." || folder == ".." || strings.ContainsAny(folder, `/\`) {
+			return nil, fmt.Errorf("invalid folder %q: must be a top-level folder name", folder)
+		}
+		if _, exists := folderUsers[folder]; exists {
+			return nil, fmt.Errorf("folder %q is mapped more than once", folder)
+		}
+		folderUsers[folder] = userID
+	}
+	return folderUsers, nil
+}
+
+// NewWatcher creates a new watcher service. Files in a top-level folder of folderUsers are
+// indexed for its user, all others for userID.
+func NewWatcher(path, userID string, folderUsers map[string]string, jobs queue.Queue, locks *repository.LockRepository) (*Watcher, error) {
 	// Create folder if it doesn't exist
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create knowledge base directory: %w", err)
@@ -34,10 +67,12 @@ func NewWatcher(path, userID string, documentService *service.DocumentService) (
 	}
 
 	return &Watcher{
-		path:            path,
-		userID:          userID,
-		documentService: documentService,
-		watcher:         fsWatcher,
+		path:        path,
+		userID:      userID,
+		folderUsers: folderUsers,
+		jobs:        jobs,
+		locks:       locks,
+		watcher:     fsWatcher,
 	}, nil
 }
 
@@ -57,7 +92,7 @@ func (w *Watcher) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to walk knowledge base path: %w", err)
 	}
 
-	logger.Info("Watcher started", "path", w.path, "user_id", w.userID)
+	logger.Info("Watcher started", "path", w.path, "user_id", w.userID, "folder_users", len(w.folderUsers))
 
 	go func() {
 		for {
@@ -79,15 +114,12 @@ func (w *Watcher) Start(ctx context.Context) error {
 						continue
 					}
 
-					// Process new file with a small delay to ensure write is complete
+					// Enqueue new file with a small delay to ensure write is complete
 					go func(path string) {
 						time.Sleep(500 * time.Millisecond)
-						logger.Info("Processing file change", "file", path)
-						_, err := w.documentService.ProcessLocalFile(context.Background(), w.userID, path)
-						if err != nil {
-							logger.Error("Failed to process local file", "file", path, "error", err)
-						} else {
-							logger.Info("Successfully indexed local file", "file", path)
+						logger.Info("Queueing file change", "file", path)
+						if err := w.enqueue(context.Background(), path, false); err != nil {
+							logger.Error("Failed to queue local file", "file", path, "error", err)
 						}
 					}(event.Name)
 				}
@@ -105,11 +137,23 @@ func (w *Watcher) Start(ctx context.Context) error {
 	return nil
 }
 
-// Sync performs a full scan of the directory
-func (w *Watcher) Sync(ctx context.Context) error {
-	logger.Info("Starting manual sync of knowledge base", "path", w.path)
+// Sync performs a full scan of the directory.
+// Concurrent syncs from other replicas are skipped. With batch set, new files are embedded
+// through the OpenAI Batch API, which suits large imports that can wait for their embeddings.
+func (w *Watcher) Sync(ctx context.Context, batch bool) error {
+	lock, err := w.locks.TryAcquire(ctx, "knowledge_base_sync:"+w.userID)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		logger.Info("Knowledge base sync already running on another instance", "path", w.path)
+		return nil
+	}
+	defer lock.Release()
+
+	logger.Info("Starting manual sync of knowledge base", "path", w.path, "batch", batch)
 	
-	err := filepath.Walk(w.path, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(w.path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -118,22 +162,13 @@ func (w *Watcher) Sync(ctx context.Context) error {
 		}
 
 		// Only process supported files
-		ext := strings.ToLower(filepath.Ext(path))
-		allowedTypes := map[string]bool{
-			".pdf": true, ".txt": true, ".md": true,
-			".json": true, ".csv": true,
-		}
-		if !allowedTypes[ext] {
+		if !parser.Supported(path) {
 			return nil
 		}
 
 		logger.Info("Syncing file", "file", path)
-		_, err = w.documentService.ProcessLocalFile(ctx, w.userID, path)
-		if err != nil {
-			// If it's already there or other errors, log and continue
-			logger.Debug("Sync skipped file", "file", path, "reason", err.Error())
-		} else {
-			logger.Info("Sync indexed file", "file", path)
+		if err := w.enqueue(ctx, path, batch); err != nil {
+			logger.Error("Failed to queue file for sync", "file", path, "error", err)
 		}
 
 		return nil
@@ -142,6 +177,32 @@ func (w *Watcher) Sync(ctx context.Context) error {
 	return err
 }
 
+// enqueue submits an ingestion job for the file; already indexed files are skipped by the worker
+func (w *Watcher) enqueue(ctx context.Context, path string, batch bool) error {
+	return w.jobs.Enqueue(ctx, service.JobIngestLocalFile, service.IngestLocalFileJob{
+		UserID: w.userFor(path),
+		Path:   path,
+		Batch:  batch,
+	})
+}
+
+// userFor returns the user a file is indexed for: its top-level folder's, or the default user
+func (w *Watcher) userFor(path string) string {
+	rel, err := filepath.Rel(w.path, path)
+	if err != nil {
+		return w.userID
+	}
+	folder, _, nested := strings.Cut(filepath.ToSlash(rel), "/")
+	if !nested {
+		// Files at the root belong to the default user
+		return w.userID
+	}
+	if userID, ok := w.folderUsers[folder]; ok {
+		return userID
+	}
+	return w.userID
+}
+
 // Close stops the watcher
 func (w *Watcher) Close() error {
 	return w.watcher.Close()
@@ -50,6 +50,26 @@ export const authApi = {
     const { data } = await api.post('/auth/refresh');
     return data;
   },
+
+  verifyEmail: async (token: string) => {
+    const { data } = await api.post('/auth/verify-email', { token });
+    return data;
+  },
+
+  resendVerification: async () => {
+    const { data } = await api.post('/auth/verification');
+    return data;
+  },
+
+  requestPasswordReset: async (email: string) => {
+    const { data } = await api.post('/auth/password-reset/request', { email });
+    return data;
+  },
+
+  resetPassword: async (token: string, password: string) => {
+    const { data } = await api.post('/auth/password-reset', { token, password });
+    return data;
+  },
 };
 
 // Documents API
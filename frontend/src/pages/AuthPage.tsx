@@ -1,5 +1,5 @@
 import { useState } from 'react';
-import { useNavigate } from 'react-router-dom';
+import { Link, useNavigate } from 'react-router-dom';
 import { useMutation } from '@tanstack/react-query';
 import { authApi } from '@/api/client';
 import { useAuthStore } from '@/stores/auth';
@@ -126,6 +126,13 @@ export default function AuthPage() {
                 required
                 minLength={6}
               />
+              {isLogin && (
+                <div className="mt-2 text-right">
+                  <Link to="/reset-password" className="text-sm text-primary hover:underline">
+                    Forgot password?
+                  </Link>
+                </div>
+              )}
             </div>
 
             <button
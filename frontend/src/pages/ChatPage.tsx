@@ -3,13 +3,14 @@ import { useMutation } from '@tanstack/react-query';
 import { useNavigate } from 'react-router-dom';
 import { queryApi } from '@/api/client';
 import type { QueryResponse } from '@/types';
-import { ArrowLeft, Send, Loader2, FileText, Bot, User } from 'lucide-react';
+import { ArrowLeft, Send, Loader2, FileText, Bot, User, AlertTriangle } from 'lucide-react';
 
 interface Message {
   id: string;
   role: 'user' | 'assistant';
   content: string;
   sources?: QueryResponse['sources'];
+  confidence?: QueryResponse['confidence'];
 }
 
 export default function ChatPage() {
@@ -28,6 +29,7 @@ export default function ChatPage() {
           role: 'assistant',
           content: data.answer,
           sources: data.sources,
+          confidence: data.confidence,
         },
       ]);
     },
@@ -114,6 +116,15 @@ export default function ChatPage() {
               >
                 <p className="whitespace-pre-wrap">{message.content}</p>
 
+                {message.confidence?.level === 'low' && (
+                  <div className="mt-3 flex items-center gap-2 text-sm text-warning">
+                    <AlertTriangle className="w-4 h-4 shrink-0" />
+                    <span>
+                      Low confidence ({Math.round(message.confidence.score * 100)}%): the documents may not fully support this answer.
+                    </span>
+                  </div>
+                )}
+
                 {message.sources && message.sources.length > 0 && (
                   <div className="mt-4 pt-4 border-t border-border">
                     <p className="text-text-muted text-sm mb-2">Sources:</p>
@@ -130,6 +141,29 @@ export default function ChatPage() {
                               Page {source.page}
                             </span>
                           )}
+                          {source.slide && (
+                            <span className="text-xs bg-bg-elevated px-2 py-0.5 rounded">
+                              Slide {source.slide}
+                              {source.section === 'notes' && ' notes'}
+                            </span>
+                          )}
+                          {source.heading_path && (
+                            <span className="text-xs bg-bg-elevated px-2 py-0.5 rounded truncate">
+                              {source.heading_path}
+                            </span>
+                          )}
+                          {source.row_start && (
+                            <span className="text-xs bg-bg-elevated px-2 py-0.5 rounded">
+                              {source.sheet && `${source.sheet}, `}
+                              Rows {source.row_start}–{source.row_end}
+                            </span>
+                          )}
+                          {source.subject && (
+                            <span className="text-xs bg-bg-elevated px-2 py-0.5 rounded truncate">
+                              {source.subject}
+                              {source.date && `, ${source.date}`}
+                            </span>
+                          )}
                         </div>
                       ))}
                     </div>
@@ -120,13 +120,13 @@ export default function DashboardPage() {
                 <input
                   type="file"
                   className="hidden"
-                  accept=".pdf,.txt,.md,.json,.csv"
+                  accept=".pdf,.pptx,.xlsx,.txt,.md,.json,.csv,.eml,.mbox,.png,.jpg,.jpeg"
                   onChange={handleFileSelect}
                 />
               </label>
             </p>
             <p className="text-text-muted text-sm">
-              Supports PDF, TXT, MD, JSON, CSV (max 10MB)
+              Supports PDF, PPTX, XLSX, TXT, MD, JSON, CSV, EML, MBOX, PNG, JPG (max 10MB)
             </p>
 
             {uploadProgress !== null && (
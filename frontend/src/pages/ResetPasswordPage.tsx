@@ -0,0 +1,114 @@
+import { useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { useMutation } from '@tanstack/react-query';
+import { authApi } from '@/api/client';
+import { Loader2, KeyRound } from 'lucide-react';
+
+export default function ResetPasswordPage() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token') || '';
+  const [email, setEmail] = useState('');
+  const [password, setPassword] = useState('');
+  const [error, setError] = useState('');
+
+  // Without a token the page asks for the email to send a reset link to
+  const requestMutation = useMutation({
+    mutationFn: () => authApi.requestPasswordReset(email),
+    onError: (err: any) => {
+      setError(err.response?.data?.error || 'Failed to send reset link');
+    },
+  });
+
+  const resetMutation = useMutation({
+    mutationFn: () => authApi.resetPassword(token, password),
+    onError: (err: any) => {
+      setError(err.response?.data?.error || 'Failed to reset password');
+    },
+  });
+
+  const handleSubmit = (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    if (token) {
+      resetMutation.mutate();
+    } else {
+      requestMutation.mutate();
+    }
+  };
+
+  const isLoading = requestMutation.isPending || resetMutation.isPending;
+  const done = requestMutation.isSuccess || resetMutation.isSuccess;
+
+  return (
+    <div className="min-h-screen flex items-center justify-center p-4">
+      <div className="w-full max-w-md card">
+        <div className="text-center mb-6">
+          <div className="inline-flex items-center justify-center w-16 h-16 rounded-2xl glass mb-4">
+            <KeyRound className="w-8 h-8 text-primary" />
+          </div>
+          <h1 className="text-2xl font-bold text-text">
+            {token ? 'Choose a new password' : 'Reset your password'}
+          </h1>
+        </div>
+
+        {error && (
+          <div className="mb-4 p-3 rounded-lg bg-error/10 border border-error/30 text-error text-sm">
+            {error}
+          </div>
+        )}
+
+        {done ? (
+          <p className="text-text-muted text-center">
+            {token
+              ? 'Your password has been updated. You can now sign in with it.'
+              : 'If an account exists for that email, a reset link is on its way.'}
+          </p>
+        ) : (
+          <form onSubmit={handleSubmit} className="space-y-4">
+            {token ? (
+              <div>
+                <label className="block text-sm text-text-muted mb-2">New password</label>
+                <input
+                  type="password"
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                  className="input"
+                  placeholder="At least 8 characters"
+                  required
+                  minLength={8}
+                />
+              </div>
+            ) : (
+              <div>
+                <label className="block text-sm text-text-muted mb-2">Email</label>
+                <input
+                  type="email"
+                  value={email}
+                  onChange={(e) => setEmail(e.target.value)}
+                  className="input"
+                  placeholder="you@example.com"
+                  required
+                />
+              </div>
+            )}
+
+            <button
+              type="submit"
+              disabled={isLoading}
+              className="btn-primary w-full flex items-center justify-center gap-2"
+            >
+              {isLoading && <Loader2 className="w-4 h-4 animate-spin" />}
+              {token ? 'Update Password' : 'Send Reset Link'}
+            </button>
+          </form>
+        )}
+
+        <div className="mt-6 text-center">
+          <Link to="/auth" className="text-sm text-primary hover:underline">
+            Back to sign in
+          </Link>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,59 @@
+import { useEffect } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { useMutation } from '@tanstack/react-query';
+import { authApi } from '@/api/client';
+import { Loader2, MailCheck } from 'lucide-react';
+
+export default function VerifyEmailPage() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token') || '';
+
+  const verifyMutation = useMutation({
+    mutationFn: () => authApi.verifyEmail(token),
+  });
+
+  useEffect(() => {
+    if (token) {
+      verifyMutation.mutate();
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [token]);
+
+  const error = !token
+    ? 'This verification link is missing its token.'
+    : verifyMutation.isError
+      ? (verifyMutation.error as any).response?.data?.error || 'Verification failed'
+      : '';
+
+  return (
+    <div className="min-h-screen flex items-center justify-center p-4">
+      <div className="w-full max-w-md card text-center">
+        <div className="inline-flex items-center justify-center w-16 h-16 rounded-2xl glass mb-4">
+          <MailCheck className="w-8 h-8 text-primary" />
+        </div>
+        <h1 className="text-2xl font-bold text-text mb-4">Verify your email</h1>
+
+        {verifyMutation.isPending && (
+          <p className="text-text-muted flex items-center justify-center gap-2">
+            <Loader2 className="w-4 h-4 animate-spin" />
+            Verifying...
+          </p>
+        )}
+
+        {verifyMutation.isSuccess && (
+          <p className="text-text-muted">Your email address is verified.</p>
+        )}
+
+        {error && (
+          <div className="p-3 rounded-lg bg-error/10 border border-error/30 text-error text-sm">
+            {error}
+          </div>
+        )}
+
+        <Link to="/" className="btn-primary inline-block mt-6">
+          Continue
+        </Link>
+      </div>
+    </div>
+  );
+}
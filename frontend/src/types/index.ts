@@ -26,11 +26,40 @@ export interface Document {
 export interface QueryResponse {
   answer: string;
   sources: Source[];
+  degraded?: boolean;
+  confidence?: Confidence;
+  explanations?: CitationExplanation[];
+}
+
+export interface CitationExplanation {
+  source: number;
+  similarity: number;
+  matched_keywords: string[];
+  filters?: string[];
+  rerank_score?: number;
+  justification?: string;
+}
+
+export interface Confidence {
+  score: number;
+  level: 'high' | 'medium' | 'low';
+  retrieval?: number;
+  coverage: number;
+  logprob?: number;
 }
 
 export interface Source {
   filename: string;
   page?: number;
+  slide?: number;
+  section?: 'slide' | 'notes';
+  heading_path?: string;
+  sheet?: string;
+  row_start?: number;
+  row_end?: number;
+  subject?: string;
+  from?: string;
+  date?: string;
   content?: string;
 }
 